@@ -24,6 +24,7 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/core/types"
 	"github.com/MatrixAINetwork/go-matrix/crypto"
+	"github.com/MatrixAINetwork/go-matrix/crypto/bls"
 	"github.com/MatrixAINetwork/go-matrix/event"
 
 	"sync"
@@ -397,6 +398,40 @@ func (ks *KeyStore) SignVrfWithPass(a accounts.Account, passphrase string, msg [
 	return ECDSAPKCompression(&key.PrivateKey.PublicKey), vrfValue, vrfProof, nil
 }
 
+// blsKeyWithPass 由签名账户私钥派生BLS私钥，无需单独保管
+func (ks *KeyStore) blsKeyWithPass(a accounts.Account, passphrase string) (*bls.SecretKey, error) {
+	key := ks.findSignKeyInTemp(a)
+	if key == nil {
+		var err error
+		ks.mu.Lock()
+		_, key, err = ks.getDecryptedKey(a, passphrase)
+		if err != nil {
+			ks.mu.Unlock()
+			return nil, err
+		}
+		ks.tempPrvKey[a.Address] = key
+		ks.mu.Unlock()
+	}
+	return bls.DeriveSecretKey(crypto.FromECDSA(key.PrivateKey)), nil
+}
+
+func (ks *KeyStore) SignBLSWithPass(a accounts.Account, passphrase string, msg []byte) ([]byte, error) {
+	sk, err := ks.blsKeyWithPass(a, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return sk.Sign(msg).Marshal(), nil
+}
+
+// BLSPublicKeyWithPass 返回BLS公钥及注册所需的持有证明
+func (ks *KeyStore) BLSPublicKeyWithPass(a accounts.Account, passphrase string) ([]byte, []byte, error) {
+	sk, err := ks.blsKeyWithPass(a, passphrase)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sk.PublicKey().Marshal(), sk.ProvePossession().Marshal(), nil
+}
+
 func (ks *KeyStore) findSignKeyInTemp(a accounts.Account) *Key {
 	ks.mu.RLock()
 	defer ks.mu.RUnlock()
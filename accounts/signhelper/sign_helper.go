@@ -18,6 +18,7 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/accounts/keystore"
 	"github.com/MatrixAINetwork/go-matrix/ca"
 	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/mc"
 	"github.com/MatrixAINetwork/go-matrix/params/enstrust"
 )
 
@@ -184,6 +185,45 @@ func (sh *SignHelper) SignVrf(msg []byte, blkHash common.Hash) ([]byte, []byte,
 	return sh.keyStore.SignVrfWithPass(signAccount, signPassword, msg)
 }
 
+func (sh *SignHelper) SignBLS(msg []byte, blkHash common.Hash) ([]byte, error) {
+	signAccount, signPassword, err := sh.getSignAccountAndPassword(sh.authReader, blkHash)
+	if err != nil {
+		return nil, ErrGetAccountAndPassword
+	}
+	if (signAccount.Address == common.Address{}) {
+		return nil, ErrIllegalSignAccount
+	}
+
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+	if nil == sh.keyStore {
+		return nil, ErrNilKeyStore
+	}
+	return sh.keyStore.SignBLSWithPass(signAccount, signPassword, msg)
+}
+
+// BLSKeyRegistration 生成当前签名账户的BLS公钥注册数据，由抵押账户发送注册交易，公钥记录在抵押账户绑定的签名账户下。更换签名账户后需要重新注册
+func (sh *SignHelper) BLSKeyRegistration(blkHash common.Hash) (*mc.BLSKeyRegistration, error) {
+	signAccount, signPassword, err := sh.getSignAccountAndPassword(sh.authReader, blkHash)
+	if err != nil {
+		return nil, ErrGetAccountAndPassword
+	}
+	if (signAccount.Address == common.Address{}) {
+		return nil, ErrIllegalSignAccount
+	}
+
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+	if nil == sh.keyStore {
+		return nil, ErrNilKeyStore
+	}
+	pubKey, proof, err := sh.keyStore.BLSPublicKeyWithPass(signAccount, signPassword)
+	if err != nil {
+		return nil, err
+	}
+	return &mc.BLSKeyRegistration{PubKey: pubKey, Proof: proof}, nil
+}
+
 func (sh *SignHelper) getSignAccountAndPasswordAtSignHeight(reader AuthReader, blkHash common.Hash, signHeight uint64, usingEntrust bool) (accounts.Account, string, error) {
 	account := accounts.Account{}
 
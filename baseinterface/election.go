@@ -4,6 +4,8 @@
 package baseinterface
 
 import (
+	"sort"
+
 	"github.com/MatrixAINetwork/go-matrix/core/state"
 	"github.com/MatrixAINetwork/go-matrix/election/support"
 	"github.com/MatrixAINetwork/go-matrix/mc"
@@ -31,6 +33,24 @@ func NewElect(ElectPlugs string) ElectionInterface {
 	return electionPlugs[DefaultElectPlug]()
 }
 
+// GetElectPlug 按名称获取选举插件，与NewElect不同，插件不存在时不使用默认插件
+func GetElectPlug(ElectPlugs string) (ElectionInterface, bool) {
+	if newPlug, ok := electionPlugs[ElectPlugs]; ok {
+		return newPlug(), true
+	}
+	return nil, false
+}
+
+// ElectPlugNames 返回所有已注册的选举插件名称
+func ElectPlugNames() []string {
+	names := make([]string, 0, len(electionPlugs))
+	for name := range electionPlugs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 type ElectionInterface interface {
 	MinerTopGen(*mc.MasterMinerReElectionReqMsg, *state.StateDBManage) *mc.MasterMinerReElectionRsp
 	ValidatorTopGen(*mc.MasterValidatorReElectionReqMsg, *state.StateDBManage) *mc.MasterValidatorReElectionRsq
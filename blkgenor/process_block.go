@@ -34,14 +34,15 @@ func (p *Process) ProcessRecoveryMsg(msg *mc.RecoveryStateMsg) {
 	}
 
 	minerResult := &mc.HD_MiningRspMsg{
-		From:       header.Coinbase,
-		Number:     header.Number.Uint64(),
-		BlockHash:  headerHash,
-		Difficulty: header.Difficulty,
-		Nonce:      header.Nonce,
-		Coinbase:   header.Coinbase,
-		MixDigest:  header.MixDigest,
-		Signatures: header.Signatures,
+		From:           header.Coinbase,
+		Number:         header.Number.Uint64(),
+		BlockHash:      headerHash,
+		Difficulty:     header.Difficulty,
+		Nonce:          header.Nonce,
+		Coinbase:       header.Coinbase,
+		MixDigest:      header.MixDigest,
+		Signatures:     header.Signatures,
+		AggregateSigns: header.AggregateSigns,
 	}
 	log.Debug(p.logExtraInfo(), "状态恢复消息处理", "开始补全挖矿结果消息")
 	if err := p.powPool.AddMinerResult(minerResult.BlockHash, minerResult.Difficulty, minerResult); err != nil {
@@ -358,6 +359,7 @@ func (p *Process) copyHeader(header *types.Header, minerResult *mc.HD_MiningRspM
 	newHeader.MixDigest = minerResult.MixDigest
 	newHeader.Signatures = make([]common.Signature, 0)
 	newHeader.Signatures = append(newHeader.Signatures, minerResult.Signatures...)
+	newHeader.AggregateSigns = minerResult.AggregateSigns
 	return newHeader
 }
 
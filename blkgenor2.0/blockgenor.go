@@ -179,8 +179,8 @@ func (self *BlockGenor) roleUpdatedMsgHandle(roleMsg *mc.RoleUpdatedMsg) error {
 	log.Info(self.logExtraInfo(), "CA身份消息处理", "开始", "高度", roleMsg.BlockNum, "角色", roleMsg.Role.String(), "block hash", roleMsg.BlockHash.TerminalString(), "version", roleMsg.Version)
 	curNumber := roleMsg.BlockNum + 1
 	self.pm.SetCurNumber(curNumber, roleMsg.SuperSeq)
-	if manversion.VersionCmp(roleMsg.Version, manversion.VersionAIMine) < 0 && curNumber < manversion.VersionNumAIMine {
-		log.Trace(self.logExtraInfo(), "CA身份消息处理", "版本号及高度不满足条件，不处理", "指定版本切换高度", manversion.VersionNumAIMine, "指定version", manversion.VersionAIMine)
+	if switchNumber := manversion.PluginNumber(manversion.PluginBlockGenor, manversion.PlugGenorV2); !isGenorVersion(roleMsg.Version) && curNumber < switchNumber {
+		log.Trace(self.logExtraInfo(), "CA身份消息处理", "版本号及高度不满足条件，不处理", "指定版本切换高度", switchNumber, "msg version", roleMsg.Version)
 		return nil
 	}
 
@@ -269,8 +269,8 @@ func (self *BlockGenor) basePowResultHandle(basePowResult *mc.HD_BasePowerDiffic
 }
 
 func (self *BlockGenor) posBlockMsgHandle(data *mc.BlockPOSFinishedV2) {
-	if manversion.VersionCmp(string(data.Header.Version), manversion.VersionAIMine) < 0 {
-		log.Trace(self.logExtraInfo(), "共识结果消息处理", "版本小于指定版本，不处理", "msg version", data.Header.Version, "指定version", manversion.VersionAIMine)
+	if !isGenorVersion(string(data.Header.Version)) {
+		log.Trace(self.logExtraInfo(), "共识结果消息处理", "版本不由本模块处理，不处理", "msg version", data.Header.Version)
 		return
 	}
 	log.Info(self.logExtraInfo(), "共识结果消息处理", "开始", "高度", data.Header.Number, "block hash", data.BlockHash.TerminalString(), "header signs", len(data.Header.Signatures))
@@ -284,7 +284,7 @@ func (self *BlockGenor) posBlockMsgHandle(data *mc.BlockPOSFinishedV2) {
 }
 
 func (self *BlockGenor) blockInsertMsgHandle(blockInsert *mc.HD_BlockInsertNotify) {
-	if manversion.VersionCmp(string(blockInsert.Header.Version), manversion.VersionAIMine) < 0 {
+	if !isGenorVersion(string(blockInsert.Header.Version)) {
 		return
 	}
 	number := blockInsert.Header.Number.Uint64()
@@ -306,8 +306,8 @@ func (self *BlockGenor) blockInsertMsgHandle(blockInsert *mc.HD_BlockInsertNotif
 }
 
 func (self *BlockGenor) broadcastBlockHandle(result *mc.HD_BroadcastMiningRspMsg) {
-	if manversion.VersionCmp(string(result.BlockMainData.Header.Version), manversion.VersionAIMine) < 0 {
-		log.Trace(self.logExtraInfo(), "广播矿工挖矿结果消息处理", "版本小于指定版本，不处理", "msg version", string(result.BlockMainData.Header.Version), "指定version", manversion.VersionAIMine)
+	if !isGenorVersion(string(result.BlockMainData.Header.Version)) {
+		log.Trace(self.logExtraInfo(), "广播矿工挖矿结果消息处理", "版本不由本模块处理，不处理", "msg version", string(result.BlockMainData.Header.Version))
 		return
 	}
 
@@ -326,7 +326,7 @@ func (self *BlockGenor) handleRecoveryMsg(msg *mc.RecoveryStateMsg) {
 		log.Error(self.logExtraInfo(), "状态恢复消息", "消息为nil")
 		return
 	}
-	if manversion.VersionCmp(string(msg.Header.Version), manversion.VersionAIMine) < 0 {
+	if !isGenorVersion(string(msg.Header.Version)) {
 		return
 	}
 	if msg.Type != mc.RecoveryTypeFullHeader {
@@ -382,3 +382,8 @@ func (self *BlockGenor) handleFullBlockRspMsg(rsp *mc.HD_V2_FullBlockRspMsg) {
 func (self *BlockGenor) logExtraInfo() string {
 	return "区块生成 2.0"
 }
+
+// isGenorVersion 版本注册表中选用本模块出块的版本
+func isGenorVersion(version string) bool {
+	return manversion.Plugin(version, manversion.PluginBlockGenor) == manversion.PlugGenorV2
+}
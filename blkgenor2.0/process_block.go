@@ -14,6 +14,7 @@ import (
 	"fmt"
 	"github.com/MatrixAINetwork/go-matrix/ca"
 	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/consensus/trace"
 	"github.com/MatrixAINetwork/go-matrix/core"
 	"github.com/MatrixAINetwork/go-matrix/core/state"
 	"github.com/MatrixAINetwork/go-matrix/core/types"
@@ -30,6 +31,7 @@ func (p *Process) AddAIMinerResult(aiResult *mc.HD_V2_AIMiningRspMsg) {
 		return
 	}
 	log.Info(p.logExtraInfo(), "AI挖矿结果消息处理", "开始", "高度", aiResult.Number, "AIHash", aiResult.AIHash.TerminalString(), "parent hash", aiResult.BlockHash.TerminalString(), "from", aiResult.From.Hex())
+	trace.Record(trace.Event{Number: p.number, Module: trace.ModuleGenerate, Kind: trace.KindMineResult, Account: aiResult.From, Detail: "ai"})
 	p.processAIPick()
 }
 
@@ -53,6 +55,7 @@ func (p *Process) AddPowMinerResult(minerResult *mc.HD_V2_PowMiningRspMsg) {
 	}
 
 	log.Info(p.logExtraInfo(), "Pow挖矿结果消息处理", "开始", "高度", minerResult.Number, "难度", minerResult.Difficulty.Uint64(), "mine hash", minerResult.BlockHash.TerminalString(), "from", minerResult.From.Hex())
+	trace.Record(trace.Event{Number: p.number, Module: trace.ModuleGenerate, Kind: trace.KindMineResult, Account: minerResult.From, Detail: "pow"})
 	p.processPowCombine(true)
 }
 
@@ -425,6 +428,7 @@ func (p *Process) insertBlock(header *types.Header, finalTxs []types.CoinSelfTra
 		return err
 	}
 	mc.PublishEvent(mc.BlockInserted, &mc.BlockInsertedMsg{Block: mc.BlockInfo{Hash: block.Hash(), Number: block.NumberU64()}, InsertTime: uint64(time.Now().Unix()), CanonState: stat == core.CanonStatTy})
+	trace.Record(trace.Event{Number: block.NumberU64(), Module: trace.ModuleGenerate, Kind: trace.KindInsert, Account: header.Leader, Detail: block.Hash().TerminalString()})
 	// Broadcast the block and announce chain insertion event
 	hash := block.Hash()
 	var (
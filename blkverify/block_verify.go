@@ -8,7 +8,9 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/baseinterface"
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/consensus/blkmanage"
+	"github.com/MatrixAINetwork/go-matrix/consensus/evidence"
 	"github.com/MatrixAINetwork/go-matrix/core"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
 	"github.com/MatrixAINetwork/go-matrix/event"
 	"github.com/MatrixAINetwork/go-matrix/log"
 	"github.com/MatrixAINetwork/go-matrix/mandb"
@@ -32,6 +34,7 @@ type Matrix interface {
 type BlockVerify struct {
 	quitCh               chan struct{}
 	processManage        *ProcessManage
+	evidence             *evidence.Collector
 	roleUpdatedMsgCh     chan *mc.RoleUpdatedMsg
 	leaderChangeNotifyCh chan *mc.LeaderChangeNotify
 	requestCh            chan *mc.HD_BlkConsensusReqMsg
@@ -67,6 +70,7 @@ func NewBlockVerify(matrix Matrix) (*BlockVerify, error) {
 	}
 
 	server.processManage = NewProcessManage(matrix)
+	server.evidence = evidence.NewCollector(matrix.ChainDb(), matrix.BlockChain())
 
 	var err error
 	if server.roleUpdatedMsgSub, err = mc.SubscribeEvent(mc.CA_RoleUpdated, server.roleUpdatedMsgCh); err != nil {
@@ -95,6 +99,11 @@ func NewBlockVerify(matrix Matrix) (*BlockVerify, error) {
 	return server, nil
 }
 
+// Evidence 返回双签证据收集器
+func (self *BlockVerify) Evidence() *evidence.Collector {
+	return self.evidence
+}
+
 func (self *BlockVerify) Close() {
 	close(self.quitCh)
 }
@@ -192,6 +201,7 @@ func (self *BlockVerify) handleRequestMsg(reqMsg *mc.HD_BlkConsensusReqMsg) {
 		log.Info(self.logExtraInfo(), "请求消息 获取Process失败", err)
 		return
 	}
+	self.addEvidenceHeader(reqMsg.Header, reqMsg.ConsensusTurn.TotalTurns())
 
 	process.AddReq(reqMsg)
 }
@@ -213,6 +223,7 @@ func (self *BlockVerify) handleLocalRequestMsg(localReq *mc.LocalBlockVerifyCons
 		log.Info(self.logExtraInfo(), "本地请求消息 获取Process失败", err)
 		return
 	}
+	self.addEvidenceHeader(localReq.BlkVerifyConsensusReq.Header, localReq.BlkVerifyConsensusReq.ConsensusTurn.TotalTurns())
 
 	process.AddLocalReq(localReq)
 }
@@ -225,6 +236,7 @@ func (self *BlockVerify) handleVoteMsg(voteMsg *mc.HD_ConsensusVote) {
 
 	log.Trace(self.logExtraInfo(), "投票消息处理", "开始", "from", voteMsg.From.Hex(), "signHash", voteMsg.SignHash.TerminalString())
 	defer log.Trace(self.logExtraInfo(), "投票消息处理", "结束", "from", voteMsg.From.Hex(), "signHash", voteMsg.SignHash.TerminalString())
+	self.evidence.AddVote(voteMsg.SignHash, voteMsg.Sign)
 
 	process, err := self.processManage.GetProcess(voteMsg.Number)
 	if err != nil {
@@ -232,6 +244,7 @@ func (self *BlockVerify) handleVoteMsg(voteMsg *mc.HD_ConsensusVote) {
 		return
 	}
 
+	process.AddBLSVote(voteMsg.SignHash, voteMsg.Sign, voteMsg.BLSSign)
 	process.HandleVote(voteMsg.SignHash, voteMsg.Sign, voteMsg.From)
 }
 
@@ -286,6 +299,18 @@ func (self *BlockVerify) reloadVerifiedBlocks() {
 	}
 }
 
+// addEvidenceHeader 区块头的高度在处理范围内且父区块已知时，才记录到双签证据收集器，
+// 避免伪造的高度推进收集器的高度，清除正常高度的记录
+func (self *BlockVerify) addEvidenceHeader(header *types.Header, turn uint32) bool {
+	parent := self.processManage.bc.GetHeaderByHash(header.ParentHash)
+	if nil == parent || nil == header.Number || parent.Number.Uint64()+1 != header.Number.Uint64() {
+		log.Debug(self.logExtraInfo(), "双签证据", "区块头的父区块未知，不记录", "高度", header.Number)
+		return false
+	}
+	self.evidence.AddBlockHeader(header, turn)
+	return true
+}
+
 func (self *BlockVerify) logExtraInfo() string {
 	return "区块验证服务"
 }
@@ -306,6 +331,13 @@ func (self *BlockVerify) handleFullBlockRequestMsg(reqMsg *mc.HD_FullBlkReqToBro
 		log.Info(self.logExtraInfo(), "请求消息 获取Process失败", err)
 		return
 	}
+	// 全区块请求的区块头中带有POS签名列表
+	if self.addEvidenceHeader(reqMsg.Header, reqMsg.ConsensusTurn.TotalTurns()) {
+		signHash := reqMsg.Header.HashNoSignsAndNonce()
+		for _, sign := range reqMsg.Header.Signatures {
+			self.evidence.AddVote(signHash, sign)
+		}
+	}
 
 	process.AddFullBlkReq(reqMsg)
 }
@@ -0,0 +1,93 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package blkverify
+
+import (
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/params/manversion"
+)
+
+const (
+	blsVoteMaxHashCount = 16  // 每个高度最多缓存的请求数量
+	blsVoteMaxPerHash   = 256 // 每个请求最多缓存的投票数量
+)
+
+// 投票附带的BLS签名，以投票的secp256k1签名为索引，聚合时与已验证的投票对应
+type blsVotePool struct {
+	votes map[common.Hash]map[common.Signature][]byte
+}
+
+func newBLSVotePool() *blsVotePool {
+	return &blsVotePool{votes: make(map[common.Hash]map[common.Signature][]byte)}
+}
+
+func (pool *blsVotePool) add(signHash common.Hash, sign common.Signature, blsSign []byte) {
+	votes, exist := pool.votes[signHash]
+	if !exist {
+		if len(pool.votes) >= blsVoteMaxHashCount {
+			return
+		}
+		votes = make(map[common.Signature][]byte)
+		pool.votes[signHash] = votes
+	}
+	if _, exist := votes[sign]; exist || len(votes) >= blsVoteMaxPerHash {
+		return
+	}
+	votes[sign] = blsSign
+}
+
+func (pool *blsVotePool) get(signHash common.Hash, sign common.Signature) ([]byte, bool) {
+	votes, exist := pool.votes[signHash]
+	if !exist {
+		return nil, false
+	}
+	blsSign, exist := votes[sign]
+	return blsSign, exist
+}
+
+func (p *Process) AddBLSVote(signHash common.Hash, sign common.Signature, blsSign []byte) {
+	if len(blsSign) == 0 {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.blsVotes.add(signHash, sign, blsSign)
+}
+
+// 区块版本支持聚合签名且配置开启时，投票附带BLS签名并在出块时聚合
+func (p *Process) blsAggregateEnabled(header *types.Header) bool {
+	if !manversion.IsEnabled(manversion.FeatureAggregateSign, string(header.Version)) {
+		return false
+	}
+	cfg, err := p.blockChain().GetBLSAggregateCfg(header.ParentHash)
+	if err != nil {
+		return false
+	}
+	return cfg.Switcher
+}
+
+// 聚合赞成票的BLS签名，失败时返回nil，区块头继续使用逐个签名
+func (p *Process) aggregateVotes(signs []*common.VerifiedSign) *types.AggregateSign {
+	header := p.curProcessReq.req.Header
+	if !p.blsAggregateEnabled(header) {
+		return nil
+	}
+	blsSigns := make(map[common.Address][]byte)
+	for _, sign := range signs {
+		if !sign.Validate {
+			continue
+		}
+		if blsSign, exist := p.blsVotes.get(p.curProcessReq.hash, sign.Sign); exist {
+			blsSigns[sign.Account] = blsSign
+		}
+	}
+	aggregate, err := p.blockChain().DPOSEngine(header.Version).AggregateVotes(p.blockChain(), p.curProcessReq.hash, blsSigns, header.ParentHash)
+	if err != nil {
+		log.Info(p.logExtraInfo(), "POS验证处理", "聚合签名失败, 使用逐个签名", "err", err, "BLS签名数量", len(blsSigns), "高度", p.number)
+		return nil
+	}
+	return aggregate
+}
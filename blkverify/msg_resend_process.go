@@ -5,15 +5,40 @@
 package blkverify
 
 import (
+	"time"
+
 	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/consensus/trace"
 	"github.com/MatrixAINetwork/go-matrix/core/types"
 	"github.com/MatrixAINetwork/go-matrix/log"
 	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/msgsend"
 	"github.com/MatrixAINetwork/go-matrix/params/manparams"
 	"github.com/MatrixAINetwork/go-matrix/params/manversion"
-	"time"
 )
 
+// 投递策略：消息由msgsend投递层确认和重发，重发间隔固定
+func deliveryPolicy(interval int, times int) msgsend.DeliveryPolicy {
+	return msgsend.DeliveryPolicy{
+		Ack:           true,
+		MaxRetry:      times - 1,
+		RetryInterval: time.Duration(interval) * time.Second,
+		MaxInterval:   time.Duration(interval) * time.Second,
+	}
+}
+
+func setDeliveryPolicies(hd *msgsend.HD) {
+	vote := deliveryPolicy(manparams.BlkVoteSendInterval, manparams.BlkVoteSendTimes)
+	mineReq := deliveryPolicy(manparams.MinerReqSendInterval, manparams.MinerReqSendTimes)
+	posedReq := deliveryPolicy(manparams.PosedReqSendInterval, manparams.PosedReqSendTimes)
+
+	hd.SetDeliveryPolicy(mc.HD_BlkConsensusVote, vote)
+	hd.SetDeliveryPolicy(mc.HD_MiningReq, mineReq)
+	hd.SetDeliveryPolicy(mc.HD_V2_MiningReq, mineReq)
+	hd.SetDeliveryPolicy(mc.HD_BlkConsensusReq, posedReq)
+	hd.SetDeliveryPolicy(mc.HD_FullBlkReqToBroadcast, posedReq)
+}
+
 func (p *Process) stopSender() {
 	p.closeMineReqMsgSender()
 	p.closePosedReqSender()
@@ -21,185 +46,110 @@ func (p *Process) stopSender() {
 }
 
 func (p *Process) startSendMineReq(posHeader *types.Header) {
-	var reqMsg interface{}
-	if manversion.VersionCmp(string(posHeader.Version), manversion.VersionAIMine) < 0 {
-		reqMsg = &mc.HD_MiningReqMsg{Header: posHeader}
-	} else {
-		// 新版本，只有AI区块才发送挖矿请求
-		bcInterval, err := p.pm.bc.GetBroadcastIntervalByHash(posHeader.ParentHash)
-		if err != nil {
-			log.Info(p.logExtraInfo(), "发送挖矿请求失败", "获取广播周期失败", "err", err, "hash", posHeader.ParentHash.TerminalString())
-			return
-		}
-
-		if posHeader.IsAIHeader(bcInterval.GetBroadcastInterval()) == false {
-			log.Debug(p.logExtraInfo(), "发送挖矿请求", "非AI区块不发送挖矿请求", "number", posHeader.Number, "broadcastInterval", bcInterval.GetBroadcastInterval())
-			return
-		}
-		reqMsg = &mc.HD_V2_MiningReqMsg{Header: posHeader}
+	p.closeMineReqMsgSender()
+	hash := posHeader.HashNoSignsAndNonce()
+	if !manversion.IsEnabled(manversion.FeatureAIMine, string(posHeader.Version)) {
+		log.Trace(p.logExtraInfo(), "关键时间点", "共识投票完毕，发送挖矿请求", "time", time.Now(), "块高", p.number)
+		trace.Record(trace.Event{Number: p.number, Module: trace.ModuleVerify, Kind: trace.KindMineReq, Account: posHeader.Leader})
+		//给矿工发送区块验证结果
+		log.Info(p.logExtraInfo(), "发出挖矿请求, Header hash with signs", hash, "高度", p.number)
+		p.mineReqMsgSender = p.pm.hd.SendNodeMsgWithHandle(mc.HD_MiningReq, &mc.HD_MiningReqMsg{Header: posHeader}, common.RoleMiner|common.RoleInnerMiner, nil)
+		return
 	}
 
-	log.Trace(p.logExtraInfo(), "关键时间点", "共识投票完毕，发送挖矿请求", "time", time.Now(), "块高", p.number)
-	p.closeMineReqMsgSender()
-	sender, err := common.NewResendMsgCtrl(reqMsg, p.sendMineReqFunc, manparams.MinerReqSendInterval, 0)
+	// 新版本，只有AI区块才发送挖矿请求
+	bcInterval, err := p.pm.bc.GetBroadcastIntervalByHash(posHeader.ParentHash)
 	if err != nil {
-		log.Error(p.logExtraInfo(), "创建挖矿请求发送器", "失败", "err", err)
+		log.Info(p.logExtraInfo(), "发送挖矿请求失败", "获取广播周期失败", "err", err, "hash", posHeader.ParentHash.TerminalString())
 		return
 	}
-	p.mineReqMsgSender = sender
-}
 
-func (p *Process) closeMineReqMsgSender() {
-	if p.mineReqMsgSender == nil {
+	if posHeader.IsAIHeader(bcInterval.GetBroadcastInterval()) == false {
+		log.Debug(p.logExtraInfo(), "发送挖矿请求", "非AI区块不发送挖矿请求", "number", posHeader.Number, "broadcastInterval", bcInterval.GetBroadcastInterval())
 		return
 	}
-	p.mineReqMsgSender.Close()
-	p.mineReqMsgSender = nil
-}
 
-func (p *Process) sendMineReqFunc(data interface{}, times uint32) {
+	log.Trace(p.logExtraInfo(), "关键时间点", "共识投票完毕，发送挖矿请求", "time", time.Now(), "块高", p.number)
+	trace.Record(trace.Event{Number: p.number, Module: trace.ModuleVerify, Kind: trace.KindMineReq, Account: posHeader.Leader})
+	//给矿工发送区块验证结果
+	log.Info(p.logExtraInfo(), "发出挖矿请求V2, Header hash with signs", hash, "高度", p.number)
+	p.mineReqMsgSender = p.pm.hd.SendNodeMsgWithHandle(mc.HD_V2_MiningReq, &mc.HD_V2_MiningReqMsg{Header: posHeader}, common.RoleMiner|common.RoleInnerMiner, nil)
+}
 
-	switch data.(type) {
-	case *mc.HD_MiningReqMsg:
-		req, OK := data.(*mc.HD_MiningReqMsg)
-		if !OK {
-			log.Error(p.logExtraInfo(), "发出挖矿请求", "反射消息失败")
-			return
-		}
-		hash := req.Header.HashNoSignsAndNonce()
-		//给矿工发送区块验证结果
-		if times == 1 {
-			log.Info(p.logExtraInfo(), "发出挖矿请求, Header hash with signs", hash, "高度", p.number)
-		} else {
-			log.Trace(p.logExtraInfo(), "发出挖矿请求, Header hash with signs", hash, "次数", times, "高度", p.number)
-		}
-		p.pm.hd.SendNodeMsg(mc.HD_MiningReq, req, common.RoleMiner|common.RoleInnerMiner, nil)
-	case *mc.HD_V2_MiningReqMsg:
-		req, OK := data.(*mc.HD_V2_MiningReqMsg)
-		if !OK {
-			log.Error(p.logExtraInfo(), "发出挖矿请求V2", "反射消息失败")
-			return
-		}
-		hash := req.Header.HashNoSignsAndNonce()
-		//给矿工发送区块验证结果
-		if times == 1 {
-			log.Info(p.logExtraInfo(), "发出挖矿请求V2, Header hash with signs", hash, "高度", p.number)
-		} else {
-			log.Trace(p.logExtraInfo(), "发出挖矿请求V2, Header hash with signs", hash, "次数", times, "高度", p.number)
-		}
-		p.pm.hd.SendNodeMsg(mc.HD_V2_MiningReq, req, common.RoleMiner|common.RoleInnerMiner, nil)
-	default:
-		log.Error(p.logExtraInfo(), "未知的data类型")
+func (p *Process) closeMineReqMsgSender() {
+	if p.mineReqMsgSender == nil {
 		return
 	}
+	p.mineReqMsgSender.Cancel()
+	p.mineReqMsgSender = nil
 }
 
 func (p *Process) startPosedReqSender(req *mc.HD_BlkConsensusReqMsg) {
 	p.closePosedReqSender()
-	sender, err := common.NewResendMsgCtrl(req, p.sendPosedReqFunc, manparams.PosedReqSendInterval, 0)
-	if err != nil {
-		log.Error(p.logExtraInfo(), "创建POS完成的req发送器", "失败", "err", err)
-		return
-	}
-	p.posedReqSender = sender
+	//给广播节点发送区块验证请求(带签名列表)
+	log.Debug(p.logExtraInfo(), "发出POS完成的req(to broadcast) leader", req.Header.Leader.Hex(), "高度", p.number)
+	p.posedReqSender = p.pm.hd.SendNodeMsgWithHandle(mc.HD_BlkConsensusReq, req, common.RoleBroadcast, nil)
 }
 
-func (p *Process) startPosedReqSenderV2(req *reqData) {
-	p.closePosedReqSender()
-	sender, err := common.NewResendMsgCtrl(req, p.sendPosedReqFuncV2, manparams.PosedReqSendInterval, 0)
-	if err != nil {
-		log.Error(p.logExtraInfo(), "创建POS完成的req发送器", "失败", "err", err)
-		return
-	}
-	p.posedReqSender = sender
-}
+// startPosedReqSenderV2 广播前一区块，区块时间2分钟后广播节点仍未完成时改为发送完整区块
+func (p *Process) startPosedReqSenderV2(reqInfo *reqData) {
+	p.startPosedReqSender(reqInfo.req)
 
-func (p *Process) closePosedReqSender() {
-	if p.posedReqSender == nil {
-		return
+	delay := time.Until(time.Unix(reqInfo.req.Header.Time.Int64()+120, 0))
+	if delay < 0 {
+		delay = 0
 	}
-	p.posedReqSender.Close()
-	p.posedReqSender = nil
+	var timer *time.Timer
+	timer = time.AfterFunc(delay, func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.fullBlkReqTimer != timer {
+			return
+		}
+		p.fullBlkReqTimer = nil
+		p.sendFullBlkReqToBroadcast(reqInfo)
+	})
+	p.fullBlkReqTimer = timer
 }
 
-func (p *Process) sendPosedReqFunc(data interface{}, times uint32) {
-	req, OK := data.(*mc.HD_BlkConsensusReqMsg)
-	if !OK {
-		log.Error(p.logExtraInfo(), "发出POS完成的req", "反射消息失败")
-		return
+func (p *Process) sendFullBlkReqToBroadcast(reqInfo *reqData) {
+	req := &mc.HD_FullBlkReqToBroadcastMsg{
+		Header:                 reqInfo.req.Header,
+		ConsensusTurn:          reqInfo.req.ConsensusTurn,
+		TxsCode:                reqInfo.req.TxsCode,
+		Txs:                    reqInfo.originalTxs,
+		OnlineConsensusResults: reqInfo.req.OnlineConsensusResults,
 	}
-	//给广播节点发送区块验证请求(带签名列表)
-	if times == 1 {
-		log.Debug(p.logExtraInfo(), "发出POS完成的req(to broadcast) leader", req.Header.Leader.Hex(), "高度", p.number)
-	} else {
-		log.Trace(p.logExtraInfo(), "发出POS完成的req(to broadcast) leader", req.Header.Leader.Hex(), "次数", times, "高度", p.number)
+	log.Trace(p.logExtraInfo(), "发出POS完成的req(完整区块)(to broadcast) leader", req.Header.Leader.Hex(), "高度", p.number)
+	if p.posedReqSender != nil {
+		p.posedReqSender.Cancel()
 	}
-	p.pm.hd.SendNodeMsg(mc.HD_BlkConsensusReq, req, common.RoleBroadcast, nil)
+	p.posedReqSender = p.pm.hd.SendNodeMsgWithHandle(mc.HD_FullBlkReqToBroadcast, req, common.RoleBroadcast, nil)
 }
 
-func (p *Process) sendPosedReqFuncV2(data interface{}, times uint32) {
-	reqInfo, OK := data.(*reqData)
-	if !OK {
-		log.Error(p.logExtraInfo(), "发出POS完成的req", "反射消息失败")
-		return
+func (p *Process) closePosedReqSender() {
+	if p.fullBlkReqTimer != nil {
+		p.fullBlkReqTimer.Stop()
+		p.fullBlkReqTimer = nil
 	}
-
-	headerTime := reqInfo.req.Header.Time.Int64()
-	curTime := time.Now().Unix()
-	if curTime <= headerTime+120 {
-		//给广播节点发送区块验证请求(带签名列表)
-		if times == 1 {
-			log.Debug(p.logExtraInfo(), "发出POS完成的req(to broadcast) leader", reqInfo.req.Header.Leader.Hex(), "高度", p.number)
-		} else {
-			log.Trace(p.logExtraInfo(), "发出POS完成的req(to broadcast) leader", reqInfo.req.Header.Leader.Hex(), "次数", times, "高度", p.number)
-		}
-		p.pm.hd.SendNodeMsg(mc.HD_BlkConsensusReq, reqInfo.req, common.RoleBroadcast, nil)
-	} else {
-		if times%2 != 0 {
-			return
-		}
-		// 2分钟后，每2个间隔发送一次全区块请求
-		req := &mc.HD_FullBlkReqToBroadcastMsg{
-			Header:        reqInfo.req.Header,
-			ConsensusTurn: reqInfo.req.ConsensusTurn,
-			TxsCode:       reqInfo.req.TxsCode,
-			Txs:           reqInfo.originalTxs,
-			OnlineConsensusResults: reqInfo.req.OnlineConsensusResults,
-		}
-		log.Trace(p.logExtraInfo(), "发出POS完成的req(完整区块)(to broadcast) leader", req.Header.Leader.Hex(), "次数", times, "高度", p.number)
-		p.pm.hd.SendNodeMsg(mc.HD_FullBlkReqToBroadcast, req, common.RoleBroadcast, nil)
+	if p.posedReqSender == nil {
+		return
 	}
+	p.posedReqSender.Cancel()
+	p.posedReqSender = nil
 }
 
 func (p *Process) startVoteMsgSender(vote *mc.HD_ConsensusVote) {
 	p.closeVoteMsgSender()
-	sender, err := common.NewResendMsgCtrl(vote, p.sendVoteMsgFunc, manparams.BlkVoteSendInterval, manparams.BlkVoteSendTimes)
-	if err != nil {
-		log.Error(p.logExtraInfo(), "创建投票消息发送器", "失败", "err", err)
-		return
-	}
-	p.voteMsgSender = sender
+	//发送投票消息
+	log.Info(p.logExtraInfo(), "发出投票消息 signHash", vote.SignHash.TerminalString(), "高度", p.number)
+	p.voteMsgSender = p.pm.hd.SendNodeMsgWithHandle(mc.HD_BlkConsensusVote, vote, common.RoleValidator, nil)
 }
 
 func (p *Process) closeVoteMsgSender() {
 	if p.voteMsgSender == nil {
 		return
 	}
-	p.voteMsgSender.Close()
+	p.voteMsgSender.Cancel()
 	p.voteMsgSender = nil
 }
-
-func (p *Process) sendVoteMsgFunc(data interface{}, times uint32) {
-	vote, OK := data.(*mc.HD_ConsensusVote)
-	if !OK {
-		log.Error(p.logExtraInfo(), "发出投票消息", "反射消息失败")
-		return
-	}
-	//发送投票消息
-	if times == 1 {
-		log.Info(p.logExtraInfo(), "发出投票消息 signHash", vote.SignHash.TerminalString(), "高度", p.number)
-	} else {
-		log.Trace(p.logExtraInfo(), "发出投票消息 signHash", vote.SignHash.TerminalString(), "次数", times, "高度", p.number)
-	}
-	p.pm.hd.SendNodeMsg(mc.HD_BlkConsensusVote, vote, common.RoleValidator, nil)
-}
@@ -39,7 +39,7 @@ type ProcessManage struct {
 }
 
 func NewProcessManage(matrix Matrix) *ProcessManage {
-	return &ProcessManage{
+	pm := &ProcessManage{
 		curChainState:  mc.ChainState{},
 		processMap:     make(map[uint64]*Process),
 		hd:             matrix.HD(),
@@ -53,6 +53,8 @@ func NewProcessManage(matrix Matrix) *ProcessManage {
 		verifiedBlocks: make(map[common.Hash]*verifiedBlock),
 		manblk:         matrix.ManBlkDeal(),
 	}
+	setDeliveryPolicies(pm.hd)
+	return pm
 }
 
 func (pm *ProcessManage) AddVerifiedBlock(block *verifiedBlock) {
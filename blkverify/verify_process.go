@@ -4,6 +4,7 @@
 package blkverify
 
 import (
+	"fmt"
 	"sync"
 	"time"
 
@@ -11,12 +12,14 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/ca"
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/consensus/blkmanage"
+	"github.com/MatrixAINetwork/go-matrix/consensus/trace"
 	"github.com/MatrixAINetwork/go-matrix/core"
 	"github.com/MatrixAINetwork/go-matrix/core/types"
 	"github.com/MatrixAINetwork/go-matrix/event"
 	"github.com/MatrixAINetwork/go-matrix/log"
 	"github.com/MatrixAINetwork/go-matrix/mandb"
 	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/msgsend"
 	"github.com/MatrixAINetwork/go-matrix/params"
 	"github.com/MatrixAINetwork/go-matrix/params/manversion"
 	"github.com/MatrixAINetwork/go-matrix/reelection"
@@ -95,11 +98,13 @@ type Process struct {
 	curProcessReq    *reqData
 	reqCache         *reqCache
 	unverifiedVotes  *unverifiedVotePool
+	blsVotes         *blsVotePool
 	pm               *ProcessManage
 	txsAcquireSeq    int
-	voteMsgSender    *common.ResendMsgCtrl
-	mineReqMsgSender *common.ResendMsgCtrl
-	posedReqSender   *common.ResendMsgCtrl
+	voteMsgSender    *msgsend.SendHandle
+	mineReqMsgSender *msgsend.SendHandle
+	posedReqSender   *msgsend.SendHandle
+	fullBlkReqTimer  *time.Timer
 	bcProcessedHash  []common.Hash
 }
 
@@ -121,11 +126,13 @@ func newProcess(number uint64, pm *ProcessManage) *Process {
 		curProcessReq:    nil,
 		reqCache:         newReqCache(pm.bc),
 		unverifiedVotes:  newUnverifiedVotePool(pm.logExtraInfo()),
+		blsVotes:         newBLSVotePool(),
 		pm:               pm,
 		txsAcquireSeq:    0,
 		voteMsgSender:    nil,
 		mineReqMsgSender: nil,
 		posedReqSender:   nil,
+		fullBlkReqTimer:  nil,
 		bcProcessedHash:  nil,
 	}
 
@@ -276,6 +283,7 @@ func (p *Process) AddFullBlkReq(reqMsg *mc.HD_FullBlkReqToBroadcastMsg) {
 		return
 	}
 	log.Info(p.logExtraInfo(), "区块共识请求处理", "请求添加缓存成功", "from", reqMsg.From.Hex(), "高度", p.number, "reqHash", reqData.hash.TerminalString(), "leader", reqMsg.Header.Leader.Hex())
+	p.recordTrace(trace.KindPosReq, reqData.req, reqMsg.From, 0, reqData.hash.TerminalString())
 
 	if p.role == common.RoleBroadcast {
 		p.startReqVerifyBC()
@@ -296,6 +304,7 @@ func (p *Process) AddReq(reqMsg *mc.HD_BlkConsensusReqMsg) {
 		return
 	}
 	log.Info(p.logExtraInfo(), "区块共识请求处理", "请求添加缓存成功", "from", reqMsg.From.Hex(), "高度", p.number, "reqHash", reqData.hash.TerminalString(), "leader", reqMsg.Header.Leader.Hex())
+	p.recordTrace(trace.KindPosReq, reqData.req, reqMsg.From, 0, reqData.hash.TerminalString())
 
 	if p.role == common.RoleBroadcast {
 		p.startReqVerifyBC()
@@ -365,6 +374,7 @@ func (p *Process) HandleVote(signHash common.Hash, vote common.Signature, from c
 	}
 
 	p.curProcessReq.addVote(verifiedVote)
+	p.recordTrace(trace.KindVote, p.curProcessReq.req, from, uint64(verifiedVote.Stock), "")
 	p.processDPOSOnce()
 }
 
@@ -560,7 +570,17 @@ func (p *Process) sendVote(validate bool) {
 		return
 	}
 
-	p.startVoteMsgSender(&mc.HD_ConsensusVote{SignHash: signHash, Sign: sign, Number: p.number})
+	vote := &mc.HD_ConsensusVote{SignHash: signHash, Sign: sign, Number: p.number}
+	if validate && p.blsAggregateEnabled(p.curProcessReq.req.Header) {
+		blsSign, err := p.signHelper().SignBLS(signHash.Bytes(), p.curProcessReq.req.Header.ParentHash)
+		if err != nil {
+			log.Error(p.logExtraInfo(), "投票BLS签名失败", err, "高度", p.number)
+		} else {
+			vote.BLSSign = blsSign
+			p.blsVotes.add(signHash, sign, blsSign)
+		}
+	}
+	p.startVoteMsgSender(vote)
 
 	//将自己的投票加入票池
 	p.curProcessReq.addVote(&common.VerifiedSign{
@@ -653,7 +673,19 @@ func (p *Process) processDPOSOnce() {
 	}
 	log.Info(p.logExtraInfo(), "POS验证处理", "POS通过", "正确签名数量", len(rightSigns), "高度", p.number)
 	p.curProcessReq.posFinished = true
-	p.curProcessReq.req.Header.Signatures = rightSigns
+	if aggregate := p.aggregateVotes(signs); aggregate != nil {
+		log.Info(p.logExtraInfo(), "POS验证处理", "使用聚合签名", "高度", p.number)
+		p.curProcessReq.req.Header.Signatures = make([]common.Signature, 0)
+		p.curProcessReq.req.Header.AggregateSigns = []types.AggregateSign{*aggregate}
+	} else {
+		p.curProcessReq.req.Header.Signatures = rightSigns
+		p.curProcessReq.req.Header.AggregateSigns = nil
+	}
+	var stocks uint64
+	for _, sign := range signs {
+		stocks += uint64(sign.Stock)
+	}
+	p.recordTrace(trace.KindPosDone, p.curProcessReq.req, p.curProcessReq.req.Header.Leader, stocks, fmt.Sprintf("votes=%d signs=%d", len(signs), len(rightSigns)))
 
 	p.finishedProcess()
 }
@@ -757,7 +789,7 @@ func (p *Process) resendMineReq() {
 		return
 	}
 
-	if manversion.VersionCmp(string(parentHeader.Version), manversion.VersionAIMine) < 0 {
+	if !manversion.IsEnabled(manversion.FeatureAIMine, string(parentHeader.Version)) {
 		log.Trace(p.logExtraInfo(), "补发挖矿请求处理", "区块版本号过低", "cur version", string(parentHeader.Version))
 		return
 	}
@@ -780,7 +812,7 @@ func (p *Process) resendMineReq() {
 			return
 		}
 
-		if manversion.VersionCmp(string(aiHeader.Version), manversion.VersionAIMine) < 0 {
+		if !manversion.IsEnabled(manversion.FeatureAIMine, string(aiHeader.Version)) {
 			log.Trace(p.logExtraInfo(), "补发挖矿请求处理", "AI区块版本号过低", "ai header version", string(aiHeader.Version))
 			return
 		}
@@ -801,3 +833,11 @@ func (p *Process) logExtraInfo() string { return p.pm.logExtraInfo() }
 func (p *Process) eventMux() *event.TypeMux { return p.pm.event }
 
 func (p *Process) ChainDb() mandb.Database { return p.pm.chainDB }
+
+func (p *Process) recordTrace(kind trace.Kind, req *mc.HD_BlkConsensusReqMsg, account common.Address, stock uint64, detail string) {
+	ev := trace.Event{Number: p.number, Module: trace.ModuleVerify, Kind: kind, Account: account, Stock: stock, Detail: detail}
+	if req != nil {
+		ev.Turn = req.ConsensusTurn.TotalTurns()
+	}
+	trace.Record(ev)
+}
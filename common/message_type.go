@@ -8,4 +8,8 @@ const (
 	NetworkMsg       = 0x12
 	BroadcastReqMsg  = 0x13
 	BroadcastRespMsg = 0x14
+
+	// 哨兵模式的凭证和转发消息
+	SentryTicketMsg = 0x1a
+	SentryRelayMsg  = 0x1b
 )
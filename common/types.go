@@ -101,6 +101,19 @@ func (h Hash) MarshalText() ([]byte, error) {
 	return hexutil.Bytes(h[:]).MarshalText()
 }
 
+// ImplementsGraphQLType returns true if Hash implements the specified GraphQL type.
+func (h Hash) ImplementsGraphQLType(name string) bool { return name == "Bytes32" }
+
+// UnmarshalGraphQL unmarshals the provided GraphQL query data.
+func (h *Hash) UnmarshalGraphQL(input interface{}) error {
+	switch input := input.(type) {
+	case string:
+		return h.UnmarshalText([]byte(input))
+	default:
+		return fmt.Errorf("unexpected type %T for Bytes32", input)
+	}
+}
+
 // Sets the hash to the Value of b. If b is larger than len(h), 'b' will be cropped (from the left).
 func (h *Hash) SetBytes(b []byte) {
 	if len(b) > len(h) {
@@ -446,6 +459,7 @@ var (
 	InterestRewardAddress     Address = HexToAddress("0x8000000000000000000000000000000000000000") //利息
 	TxGasRewardAddress        Address = HexToAddress("0x8000000000000000000000000000000000000001") //交易费
 	LotteryRewardAddress      Address = HexToAddress("0x8000000000000000000000000000000000000002") //彩票
+	CoinRewardPoolAddress     Address = HexToAddress("0x8000000000000000000000000000000000000003") //多币种奖励池
 	ContractAddress           Address = HexToAddress("0x000000000000000000000000000000000000000A") //合约账户
 
 	DestroyAddress Address = HexToAddress("0xA27d3632A283c138C2F78ba21d1e473a500e4AF3") //创建币种的转账地址（MAN.3GJF5vPbrmbUG7ZTFyFogdiuXY3Lp）
@@ -467,6 +481,8 @@ const (
 	ExtraUnGasTxsType         byte = 12  //交易费奖励类型
 	ExtraUnGasLotteryTxType   byte = 13  //彩票奖励类型
 	ExtraSetBlackListTxType   byte = 14  //设置黑名单交易
+	ExtraSlashEvidenceTxType  byte = 15  //提交双签证据交易
+	ExtraRegisterBLSKeyTxType byte = 16  //注册BLS公钥交易
 	ExtraSuperBlockTx         byte = 120 //超级区块交易
 )
 
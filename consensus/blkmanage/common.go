@@ -5,6 +5,7 @@ package blkmanage
 
 import (
 	"errors"
+	"sync"
 
 	"github.com/MatrixAINetwork/go-matrix/accounts/signhelper"
 	"github.com/MatrixAINetwork/go-matrix/reelection"
@@ -120,13 +121,17 @@ type VrfMsg struct {
 
 var (
 	LogManBlk    = "区块生成验证引擎"
-	CommonBlk    = "common"
-	BroadcastBlk = "broadcast"
+	CommonBlk    = manversion.PluginCommon
+	BroadcastBlk = manversion.PluginBroadcast
 )
 
+// ManBlkManage 区块插件管理。插件实现按名称注册，版本使用的插件在调用时从版本注册表中查找，
+// 之后注册的版本无需重新创建
 type ManBlkManage struct {
 	support        BlKSupport
-	mapManBlkPlugs map[string]MANBLKPlUGS
+	mu             sync.RWMutex
+	mapManBlkPlugs map[string]MANBLKPlUGS // 按类型和版本指定的插件，优先于注册表
+	plugsByName    map[string]MANBLKPlUGS
 }
 
 func New(support BlKSupport) (*ManBlkManage, error) {
@@ -144,81 +149,48 @@ func New(support BlKSupport) (*ManBlkManage, error) {
 		return nil, err
 	}
 
-	obj.RegisterManBLkPlugs(CommonBlk, manversion.VersionAlpha, manCommonplug)
-
 	manBcplug, err := NewBCBlkPlug()
+	if err != nil {
+		return nil, err
+	}
 
-	obj.RegisterManBLkPlugs(BroadcastBlk, manversion.VersionAlpha, manBcplug)
-
-	obj.RegisterManBLkPlugs(CommonBlk, manversion.VersionBeta, manCommonplug)
-
-	obj.RegisterManBLkPlugs(BroadcastBlk, manversion.VersionBeta, manBcplug)
-
-	obj.RegisterManBLkPlugs(CommonBlk, manversion.VersionGamma, manCommonplug)
-
-	obj.RegisterManBLkPlugs(BroadcastBlk, manversion.VersionGamma, manBcplug)
-
-	obj.RegisterManBLkPlugs(CommonBlk, manversion.VersionDelta, manCommonplug)
-
-	obj.RegisterManBLkPlugs(BroadcastBlk, manversion.VersionDelta, manBcplug)
-
-	obj.RegisterManBLkPlugs(CommonBlk, manversion.VersionAIMine, aiMinePlug)
-
-	obj.RegisterManBLkPlugs(BroadcastBlk, manversion.VersionAIMine, manBcplug)
-
-	obj.RegisterManBLkPlugs(CommonBlk, manversion.VersionZeta, aiMinePlug)
-
-	obj.RegisterManBLkPlugs(BroadcastBlk, manversion.VersionZeta, manBcplug)
+	obj.plugsByName = map[string]MANBLKPlUGS{
+		manversion.PlugBase:      manCommonplug,
+		manversion.PlugBroadcast: manBcplug,
+		manversion.PlugAIMine:    aiMinePlug,
+	}
 
 	return obj, nil
 }
 
 func (bd *ManBlkManage) RegisterManBLkPlugs(types string, version string, plug MANBLKPlUGS) {
+	bd.mu.Lock()
+	defer bd.mu.Unlock()
 	bd.mapManBlkPlugs[types+version] = plug
 }
 
-func (bd *ManBlkManage) ProduceBlockVersion(num uint64, preVersion string) (string, error) {
-	switch num {
-	case manversion.VersionNumGamma:
-		if manversion.VersionCmp(preVersion, manversion.VersionGamma) >= 0 {
-			return preVersion, nil
-		} else {
-			return manversion.VersionGamma, nil
-		}
-
-	case manversion.VersionNumDelta:
-		if manversion.VersionCmp(preVersion, manversion.VersionDelta) >= 0 {
-			return preVersion, nil
-		} else {
-			if manversion.VersionCmp(preVersion, manversion.VersionGamma) != 0 {
-				return "", fmt.Errorf("Delta版本切换点(%d), 前一区块版本号(%s)不是Gamma版本(%s), 无法切换版本", manversion.VersionNumDelta, preVersion, manversion.VersionGamma)
-			}
-			return manversion.VersionDelta, nil
-		}
-
-	case manversion.VersionNumAIMine:
-		if manversion.VersionCmp(preVersion, manversion.VersionAIMine) >= 0 {
-			return preVersion, nil
-		} else {
-			if manversion.VersionCmp(preVersion, manversion.VersionDelta) != 0 {
-				return "", fmt.Errorf("AIMine版本切换点(%d), 前一区块版本号(%s)不是Delta版本(%s), 无法切换版本", manversion.VersionNumAIMine, preVersion, manversion.VersionDelta)
-			}
-			return manversion.VersionAIMine, nil
-		}
-
-	case manversion.VersionNumZeta:
-		if manversion.VersionCmp(preVersion, manversion.VersionZeta) >= 0 {
-			return preVersion, nil
-		} else {
-			if manversion.VersionCmp(preVersion, manversion.VersionAIMine) != 0 {
-				return "", fmt.Errorf("Zeta版本切换点(%d), 前一区块版本号(%s)不是AI Mine版本(%s), 无法切换版本", manversion.VersionNumZeta, preVersion, manversion.VersionAIMine)
-			}
-			return manversion.VersionZeta, nil
-		}
-
-	default:
-		return preVersion, nil
+// RegisterPlug 注册插件实现，版本注册表中以name引用
+func (bd *ManBlkManage) RegisterPlug(name string, plug MANBLKPlUGS) {
+	bd.mu.Lock()
+	defer bd.mu.Unlock()
+	bd.plugsByName[name] = plug
+}
+
+func (bd *ManBlkManage) getPlug(types string, version string) (MANBLKPlUGS, bool) {
+	bd.mu.RLock()
+	defer bd.mu.RUnlock()
+	if plug, ok := bd.mapManBlkPlugs[types+version]; ok {
+		return plug, true
+	}
+	if _, ok := manversion.GetFork(version); !ok {
+		return nil, false
 	}
+	plug, ok := bd.plugsByName[manversion.Plugin(version, types)]
+	return plug, ok
+}
+
+func (bd *ManBlkManage) ProduceBlockVersion(num uint64, preVersion string) (string, error) {
+	return manversion.NextVersion(num, preVersion)
 }
 
 func (bd *ManBlkManage) VerifyBlockVersion(num uint64, curVersion string, preVersion string) error {
@@ -235,7 +207,7 @@ func (bd *ManBlkManage) VerifyBlockVersion(num uint64, curVersion string, preVer
 }
 
 func (bd *ManBlkManage) Prepare(types string, version string, num uint64, interval *mc.BCIntervalInfo, args ...interface{}) (*types.Header, interface{}, error) {
-	plug, ok := bd.mapManBlkPlugs[types+version]
+	plug, ok := bd.getPlug(types, version)
 	if !ok {
 		log.Error(LogManBlk, "获取插件失败", "")
 		return nil, nil, errors.New("获取插件失败")
@@ -244,7 +216,7 @@ func (bd *ManBlkManage) Prepare(types string, version string, num uint64, interv
 }
 
 func (bd *ManBlkManage) ProcessState(types string, version string, header *types.Header, args ...interface{}) ([]*common.RetCallTxN, *state.StateDBManage, []types.CoinReceipts, []types.CoinSelfTransaction, []types.CoinSelfTransaction, interface{}, error) {
-	plug, ok := bd.mapManBlkPlugs[types+version]
+	plug, ok := bd.getPlug(types, version)
 	if !ok {
 		log.Error(LogManBlk, "获取插件失败", "")
 		return nil, nil, nil, nil, nil, nil, errors.New("获取插件失败")
@@ -253,7 +225,7 @@ func (bd *ManBlkManage) ProcessState(types string, version string, header *types
 }
 
 func (bd *ManBlkManage) Finalize(types string, version string, header *types.Header, state *state.StateDBManage, txs []types.CoinSelfTransaction, uncles []*types.Header, receipts []types.CoinReceipts, args ...interface{}) (*types.Block, interface{}, error) {
-	plug, ok := bd.mapManBlkPlugs[types+version]
+	plug, ok := bd.getPlug(types, version)
 	if !ok {
 		log.Error(LogManBlk, "获取插件失败", "")
 		return nil, nil, errors.New("获取插件失败")
@@ -262,7 +234,7 @@ func (bd *ManBlkManage) Finalize(types string, version string, header *types.Hea
 }
 
 func (bd *ManBlkManage) VerifyHeader(types string, version string, header *types.Header, args ...interface{}) (interface{}, error) {
-	plug, ok := bd.mapManBlkPlugs[types+version]
+	plug, ok := bd.getPlug(types, version)
 	if !ok {
 		log.Error(LogManBlk, "获取插件失败", "")
 		return nil, errors.New("获取插件失败")
@@ -271,7 +243,7 @@ func (bd *ManBlkManage) VerifyHeader(types string, version string, header *types
 }
 
 func (bd *ManBlkManage) VerifyTxsAndState(types string, version string, header *types.Header, Txs []types.CoinSelfTransaction, args ...interface{}) (*state.StateDBManage, []types.CoinSelfTransaction, []types.CoinReceipts, interface{}, error) {
-	plug, ok := bd.mapManBlkPlugs[types+version]
+	plug, ok := bd.getPlug(types, version)
 	if !ok {
 		log.Error(LogManBlk, "获取插件失败", "")
 		return nil, nil, nil, nil, errors.New("获取插件失败")
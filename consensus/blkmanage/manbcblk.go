@@ -73,7 +73,7 @@ func (bd *ManBCBlkPlug) Prepare(version string, support BlKSupport, interval *mc
 		return nil, nil, err
 	}
 	bd.baseInterface.initBasePowers(originHeader)
-	if manversion.VersionCmp(string(originHeader.Version), manversion.VersionAIMine) >= 0 {
+	if manversion.IsEnabled(manversion.FeatureAIMine, string(originHeader.Version)) {
 		bd.setBCMiner(originHeader)
 	}
 	if err := support.BlockChain().Engine(originHeader.Version).Prepare(support.BlockChain(), originHeader); err != nil {
@@ -149,6 +149,12 @@ type StateReader interface {
 	GetA0AccountFromAnyAccount(account common.Address, blockHash common.Hash) (common.Address, common.Address, error)
 }
 
+// BLSKeyReader is implemented by state readers that support verifying BLS aggregate signatures.
+type BLSKeyReader interface {
+	GetBLSAggregateCfg(blockHash common.Hash) (*mc.BLSAggregateCfg, error)
+	GetBLSPublicKeys(blockHash common.Hash) (*mc.BLSPublicKeys, error)
+}
+
 type DPOSEngine interface {
 	VerifyVersionSigns(reader StateReader, header *types.Header) error
 
@@ -165,4 +171,9 @@ type DPOSEngine interface {
 	VerifyHashWithVerifiedSigns(reader StateReader, signs []*common.VerifiedSign) ([]common.Signature, error)
 
 	VerifyHashWithVerifiedSignsAndBlock(reader StateReader, signs []*common.VerifiedSign, blockHash common.Hash) ([]common.Signature, error)
+
+	//aggregate agree votes into one BLS signature, blsSigns is keyed by deposit account
+	AggregateVotes(reader StateReader, signHash common.Hash, blsSigns map[common.Address][]byte, blockHash common.Hash) (*types.AggregateSign, error)
+
+	VerifyAggregate(reader StateReader, signHash common.Hash, aggregate *types.AggregateSign, blockHash common.Hash) error
 }
@@ -0,0 +1,322 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+// Package evidence 从区块共识和在线共识的投票中发现双签：同一签名账户对同一高度、同一轮次的
+// 两个冲突内容都投了赞成票。区块头以时间戳区分轮次，同一leader被重新选中后的区块头不算冲突。发现的证据保存到数据库，可通过双签证据交易提交上链。
+package evidence
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/crypto"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/rlp"
+)
+
+const (
+	logInfo = "双签证据"
+
+	DefaultKeepHeights       = 64   // 保留投票记录的高度范围
+	DefaultMaxPending        = 4096 // 内容未知的投票最多缓存数量
+	DefaultMaxEvidences      = 1024 // 最多保存的证据数量
+	DefaultMaxHeightContents = 256  // 每个高度最多记录的签名内容数量
+)
+
+var evidenceDBKey = []byte("equivocation-evidence-list")
+
+// AccountReader 由签名账户换算抵押账户
+type AccountReader interface {
+	GetA0AccountFromAnyAccount(account common.Address, blockHash common.Hash) (common.Address, common.Address, error)
+}
+
+// 冲突判定的键：同一个键下出现两个不同的签名内容即为冲突
+type contentKey struct {
+	kind    uint8
+	number  uint64
+	parent  common.Hash    // 区块：父区块hash
+	leader  common.Address // 区块：leader；在线共识：leader
+	node    common.Address // 在线共识：被共识的节点
+	turn    uint32         // 区块：共识总轮次；在线共识：leader轮次
+	time    uint64         // 区块：区块头时间戳，同一leader不同轮次的区块头时间戳不同
+	blkHash common.Hash    // 签名账户换算时使用的区块hash
+}
+
+type content struct {
+	key    contentKey
+	turn   uint32
+	header *types.Header
+	online *mc.OnlineConsensusReq
+}
+
+type signedVote struct {
+	hash common.Hash
+	sign common.Signature
+}
+
+type voteKey struct {
+	key    contentKey
+	signer common.Address
+}
+
+type pendingVote struct {
+	hash   common.Hash
+	sign   common.Signature
+	signer common.Address
+}
+
+// Collector 双签证据收集器
+type Collector struct {
+	mu        sync.Mutex
+	db        mandb.Database
+	reader    AccountReader
+	contents  map[common.Hash]*content
+	votes     map[voteKey]signedVote
+	pending   []pendingVote
+	evidences []mc.EquivocationEvidence
+	counts    map[uint64]int // 各高度已记录的签名内容数量
+	highest   uint64
+}
+
+// NewCollector 创建收集器，并从数据库恢复已保存的证据。db或reader为nil时对应功能不可用
+func NewCollector(db mandb.Database, reader AccountReader) *Collector {
+	c := &Collector{
+		db:        db,
+		reader:    reader,
+		contents:  make(map[common.Hash]*content),
+		votes:     make(map[voteKey]signedVote),
+		pending:   make([]pendingVote, 0),
+		evidences: make([]mc.EquivocationEvidence, 0),
+		counts:    make(map[uint64]int),
+	}
+	c.load()
+	return c
+}
+
+// AddBlockHeader 记录区块共识请求中的区块头，turn为共识总轮次。调用者需先校验区块头的高度，
+// 否则伪造的高度会推进highest，清除正常高度的记录
+func (c *Collector) AddBlockHeader(header *types.Header, turn uint32) {
+	if nil == header || nil == header.Number || nil == header.Time {
+		return
+	}
+	hash := header.HashNoSignsAndNonce()
+	ct := &content{
+		key: contentKey{
+			kind:    mc.EquivocationKindBlock,
+			number:  header.Number.Uint64(),
+			parent:  header.ParentHash,
+			leader:  header.Leader,
+			turn:    turn,
+			time:    header.Time.Uint64(),
+			blkHash: header.ParentHash,
+		},
+		turn:   turn,
+		header: types.CopyHeader(header),
+	}
+	c.addContent(hash, ct)
+}
+
+// AddOnlineReq 记录在线共识请求，blkHash用于签名账户换算
+func (c *Collector) AddOnlineReq(req *mc.OnlineConsensusReq, blkHash common.Hash) {
+	if nil == req {
+		return
+	}
+	copyReq := *req
+	ct := &content{
+		key: contentKey{
+			kind:    mc.EquivocationKindOnline,
+			number:  req.Number,
+			leader:  req.Leader,
+			node:    req.Node,
+			turn:    req.LeaderTurn,
+			blkHash: blkHash,
+		},
+		turn:   req.LeaderTurn,
+		online: &copyReq,
+	}
+	c.addContent(types.RlpHash(req), ct)
+}
+
+// AddVote 记录投票，反对票不参与双签判定
+func (c *Collector) AddVote(signHash common.Hash, sign common.Signature) {
+	if (signHash == common.Hash{}) || (sign == common.Signature{}) {
+		return
+	}
+	signer, validate, err := crypto.VerifySignWithValidate(signHash.Bytes(), sign.Bytes())
+	if err != nil || !validate {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ct, exist := c.contents[signHash]
+	if !exist {
+		c.pending = append(c.pending, pendingVote{hash: signHash, sign: sign, signer: signer})
+		if len(c.pending) > DefaultMaxPending {
+			c.pending = c.pending[len(c.pending)-DefaultMaxPending:]
+		}
+		return
+	}
+	c.checkVote(signHash, ct, signer, sign)
+}
+
+// Evidences 返回作恶高度在[from, to]范围内的证据
+func (c *Collector) Evidences(from, to uint64) []mc.EquivocationEvidence {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ret := make([]mc.EquivocationEvidence, 0)
+	for _, ev := range c.evidences {
+		if ev.Number >= from && ev.Number <= to {
+			ret = append(ret, ev)
+		}
+	}
+	return ret
+}
+
+func (c *Collector) addContent(hash common.Hash, ct *content) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, exist := c.contents[hash]; exist {
+		return
+	}
+	if c.highest > DefaultKeepHeights && ct.key.number < c.highest-DefaultKeepHeights {
+		return
+	}
+	if c.counts[ct.key.number] >= DefaultMaxHeightContents {
+		log.Debug(logInfo, "高度记录的签名内容过多，丢弃", ct.key.number)
+		return
+	}
+	c.counts[ct.key.number]++
+	c.contents[hash] = ct
+	if ct.key.number > c.highest {
+		c.highest = ct.key.number
+		c.prune()
+	}
+
+	remain := c.pending[:0]
+	for _, vote := range c.pending {
+		if vote.hash == hash {
+			c.checkVote(hash, ct, vote.signer, vote.sign)
+		} else {
+			remain = append(remain, vote)
+		}
+	}
+	c.pending = remain
+}
+
+func (c *Collector) checkVote(hash common.Hash, ct *content, signer common.Address, sign common.Signature) {
+	key := voteKey{key: ct.key, signer: signer}
+	old, exist := c.votes[key]
+	if !exist {
+		c.votes[key] = signedVote{hash: hash, sign: sign}
+		return
+	}
+	if old.hash == hash {
+		return
+	}
+	oldContent, exist := c.contents[old.hash]
+	if !exist {
+		return
+	}
+	c.addEvidence(c.makeEvidence(oldContent, ct, signer, old.sign, sign))
+}
+
+func (c *Collector) makeEvidence(a, b *content, signer common.Address, signA, signB common.Signature) mc.EquivocationEvidence {
+	ev := mc.EquivocationEvidence{
+		Kind:          a.key.kind,
+		Number:        a.key.number,
+		ConsensusTurn: a.turn,
+		Signer:        signer,
+		HeaderA:       a.header,
+		HeaderB:       b.header,
+		OnlineA:       a.online,
+		OnlineB:       b.online,
+		SignA:         signA,
+		SignB:         signB,
+	}
+	if c.reader != nil {
+		account, _, err := c.reader.GetA0AccountFromAnyAccount(signer, a.key.blkHash)
+		if err != nil {
+			log.Warn(logInfo, "签名账户换算抵押账户失败", err, "signer", signer.Hex(), "高度", ev.Number)
+		} else {
+			ev.Account = account
+		}
+	}
+	if ev.Kind == mc.EquivocationKindBlock && (ev.Account != common.Address{}) && ev.Account == a.header.Leader {
+		ev.Kind = mc.EquivocationKindPOSReq
+	}
+	return ev
+}
+
+func (c *Collector) addEvidence(ev mc.EquivocationEvidence) {
+	for _, v := range c.evidences {
+		if v.Kind == ev.Kind && v.Number == ev.Number && v.Signer == ev.Signer {
+			return
+		}
+	}
+	log.Warn(logInfo, "发现双签", "类型", ev.Kind, "高度", ev.Number, "轮次", ev.ConsensusTurn, "signer", ev.Signer.Hex(), "account", ev.Account.Hex())
+	c.evidences = append(c.evidences, ev)
+	if len(c.evidences) > DefaultMaxEvidences {
+		c.evidences = c.evidences[len(c.evidences)-DefaultMaxEvidences:]
+	}
+	c.save()
+}
+
+// 删除低于保留范围的内容和投票
+func (c *Collector) prune() {
+	if c.highest <= DefaultKeepHeights {
+		return
+	}
+	deadline := c.highest - DefaultKeepHeights
+	for hash, ct := range c.contents {
+		if ct.key.number < deadline {
+			delete(c.contents, hash)
+		}
+	}
+	for key := range c.votes {
+		if key.key.number < deadline {
+			delete(c.votes, key)
+		}
+	}
+	for number := range c.counts {
+		if number < deadline {
+			delete(c.counts, number)
+		}
+	}
+}
+
+func (c *Collector) load() {
+	if c.db == nil {
+		return
+	}
+	data, err := c.db.Get(evidenceDBKey)
+	if err != nil || len(data) == 0 {
+		return
+	}
+	list := make([]mc.EquivocationEvidence, 0)
+	if err := rlp.DecodeBytes(data, &list); err != nil {
+		log.Error(logInfo, "读取数据库中的证据失败", err)
+		return
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].Number < list[j].Number })
+	c.evidences = list
+}
+
+func (c *Collector) save() {
+	if c.db == nil {
+		return
+	}
+	data, err := rlp.EncodeToBytes(c.evidences)
+	if err != nil {
+		log.Error(logInfo, "证据编码失败", err)
+		return
+	}
+	if err := c.db.Put(evidenceDBKey, data); err != nil {
+		log.Error(logInfo, "证据写入数据库失败", err)
+	}
+}
@@ -0,0 +1,182 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package evidence
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/crypto"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/params/manversion"
+	"github.com/MatrixAINetwork/go-matrix/reward/slash"
+)
+
+type testReader struct {
+	account common.Address
+}
+
+func (r *testReader) GetA0AccountFromAnyAccount(account common.Address, blockHash common.Hash) (common.Address, common.Address, error) {
+	return r.account, account, nil
+}
+
+func testHeader(number int64, extra string) *types.Header {
+	return &types.Header{
+		ParentHash: common.HexToHash("0x01"),
+		Leader:     common.HexToAddress("0x1000"),
+		Number:     big.NewInt(number),
+		Difficulty: big.NewInt(1),
+		Time:       big.NewInt(1),
+		Extra:      []byte(extra),
+		Version:    []byte(manversion.VersionAIMine),
+	}
+}
+
+func testSign(t *testing.T, key *ecdsa.PrivateKey, hash common.Hash, validate bool) common.Signature {
+	sign, err := crypto.SignWithValidate(hash.Bytes(), validate, key)
+	if err != nil {
+		t.Fatalf("sign failed: %v", err)
+	}
+	return common.BytesToSignature(sign)
+}
+
+func TestCollectorBlockEquivocation(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	signer := crypto.PubkeyToAddress(key.PublicKey)
+	account := common.HexToAddress("0x2000")
+	db := mandb.NewMemDatabase()
+	c := NewCollector(db, &testReader{account: account})
+
+	a, b := testHeader(10, "a"), testHeader(10, "b")
+	c.AddBlockHeader(a, 1)
+	c.AddBlockHeader(b, 1)
+
+	// 反对票不构成双签
+	c.AddVote(a.HashNoSignsAndNonce(), testSign(t, key, a.HashNoSignsAndNonce(), true))
+	c.AddVote(b.HashNoSignsAndNonce(), testSign(t, key, b.HashNoSignsAndNonce(), false))
+	if evs := c.Evidences(0, 100); len(evs) != 0 {
+		t.Fatalf("reject vote produced evidence: %+v", evs)
+	}
+
+	c.AddVote(b.HashNoSignsAndNonce(), testSign(t, key, b.HashNoSignsAndNonce(), true))
+	evs := c.Evidences(0, 100)
+	if len(evs) != 1 {
+		t.Fatalf("evidences = %d, want 1", len(evs))
+	}
+	ev := evs[0]
+	if ev.Kind != mc.EquivocationKindBlock || ev.Number != 10 || ev.Signer != signer || ev.Account != account || ev.ConsensusTurn != 1 {
+		t.Fatalf("unexpected evidence %+v", ev)
+	}
+	hashA, hashB, err := slash.EquivocationSignHashes(&ev)
+	if err != nil {
+		t.Fatalf("evidence not conflicting: %v", err)
+	}
+	if hashA != a.HashNoSignsAndNonce() || hashB != b.HashNoSignsAndNonce() {
+		t.Fatalf("sign hashes mismatch")
+	}
+
+	// 重复投票不产生新证据
+	c.AddVote(b.HashNoSignsAndNonce(), testSign(t, key, b.HashNoSignsAndNonce(), true))
+	if evs := c.Evidences(0, 100); len(evs) != 1 {
+		t.Fatalf("evidences = %d after duplicate vote, want 1", len(evs))
+	}
+
+	// 重启后从数据库恢复
+	reloaded := NewCollector(db, nil)
+	if evs := reloaded.Evidences(10, 10); len(evs) != 1 || evs[0].Signer != signer || evs[0].HeaderB == nil {
+		t.Fatalf("evidence not restored: %+v", evs)
+	}
+	if evs := reloaded.Evidences(11, 100); len(evs) != 0 {
+		t.Fatalf("range filter failed: %+v", evs)
+	}
+}
+
+func TestCollectorPendingVote(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	leader := common.HexToAddress("0x1000")
+	c := NewCollector(nil, &testReader{account: leader})
+
+	a, b := testHeader(20, "a"), testHeader(20, "b")
+	// 投票先于请求到达
+	c.AddVote(a.HashNoSignsAndNonce(), testSign(t, key, a.HashNoSignsAndNonce(), true))
+	c.AddVote(b.HashNoSignsAndNonce(), testSign(t, key, b.HashNoSignsAndNonce(), true))
+	c.AddBlockHeader(a, 0)
+	if evs := c.Evidences(0, 100); len(evs) != 0 {
+		t.Fatalf("unexpected evidence %+v", evs)
+	}
+	c.AddBlockHeader(b, 0)
+	evs := c.Evidences(0, 100)
+	if len(evs) != 1 {
+		t.Fatalf("evidences = %d, want 1", len(evs))
+	}
+	// leader自己双签，按POS请求双签处理
+	if evs[0].Kind != mc.EquivocationKindPOSReq {
+		t.Fatalf("kind = %d, want %d", evs[0].Kind, mc.EquivocationKindPOSReq)
+	}
+}
+
+func TestCollectorOnlineEquivocation(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	c := NewCollector(nil, nil)
+
+	reqA := &mc.OnlineConsensusReq{Number: 30, LeaderTurn: 2, Leader: common.HexToAddress("0x1000"), Node: common.HexToAddress("0x3000"), OnlineState: mc.OnLine}
+	reqB := *reqA
+	reqB.OnlineState = mc.OffLine
+	c.AddOnlineReq(reqA, common.Hash{})
+	c.AddOnlineReq(&reqB, common.Hash{})
+	c.AddVote(types.RlpHash(reqA), testSign(t, key, types.RlpHash(reqA), true))
+	c.AddVote(types.RlpHash(&reqB), testSign(t, key, types.RlpHash(&reqB), true))
+
+	evs := c.Evidences(0, 100)
+	if len(evs) != 1 || evs[0].Kind != mc.EquivocationKindOnline || evs[0].OnlineB.OnlineState != mc.OffLine {
+		t.Fatalf("unexpected evidences %+v", evs)
+	}
+	if _, _, err := slash.EquivocationSignHashes(&evs[0]); err != nil {
+		t.Fatalf("evidence not conflicting: %v", err)
+	}
+}
+
+func TestCollectorReelectedLeader(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	c := NewCollector(nil, &testReader{account: common.HexToAddress("0x2000")})
+
+	// 同一leader被重新选中，新轮次的区块头时间戳不同，验证者对两者都投赞成票不算双签
+	a, b := testHeader(10, "a"), testHeader(10, "b")
+	b.Time = big.NewInt(20)
+	c.AddBlockHeader(a, 1)
+	c.AddBlockHeader(b, 3)
+	c.AddVote(a.HashNoSignsAndNonce(), testSign(t, key, a.HashNoSignsAndNonce(), true))
+	c.AddVote(b.HashNoSignsAndNonce(), testSign(t, key, b.HashNoSignsAndNonce(), true))
+	if evs := c.Evidences(0, 100); len(evs) != 0 {
+		t.Fatalf("re-elected leader produced evidence: %+v", evs)
+	}
+	ev := mc.EquivocationEvidence{Kind: mc.EquivocationKindBlock, Number: 10, HeaderA: a, HeaderB: b}
+	if _, _, err := slash.EquivocationSignHashes(&ev); err != slash.ErrEquivocationContent {
+		t.Fatalf("err = %v, want %v", err, slash.ErrEquivocationContent)
+	}
+}
+
+func TestCollectorContentLimit(t *testing.T) {
+	c := NewCollector(nil, nil)
+	for i := 0; i < DefaultMaxHeightContents+10; i++ {
+		c.AddBlockHeader(testHeader(10, fmt.Sprint(i)), 0)
+	}
+	if len(c.contents) != DefaultMaxHeightContents {
+		t.Fatalf("contents = %d, want %d", len(c.contents), DefaultMaxHeightContents)
+	}
+	c.AddBlockHeader(testHeader(10+DefaultKeepHeights+1, "new"), 0)
+	if len(c.contents) != 1 || len(c.counts) != 1 {
+		t.Fatalf("contents = %d, counts = %d after prune, want 1", len(c.contents), len(c.counts))
+	}
+	// 低于保留范围的区块头不再记录
+	c.AddBlockHeader(testHeader(5, "old"), 0)
+	if len(c.contents) != 1 {
+		t.Fatalf("expired header recorded")
+	}
+}
@@ -345,7 +345,7 @@ func calcDifficultyByzantium(curVersion string, time uint64, parent *types.Heade
 	// (2 if len(parent_uncles) else 1) - (block_timestamp - parent_timestamp) // 9
 	x.Sub(bigTime, bigParentTime)
 	var durationLimit *big.Int
-	if manversion.VersionCmp(curVersion, manversion.VersionGamma) >= 0 {
+	if manversion.IsEnabled(manversion.FeatureGamma, curVersion) {
 		durationLimit = params.VersionGammaDurationLimit
 	} else {
 		durationLimit = params.DurationLimit
@@ -0,0 +1,303 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package mtxdpos
+
+import (
+	"bytes"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/consensus"
+	"github.com/MatrixAINetwork/go-matrix/core/matrixstate"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/core/vm"
+	"github.com/MatrixAINetwork/go-matrix/crypto/bls"
+	"github.com/MatrixAINetwork/go-matrix/depoistInfo"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/params/manversion"
+	"github.com/pkg/errors"
+)
+
+var (
+	errBLSNotSupported = errors.New("state reader does not support bls aggregate signature")
+
+	errBLSDisabled = errors.New("bls aggregate signature is disabled")
+
+	errAggregateSignVersion = errors.New("block version does not support aggregate sign")
+
+	errAggregateSignCount = errors.New("block's aggregate sign count err, need one aggregate sign without other signs")
+
+	errAggregateBitmap = errors.New("aggregate sign bitmap err")
+
+	errAggregateVerify = errors.New("aggregate sign verify failed")
+)
+
+// 拓扑图中的验证者按出现顺序排列，聚合签名的bitmap以此为序
+func (md *MtxDPOS) getOrderedValidators(reader consensus.StateReader, hash common.Hash) ([]common.Address, map[common.Address]uint16, error) {
+	topologyInfo, electInfo, err := reader.GetGraphByHash(hash)
+	if err != nil {
+		return nil, nil, err
+	}
+	validators := make([]common.Address, 0)
+	stocks := make(map[common.Address]uint16)
+	for _, node := range topologyInfo.NodeList {
+		if node.Type != common.RoleValidator {
+			continue
+		}
+		if _, exist := stocks[node.Account]; exist {
+			continue
+		}
+		stocks[node.Account] = md.findStockInElect(node.Account, electInfo)
+		validators = append(validators, node.Account)
+	}
+	return validators, stocks, nil
+}
+
+func (md *MtxDPOS) getBLSPublicKeys(reader consensus.StateReader, hash common.Hash) (map[common.Address]*bls.PublicKey, error) {
+	keyReader, ok := reader.(consensus.BLSKeyReader)
+	if !ok {
+		return nil, errBLSNotSupported
+	}
+	cfg, err := keyReader.GetBLSAggregateCfg(hash)
+	if err != nil {
+		return nil, err
+	}
+	if !cfg.Switcher {
+		return nil, errBLSDisabled
+	}
+	keys, err := keyReader.GetBLSPublicKeys(hash)
+	if err != nil {
+		return nil, err
+	}
+	// 公钥以签名账户注册，转换为抵押账户索引。签名账户已更换的公钥不再有效
+	pks := make(map[common.Address]*bls.PublicKey, len(keys.Keys))
+	for _, key := range keys.Keys {
+		a0Account, a1Account, err := reader.GetA0AccountFromAnyAccount(key.Account, hash)
+		if err != nil || a1Account != key.Account {
+			log.Debug("共识引擎", "BLS公钥的签名账户无效, account", key.Account.Hex())
+			continue
+		}
+		pk, err := bls.UnmarshalPublicKey(key.PubKey)
+		if err != nil {
+			log.Warn("共识引擎", "BLS公钥解析失败", err, "account", key.Account.Hex())
+			continue
+		}
+		pks[a0Account] = pk
+	}
+	return pks, nil
+}
+
+func (md *MtxDPOS) checkAggregateTarget(signers []common.Address, stocks map[common.Address]uint16) error {
+	target, err := md.calculateDPOSTarget(stocks)
+	if err != nil {
+		return err
+	}
+	var agreeStock uint64
+	for _, signer := range signers {
+		agreeStock += uint64(stocks[signer])
+	}
+	if len(signers) < target.targetCount {
+		log.Error("共识引擎", "聚合签名数量不足 size", len(signers), "target", target.targetCount)
+		return errSignCountErr
+	}
+	if agreeStock < target.targetStock {
+		return errSignStockErr
+	}
+	return nil
+}
+
+// AggregateVotes 将赞成票的BLS签名聚合为一个签名，blsSigns的key为抵押账户，无效签名被剔除
+func (md *MtxDPOS) AggregateVotes(reader consensus.StateReader, signHash common.Hash, blsSigns map[common.Address][]byte, blockHash common.Hash) (*types.AggregateSign, error) {
+	validators, stocks, err := md.getOrderedValidators(reader, blockHash)
+	if err != nil {
+		return nil, err
+	}
+	pks, err := md.getBLSPublicKeys(reader, blockHash)
+	if err != nil {
+		return nil, err
+	}
+
+	type part struct {
+		index int
+		pk    *bls.PublicKey
+		sig   *bls.Signature
+	}
+	parts := make([]part, 0, len(validators))
+	for i, validator := range validators {
+		data, exist := blsSigns[validator]
+		if !exist {
+			continue
+		}
+		pk, exist := pks[validator]
+		if !exist {
+			continue
+		}
+		sig, err := bls.UnmarshalSignature(data)
+		if err != nil {
+			continue
+		}
+		parts = append(parts, part{index: i, pk: pk, sig: sig})
+	}
+
+	build := func(parts []part) (*types.AggregateSign, bool) {
+		bitmap := make([]byte, (len(validators)+7)/8)
+		signers := make([]common.Address, 0, len(parts))
+		pkList := make([]*bls.PublicKey, 0, len(parts))
+		sigList := make([]*bls.Signature, 0, len(parts))
+		for _, p := range parts {
+			bitmap[p.index/8] |= 1 << uint(p.index%8)
+			signers = append(signers, validators[p.index])
+			pkList = append(pkList, p.pk)
+			sigList = append(sigList, p.sig)
+		}
+		if err = md.checkAggregateTarget(signers, stocks); err != nil {
+			return nil, false
+		}
+		aggPk, _ := bls.AggregatePublicKeys(pkList)
+		aggSig, _ := bls.AggregateSignatures(sigList)
+		if !bls.Verify(aggPk, signHash.Bytes(), aggSig) {
+			err = errAggregateVerify
+			return nil, false
+		}
+		return &types.AggregateSign{Signature: aggSig.Marshal(), Bitmap: bitmap}, true
+	}
+
+	if aggregate, ok := build(parts); ok {
+		return aggregate, nil
+	} else if err != errAggregateVerify {
+		return nil, err
+	}
+
+	// 聚合验证失败，逐个验证剔除错误签名后重新聚合
+	valid := parts[:0]
+	for _, p := range parts {
+		if bls.Verify(p.pk, signHash.Bytes(), p.sig) {
+			valid = append(valid, p)
+		} else {
+			log.Warn("共识引擎", "BLS投票签名错误", validators[p.index].Hex())
+		}
+	}
+	if aggregate, ok := build(valid); ok {
+		return aggregate, nil
+	}
+	return nil, err
+}
+
+// VerifyAggregate 验证聚合签名：bitmap中的验证者股权满足DPOS要求，且聚合签名与聚合公钥匹配
+func (md *MtxDPOS) VerifyAggregate(reader consensus.StateReader, signHash common.Hash, aggregate *types.AggregateSign, blockHash common.Hash) error {
+	if nil == aggregate {
+		return errAggregateSignCount
+	}
+	validators, stocks, err := md.getOrderedValidators(reader, blockHash)
+	if err != nil {
+		return err
+	}
+	if len(aggregate.Bitmap) != (len(validators)+7)/8 {
+		return errAggregateBitmap
+	}
+	pks, err := md.getBLSPublicKeys(reader, blockHash)
+	if err != nil {
+		return err
+	}
+
+	signers := make([]common.Address, 0, len(validators))
+	pkList := make([]*bls.PublicKey, 0, len(validators))
+	for i := 0; i < len(aggregate.Bitmap)*8; i++ {
+		if aggregate.Bitmap[i/8]&(1<<uint(i%8)) == 0 {
+			continue
+		}
+		if i >= len(validators) {
+			return errAggregateBitmap
+		}
+		pk, exist := pks[validators[i]]
+		if !exist {
+			return errors.Errorf("validator %s has no bls public key", validators[i].Hex())
+		}
+		signers = append(signers, validators[i])
+		pkList = append(pkList, pk)
+	}
+	if err := md.checkAggregateTarget(signers, stocks); err != nil {
+		return err
+	}
+
+	sig, err := bls.UnmarshalSignature(aggregate.Signature)
+	if err != nil {
+		return err
+	}
+	aggPk, _ := bls.AggregatePublicKeys(pkList)
+	if !bls.Verify(aggPk, signHash.Bytes(), sig) {
+		return errAggregateVerify
+	}
+	return nil
+}
+
+// VerifyBLSKeyRegistration 校验注册数据中的公钥格式及私钥持有证明
+func VerifyBLSKeyRegistration(reg *mc.BLSKeyRegistration) error {
+	if nil == reg {
+		return errors.New("bls key registration is nil")
+	}
+	pk, err := bls.UnmarshalPublicKey(reg.PubKey)
+	if err != nil {
+		return err
+	}
+	proof, err := bls.UnmarshalSignature(reg.Proof)
+	if err != nil {
+		return err
+	}
+	if !bls.VerifyPossession(pk, proof) {
+		return errors.New("bls key proof of possession verify failed")
+	}
+	return nil
+}
+
+// BLSKeyRegistrationEnabled 当前版本支持聚合签名且配置开关打开时才接受BLS公钥注册
+func BLSKeyRegistrationEnabled(st vm.StateDBManager) error {
+	version := matrixstate.GetVersionInfo(st)
+	if !manversion.IsEnabled(manversion.FeatureAggregateSign, version) {
+		return errors.Errorf("bls key registration not supported in version %s", version)
+	}
+	cfg, err := matrixstate.GetBLSAggregateCfg(st)
+	if err != nil {
+		return err
+	}
+	if !cfg.Switcher {
+		return errBLSDisabled
+	}
+	return nil
+}
+
+// RegisterBLSKey 抵押账户为其签名账户注册(或更换)BLS公钥，需提供私钥对公钥的签名。
+// BLS私钥由签名账户私钥派生，公钥以签名账户记录
+func RegisterBLSKey(st vm.StateDBManager, depositAccount common.Address, reg *mc.BLSKeyRegistration, num uint64) error {
+	if err := BLSKeyRegistrationEnabled(st); err != nil {
+		return err
+	}
+	if err := VerifyBLSKeyRegistration(reg); err != nil {
+		return err
+	}
+	account := depoistInfo.GetAuthAccount(st, depositAccount)
+	if account == (common.Address{}) {
+		return errors.Errorf("account %s has no deposit", depositAccount.Hex())
+	}
+
+	keys, err := matrixstate.GetBLSPublicKeys(st)
+	if err != nil {
+		return err
+	}
+	found := false
+	for i := range keys.Keys {
+		if keys.Keys[i].Account == account {
+			keys.Keys[i].PubKey = reg.PubKey
+			keys.Keys[i].Number = num
+			found = true
+		} else if bytes.Equal(keys.Keys[i].PubKey, reg.PubKey) {
+			return errors.Errorf("bls public key already registered by %s", keys.Keys[i].Account.Hex())
+		}
+	}
+	if !found {
+		keys.Keys = append(keys.Keys, mc.BLSPublicKey{Account: account, PubKey: reg.PubKey, Number: num})
+	}
+	log.Info("共识引擎", "注册BLS公钥, 抵押账户", depositAccount.Hex(), "签名账户", account.Hex(), "高度", num)
+	return matrixstate.SetBLSPublicKeys(st, keys)
+}
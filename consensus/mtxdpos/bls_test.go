@@ -0,0 +1,200 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package mtxdpos
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/matrixstate"
+	"github.com/MatrixAINetwork/go-matrix/core/state"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/crypto/bls"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/params/manversion"
+)
+
+type blsTestReader struct {
+	topology *mc.TopologyGraph
+	elect    *mc.ElectGraph
+	cfg      *mc.BLSAggregateCfg
+	keys     *mc.BLSPublicKeys
+	a0       map[common.Address]common.Address // 签名账户 -> 抵押账户，为空时账户相同
+}
+
+func (r *blsTestReader) GetCurrentHash() common.Hash { return common.Hash{} }
+func (r *blsTestReader) GetGraphByHash(hash common.Hash) (*mc.TopologyGraph, *mc.ElectGraph, error) {
+	return r.topology, r.elect, nil
+}
+func (r *blsTestReader) GetBroadcastAccounts(blockHash common.Hash) ([]common.Address, error) {
+	return nil, nil
+}
+func (r *blsTestReader) GetVersionSuperAccounts(blockHash common.Hash) ([]common.Address, error) {
+	return nil, nil
+}
+func (r *blsTestReader) GetBlockSuperAccounts(blockHash common.Hash) ([]common.Address, error) {
+	return nil, nil
+}
+func (r *blsTestReader) GetBroadcastIntervalByHash(blockHash common.Hash) (*mc.BCIntervalInfo, error) {
+	return nil, nil
+}
+func (r *blsTestReader) GetA0AccountFromAnyAccount(account common.Address, blockHash common.Hash) (common.Address, common.Address, error) {
+	if r.a0 == nil {
+		return account, account, nil
+	}
+	a0, exist := r.a0[account]
+	if !exist {
+		return common.Address{}, common.Address{}, errors.New("unknown account")
+	}
+	return a0, account, nil
+}
+func (r *blsTestReader) GetBLSAggregateCfg(blockHash common.Hash) (*mc.BLSAggregateCfg, error) {
+	return r.cfg, nil
+}
+func (r *blsTestReader) GetBLSPublicKeys(blockHash common.Hash) (*mc.BLSPublicKeys, error) {
+	return r.keys, nil
+}
+
+func newBLSTestValidators(count int) (*blsTestReader, []common.Address, []*bls.SecretKey) {
+	reader := &blsTestReader{
+		topology: &mc.TopologyGraph{},
+		elect:    &mc.ElectGraph{},
+		cfg:      &mc.BLSAggregateCfg{Switcher: true},
+		keys:     &mc.BLSPublicKeys{},
+	}
+	accounts := make([]common.Address, 0, count)
+	sks := make([]*bls.SecretKey, 0, count)
+	for i := 0; i < count; i++ {
+		account := common.BigToAddress(big.NewInt(int64(i + 1)))
+		sk := bls.DeriveSecretKey(account.Bytes())
+		reader.topology.NodeList = append(reader.topology.NodeList, mc.TopologyNodeInfo{Account: account, Position: uint16(i), Type: common.RoleValidator})
+		reader.elect.ElectList = append(reader.elect.ElectList, mc.ElectNodeInfo{Account: account, Stock: 1, Type: common.RoleValidator})
+		reader.keys.Keys = append(reader.keys.Keys, mc.BLSPublicKey{Account: account, PubKey: sk.PublicKey().Marshal()})
+		accounts = append(accounts, account)
+		sks = append(sks, sk)
+	}
+	return reader, accounts, sks
+}
+
+func TestAggregateVotes(t *testing.T) {
+	reader, accounts, sks := newBLSTestValidators(9)
+	signHash := common.HexToHash("0x1234")
+	dpos := NewMtxDPOS(false)
+
+	blsSigns := make(map[common.Address][]byte)
+	for i := 0; i < 8; i++ {
+		blsSigns[accounts[i]] = sks[i].Sign(signHash.Bytes()).Marshal()
+	}
+	// 错误签名被剔除，不影响聚合
+	blsSigns[accounts[7]] = sks[7].Sign([]byte("other")).Marshal()
+
+	aggregate, err := dpos.AggregateVotes(reader, signHash, blsSigns, common.Hash{})
+	if err != nil {
+		t.Fatalf("aggregate err: %v", err)
+	}
+	if len(aggregate.Bitmap) != 2 || aggregate.Bitmap[0] != 0x7f || aggregate.Bitmap[1] != 0 {
+		t.Fatalf("bitmap = %x", aggregate.Bitmap)
+	}
+	if err := dpos.VerifyAggregate(reader, signHash, aggregate, common.Hash{}); err != nil {
+		t.Fatalf("verify aggregate err: %v", err)
+	}
+	if err := dpos.VerifyAggregate(reader, common.HexToHash("0x5678"), aggregate, common.Hash{}); err == nil {
+		t.Fatal("aggregate accepted for wrong hash")
+	}
+
+	tampered := *aggregate
+	tampered.Bitmap = []byte{0xff, 0}
+	if err := dpos.VerifyAggregate(reader, signHash, &tampered, common.Hash{}); err == nil {
+		t.Fatal("aggregate accepted with extra signer in bitmap")
+	}
+
+	reader.cfg.Switcher = false
+	if err := dpos.VerifyAggregate(reader, signHash, aggregate, common.Hash{}); err != errBLSDisabled {
+		t.Fatalf("err = %v, want %v", err, errBLSDisabled)
+	}
+}
+
+func TestAggregateVotesNotEnough(t *testing.T) {
+	reader, accounts, sks := newBLSTestValidators(9)
+	signHash := common.HexToHash("0x1234")
+
+	blsSigns := make(map[common.Address][]byte)
+	for i := 0; i < 3; i++ {
+		blsSigns[accounts[i]] = sks[i].Sign(signHash.Bytes()).Marshal()
+	}
+	if _, err := NewMtxDPOS(false).AggregateVotes(reader, signHash, blsSigns, common.Hash{}); err == nil {
+		t.Fatal("aggregate succeeded without enough signers")
+	}
+}
+
+func TestVerifyBLSKeyRegistration(t *testing.T) {
+	sk := bls.DeriveSecretKey([]byte("seed"))
+	reg := &mc.BLSKeyRegistration{PubKey: sk.PublicKey().Marshal(), Proof: sk.ProvePossession().Marshal()}
+	if err := VerifyBLSKeyRegistration(reg); err != nil {
+		t.Fatalf("valid registration rejected: %v", err)
+	}
+	reg.Proof = sk.Sign(reg.PubKey).Marshal()
+	if err := VerifyBLSKeyRegistration(reg); err == nil {
+		t.Fatal("registration accepted with signature instead of proof")
+	}
+}
+
+func TestRegisterBLSKeyVersion(t *testing.T) {
+	chaindb := mandb.NewMemDatabase()
+	st, _ := state.NewStateDBManage(nil, chaindb, state.NewDatabase(chaindb))
+	matrixstate.SetVersionInfo(st, manversion.VersionZeta)
+
+	sk := bls.DeriveSecretKey([]byte("seed"))
+	reg := &mc.BLSKeyRegistration{PubKey: sk.PublicKey().Marshal(), Proof: sk.ProvePossession().Marshal()}
+	if err := RegisterBLSKey(st, common.HexToAddress("0x1000"), reg, 10); err == nil {
+		t.Fatal("registration accepted in a version without aggregate sign")
+	}
+	if err := BLSKeyRegistrationEnabled(st); err == nil {
+		t.Fatal("registration enabled in a version without aggregate sign")
+	}
+}
+
+func TestBLSPublicKeysBySignAccount(t *testing.T) {
+	reader, accounts, sks := newBLSTestValidators(4)
+	reader.a0 = make(map[common.Address]common.Address)
+	for i := range reader.keys.Keys {
+		signAccount := common.BigToAddress(big.NewInt(int64(100 + i)))
+		reader.keys.Keys[i].Account = signAccount
+		if i != 3 {
+			reader.a0[signAccount] = accounts[i]
+		}
+	}
+
+	pks, err := NewMtxDPOS(false).getBLSPublicKeys(reader, common.Hash{})
+	if err != nil {
+		t.Fatalf("get bls public keys err: %v", err)
+	}
+	if len(pks) != 3 {
+		t.Fatalf("pks count = %d, want 3", len(pks))
+	}
+	for i := 0; i < 3; i++ {
+		pk, exist := pks[accounts[i]]
+		if !exist || !bytes.Equal(pk.Marshal(), sks[i].PublicKey().Marshal()) {
+			t.Fatalf("validator %d public key not indexed by deposit account", i)
+		}
+	}
+	if _, exist := pks[accounts[3]]; exist {
+		t.Fatal("public key of replaced sign account is still valid")
+	}
+}
+
+func TestVerifyBlockAggregateVersion(t *testing.T) {
+	header := &types.Header{
+		Number:         big.NewInt(100),
+		Version:        []byte(manversion.VersionZeta),
+		AggregateSigns: []types.AggregateSign{{Signature: []byte{1}, Bitmap: []byte{1}}},
+	}
+	if err := NewMtxDPOS(false).VerifyBlock(&blsTestReader{}, header); err != errAggregateSignVersion {
+		t.Fatalf("err = %v, want %v", err, errAggregateSignVersion)
+	}
+}
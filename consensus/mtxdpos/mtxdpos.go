@@ -12,6 +12,7 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/crypto"
 	"github.com/MatrixAINetwork/go-matrix/log"
 	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/params/manversion"
 	"github.com/pkg/errors"
 )
 
@@ -181,6 +182,9 @@ func (md *MtxDPOS) VerifyBlock(reader consensus.StateReader, header *types.Heade
 	if nil == header {
 		return errors.New("header is nil")
 	}
+	if len(header.AggregateSigns) != 0 && !manversion.IsEnabled(manversion.FeatureAggregateSign, string(header.Version)) {
+		return errAggregateSignVersion
+	}
 	if err := md.VerifyVersionSigns(reader, header); err != nil {
 		log.Info("共识引擎", "验证版本号签名失败", err)
 		return err
@@ -206,6 +210,13 @@ func (md *MtxDPOS) VerifyBlock(reader consensus.StateReader, header *types.Heade
 	}
 
 	hash := header.HashNoSignsAndNonce()
+	if len(header.AggregateSigns) != 0 {
+		if len(header.AggregateSigns) != 1 || len(header.Signatures) != 0 {
+			return errAggregateSignCount
+		}
+		log.Trace("共识引擎", "VerifyBlock, 聚合签名", "hash", hash)
+		return md.VerifyAggregate(reader, hash, &header.AggregateSigns[0], header.ParentHash)
+	}
 	log.Trace("共识引擎", "VerifyBlock, 签名总数", len(header.Signatures), "hash", hash, "txhash:", header.Roots)
 	_, err = md.VerifyHashWithStocks(reader, hash, header.Signatures, stocks, header.ParentHash)
 	return err
@@ -0,0 +1,164 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package trace
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+)
+
+// ChromeEvent Chrome trace格式（chrome://tracing、Perfetto）的单条记录
+type ChromeEvent struct {
+	Name  string                 `json:"name"`
+	Cat   string                 `json:"cat,omitempty"`
+	Phase string                 `json:"ph"`
+	TS    int64                  `json:"ts"`
+	Dur   int64                  `json:"dur,omitempty"`
+	PID   uint64                 `json:"pid"`
+	TID   int                    `json:"tid"`
+	Scope string                 `json:"s,omitempty"`
+	Args  map[string]interface{} `json:"args,omitempty"`
+}
+
+// ChromeTrace Chrome trace文件内容
+type ChromeTrace struct {
+	TraceEvents     []ChromeEvent `json:"traceEvents"`
+	DisplayTimeUnit string        `json:"displayTimeUnit"`
+}
+
+// moduleTID 每个模块固定一个线程号，第0号线程用于整个高度的时间跨度
+var moduleTID = map[string]int{
+	ModuleLeader:   1,
+	ModuleVerify:   2,
+	ModuleGenerate: 3,
+}
+
+func tidOf(module string, extra map[string]int) int {
+	if tid, ok := moduleTID[module]; ok {
+		return tid
+	}
+	if tid, ok := extra[module]; ok {
+		return tid
+	}
+	tid := len(moduleTID) + len(extra) + 1
+	extra[module] = tid
+	return tid
+}
+
+// ToChromeTrace 将事件转换为Chrome trace格式：每个高度对应一个进程，每个模块对应一个线程，
+// 事件为瞬时事件，每个高度以及每个(高度,模块,轮次)生成一个时间跨度
+func ToChromeTrace(events []Event) *ChromeTrace {
+	out := &ChromeTrace{TraceEvents: make([]ChromeEvent, 0, len(events)), DisplayTimeUnit: "ms"}
+	if len(events) == 0 {
+		return out
+	}
+
+	type spanKey struct {
+		number uint64
+		module string
+		turn   uint32
+	}
+	type span struct {
+		begin, end int64
+	}
+	heightSpans := make(map[uint64]*span)
+	turnSpans := make(map[spanKey]*span)
+	extra := make(map[string]int)
+	modules := make(map[uint64]map[string]int)
+
+	for _, ev := range events {
+		ts := ev.Time.UnixNano() / 1000
+		tid := tidOf(ev.Module, extra)
+		if modules[ev.Number] == nil {
+			modules[ev.Number] = make(map[string]int)
+		}
+		modules[ev.Number][ev.Module] = tid
+
+		args := map[string]interface{}{
+			"turn":        ev.Turn,
+			"reelectTurn": ev.ReelectTurn,
+		}
+		if (ev.Account != common.Address{}) {
+			args["account"] = ev.Account.Hex()
+		}
+		if ev.Stock != 0 {
+			args["stock"] = ev.Stock
+		}
+		if ev.Detail != "" {
+			args["detail"] = ev.Detail
+		}
+		out.TraceEvents = append(out.TraceEvents, ChromeEvent{
+			Name: string(ev.Kind), Cat: ev.Module, Phase: "i", TS: ts, PID: ev.Number, TID: tid, Scope: "t", Args: args,
+		})
+
+		extend := func(s *span) {
+			if ts < s.begin {
+				s.begin = ts
+			}
+			if ts > s.end {
+				s.end = ts
+			}
+		}
+		if s, ok := heightSpans[ev.Number]; ok {
+			extend(s)
+		} else {
+			heightSpans[ev.Number] = &span{ts, ts}
+		}
+		key := spanKey{ev.Number, ev.Module, ev.Turn}
+		if s, ok := turnSpans[key]; ok {
+			extend(s)
+		} else {
+			turnSpans[key] = &span{ts, ts}
+		}
+	}
+
+	numbers := make([]uint64, 0, len(heightSpans))
+	for number := range heightSpans {
+		numbers = append(numbers, number)
+	}
+	sort.Slice(numbers, func(i, j int) bool { return numbers[i] < numbers[j] })
+	for _, number := range numbers {
+		s := heightSpans[number]
+		out.TraceEvents = append(out.TraceEvents,
+			ChromeEvent{Name: "process_name", Phase: "M", PID: number, Args: map[string]interface{}{"name": fmt.Sprintf("height %d", number)}},
+			ChromeEvent{Name: "process_sort_index", Phase: "M", PID: number, Args: map[string]interface{}{"sort_index": number}},
+			ChromeEvent{Name: "thread_name", Phase: "M", PID: number, TID: 0, Args: map[string]interface{}{"name": "round"}},
+			ChromeEvent{Name: fmt.Sprintf("height %d", number), Cat: "round", Phase: "X", TS: s.begin, Dur: s.end - s.begin, PID: number, TID: 0},
+		)
+		names := make([]string, 0, len(modules[number]))
+		for module := range modules[number] {
+			names = append(names, module)
+		}
+		sort.Strings(names)
+		for _, module := range names {
+			out.TraceEvents = append(out.TraceEvents, ChromeEvent{
+				Name: "thread_name", Phase: "M", PID: number, TID: modules[number][module], Args: map[string]interface{}{"name": module},
+			})
+		}
+	}
+
+	keys := make([]spanKey, 0, len(turnSpans))
+	for key := range turnSpans {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].number != keys[j].number {
+			return keys[i].number < keys[j].number
+		}
+		if keys[i].module != keys[j].module {
+			return keys[i].module < keys[j].module
+		}
+		return keys[i].turn < keys[j].turn
+	})
+	for _, key := range keys {
+		s := turnSpans[key]
+		out.TraceEvents = append(out.TraceEvents, ChromeEvent{
+			Name: fmt.Sprintf("turn %d", key.turn), Cat: key.module, Phase: "X", TS: s.begin, Dur: s.end - s.begin,
+			PID: key.number, TID: tidOf(key.module, extra),
+		})
+	}
+	return out
+}
@@ -0,0 +1,172 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+// Package trace 记录共识各模块按高度划分的关键事件（轮次开始、leader、重选轮次、
+// 请求接收、投票收集、挖矿结果、区块插入），用于事后分析共识时间线。
+package trace
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+)
+
+const (
+	DefaultMaxHeights = 256  // 默认保留的高度数量
+	DefaultMaxEvents  = 1024 // 默认单个高度保留的事件数量
+)
+
+// Kind 事件类型
+type Kind string
+
+const (
+	KindRoundStart    Kind = "round_start"
+	KindLeader        Kind = "leader"
+	KindTimeout       Kind = "timeout"
+	KindReelectStart  Kind = "reelect_start"
+	KindReelectFinish Kind = "reelect_finish"
+	KindPosReq        Kind = "pos_req"
+	KindVote          Kind = "vote"
+	KindPosDone       Kind = "pos_done"
+	KindMineReq       Kind = "mine_req"
+	KindMineResult    Kind = "mine_result"
+	KindInsert        Kind = "insert"
+)
+
+// Module 事件来源模块
+const (
+	ModuleLeader   = "leader"
+	ModuleVerify   = "verify"
+	ModuleGenerate = "generate"
+)
+
+// Event 单个共识事件
+type Event struct {
+	Time        time.Time      `json:"time"`
+	Number      uint64         `json:"number"`
+	Module      string         `json:"module"`
+	Kind        Kind           `json:"kind"`
+	Turn        uint32         `json:"turn"`
+	ReelectTurn uint32         `json:"reelectTurn"`
+	Account     common.Address `json:"account"`
+	Stock       uint64         `json:"stock,omitempty"`
+	Detail      string         `json:"detail,omitempty"`
+}
+
+// Tracer 按高度保存共识事件，超出容量时淘汰最早的高度
+type Tracer struct {
+	mu         sync.RWMutex
+	enabled    bool
+	maxHeights int
+	maxEvents  int
+	heights    map[uint64][]Event
+	order      []uint64
+}
+
+// NewTracer 创建事件记录器，maxHeights、maxEvents为0时使用默认值
+func NewTracer(maxHeights, maxEvents int) *Tracer {
+	if maxHeights <= 0 {
+		maxHeights = DefaultMaxHeights
+	}
+	if maxEvents <= 0 {
+		maxEvents = DefaultMaxEvents
+	}
+	return &Tracer{
+		enabled:    true,
+		maxHeights: maxHeights,
+		maxEvents:  maxEvents,
+		heights:    make(map[uint64][]Event),
+	}
+}
+
+// SetEnabled 开启或关闭记录，关闭时不清除已有事件
+func (t *Tracer) SetEnabled(enabled bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.enabled = enabled
+}
+
+// Enabled 是否正在记录
+func (t *Tracer) Enabled() bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.enabled
+}
+
+// Record 记录事件，Time为空时取当前时间
+func (t *Tracer) Record(ev Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.enabled {
+		return
+	}
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+	list, exist := t.heights[ev.Number]
+	if !exist {
+		t.order = append(t.order, ev.Number)
+		for len(t.order) > t.maxHeights {
+			delete(t.heights, t.order[0])
+			t.order = t.order[1:]
+		}
+	}
+	if len(list) >= t.maxEvents {
+		return
+	}
+	t.heights[ev.Number] = append(list, ev)
+}
+
+// Events 返回[from, to]高度范围内的事件，按高度升序、同高度按记录顺序排列
+func (t *Tracer) Events(from, to uint64) []Event {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	numbers := make([]uint64, 0, len(t.heights))
+	for number := range t.heights {
+		if number >= from && number <= to {
+			numbers = append(numbers, number)
+		}
+	}
+	sort.Slice(numbers, func(i, j int) bool { return numbers[i] < numbers[j] })
+
+	events := make([]Event, 0)
+	for _, number := range numbers {
+		events = append(events, t.heights[number]...)
+	}
+	return events
+}
+
+// Heights 返回当前保存的高度范围，无事件时ok为false
+func (t *Tracer) Heights() (min uint64, max uint64, ok bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	for number := range t.heights {
+		if !ok || number < min {
+			min = number
+		}
+		if !ok || number > max {
+			max = number
+		}
+		ok = true
+	}
+	return
+}
+
+// Clear 清除全部事件
+func (t *Tracer) Clear() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.heights = make(map[uint64][]Event)
+	t.order = nil
+}
+
+// DefaultTracer 共识模块使用的全局记录器
+var DefaultTracer = NewTracer(DefaultMaxHeights, DefaultMaxEvents)
+
+// Record 向全局记录器写入事件
+func Record(ev Event) {
+	DefaultTracer.Record(ev)
+}
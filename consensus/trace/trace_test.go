@@ -0,0 +1,82 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package trace
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+)
+
+func TestTracerEviction(t *testing.T) {
+	tr := NewTracer(3, 2)
+	for number := uint64(1); number <= 5; number++ {
+		for i := 0; i < 3; i++ {
+			tr.Record(Event{Number: number, Module: ModuleLeader, Kind: KindRoundStart})
+		}
+	}
+	min, max, ok := tr.Heights()
+	if !ok || min != 3 || max != 5 {
+		t.Fatalf("heights = %d-%d %v, want 3-5", min, max, ok)
+	}
+	if events := tr.Events(0, 100); len(events) != 6 {
+		t.Fatalf("events = %d, want 6", len(events))
+	}
+	if events := tr.Events(4, 4); len(events) != 2 || events[0].Number != 4 || events[0].Time.IsZero() {
+		t.Fatalf("unexpected events %+v", events)
+	}
+
+	tr.SetEnabled(false)
+	tr.Record(Event{Number: 6})
+	if _, max, _ := tr.Heights(); max != 5 {
+		t.Fatalf("recorded while disabled")
+	}
+	tr.Clear()
+	if _, _, ok := tr.Heights(); ok {
+		t.Fatalf("not cleared")
+	}
+}
+
+func TestChromeTrace(t *testing.T) {
+	base := time.Unix(1000, 0)
+	events := []Event{
+		{Time: base, Number: 10, Module: ModuleLeader, Kind: KindRoundStart},
+		{Time: base.Add(time.Second), Number: 10, Module: ModuleVerify, Kind: KindVote, Account: common.HexToAddress("0x01"), Stock: 3},
+		{Time: base.Add(2 * time.Second), Number: 10, Module: ModuleLeader, Kind: KindReelectStart, Turn: 1, ReelectTurn: 1},
+		{Time: base.Add(3 * time.Second), Number: 10, Module: ModuleGenerate, Kind: KindInsert},
+	}
+	ct := ToChromeTrace(events)
+	data, err := json.Marshal(ct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded struct {
+		TraceEvents []map[string]interface{} `json:"traceEvents"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	var instants, spans int
+	for _, ev := range decoded.TraceEvents {
+		switch ev["ph"] {
+		case "i":
+			instants++
+		case "X":
+			spans++
+			if ev["name"] == "height 10" && ev["dur"].(float64) != 3e6 {
+				t.Fatalf("height span dur = %v", ev["dur"])
+			}
+		}
+	}
+	// 1个高度跨度 + leader模块轮次0、1 + verify轮次0 + generate轮次0
+	if instants != 4 || spans != 5 {
+		t.Fatalf("instants = %d spans = %d", instants, spans)
+	}
+	if ct.TraceEvents[1].Args["stock"] != uint64(3) {
+		t.Fatalf("vote args = %v", ct.TraceEvents[1].Args)
+	}
+}
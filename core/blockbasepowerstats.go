@@ -13,6 +13,7 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/mc"
 	"github.com/MatrixAINetwork/go-matrix/params/manparams"
 	"github.com/MatrixAINetwork/go-matrix/params/manversion"
+	"github.com/MatrixAINetwork/go-matrix/reward/slash"
 	"github.com/pkg/errors"
 )
 
@@ -30,7 +31,7 @@ func (bc *BlockChain) basePowerSlashCfgProc(state *state.StateDBManage, num uint
 }
 
 func (bc *BlockChain) BasePowerGProduceSlash(version string, state *state.StateDBManage, header *types.Header) error {
-	if manversion.VersionCmp(version, manversion.VersionAIMine) < 0 {
+	if !manversion.IsEnabled(manversion.FeatureAIMine, version) {
 		return nil
 	}
 
@@ -91,6 +92,29 @@ func (bc *BlockChain) basePowerStatsListToBlackList(state *state.StateDBManage,
 	if err := matrixstate.SetBasePowerBlackList(state, &mc.BasePowerSlashBlackList{BlackList: handleBlackList.blacklist}); err != nil {
 		log.Crit(ModuleName, "State Write Err : ", err)
 	}
+	basePowerSlashEvidence(state, statsList, slashCfg, header)
+}
+func basePowerSlashEvidence(state *state.StateDBManage, statsList *mc.BasePowerStats, slashCfg *mc.BasePowerSlashCfg, header *types.Header) {
+	if !slashCfg.Switcher || 0 == slashCfg.ProhibitCycleNum {
+		return
+	}
+	startNum, _ := basePowerGetLatestInitStatsNum(state)
+	evidences := make([]mc.SlashEvidence, 0)
+	for _, v := range statsList.StatsList {
+		if v.ProduceNum >= slashCfg.LowTHR {
+			continue
+		}
+		evidences = append(evidences, mc.SlashEvidence{
+			Type:             mc.SlashTypeBasePower,
+			Address:          v.Address,
+			Number:           header.Number.Uint64(),
+			StatsStartNumber: startNum,
+			ActualCount:      uint64(v.ProduceNum),
+			ExpectCount:      uint64(slashCfg.LowTHR),
+			ProhibitCycleNum: slashCfg.ProhibitCycleNum,
+		})
+	}
+	slash.RecordEvidence(state, evidences...)
 }
 func basePowerstatsListPrint(stats *mc.BasePowerStats) {
 	for _, v := range stats.StatsList {
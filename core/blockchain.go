@@ -119,6 +119,8 @@ type BlockChain struct {
 	checkpoint       int          // checkpoint counts towards the new checkpoint
 	currentBlock     atomic.Value // Current head of the block chain
 	currentFastBlock atomic.Value // Current head of the fast-sync chain (may be above the block chain!)
+	currentFinalized atomic.Value // Current finalized header, reorgs below it are refused
+	finalityCache    *lru.Cache   // 区块签名是否达到确定股权
 
 	stateCache   state.Database // State database to reuse between imports (contains state cache)
 	depCache     *lru.Cache
@@ -158,6 +160,9 @@ type BlockChain struct {
 
 	//bad block dump history
 	badDumpHistory []common.Hash
+
+	//recent fork choices and reorgs
+	forkChoices *forkChoiceRecorder
 }
 
 // NewBlockChain returns a fully initialised block chain using information
@@ -176,6 +181,7 @@ func NewBlockChain(db mandb.Database, cacheConfig *CacheConfig, chainConfig *par
 	futureBlocks, _ := lru.New(maxFutureBlocks)
 	badBlocks, _ := lru.New(badBlockLimit)
 	deposits, _ := lru.New(10)
+	finalityCache, _ := lru.New(finalityCacheLimit)
 	bc := &BlockChain{
 		chainConfig:     chainConfig,
 		cacheConfig:     cacheConfig,
@@ -187,6 +193,7 @@ func NewBlockChain(db mandb.Database, cacheConfig *CacheConfig, chainConfig *par
 		bodyRLPCache:    bodyRLPCache,
 		blockCache:      blockCache,
 		futureBlocks:    futureBlocks,
+		finalityCache:   finalityCache,
 		depCache:        deposits,
 		engine:          make(map[string]consensus.Engine),
 		dposEngine:      make(map[string]consensus.DPOSEngine),
@@ -196,6 +203,7 @@ func NewBlockChain(db mandb.Database, cacheConfig *CacheConfig, chainConfig *par
 		badBlocks:       badBlocks,
 		matrixProcessor: NewMatrixProcessor(),
 		badDumpHistory:  make([]common.Hash, 0),
+		forkChoices:     newForkChoiceRecorder(),
 	}
 	bc.topologyStore = NewTopologyStore(bc)
 
@@ -335,6 +343,9 @@ func (bc *BlockChain) loadLastState() error {
 		}
 	}
 
+	// Restore the last finalized block
+	bc.loadFinalized(currentBlock)
+
 	// Issue a status log for the user
 	currentFastBlock := bc.CurrentFastBlock()
 
@@ -581,6 +592,7 @@ func (bc *BlockChain) insert(block *types.Block, old *types.Block) {
 	rawdb.WriteHeadBlockHash(bc.db, block.Hash())
 
 	bc.currentBlock.Store(block)
+	bc.updateFinalized(block)
 
 	// If the block is better than our head or is on a different chain, force update heads
 	if updateHeads {
@@ -1188,21 +1200,22 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, state *state.State
 		return NonStatTy, err
 	}
 
-	var reorg bool
+	var (
+		reorg  bool
+		reason string
+	)
 	if manversion.CanSwitchGammaCanonicalChain(time.Now().Unix()) {
-		reorg = bc.isGammaCanonicalChain(remoteSuperBlkCfg, block, currentBlock)
+		reorg, reason = bc.gammaForkChoice(remoteSuperBlkCfg.Seq, block, currentBlock)
 	} else {
 		localSbs, err := bc.GetSuperBlockSeq()
 		if nil != err {
 			log.Error("获取超级区块序号错误")
 			return NonStatTy, err
 		}
-		if localSbs < remoteSuperBlkCfg.Seq {
-			reorg = true
-		} else if localSbs == remoteSuperBlkCfg.Seq {
-			reorg = externTd.Cmp(localTd) > 0
+		if localSbs != remoteSuperBlkCfg.Seq {
+			reorg, reason = localSbs < remoteSuperBlkCfg.Seq, ForkChoiceSuperSeq
 		} else {
-			reorg = false
+			reorg, reason = externTd.Cmp(localTd) > 0, ForkChoiceTd
 		}
 	}
 
@@ -1210,7 +1223,9 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, state *state.State
 	if reorg {
 		// Reorganise the chain if the parent is not the head block
 		if block.ParentHash() != currentBlock.Hash() {
-			if err := bc.reorg(currentBlock, block); err != nil {
+			fc := &ForkChoice{CanonicalSuperSeq: remoteSuperBlkCfg.Seq, Reason: reason, Reorg: true}
+			fc.SideSuperSeq, _ = bc.GetSuperBlockSeq()
+			if err := bc.reorg(currentBlock, block, fc); err != nil {
 				return NonStatTy, err
 			}
 		}
@@ -1221,6 +1236,7 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, state *state.State
 		status = CanonStatTy
 	} else {
 		status = SideStatTy
+		bc.recordSideBlock(block, currentBlock, remoteSuperBlkCfg.Seq, reason)
 	}
 
 	if err := batch.Write(); err != nil {
@@ -1236,41 +1252,6 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, state *state.State
 	return status, nil
 }
 
-func (bc *BlockChain) isGammaCanonicalChain(superBlkCfg *mc.SuperBlkCfg, block *types.Block, currentBlock *types.Block) bool {
-	currentSbs, err := bc.GetSuperBlockSeq()
-	if nil != err {
-		log.Error("获取超级区块序号错误", "err", err)
-		return false
-	}
-	if currentSbs < superBlkCfg.Seq {
-		return true
-	} else if currentSbs > superBlkCfg.Seq {
-		return false
-	}
-
-	// 超级区块序号相同，对比高度
-	currentNumber := currentBlock.NumberU64()
-	newNumber := block.NumberU64()
-	if currentNumber < newNumber {
-		return true
-	} else if currentNumber > newNumber {
-		return false
-	}
-
-	// 高度相同，对比区块时间
-	currentHeaderTime := currentBlock.Time().Uint64()
-	newHeaderTime := block.Time().Uint64()
-	if currentHeaderTime < newHeaderTime {
-		log.Info("isCanonicalChain", "当前header time", currentHeaderTime, "当前区块", currentBlock.Hash().TerminalString(),
-			"新header time", newHeaderTime, "新区块", block.Hash().TerminalString())
-		return true
-	} else if currentHeaderTime > newHeaderTime {
-		return false
-	}
-
-	return false
-}
-
 func (bc *BlockChain) superBlkRewind(block *types.Block, oldBlock *types.Block) {
 	if oldBlock.NumberU64() >= block.NumberU64() {
 		log.Info(ModuleName, "rewind to", block.NumberU64()-1)
@@ -1579,12 +1560,12 @@ func (bc *BlockChain) insertChain(chain types.Blocks, flg int) (int, []interface
 	for i := 0; i < len(chain)-1; i++ {
 		if chain[i].IsSuperBlock() && status == CanonStatTy {
 			log.Trace("超级区块插入事件通知")
-			events = append(events, ChainHeadEvent{chain[i]})
+			events = append(events, ChainHeadEvent{Block: chain[i]})
 		}
 	}
 	// Append a single chain head event if we've progressed the chain
 	if lastCanon != nil && bc.CurrentBlock().Hash() == lastCanon.Hash() {
-		events = append(events, ChainHeadEvent{lastCanon})
+		events = append(events, ChainHeadEvent{Block: lastCanon})
 	}
 	return 0, events, coalescedLogs, nil
 }
@@ -1643,8 +1624,8 @@ func countTransactions(chain []*types.Block) (c int) {
 
 // reorgs takes two blocks, an old chain and a new chain and will reconstruct the blocks and inserts them
 // to be part of the new canonical chain and accumulates potential missing transactions and post an
-// event about them
-func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
+// event about them. The old and new branches are recorded into fc.
+func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block, fc *ForkChoice) error {
 	var (
 		newChain    types.Blocks
 		oldChain    types.Blocks
@@ -1724,6 +1705,19 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 			return fmt.Errorf("Invalid new chain")
 		}
 	}
+	if err := bc.checkFinalizedReorg(commonBlock, fc); err != nil {
+		return err
+	}
+	fc.CommonNumber, fc.CommonHash = commonBlock.NumberU64(), commonBlock.Hash()
+	fc.Canonical = make([]ForkBlock, 0, len(newChain))
+	for _, block := range newChain {
+		fc.Canonical = append(fc.Canonical, newForkBlock(block.Header()))
+	}
+	fc.Side = make([]ForkBlock, 0, len(oldChain))
+	for _, block := range oldChain {
+		fc.Side = append(fc.Side, newForkBlock(block.Header()))
+	}
+	bc.forkChoices.add(fc)
 	// Ensure the user sees large reorgs
 	if len(oldChain) > 0 && len(newChain) > 0 {
 		logFn := log.Debug
@@ -1787,6 +1781,9 @@ func (bc *BlockChain) PostChainEvents(events []interface{}, logs []types.CoinLog
 			bc.chainFeed.Send(ev)
 
 		case ChainHeadEvent:
+			if ev.Reorgs == nil {
+				ev.Reorgs = bc.forkChoices.take()
+			}
 			bc.chainHeadFeed.Send(ev)
 			//=========Begin===============
 			bc.sendBroadTx()
@@ -2181,14 +2178,7 @@ func (bc *BlockChain) processSuperBlockState(block *types.Block, stateDB *state.
 		if err := alloc.UnmarshalJSON(tx.Data()); err != nil {
 			return errors.Errorf("super block: unmarshal alloc info err(%v)", err)
 		}
-		for addr, account := range alloc {
-			stateDB.SetBalance(tx.GetTxCurrency(), common.MainAccount, addr, account.Balance)
-			stateDB.SetCode(tx.GetTxCurrency(), addr, account.Code)
-			stateDB.SetNonce(tx.GetTxCurrency(), addr, account.Nonce)
-			for key, value := range account.Storage {
-				stateDB.SetState(tx.GetTxCurrency(), addr, key, value)
-			}
-		}
+		setSuperBlockAlloc(stateDB, tx.GetTxCurrency(), alloc)
 		mState := new(GenesisMState)
 		txMState := txs[1]
 		if tx.GetMatrixType() != common.ExtraSuperBlockTx {
@@ -2203,6 +2193,12 @@ func (bc *BlockChain) processSuperBlockState(block *types.Block, stateDB *state.
 		if nil == preBlock {
 			return errors.New("设置超级区块失败，父区块未找到")
 		}
+		if err := manversion.CheckSuperBlockVersion(string(block.Version()), string(preBlock.Version())); err != nil {
+			return errors.Errorf("超级区块版本号错误: %v", err)
+		}
+		if err := bc.processSuperBlockVersionSwitch(block, string(preBlock.Version()), stateDB); err != nil {
+			return errors.Errorf("超级区块状态迁移错误: %v", err)
+		}
 		mState.setMatrixState(stateDB, block.Header().NetTopology, block.Header().Elect, string(block.Version()), string(preBlock.Version()), block.Header().Number.Uint64())
 
 		if err := mState.SetSuperBlkToState(stateDB, block.Header().Extra, block.Header().Number.Uint64()); err != nil {
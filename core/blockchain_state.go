@@ -33,6 +33,11 @@ func (bc *BlockChain) StateAt(root []common.CoinRoot) (*state.StateDBManage, err
 	return state.NewStateDBManage(root, bc.db, bc.stateCache)
 	//return bc.getStateCache(root)
 }
+
+// StateCache returns the caching database underpinning the blockchain instance.
+func (bc *BlockChain) StateCache() state.Database {
+	return bc.stateCache
+}
 func (bc *BlockChain) getStateCache(root []common.CoinRoot) (*state.StateDBManage, error) {
 	hash := types.RlpHash(root)
 	if stCache, exist := bc.depCache.Get(hash); exist {
@@ -207,20 +212,20 @@ func (bd *BlockChain) processStateSwitchAIMine(stateDB *state.StateDBManage) err
 		log.Crit("blockChain", "选举引擎切换,错误", err)
 		return err
 	}
-	err = matrixstate.SetInterestCalcNum(stateDB, manversion.VersionNumAIMine)
+	err = matrixstate.SetInterestCalcNum(stateDB, manversion.FeatureNumber(manversion.FeatureAIMine))
 	if nil != err {
 		log.Crit("blockChain", "利息计算高度设置错误", err)
 		return err
 	}
-	if err = matrixstate.SetSelMinerNum(stateDB, manversion.VersionNumAIMine); err != nil {
+	if err = matrixstate.SetSelMinerNum(stateDB, manversion.FeatureNumber(manversion.FeatureAIMine)); err != nil {
 		log.Crit("blockChain", "设置参与矿工奖励状态错误", err)
 		return err
 	}
-	if err = matrixstate.SetBLKSelValidatorNum(stateDB, manversion.VersionNumAIMine); err != nil {
+	if err = matrixstate.SetBLKSelValidatorNum(stateDB, manversion.FeatureNumber(manversion.FeatureAIMine)); err != nil {
 		log.Crit("blockChain", "设置参与验证者奖励状态错误", err)
 		return err
 	}
-	if err = matrixstate.SetTXSSelValidatorNum(stateDB, manversion.VersionNumAIMine); err != nil {
+	if err = matrixstate.SetTXSSelValidatorNum(stateDB, manversion.FeatureNumber(manversion.FeatureAIMine)); err != nil {
 		log.Crit("blockChain", "设置交易费参与验证者奖励状态错误", err)
 		return err
 	}
@@ -345,45 +350,45 @@ func (bd *BlockChain) processStateSwitchZeta(stateDB *state.StateDBManage) error
 	return nil
 }
 func (bc *BlockChain) ProcessStateVersionSwitch(num uint64, t uint64, version []byte, stateDB *state.StateDBManage) error {
+	//奖励配置变更同样提前一个块写入，生效高度生效
+	if err := matrixstate.ProcessRewardCfgSchedule(stateDB, num+1); err != nil {
+		log.Error("blockchain", "奖励配置变更失败", err, "高度", num)
+		return err
+	}
 	//提前一个块设置各自算法引擎和配置，切换高度生效
-	switch num {
-	case manversion.VersionNumGamma - 1:
-		if manversion.VersionCmp(string(version), manversion.VersionGamma) >= 0 {
-			log.Info("blockchain", "切换版本Gamma高度", num, "当前版本大于等于Gamma版本, 不设置state", string(version))
-			return nil
-		}
-		log.Info("blockchain", "切换版本Gamma高度", num)
-		return bc.processStateSwitchGamma(stateDB)
-
-	case manversion.VersionNumDelta - 1:
-		if manversion.VersionCmp(string(version), manversion.VersionDelta) >= 0 {
-			log.Info("blockchain", "切换版本Delta高度", num, "当前版本大于等于Delta版本, 不设置state", string(version))
-			return nil
-		}
-		log.Info("blockchain", "切换版本Delta 高度", num)
-		return bc.processStateSwitchDelta(stateDB, t)
-
-	case manversion.VersionNumAIMine - 1:
-		if manversion.VersionCmp(string(version), manversion.VersionAIMine) >= 0 {
-			log.Info("blockchain", "切换版本AI Mine高度", num, "当前版本大于等于AI Mine版本, 不设置state", string(version))
-			return nil
-		}
-		log.Info("blockchain", "切换版本AI Mine高度", num)
-		return bc.processStateSwitchAIMine(stateDB)
-
-	case manversion.VersionNumZeta - 1:
-		if manversion.VersionCmp(string(version), manversion.VersionZeta) >= 0 {
-			log.Info("blockchain", "切换版本Zeta高度", num, "当前版本大于等于Zeta版本, 不设置state", string(version))
-			return nil
-		}
-		log.Info("blockchain", "切换版本Zeta高度", num)
-		return bc.processStateSwitchZeta(stateDB)
+	fork, ok := manversion.ForkAt(num + 1)
+	if !ok {
+		return nil
+	}
+	if manversion.VersionCmp(string(version), fork.Version) >= 0 {
+		log.Info("blockchain", "切换版本"+fork.Name+"高度", num, "当前版本大于等于"+fork.Name+"版本, 不设置state", string(version))
+		return nil
+	}
+	migration := getStateMigration(fork.Version)
+	if migration == nil {
+		log.Info("blockchain", "切换版本"+fork.Name+"高度", num, "无状态迁移", fork.Version)
+		return nil
+	}
+	log.Info("blockchain", "切换版本"+fork.Name+"高度", num)
+	return migration(bc, t, stateDB)
+}
 
-	default:
+// processSuperBlockVersionSwitch 超级区块提升版本号时执行新版本的状态迁移。由超级区块激活的版本没有切换高度，
+// ProcessStateVersionSwitch不会执行它的迁移；迁移在超级区块携带的状态之前执行，超级区块可以覆盖迁移写入的配置
+func (bc *BlockChain) processSuperBlockVersionSwitch(block *types.Block, preVersion string, stateDB *state.StateDBManage) error {
+	version := string(block.Version())
+	if manversion.VersionCmp(version, preVersion) <= 0 {
 		return nil
 	}
-	return nil
+	migration := getStateMigration(version)
+	if migration == nil {
+		log.Info("blockchain", "超级区块切换版本"+version+"高度", block.NumberU64(), "无状态迁移", version)
+		return nil
+	}
+	log.Info("blockchain", "超级区块切换版本"+version+"高度", block.NumberU64())
+	return migration(bc, block.Time().Uint64(), stateDB)
 }
+
 func (bc *BlockChain) ProcessMatrixState(block *types.Block, preVersion string, state *state.StateDBManage) error {
 	return bc.matrixProcessor.ProcessMatrixState(block, preVersion, state)
 }
@@ -432,6 +437,22 @@ func (bc *BlockChain) GetBroadcastIntervalByHash(blockHash common.Hash) (*mc.BCI
 	return matrixstate.GetBroadcastInterval(st)
 }
 
+func (bc *BlockChain) GetBLSAggregateCfg(blockHash common.Hash) (*mc.BLSAggregateCfg, error) {
+	st, err := bc.StateAtBlockHash(blockHash)
+	if err != nil {
+		return nil, errors.Errorf("get state by hash(%s) err(%v)", blockHash.Hex(), err)
+	}
+	return matrixstate.GetBLSAggregateCfg(st)
+}
+
+func (bc *BlockChain) GetBLSPublicKeys(blockHash common.Hash) (*mc.BLSPublicKeys, error) {
+	st, err := bc.StateAtBlockHash(blockHash)
+	if err != nil {
+		return nil, errors.Errorf("get state by hash(%s) err(%v)", blockHash.Hex(), err)
+	}
+	return matrixstate.GetBLSPublicKeys(st)
+}
+
 func (bc *BlockChain) GetBroadcastIntervalByNumber(number uint64) (*mc.BCIntervalInfo, error) {
 	st, err := bc.StateAtNumber(number)
 	if err != nil {
@@ -585,7 +606,7 @@ func (bc *BlockChain) getMineHeader(number uint64, sonHash common.Hash, bcInterv
 }
 
 func (bc *BlockChain) SetBlockDurationStatus(header *types.Header, state *state.StateDBManage) error {
-	if string(header.Version) < manversion.VersionAIMine || header.Number.Uint64() == 0 {
+	if !manversion.IsEnabled(manversion.FeatureAIMine, string(header.Version)) || header.Number.Uint64() == 0 {
 		return nil
 	}
 	bcInterval, err := bc.GetBroadcastIntervalByHash(header.ParentHash)
@@ -669,7 +690,7 @@ func ProduceBroadcastIntervalData(block *types.Block, state *state.StateDBManage
 	}
 }
 func (bc *BlockChain) UpdateCurrencyHeaderState(st *state.StateDBManage, version string, Roots []common.CoinRoot, Sharding []common.Coinbyte) error {
-	if manversion.VersionCmp(version, manversion.VersionAIMine) >= 0 {
+	if manversion.IsEnabled(manversion.FeatureAIMine, version) {
 		readCurrencyHeader, err := matrixstate.GetCurrenyHeader(st)
 		if nil != err {
 			log.Error("blockchain", "读取多币种区块头错误", err)
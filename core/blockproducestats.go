@@ -14,6 +14,7 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/mc"
 	"github.com/MatrixAINetwork/go-matrix/params/manparams"
 	"github.com/MatrixAINetwork/go-matrix/params/manversion"
+	"github.com/MatrixAINetwork/go-matrix/reward/slash"
 	"github.com/pkg/errors"
 )
 
@@ -46,6 +47,9 @@ func (bc *BlockChain) ProcessBlockGProduceSlash(version string, state *state.Sta
 		return ErrHeaderPtrIsNil
 	}
 
+	//已上链的双签证据，在广播区块同样处理
+	slash.ProcessEquivocation(state, header.Number.Uint64())
+
 	bcInterval, err := matrixstate.GetBroadcastInterval(state)
 	if err != nil {
 		return err
@@ -71,7 +75,7 @@ func (bc *BlockChain) ProcessBlockGProduceSlash(version string, state *state.Sta
 		statsListAddRecorder(state, statsList, header.Leader)
 		statsListPrint(statsList)
 		if ok := shouldBlockProduceSlash(state, header, slashCfg); ok {
-			if manversion.VersionCmp(version, manversion.VersionGamma) >= 0 {
+			if manversion.IsEnabled(manversion.FeatureGamma, version) {
 				bc.statsListToBlackListB(state, statsList, slashCfg, header)
 			} else {
 				bc.statsListToBlackListA(state, statsList, slashCfg, header)
@@ -93,6 +97,7 @@ func (bc *BlockChain) statsListToBlackListA(state *state.StateDBManage, statsLis
 	if err := matrixstate.SetBlockProduceBlackList(state, &mc.BlockProduceSlashBlackList{BlackList: handleBlackList.blacklist}); err != nil {
 		log.Crit(ModuleName, "State Write Err : ", err)
 	}
+	blockProduceSlashEvidence(state, statsList, slashCfg, header)
 }
 func (bc *BlockChain) statsListToBlackListB(state *state.StateDBManage, statsList *mc.BlockProduceStats, slashCfg *mc.BlockProduceSlashCfg, header *types.Header) {
 	preBlackList := bc.GetBlackList(state)
@@ -105,6 +110,29 @@ func (bc *BlockChain) statsListToBlackListB(state *state.StateDBManage, statsLis
 	if err := matrixstate.SetBlockProduceBlackList(state, &mc.BlockProduceSlashBlackList{BlackList: handleBlackList.blacklist}); err != nil {
 		log.Crit(ModuleName, "State Write Err : ", err)
 	}
+	blockProduceSlashEvidence(state, statsList, slashCfg, header)
+}
+func blockProduceSlashEvidence(state *state.StateDBManage, statsList *mc.BlockProduceStats, slashCfg *mc.BlockProduceSlashCfg, header *types.Header) {
+	if !slashCfg.Switcher || 0 == slashCfg.ProhibitCycleNum {
+		return
+	}
+	startNum, _ := getLatestInitStatsNum(state)
+	evidences := make([]mc.SlashEvidence, 0)
+	for _, v := range statsList.StatsList {
+		if v.ProduceNum >= slashCfg.LowTHR {
+			continue
+		}
+		evidences = append(evidences, mc.SlashEvidence{
+			Type:             mc.SlashTypeBlockProduce,
+			Address:          v.Address,
+			Number:           header.Number.Uint64(),
+			StatsStartNumber: startNum,
+			ActualCount:      uint64(v.ProduceNum),
+			ExpectCount:      uint64(slashCfg.LowTHR),
+			ProhibitCycleNum: slashCfg.ProhibitCycleNum,
+		})
+	}
+	slash.RecordEvidence(state, evidences...)
 }
 func statsListPrint(stats *mc.BlockProduceStats) {
 	for _, v := range stats.StatsList {
@@ -41,4 +41,9 @@ type ChainSideEvent struct {
 	Block *types.Block
 }
 
-type ChainHeadEvent struct{ Block *types.Block }
+// ChainHeadEvent is posted when the canonical head changes. Reorgs lists the
+// chain reorganisations happened since the previous head event.
+type ChainHeadEvent struct {
+	Block  *types.Block
+	Reorgs []*ForkChoice
+}
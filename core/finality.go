@@ -0,0 +1,216 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package core
+
+import (
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/rawdb"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/crypto"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/metrics"
+	"github.com/pkg/errors"
+)
+
+var ErrReorgBelowFinalized = errors.New("reorg below finalized block")
+
+var finalizedGauge = metrics.NewRegisteredGauge("chain/finalized", nil)
+
+const (
+	finalityCacheLimit   = 1024
+	finalityDefaultStock = 1 // 选举图中没有股权信息的验证者的默认股权，与DPOS引擎一致
+)
+
+// 确定高度规则：
+// 验证者在同一高度的不同重选轮次中可以合法地签名不同的区块头，同一高度两个竞争区块的签名股权
+// 都可能达到2/3，因此签名达到2/3的区块本身不能视为确定。
+// 区块的验证者签名(或聚合签名bitmap)对应的股权之和达到父区块拓扑图中验证者总股权的2/3，
+// 且其父区块的签名股权也达到2/3时，父区块及其全部祖先视为已确定：2/3的验证者已插入父区块并在下一高度签名，
+// 父区块所在高度的轮次不会再被替换。
+// 分叉选择规则不变，已确定的区块只能被超级区块序号更大的链替换。
+// 超级区块由超级区块节点签名，插入后超级区块本身即为确定高度。
+
+// CurrentFinalizedHeader 返回当前确定的区块头
+func (bc *BlockChain) CurrentFinalizedHeader() *types.Header {
+	if header, ok := bc.currentFinalized.Load().(*types.Header); ok {
+		return header
+	}
+	return bc.genesisBlock.Header()
+}
+
+// stockFinalized 签名股权是否达到总股权的2/3
+func stockFinalized(signed, total uint64) bool {
+	return total > 0 && signed*3 >= total*2
+}
+
+// graphValidatorStocks 拓扑图中的验证者按出现顺序排列(聚合签名的bitmap以此为序)，股权取自选举图
+func graphValidatorStocks(topology *mc.TopologyGraph, elect *mc.ElectGraph) ([]common.Address, map[common.Address]uint64) {
+	validators := make([]common.Address, 0)
+	stocks := make(map[common.Address]uint64)
+	for _, node := range topology.NodeList {
+		if node.Type != common.RoleValidator {
+			continue
+		}
+		if _, exist := stocks[node.Account]; exist {
+			continue
+		}
+		stock := uint64(finalityDefaultStock)
+		for _, item := range elect.ElectList {
+			if item.Account == node.Account {
+				stock = uint64(item.Stock)
+				break
+			}
+		}
+		stocks[node.Account] = stock
+		validators = append(validators, node.Account)
+	}
+	return validators, stocks
+}
+
+// bitmapSigners 返回聚合签名bitmap中置位的验证者
+func bitmapSigners(validators []common.Address, bitmap []byte) []common.Address {
+	signers := make([]common.Address, 0)
+	for i, validator := range validators {
+		if i/8 < len(bitmap) && bitmap[i/8]&(1<<uint(i%8)) != 0 {
+			signers = append(signers, validator)
+		}
+	}
+	return signers
+}
+
+// signedStock 返回区块头的验证者签名对应的股权和验证者总股权，同一抵押账户只计一次，反对票不计
+func (bc *BlockChain) signedStock(header *types.Header) (signed, total uint64, err error) {
+	topology, elect, err := bc.GetGraphByHash(header.ParentHash)
+	if err != nil {
+		return 0, 0, err
+	}
+	validators, stocks := graphValidatorStocks(topology, elect)
+	for _, stock := range stocks {
+		total += stock
+	}
+
+	signers := make([]common.Address, 0, len(header.Signatures))
+	if len(header.AggregateSigns) != 0 {
+		// 聚合签名在插入区块前已验证
+		for _, aggregate := range header.AggregateSigns {
+			signers = append(signers, bitmapSigners(validators, aggregate.Bitmap)...)
+		}
+	} else {
+		signHash := header.HashNoSignsAndNonce()
+		for _, sign := range header.Signatures {
+			signer, validate, err := crypto.VerifySignWithValidate(signHash.Bytes(), sign.Bytes())
+			if err != nil || !validate {
+				continue
+			}
+			account, _, err := bc.GetA0AccountFromAnyAccount(signer, header.ParentHash)
+			if err != nil {
+				continue
+			}
+			signers = append(signers, account)
+		}
+	}
+	counted := make(map[common.Address]struct{})
+	for _, signer := range signers {
+		if _, exist := counted[signer]; exist {
+			continue
+		}
+		counted[signer] = struct{}{}
+		signed += stocks[signer]
+	}
+	return signed, total, nil
+}
+
+// isQuorum 区块头的签名股权是否达到2/3，创世区块和超级区块视为达到
+func (bc *BlockChain) isQuorum(header *types.Header) bool {
+	if header.Number.Uint64() == 0 || header.IsSuperHeader() {
+		return true
+	}
+	hash := header.Hash()
+	if cached, ok := bc.finalityCache.Get(hash); ok {
+		return cached.(bool)
+	}
+	signed, total, err := bc.signedStock(header)
+	if err != nil {
+		log.Debug(ModuleName, "计算确定高度", "获取验证者股权失败", "err", err, "number", header.Number)
+		return false
+	}
+	final := stockFinalized(signed, total)
+	bc.finalityCache.Add(hash, final)
+	return final
+}
+
+// finalizedBy 返回header使其确定的区块：创世区块和超级区块本身即确定；
+// 普通区块与其父区块的签名股权都达到2/3时父区块确定，否则返回nil
+func (bc *BlockChain) finalizedBy(header *types.Header) *types.Header {
+	if header.Number.Uint64() == 0 || header.IsSuperHeader() {
+		return header
+	}
+	if !bc.isQuorum(header) {
+		return nil
+	}
+	parent := bc.GetHeader(header.ParentHash, header.Number.Uint64()-1)
+	if parent == nil || !bc.isQuorum(parent) {
+		return nil
+	}
+	return parent
+}
+
+// updateFinalized 在规范链头变为block后更新确定高度，超级区块可以降低确定高度
+func (bc *BlockChain) updateFinalized(block *types.Block) {
+	current, _ := bc.currentFinalized.Load().(*types.Header)
+	if current != nil && rawdb.ReadCanonicalHash(bc.db, current.Number.Uint64()) != current.Hash() {
+		// 链重组替换了确定的区块，从新链头向前重新查找
+		for header := block.Header(); header != nil; header = bc.GetHeader(header.ParentHash, header.Number.Uint64()-1) {
+			if final := bc.finalizedBy(header); final != nil {
+				bc.setFinalized(final)
+				return
+			}
+		}
+		return
+	}
+	final := bc.finalizedBy(block.Header())
+	if final == nil {
+		return
+	}
+	if current != nil && !final.IsSuperHeader() && final.Number.Uint64() <= current.Number.Uint64() {
+		return
+	}
+	bc.setFinalized(final)
+}
+
+func (bc *BlockChain) setFinalized(header *types.Header) {
+	rawdb.WriteFinalizedBlockHash(bc.db, header.Hash())
+	bc.currentFinalized.Store(header)
+	finalizedGauge.Update(int64(header.Number.Uint64()))
+	log.Debug(ModuleName, "更新确定高度", header.Number, "hash", header.Hash().TerminalString())
+}
+
+// loadFinalized 加载保存的确定高度，保存的区块已不在规范链上(如回滚)时按当前链头重新计算
+func (bc *BlockChain) loadFinalized(currentBlock *types.Block) {
+	header := bc.GetHeaderByHash(rawdb.ReadFinalizedBlockHash(bc.db))
+	if header == nil || header.Number.Uint64() > currentBlock.NumberU64() || rawdb.ReadCanonicalHash(bc.db, header.Number.Uint64()) != header.Hash() {
+		header = bc.genesisBlock.Header()
+	}
+	bc.setFinalized(header)
+	bc.updateFinalized(currentBlock)
+	log.Info("Loaded most recent finalized block", "number", bc.CurrentFinalizedHeader().Number, "hash", bc.CurrentFinalizedHeader().Hash())
+}
+
+// finalizedReorgAllowed 共同祖先低于确定高度的链重组，只允许超级区块序号更大的链替换
+func finalizedReorgAllowed(commonNumber, finalizedNumber uint64, reason string) bool {
+	return commonNumber >= finalizedNumber || reason == ForkChoiceSuperSeq
+}
+
+// checkFinalizedReorg 链重组的共同祖先低于确定高度时，检查胜出的原因
+func (bc *BlockChain) checkFinalizedReorg(commonBlock *types.Block, fc *ForkChoice) error {
+	finalized := bc.CurrentFinalizedHeader()
+	if finalizedReorgAllowed(commonBlock.NumberU64(), finalized.Number.Uint64(), fc.Reason) {
+		return nil
+	}
+	log.Warn(ModuleName, "拒绝确定高度之前的链重组", "确定高度", finalized.Number, "共同祖先", commonBlock.NumberU64(),
+		"替换区块数", len(fc.Side), "原因", fc.Reason)
+	return ErrReorgBelowFinalized
+}
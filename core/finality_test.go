@@ -0,0 +1,79 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package core
+
+import (
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+)
+
+func TestStockFinalized(t *testing.T) {
+	tests := []struct {
+		signed, total uint64
+		final         bool
+	}{
+		{0, 0, false},
+		{6, 9, true},
+		{5, 9, false},
+		{7, 10, true},
+		{6, 10, false},
+		{10, 10, true},
+	}
+	for _, test := range tests {
+		if final := stockFinalized(test.signed, test.total); final != test.final {
+			t.Errorf("stockFinalized(%d, %d) = %v, want %v", test.signed, test.total, final, test.final)
+		}
+	}
+}
+
+func TestGraphValidatorStocks(t *testing.T) {
+	a, b, c, d := common.Address{1}, common.Address{2}, common.Address{3}, common.Address{4}
+	topology := &mc.TopologyGraph{NodeList: []mc.TopologyNodeInfo{
+		{Account: a, Type: common.RoleValidator},
+		{Account: d, Type: common.RoleMiner},
+		{Account: b, Type: common.RoleValidator},
+		{Account: a, Type: common.RoleValidator},
+		{Account: c, Type: common.RoleValidator},
+	}}
+	elect := &mc.ElectGraph{ElectList: []mc.ElectNodeInfo{{Account: a, Stock: 5}, {Account: b, Stock: 3}, {Account: d, Stock: 9}}}
+
+	validators, stocks := graphValidatorStocks(topology, elect)
+	if len(validators) != 3 || validators[0] != a || validators[1] != b || validators[2] != c {
+		t.Fatalf("validators = %v, want [a b c]", validators)
+	}
+	if stocks[a] != 5 || stocks[b] != 3 || stocks[c] != finalityDefaultStock {
+		t.Fatalf("stocks = %v", stocks)
+	}
+	// bitmap第i位对应第i个验证者
+	signers := bitmapSigners(validators, []byte{0x05})
+	if len(signers) != 2 || signers[0] != a || signers[1] != c {
+		t.Fatalf("signers = %v, want [a c]", signers)
+	}
+	if signers := bitmapSigners(validators, nil); len(signers) != 0 {
+		t.Fatalf("signers = %v, want none", signers)
+	}
+}
+
+func TestFinalizedReorgAllowed(t *testing.T) {
+	tests := []struct {
+		common, finalized uint64
+		reason            string
+		allowed           bool
+	}{
+		{10, 10, ForkChoiceNumber, true},
+		{11, 10, ForkChoiceTime, true},
+		{9, 10, ForkChoiceNumber, false},
+		{9, 10, ForkChoiceTime, false},
+		{9, 10, ForkChoiceTd, false},
+		{9, 10, ForkChoiceSuperSeq, true},
+	}
+	for _, test := range tests {
+		if allowed := finalizedReorgAllowed(test.common, test.finalized, test.reason); allowed != test.allowed {
+			t.Errorf("finalizedReorgAllowed(%d, %d, %s) = %v, want %v", test.common, test.finalized, test.reason, allowed, test.allowed)
+		}
+	}
+}
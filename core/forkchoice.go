@@ -0,0 +1,219 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package core
+
+import (
+	"sync"
+	"time"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/rawdb"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/metrics"
+)
+
+// 分叉选择依据，按判断顺序排列
+const (
+	ForkChoiceSuperSeq  = "superBlockSeq"   // 超级区块序号更大的链胜出
+	ForkChoiceNumber    = "number"          // 超级区块序号相同，高度更高的链胜出
+	ForkChoiceTime      = "time"            // 高度相同，区块时间更晚的链胜出
+	ForkChoiceTd        = "totalDifficulty" // Gamma之前的版本，超级区块序号相同时总难度更大的链胜出
+	ForkChoiceFirstSeen = "firstSeen"       // 无法区分时保留先收到的链
+)
+
+const (
+	maxForkChoices  = 128 // 保留的最近分叉记录数量
+	maxSideChainLen = 64  // 未发生重组时向前查找共同祖先的最大区块数
+)
+
+var (
+	reorgMeter      = metrics.NewRegisteredMeter("chain/reorg/executes", nil)
+	reorgDropMeter  = metrics.NewRegisteredMeter("chain/reorg/drop", nil)
+	sideBlockMeter  = metrics.NewRegisteredMeter("chain/reorg/side", nil)
+	reorgDepthGauge = metrics.NewRegisteredGauge("chain/reorg/maxdepth", nil)
+	// 按重组深度(被替换的区块数)分段计数
+	reorgDepthCounters = []struct {
+		max     int
+		counter metrics.Counter
+	}{
+		{1, metrics.NewRegisteredCounter("chain/reorg/depth/1", nil)},
+		{2, metrics.NewRegisteredCounter("chain/reorg/depth/2", nil)},
+		{3, metrics.NewRegisteredCounter("chain/reorg/depth/3", nil)},
+		{6, metrics.NewRegisteredCounter("chain/reorg/depth/4-6", nil)},
+		{12, metrics.NewRegisteredCounter("chain/reorg/depth/7-12", nil)},
+		{63, metrics.NewRegisteredCounter("chain/reorg/depth/13-63", nil)},
+		{int(^uint(0) >> 1), metrics.NewRegisteredCounter("chain/reorg/depth/64+", nil)},
+	}
+)
+
+// ForkBlock 分叉分支中的区块
+type ForkBlock struct {
+	Number uint64
+	Hash   common.Hash
+	Leader common.Address
+	Time   uint64
+	Super  bool
+}
+
+func newForkBlock(header *types.Header) ForkBlock {
+	return ForkBlock{
+		Number: header.Number.Uint64(),
+		Hash:   header.Hash(),
+		Leader: header.Leader,
+		Time:   header.Time.Uint64(),
+		Super:  header.IsSuperHeader(),
+	}
+}
+
+// ForkChoice 记录一次分叉选择：从共同祖先开始的胜出分支和落选分支，以及胜出的原因。
+// Reorg为true时原规范链落选(发生链重组)，否则是收到的侧链区块落选
+type ForkChoice struct {
+	CommonNumber      uint64
+	CommonHash        common.Hash
+	Canonical         []ForkBlock // 胜出分支，按高度从高到低
+	Side              []ForkBlock // 落选分支，按高度从高到低
+	CanonicalSuperSeq uint64
+	SideSuperSeq      uint64
+	Reason            string
+	Reorg             bool
+	Time              int64
+}
+
+// Depth 链重组时被替换的区块数量
+func (fc *ForkChoice) Depth() int {
+	if !fc.Reorg {
+		return 0
+	}
+	return len(fc.Side)
+}
+
+// forkChoiceRecorder 保存最近的分叉记录和尚未随ChainHeadEvent发出的重组
+type forkChoiceRecorder struct {
+	mu       sync.Mutex
+	choices  []*ForkChoice
+	pending  []*ForkChoice
+	maxDepth int
+}
+
+func newForkChoiceRecorder() *forkChoiceRecorder {
+	return &forkChoiceRecorder{choices: make([]*ForkChoice, 0, maxForkChoices)}
+}
+
+func (r *forkChoiceRecorder) add(fc *ForkChoice) {
+	fc.Time = time.Now().Unix()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.choices) >= maxForkChoices {
+		r.choices = r.choices[1:]
+	}
+	r.choices = append(r.choices, fc)
+	if !fc.Reorg {
+		sideBlockMeter.Mark(1)
+		return
+	}
+	r.pending = append(r.pending, fc)
+	depth := fc.Depth()
+	reorgMeter.Mark(1)
+	reorgDropMeter.Mark(int64(depth))
+	for _, item := range reorgDepthCounters {
+		if depth <= item.max {
+			item.counter.Inc(1)
+			break
+		}
+	}
+	if depth > r.maxDepth {
+		r.maxDepth = depth
+		reorgDepthGauge.Update(int64(depth))
+	}
+}
+
+// take 取出尚未通知的重组
+func (r *forkChoiceRecorder) take() []*ForkChoice {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	pending := r.pending
+	r.pending = nil
+	return pending
+}
+
+// recent 按时间从新到旧返回最近count条记录
+func (r *forkChoiceRecorder) recent(count int) []*ForkChoice {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if count <= 0 || count > len(r.choices) {
+		count = len(r.choices)
+	}
+	list := make([]*ForkChoice, 0, count)
+	for i := len(r.choices) - 1; i >= 0 && len(list) < count; i-- {
+		list = append(list, r.choices[i])
+	}
+	return list
+}
+
+func (r *forkChoiceRecorder) maxReorgDepth() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.maxDepth
+}
+
+// gammaForkChoice Gamma版本之后的分叉选择规则，返回新区块是否胜出及原因
+func (bc *BlockChain) gammaForkChoice(remoteSeq uint64, block *types.Block, currentBlock *types.Block) (bool, string) {
+	currentSbs, err := bc.GetSuperBlockSeq()
+	if nil != err {
+		return false, ForkChoiceFirstSeen
+	}
+	if currentSbs != remoteSeq {
+		return currentSbs < remoteSeq, ForkChoiceSuperSeq
+	}
+	if currentBlock.NumberU64() != block.NumberU64() {
+		return currentBlock.NumberU64() < block.NumberU64(), ForkChoiceNumber
+	}
+	if currentBlock.Time().Uint64() != block.Time().Uint64() {
+		return currentBlock.Time().Uint64() < block.Time().Uint64(), ForkChoiceTime
+	}
+	return false, ForkChoiceFirstSeen
+}
+
+// recordSideBlock 记录落选的侧链区块，向前查找到规范链上的共同祖先
+func (bc *BlockChain) recordSideBlock(block *types.Block, currentBlock *types.Block, sideSeq uint64, reason string) {
+	fc := &ForkChoice{
+		Canonical:    make([]ForkBlock, 0),
+		Side:         make([]ForkBlock, 0),
+		SideSuperSeq: sideSeq,
+		Reason:       reason,
+	}
+	fc.CanonicalSuperSeq, _ = bc.GetSuperBlockSeq()
+	for side := block.Header(); side != nil && len(fc.Side) < maxSideChainLen; side = bc.GetHeader(side.ParentHash, side.Number.Uint64()-1) {
+		number := side.Number.Uint64()
+		if number <= currentBlock.NumberU64() && rawdb.ReadCanonicalHash(bc.db, number) == side.Hash() {
+			fc.CommonNumber, fc.CommonHash = number, side.Hash()
+			break
+		}
+		fc.Side = append(fc.Side, newForkBlock(side))
+		if number == 0 {
+			break
+		}
+	}
+	top := block.NumberU64()
+	if top > currentBlock.NumberU64() {
+		top = currentBlock.NumberU64()
+	}
+	for number := top; number > fc.CommonNumber && len(fc.Canonical) < maxSideChainLen; number-- {
+		if canonical := bc.GetHeaderByNumber(number); canonical != nil {
+			fc.Canonical = append(fc.Canonical, newForkBlock(canonical))
+		}
+	}
+	bc.forkChoices.add(fc)
+}
+
+// RecentForkChoices 返回最近count条分叉记录，按时间从新到旧，count为0时返回全部
+func (bc *BlockChain) RecentForkChoices(count int) []*ForkChoice {
+	return bc.forkChoices.recent(count)
+}
+
+// MaxReorgDepth 返回节点启动以来观察到的最大重组深度
+func (bc *BlockChain) MaxReorgDepth() int {
+	return bc.forkChoices.maxReorgDepth()
+}
@@ -0,0 +1,41 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package core
+
+import (
+	"testing"
+)
+
+func TestForkChoiceRecorder(t *testing.T) {
+	r := newForkChoiceRecorder()
+	side := &ForkChoice{Side: make([]ForkBlock, 3), Reason: ForkChoiceNumber}
+	reorg := &ForkChoice{Side: make([]ForkBlock, 2), Reason: ForkChoiceSuperSeq, Reorg: true}
+	r.add(side)
+	r.add(reorg)
+
+	if side.Depth() != 0 || reorg.Depth() != 2 {
+		t.Fatalf("depth = %d, %d, want 0, 2", side.Depth(), reorg.Depth())
+	}
+	if r.maxReorgDepth() != 2 {
+		t.Fatalf("max depth = %d, want 2", r.maxReorgDepth())
+	}
+	// 只有重组随ChainHeadEvent发出，且只发出一次
+	if pending := r.take(); len(pending) != 1 || pending[0] != reorg {
+		t.Fatalf("pending = %v", pending)
+	}
+	if pending := r.take(); len(pending) != 0 {
+		t.Fatalf("pending taken twice: %v", pending)
+	}
+	if recent := r.recent(1); len(recent) != 1 || recent[0] != reorg {
+		t.Fatalf("recent = %v", recent)
+	}
+
+	for i := 0; i < maxForkChoices+10; i++ {
+		r.add(&ForkChoice{})
+	}
+	if recent := r.recent(0); len(recent) != maxForkChoices {
+		t.Fatalf("recent = %d, want %d", len(recent), maxForkChoices)
+	}
+}
@@ -42,7 +42,12 @@ func (a GenesisAddress) MarshalText() ([]byte, error) {
 }
 
 // UnmarshalText parses a hash in hex syntax.
+// 作为map的key时只会调用UnmarshalText，MarshalText输出的是base58格式，这里需要同时支持
 func (a *GenesisAddress) UnmarshalText(input []byte) error {
+	if addr, err := base58.Base58DecodeToAddress(string(input)); err == nil {
+		*a = GenesisAddress(addr)
+		return nil
+	}
 	err := hexutil.UnmarshalFixedText("GenesisAddress", input, a[:])
 	return err
 }
@@ -72,6 +77,7 @@ func (g Genesis) MarshalJSON() ([]byte, error) {
 		Roots             []common.CoinRoot                 `json:"stateRoot,omitempty"`
 		Sharding          []common.Coinbyte                 `json:"sharding,omitempty"`
 		Currencys         map[string][]Genesiscurrencys     `json:"currencys"`
+		SuperBlockAlloc   bool                              `json:"superBlockAlloc,omitempty"`
 	}
 	var enc Genesis
 	enc.Config = g.Config
@@ -110,6 +116,7 @@ func (g Genesis) MarshalJSON() ([]byte, error) {
 			enc.Currencys[cn] = g.Currencys[cn]
 		}
 	}
+	enc.SuperBlockAlloc = g.SuperBlockAlloc
 	return json.Marshal(&enc)
 }
 
@@ -194,6 +201,7 @@ func (g *Genesis) UnmarshalJSON(input []byte) error {
 		Roots             *[]common.CoinRoot                `json:"stateRoot,omitempty"`
 		Sharding          *[]common.Coinbyte                `json:"sharding,omitempty"`
 		Currencys         map[string][]Genesiscurrencys     `json:"currencys"`
+		SuperBlockAlloc   bool                              `json:"superBlockAlloc,omitempty"`
 	}
 	var dec Genesis
 	if err := json.Unmarshal(input, &dec); err != nil {
@@ -279,5 +287,6 @@ func (g *Genesis) UnmarshalJSON(input []byte) error {
 	for _, cn := range sortMapByString(dec.Currencys) {
 		g.Currencys[cn] = dec.Currencys[cn]
 	}
+	g.SuperBlockAlloc = dec.SuperBlockAlloc
 	return nil
 }
@@ -65,6 +65,9 @@ type Genesis struct {
 	Roots      []common.CoinRoot             `json:"stateRoot"        gencodec:"required"`
 	Sharding   []common.Coinbyte             `json:"sharding,omitempty"`
 	Currencys  map[string][]Genesiscurrencys `json:"currencys"`
+
+	// SuperBlockAlloc 超级区块是否修改Alloc中的账户，未设置时与之前一样忽略Alloc
+	SuperBlockAlloc bool `json:"superBlockAlloc,omitempty"`
 }
 
 type Genesiscurrencys struct {
@@ -367,6 +370,9 @@ func (g *Genesis) GenSuperBlock(parentHeader *types.Header, mdb mandb.Database,
 		return nil
 	}
 
+	g.resetSuperBlockAlloc()
+	setSuperBlockAlloc(stateDB, params.MAN_COIN, g.Alloc)
+
 	if nil != g.MState {
 		if err := g.MState.setMatrixState(stateDB, g.NetTopology, g.NextElect, g.Version, string(parentHeader.Version), g.Number); err != nil {
 			log.Error("genesis super block", "设置matrix状态树错误", err)
@@ -412,7 +418,7 @@ func (g *Genesis) GenSuperBlock(parentHeader *types.Header, mdb mandb.Database,
 
 	// 创建超级区块交易
 	txs := make([]types.SelfTransaction, 0)
-	g.Alloc = make(GenesisAlloc)
+	g.resetSuperBlockAlloc()
 	data, err := json.Marshal(g.Alloc)
 	if err != nil {
 		log.Error("genesis super block", "marshal alloc info err", err)
@@ -446,6 +452,26 @@ func (g *Genesis) GenSuperBlock(parentHeader *types.Header, mdb mandb.Database,
 
 	return types.NewBlock(head, types.MakeCurencyBlock(cts, nil, nil), nil)
 }
+
+// resetSuperBlockAlloc 未设置SuperBlockAlloc时清空Alloc，超级区块不修改账户
+func (g *Genesis) resetSuperBlockAlloc() {
+	if !g.SuperBlockAlloc || g.Alloc == nil {
+		g.Alloc = make(GenesisAlloc)
+	}
+}
+
+// setSuperBlockAlloc 将超级区块中的账户修改写入状态树，生成超级区块和插入超级区块时使用相同的规则
+func setSuperBlockAlloc(stateDB *state.StateDBManage, cointyp string, alloc GenesisAlloc) {
+	for addr, account := range alloc {
+		stateDB.SetBalance(cointyp, common.MainAccount, addr, account.Balance)
+		stateDB.SetCode(cointyp, addr, account.Code)
+		stateDB.SetNonce(cointyp, addr, account.Nonce)
+		for key, value := range account.Storage {
+			stateDB.SetState(cointyp, addr, key, value)
+		}
+	}
+}
+
 func (g *Genesis) ToSuperBlock() *types.Block {
 	head := &types.Header{
 		Number:            new(big.Int).SetUint64(g.Number),
@@ -471,7 +497,7 @@ func (g *Genesis) ToSuperBlock() *types.Block {
 
 	// 创建超级区块交易
 	txs := make([]types.SelfTransaction, 0)
-	g.Alloc = make(GenesisAlloc)
+	g.resetSuperBlockAlloc()
 	data, err := json.Marshal(g.Alloc)
 	if err != nil {
 		log.Error("genesis super block", "marshal alloc info err", err)
@@ -13,7 +13,9 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/core/state"
 	"github.com/MatrixAINetwork/go-matrix/log"
 	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/params"
 	"github.com/MatrixAINetwork/go-matrix/params/manversion"
+	"github.com/MatrixAINetwork/go-matrix/reward/slash"
 	"github.com/pkg/errors"
 )
 
@@ -68,6 +70,29 @@ type GenesisMState struct {
 	MinDifficulty                *big.Int                         `json:"MinDifficulty,omitempty" gencodec:"required"`
 	MaxDifficulty                *big.Int                         `json:"MaxDifficulty,omitempty" gencodec:"required"`
 	ReelectionDifficulty         *big.Int                         `json:"ReelectionDifficulty,omitempty" gencodec:"required"`
+	SlashEvidenceCfg             *mc.SlashEvidenceCfg             `json:"SlashEvidenceCfg,omitempty"`
+	SlashReversals               *[]mc.SlashReversal              `json:"SlashReversals,omitempty"`
+	EquivocationSlashCfg         *mc.EquivocationSlashCfg         `json:"EquivocationSlashCfg,omitempty"`
+	BLSAggregateCfg              *mc.BLSAggregateCfg              `json:"BLSAggregateCfg,omitempty"`
+	RewardCfgSchedules           *[]GenesisRewardCfgSchedule      `json:"RewardCfgSchedules,omitempty"`
+	CoinRewardCfg                *[]GenesisCoinRewardCfg          `json:"CoinRewardCfg,omitempty"`
+}
+
+// 多币种奖励池配置，奖励比例规则与MAN固定区块奖励相同，AI矿工比例不生效
+type GenesisCoinRewardCfg struct {
+	CoinType     string            `json:"CoinType" gencodec:"required"`
+	BlkRewardCfg mc.AIBlkRewardCfg `json:"BlkRewardCfg" gencodec:"required"`
+}
+
+// 奖励配置变更提案，配置在ActivateNumber高度生效
+type GenesisRewardCfgSchedule struct {
+	ActivateNumber uint64                  `json:"ActivateNumber" gencodec:"required"`
+	BlkRewardCfg   *mc.AIBlkRewardCfg      `json:"BlkRewardCfg,omitempty"`
+	TxsRewardCfg   *mc.TxsRewardCfg        `json:"TxsRewardCfg,omitempty"`
+	LotteryCfg     *mc.LotteryCfg          `json:"LotteryCfg,omitempty"`
+	InterestCfg    *mc.InterestCfg         `json:"InterestCfg,omitempty"`
+	SlashCfg       *mc.SlashCfg            `json:"SlashCfg,omitempty"`
+	CoinRewardCfg  *[]GenesisCoinRewardCfg `json:"CoinRewardCfg,omitempty"`
 }
 
 func (ms *GenesisMState) setMatrixState(state *state.StateDBManage, netTopology common.NetTopology, nextElect []common.Elect, newVersion string, oldVersion string, num uint64) error {
@@ -206,6 +231,24 @@ func (ms *GenesisMState) setMatrixState(state *state.StateDBManage, netTopology
 	if err := ms.setReelectionDifficulty(state, num, newVersion); err != nil {
 		return err
 	}
+	if err := ms.setSlashEvidenceCfg(state, num, newVersion); err != nil {
+		return err
+	}
+	if err := ms.setSlashReversals(state, num, newVersion); err != nil {
+		return err
+	}
+	if err := ms.setEquivocationSlashCfg(state, num, newVersion); err != nil {
+		return err
+	}
+	if err := ms.setBLSAggregateCfg(state, num, newVersion); err != nil {
+		return err
+	}
+	if err := ms.setRewardCfgSchedules(state, num, newVersion); err != nil {
+		return err
+	}
+	if err := ms.setCoinRewardCfg(state, num, newVersion); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -598,7 +641,7 @@ func (g *GenesisMState) setSlashCalcToState(state *state.StateDBManage, num uint
 	return matrixstate.SetSlashCalc(state, *g.SlashCalcCfg)
 }
 func (g *GenesisMState) setBlkRewardCfgToState(state *state.StateDBManage, num uint64) error {
-	if num >= manversion.VersionNumAIMine {
+	if num >= manversion.FeatureNumber(manversion.FeatureAIMine) {
 		if g.BlkRewardCfg == nil {
 			if num == 0 {
 				return errors.New("固定区块配置信息为nil")
@@ -608,27 +651,8 @@ func (g *GenesisMState) setBlkRewardCfgToState(state *state.StateDBManage, num u
 			}
 		}
 
-		rateCfg := g.BlkRewardCfg.RewardRate
-
-		if RewardFullRate != rateCfg.MinerOutRate+rateCfg.ElectedMinerRate+rateCfg.FoundationMinerRate+rateCfg.AIMinerOutRate {
-
-			return errors.Errorf("矿工固定区块奖励比例配置错误")
-		}
-		if RewardFullRate != rateCfg.LeaderRate+rateCfg.ElectedValidatorsRate+rateCfg.FoundationValidatorRate {
-
-			return errors.Errorf("验证者固定区块奖励比例配置错误")
-		}
-
-		if RewardFullRate != rateCfg.OriginElectOfflineRate+rateCfg.BackupRewardRate {
-
-			return errors.Errorf("替补固定区块奖励比例配置错误")
-		}
-		if uint64(g.BlkRewardCfg.MinerAttenuationRate) > RewardFullRate {
-			return errors.Errorf("矿工衰减比例配置错误")
-		}
-
-		if uint64(g.BlkRewardCfg.ValidatorAttenuationRate) > RewardFullRate {
-			return errors.Errorf("验证者衰减比例配置错误")
+		if err := checkBlkRewardCfg(g.BlkRewardCfg, true); err != nil {
+			return err
 		}
 
 		log.Info("Geneis", "BlkRewardCfg", g.BlkRewardCfg)
@@ -643,50 +667,65 @@ func (g *GenesisMState) setBlkRewardCfgToState(state *state.StateDBManage, num u
 			}
 		}
 
-		rateCfg := g.BlkRewardCfg.RewardRate
+		if err := checkBlkRewardCfg(g.BlkRewardCfg, false); err != nil {
+			return err
+		}
+
+		log.Info("Geneis", "BlkRewardCfg", g.BlkRewardCfg)
+		return matrixstate.SetBlkRewardCfg(state, toBlkRewardCfg(g.BlkRewardCfg))
+	}
+}
 
-		if RewardFullRate != rateCfg.MinerOutRate+rateCfg.ElectedMinerRate+rateCfg.FoundationMinerRate {
+func checkBlkRewardCfg(cfg *mc.AIBlkRewardCfg, aiMine bool) error {
+	rateCfg := cfg.RewardRate
 
-			return errors.Errorf("矿工固定区块奖励比例配置错误")
-		}
-		if RewardFullRate != rateCfg.LeaderRate+rateCfg.ElectedValidatorsRate+rateCfg.FoundationValidatorRate {
+	minerRate := rateCfg.MinerOutRate + rateCfg.ElectedMinerRate + rateCfg.FoundationMinerRate
+	if aiMine {
+		minerRate += rateCfg.AIMinerOutRate
+	}
+	if RewardFullRate != minerRate {
 
-			return errors.Errorf("验证者固定区块奖励比例配置错误")
-		}
+		return errors.Errorf("矿工固定区块奖励比例配置错误")
+	}
+	if RewardFullRate != rateCfg.LeaderRate+rateCfg.ElectedValidatorsRate+rateCfg.FoundationValidatorRate {
 
-		if RewardFullRate != rateCfg.OriginElectOfflineRate+rateCfg.BackupRewardRate {
+		return errors.Errorf("验证者固定区块奖励比例配置错误")
+	}
 
-			return errors.Errorf("替补固定区块奖励比例配置错误")
-		}
-		if uint64(g.BlkRewardCfg.MinerAttenuationRate) > RewardFullRate {
-			return errors.Errorf("矿工衰减比例配置错误")
-		}
+	if RewardFullRate != rateCfg.OriginElectOfflineRate+rateCfg.BackupRewardRate {
 
-		if uint64(g.BlkRewardCfg.ValidatorAttenuationRate) > RewardFullRate {
-			return errors.Errorf("验证者衰减比例配置错误")
-		}
+		return errors.Errorf("替补固定区块奖励比例配置错误")
+	}
+	if uint64(cfg.MinerAttenuationRate) > RewardFullRate {
+		return errors.Errorf("矿工衰减比例配置错误")
+	}
 
-		log.Info("Geneis", "BlkRewardCfg", g.BlkRewardCfg)
-		return matrixstate.SetBlkRewardCfg(state, &mc.BlkRewardCfg{
-			MinerMount:               g.BlkRewardCfg.MinerMount,
-			MinerAttenuationRate:     g.BlkRewardCfg.MinerAttenuationRate,
-			MinerAttenuationNum:      g.BlkRewardCfg.MinerAttenuationNum,
-			ValidatorMount:           g.BlkRewardCfg.ValidatorMount,
-			ValidatorAttenuationRate: g.BlkRewardCfg.ValidatorAttenuationRate,
-			ValidatorAttenuationNum:  g.BlkRewardCfg.ValidatorAttenuationNum,
-			RewardRate: mc.RewardRateCfg{
-				MinerOutRate:        g.BlkRewardCfg.RewardRate.MinerOutRate,        //出块矿工奖励
-				ElectedMinerRate:    g.BlkRewardCfg.RewardRate.ElectedMinerRate,    //当选矿工奖励
-				FoundationMinerRate: g.BlkRewardCfg.RewardRate.FoundationMinerRate, //基金会网络奖励
-
-				LeaderRate:              g.BlkRewardCfg.RewardRate.LeaderRate,              //出块验证者（leader）奖励
-				ElectedValidatorsRate:   g.BlkRewardCfg.RewardRate.ElectedValidatorsRate,   //当选验证者奖励
-				FoundationValidatorRate: g.BlkRewardCfg.RewardRate.FoundationValidatorRate, //基金会网络奖励
-
-				OriginElectOfflineRate: g.BlkRewardCfg.RewardRate.OriginElectOfflineRate, //初选下线验证者奖励
-				BackupRewardRate:       g.BlkRewardCfg.RewardRate.BackupRewardRate,       //当前替补验证者奖励
-			}})
+	if uint64(cfg.ValidatorAttenuationRate) > RewardFullRate {
+		return errors.Errorf("验证者衰减比例配置错误")
 	}
+	return nil
+}
+
+func toBlkRewardCfg(cfg *mc.AIBlkRewardCfg) *mc.BlkRewardCfg {
+	return &mc.BlkRewardCfg{
+		MinerMount:               cfg.MinerMount,
+		MinerAttenuationRate:     cfg.MinerAttenuationRate,
+		MinerAttenuationNum:      cfg.MinerAttenuationNum,
+		ValidatorMount:           cfg.ValidatorMount,
+		ValidatorAttenuationRate: cfg.ValidatorAttenuationRate,
+		ValidatorAttenuationNum:  cfg.ValidatorAttenuationNum,
+		RewardRate: mc.RewardRateCfg{
+			MinerOutRate:        cfg.RewardRate.MinerOutRate,        //出块矿工奖励
+			ElectedMinerRate:    cfg.RewardRate.ElectedMinerRate,    //当选矿工奖励
+			FoundationMinerRate: cfg.RewardRate.FoundationMinerRate, //基金会网络奖励
+
+			LeaderRate:              cfg.RewardRate.LeaderRate,              //出块验证者（leader）奖励
+			ElectedValidatorsRate:   cfg.RewardRate.ElectedValidatorsRate,   //当选验证者奖励
+			FoundationValidatorRate: cfg.RewardRate.FoundationValidatorRate, //基金会网络奖励
+
+			OriginElectOfflineRate: cfg.RewardRate.OriginElectOfflineRate, //初选下线验证者奖励
+			BackupRewardRate:       cfg.RewardRate.BackupRewardRate,       //当前替补验证者奖励
+		}}
 }
 
 func (g *GenesisMState) setTxsRewardCfgToState(state *state.StateDBManage, num uint64) error {
@@ -698,9 +737,17 @@ func (g *GenesisMState) setTxsRewardCfgToState(state *state.StateDBManage, num u
 			return nil
 		}
 	}
-	rateCfg := g.TxsRewardCfg.RewardRate
+	if err := checkTxsRewardCfg(g.TxsRewardCfg); err != nil {
+		return err
+	}
+	log.Info("Geneis", "TxsRewardCfg", g.TxsRewardCfg)
+	return matrixstate.SetTxsRewardCfg(state, g.TxsRewardCfg)
+}
+
+func checkTxsRewardCfg(cfg *mc.TxsRewardCfg) error {
+	rateCfg := cfg.RewardRate
 
-	if RewardFullRate != g.TxsRewardCfg.ValidatorsRate+g.TxsRewardCfg.MinersRate {
+	if RewardFullRate != cfg.ValidatorsRate+cfg.MinersRate {
 
 		return errors.Errorf("交易奖励比例配置错误")
 	}
@@ -718,8 +765,7 @@ func (g *GenesisMState) setTxsRewardCfgToState(state *state.StateDBManage, num u
 
 		return errors.Errorf("替补固定区块奖励比例配置错误")
 	}
-	log.Info("Geneis", "TxsRewardCfg", g.TxsRewardCfg)
-	return matrixstate.SetTxsRewardCfg(state, g.TxsRewardCfg)
+	return nil
 }
 
 func (g *GenesisMState) setLotteryCfgToState(state *state.StateDBManage, num uint64) error {
@@ -967,7 +1013,7 @@ func (g *GenesisMState) setMinDifficulty(state *state.StateDBManage, num uint64,
 		if g.MinDifficulty == nil {
 			return nil
 		} else {
-			if manversion.VersionCmp(version, manversion.VersionAIMine) < 0 {
+			if !manversion.IsEnabled(manversion.FeatureAIMine, version) {
 				log.Error("Geneis", "setMinDifficulty", "链版本号过低", "version", version)
 				return errors.New("setMinDifficulty: 链版本号过低")
 			}
@@ -990,7 +1036,7 @@ func (g *GenesisMState) setMaxDifficulty(state *state.StateDBManage, num uint64,
 		if g.MaxDifficulty == nil {
 			return nil
 		} else {
-			if manversion.VersionCmp(version, manversion.VersionAIMine) < 0 {
+			if !manversion.IsEnabled(manversion.FeatureAIMine, version) {
 				log.Error("Geneis", "setMaxDifficulty", "链版本号过低", "version", version)
 				return errors.New("setMaxDifficulty: 链版本号过低")
 			}
@@ -1013,7 +1059,7 @@ func (g *GenesisMState) setReelectionDifficulty(state *state.StateDBManage, num
 		if g.ReelectionDifficulty == nil {
 			return nil
 		} else {
-			if manversion.VersionCmp(version, manversion.VersionAIMine) < 0 {
+			if !manversion.IsEnabled(manversion.FeatureAIMine, version) {
 				log.Error("Geneis", "setReelectionDifficulty", "链版本号过低", "version", version)
 				return errors.New("setReelectionDifficulty: 链版本号过低")
 			}
@@ -1035,7 +1081,7 @@ func (g *GenesisMState) setBasePowerSlashCfg(state *state.StateDBManage, num uin
 		if g.BasePowerSlashCfg == nil {
 			return nil
 		}
-		if manversion.VersionCmp(version, manversion.VersionAIMine) < 0 {
+		if !manversion.IsEnabled(manversion.FeatureAIMine, version) {
 			log.Error("Geneis", "setBasePowerSlashCfg", "链版本号过低", "version", version)
 			return errors.New("setBasePowerSlashCfg: 链版本号过低")
 		}
@@ -1056,7 +1102,7 @@ func (g *GenesisMState) setBasePowerStats(state *state.StateDBManage, num uint64
 		if g.BasePowerStats == nil {
 			return nil
 		}
-		if manversion.VersionCmp(version, manversion.VersionAIMine) < 0 {
+		if !manversion.IsEnabled(manversion.FeatureAIMine, version) {
 			log.Error("Geneis", "setBasePowerStats", "链版本号过低", "version", version)
 			return errors.New("setBasePowerStats: 链版本号过低")
 		}
@@ -1077,7 +1123,7 @@ func (g *GenesisMState) setBasePowerSlashBlkList(state *state.StateDBManage, num
 		if g.BasePowerSlashBlackList == nil {
 			return nil
 		}
-		if manversion.VersionCmp(version, manversion.VersionAIMine) < 0 {
+		if !manversion.IsEnabled(manversion.FeatureAIMine, version) {
 			log.Error("Geneis", "setBasePowerSlashBlkList", "链版本号过低", "version", version)
 			return errors.New("setBasePowerSlashBlkList: 链版本号过低")
 		}
@@ -1098,7 +1144,7 @@ func (g *GenesisMState) setBasePowerSlashStatsStatus(state *state.StateDBManage,
 		if g.BasePowerSlashStatsStatus == nil {
 			return nil
 		}
-		if manversion.VersionCmp(version, manversion.VersionAIMine) < 0 {
+		if !manversion.IsEnabled(manversion.FeatureAIMine, version) {
 			log.Error("Geneis", "setBasePowerSlashStatsStatus", "链版本号过低", "version", version)
 			return errors.New("setBasePowerSlashStatsStatus: 链版本号过低")
 		}
@@ -1107,3 +1153,166 @@ func (g *GenesisMState) setBasePowerSlashStatsStatus(state *state.StateDBManage,
 	}
 
 }
+
+func (g *GenesisMState) setSlashEvidenceCfg(state *state.StateDBManage, num uint64, version string) error {
+	if g.SlashEvidenceCfg == nil {
+		return nil
+	}
+	if !manversion.IsEnabled(manversion.FeatureDelta, version) {
+		log.Error("Geneis", "setSlashEvidenceCfg", "链版本号过低", "version", version)
+		return errors.New("setSlashEvidenceCfg: 链版本号过低")
+	}
+	log.Info("Geneis", "SlashEvidenceCfg", g.SlashEvidenceCfg)
+	return matrixstate.SetSlashEvidenceCfg(state, g.SlashEvidenceCfg)
+}
+
+func (g *GenesisMState) setEquivocationSlashCfg(state *state.StateDBManage, num uint64, version string) error {
+	if g.EquivocationSlashCfg == nil {
+		return nil
+	}
+	if !manversion.IsEnabled(manversion.FeatureDelta, version) {
+		log.Error("Geneis", "setEquivocationSlashCfg", "链版本号过低", "version", version)
+		return errors.New("setEquivocationSlashCfg: 链版本号过低")
+	}
+	log.Info("Geneis", "EquivocationSlashCfg", g.EquivocationSlashCfg)
+	return matrixstate.SetEquivocationSlashCfg(state, g.EquivocationSlashCfg)
+}
+
+func (g *GenesisMState) setBLSAggregateCfg(state *state.StateDBManage, num uint64, version string) error {
+	if g.BLSAggregateCfg == nil {
+		return nil
+	}
+	if !manversion.IsEnabled(manversion.FeatureDelta, version) {
+		log.Error("Geneis", "setBLSAggregateCfg", "链版本号过低", "version", version)
+		return errors.New("setBLSAggregateCfg: 链版本号过低")
+	}
+	log.Info("Geneis", "BLSAggregateCfg", g.BLSAggregateCfg)
+	return matrixstate.SetBLSAggregateCfg(state, g.BLSAggregateCfg)
+}
+
+// 超级区块撤销指定的惩罚，创世区块不支持
+func (g *GenesisMState) setSlashReversals(state *state.StateDBManage, num uint64, version string) error {
+	if g.SlashReversals == nil {
+		return nil
+	}
+	if num == 0 {
+		log.Error("Geneis", "不支持的参数", "SlashReversals")
+		return errors.New("不支持的参数 SlashReversals")
+	}
+	for _, reversal := range *g.SlashReversals {
+		if err := slash.ReverseEvidence(state, reversal, num); err != nil {
+			log.Error("Geneis", "撤销惩罚失败", err, "type", reversal.Type, "account", reversal.Address, "number", reversal.Number)
+			return errors.Errorf("撤销惩罚失败(%v)", err)
+		}
+	}
+	return nil
+}
+
+// 奖励配置变更提案只写入待生效列表，由ProcessStateVersionSwitch在生效高度前一个区块替换
+func (g *GenesisMState) setRewardCfgSchedules(state *state.StateDBManage, num uint64, version string) error {
+	if g.RewardCfgSchedules == nil {
+		return nil
+	}
+	if num == 0 {
+		log.Error("Geneis", "不支持的参数", "RewardCfgSchedules")
+		return errors.New("不支持的参数 RewardCfgSchedules")
+	}
+	if !manversion.IsEnabled(manversion.FeatureDelta, version) {
+		log.Error("Geneis", "setRewardCfgSchedules", "链版本号过低", "version", version)
+		return errors.New("setRewardCfgSchedules: 链版本号过低")
+	}
+	for _, item := range *g.RewardCfgSchedules {
+		if item.ActivateNumber <= num+1 {
+			return errors.Errorf("奖励配置生效高度(%d)过低，当前高度(%d)", item.ActivateNumber, num)
+		}
+		if item.BlkRewardCfg != nil {
+			aiMine := item.ActivateNumber >= manversion.FeatureNumber(manversion.FeatureAIMine)
+			if err := checkBlkRewardCfg(item.BlkRewardCfg, aiMine); err != nil {
+				return err
+			}
+			var err error
+			if aiMine {
+				err = matrixstate.AddRewardCfgSchedule(state, mc.MSKeyAIBlkRewardCfg, item.BlkRewardCfg, num, item.ActivateNumber)
+			} else {
+				err = matrixstate.AddRewardCfgSchedule(state, mc.MSKeyBlkRewardCfg, toBlkRewardCfg(item.BlkRewardCfg), num, item.ActivateNumber)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if item.TxsRewardCfg != nil {
+			if err := checkTxsRewardCfg(item.TxsRewardCfg); err != nil {
+				return err
+			}
+			if err := matrixstate.AddRewardCfgSchedule(state, mc.MSKeyTxsRewardCfg, item.TxsRewardCfg, num, item.ActivateNumber); err != nil {
+				return err
+			}
+		}
+		if item.LotteryCfg != nil {
+			if err := matrixstate.AddRewardCfgSchedule(state, mc.MSKeyLotteryCfg, item.LotteryCfg, num, item.ActivateNumber); err != nil {
+				return err
+			}
+		}
+		if item.InterestCfg != nil {
+			if uint64(item.InterestCfg.AttenuationRate) > RewardFullRate {
+				return errors.Errorf("利息衰减比例配置错误")
+			}
+			if err := matrixstate.AddRewardCfgSchedule(state, mc.MSKeyInterestCfg, item.InterestCfg, num, item.ActivateNumber); err != nil {
+				return err
+			}
+		}
+		if item.SlashCfg != nil {
+			if err := matrixstate.AddRewardCfgSchedule(state, mc.MSKeySlashCfg, item.SlashCfg, num, item.ActivateNumber); err != nil {
+				return err
+			}
+		}
+		if item.CoinRewardCfg != nil {
+			cfgList, err := toCoinRewardCfgList(*item.CoinRewardCfg)
+			if err != nil {
+				return err
+			}
+			if err := matrixstate.AddRewardCfgSchedule(state, mc.MSKeyCoinRewardCfg, cfgList, num, item.ActivateNumber); err != nil {
+				return err
+			}
+		}
+		log.Info("Geneis", "RewardCfgSchedule", item.ActivateNumber)
+	}
+	return nil
+}
+
+// 多币种奖励池配置整体替换，奖励从CoinRewardPoolAddress账户对应币种的余额中发放
+func (g *GenesisMState) setCoinRewardCfg(state *state.StateDBManage, num uint64, version string) error {
+	if g.CoinRewardCfg == nil {
+		return nil
+	}
+	if !manversion.IsEnabled(manversion.FeatureDelta, version) {
+		log.Error("Geneis", "setCoinRewardCfg", "链版本号过低", "version", version)
+		return errors.New("setCoinRewardCfg: 链版本号过低")
+	}
+	cfgList, err := toCoinRewardCfgList(*g.CoinRewardCfg)
+	if err != nil {
+		return err
+	}
+	log.Info("Geneis", "CoinRewardCfg", cfgList.CfgList)
+	return matrixstate.SetCoinRewardCfg(state, cfgList)
+}
+
+func toCoinRewardCfgList(cfgs []GenesisCoinRewardCfg) (*mc.CoinRewardCfgList, error) {
+	cfgList := &mc.CoinRewardCfgList{CfgList: make([]mc.CoinRewardCfg, 0, len(cfgs))}
+	coins := make(map[string]bool)
+	for i := range cfgs {
+		item := &cfgs[i]
+		if item.CoinType == "" || item.CoinType == params.MAN_COIN {
+			return nil, errors.Errorf("奖励池币种(%s)配置错误", item.CoinType)
+		}
+		if coins[item.CoinType] {
+			return nil, errors.Errorf("奖励池币种(%s)重复配置", item.CoinType)
+		}
+		coins[item.CoinType] = true
+		if err := checkBlkRewardCfg(&item.BlkRewardCfg, false); err != nil {
+			return nil, err
+		}
+		cfgList.CfgList = append(cfgList.CfgList, mc.CoinRewardCfg{CoinType: item.CoinType, BlkRewardCfg: *toBlkRewardCfg(&item.BlkRewardCfg)})
+	}
+	return cfgList, nil
+}
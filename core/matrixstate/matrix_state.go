@@ -323,6 +323,15 @@ func newManger(version string) *Manager {
 				mc.MSKeyBasePowerBlackList:      newBasePowerBlackListOpt(),
 				mc.MSKeyElectDynamicPollingInfo: newDynamicPollingOpt(),
 				mc.MSCurrencyHeader:             newCurrencyHeaderCfgOpt(),
+				mc.MSKeySlashEvidenceCfg:        newSlashEvidenceCfgOpt(),
+				mc.MSKeySlashEvidence:           newSlashEvidenceOpt(),
+				mc.MSKeyEquivocationSlashCfg:    newEquivocationSlashCfgOpt(),
+				mc.MSKeyEquivocationRecords:     newEquivocationRecordsOpt(),
+				mc.MSKeyBLSAggregateCfg:         newBLSAggregateCfgOpt(),
+				mc.MSKeyBLSPublicKeys:           newBLSPublicKeysOpt(),
+				mc.MSKeyRewardCfgSchedule:       newRewardCfgScheduleOpt(),
+				mc.MSKeyCoinRewardCfg:           newCoinRewardCfgOpt(),
+				mc.MSKeyCoinRewardPool:          newCoinRewardPoolOpt(),
 			},
 		}
 	default:
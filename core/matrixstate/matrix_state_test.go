@@ -4,10 +4,13 @@
 package matrixstate
 
 import (
+	"math/big"
+	"testing"
+
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/log"
 	"github.com/MatrixAINetwork/go-matrix/mc"
-	"testing"
+	"github.com/MatrixAINetwork/go-matrix/params/manversion"
 )
 
 type TestState struct {
@@ -64,3 +67,79 @@ func Test_GetUpTime(t *testing.T) {
 
 	t.Log(num)
 }
+
+func Test_SlashEvidence(t *testing.T) {
+	log.InitLog(3)
+	st := newTestState()
+	SetVersionInfo(st, manversion.VersionZeta)
+
+	cfg, err := GetSlashEvidenceCfg(st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Switcher {
+		t.Fatal("slash evidence should be disabled by default")
+	}
+
+	list := &mc.SlashEvidenceList{EvidenceList: []mc.SlashEvidence{
+		{Type: mc.SlashTypeBlockProduce, Address: common.HexToAddress("0x01"), Number: 100, ActualCount: 0, ExpectCount: 1, ProhibitCycleNum: 2},
+		{Type: mc.SlashTypeInterest, Address: common.HexToAddress("0x02"), Number: 101, SlashRate: 7500,
+			Positions: []mc.SlashPosition{{Position: 1, Amount: big.NewInt(300)}}},
+	}}
+	if err := SetSlashEvidence(st, list); err != nil {
+		t.Fatal(err)
+	}
+	got, err := GetSlashEvidence(st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.EvidenceList) != 2 || got.EvidenceList[1].Positions[0].Amount.Cmp(big.NewInt(300)) != 0 {
+		t.Fatalf("evidence mismatch: %+v", got.EvidenceList)
+	}
+}
+
+func Test_RewardCfgSchedule(t *testing.T) {
+	log.InitLog(3)
+	st := newTestState()
+	SetVersionInfo(st, manversion.VersionZeta)
+
+	if err := SetLotteryCfg(st, &mc.LotteryCfg{LotteryInfo: []mc.LotteryInfo{{PrizeLevel: 0, PrizeNum: 1, PrizeMoney: 6}}}); err != nil {
+		t.Fatal(err)
+	}
+	newCfg := &mc.LotteryCfg{LotteryInfo: []mc.LotteryInfo{{PrizeLevel: 0, PrizeNum: 2, PrizeMoney: 8}}}
+	if err := AddRewardCfgSchedule(st, mc.MSKeyLotteryCfg, newCfg, 10, 20); err != nil {
+		t.Fatal(err)
+	}
+	if err := AddRewardCfgSchedule(st, mc.MSKeyLotteryCfg, newCfg, 20, 10); err == nil {
+		t.Fatal("activate number lower than propose number should be refused")
+	}
+
+	if err := ProcessRewardCfgSchedule(st, 19); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := GetLotteryCfg(st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.LotteryInfo[0].PrizeNum != 1 {
+		t.Fatal("reward cfg changed before activate number")
+	}
+
+	if err := ProcessRewardCfgSchedule(st, 20); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err = GetLotteryCfg(st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.LotteryInfo[0].PrizeNum != 2 || cfg.LotteryInfo[0].PrizeMoney != 8 {
+		t.Fatalf("reward cfg not activated: %+v", cfg)
+	}
+	list, err := GetRewardCfgSchedule(st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.ScheduleList) != 0 {
+		t.Fatalf("activated schedule not removed: %+v", list.ScheduleList)
+	}
+}
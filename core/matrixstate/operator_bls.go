@@ -0,0 +1,110 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package matrixstate
+
+import (
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/rlp"
+)
+
+/////////////////////////////////////////////////////////////////////////////////////////
+// BLS聚合签名配置
+type operatorBLSAggregateCfg struct {
+	key common.Hash
+}
+
+func newBLSAggregateCfgOpt() *operatorBLSAggregateCfg {
+	return &operatorBLSAggregateCfg{
+		key: types.RlpHash(matrixStatePrefix + mc.MSKeyBLSAggregateCfg),
+	}
+}
+
+func (opt *operatorBLSAggregateCfg) KeyHash() common.Hash {
+	return opt.key
+}
+
+func (opt *operatorBLSAggregateCfg) GetValue(st StateDB) (interface{}, error) {
+	if err := checkStateDB(st); err != nil {
+		return nil, err
+	}
+
+	data := st.GetMatrixData(opt.key)
+	if len(data) == 0 {
+		return &mc.BLSAggregateCfg{Switcher: false}, nil
+	}
+
+	value := new(mc.BLSAggregateCfg)
+	err := rlp.DecodeBytes(data, &value)
+	if err != nil {
+		log.Error(logInfo, "blsAggregateCfg rlp decode failed", err)
+		return nil, err
+	}
+	return value, nil
+}
+
+func (opt *operatorBLSAggregateCfg) SetValue(st StateDB, value interface{}) error {
+	if err := checkStateDB(st); err != nil {
+		return err
+	}
+
+	data, err := rlp.EncodeToBytes(value)
+	if err != nil {
+		log.Error(logInfo, "blsAggregateCfg rlp encode failed", err)
+		return err
+	}
+	st.SetMatrixData(opt.key, data)
+	return nil
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////
+// 验证者注册的BLS公钥
+type operatorBLSPublicKeys struct {
+	key common.Hash
+}
+
+func newBLSPublicKeysOpt() *operatorBLSPublicKeys {
+	return &operatorBLSPublicKeys{
+		key: types.RlpHash(matrixStatePrefix + mc.MSKeyBLSPublicKeys),
+	}
+}
+
+func (opt *operatorBLSPublicKeys) KeyHash() common.Hash {
+	return opt.key
+}
+
+func (opt *operatorBLSPublicKeys) GetValue(st StateDB) (interface{}, error) {
+	if err := checkStateDB(st); err != nil {
+		return nil, err
+	}
+
+	data := st.GetMatrixData(opt.key)
+	if len(data) == 0 {
+		return &mc.BLSPublicKeys{Keys: make([]mc.BLSPublicKey, 0)}, nil
+	}
+
+	value := new(mc.BLSPublicKeys)
+	err := rlp.DecodeBytes(data, &value)
+	if err != nil {
+		log.Error(logInfo, "blsPublicKeys rlp decode failed", err)
+		return nil, err
+	}
+	return value, nil
+}
+
+func (opt *operatorBLSPublicKeys) SetValue(st StateDB, value interface{}) error {
+	if err := checkStateDB(st); err != nil {
+		return err
+	}
+
+	data, err := rlp.EncodeToBytes(value)
+	if err != nil {
+		log.Error(logInfo, "blsPublicKeys rlp encode failed", err)
+		return err
+	}
+	st.SetMatrixData(opt.key, data)
+	return nil
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package matrixstate
+
+import (
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/rlp"
+)
+
+/////////////////////////////////////////////////////////////////////////////////////////
+// 多币种奖励池配置
+type operatorCoinRewardCfg struct {
+	key common.Hash
+}
+
+func newCoinRewardCfgOpt() *operatorCoinRewardCfg {
+	return &operatorCoinRewardCfg{
+		key: types.RlpHash(matrixStatePrefix + mc.MSKeyCoinRewardCfg),
+	}
+}
+
+func (opt *operatorCoinRewardCfg) KeyHash() common.Hash {
+	return opt.key
+}
+
+func (opt *operatorCoinRewardCfg) GetValue(st StateDB) (interface{}, error) {
+	if err := checkStateDB(st); err != nil {
+		return nil, err
+	}
+
+	data := st.GetMatrixData(opt.key)
+	if len(data) == 0 {
+		return &mc.CoinRewardCfgList{CfgList: make([]mc.CoinRewardCfg, 0)}, nil
+	}
+
+	value := new(mc.CoinRewardCfgList)
+	err := rlp.DecodeBytes(data, &value)
+	if err != nil {
+		log.Error(logInfo, "coinRewardCfg rlp decode failed", err)
+		return nil, err
+	}
+	return value, nil
+}
+
+func (opt *operatorCoinRewardCfg) SetValue(st StateDB, value interface{}) error {
+	if err := checkStateDB(st); err != nil {
+		return err
+	}
+
+	data, err := rlp.EncodeToBytes(value)
+	if err != nil {
+		log.Error(logInfo, "coinRewardCfg rlp encode failed", err)
+		return err
+	}
+	st.SetMatrixData(opt.key, data)
+	return nil
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////
+// 多币种奖励池发放记录
+type operatorCoinRewardPool struct {
+	key common.Hash
+}
+
+func newCoinRewardPoolOpt() *operatorCoinRewardPool {
+	return &operatorCoinRewardPool{
+		key: types.RlpHash(matrixStatePrefix + mc.MSKeyCoinRewardPool),
+	}
+}
+
+func (opt *operatorCoinRewardPool) KeyHash() common.Hash {
+	return opt.key
+}
+
+func (opt *operatorCoinRewardPool) GetValue(st StateDB) (interface{}, error) {
+	if err := checkStateDB(st); err != nil {
+		return nil, err
+	}
+
+	data := st.GetMatrixData(opt.key)
+	if len(data) == 0 {
+		return &mc.CoinRewardPoolList{PoolList: make([]mc.CoinRewardPoolInfo, 0)}, nil
+	}
+
+	value := new(mc.CoinRewardPoolList)
+	err := rlp.DecodeBytes(data, &value)
+	if err != nil {
+		log.Error(logInfo, "coinRewardPool rlp decode failed", err)
+		return nil, err
+	}
+	return value, nil
+}
+
+func (opt *operatorCoinRewardPool) SetValue(st StateDB, value interface{}) error {
+	if err := checkStateDB(st); err != nil {
+		return err
+	}
+
+	data, err := rlp.EncodeToBytes(value)
+	if err != nil {
+		log.Error(logInfo, "coinRewardPool rlp encode failed", err)
+		return err
+	}
+	st.SetMatrixData(opt.key, data)
+	return nil
+}
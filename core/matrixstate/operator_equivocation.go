@@ -0,0 +1,110 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package matrixstate
+
+import (
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/rlp"
+)
+
+/////////////////////////////////////////////////////////////////////////////////////////
+// 双签惩罚配置
+type operatorEquivocationSlashCfg struct {
+	key common.Hash
+}
+
+func newEquivocationSlashCfgOpt() *operatorEquivocationSlashCfg {
+	return &operatorEquivocationSlashCfg{
+		key: types.RlpHash(matrixStatePrefix + mc.MSKeyEquivocationSlashCfg),
+	}
+}
+
+func (opt *operatorEquivocationSlashCfg) KeyHash() common.Hash {
+	return opt.key
+}
+
+func (opt *operatorEquivocationSlashCfg) GetValue(st StateDB) (interface{}, error) {
+	if err := checkStateDB(st); err != nil {
+		return nil, err
+	}
+
+	data := st.GetMatrixData(opt.key)
+	if len(data) == 0 {
+		return &mc.EquivocationSlashCfg{Switcher: false, ProhibitCycleNum: 4, ValidNumber: 3600, MaxRecords: 1000}, nil
+	}
+
+	value := new(mc.EquivocationSlashCfg)
+	err := rlp.DecodeBytes(data, &value)
+	if err != nil {
+		log.Error(logInfo, "equivocationSlashCfg rlp decode failed", err)
+		return nil, err
+	}
+	return value, nil
+}
+
+func (opt *operatorEquivocationSlashCfg) SetValue(st StateDB, value interface{}) error {
+	if err := checkStateDB(st); err != nil {
+		return err
+	}
+
+	data, err := rlp.EncodeToBytes(value)
+	if err != nil {
+		log.Error(logInfo, "equivocationSlashCfg rlp encode failed", err)
+		return err
+	}
+	st.SetMatrixData(opt.key, data)
+	return nil
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////
+// 已提交的双签证据
+type operatorEquivocationRecords struct {
+	key common.Hash
+}
+
+func newEquivocationRecordsOpt() *operatorEquivocationRecords {
+	return &operatorEquivocationRecords{
+		key: types.RlpHash(matrixStatePrefix + mc.MSKeyEquivocationRecords),
+	}
+}
+
+func (opt *operatorEquivocationRecords) KeyHash() common.Hash {
+	return opt.key
+}
+
+func (opt *operatorEquivocationRecords) GetValue(st StateDB) (interface{}, error) {
+	if err := checkStateDB(st); err != nil {
+		return nil, err
+	}
+
+	data := st.GetMatrixData(opt.key)
+	if len(data) == 0 {
+		return &mc.EquivocationRecords{Pending: make([]mc.EquivocationRecord, 0), History: make([]mc.EquivocationRecord, 0)}, nil
+	}
+
+	value := new(mc.EquivocationRecords)
+	err := rlp.DecodeBytes(data, &value)
+	if err != nil {
+		log.Error(logInfo, "equivocationRecords rlp decode failed", err)
+		return nil, err
+	}
+	return value, nil
+}
+
+func (opt *operatorEquivocationRecords) SetValue(st StateDB, value interface{}) error {
+	if err := checkStateDB(st); err != nil {
+		return err
+	}
+
+	data, err := rlp.EncodeToBytes(value)
+	if err != nil {
+		log.Error(logInfo, "equivocationRecords rlp encode failed", err)
+		return err
+	}
+	st.SetMatrixData(opt.key, data)
+	return nil
+}
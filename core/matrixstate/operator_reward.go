@@ -5,6 +5,7 @@ package matrixstate
 
 import (
 	"math/big"
+	"sort"
 
 	"encoding/json"
 	"reflect"
@@ -14,6 +15,7 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/log"
 	"github.com/MatrixAINetwork/go-matrix/mc"
 	"github.com/MatrixAINetwork/go-matrix/params"
+	"github.com/MatrixAINetwork/go-matrix/params/manversion"
 	"github.com/MatrixAINetwork/go-matrix/rlp"
 	"github.com/pkg/errors"
 )
@@ -1462,3 +1464,147 @@ func (opt *operatorTXSSelValidatorNum) SetValue(st StateDB, value interface{}) e
 	st.SetMatrixData(opt.key, encodeUint64(num))
 	return nil
 }
+
+/////////////////////////////////////////////////////////////////////////////////////////
+// 待生效的奖励配置变更
+type operatorRewardCfgSchedule struct {
+	key common.Hash
+}
+
+// 支持定时生效的奖励配置及其类型
+var rewardCfgScheduleTypes = map[string]func() interface{}{
+	mc.MSKeyBlkRewardCfg:   func() interface{} { return new(mc.BlkRewardCfg) },
+	mc.MSKeyAIBlkRewardCfg: func() interface{} { return new(mc.AIBlkRewardCfg) },
+	mc.MSKeyTxsRewardCfg:   func() interface{} { return new(mc.TxsRewardCfg) },
+	mc.MSKeyInterestCfg:    func() interface{} { return new(mc.InterestCfg) },
+	mc.MSKeyLotteryCfg:     func() interface{} { return new(mc.LotteryCfg) },
+	mc.MSKeySlashCfg:       func() interface{} { return new(mc.SlashCfg) },
+	mc.MSKeyCoinRewardCfg:  func() interface{} { return new(mc.CoinRewardCfgList) },
+}
+
+func newRewardCfgScheduleOpt() *operatorRewardCfgSchedule {
+	return &operatorRewardCfgSchedule{
+		key: types.RlpHash(matrixStatePrefix + mc.MSKeyRewardCfgSchedule),
+	}
+}
+
+func (opt *operatorRewardCfgSchedule) KeyHash() common.Hash {
+	return opt.key
+}
+
+func (opt *operatorRewardCfgSchedule) GetValue(st StateDB) (interface{}, error) {
+	if err := checkStateDB(st); err != nil {
+		return nil, err
+	}
+
+	data := st.GetMatrixData(opt.key)
+	if len(data) == 0 {
+		return &mc.RewardCfgScheduleList{ScheduleList: make([]mc.RewardCfgSchedule, 0)}, nil
+	}
+
+	value := new(mc.RewardCfgScheduleList)
+	err := rlp.DecodeBytes(data, &value)
+	if err != nil {
+		log.Error(logInfo, "rewardCfgSchedule rlp decode failed", err)
+		return nil, err
+	}
+	return value, nil
+}
+
+func (opt *operatorRewardCfgSchedule) SetValue(st StateDB, value interface{}) error {
+	if err := checkStateDB(st); err != nil {
+		return err
+	}
+
+	data, err := rlp.EncodeToBytes(value)
+	if err != nil {
+		log.Error(logInfo, "rewardCfgSchedule rlp encode failed", err)
+		return err
+	}
+	st.SetMatrixData(opt.key, data)
+	return nil
+}
+
+// DecodeRewardCfgSchedule 解码提案中的奖励配置
+func DecodeRewardCfgSchedule(item *mc.RewardCfgSchedule) (interface{}, error) {
+	newCfg, exist := rewardCfgScheduleTypes[item.Key]
+	if !exist {
+		return nil, errors.Errorf("reward cfg(%s) can't be scheduled", item.Key)
+	}
+	value := newCfg()
+	if err := rlp.DecodeBytes(item.Data, value); err != nil {
+		return nil, errors.Errorf("reward cfg(%s) rlp decode failed: %v", item.Key, err)
+	}
+	return value, nil
+}
+
+// AddRewardCfgSchedule 增加奖励配置变更提案，相同key和生效高度的提案会被覆盖
+func AddRewardCfgSchedule(st StateDB, key string, cfg interface{}, proposeNum uint64, activateNum uint64) error {
+	if _, exist := rewardCfgScheduleTypes[key]; !exist {
+		return errors.Errorf("reward cfg(%s) can't be scheduled", key)
+	}
+	if activateNum <= proposeNum {
+		return errors.Errorf("activate number(%d) must be higher than propose number(%d)", activateNum, proposeNum)
+	}
+	data, err := rlp.EncodeToBytes(cfg)
+	if err != nil {
+		return err
+	}
+	list, err := GetRewardCfgSchedule(st)
+	if err != nil {
+		return err
+	}
+	item := mc.RewardCfgSchedule{Key: key, ProposeNumber: proposeNum, ActivateNumber: activateNum, Data: data}
+	for i, v := range list.ScheduleList {
+		if v.Key == key && v.ActivateNumber == activateNum {
+			list.ScheduleList[i] = item
+			return SetRewardCfgSchedule(st, list)
+		}
+	}
+	list.ScheduleList = append(list.ScheduleList, item)
+	sort.SliceStable(list.ScheduleList, func(i, j int) bool {
+		return list.ScheduleList[i].ActivateNumber < list.ScheduleList[j].ActivateNumber
+	})
+	return SetRewardCfgSchedule(st, list)
+}
+
+// ProcessRewardCfgSchedule 将生效高度不高于activateNum的提案写入对应的奖励配置
+func ProcessRewardCfgSchedule(st StateDB, activateNum uint64) error {
+	if !manversion.IsEnabled(manversion.FeatureDelta, GetVersionInfo(st)) {
+		return nil
+	}
+	list, err := GetRewardCfgSchedule(st)
+	if err != nil {
+		return err
+	}
+	if len(list.ScheduleList) == 0 || list.ScheduleList[0].ActivateNumber > activateNum {
+		return nil
+	}
+	mgr := GetManager(GetVersionInfo(st))
+	if mgr == nil {
+		return ErrFindManager
+	}
+
+	remain := make([]mc.RewardCfgSchedule, 0, len(list.ScheduleList))
+	for i := range list.ScheduleList {
+		item := &list.ScheduleList[i]
+		if item.ActivateNumber > activateNum {
+			remain = append(remain, *item)
+			continue
+		}
+		value, err := DecodeRewardCfgSchedule(item)
+		if err != nil {
+			return err
+		}
+		opt, err := mgr.FindOperator(item.Key)
+		if err != nil {
+			return err
+		}
+		log.Info(logInfo, "reward cfg schedule activate", item.Key, "propose number", item.ProposeNumber, "activate number", item.ActivateNumber)
+		if err := opt.SetValue(st, value); err != nil {
+			return err
+		}
+	}
+	list.ScheduleList = remain
+	return SetRewardCfgSchedule(st, list)
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package matrixstate
+
+import (
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/rlp"
+)
+
+/////////////////////////////////////////////////////////////////////////////////////////
+// 惩罚证据记录配置
+type operatorSlashEvidenceCfg struct {
+	key common.Hash
+}
+
+func newSlashEvidenceCfgOpt() *operatorSlashEvidenceCfg {
+	return &operatorSlashEvidenceCfg{
+		key: types.RlpHash(matrixStatePrefix + mc.MSKeySlashEvidenceCfg),
+	}
+}
+
+func (opt *operatorSlashEvidenceCfg) KeyHash() common.Hash {
+	return opt.key
+}
+
+func (opt *operatorSlashEvidenceCfg) GetValue(st StateDB) (interface{}, error) {
+	if err := checkStateDB(st); err != nil {
+		return nil, err
+	}
+
+	data := st.GetMatrixData(opt.key)
+	if len(data) == 0 {
+		return &mc.SlashEvidenceCfg{Switcher: false, MaxRecords: 1000}, nil
+	}
+
+	value := new(mc.SlashEvidenceCfg)
+	err := rlp.DecodeBytes(data, &value)
+	if err != nil {
+		log.Error(logInfo, "slashEvidenceCfg rlp decode failed", err)
+		return nil, err
+	}
+	return value, nil
+}
+
+func (opt *operatorSlashEvidenceCfg) SetValue(st StateDB, value interface{}) error {
+	if err := checkStateDB(st); err != nil {
+		return err
+	}
+
+	data, err := rlp.EncodeToBytes(value)
+	if err != nil {
+		log.Error(logInfo, "slashEvidenceCfg rlp encode failed", err)
+		return err
+	}
+	st.SetMatrixData(opt.key, data)
+	return nil
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////
+// 惩罚证据列表
+type operatorSlashEvidence struct {
+	key common.Hash
+}
+
+func newSlashEvidenceOpt() *operatorSlashEvidence {
+	return &operatorSlashEvidence{
+		key: types.RlpHash(matrixStatePrefix + mc.MSKeySlashEvidence),
+	}
+}
+
+func (opt *operatorSlashEvidence) KeyHash() common.Hash {
+	return opt.key
+}
+
+func (opt *operatorSlashEvidence) GetValue(st StateDB) (interface{}, error) {
+	if err := checkStateDB(st); err != nil {
+		return nil, err
+	}
+
+	data := st.GetMatrixData(opt.key)
+	if len(data) == 0 {
+		return &mc.SlashEvidenceList{EvidenceList: make([]mc.SlashEvidence, 0)}, nil
+	}
+
+	value := new(mc.SlashEvidenceList)
+	err := rlp.DecodeBytes(data, &value)
+	if err != nil {
+		log.Error(logInfo, "slashEvidence rlp decode failed", err)
+		return nil, err
+	}
+	return value, nil
+}
+
+func (opt *operatorSlashEvidence) SetValue(st StateDB, value interface{}) error {
+	if err := checkStateDB(st); err != nil {
+		return err
+	}
+
+	data, err := rlp.EncodeToBytes(value)
+	if err != nil {
+		log.Error(logInfo, "slashEvidence rlp encode failed", err)
+		return err
+	}
+	st.SetMatrixData(opt.key, data)
+	return nil
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package matrixstate
+
+import "github.com/MatrixAINetwork/go-matrix/mc"
+
+func GetBLSAggregateCfg(st StateDB) (*mc.BLSAggregateCfg, error) {
+	mgr := GetManager(GetVersionInfo(st))
+	if mgr == nil {
+		return nil, ErrFindManager
+	}
+	opt, err := mgr.FindOperator(mc.MSKeyBLSAggregateCfg)
+	if err != nil {
+		return nil, err
+	}
+	value, err := opt.GetValue(st)
+	if err != nil {
+		return nil, err
+	}
+	return value.(*mc.BLSAggregateCfg), nil
+}
+
+func SetBLSAggregateCfg(st StateDB, cfg *mc.BLSAggregateCfg) error {
+	mgr := GetManager(GetVersionInfo(st))
+	if mgr == nil {
+		return ErrFindManager
+	}
+	opt, err := mgr.FindOperator(mc.MSKeyBLSAggregateCfg)
+	if err != nil {
+		return err
+	}
+	return opt.SetValue(st, cfg)
+}
+
+func GetBLSPublicKeys(st StateDB) (*mc.BLSPublicKeys, error) {
+	mgr := GetManager(GetVersionInfo(st))
+	if mgr == nil {
+		return nil, ErrFindManager
+	}
+	opt, err := mgr.FindOperator(mc.MSKeyBLSPublicKeys)
+	if err != nil {
+		return nil, err
+	}
+	value, err := opt.GetValue(st)
+	if err != nil {
+		return nil, err
+	}
+	return value.(*mc.BLSPublicKeys), nil
+}
+
+func SetBLSPublicKeys(st StateDB, keys *mc.BLSPublicKeys) error {
+	mgr := GetManager(GetVersionInfo(st))
+	if mgr == nil {
+		return ErrFindManager
+	}
+	opt, err := mgr.FindOperator(mc.MSKeyBLSPublicKeys)
+	if err != nil {
+		return err
+	}
+	return opt.SetValue(st, keys)
+}
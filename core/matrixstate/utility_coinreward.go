@@ -0,0 +1,64 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package matrixstate
+
+import "github.com/MatrixAINetwork/go-matrix/mc"
+
+/////////////////////////////////////////////////////////////////////
+// 多币种奖励池
+func GetCoinRewardCfg(st StateDB) (*mc.CoinRewardCfgList, error) {
+	mgr := GetManager(GetVersionInfo(st))
+	if mgr == nil {
+		return nil, ErrFindManager
+	}
+	opt, err := mgr.FindOperator(mc.MSKeyCoinRewardCfg)
+	if err != nil {
+		return nil, err
+	}
+	value, err := opt.GetValue(st)
+	if err != nil {
+		return nil, err
+	}
+	return value.(*mc.CoinRewardCfgList), nil
+}
+
+func SetCoinRewardCfg(st StateDB, list *mc.CoinRewardCfgList) error {
+	mgr := GetManager(GetVersionInfo(st))
+	if mgr == nil {
+		return ErrFindManager
+	}
+	opt, err := mgr.FindOperator(mc.MSKeyCoinRewardCfg)
+	if err != nil {
+		return err
+	}
+	return opt.SetValue(st, list)
+}
+
+func GetCoinRewardPool(st StateDB) (*mc.CoinRewardPoolList, error) {
+	mgr := GetManager(GetVersionInfo(st))
+	if mgr == nil {
+		return nil, ErrFindManager
+	}
+	opt, err := mgr.FindOperator(mc.MSKeyCoinRewardPool)
+	if err != nil {
+		return nil, err
+	}
+	value, err := opt.GetValue(st)
+	if err != nil {
+		return nil, err
+	}
+	return value.(*mc.CoinRewardPoolList), nil
+}
+
+func SetCoinRewardPool(st StateDB, list *mc.CoinRewardPoolList) error {
+	mgr := GetManager(GetVersionInfo(st))
+	if mgr == nil {
+		return ErrFindManager
+	}
+	opt, err := mgr.FindOperator(mc.MSKeyCoinRewardPool)
+	if err != nil {
+		return err
+	}
+	return opt.SetValue(st, list)
+}
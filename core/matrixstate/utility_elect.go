@@ -276,6 +276,47 @@ func SetElectBlackList(st StateDB, accounts []common.Address) error {
 	return opt.SetValue(st, accounts)
 }
 
+// GetElectConfigInfoAll 汇总选举所需的配置，内部矿工账户作为黑名单处理
+func GetElectConfigInfoAll(st StateDB) (*mc.ElectConfigInfo_All, error) {
+	electInfo, err := GetElectConfigInfo(st)
+	if err != nil {
+		return nil, err
+	}
+	if electInfo == nil {
+		return nil, ErrDataEmpty
+	}
+	electMinerNum, err := GetElectMinerNum(st)
+	if err != nil {
+		return nil, err
+	}
+	blackList, err := GetElectBlackList(st)
+	if err != nil {
+		return nil, err
+	}
+	innerMiners, err := GetInnerMinerAccounts(st)
+	if err != nil {
+		return nil, err
+	}
+	blackList = append(blackList, innerMiners...)
+	whiteList, err := GetElectWhiteList(st)
+	if err != nil {
+		return nil, err
+	}
+	whiteListSwitcher, err := GetElectWhiteListSwitcher(st)
+	if err != nil {
+		return nil, err
+	}
+	return &mc.ElectConfigInfo_All{
+		MinerNum:          electMinerNum.MinerNum,
+		ValidatorNum:      electInfo.ValidatorNum,
+		BackValidator:     electInfo.BackValidator,
+		ElectPlug:         electInfo.ElectPlug,
+		WhiteList:         whiteList,
+		BlackList:         blackList,
+		WhiteListSwitcher: whiteListSwitcher,
+	}, nil
+}
+
 func GetVIPConfig(st StateDB) ([]mc.VIPConfig, error) {
 	mgr := GetManager(GetVersionInfo(st))
 	if mgr == nil {
@@ -0,0 +1,62 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package matrixstate
+
+import "github.com/MatrixAINetwork/go-matrix/mc"
+
+func GetEquivocationSlashCfg(st StateDB) (*mc.EquivocationSlashCfg, error) {
+	mgr := GetManager(GetVersionInfo(st))
+	if mgr == nil {
+		return nil, ErrFindManager
+	}
+	opt, err := mgr.FindOperator(mc.MSKeyEquivocationSlashCfg)
+	if err != nil {
+		return nil, err
+	}
+	value, err := opt.GetValue(st)
+	if err != nil {
+		return nil, err
+	}
+	return value.(*mc.EquivocationSlashCfg), nil
+}
+
+func SetEquivocationSlashCfg(st StateDB, cfg *mc.EquivocationSlashCfg) error {
+	mgr := GetManager(GetVersionInfo(st))
+	if mgr == nil {
+		return ErrFindManager
+	}
+	opt, err := mgr.FindOperator(mc.MSKeyEquivocationSlashCfg)
+	if err != nil {
+		return err
+	}
+	return opt.SetValue(st, cfg)
+}
+
+func GetEquivocationRecords(st StateDB) (*mc.EquivocationRecords, error) {
+	mgr := GetManager(GetVersionInfo(st))
+	if mgr == nil {
+		return nil, ErrFindManager
+	}
+	opt, err := mgr.FindOperator(mc.MSKeyEquivocationRecords)
+	if err != nil {
+		return nil, err
+	}
+	value, err := opt.GetValue(st)
+	if err != nil {
+		return nil, err
+	}
+	return value.(*mc.EquivocationRecords), nil
+}
+
+func SetEquivocationRecords(st StateDB, records *mc.EquivocationRecords) error {
+	mgr := GetManager(GetVersionInfo(st))
+	if mgr == nil {
+		return ErrFindManager
+	}
+	opt, err := mgr.FindOperator(mc.MSKeyEquivocationRecords)
+	if err != nil {
+		return err
+	}
+	return opt.SetValue(st, records)
+}
@@ -715,3 +715,33 @@ func SetTXSSelValidatorNum(st StateDB, num uint64) error {
 	}
 	return opt.SetValue(st, num)
 }
+
+/////////////////////////////////////////////////////////////////////
+// 奖励配置定时变更
+func GetRewardCfgSchedule(st StateDB) (*mc.RewardCfgScheduleList, error) {
+	mgr := GetManager(GetVersionInfo(st))
+	if mgr == nil {
+		return nil, ErrFindManager
+	}
+	opt, err := mgr.FindOperator(mc.MSKeyRewardCfgSchedule)
+	if err != nil {
+		return nil, err
+	}
+	value, err := opt.GetValue(st)
+	if err != nil {
+		return nil, err
+	}
+	return value.(*mc.RewardCfgScheduleList), nil
+}
+
+func SetRewardCfgSchedule(st StateDB, list *mc.RewardCfgScheduleList) error {
+	mgr := GetManager(GetVersionInfo(st))
+	if mgr == nil {
+		return ErrFindManager
+	}
+	opt, err := mgr.FindOperator(mc.MSKeyRewardCfgSchedule)
+	if err != nil {
+		return err
+	}
+	return opt.SetValue(st, list)
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package matrixstate
+
+import "github.com/MatrixAINetwork/go-matrix/mc"
+
+func GetSlashEvidenceCfg(st StateDB) (*mc.SlashEvidenceCfg, error) {
+	mgr := GetManager(GetVersionInfo(st))
+	if mgr == nil {
+		return nil, ErrFindManager
+	}
+	opt, err := mgr.FindOperator(mc.MSKeySlashEvidenceCfg)
+	if err != nil {
+		return nil, err
+	}
+	value, err := opt.GetValue(st)
+	if err != nil {
+		return nil, err
+	}
+	return value.(*mc.SlashEvidenceCfg), nil
+}
+
+func SetSlashEvidenceCfg(st StateDB, cfg *mc.SlashEvidenceCfg) error {
+	mgr := GetManager(GetVersionInfo(st))
+	if mgr == nil {
+		return ErrFindManager
+	}
+	opt, err := mgr.FindOperator(mc.MSKeySlashEvidenceCfg)
+	if err != nil {
+		return err
+	}
+	return opt.SetValue(st, cfg)
+}
+
+func GetSlashEvidence(st StateDB) (*mc.SlashEvidenceList, error) {
+	mgr := GetManager(GetVersionInfo(st))
+	if mgr == nil {
+		return nil, ErrFindManager
+	}
+	opt, err := mgr.FindOperator(mc.MSKeySlashEvidence)
+	if err != nil {
+		return nil, err
+	}
+	value, err := opt.GetValue(st)
+	if err != nil {
+		return nil, err
+	}
+	return value.(*mc.SlashEvidenceList), nil
+}
+
+func SetSlashEvidence(st StateDB, list *mc.SlashEvidenceList) error {
+	mgr := GetManager(GetVersionInfo(st))
+	if mgr == nil {
+		return ErrFindManager
+	}
+	opt, err := mgr.FindOperator(mc.MSKeySlashEvidence)
+	if err != nil {
+		return err
+	}
+	return opt.SetValue(st, list)
+}
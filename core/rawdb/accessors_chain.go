@@ -105,6 +105,22 @@ func WriteHeadFastBlockHash(db DatabaseWriter, hash common.Hash) {
 	}
 }
 
+// ReadFinalizedBlockHash retrieves the hash of the latest finalized block.
+func ReadFinalizedBlockHash(db DatabaseReader) common.Hash {
+	data, _ := db.Get(finalizedBlockKey)
+	if len(data) == 0 {
+		return common.Hash{}
+	}
+	return common.BytesToHash(data)
+}
+
+// WriteFinalizedBlockHash stores the hash of the latest finalized block.
+func WriteFinalizedBlockHash(db DatabaseWriter, hash common.Hash) {
+	if err := db.Put(finalizedBlockKey, hash.Bytes()); err != nil {
+		log.Crit("Failed to store last finalized block's hash", "err", err)
+	}
+}
+
 // ReadFastTrieProgress retrieves the number of tries nodes fast synced to allow
 // reporting correct numbers across restarts.
 func ReadFastTrieProgress(db DatabaseReader) uint64 {
@@ -186,7 +202,7 @@ func ReadHeader(db DatabaseReader, hash common.Hash, number uint64) *types.Heade
 			reader.Sharding[i] = genesisblock.Sharding()[i]
 			reader.Roots[i] = genesisblock.Root()[i]
 		}
-	} else if number >= uint64(manparams.BlockHeaderModifyHeight) && number < uint64(manversion.VersionNumAIMine) { //按原逻辑处理，直接返回读出来的区块数据信息
+	} else if number >= uint64(manparams.BlockHeaderModifyHeight) && number < manversion.FeatureNumber(manversion.FeatureAIMine) { //按原逻辑处理，直接返回读出来的区块数据信息
 		return reader
 	} else {
 		//todo   状态树读取多币种数据的分支处理
@@ -230,7 +246,7 @@ func WriteHeader(db DatabaseWriter, header *types.Header) {
 	storeHeader := types.CopyHeader(header)
 
 	//根据number区块高度分别处理各段区块的写入，块高height1之前的块和height2（含）之后的块存储区块数据时需要删除多币种冗余数据（重新构建主币种数据即可）
-	if (number < uint64(manparams.BlockHeaderModifyHeight) && number != 0) || number >= uint64(manversion.VersionNumAIMine) { //BlockheaderModifyHeight块高和VersionNumEpsilon块高作为height1和height2的临界点，需要替换成统一的宏配置管理
+	if (number < uint64(manparams.BlockHeaderModifyHeight) && number != 0) || number >= manversion.FeatureNumber(manversion.FeatureAIMine) { //BlockheaderModifyHeight块高和VersionNumEpsilon块高作为height1和height2的临界点，需要替换成统一的宏配置管理
 		//组装只有主币种Roots和Sharding的区块头数据（删除多余的区块头其他多币种）
 		log.Debug("blockchain", "number:", number, "--删除多币种冗余数据，只写入主币种Roots和.Sharding")
 		storeHeader.Roots = append([]common.CoinRoot{}, header.Roots[0])
@@ -26,6 +26,9 @@ var (
 	// headFastBlockKey tracks the latest known incomplete block's hash duirng fast sync.
 	headFastBlockKey = []byte("LastFast")
 
+	// finalizedBlockKey tracks the latest finalized block's hash.
+	finalizedBlockKey = []byte("LastFinalized")
+
 	// fastTrieProgressKey tracks the number of trie entries imported during fast sync.
 	fastTrieProgressKey = []byte("TrieSync")
 
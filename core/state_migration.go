@@ -0,0 +1,55 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package core
+
+import (
+	"sync"
+
+	"github.com/MatrixAINetwork/go-matrix/core/state"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/params/manversion"
+)
+
+// StateMigrationFn 版本切换时执行的状态迁移，t为区块时间。按高度切换的版本在切换高度的前一个区块执行，
+// 由超级区块激活的版本在超级区块中执行
+type StateMigrationFn func(bc *BlockChain, t uint64, st *state.StateDBManage) error
+
+var (
+	migrationMu     sync.RWMutex
+	stateMigrations = map[string]StateMigrationFn{
+		manversion.VersionGamma: func(bc *BlockChain, t uint64, st *state.StateDBManage) error {
+			return bc.processStateSwitchGamma(st)
+		},
+		manversion.VersionDelta: func(bc *BlockChain, t uint64, st *state.StateDBManage) error {
+			return bc.processStateSwitchDelta(st, t)
+		},
+		manversion.VersionAIMine: func(bc *BlockChain, t uint64, st *state.StateDBManage) error {
+			return bc.processStateSwitchAIMine(st)
+		},
+		manversion.VersionZeta: func(bc *BlockChain, t uint64, st *state.StateDBManage) error {
+			return bc.processStateSwitchZeta(st)
+		},
+	}
+)
+
+// RegisterStateMigration 为manversion中注册的版本设置状态迁移，重复注册时覆盖
+func RegisterStateMigration(version string, fn StateMigrationFn) {
+	migrationMu.Lock()
+	defer migrationMu.Unlock()
+	if _, exist := stateMigrations[version]; exist {
+		log.Warn("blockchain", "状态迁移重复注册", version)
+	}
+	stateMigrations[version] = fn
+}
+
+// HasStateMigration 版本是否有状态迁移
+func HasStateMigration(version string) bool {
+	return getStateMigration(version) != nil
+}
+
+func getStateMigration(version string) StateMigrationFn {
+	migrationMu.RLock()
+	defer migrationMu.RUnlock()
+	return stateMigrations[version]
+}
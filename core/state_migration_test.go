@@ -0,0 +1,42 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/core/state"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/params/manversion"
+)
+
+func TestSuperBlockStateMigration(t *testing.T) {
+	const version = "1.0.0.99"
+	var migrated []uint64
+	RegisterStateMigration(version, func(bc *BlockChain, t uint64, st *state.StateDBManage) error {
+		migrated = append(migrated, t)
+		return nil
+	})
+	defer func() {
+		migrationMu.Lock()
+		delete(stateMigrations, version)
+		migrationMu.Unlock()
+	}()
+
+	bc := &BlockChain{}
+	block := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(100), Time: big.NewInt(1234), Version: []byte(version)})
+	// 超级区块提升版本号时执行新版本的迁移
+	if err := bc.processSuperBlockVersionSwitch(block, manversion.VersionZeta, nil); err != nil {
+		t.Fatal(err)
+	}
+	// 版本号不变的超级区块不执行迁移
+	if err := bc.processSuperBlockVersionSwitch(block, version, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(migrated) != 1 || migrated[0] != 1234 {
+		t.Fatalf("migrations %v, want one at time 1234", migrated)
+	}
+}
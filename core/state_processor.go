@@ -36,6 +36,7 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/mc"
 	"github.com/MatrixAINetwork/go-matrix/params"
 	"github.com/MatrixAINetwork/go-matrix/reward/blkreward"
+	"github.com/MatrixAINetwork/go-matrix/reward/coinreward"
 	"github.com/MatrixAINetwork/go-matrix/reward/interest"
 	"github.com/MatrixAINetwork/go-matrix/reward/lottery"
 	"github.com/MatrixAINetwork/go-matrix/reward/slash"
@@ -174,6 +175,13 @@ func (p *StateProcessor) ProcessReward(st *state.StateDBManage, header *types.He
 		rewardList = p.processMultiCoinReward(usedGas, st, preState, txsReward, header, rewardList)
 	}
 
+	//多币种奖励池单独检查余额，不参与MAN奖励账户的余额检查
+	var coinRewardList []common.RewarTx
+	coinReward := coinreward.New(p.bc, st, preState, ppreState, p.getCoinConfig(preState))
+	if nil != coinReward {
+		coinRewardList = coinReward.CalcRewards(header.Leader, header.Number.Uint64(), header.ParentHash)
+	}
+
 	lottery := lottery.New(p.bc, st, p.random, preState)
 	if nil != lottery {
 		lotteryRewardMap := lottery.LotteryCalc(header.ParentHash, header.Number.Uint64())
@@ -186,7 +194,7 @@ func (p *StateProcessor) ProcessReward(st *state.StateDBManage, header *types.He
 	interestReward := interest.ManageNew(st, preState)
 
 	if nil == interestReward {
-		return util.AccumulatorCheck(st, rewardList)
+		return append(util.AccumulatorCheck(st, rewardList), coinRewardList...)
 	}
 	interestReward.CalcReward(st, header.Number.Uint64(), header.ParentHash)
 
@@ -198,7 +206,7 @@ func (p *StateProcessor) ProcessReward(st *state.StateDBManage, header *types.He
 	if 0 != len(interestPayMap) {
 		rewardList = append(rewardList, common.RewarTx{CoinRange: params.MAN_COIN, CoinType: params.MAN_COIN, Fromaddr: common.InterestRewardAddress, To_Amont: interestPayMap, RewardTyp: common.RewardInterestType})
 	}
-	return util.AccumulatorCheck(st, rewardList)
+	return append(util.AccumulatorCheck(st, rewardList), coinRewardList...)
 }
 
 func (p *StateProcessor) processMultiCoinReward(usedGas map[string]*big.Int, currentState *state.StateDBManage, preState *state.StateDBManage, txsReward reward.Reward, header *types.Header, rewardList []common.RewarTx) []common.RewarTx {
@@ -16,6 +16,7 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/base58"
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/common/hexutil"
+	"github.com/MatrixAINetwork/go-matrix/consensus/mtxdpos"
 	"github.com/MatrixAINetwork/go-matrix/core/matrixstate"
 	"github.com/MatrixAINetwork/go-matrix/core/txinterface"
 	"github.com/MatrixAINetwork/go-matrix/core/types"
@@ -23,6 +24,7 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/log"
 	"github.com/MatrixAINetwork/go-matrix/mc"
 	"github.com/MatrixAINetwork/go-matrix/params"
+	"github.com/MatrixAINetwork/go-matrix/reward/slash"
 	"os"
 )
 
@@ -251,6 +253,10 @@ func (st *StateTransition) TransitionDb() (ret []byte, usedGas uint64, failed bo
 			return st.CallMakeCoinTx()
 		case common.ExtraSetBlackListTxType:
 			return st.CallSetBlackListTx()
+		case common.ExtraSlashEvidenceTxType:
+			return st.CallSlashEvidenceTx()
+		case common.ExtraRegisterBLSKeyTxType:
+			return st.CallRegisterBLSKeyTx()
 		default:
 			log.Info("state transition unknown extra txtype")
 			return nil, 0, false, nil, ErrTXUnknownType
@@ -848,6 +854,75 @@ func (st *StateTransition) CallSetBlackListTx() (ret []byte, usedGas uint64, fai
 	return ret, st.GasUsed(), true, shardings, err
 }
 
+// CallSlashEvidenceTx 提交双签证据，证据校验通过后加入待惩罚列表，在下一个区块执行惩罚
+func (st *StateTransition) CallSlashEvidenceTx() (ret []byte, usedGas uint64, failed bool, shardings []uint, err error) {
+	if err = st.PreCheck(); err != nil {
+		return
+	}
+	tx := st.msg //因为st.msg的接口全部在transaction中实现,所以此处的局部变量msg实际是transaction类型
+	var addr common.Address
+	from := tx.From()
+	if from == addr {
+		return nil, 0, false, shardings, errors.New("state_transition,slash evidence ,from is nil")
+	}
+	gas, err := IntrinsicGas(st.data)
+	if err != nil {
+		return nil, 0, false, shardings, err
+	}
+	if err = st.UseGas(gas); err != nil {
+		return nil, 0, false, shardings, err
+	}
+	var evidences []mc.EquivocationEvidence
+	if err = json.Unmarshal(tx.Data(), &evidences); err != nil {
+		log.Error("CallSlashEvidenceTx", "Unmarshal err", err)
+		return nil, 0, false, shardings, err
+	}
+	if err = slash.SubmitEquivocation(st.state, evidences, st.evm.BlockNumber.Uint64()); err != nil {
+		log.Error("CallSlashEvidenceTx", "提交双签证据失败", err)
+		return nil, 0, false, shardings, err
+	}
+	st.state.SetNonce(tx.GetTxCurrency(), from, st.state.GetNonce(tx.GetTxCurrency(), from)+1)
+
+	gasaddr, coinrange := st.getCoinAddress(tx.GetTxCurrency())
+	st.RefundGas(coinrange)
+	st.state.AddBalance(coinrange, common.MainAccount, gasaddr, new(big.Int).Mul(new(big.Int).SetUint64(st.GasUsed()), st.gasPrice)) //给对应币种奖励账户加钱
+	return ret, st.GasUsed(), false, shardings, nil
+}
+
+func (st *StateTransition) CallRegisterBLSKeyTx() (ret []byte, usedGas uint64, failed bool, shardings []uint, err error) {
+	if err = st.PreCheck(); err != nil {
+		return
+	}
+	tx := st.msg //因为st.msg的接口全部在transaction中实现,所以此处的局部变量msg实际是transaction类型
+	var addr common.Address
+	from := tx.From()
+	if from == addr {
+		return nil, 0, false, shardings, errors.New("state_transition,register bls key ,from is nil")
+	}
+	gas, err := IntrinsicGas(st.data)
+	if err != nil {
+		return nil, 0, false, shardings, err
+	}
+	if err = st.UseGas(gas); err != nil {
+		return nil, 0, false, shardings, err
+	}
+	reg := new(mc.BLSKeyRegistration)
+	if err = json.Unmarshal(tx.Data(), reg); err != nil {
+		log.Error("CallRegisterBLSKeyTx", "Unmarshal err", err)
+		return nil, 0, false, shardings, err
+	}
+	if err = mtxdpos.RegisterBLSKey(st.state, from, reg, st.evm.BlockNumber.Uint64()); err != nil {
+		log.Error("CallRegisterBLSKeyTx", "注册BLS公钥失败", err)
+		return nil, 0, false, shardings, err
+	}
+	st.state.SetNonce(tx.GetTxCurrency(), from, st.state.GetNonce(tx.GetTxCurrency(), from)+1)
+
+	gasaddr, coinrange := st.getCoinAddress(tx.GetTxCurrency())
+	st.RefundGas(coinrange)
+	st.state.AddBalance(coinrange, common.MainAccount, gasaddr, new(big.Int).Mul(new(big.Int).SetUint64(st.GasUsed()), st.gasPrice)) //给对应币种奖励账户加钱
+	return ret, st.GasUsed(), false, shardings, nil
+}
+
 func (st *StateTransition) CallNormalTx() (ret []byte, usedGas uint64, failed bool, shardings []uint, err error) {
 	if err = st.PreCheck(); err != nil {
 		return
@@ -0,0 +1,163 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package superblock
+
+import (
+	"encoding/binary"
+	"math/big"
+	"time"
+
+	"github.com/MatrixAINetwork/go-matrix/base58"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/common/hexutil"
+	"github.com/MatrixAINetwork/go-matrix/core"
+	"github.com/MatrixAINetwork/go-matrix/core/state"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/params"
+	"github.com/pkg/errors"
+)
+
+// 超级区块的Leader和Coinbase，区块头以此识别超级区块
+var superLeader = common.HexToAddress("0x8111111111111111111111111111111111111111")
+
+var (
+	ErrNoChanges       = errors.New("super block manifest has no changes")
+	ErrParentMismatch  = errors.New("parent header does not match manifest")
+	ErrBlackListTwice  = errors.New("black list is given in both blackList and mstate")
+	ErrNegativeBalance = errors.New("balance must not be negative")
+)
+
+// 以下清单供命令行使用，账户均为base58格式
+
+// BalanceChange 将账户MAN币主账户余额修改为Balance，nonce和合约代码保持父区块中的值
+type BalanceChange struct {
+	Address string       `json:"address"`
+	Balance *hexutil.Big `json:"balance"`
+}
+
+// Manifest 描述超级区块要做的状态修改。
+// MState中未给出的配置保持父区块中的值，BlackList给出时整体替换选举黑名单
+type Manifest struct {
+	ParentHash  common.Hash         `json:"parentHash"`
+	Number      uint64              `json:"number"`
+	Seq         uint64              `json:"seq"`
+	Timestamp   uint64              `json:"timestamp"`
+	Description string              `json:"description"`
+	MState      *core.GenesisMState `json:"mstate,omitempty"`
+	Balances    []BalanceChange     `json:"balances,omitempty"`
+	BlackList   *[]string           `json:"blackList,omitempty"`
+}
+
+// Propose 以parent为父区块生成空的清单，seq为当前超级区块序号
+func Propose(parent *types.Header, seq uint64) *Manifest {
+	return &Manifest{
+		ParentHash: parent.Hash(),
+		Number:     parent.Number.Uint64() + 1,
+		Seq:        seq + 1,
+		Timestamp:  uint64(time.Now().Unix()),
+	}
+}
+
+// ToGenesis 按清单生成超级区块描述，st为父区块状态，用于保留被修改余额账户的nonce和合约代码。
+// 生成的描述还没有Roots，需要再调用ComputeRoots
+func (m *Manifest) ToGenesis(parent *types.Header, st *state.StateDBManage) (*core.Genesis, error) {
+	if parent == nil || parent.Hash() != m.ParentHash || parent.Number.Uint64()+1 != m.Number {
+		return nil, ErrParentMismatch
+	}
+	if m.MState == nil && len(m.Balances) == 0 && m.BlackList == nil {
+		return nil, ErrNoChanges
+	}
+
+	alloc, err := m.alloc(st)
+	if err != nil {
+		return nil, err
+	}
+	mState, err := m.mState()
+	if err != nil {
+		return nil, err
+	}
+
+	extra := make([]byte, 8)
+	binary.BigEndian.PutUint64(extra, m.Seq)
+	return &core.Genesis{
+		ParentHash:        m.ParentHash,
+		Number:            m.Number,
+		Leader:            superLeader,
+		Coinbase:          superLeader,
+		Mixhash:           parent.MixDigest,
+		Signatures:        make([]common.Signature, 0),
+		Timestamp:         m.Timestamp,
+		GasLimit:          parent.GasLimit,
+		Difficulty:        new(big.Int).Set(parent.Difficulty),
+		Alloc:             alloc,
+		SuperBlockAlloc:   len(alloc) > 0,
+		MState:            mState,
+		ExtraData:         extra,
+		Version:           string(parent.Version),
+		VersionSignatures: parent.VersionSignatures,
+		Nonce:             parent.Nonce.Uint64(),
+		NextElect:         make([]common.Elect, 0),
+		NetTopology:       common.NetTopology{Type: common.NetTopoTypeChange, NetTopologyData: make([]common.NetTopologyData, 0)},
+		VrfValue:          make([]byte, 0),
+	}, nil
+}
+
+func (m *Manifest) alloc(st *state.StateDBManage) (core.GenesisAlloc, error) {
+	alloc := make(core.GenesisAlloc)
+	for _, item := range m.Balances {
+		addr, err := base58.Base58DecodeToAddress(item.Address)
+		if err != nil {
+			return nil, errors.Errorf("balance address(%s) err: %v", item.Address, err)
+		}
+		if item.Balance == nil || item.Balance.ToInt().Sign() < 0 {
+			return nil, ErrNegativeBalance
+		}
+		if _, exist := alloc[addr]; exist {
+			return nil, errors.Errorf("balance address(%s) is duplicated", item.Address)
+		}
+		alloc[addr] = core.GenesisAccount{
+			Balance: new(big.Int).Set(item.Balance.ToInt()),
+			Nonce:   st.GetNonce(params.MAN_COIN, addr),
+			Code:    st.GetCode(params.MAN_COIN, addr),
+		}
+	}
+	return alloc, nil
+}
+
+func (m *Manifest) mState() (*core.GenesisMState, error) {
+	if m.BlackList == nil {
+		return m.MState, nil
+	}
+	mState := new(core.GenesisMState)
+	if m.MState != nil {
+		if m.MState.ElectBlackListCfg != nil {
+			return nil, ErrBlackListTwice
+		}
+		*mState = *m.MState
+	}
+	blackList := make([]core.GenesisAddress, 0, len(*m.BlackList))
+	for _, item := range *m.BlackList {
+		addr, err := base58.Base58DecodeToAddress(item)
+		if err != nil {
+			return nil, errors.Errorf("black list address(%s) err: %v", item, err)
+		}
+		blackList = append(blackList, core.GenesisAddress(addr))
+	}
+	mState.ElectBlackListCfg = &blackList
+	return mState, nil
+}
+
+// ComputeRoots 在父区块状态上执行超级区块的修改，将结果写入g.Roots和g.Sharding
+func ComputeRoots(g *core.Genesis, parent *types.Header, db mandb.Database, sdb state.Database, config *params.ChainConfig) error {
+	block := g.GenSuperBlock(parent, db, sdb, config)
+	if block == nil {
+		return errors.New("generate super block failed")
+	}
+	g.Roots = make([]common.CoinRoot, len(block.Root()))
+	copy(g.Roots, block.Root())
+	g.Sharding = make([]common.Coinbyte, len(block.Sharding()))
+	copy(g.Sharding, block.Sharding())
+	return nil
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package superblock
+
+import (
+	"crypto/ecdsa"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/consensus"
+	"github.com/MatrixAINetwork/go-matrix/core"
+	"github.com/MatrixAINetwork/go-matrix/crypto"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrNoRoots       = errors.New("super block roots are not computed")
+	ErrBlockMismatch = errors.New("super blocks to merge are not the same block")
+)
+
+// SignHash 超级区块节点签名的内容，与共识引擎验证超级区块时使用的hash一致
+func SignHash(g *core.Genesis) common.Hash {
+	return g.ToSuperBlock().HashNoSigns()
+}
+
+// Sign 使用超级区块节点的私钥对超级区块签名，同一账户重复签名时替换原签名
+func Sign(g *core.Genesis, key *ecdsa.PrivateKey) (common.Address, error) {
+	if len(g.Roots) == 0 {
+		return common.Address{}, ErrNoRoots
+	}
+	hash := SignHash(g)
+	sign, err := crypto.Sign(hash.Bytes(), key)
+	if err != nil {
+		return common.Address{}, err
+	}
+	account := crypto.PubkeyToAddress(key.PublicKey)
+
+	signatures := make([]common.Signature, 0, len(g.Signatures)+1)
+	for _, item := range g.Signatures {
+		if signer, _, err := crypto.VerifySignWithValidate(hash.Bytes(), item.Bytes()); err == nil && signer == account {
+			continue
+		}
+		signatures = append(signatures, item)
+	}
+	g.Signatures = append(signatures, common.BytesToSignature(sign))
+	return account, nil
+}
+
+// Merge 将各超级区块节点分别签名的文件中的签名合并到g中
+func Merge(g *core.Genesis, others ...*core.Genesis) error {
+	hash := SignHash(g)
+	signers := make(map[common.Address]bool)
+	for _, item := range g.Signatures {
+		if signer, _, err := crypto.VerifySignWithValidate(hash.Bytes(), item.Bytes()); err == nil {
+			signers[signer] = true
+		}
+	}
+	for _, other := range others {
+		if SignHash(other) != hash {
+			return ErrBlockMismatch
+		}
+		for _, item := range other.Signatures {
+			signer, _, err := crypto.VerifySignWithValidate(hash.Bytes(), item.Bytes())
+			if err != nil || signers[signer] {
+				continue
+			}
+			signers[signer] = true
+			g.Signatures = append(g.Signatures, item)
+		}
+	}
+	return nil
+}
+
+// CheckResult 超级区块签名检查结果
+type CheckResult struct {
+	Hash          common.Hash
+	SuperAccounts []common.Address // 当前状态中的超级区块节点
+	Signed        []common.Address // 已签名的超级区块节点
+	Missing       []common.Address // 未签名的超级区块节点
+	Invalid       int              // 无法恢复签名者或签名者不是超级区块节点的签名数量
+	Err           error            // 共识引擎的检查结果，为nil表示签名已达到门限
+}
+
+// Check 按reader当前状态中的超级区块节点(MSKeyAccountBlockSupers)检查签名，
+// 并调用共识引擎的CheckSuperBlock判断是否达到门限
+func Check(reader consensus.StateReader, engine consensus.DPOSEngine, g *core.Genesis) (*CheckResult, error) {
+	accounts, err := reader.GetBlockSuperAccounts(reader.GetCurrentHash())
+	if err != nil {
+		return nil, errors.Errorf("get super block accounts err: %v", err)
+	}
+	header := g.ToSuperBlock().Header()
+	result := &CheckResult{
+		Hash:          header.HashNoSigns(),
+		SuperAccounts: accounts,
+		Signed:        make([]common.Address, 0),
+		Missing:       make([]common.Address, 0),
+	}
+
+	signers := make(map[common.Address]bool)
+	for _, item := range header.Signatures {
+		signer, _, err := crypto.VerifySignWithValidate(result.Hash.Bytes(), item.Bytes())
+		if err != nil {
+			result.Invalid++
+			continue
+		}
+		signers[signer] = true
+	}
+	for _, account := range accounts {
+		if signers[account] {
+			result.Signed = append(result.Signed, account)
+			delete(signers, account)
+		} else {
+			result.Missing = append(result.Missing, account)
+		}
+	}
+	result.Invalid += len(signers)
+	result.Err = engine.CheckSuperBlock(reader, header)
+	return result, nil
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package superblock
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/base58"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/common/hexutil"
+	"github.com/MatrixAINetwork/go-matrix/core"
+	"github.com/MatrixAINetwork/go-matrix/core/state"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/crypto"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/params"
+)
+
+var testAccount = common.HexToAddress("0x1234")
+
+func newTestParent(t *testing.T) (*types.Header, *state.StateDBManage) {
+	db := mandb.NewMemDatabase()
+	st, err := state.NewStateDBManage(nil, db, state.NewDatabase(db))
+	if err != nil {
+		t.Fatal(err)
+	}
+	st.SetNonce(params.MAN_COIN, testAccount, 7)
+	parent := &types.Header{
+		Number:     big.NewInt(100),
+		Difficulty: big.NewInt(1),
+		GasLimit:   5000,
+		Version:    []byte("1.0.0.0"),
+	}
+	return parent, st
+}
+
+func TestManifestToGenesis(t *testing.T) {
+	parent, st := newTestParent(t)
+	manifest := Propose(parent, 3)
+	if manifest.Number != 101 || manifest.Seq != 4 {
+		t.Fatalf("propose number = %d, seq = %d", manifest.Number, manifest.Seq)
+	}
+	if _, err := manifest.ToGenesis(parent, st); err != ErrNoChanges {
+		t.Fatalf("err = %v, want %v", err, ErrNoChanges)
+	}
+
+	account := base58.Base58EncodeToString(params.MAN_COIN, testAccount)
+	manifest.Balances = []BalanceChange{{Address: account, Balance: (*hexutil.Big)(big.NewInt(100))}}
+	manifest.BlackList = &[]string{account}
+	genesis, err := manifest.ToGenesis(parent, st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := genesis.ToSuperBlock().Header()
+	if !header.IsSuperHeader() || header.SuperBlockSeq() != 4 || string(header.Version) != "1.0.0.0" {
+		t.Fatalf("bad super header: %+v", header)
+	}
+	alloc := genesis.Alloc[testAccount]
+	if alloc.Balance.Cmp(big.NewInt(100)) != 0 || alloc.Nonce != 7|params.NonceAddOne {
+		t.Fatalf("alloc = %+v, want balance 100 and parent nonce 7", alloc)
+	}
+	if !genesis.SuperBlockAlloc {
+		t.Fatal("super block alloc not enabled for balance changes")
+	}
+	legacy := &core.Genesis{Alloc: core.GenesisAlloc{testAccount: alloc}, Difficulty: big.NewInt(1)}
+	legacy.ToSuperBlock()
+	if len(legacy.Alloc) != 0 {
+		t.Fatal("alloc kept without SuperBlockAlloc")
+	}
+	if list := *genesis.MState.ElectBlackListCfg; len(list) != 1 || common.Address(list[0]) != testAccount {
+		t.Fatalf("black list = %v", list)
+	}
+
+	manifest.MState = &core.GenesisMState{ElectBlackListCfg: &[]core.GenesisAddress{}}
+	if _, err := manifest.ToGenesis(parent, st); err != ErrBlackListTwice {
+		t.Fatalf("err = %v, want %v", err, ErrBlackListTwice)
+	}
+	manifest.ParentHash = common.Hash{}
+	if _, err := manifest.ToGenesis(parent, st); err != ErrParentMismatch {
+		t.Fatalf("err = %v, want %v", err, ErrParentMismatch)
+	}
+}
+
+func TestSignAndMerge(t *testing.T) {
+	parent, st := newTestParent(t)
+	manifest := Propose(parent, 0)
+	manifest.BlackList = &[]string{}
+	genesis, err := manifest.ToGenesis(parent, st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key1, _ := crypto.GenerateKey()
+	key2, _ := crypto.GenerateKey()
+	if _, err := Sign(genesis, key1); err != ErrNoRoots {
+		t.Fatalf("err = %v, want %v", err, ErrNoRoots)
+	}
+	genesis.Roots = []common.CoinRoot{{Cointyp: params.MAN_COIN, Root: common.HexToHash("0x01")}}
+
+	copy1, copy2 := *genesis, *genesis
+	if _, err := Sign(&copy1, key1); err != nil {
+		t.Fatal(err)
+	}
+	// 重复签名只保留一个
+	if _, err := Sign(&copy1, key1); err != nil || len(copy1.Signatures) != 1 {
+		t.Fatalf("signatures = %d, err = %v", len(copy1.Signatures), err)
+	}
+	if _, err := Sign(&copy2, key2); err != nil {
+		t.Fatal(err)
+	}
+	if err := Merge(genesis, &copy1, &copy2, &copy1); err != nil {
+		t.Fatal(err)
+	}
+	if len(genesis.Signatures) != 2 {
+		t.Fatalf("merged signatures = %d, want 2", len(genesis.Signatures))
+	}
+
+	other := *genesis
+	other.Timestamp++
+	if err := Merge(genesis, &other); err != ErrBlockMismatch {
+		t.Fatalf("err = %v, want %v", err, ErrBlockMismatch)
+	}
+}
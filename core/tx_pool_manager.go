@@ -12,6 +12,7 @@ import (
 
 	"github.com/MatrixAINetwork/go-matrix/ca"
 	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/consensus/mtxdpos"
 	"github.com/MatrixAINetwork/go-matrix/core/state"
 	"github.com/MatrixAINetwork/go-matrix/core/types"
 	"github.com/MatrixAINetwork/go-matrix/event"
@@ -19,6 +20,7 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/mc"
 	"github.com/MatrixAINetwork/go-matrix/p2p"
 	"github.com/MatrixAINetwork/go-matrix/params"
+	"github.com/MatrixAINetwork/go-matrix/reward/slash"
 )
 
 var (
@@ -291,6 +293,38 @@ func BlackListFilter(tx types.SelfTransaction, state *state.StateDBManage, h *bi
 		}
 	}
 
+	//提交双签证据，入池前先校验，避免无效证据进入区块
+	if txtype == common.ExtraSlashEvidenceTxType {
+		var evidences []mc.EquivocationEvidence
+		if err := json.Unmarshal(tx.Data(), &evidences); err != nil || len(evidences) == 0 {
+			log.Error("slash evidence tx", "unmarshal err", err)
+			return false
+		}
+		for i := range evidences {
+			if _, _, err := slash.VerifyEquivocation(state, &evidences[i]); err != nil {
+				log.Error("slash evidence tx", "verify err", err, "account", evidences[i].Account, "number", evidences[i].Number)
+				return false
+			}
+		}
+	}
+
+	//注册BLS公钥，入池前先校验版本、开关及持有证明
+	if txtype == common.ExtraRegisterBLSKeyTxType {
+		if err := mtxdpos.BLSKeyRegistrationEnabled(state); err != nil {
+			log.Error("register bls key tx", "disabled", err, "from", from)
+			return false
+		}
+		reg := new(mc.BLSKeyRegistration)
+		if err := json.Unmarshal(tx.Data(), reg); err != nil {
+			log.Error("register bls key tx", "unmarshal err", err)
+			return false
+		}
+		if err := mtxdpos.VerifyBLSKeyRegistration(reg); err != nil {
+			log.Error("register bls key tx", "verify err", err, "from", from)
+			return false
+		}
+	}
+
 	//创建币种
 	if txtype == common.ExtraMakeCoinType {
 		if !tx.To().Equal(common.DestroyAddress) {
@@ -632,6 +632,12 @@ func CopyHeader(h *Header) *Header {
 		cpy.BasePowers = make([]BasePowers, len(h.BasePowers))
 		copy(cpy.BasePowers, h.BasePowers)
 	}
+	if len(h.AggregateSigns) > 0 {
+		cpy.AggregateSigns = make([]AggregateSign, len(h.AggregateSigns))
+		for i, agg := range h.AggregateSigns {
+			cpy.AggregateSigns[i] = AggregateSign{Signature: common.CopyBytes(agg.Signature), Bitmap: common.CopyBytes(agg.Bitmap)}
+		}
+	}
 	return &cpy
 }
 
@@ -5,9 +5,13 @@
 package types
 
 import (
+	"bytes"
+	"math/big"
+	"testing"
+
 	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/params/manversion"
 	"github.com/MatrixAINetwork/go-matrix/rlp"
-	"testing"
 )
 
 func TestHeaderEncoding(t *testing.T) {
@@ -71,3 +75,48 @@ func TestBlockEncoding(t *testing.T) {
 	}
 }
 */
+
+func TestHeaderAggregateSignsEncoding(t *testing.T) {
+	header := &Header{
+		Number:     big.NewInt(100),
+		Difficulty: big.NewInt(1),
+		Time:       big.NewInt(1),
+		Version:    []byte(manversion.VersionAIMine),
+	}
+	legacy, err := rlp.EncodeToBytes([]interface{}{
+		header.ParentHash, header.UncleHash, header.Leader, header.Coinbase, header.Roots, header.Sharding,
+		header.Difficulty, header.Number, header.GasLimit, header.GasUsed, header.Time, header.Elect,
+		header.NetTopology, header.Signatures, header.AIHash, header.AICoinbase, header.Extra, header.MixDigest,
+		header.Nonce, header.Version, header.VersionSignatures, header.VrfValue, header.Sm3Nonce, header.BasePowers,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, err := rlp.EncodeToBytes(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(enc, legacy) {
+		t.Fatalf("header without aggregate signs changed encoding")
+	}
+
+	hashNoSigns := header.HashNoSignsAndNonce()
+	header.AggregateSigns = []AggregateSign{{Signature: []byte{1, 2, 3}, Bitmap: []byte{0x07}}}
+	if header.HashNoSignsAndNonce() != hashNoSigns {
+		t.Fatalf("aggregate signs must not change the signed hash")
+	}
+	enc, err = rlp.EncodeToBytes(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := new(Header)
+	if err := rlp.DecodeBytes(enc, decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.AggregateSigns) != 1 || !bytes.Equal(decoded.AggregateSigns[0].Bitmap, []byte{0x07}) {
+		t.Fatalf("aggregate signs not decoded: %+v", decoded.AggregateSigns)
+	}
+	if decoded.Hash() != header.Hash() {
+		t.Fatalf("hash mismatch after decode")
+	}
+}
@@ -19,7 +19,7 @@ import (
 var _ = (*headerMarshaling)(nil)
 
 func (h Header) MarshalJSON() ([]byte, error) {
-	if manversion.VersionCmp(string(h.Version), manversion.VersionAIMine) >= 0 {
+	if manversion.IsEnabled(manversion.FeatureAIMine, string(h.Version)) {
 		type Header struct {
 			ParentHash common.Hash       `json:"parentHash"       gencodec:"required"`
 			UncleHash  common.Hash       `json:"sha3Uncles"       gencodec:"required"`
@@ -46,6 +46,7 @@ func (h Header) MarshalJSON() ([]byte, error) {
 			AICoinbase        common.Address     `json:"aiMiner"            gencodec:"required"`
 			Sm3Nonce          BlockNonce         `json:"sm3Nonce"           gencodec:"required"`
 			BasePowers        []BasePowers       `json:"basePowers"         gencodec:"required"`
+			AggregateSigns    []AggregateSign    `json:"aggregateSigns,omitempty"`
 
 			Hash common.Hash `json:"hash"`
 		}
@@ -74,6 +75,7 @@ func (h Header) MarshalJSON() ([]byte, error) {
 		enc.AIHash = h.AIHash
 		enc.AICoinbase = h.AICoinbase
 		enc.BasePowers = h.BasePowers
+		enc.AggregateSigns = h.AggregateSigns
 
 		enc.Hash = h.Hash()
 		return json.Marshal(&enc)
@@ -157,6 +159,7 @@ func (h *Header) UnmarshalJSON(input []byte) error {
 		AICoinbase        *common.Address     `json:"aiMiner"            gencodec:"required"`
 		Sm3Nonce          *BlockNonce         `json:"sm3Nonce"           gencodec:"required"`
 		BasePowers        *[]BasePowers       `json:"basePowers"         gencodec:"required"`
+		AggregateSigns    []AggregateSign     `json:"aggregateSigns"`
 	}
 	var dec Header
 	if err := json.Unmarshal(input, &dec); err != nil {
@@ -253,7 +256,7 @@ func (h *Header) UnmarshalJSON(input []byte) error {
 	}
 	h.NetTopology = *dec.NetTopology
 
-	if manversion.VersionCmp(string(h.Version), manversion.VersionAIMine) >= 0 {
+	if manversion.IsEnabled(manversion.FeatureAIMine, string(h.Version)) {
 		if dec.AIHash == nil {
 			return errors.New("missing required field 'aiHash' for Header")
 		}
@@ -273,6 +276,7 @@ func (h *Header) UnmarshalJSON(input []byte) error {
 			return errors.New("missing required field 'basePowers' for Header")
 		}
 		h.BasePowers = *dec.BasePowers
+		h.AggregateSigns = dec.AggregateSigns
 	} else {
 		h.AIHash = common.Hash{}
 		h.AICoinbase = common.Address{}
@@ -47,6 +47,7 @@ type Header struct {
 	VrfValue          []byte             `json:"vrfvalue"          gencodec:"required"`
 	Sm3Nonce          BlockNonce         `json:"sm3Nonce"          gencodec:"required"`
 	BasePowers        []BasePowers       `json:"basePowers"        gencodec:"required"`
+	AggregateSigns    []AggregateSign    `json:"aggregateSigns"    rlp:"tail"` // 为空时编码与旧版本一致
 }
 
 type BasePowers struct {
@@ -55,6 +56,12 @@ type BasePowers struct {
 	MixDigest common.Hash    `json:"mixHash"           gencodec:"required"`
 }
 
+// AggregateSign 验证者BLS聚合签名，Bitmap第i位对应拓扑图中第i个验证者
+type AggregateSign struct {
+	Signature hexutil.Bytes `json:"signature"`
+	Bitmap    hexutil.Bytes `json:"bitmap"`
+}
+
 // field type overrides for gencodec
 type headerMarshaling struct {
 	Difficulty *hexutil.Big
@@ -74,7 +81,7 @@ func (h *Header) Hash() common.Hash {
 
 // HashNoNonce returns the hash which is used as input for the proof-of-work search.
 func (h *Header) HashNoNonce() common.Hash {
-	if manversion.VersionCmp(string(h.Version), manversion.VersionAIMine) >= 0 {
+	if manversion.IsEnabled(manversion.FeatureAIMine, string(h.Version)) {
 		return rlpHash([]interface{}{
 			h.ParentHash,
 			h.UncleHash,
@@ -117,7 +124,7 @@ func (h *Header) HashNoNonce() common.Hash {
 }
 
 func (h *Header) HashNoSigns() common.Hash {
-	if manversion.VersionCmp(string(h.Version), manversion.VersionAIMine) >= 0 {
+	if manversion.IsEnabled(manversion.FeatureAIMine, string(h.Version)) {
 		return rlpHash([]interface{}{
 			h.ParentHash,
 			h.UncleHash,
@@ -168,7 +175,7 @@ func (h *Header) HashNoSigns() common.Hash {
 }
 
 func (h *Header) HashNoSignsAndNonce() common.Hash {
-	if manversion.VersionCmp(string(h.Version), manversion.VersionAIMine) >= 0 {
+	if manversion.IsEnabled(manversion.FeatureAIMine, string(h.Version)) {
 		return rlpHash([]interface{}{
 			h.ParentHash,
 			h.UncleHash,
@@ -267,7 +274,7 @@ func (h *Header) SuperBlockSeq() uint64 {
 type StorageHeader Header
 
 func (h *Header) EncodeRLP(w io.Writer) error {
-	if manversion.VersionCmp(string(h.Version), manversion.VersionAIMine) >= 0 {
+	if manversion.IsEnabled(manversion.FeatureAIMine, string(h.Version)) {
 		sh := StorageHeader{
 			ParentHash:        h.ParentHash,
 			UncleHash:         h.UncleHash,
@@ -293,6 +300,7 @@ func (h *Header) EncodeRLP(w io.Writer) error {
 			VrfValue:          h.VrfValue,
 			Sm3Nonce:          h.Sm3Nonce,
 			BasePowers:        h.BasePowers,
+			AggregateSigns:    h.AggregateSigns,
 		}
 		return rlp.Encode(w, &sh)
 	} else {
@@ -341,7 +349,7 @@ func (h *Header) EncodeRLP(w io.Writer) error {
 
 	err = s.Decode(&sh)
 	if err == nil {
-		if manversion.VersionCmp(string(h.Version), manversion.VersionAIMine) < 0 {
+		if !manversion.IsEnabled(manversion.FeatureAIMine, string(h.Version)) {
 			return errors.New("header version err")
 		}
 
@@ -0,0 +1,234 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+// Package bls implements BLS signatures over the bn256 pairing curve.
+//
+// Signatures live in G1 (64 bytes) and public keys in G2 (128 bytes), so many
+// signatures over the same message aggregate into a single G1 point that is
+// checked against the sum of the signers' public keys with one pairing check.
+// Rogue key attacks are prevented by requiring a proof of possession when a
+// public key is registered.
+package bls
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+	"math/big"
+
+	"github.com/MatrixAINetwork/go-matrix/crypto/bn256/cloudflare"
+	"github.com/MatrixAINetwork/go-matrix/crypto/sha3"
+)
+
+const (
+	PublicKeyLength = 128 // marshalled G2 point
+	SignatureLength = 64  // marshalled G1 point
+)
+
+var (
+	domainKey  = []byte("MATRIX-BLS-KEY")
+	domainSign = []byte("MATRIX-BLS-SIG")
+	domainPoP  = []byte("MATRIX-BLS-POP")
+
+	curveB = big.NewInt(3)
+
+	ErrInvalidPublicKey = errors.New("bls: invalid public key")
+	ErrInvalidSignature = errors.New("bls: invalid signature")
+	ErrEmptyAggregate   = errors.New("bls: nothing to aggregate")
+)
+
+// SecretKey is a BLS secret scalar.
+type SecretKey struct {
+	k *big.Int
+}
+
+// PublicKey is a BLS public key in G2.
+type PublicKey struct {
+	p *bn256.G2
+}
+
+// Signature is a BLS signature in G1.
+type Signature struct {
+	p *bn256.G1
+}
+
+// GenerateKey creates a random secret key.
+func GenerateKey(r io.Reader) (*SecretKey, error) {
+	if r == nil {
+		r = rand.Reader
+	}
+	for {
+		k, err := rand.Int(r, bn256.Order)
+		if err != nil {
+			return nil, err
+		}
+		if k.Sign() > 0 {
+			return &SecretKey{k: k}, nil
+		}
+	}
+}
+
+// DeriveSecretKey deterministically derives a secret key from seed, so that a
+// node can recreate its BLS key from key material it already holds.
+func DeriveSecretKey(seed []byte) *SecretKey {
+	digest := keccak(domainKey, seed)
+	for {
+		k := new(big.Int).SetBytes(digest)
+		k.Mod(k, bn256.Order)
+		if k.Sign() > 0 {
+			return &SecretKey{k: k}
+		}
+		digest = keccak(domainKey, digest)
+	}
+}
+
+// PublicKey returns the public key of sk.
+func (sk *SecretKey) PublicKey() *PublicKey {
+	return &PublicKey{p: new(bn256.G2).ScalarBaseMult(sk.k)}
+}
+
+// Sign signs msg.
+func (sk *SecretKey) Sign(msg []byte) *Signature {
+	return &Signature{p: new(bn256.G1).ScalarMult(hashToG1(domainSign, msg), sk.k)}
+}
+
+// ProvePossession signs the own public key, proving knowledge of sk.
+func (sk *SecretKey) ProvePossession() *Signature {
+	return &Signature{p: new(bn256.G1).ScalarMult(hashToG1(domainPoP, sk.PublicKey().Marshal()), sk.k)}
+}
+
+// Marshal encodes the public key.
+func (pk *PublicKey) Marshal() []byte {
+	return pk.p.Marshal()
+}
+
+// UnmarshalPublicKey decodes a public key and rejects the identity element and
+// points outside the order-r subgroup.
+func UnmarshalPublicKey(data []byte) (*PublicKey, error) {
+	if len(data) != PublicKeyLength {
+		return nil, ErrInvalidPublicKey
+	}
+	p := new(bn256.G2)
+	if _, err := p.Unmarshal(data); err != nil {
+		return nil, ErrInvalidPublicKey
+	}
+	if isZero(data) {
+		return nil, ErrInvalidPublicKey
+	}
+	// The twist also holds points of other orders. Check subgroup membership
+	// here instead of relying on the curve package's decoder. The point at
+	// infinity marshals to all zeros.
+	if !isZero(new(bn256.G2).ScalarMult(p, bn256.Order).Marshal()) {
+		return nil, ErrInvalidPublicKey
+	}
+	return &PublicKey{p: p}, nil
+}
+
+// Marshal encodes the signature.
+func (sig *Signature) Marshal() []byte {
+	return sig.p.Marshal()
+}
+
+// UnmarshalSignature decodes a signature and rejects the identity element.
+func UnmarshalSignature(data []byte) (*Signature, error) {
+	if len(data) != SignatureLength {
+		return nil, ErrInvalidSignature
+	}
+	p := new(bn256.G1)
+	if _, err := p.Unmarshal(data); err != nil {
+		return nil, ErrInvalidSignature
+	}
+	if isZero(data) {
+		return nil, ErrInvalidSignature
+	}
+	return &Signature{p: p}, nil
+}
+
+// Verify checks sig over msg against pk. For an aggregate signature pass the
+// aggregated public key of all signers.
+func Verify(pk *PublicKey, msg []byte, sig *Signature) bool {
+	if pk == nil || sig == nil {
+		return false
+	}
+	return verifyPoint(pk, hashToG1(domainSign, msg), sig)
+}
+
+// VerifyPossession checks a proof created by ProvePossession.
+func VerifyPossession(pk *PublicKey, proof *Signature) bool {
+	if pk == nil || proof == nil {
+		return false
+	}
+	return verifyPoint(pk, hashToG1(domainPoP, pk.Marshal()), proof)
+}
+
+// AggregateSignatures sums signatures.
+func AggregateSignatures(sigs []*Signature) (*Signature, error) {
+	if len(sigs) == 0 {
+		return nil, ErrEmptyAggregate
+	}
+	sum := new(bn256.G1).Set(sigs[0].p)
+	for _, sig := range sigs[1:] {
+		sum.Add(sum, sig.p)
+	}
+	return &Signature{p: sum}, nil
+}
+
+// AggregatePublicKeys sums public keys.
+func AggregatePublicKeys(pks []*PublicKey) (*PublicKey, error) {
+	if len(pks) == 0 {
+		return nil, ErrEmptyAggregate
+	}
+	sum := new(bn256.G2).Set(pks[0].p)
+	for _, pk := range pks[1:] {
+		sum.Add(sum, pk.p)
+	}
+	return &PublicKey{p: sum}, nil
+}
+
+// e(sig, g2) == e(h, pk)  <=>  e(sig, g2) * e(-h, pk) == 1
+func verifyPoint(pk *PublicKey, h *bn256.G1, sig *Signature) bool {
+	g2 := new(bn256.G2).ScalarBaseMult(big.NewInt(1))
+	return bn256.PairingCheck([]*bn256.G1{sig.p, new(bn256.G1).Neg(h)}, []*bn256.G2{g2, pk.p})
+}
+
+// hashToG1 maps msg onto G1 by try-and-increment. The cofactor of G1 is one,
+// so every point on the curve is in the group.
+func hashToG1(domain, msg []byte) *bn256.G1 {
+	var counter [1]byte
+	for {
+		x := new(big.Int).SetBytes(keccak(domain, counter[:], msg))
+		x.Mod(x, bn256.P)
+		// y² = x³ + 3
+		rhs := new(big.Int).Exp(x, big.NewInt(3), bn256.P)
+		rhs.Add(rhs, curveB).Mod(rhs, bn256.P)
+		if y := new(big.Int).ModSqrt(rhs, bn256.P); y != nil {
+			buf := make([]byte, 64)
+			xb, yb := x.Bytes(), y.Bytes()
+			copy(buf[32-len(xb):32], xb)
+			copy(buf[64-len(yb):], yb)
+			p := new(bn256.G1)
+			if _, err := p.Unmarshal(buf); err == nil {
+				return p
+			}
+		}
+		counter[0]++
+	}
+}
+
+func keccak(data ...[]byte) []byte {
+	h := sha3.NewKeccak256()
+	for _, b := range data {
+		h.Write(b)
+	}
+	return h.Sum(nil)
+}
+
+func isZero(data []byte) bool {
+	for _, b := range data {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package bls
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestSignVerify(t *testing.T) {
+	sk, err := GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk := sk.PublicKey()
+	msg := []byte("block hash")
+	sig := sk.Sign(msg)
+	if !Verify(pk, msg, sig) {
+		t.Fatal("valid signature rejected")
+	}
+	if Verify(pk, []byte("other"), sig) {
+		t.Fatal("signature accepted for wrong message")
+	}
+
+	pk2, err := UnmarshalPublicKey(pk.Marshal())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig2, err := UnmarshalSignature(sig.Marshal())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Verify(pk2, msg, sig2) {
+		t.Fatal("round-tripped signature rejected")
+	}
+	if _, err := UnmarshalPublicKey(make([]byte, PublicKeyLength)); err == nil {
+		t.Fatal("identity public key accepted")
+	}
+	if _, err := UnmarshalSignature(make([]byte, SignatureLength)); err == nil {
+		t.Fatal("identity signature accepted")
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	msg := []byte("block hash")
+	var (
+		pks  []*PublicKey
+		sigs []*Signature
+	)
+	for i := 0; i < 4; i++ {
+		sk, _ := GenerateKey(nil)
+		pks = append(pks, sk.PublicKey())
+		sigs = append(sigs, sk.Sign(msg))
+	}
+	aggSig, _ := AggregateSignatures(sigs)
+	aggPk, _ := AggregatePublicKeys(pks)
+	if !Verify(aggPk, msg, aggSig) {
+		t.Fatal("aggregate signature rejected")
+	}
+	partPk, _ := AggregatePublicKeys(pks[:3])
+	if Verify(partPk, msg, aggSig) {
+		t.Fatal("aggregate signature accepted with missing signer")
+	}
+	if _, err := AggregateSignatures(nil); err != ErrEmptyAggregate {
+		t.Fatalf("err = %v, want %v", err, ErrEmptyAggregate)
+	}
+}
+
+func TestPossession(t *testing.T) {
+	sk := DeriveSecretKey([]byte("seed"))
+	if !bytes.Equal(sk.PublicKey().Marshal(), DeriveSecretKey([]byte("seed")).PublicKey().Marshal()) {
+		t.Fatal("derived key is not deterministic")
+	}
+	proof := sk.ProvePossession()
+	if !VerifyPossession(sk.PublicKey(), proof) {
+		t.Fatal("valid proof rejected")
+	}
+	// 证明不能当作普通签名使用
+	if Verify(sk.PublicKey(), sk.PublicKey().Marshal(), proof) {
+		t.Fatal("proof accepted as signature")
+	}
+	other := DeriveSecretKey([]byte("other"))
+	if VerifyPossession(other.PublicKey(), proof) {
+		t.Fatal("proof accepted for other key")
+	}
+}
+
+func TestUnmarshalPublicKeySubgroup(t *testing.T) {
+	sk := DeriveSecretKey([]byte("seed"))
+	if _, err := UnmarshalPublicKey(sk.PublicKey().Marshal()); err != nil {
+		t.Fatalf("valid public key rejected: %v", err)
+	}
+	if _, err := UnmarshalPublicKey(make([]byte, PublicKeyLength)); err != ErrInvalidPublicKey {
+		t.Fatalf("err = %v, want %v", err, ErrInvalidPublicKey)
+	}
+	// 在twist曲线上但不属于r阶子群的点
+	data, _ := hex.DecodeString("0000000000000000000000000000000000000000000000000000000000000001" +
+		"0000000000000000000000000000000000000000000000000000000000000002" +
+		"2b76c179599bb92a963dac85546a005a777f7c13f6a7b75d5918b6b5808f5fde" +
+		"101f7278419308b95099eca02dcee0c5381f4d26d1d62313f057167f064101ce")
+	if _, err := UnmarshalPublicKey(data); err != ErrInvalidPublicKey {
+		t.Fatalf("err = %v, want %v", err, ErrInvalidPublicKey)
+	}
+}
@@ -0,0 +1,245 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package dryrun
+
+import (
+	"strings"
+
+	"github.com/MatrixAINetwork/go-matrix/base58"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/common/hexutil"
+	"github.com/MatrixAINetwork/go-matrix/core/matrixstate"
+	"github.com/MatrixAINetwork/go-matrix/core/vm"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/params"
+	"github.com/pkg/errors"
+)
+
+// 以下参数类型供RPC和命令行使用，账户均为base58格式
+
+type DepositArgs struct {
+	Address     string       `json:"address"`
+	SignAddress string       `json:"signAddress"`
+	Deposit     *hexutil.Big `json:"deposit"`
+	WithdrawH   *hexutil.Big `json:"withdrawH"`
+	OnlineTime  *hexutil.Big `json:"onlineTime"`
+}
+
+type ElectConfigArgs struct {
+	MinerNum          uint16   `json:"minerNum"`
+	ValidatorNum      uint16   `json:"validatorNum"`
+	BackValidator     uint16   `json:"backValidator"`
+	WhiteList         []string `json:"whiteList"`
+	BlackList         []string `json:"blackList"`
+	WhiteListSwitcher bool     `json:"whiteListSwitcher"`
+}
+
+type BlackListArgs struct {
+	Address              string `json:"address"`
+	ProhibitCycleCounter uint16 `json:"prohibitCycleCounter"`
+}
+
+// Args 为空的配置项从状态树读取，没有状态树时必须全部给出
+type Args struct {
+	Plug                  string           `json:"plug"`
+	Role                  string           `json:"role"` // miner 或 validator
+	SeqNum                uint64           `json:"seqNum"`
+	Seed                  *hexutil.Big     `json:"seed"`
+	Trials                uint64           `json:"trials"`
+	Deposits              []DepositArgs    `json:"deposits"`
+	FoundationDeposits    []DepositArgs    `json:"foundationDeposits"`
+	ElectConfig           *ElectConfigArgs `json:"electConfig"`
+	VIPList               *[]mc.VIPConfig  `json:"vipList"`
+	BlockProduceBlackList *[]BlackListArgs `json:"blockProduceBlackList"`
+}
+
+func ParseRole(role string) (common.RoleType, error) {
+	switch strings.ToLower(role) {
+	case "miner":
+		return common.RoleMiner, nil
+	case "validator":
+		return common.RoleValidator, nil
+	default:
+		return common.RoleNil, ErrRoleNotMatch
+	}
+}
+
+func decodeAddresses(list []string) ([]common.Address, error) {
+	accounts := make([]common.Address, 0, len(list))
+	for _, str := range list {
+		account, err := base58.Base58DecodeToAddress(str)
+		if err != nil {
+			return nil, errors.Errorf("invalid address %q: %v", str, err)
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts, nil
+}
+
+func decodeDeposits(list []DepositArgs) ([]vm.DepositDetail, error) {
+	deposits := make([]vm.DepositDetail, 0, len(list))
+	for _, item := range list {
+		account, err := base58.Base58DecodeToAddress(item.Address)
+		if err != nil {
+			return nil, errors.Errorf("invalid deposit address %q: %v", item.Address, err)
+		}
+		detail := vm.DepositDetail{Address: account, SignAddress: account}
+		if item.SignAddress != "" {
+			if detail.SignAddress, err = base58.Base58DecodeToAddress(item.SignAddress); err != nil {
+				return nil, errors.Errorf("invalid sign address %q: %v", item.SignAddress, err)
+			}
+		}
+		if item.Deposit == nil {
+			return nil, errors.Errorf("deposit of %s is empty", item.Address)
+		}
+		detail.Deposit = item.Deposit.ToInt()
+		if item.WithdrawH != nil {
+			detail.WithdrawH = item.WithdrawH.ToInt()
+		}
+		if item.OnlineTime != nil {
+			detail.OnlineTime = item.OnlineTime.ToInt()
+		}
+		deposits = append(deposits, detail)
+	}
+	return deposits, nil
+}
+
+// ToRequest 转换为预演请求，st不为空时用状态树中的配置补全未给出的参数
+func (args *Args) ToRequest(st matrixstate.StateDB) (*Request, error) {
+	role, err := ParseRole(args.Role)
+	if err != nil {
+		return nil, err
+	}
+	if args.Seed == nil {
+		return nil, ErrSeedNil
+	}
+	req := &Request{Plug: args.Plug, Role: role, SeqNum: args.SeqNum, Seed: args.Seed.ToInt(), Trials: args.Trials}
+	if req.Deposits, err = decodeDeposits(args.Deposits); err != nil {
+		return nil, err
+	}
+	if req.FoundationDeposits, err = decodeDeposits(args.FoundationDeposits); err != nil {
+		return nil, err
+	}
+
+	if args.ElectConfig != nil {
+		cfg := args.ElectConfig
+		req.ElectConfig = mc.ElectConfigInfo_All{MinerNum: cfg.MinerNum, ValidatorNum: cfg.ValidatorNum, BackValidator: cfg.BackValidator, ElectPlug: args.Plug, WhiteListSwitcher: cfg.WhiteListSwitcher}
+		if req.ElectConfig.WhiteList, err = decodeAddresses(cfg.WhiteList); err != nil {
+			return nil, err
+		}
+		if req.ElectConfig.BlackList, err = decodeAddresses(cfg.BlackList); err != nil {
+			return nil, err
+		}
+	} else if st != nil {
+		cfg, err := matrixstate.GetElectConfigInfoAll(st)
+		if err != nil {
+			return nil, errors.Errorf("get elect config from state failed: %v", err)
+		}
+		req.ElectConfig = *cfg
+	} else {
+		return nil, errors.New("elect config is required without chain state")
+	}
+	if req.Plug == "" {
+		req.Plug = req.ElectConfig.ElectPlug
+	}
+
+	if args.VIPList != nil {
+		req.VIPList = *args.VIPList
+	} else if st != nil {
+		if req.VIPList, err = matrixstate.GetVIPConfig(st); err != nil {
+			return nil, errors.Errorf("get vip config from state failed: %v", err)
+		}
+	}
+
+	if args.BlockProduceBlackList != nil {
+		for _, item := range *args.BlockProduceBlackList {
+			account, err := base58.Base58DecodeToAddress(item.Address)
+			if err != nil {
+				return nil, errors.Errorf("invalid black list address %q: %v", item.Address, err)
+			}
+			req.BlockProduceBlackList = append(req.BlockProduceBlackList, mc.UserBlockProduceSlash{Address: account, ProhibitCycleCounter: item.ProhibitCycleCounter})
+		}
+	} else if st != nil && role == common.RoleValidator {
+		if req.BlockProduceBlackList, err = stateBlockProduceBlackList(st); err != nil {
+			return nil, err
+		}
+	}
+	return req, nil
+}
+
+// 与重选流程一致，惩罚开关关闭时不使用出块黑名单
+func stateBlockProduceBlackList(st matrixstate.StateDB) ([]mc.UserBlockProduceSlash, error) {
+	slashCfg, err := matrixstate.GetBlockProduceSlashCfg(st)
+	if err != nil {
+		return nil, errors.Errorf("get block produce slash config from state failed: %v", err)
+	}
+	if !slashCfg.Switcher {
+		return nil, nil
+	}
+	blackList, err := matrixstate.GetBlockProduceBlackList(st)
+	if err != nil {
+		return nil, errors.Errorf("get block produce black list from state failed: %v", err)
+	}
+	return blackList.BlackList, nil
+}
+
+type NodeOutput struct {
+	Account  string             `json:"account"`
+	Position uint16             `json:"position"`
+	Stock    uint16             `json:"stock"`
+	VIPLevel common.VIPRoleType `json:"vipLevel"`
+	Type     common.RoleType    `json:"type"`
+}
+
+type FrequencyOutput struct {
+	Account     string  `json:"account"`
+	Count       uint64  `json:"count"`
+	Probability float64 `json:"probability"`
+}
+
+type Output struct {
+	Plug      string            `json:"plug"`
+	Seed      *hexutil.Big      `json:"seed"`
+	Master    []NodeOutput      `json:"master"`
+	Backup    []NodeOutput      `json:"backup"`
+	Candidate []NodeOutput      `json:"candidate"`
+	Trials    uint64            `json:"trials"`
+	Frequency []FrequencyOutput `json:"frequency"`
+}
+
+func nodesOutput(nodes []mc.ElectNodeInfo) []NodeOutput {
+	out := make([]NodeOutput, 0, len(nodes))
+	for _, node := range nodes {
+		out = append(out, NodeOutput{
+			Account:  base58.Base58EncodeToString(params.MAN_COIN, node.Account),
+			Position: node.Position,
+			Stock:    node.Stock,
+			VIPLevel: node.VIPLevel,
+			Type:     node.Type,
+		})
+	}
+	return out
+}
+
+// Output 转换为RPC和命令行的输出格式
+func (r *Result) Output() *Output {
+	out := &Output{
+		Plug:      r.Plug,
+		Seed:      (*hexutil.Big)(r.Seed),
+		Master:    nodesOutput(r.Master),
+		Backup:    nodesOutput(r.Backup),
+		Candidate: nodesOutput(r.Candidate),
+		Trials:    r.Trials,
+		Frequency: make([]FrequencyOutput, 0, len(r.Frequency)),
+	}
+	for _, account := range r.SortedFrequency() {
+		count := r.Frequency[account]
+		out.Frequency = append(out.Frequency, FrequencyOutput{
+			Account:     base58.Base58EncodeToString(params.MAN_COIN, account),
+			Count:       count,
+			Probability: float64(count) / float64(r.Trials),
+		})
+	}
+	return out
+}
@@ -0,0 +1,165 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+// Package dryrun 使用给定的抵押列表、随机种子和选举配置运行已注册的选举插件，
+// 不修改链上状态，用于估算当选概率和调试选举结果
+package dryrun
+
+import (
+	"math/big"
+	"sort"
+
+	"github.com/MatrixAINetwork/go-matrix/baseinterface"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/matrixstate"
+	"github.com/MatrixAINetwork/go-matrix/core/state"
+	"github.com/MatrixAINetwork/go-matrix/core/vm"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/pkg/errors"
+)
+
+const (
+	ModuleName = "选举预演"
+
+	MaxTrials = 1000 // 单次请求最多预演次数
+)
+
+var (
+	ErrSeedNil      = errors.New("elect dry run seed is nil")
+	ErrNoDeposit    = errors.New("elect dry run deposit list is empty")
+	ErrTrials       = errors.Errorf("elect dry run trials must not exceed %d", MaxTrials)
+	ErrRoleNotMatch = errors.New("elect dry run role must be miner or validator")
+	ErrStateNil     = errors.New("elect dry run state is nil")
+)
+
+type Request struct {
+	Plug                  string
+	Role                  common.RoleType
+	SeqNum                uint64
+	Seed                  *big.Int
+	Trials                uint64 // 大于1时依次使用Seed, Seed+1, ... 统计当选次数
+	Deposits              []vm.DepositDetail
+	FoundationDeposits    []vm.DepositDetail
+	ElectConfig           mc.ElectConfigInfo_All
+	VIPList               []mc.VIPConfig
+	BlockProduceBlackList []mc.UserBlockProduceSlash
+}
+
+type Result struct {
+	Plug      string
+	Seed      *big.Int
+	Master    []mc.ElectNodeInfo
+	Backup    []mc.ElectNodeInfo
+	Candidate []mc.ElectNodeInfo
+	Trials    uint64
+	Frequency map[common.Address]uint64 // 账户在各次预演中当选主节点或备份节点的次数
+}
+
+// Run 运行选举插件，每次预演都在状态副本上执行，插件写入的状态不会保留，最多预演 MaxTrials 次
+func Run(req *Request, st *state.StateDBManage) (*Result, error) {
+	if req.Trials > MaxTrials {
+		return nil, ErrTrials
+	}
+	return RunTrials(req, st)
+}
+
+// RunTrials 与 Run 相同但不限制预演次数，由调用方限制，供离线分析工具使用
+func RunTrials(req *Request, st *state.StateDBManage) (*Result, error) {
+	if nil == st {
+		return nil, ErrStateNil
+	}
+	if nil == req.Seed {
+		return nil, ErrSeedNil
+	}
+	if 0 == len(req.Deposits) {
+		return nil, ErrNoDeposit
+	}
+	if req.Role != common.RoleMiner && req.Role != common.RoleValidator {
+		return nil, ErrRoleNotMatch
+	}
+	trials := req.Trials
+	if trials == 0 {
+		trials = 1
+	}
+	elect, exist := baseinterface.GetElectPlug(req.Plug)
+	if !exist {
+		return nil, errors.Errorf("elect plug %q not registered, registered plugs %v", req.Plug, baseinterface.ElectPlugNames())
+	}
+
+	result := &Result{Plug: req.Plug, Seed: new(big.Int).Set(req.Seed), Trials: trials, Frequency: make(map[common.Address]uint64)}
+	for i := uint64(0); i < trials; i++ {
+		seed := new(big.Int).Add(req.Seed, new(big.Int).SetUint64(i))
+		master, backup, candidate, err := runOnce(elect, req, seed, st.Copy())
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			result.Master, result.Backup, result.Candidate = master, backup, candidate
+		}
+		for _, node := range master {
+			result.Frequency[node.Account]++
+		}
+		for _, node := range backup {
+			result.Frequency[node.Account]++
+		}
+	}
+	log.Debug(ModuleName, "插件", req.Plug, "角色", req.Role, "预演次数", trials, "主节点数", len(result.Master), "备份节点数", len(result.Backup))
+	return result, nil
+}
+
+func runOnce(elect baseinterface.ElectionInterface, req *Request, seed *big.Int, st *state.StateDBManage) ([]mc.ElectNodeInfo, []mc.ElectNodeInfo, []mc.ElectNodeInfo, error) {
+	switch req.Role {
+	case common.RoleMiner:
+		rsp := elect.MinerTopGen(&mc.MasterMinerReElectionReqMsg{SeqNum: req.SeqNum, RandSeed: seed, MinerList: req.Deposits, ElectConfig: req.ElectConfig}, st)
+		if nil == rsp {
+			return nil, nil, nil, errors.Errorf("elect plug %q returned no miner topology", req.Plug)
+		}
+		return rsp.MasterMiner, []mc.ElectNodeInfo{}, []mc.ElectNodeInfo{}, nil
+	default:
+		rsp := elect.ValidatorTopGen(&mc.MasterValidatorReElectionReqMsg{
+			SeqNum:                  req.SeqNum,
+			RandSeed:                seed,
+			ValidatorList:           req.Deposits,
+			FoundationValidatorList: req.FoundationDeposits,
+			ElectConfig:             req.ElectConfig,
+			VIPList:                 req.VIPList,
+			BlockProduceBlackList:   mc.BlockProduceSlashBlackList{BlackList: req.BlockProduceBlackList},
+		}, st)
+		if nil == rsp {
+			return nil, nil, nil, errors.Errorf("elect plug %q returned no validator topology", req.Plug)
+		}
+		return rsp.MasterValidator, rsp.BackUpValidator, rsp.CandidateValidator, nil
+	}
+}
+
+// SortedFrequency 按当选次数从高到低排列账户，次数相同时按地址排列
+func (r *Result) SortedFrequency() []common.Address {
+	accounts := make([]common.Address, 0, len(r.Frequency))
+	for account := range r.Frequency {
+		accounts = append(accounts, account)
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		ci, cj := r.Frequency[accounts[i]], r.Frequency[accounts[j]]
+		if ci != cj {
+			return ci > cj
+		}
+		return accounts[i].Hex() < accounts[j].Hex()
+	})
+	return accounts
+}
+
+// NewState 创建空的内存状态树，供离线预演使用
+func NewState(version string) (*state.StateDBManage, error) {
+	db := mandb.NewMemDatabase()
+	st, err := state.NewStateDBManage(nil, db, state.NewDatabase(db))
+	if err != nil {
+		return nil, err
+	}
+	if err := matrixstate.SetVersionInfo(st, version); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package dryrun
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/vm"
+	_ "github.com/MatrixAINetwork/go-matrix/election/layered"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/params/manparams"
+	"github.com/MatrixAINetwork/go-matrix/params/manversion"
+)
+
+func testDeposits(n int) []vm.DepositDetail {
+	deposits := make([]vm.DepositDetail, 0, n)
+	for i := 1; i <= n; i++ {
+		account := common.BigToAddress(big.NewInt(int64(i)))
+		deposits = append(deposits, vm.DepositDetail{
+			Address:     account,
+			SignAddress: account,
+			Deposit:     new(big.Int).Mul(big.NewInt(int64(10000*i)), big.NewInt(1e18)),
+			WithdrawH:   big.NewInt(0),
+			OnlineTime:  big.NewInt(300),
+		})
+	}
+	return deposits
+}
+
+func TestRunMinerTrials(t *testing.T) {
+	st, err := NewState(manversion.VersionZeta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &Request{
+		Plug:        manparams.ElectPlug_layerd,
+		Role:        common.RoleMiner,
+		SeqNum:      100,
+		Seed:        big.NewInt(12345),
+		Trials:      20,
+		Deposits:    testDeposits(8),
+		ElectConfig: mc.ElectConfigInfo_All{MinerNum: 3, ElectPlug: manparams.ElectPlug_layerd},
+	}
+	result, err := Run(req, st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Master) != 3 {
+		t.Fatalf("master num %d, want 3", len(result.Master))
+	}
+	var total uint64
+	for _, count := range result.Frequency {
+		total += count
+	}
+	if total != 3*req.Trials {
+		t.Fatalf("frequency total %d, want %d", total, 3*req.Trials)
+	}
+
+	again, err := Run(req, st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range result.Master {
+		if result.Master[i].Account != again.Master[i].Account {
+			t.Fatalf("dry run with same seed is not deterministic")
+		}
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	st, err := NewState(manversion.VersionZeta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &Request{Plug: "unknown", Role: common.RoleMiner, Seed: big.NewInt(1), Deposits: testDeposits(2)}
+	if _, err := Run(req, st); err == nil {
+		t.Fatalf("unknown plug should fail")
+	}
+	req.Plug = manparams.ElectPlug_layerd
+	req.Trials = MaxTrials + 1
+	if _, err := Run(req, st); err != ErrTrials {
+		t.Fatalf("err %v, want %v", err, ErrTrials)
+	}
+	req.Trials = 1
+	req.Role = common.RoleBackupMiner
+	if _, err := Run(req, st); err != ErrRoleNotMatch {
+		t.Fatalf("err %v, want %v", err, ErrRoleNotMatch)
+	}
+	if _, err := Run(req, nil); err != ErrStateNil {
+		t.Fatalf("err %v, want %v", err, ErrStateNil)
+	}
+}
@@ -0,0 +1,261 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+// Package fairness 多次运行选举采样算法和选举插件，统计各账户的当选频率，
+// 并与抵押权重比较给出卡方检验结果，用于定量评估归一化和股权处理的修改
+package fairness
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/common/mt19937"
+	"github.com/MatrixAINetwork/go-matrix/election/support"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/pkg/errors"
+)
+
+const MaxTrials = 100000 // 单次统计最多运行次数
+
+var (
+	ErrTrials      = errors.Errorf("fairness trials must be between 1 and %d", MaxTrials)
+	ErrNoWeight    = errors.New("fairness weight list is empty")
+	ErrNeedNum     = errors.New("fairness need num must be positive")
+	ErrNoEligible  = errors.New("fairness weight list has no eligible account")
+	ErrInvalidData = errors.New("fairness weight must be positive")
+)
+
+// Sampler 对一组权重做一次选举采样，返回结果按首次被抽中的顺序排列
+type Sampler struct {
+	Name   string
+	Sample func(weights []support.Pnormalized, needNum int, rand *mt19937.RandUniform) []support.Strallyint
+	// Eligible 为nil时所有账户都可当选
+	Eligible func(account common.Address) bool
+}
+
+func CommonSampler() Sampler {
+	return Sampler{Name: "GetList_Common", Sample: func(weights []support.Pnormalized, needNum int, rand *mt19937.RandUniform) []support.Strallyint {
+		chosen, _ := support.GetList_Common(weights, needNum, rand)
+		return chosen
+	}}
+}
+
+func VIPSampler() Sampler {
+	return Sampler{Name: "GetList_VIP", Sample: func(weights []support.Pnormalized, needNum int, rand *mt19937.RandUniform) []support.Strallyint {
+		chosen, _ := support.GetList_VIP(weights, needNum, rand)
+		return chosen
+	}}
+}
+
+func MEPSampler() Sampler {
+	return Sampler{Name: "GetList_MEP", Sample: func(weights []support.Pnormalized, needNum int, rand *mt19937.RandUniform) []support.Strallyint {
+		chosen, _ := support.GetList_MEP(weights, needNum, rand)
+		return chosen
+	}}
+}
+
+// BlackListSampler 使用 RandSampleFilterBlackList 采样，黑名单账户不可当选。每次采样使用黑名单的副本，禁止周期不会在多次运行间累减
+func BlackListSampler(blackList []mc.UserBlockProduceSlash) Sampler {
+	black := make(map[common.Address]bool)
+	for _, item := range blackList {
+		black[item.Address] = true
+	}
+	return Sampler{
+		Name: "RandSampleFilterBlackList",
+		Sample: func(weights []support.Pnormalized, needNum int, rand *mt19937.RandUniform) []support.Strallyint {
+			list := append([]mc.UserBlockProduceSlash{}, blackList...)
+			chosen, _ := support.RandSampleFilterBlackList(weights, nil, needNum, rand, support.NewBlockProduceProc(mc.BlockProduceSlashBlackList{BlackList: list}))
+			return chosen
+		},
+		Eligible: func(account common.Address) bool {
+			return !black[account]
+		},
+	}
+}
+
+// DefaultSamplers 返回 election/support 中的全部采样算法
+func DefaultSamplers() []Sampler {
+	return []Sampler{CommonSampler(), VIPSampler(), MEPSampler(), BlackListSampler(nil)}
+}
+
+type AccountStat struct {
+	Account   common.Address
+	Weight    float64 // 在可当选账户中的权重占比
+	Selected  uint64  // 当选次数
+	First     uint64  // 第一个被抽中的次数
+	Frequency float64 // 当选次数/运行次数
+}
+
+// Report 中 First 系列结果检验第一个被抽中的账户是否服从权重分布，公平的采样算法应当通过该检验；
+// Selected 系列结果检验当选次数是否与权重成比例，不放回采样时大权重账户必然偏低，用于比较修改前后的偏离程度
+type Report struct {
+	Name    string
+	Trials  uint64
+	NeedNum int
+	Stats   []AccountStat
+
+	DegreesOfFreedom  int
+	FirstChiSquare    float64
+	FirstPValue       float64
+	SelectedChiSquare float64
+	SelectedPValue    float64
+	// MaxDeviation 当选占比与权重占比之差的最大绝对值
+	MaxDeviation float64
+
+	hasFirst bool
+}
+
+type Config struct {
+	Weights []support.Pnormalized
+	NeedNum int
+	Trials  uint64
+	Seed    int64 // 第i次运行使用 Seed+i 初始化随机数
+}
+
+func (cfg *Config) check() error {
+	if 0 == len(cfg.Weights) {
+		return ErrNoWeight
+	}
+	if cfg.NeedNum <= 0 {
+		return ErrNeedNum
+	}
+	if cfg.Trials == 0 || cfg.Trials > MaxTrials {
+		return ErrTrials
+	}
+	for _, w := range cfg.Weights {
+		if !(w.Value > 0) {
+			return ErrInvalidData
+		}
+	}
+	return nil
+}
+
+// Analyze 使用采样算法运行 cfg.Trials 次选举并生成统计报告
+func Analyze(cfg Config, sampler Sampler) (*Report, error) {
+	if err := cfg.check(); err != nil {
+		return nil, err
+	}
+	report, eligibleNum := newReport(sampler.Name, cfg.Weights, sampler.Eligible, cfg.Trials)
+	if eligibleNum == 0 {
+		return nil, ErrNoEligible
+	}
+	report.NeedNum = cfg.NeedNum
+	if report.NeedNum > eligibleNum {
+		report.NeedNum = eligibleNum
+	}
+
+	index := make(map[common.Address]int, len(report.Stats))
+	for i, stat := range report.Stats {
+		index[stat.Account] = i
+	}
+	for i := uint64(0); i < cfg.Trials; i++ {
+		weights := append([]support.Pnormalized{}, cfg.Weights...)
+		chosen := sampler.Sample(weights, cfg.NeedNum, mt19937.RandUniformInit(cfg.Seed+int64(i)))
+		for j, node := range chosen {
+			k, ok := index[node.Addr]
+			if !ok {
+				return nil, errors.Errorf("sampler %s chose unknown account %s", sampler.Name, node.Addr.Hex())
+			}
+			report.Stats[k].Selected++
+			if j == 0 {
+				report.Stats[k].First++
+			}
+		}
+	}
+	report.finish(true)
+	return report, nil
+}
+
+func newReport(name string, weights []support.Pnormalized, eligible func(common.Address) bool, trials uint64) (*Report, int) {
+	report := &Report{Name: name, Trials: trials, Stats: make([]AccountStat, 0, len(weights))}
+	total, eligibleNum := 0.0, 0
+	for _, w := range weights {
+		if eligible != nil && !eligible(w.Addr) {
+			continue
+		}
+		total += w.Value
+		eligibleNum++
+	}
+	for _, w := range weights {
+		stat := AccountStat{Account: w.Addr}
+		if total > 0 && (eligible == nil || eligible(w.Addr)) {
+			stat.Weight = w.Value / total
+		}
+		report.Stats = append(report.Stats, stat)
+	}
+	return report, eligibleNum
+}
+
+func (r *Report) finish(withFirst bool) {
+	observed := make([]float64, len(r.Stats))
+	expected := make([]float64, len(r.Stats))
+	selectedTotal := uint64(0)
+	for _, stat := range r.Stats {
+		selectedTotal += stat.Selected
+	}
+	for i := range r.Stats {
+		stat := &r.Stats[i]
+		stat.Frequency = float64(stat.Selected) / float64(r.Trials)
+		observed[i] = float64(stat.Selected)
+		expected[i] = stat.Weight * float64(selectedTotal)
+		if selectedTotal > 0 {
+			if dev := abs(float64(stat.Selected)/float64(selectedTotal) - stat.Weight); dev > r.MaxDeviation {
+				r.MaxDeviation = dev
+			}
+		}
+	}
+	r.SelectedChiSquare, r.DegreesOfFreedom = chiSquare(observed, expected)
+	r.SelectedPValue = chiSquarePValue(r.SelectedChiSquare, r.DegreesOfFreedom)
+
+	r.hasFirst = withFirst
+	if withFirst {
+		for i := range r.Stats {
+			observed[i] = float64(r.Stats[i].First)
+			expected[i] = r.Stats[i].Weight * float64(r.Trials)
+		}
+		r.FirstChiSquare, _ = chiSquare(observed, expected)
+		r.FirstPValue = chiSquarePValue(r.FirstChiSquare, r.DegreesOfFreedom)
+	}
+	sort.SliceStable(r.Stats, func(i, j int) bool {
+		return r.Stats[i].Weight > r.Stats[j].Weight
+	})
+}
+
+func abs(v float64) float64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// DepositWeights 按抵押金额生成权重，单位为MAN，不足1 MAN的按1计算
+func DepositWeights(accounts []common.Address, deposits []*big.Int) []support.Pnormalized {
+	weights := make([]support.Pnormalized, 0, len(accounts))
+	for i, account := range accounts {
+		value := 1.0
+		if i < len(deposits) && deposits[i] != nil {
+			if man := new(big.Int).Div(deposits[i], common.ManValue); man.Sign() > 0 {
+				value, _ = new(big.Float).SetInt(man).Float64()
+			}
+		}
+		weights = append(weights, support.Pnormalized{Addr: account, Value: value})
+	}
+	return weights
+}
+
+func (r *Report) String() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s trials=%d need=%d df=%d\n", r.Name, r.Trials, r.NeedNum, r.DegreesOfFreedom)
+	if r.hasFirst {
+		fmt.Fprintf(&buf, "  first    chi2=%.3f p=%.4f\n", r.FirstChiSquare, r.FirstPValue)
+	}
+	fmt.Fprintf(&buf, "  selected chi2=%.3f p=%.4f maxDeviation=%.4f\n", r.SelectedChiSquare, r.SelectedPValue, r.MaxDeviation)
+	for _, stat := range r.Stats {
+		fmt.Fprintf(&buf, "  %s weight=%.4f selected=%d first=%d frequency=%.4f\n", stat.Account.Hex(), stat.Weight, stat.Selected, stat.First, stat.Frequency)
+	}
+	return buf.String()
+}
@@ -0,0 +1,139 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package fairness
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/vm"
+	"github.com/MatrixAINetwork/go-matrix/election/dryrun"
+	_ "github.com/MatrixAINetwork/go-matrix/election/layered"
+	_ "github.com/MatrixAINetwork/go-matrix/election/layeredbss"
+	_ "github.com/MatrixAINetwork/go-matrix/election/layereddp"
+	_ "github.com/MatrixAINetwork/go-matrix/election/layereddp2.0"
+	_ "github.com/MatrixAINetwork/go-matrix/election/layeredmep"
+	_ "github.com/MatrixAINetwork/go-matrix/election/nochoice"
+	_ "github.com/MatrixAINetwork/go-matrix/election/stock"
+	"github.com/MatrixAINetwork/go-matrix/election/support"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/params/manversion"
+)
+
+func testWeights(n int) []support.Pnormalized {
+	weights := make([]support.Pnormalized, 0, n)
+	for i := 1; i <= n; i++ {
+		weights = append(weights, support.Pnormalized{Addr: common.BigToAddress(big.NewInt(int64(i))), Value: float64(i)})
+	}
+	return weights
+}
+
+func TestChiSquarePValue(t *testing.T) {
+	tests := []struct {
+		stat float64
+		df   int
+		want float64
+	}{
+		{3.841, 1, 0.05},
+		{5.991, 2, 0.05},
+		{18.307, 10, 0.05},
+		{6.635, 1, 0.01},
+		{10, 10, 0.4405},
+	}
+	for _, test := range tests {
+		if got := chiSquarePValue(test.stat, test.df); math.Abs(got-test.want) > 1e-3 {
+			t.Errorf("chi2=%v df=%d p=%v, want %v", test.stat, test.df, got, test.want)
+		}
+	}
+}
+
+func TestAnalyzeSamplers(t *testing.T) {
+	for _, sampler := range DefaultSamplers() {
+		report, err := Analyze(Config{Weights: testWeights(10), NeedNum: 3, Trials: 2000, Seed: 1}, sampler)
+		if err != nil {
+			t.Fatalf("%s: %v", sampler.Name, err)
+		}
+		t.Log(report)
+		if report.FirstPValue < 0.001 {
+			t.Errorf("%s: first pick does not follow weight, p=%v", sampler.Name, report.FirstPValue)
+		}
+		var selected uint64
+		for _, stat := range report.Stats {
+			selected += stat.Selected
+		}
+		if selected != 3*report.Trials {
+			t.Errorf("%s: selected %d, want %d", sampler.Name, selected, 3*report.Trials)
+		}
+	}
+}
+
+func TestAnalyzeBlackList(t *testing.T) {
+	weights := testWeights(6)
+	blackList := []mc.UserBlockProduceSlash{{Address: weights[5].Addr, ProhibitCycleCounter: 2}}
+	report, err := Analyze(Config{Weights: weights, NeedNum: 2, Trials: 500, Seed: 7}, BlackListSampler(blackList))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, stat := range report.Stats {
+		if stat.Account == weights[5].Addr && (stat.Selected != 0 || stat.Weight != 0) {
+			t.Fatalf("black list account elected %d times, weight %v", stat.Selected, stat.Weight)
+		}
+	}
+	if blackList[0].ProhibitCycleCounter != 2 {
+		t.Fatalf("black list changed by analyze")
+	}
+}
+
+func TestAnalyzeInvalid(t *testing.T) {
+	if _, err := Analyze(Config{NeedNum: 1, Trials: 1}, CommonSampler()); err != ErrNoWeight {
+		t.Errorf("err %v, want %v", err, ErrNoWeight)
+	}
+	if _, err := Analyze(Config{Weights: testWeights(2), NeedNum: 1}, CommonSampler()); err != ErrTrials {
+		t.Errorf("err %v, want %v", err, ErrTrials)
+	}
+	if _, err := Analyze(Config{Weights: []support.Pnormalized{{Value: 0}}, NeedNum: 1, Trials: 1}, CommonSampler()); err != ErrInvalidData {
+		t.Errorf("err %v, want %v", err, ErrInvalidData)
+	}
+}
+
+func TestAnalyzePlugs(t *testing.T) {
+	st, err := dryrun.NewState(manversion.VersionZeta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deposits := make([]vm.DepositDetail, 0)
+	for i := 1; i <= 12; i++ {
+		account := common.BigToAddress(big.NewInt(int64(i)))
+		deposits = append(deposits, vm.DepositDetail{
+			Address:     account,
+			SignAddress: account,
+			Deposit:     new(big.Int).Mul(big.NewInt(int64(10000*i)), common.ManValue),
+			WithdrawH:   big.NewInt(0),
+			OnlineTime:  big.NewInt(300),
+		})
+	}
+	req := &dryrun.Request{
+		Role:        common.RoleMiner,
+		SeqNum:      100,
+		Seed:        big.NewInt(99),
+		Trials:      50,
+		Deposits:    deposits,
+		ElectConfig: mc.ElectConfigInfo_All{MinerNum: 4},
+	}
+	reports, failed := AnalyzePlugs(req, st)
+	for plug, err := range failed {
+		t.Logf("plug %s: %v", plug, err)
+	}
+	if len(reports) == 0 {
+		t.Fatalf("no plug report")
+	}
+	for _, report := range reports {
+		t.Log(report)
+		if report.NeedNum != 4 {
+			t.Errorf("%s: need num %d", report.Name, report.NeedNum)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package fairness
+
+import (
+	"math/big"
+
+	"github.com/MatrixAINetwork/go-matrix/baseinterface"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/state"
+	"github.com/MatrixAINetwork/go-matrix/election/dryrun"
+)
+
+// AnalyzePlug 通过选举预演运行插件 req.Trials 次(最多 MaxTrials 次)，统计主节点和备份节点的当选次数并与抵押权重比较。
+// 插件输出按位置排序，无法得到第一个被抽中的账户，报告中不包含 First 检验结果
+func AnalyzePlug(req *dryrun.Request, st *state.StateDBManage) (*Report, error) {
+	if req.Trials == 0 || req.Trials > MaxTrials {
+		return nil, ErrTrials
+	}
+	result, err := dryrun.RunTrials(req, st)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := make([]common.Address, 0, len(req.Deposits))
+	for _, deposit := range req.Deposits {
+		accounts = append(accounts, deposit.Address)
+	}
+	deposits := make([]*big.Int, 0, len(req.Deposits))
+	for _, deposit := range req.Deposits {
+		deposits = append(deposits, deposit.Deposit)
+	}
+	report, eligibleNum := newReport(req.Plug, DepositWeights(accounts, deposits), plugEligible(req), req.Trials)
+	if eligibleNum == 0 {
+		return nil, ErrNoEligible
+	}
+	for i := range report.Stats {
+		report.Stats[i].Selected = result.Frequency[report.Stats[i].Account]
+	}
+	if req.Role == common.RoleMiner {
+		report.NeedNum = int(req.ElectConfig.MinerNum)
+	} else {
+		report.NeedNum = int(req.ElectConfig.ValidatorNum + req.ElectConfig.BackValidator)
+	}
+	report.finish(false)
+	return report, nil
+}
+
+// plugEligible 与选举流程一致，白名单开关打开时只统计白名单账户，并排除黑名单账户
+func plugEligible(req *dryrun.Request) func(common.Address) bool {
+	white := make(map[common.Address]bool)
+	for _, account := range req.ElectConfig.WhiteList {
+		white[account] = true
+	}
+	black := make(map[common.Address]bool)
+	for _, account := range req.ElectConfig.BlackList {
+		black[account] = true
+	}
+	for _, item := range req.BlockProduceBlackList {
+		black[item.Address] = true
+	}
+	return func(account common.Address) bool {
+		if req.ElectConfig.WhiteListSwitcher && !white[account] {
+			return false
+		}
+		return !black[account]
+	}
+}
+
+// AnalyzePlugs 对所有已注册的选举插件生成报告，运行失败的插件记录在返回的错误表中
+func AnalyzePlugs(req *dryrun.Request, st *state.StateDBManage) ([]*Report, map[string]error) {
+	reports := make([]*Report, 0)
+	failed := make(map[string]error)
+	for _, plug := range baseinterface.ElectPlugNames() {
+		plugReq := *req
+		plugReq.Plug = plug
+		plugReq.ElectConfig.ElectPlug = plug
+		report, err := AnalyzePlug(&plugReq, st)
+		if err != nil {
+			failed[plug] = err
+			continue
+		}
+		reports = append(reports, report)
+	}
+	return reports, failed
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package fairness
+
+import "math"
+
+const (
+	gammaMaxIter = 500
+	gammaEps     = 1e-14
+	gammaTiny    = 1e-300
+)
+
+// chiSquare 计算观测次数相对期望次数的卡方统计量，期望为0的项不参与计算，返回统计量和自由度
+func chiSquare(observed, expected []float64) (float64, int) {
+	stat, cells := 0.0, 0
+	for i := range observed {
+		if expected[i] <= 0 {
+			continue
+		}
+		diff := observed[i] - expected[i]
+		stat += diff * diff / expected[i]
+		cells++
+	}
+	if cells < 2 {
+		return 0, 0
+	}
+	return stat, cells - 1
+}
+
+// chiSquarePValue 卡方分布上尾概率 Q(df/2, stat/2)
+func chiSquarePValue(stat float64, df int) float64 {
+	if df <= 0 {
+		return 1
+	}
+	if stat <= 0 {
+		return 1
+	}
+	return gammaQ(float64(df)/2, stat/2)
+}
+
+// gammaQ 正则化上不完全伽马函数，x较小时用级数展开，否则用连分式
+func gammaQ(a, x float64) float64 {
+	if x < a+1 {
+		return 1 - gammaPSeries(a, x)
+	}
+	return gammaQFraction(a, x)
+}
+
+func gammaPSeries(a, x float64) float64 {
+	lg, _ := math.Lgamma(a)
+	sum := 1 / a
+	term := sum
+	for n := 1; n < gammaMaxIter; n++ {
+		term *= x / (a + float64(n))
+		sum += term
+		if math.Abs(term) < math.Abs(sum)*gammaEps {
+			break
+		}
+	}
+	return sum * math.Exp(-x+a*math.Log(x)-lg)
+}
+
+func gammaQFraction(a, x float64) float64 {
+	lg, _ := math.Lgamma(a)
+	b := x + 1 - a
+	c := 1 / gammaTiny
+	d := 1 / b
+	h := d
+	for i := 1; i < gammaMaxIter; i++ {
+		an := -float64(i) * (float64(i) - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < gammaTiny {
+			d = gammaTiny
+		}
+		c = b + an/c
+		if math.Abs(c) < gammaTiny {
+			c = gammaTiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < gammaEps {
+			break
+		}
+	}
+	return math.Exp(-x+a*math.Log(x)-lg) * h
+}
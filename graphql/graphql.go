@@ -0,0 +1,635 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+// Package graphql provides a GraphQL interface to the chain, the multi-coin state and the matrix state.
+package graphql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/MatrixAINetwork/go-matrix/base58"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/common/hexutil"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/internal/manapi"
+	"github.com/MatrixAINetwork/go-matrix/params"
+	"github.com/MatrixAINetwork/go-matrix/rpc"
+)
+
+// maxBlockRange 单次blocks查询最多返回的区块数
+const maxBlockRange = 256
+
+var (
+	errBlockRange   = fmt.Errorf("block range must be ascending and contain at most %d blocks", maxBlockRange)
+	errBlockInvalid = errors.New("block number and hash are mutually exclusive")
+)
+
+// Long is a 64 bit signed integer, serialized as a JSON number.
+type Long int64
+
+// ImplementsGraphQLType returns true if Long implements the provided GraphQL type.
+func (l Long) ImplementsGraphQLType(name string) bool { return name == "Long" }
+
+// UnmarshalGraphQL unmarshals the provided GraphQL query data.
+func (l *Long) UnmarshalGraphQL(input interface{}) error {
+	switch input := input.(type) {
+	case string:
+		value, err := strconv.ParseInt(input, 0, 64)
+		if err != nil {
+			return err
+		}
+		*l = Long(value)
+	case int32:
+		*l = Long(input)
+	case float64:
+		if input != math.Trunc(input) {
+			return fmt.Errorf("non-integer value %v for Long", input)
+		}
+		*l = Long(input)
+	default:
+		return fmt.Errorf("unexpected type %T for Long", input)
+	}
+	return nil
+}
+
+func bigValue(b *hexutil.Big) hexutil.Big {
+	if b == nil {
+		return hexutil.Big{}
+	}
+	return *b
+}
+
+func manAddress(addr common.Address) string {
+	return base58.Base58EncodeToString(params.MAN_COIN, addr)
+}
+
+// Resolver is the root resolver of the schema.
+type Resolver struct {
+	backend manapi.Backend
+	chain   *manapi.PublicBlockChainAPI
+	txpool  *manapi.PublicTransactionPoolAPI
+}
+
+func newResolver(backend manapi.Backend) *Resolver {
+	return &Resolver{
+		backend: backend,
+		chain:   manapi.NewPublicBlockChainAPI(backend),
+		txpool:  manapi.NewPublicTransactionPoolAPI(backend, new(manapi.AddrLocker)),
+	}
+}
+
+// number 未指定区块时使用当前区块的高度，保证同一查询内的状态一致
+func (r *Resolver) number(n *Long) rpc.BlockNumber {
+	if n == nil {
+		return rpc.BlockNumber(r.backend.CurrentBlock().NumberU64())
+	}
+	return rpc.BlockNumber(*n)
+}
+
+func (r *Resolver) Block(ctx context.Context, args struct {
+	Number *Long
+	Hash   *common.Hash
+}) (*Block, error) {
+	var (
+		block *types.Block
+		err   error
+	)
+	switch {
+	case args.Number != nil && args.Hash != nil:
+		return nil, errBlockInvalid
+	case args.Hash != nil:
+		block, err = r.backend.GetBlock(ctx, *args.Hash)
+	default:
+		block, err = r.backend.BlockByNumber(ctx, r.number(args.Number))
+	}
+	if block == nil || err != nil {
+		return nil, err
+	}
+	return &Block{r: r, block: block}, nil
+}
+
+func (r *Resolver) Blocks(ctx context.Context, args struct {
+	From Long
+	To   *Long
+}) ([]*Block, error) {
+	to := Long(r.number(args.To))
+	if args.From < 0 || to < args.From || to-args.From >= maxBlockRange {
+		return nil, errBlockRange
+	}
+	blocks := make([]*Block, 0, to-args.From+1)
+	for number := args.From; number <= to; number++ {
+		block, err := r.backend.BlockByNumber(ctx, rpc.BlockNumber(number))
+		if err != nil {
+			return nil, err
+		}
+		if block == nil {
+			break
+		}
+		blocks = append(blocks, &Block{r: r, block: block})
+	}
+	return blocks, nil
+}
+
+func (r *Resolver) Transaction(ctx context.Context, args struct{ Hash common.Hash }) *Transaction {
+	if tx := r.txpool.GetTransactionByHash(ctx, args.Hash); tx != nil {
+		return &Transaction{r: r, tx: tx}
+	}
+	return nil
+}
+
+func (r *Resolver) Account(ctx context.Context, args struct {
+	Address string
+	Block   *Long
+}) (*Account, error) {
+	address := strings.TrimSpace(args.Address)
+	if err := manapi.CheckParams(address); err != nil {
+		return nil, fmt.Errorf("invalid address %q: %v", args.Address, err)
+	}
+	addr, err := base58.Base58DecodeToAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	return &Account{
+		r:       r,
+		address: address,
+		coin:    strings.Split(address, ".")[0],
+		addr:    addr,
+		number:  r.number(args.Block),
+	}, nil
+}
+
+func (r *Resolver) Coins(ctx context.Context, args struct{ Block *Long }) ([]string, error) {
+	coins, err := r.chain.GetMatrixCoin(ctx, r.number(args.Block))
+	if err != nil {
+		return nil, err
+	}
+	return append([]string{params.MAN_COIN}, coins...), nil
+}
+
+func (r *Resolver) Deposit(ctx context.Context, args struct {
+	Address string
+	Block   *Long
+}) (*Deposit, error) {
+	deposit, err := r.chain.GetDepositByAddr(ctx, args.Address, r.number(args.Block))
+	if deposit == nil || err != nil {
+		return nil, err
+	}
+	return &Deposit{deposit}, nil
+}
+
+func (r *Resolver) Entrusts(args struct{ Address string }) []*Entrust {
+	return newEntrusts(r.chain.GetEntrustList(args.Address))
+}
+
+func (r *Resolver) Topology(ctx context.Context, args struct{ Block *Long }) (*Topology, error) {
+	status, err := r.chain.GetTopologyStatusByNumber(ctx, r.number(args.Block))
+	if status == nil || err != nil {
+		return nil, err
+	}
+	return &Topology{status}, nil
+}
+
+func (r *Resolver) RewardPools(ctx context.Context, args struct {
+	Coin  *string
+	Block *Long
+}) ([]*RewardPool, error) {
+	coin := ""
+	if args.Coin != nil {
+		coin = *args.Coin
+	}
+	pools, err := r.chain.GetCoinRewardPool(ctx, coin, r.number(args.Block))
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*RewardPool, 0, len(pools))
+	for i := range pools {
+		result = append(result, &RewardPool{&pools[i]})
+	}
+	return result, nil
+}
+
+// Block 区块，交易按币种分组
+type Block struct {
+	r     *Resolver
+	block *types.Block
+}
+
+func (b *Block) Number() Long            { return Long(b.block.NumberU64()) }
+func (b *Block) Hash() common.Hash       { return b.block.Hash() }
+func (b *Block) Timestamp() hexutil.Big  { return hexutil.Big(*b.block.Time()) }
+func (b *Block) Miner() string           { return manAddress(b.block.Coinbase()) }
+func (b *Block) Leader() string          { return manAddress(b.block.Header().Leader) }
+func (b *Block) GasLimit() Long          { return Long(b.block.GasLimit()) }
+func (b *Block) GasUsed() Long           { return Long(b.block.GasUsed()) }
+func (b *Block) Version() string         { return string(b.block.Version()) }
+func (b *Block) IsSuperBlock() bool      { return b.block.IsSuperBlock() }
+func (b *Block) Currencies() []*Currency { return b.currencies("") }
+
+func (b *Block) Parent(ctx context.Context) (*Block, error) {
+	if b.block.NumberU64() == 0 {
+		return nil, nil
+	}
+	parent, err := b.r.backend.GetBlock(ctx, b.block.ParentHash())
+	if parent == nil || err != nil {
+		return nil, err
+	}
+	return &Block{r: b.r, block: parent}, nil
+}
+
+func (b *Block) SignAccounts(ctx context.Context) ([]*SignAccount, error) {
+	accounts, err := b.r.chain.GetSignAccountsByHash(ctx, b.block.Hash())
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*SignAccount, 0, len(accounts))
+	for i := range accounts {
+		result = append(result, &SignAccount{&accounts[i]})
+	}
+	return result, nil
+}
+
+func (b *Block) Topology(ctx context.Context) (*Topology, error) {
+	number := b.Number()
+	return b.r.Topology(ctx, struct{ Block *Long }{Block: &number})
+}
+
+func (b *Block) TransactionCount() int32 {
+	count := 0
+	for _, curr := range b.block.Currencies() {
+		count += len(curr.Transactions.GetTransactions())
+	}
+	return int32(count)
+}
+
+func (b *Block) Transactions(ctx context.Context, args struct{ Coin *string }) []*Transaction {
+	coin := ""
+	if args.Coin != nil {
+		coin = *args.Coin
+	}
+	txs := make([]*Transaction, 0)
+	for _, curr := range b.currencies(coin) {
+		txs = append(txs, curr.Transactions(ctx)...)
+	}
+	return txs
+}
+
+func (b *Block) currencies(coin string) []*Currency {
+	result := make([]*Currency, 0)
+	for _, curr := range b.block.Currencies() {
+		if coin != "" && curr.CurrencyName != coin {
+			continue
+		}
+		result = append(result, &Currency{block: b, coin: curr.CurrencyName, txs: curr.Transactions.GetTransactions()})
+	}
+	return result
+}
+
+// Currency 区块中一个币种的交易
+type Currency struct {
+	block *Block
+	coin  string
+	txs   types.SelfTransactions
+}
+
+func (c *Currency) Coin() string            { return c.coin }
+func (c *Currency) TransactionCount() int32 { return int32(len(c.txs)) }
+
+func (c *Currency) Transactions(ctx context.Context) []*Transaction {
+	result := make([]*Transaction, 0, len(c.txs))
+	for i := range c.txs {
+		tx := c.block.r.txpool.GetTransactionByBlockHashAndIndex(ctx, c.block.block.Hash(), hexutil.Uint(i), c.coin)
+		if tx != nil {
+			result = append(result, &Transaction{r: c.block.r, tx: tx})
+		}
+	}
+	return result
+}
+
+// Transaction 已上链或交易池中的交易
+type Transaction struct {
+	r  *Resolver
+	tx *manapi.RPCTransaction1
+}
+
+func (t *Transaction) Hash() common.Hash     { return t.tx.Hash }
+func (t *Transaction) From() string          { return t.tx.From }
+func (t *Transaction) To() *string           { return t.tx.To }
+func (t *Transaction) Value() hexutil.Big    { return bigValue(t.tx.Value) }
+func (t *Transaction) Gas() Long             { return Long(t.tx.Gas) }
+func (t *Transaction) GasPrice() hexutil.Big { return bigValue(t.tx.GasPrice) }
+func (t *Transaction) Nonce() Long           { return Long(t.tx.Nonce) }
+func (t *Transaction) Input() hexutil.Bytes  { return t.tx.Input }
+func (t *Transaction) MatrixType() int32     { return int32(t.tx.MatrixType) }
+func (t *Transaction) TxEnterType() int32    { return int32(t.tx.TxEnterType) }
+func (t *Transaction) IsEntrustTx() bool     { return t.tx.IsEntrustTx }
+func (t *Transaction) CommitTime() Long      { return Long(t.tx.CommitTime) }
+
+// Coin 内部发送的交易没有币种，默认为MAN
+func (t *Transaction) Coin() string {
+	if t.tx.Currency == "" {
+		return params.MAN_COIN
+	}
+	return t.tx.Currency
+}
+
+func (t *Transaction) pending() bool {
+	return t.tx.BlockHash == (common.Hash{})
+}
+
+func (t *Transaction) Index() *int32 {
+	if t.pending() {
+		return nil
+	}
+	index := int32(t.tx.TransactionIndex)
+	return &index
+}
+
+func (t *Transaction) Block(ctx context.Context) (*Block, error) {
+	if t.pending() {
+		return nil, nil
+	}
+	return t.r.Block(ctx, struct {
+		Number *Long
+		Hash   *common.Hash
+	}{Hash: &t.tx.BlockHash})
+}
+
+func (t *Transaction) ExtraTo() []*ExtraTo {
+	result := make([]*ExtraTo, 0, len(t.tx.ExtraTo))
+	for _, extra := range t.tx.ExtraTo {
+		result = append(result, &ExtraTo{extra})
+	}
+	return result
+}
+
+func (t *Transaction) Receipt(ctx context.Context) (*Receipt, error) {
+	if t.pending() {
+		return nil, nil
+	}
+	coinReceipts, err := t.r.backend.GetReceipts(ctx, t.tx.BlockHash)
+	if err != nil {
+		return nil, err
+	}
+	coin := t.Coin()
+	for _, cr := range coinReceipts {
+		if cr.CoinType == coin && int(t.tx.TransactionIndex) < len(cr.Receiptlist) {
+			return &Receipt{coin: coin, receipt: cr.Receiptlist[t.tx.TransactionIndex]}, nil
+		}
+	}
+	return nil, nil
+}
+
+// ExtraTo 一对多交易的附加接收方
+type ExtraTo struct {
+	extra *manapi.ExtraTo_Mx1
+}
+
+func (e *ExtraTo) To() *string           { return e.extra.To2 }
+func (e *ExtraTo) Value() *hexutil.Big   { return e.extra.Value2 }
+func (e *ExtraTo) Input() *hexutil.Bytes { return e.extra.Input2 }
+
+// Receipt 交易收据，地址使用交易的币种
+type Receipt struct {
+	coin    string
+	receipt *types.Receipt
+}
+
+func (r *Receipt) Status() Long            { return Long(r.receipt.Status) }
+func (r *Receipt) GasUsed() Long           { return Long(r.receipt.GasUsed) }
+func (r *Receipt) CumulativeGasUsed() Long { return Long(r.receipt.CumulativeGasUsed) }
+
+func (r *Receipt) ContractAddress() *string {
+	if r.receipt.ContractAddress == (common.Address{}) {
+		return nil
+	}
+	addr := base58.Base58EncodeToString(r.coin, r.receipt.ContractAddress)
+	return &addr
+}
+
+func (r *Receipt) Logs() []*Log {
+	result := make([]*Log, 0, len(r.receipt.Logs))
+	for _, log := range r.receipt.Logs {
+		result = append(result, &Log{coin: r.coin, log: log})
+	}
+	return result
+}
+
+type Log struct {
+	coin string
+	log  *types.Log
+}
+
+func (l *Log) Index() int32          { return int32(l.log.Index) }
+func (l *Log) Address() string       { return base58.Base58EncodeToString(l.coin, l.log.Address) }
+func (l *Log) Topics() []common.Hash { return l.log.Topics }
+func (l *Log) Data() hexutil.Bytes   { return l.log.Data }
+
+// Account 一个币种下的账户
+type Account struct {
+	r       *Resolver
+	address string
+	coin    string
+	addr    common.Address
+	number  rpc.BlockNumber
+}
+
+func (a *Account) Address() string { return a.address }
+func (a *Account) Coin() string    { return a.coin }
+
+func (a *Account) Balances(ctx context.Context) ([]*Balance, error) {
+	balances, err := a.r.chain.GetBalance(ctx, a.address, a.number)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*Balance, 0, len(balances))
+	for i := range balances {
+		result = append(result, &Balance{&balances[i]})
+	}
+	return result, nil
+}
+
+func (a *Account) Nonce(ctx context.Context) (Long, error) {
+	nonce, err := a.r.txpool.GetTransactionCount(ctx, a.address, a.number)
+	if nonce == nil || err != nil {
+		return 0, err
+	}
+	return Long(*nonce), nil
+}
+
+// Deposit 抵押只存在于MAN账户
+func (a *Account) Deposit(ctx context.Context) (*Deposit, error) {
+	if a.coin != params.MAN_COIN {
+		return nil, nil
+	}
+	return a.r.Deposit(ctx, struct {
+		Address string
+		Block   *Long
+	}{Address: a.address, Block: (*Long)(&a.number)})
+}
+
+func (a *Account) Entrusts() []*Entrust {
+	return a.r.Entrusts(struct{ Address string }{a.address})
+}
+
+type Balance struct {
+	balance *manapi.RPCBalanceType
+}
+
+func (b *Balance) AccountType() int32   { return int32(b.balance.AccountType) }
+func (b *Balance) Balance() hexutil.Big { return bigValue(b.balance.Balance) }
+
+type Deposit struct {
+	deposit *manapi.RpcDepositBase
+}
+
+func (d *Deposit) Address() string         { return d.deposit.AddressA0 }
+func (d *Deposit) SignAddress() string     { return d.deposit.AddressA1 }
+func (d *Deposit) OnlineTime() hexutil.Big { return bigValue(d.deposit.OnlineTime) }
+func (d *Deposit) Role() hexutil.Big       { return bigValue(d.deposit.Role) }
+func (d *Deposit) PositionNonce() Long     { return Long(d.deposit.PositionNonce) }
+
+func (d *Deposit) Entries() []*DepositEntry {
+	result := make([]*DepositEntry, 0, len(d.deposit.Dpstmsg))
+	for i := range d.deposit.Dpstmsg {
+		result = append(result, &DepositEntry{&d.deposit.Dpstmsg[i]})
+	}
+	return result
+}
+
+type DepositEntry struct {
+	msg *manapi.RpcDepositMsg
+}
+
+func (e *DepositEntry) DepositType() Long     { return Long(e.msg.DepositType) }
+func (e *DepositEntry) Amount() hexutil.Big   { return bigValue(e.msg.DepositAmount) }
+func (e *DepositEntry) Interest() hexutil.Big { return bigValue(e.msg.Interest) }
+func (e *DepositEntry) Slash() hexutil.Big    { return bigValue(e.msg.Slash) }
+func (e *DepositEntry) BeginTime() Long       { return Long(e.msg.BeginTime) }
+func (e *DepositEntry) EndTime() Long         { return Long(e.msg.EndTime) }
+func (e *DepositEntry) Position() Long        { return Long(e.msg.Position) }
+
+func (e *DepositEntry) Withdrawals() []*Withdrawal {
+	result := make([]*Withdrawal, 0, len(e.msg.WithDrawInfolist))
+	for i := range e.msg.WithDrawInfolist {
+		result = append(result, &Withdrawal{&e.msg.WithDrawInfolist[i]})
+	}
+	return result
+}
+
+type Withdrawal struct {
+	info *manapi.RpcWithDrawInfo
+}
+
+func (w *Withdrawal) Amount() hexutil.Big { return bigValue(w.info.WithDrawAmount) }
+func (w *Withdrawal) Time() Long          { return Long(w.info.WithDrawTime) }
+
+type Entrust struct {
+	entrust common.EntrustType
+}
+
+func newEntrusts(list []common.EntrustType) []*Entrust {
+	result := make([]*Entrust, 0, len(list))
+	for _, entrust := range list {
+		result = append(result, &Entrust{entrust})
+	}
+	return result
+}
+
+func (e *Entrust) Address() string   { return e.entrust.EntrustAddres }
+func (e *Entrust) EntrustGas() bool  { return e.entrust.IsEntrustGas }
+func (e *Entrust) EntrustSign() bool { return e.entrust.IsEntrustSign }
+func (e *Entrust) SetType() int32    { return int32(e.entrust.EnstrustSetType) }
+func (e *Entrust) StartHeight() Long { return Long(e.entrust.StartHeight) }
+func (e *Entrust) EndHeight() Long   { return Long(e.entrust.EndHeight) }
+func (e *Entrust) StartTime() Long   { return Long(e.entrust.StartTime) }
+func (e *Entrust) EndTime() Long     { return Long(e.entrust.EndTime) }
+func (e *Entrust) Count() int32      { return int32(e.entrust.EntrustCount) }
+
+type Topology struct {
+	status *manapi.TopologyStatus
+}
+
+func newTopologyNodes(nodes []manapi.NodeInfo) []*TopologyNode {
+	result := make([]*TopologyNode, 0, len(nodes))
+	for i := range nodes {
+		result = append(result, &TopologyNode{&nodes[i]})
+	}
+	return result
+}
+
+func (t *Topology) LeaderReelect() bool         { return t.status.LeaderReelect }
+func (t *Topology) Validators() []*TopologyNode { return newTopologyNodes(t.status.Validators) }
+func (t *Topology) BackupValidators() []*TopologyNode {
+	return newTopologyNodes(t.status.BackupValidators)
+}
+func (t *Topology) Miners() []*TopologyNode { return newTopologyNodes(t.status.Miners) }
+func (t *Topology) ElectValidators() []*TopologyNode {
+	return newTopologyNodes(t.status.ElectValidators)
+}
+func (t *Topology) ElectBackupValidators() []*TopologyNode {
+	return newTopologyNodes(t.status.ElectBackupValidators)
+}
+
+type TopologyNode struct {
+	node *manapi.NodeInfo
+}
+
+func (n *TopologyNode) Account() string { return n.node.Account }
+func (n *TopologyNode) Online() bool    { return n.node.Online }
+func (n *TopologyNode) Position() int32 { return int32(n.node.Position) }
+
+type SignAccount struct {
+	sign *common.VerifiedSign1
+}
+
+func (s *SignAccount) Account() string     { return s.sign.Account }
+func (s *SignAccount) Sign() hexutil.Bytes { return s.sign.Sign[:] }
+func (s *SignAccount) Validate() bool      { return s.sign.Validate }
+func (s *SignAccount) Stock() int32        { return int32(s.sign.Stock) }
+
+type RewardPool struct {
+	pool *manapi.RPCCoinRewardPool
+}
+
+func (p *RewardPool) Coin() string           { return p.pool.CoinType }
+func (p *RewardPool) PoolAddress() string    { return p.pool.PoolAddress }
+func (p *RewardPool) Balance() hexutil.Big   { return bigValue(p.pool.Balance) }
+func (p *RewardPool) TotalPaid() hexutil.Big { return bigValue(p.pool.TotalPaid) }
+
+func (p *RewardPool) MinerReward() *Long {
+	if p.pool.RewardCfg == nil {
+		return nil
+	}
+	reward := Long(p.pool.RewardCfg.MinerMount)
+	return &reward
+}
+
+func (p *RewardPool) ValidatorReward() *Long {
+	if p.pool.RewardCfg == nil {
+		return nil
+	}
+	reward := Long(p.pool.RewardCfg.ValidatorMount)
+	return &reward
+}
+
+func (p *RewardPool) History() []*RewardPayout {
+	result := make([]*RewardPayout, 0, len(p.pool.History))
+	for i := range p.pool.History {
+		result = append(result, &RewardPayout{&p.pool.History[i]})
+	}
+	return result
+}
+
+type RewardPayout struct {
+	payout *manapi.RPCCoinRewardPayout
+}
+
+func (p *RewardPayout) Number() Long                 { return Long(p.payout.Number) }
+func (p *RewardPayout) MinerAmount() hexutil.Big     { return bigValue(p.payout.MinerAmount) }
+func (p *RewardPayout) ValidatorAmount() hexutil.Big { return bigValue(p.payout.ValidatorAmount) }
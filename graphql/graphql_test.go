@@ -0,0 +1,146 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/internal/manapi"
+	"github.com/MatrixAINetwork/go-matrix/rpc"
+)
+
+// testBackend 只实现区块查询的Backend，其他方法未实现
+type testBackend struct {
+	manapi.Backend
+	blocks []*types.Block
+}
+
+func newTestBackend(n int) *testBackend {
+	b := &testBackend{}
+	parent := common.Hash{}
+	for i := 0; i < n; i++ {
+		block := types.NewBlockWithHeader(&types.Header{
+			Number:     big.NewInt(int64(i)),
+			ParentHash: parent,
+			Time:       big.NewInt(int64(1000 + i)),
+			GasLimit:   uint64(5000 + i),
+			Version:    []byte("1.0.0.0"),
+		})
+		b.blocks = append(b.blocks, block)
+		parent = block.Hash()
+	}
+	return b
+}
+
+func (b *testBackend) CurrentBlock() *types.Block { return b.blocks[len(b.blocks)-1] }
+
+func (b *testBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Block, error) {
+	if number == rpc.LatestBlockNumber {
+		return b.CurrentBlock(), nil
+	}
+	if number < 0 || int(number) >= len(b.blocks) {
+		return nil, nil
+	}
+	return b.blocks[number], nil
+}
+
+func (b *testBackend) GetBlock(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	for _, block := range b.blocks {
+		if block.Hash() == hash {
+			return block, nil
+		}
+	}
+	return nil, nil
+}
+
+func query(t *testing.T, handler http.Handler, q string, variables map[string]interface{}) (map[string]interface{}, []interface{}) {
+	body, _ := json.Marshal(map[string]interface{}{"query": q, "variables": variables})
+	req := httptest.NewRequest(http.MethodPost, Path, strings.NewReader(string(body)))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+	}
+	var result struct {
+		Data   map[string]interface{} `json:"data"`
+		Errors []interface{}          `json:"errors"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	return result.Data, result.Errors
+}
+
+func TestBlockQuery(t *testing.T) {
+	backend := newTestBackend(5)
+	service, err := New(backend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := service.HTTPHandlers()[Path]
+
+	data, errs := query(t, handler, `{ block { number gasLimit version timestamp parent { number hash } transactionCount } }`, nil)
+	if len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	block := data["block"].(map[string]interface{})
+	if block["number"].(float64) != 4 || block["gasLimit"].(float64) != 5004 || block["version"] != "1.0.0.0" || block["timestamp"] != "0x3ec" {
+		t.Errorf("latest block %v", block)
+	}
+	parent := block["parent"].(map[string]interface{})
+	if parent["number"].(float64) != 3 || parent["hash"] != backend.blocks[3].Hash().Hex() {
+		t.Errorf("parent %v", parent)
+	}
+
+	// 变量中的Long可以是数字或字符串，区块按哈希查询
+	data, errs = query(t, handler, `query($from: Long!, $hash: Bytes32) { blocks(from: $from, to: "0x2") { number } block(hash: $hash) { number } }`,
+		map[string]interface{}{"from": 1, "hash": backend.blocks[2].Hash().Hex()})
+	if len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	if blocks := data["blocks"].([]interface{}); len(blocks) != 2 || blocks[1].(map[string]interface{})["number"].(float64) != 2 {
+		t.Errorf("blocks %v", blocks)
+	}
+	if data["block"].(map[string]interface{})["number"].(float64) != 2 {
+		t.Errorf("block by hash %v", data["block"])
+	}
+
+	if _, errs = query(t, handler, `{ blocks(from: 3, to: 1) { number } }`, nil); len(errs) != 1 {
+		t.Errorf("descending range accepted")
+	}
+	if _, errs = query(t, handler, `{ block(number: 1, hash: "0x00") { number } }`, nil); len(errs) == 0 {
+		t.Errorf("number and hash accepted")
+	}
+	data, errs = query(t, handler, `{ block(number: 10) { number } }`, nil)
+	if len(errs) != 0 || data["block"] != nil {
+		t.Errorf("missing block %v %v", data, errs)
+	}
+
+	// 超出嵌套深度的查询被拒绝
+	deep := `{ block { parent { parent { parent { parent { parent { parent { parent { parent { number } } } } } } } } } }`
+	if _, errs = query(t, handler, deep, nil); len(errs) == 0 {
+		t.Errorf("query deeper than %d accepted", maxQueryDepth)
+	}
+}
+
+func TestServeHTTPMethod(t *testing.T) {
+	service, err := New(newTestBackend(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	service.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, Path, nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET status %d", rec.Code)
+	}
+}
@@ -0,0 +1,202 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package graphql
+
+const schema string = `
+    # Bytes32 is a 32 byte binary string, represented as 0x-prefixed hexadecimal.
+    scalar Bytes32
+    # Bytes is an arbitrary length binary string, represented as 0x-prefixed hexadecimal.
+    scalar Bytes
+    # BigInt is a large integer, represented as 0x-prefixed hexadecimal.
+    scalar BigInt
+    # Long is a 64 bit signed integer. Input accepts numbers and decimal or 0x-prefixed hexadecimal strings.
+    scalar Long
+
+    schema {
+        query: Query
+    }
+
+    type Query {
+        # block returns a block by number or hash, the latest block if neither is given.
+        block(number: Long, hash: Bytes32): Block
+        # blocks returns the blocks in the inclusive range [from, to], to defaults to the latest block.
+        blocks(from: Long!, to: Long): [Block!]!
+        # transaction returns a mined or pending transaction by hash.
+        transaction(hash: Bytes32!): Transaction
+        # account returns the state of a coin account, address is in the COIN.xxx format.
+        account(address: String!, block: Long): Account!
+        # coins returns MAN and the coins issued on the chain.
+        coins(block: Long): [String!]!
+        # deposit returns the deposit of a MAN address.
+        deposit(address: String!, block: Long): Deposit
+        # entrusts returns the currently valid entrusts of an authorizing address.
+        entrusts(address: String!): [Entrust!]!
+        # topology returns the topology and election status of a block.
+        topology(block: Long): Topology
+        # rewardPools returns the coin reward pools, all of them if coin is not given.
+        rewardPools(coin: String, block: Long): [RewardPool!]!
+    }
+
+    type Block {
+        number: Long!
+        hash: Bytes32!
+        parent: Block
+        timestamp: BigInt!
+        miner: String!
+        leader: String!
+        gasLimit: Long!
+        gasUsed: Long!
+        version: String!
+        isSuperBlock: Boolean!
+        # signAccounts are the deposit accounts that signed the block.
+        signAccounts: [SignAccount!]!
+        topology: Topology
+        currencies: [Currency!]!
+        transactionCount: Int!
+        # transactions of all coins, or of the given coin only.
+        transactions(coin: String): [Transaction!]!
+    }
+
+    type Currency {
+        coin: String!
+        transactionCount: Int!
+        transactions: [Transaction!]!
+    }
+
+    type Transaction {
+        hash: Bytes32!
+        coin: String!
+        from: String!
+        to: String
+        value: BigInt!
+        gas: Long!
+        gasPrice: BigInt!
+        nonce: Long!
+        input: Bytes!
+        matrixType: Int!
+        txEnterType: Int!
+        isEntrustTx: Boolean!
+        commitTime: Long!
+        # index is the index of the transaction among the transactions of its coin, null when pending.
+        index: Int
+        block: Block
+        extraTo: [ExtraTo!]!
+        receipt: Receipt
+    }
+
+    type ExtraTo {
+        to: String
+        value: BigInt
+        input: Bytes
+    }
+
+    type Receipt {
+        status: Long!
+        gasUsed: Long!
+        cumulativeGasUsed: Long!
+        contractAddress: String
+        logs: [Log!]!
+    }
+
+    type Log {
+        index: Int!
+        address: String!
+        topics: [Bytes32!]!
+        data: Bytes!
+    }
+
+    type Account {
+        address: String!
+        coin: String!
+        # balances are the balance buckets of the account.
+        balances: [Balance!]!
+        nonce: Long!
+        deposit: Deposit
+        entrusts: [Entrust!]!
+    }
+
+    type Balance {
+        accountType: Int!
+        balance: BigInt!
+    }
+
+    type Deposit {
+        address: String!
+        signAddress: String!
+        onlineTime: BigInt!
+        role: BigInt!
+        positionNonce: Long!
+        entries: [DepositEntry!]!
+    }
+
+    type DepositEntry {
+        # depositType is 0 for current deposits and the number of months for time deposits.
+        depositType: Long!
+        amount: BigInt!
+        interest: BigInt!
+        slash: BigInt!
+        beginTime: Long!
+        endTime: Long!
+        position: Long!
+        withdrawals: [Withdrawal!]!
+    }
+
+    type Withdrawal {
+        amount: BigInt!
+        time: Long!
+    }
+
+    type Entrust {
+        address: String!
+        entrustGas: Boolean!
+        entrustSign: Boolean!
+        # setType is 0 for entrusts by height, 1 by time and 2 by count.
+        setType: Int!
+        startHeight: Long!
+        endHeight: Long!
+        startTime: Long!
+        endTime: Long!
+        count: Int!
+    }
+
+    type Topology {
+        leaderReelect: Boolean!
+        validators: [TopologyNode!]!
+        backupValidators: [TopologyNode!]!
+        miners: [TopologyNode!]!
+        electValidators: [TopologyNode!]!
+        electBackupValidators: [TopologyNode!]!
+    }
+
+    type TopologyNode {
+        account: String!
+        online: Boolean!
+        position: Int!
+    }
+
+    type SignAccount {
+        account: String!
+        sign: Bytes!
+        validate: Boolean!
+        stock: Int!
+    }
+
+    type RewardPool {
+        coin: String!
+        poolAddress: String!
+        balance: BigInt!
+        totalPaid: BigInt!
+        # minerReward and validatorReward are the configured rewards per block, in whole coins.
+        minerReward: Long
+        validatorReward: Long
+        history: [RewardPayout!]!
+    }
+
+    type RewardPayout {
+        number: Long!
+        minerAmount: BigInt!
+        validatorAmount: BigInt!
+    }
+`
@@ -0,0 +1,87 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package graphql
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/MatrixAINetwork/go-matrix/internal/manapi"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/p2p"
+	"github.com/MatrixAINetwork/go-matrix/rpc"
+	"github.com/graph-gophers/graphql-go"
+)
+
+const (
+	// Path GraphQL在HTTP-RPC端口上的路径
+	Path = "/graphql"
+
+	maxRequestContentLength = 1024 * 512
+
+	// 查询的嵌套深度和并发解析数上限，限制parent等嵌套字段和blocks下交易、收据的单次查询开销
+	maxQueryDepth       = 8
+	maxQueryParallelism = 8
+)
+
+// Service is a pod service serving GraphQL queries on the HTTP-RPC endpoint.
+type Service struct {
+	schema *graphql.Schema
+}
+
+// New constructs a new GraphQL service over the API backend.
+func New(backend manapi.Backend) (*Service, error) {
+	s, err := graphql.ParseSchema(schema, newResolver(backend), graphql.MaxDepth(maxQueryDepth), graphql.MaxParallelism(maxQueryParallelism))
+	if err != nil {
+		return nil, err
+	}
+	return &Service{schema: s}, nil
+}
+
+// Protocols implements pod.Service, returning no p2p protocols.
+func (s *Service) Protocols() []p2p.Protocol { return nil }
+
+// APIs implements pod.Service, returning no RPC APIs.
+func (s *Service) APIs() []rpc.API { return nil }
+
+// Start implements pod.Service.
+func (s *Service) Start(server *p2p.Server) error {
+	log.Info("GraphQL", "服务启动, path", Path)
+	return nil
+}
+
+// Stop implements pod.Service.
+func (s *Service) Stop() error { return nil }
+
+// HTTPHandlers implements pod.HTTPService, mounting the query handler on the HTTP-RPC endpoint.
+func (s *Service) HTTPHandlers() map[string]http.Handler {
+	return map[string]http.Handler{Path: s}
+}
+
+// ServeHTTP executes a GraphQL request, posted as {"query", "operationName", "variables"}.
+func (s *Service) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var params struct {
+		Query         string                 `json:"query"`
+		OperationName string                 `json:"operationName"`
+		Variables     map[string]interface{} `json:"variables"`
+	}
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxRequestContentLength)).Decode(&params); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	response := s.schema.Exec(r.Context(), params.Query, params.OperationName, params.Variables)
+	responseJSON, err := json.Marshal(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(responseJSON)
+}
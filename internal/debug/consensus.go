@@ -0,0 +1,78 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package debug
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+
+	"github.com/MatrixAINetwork/go-matrix/consensus/trace"
+	"github.com/MatrixAINetwork/go-matrix/log"
+)
+
+const (
+	traceFormatJSON   = "json"
+	traceFormatChrome = "chrome"
+)
+
+// ConsensusTraceRange is the range of heights currently held by the consensus tracer.
+type ConsensusTraceRange struct {
+	Enabled bool   `json:"enabled"`
+	From    uint64 `json:"from"`
+	To      uint64 `json:"to"`
+	Empty   bool   `json:"empty"`
+}
+
+func consensusTrace(from, to uint64, format string) (interface{}, error) {
+	if from > to {
+		return nil, errors.New("from is greater than to")
+	}
+	events := trace.DefaultTracer.Events(from, to)
+	switch format {
+	case "", traceFormatJSON:
+		return events, nil
+	case traceFormatChrome:
+		return trace.ToChromeTrace(events), nil
+	default:
+		return nil, errors.New("unknown trace format, want json or chrome")
+	}
+}
+
+// ConsensusTrace returns the consensus events recorded for heights in [from, to].
+// Format is either "json" (plain event list) or "chrome" (Chrome trace format,
+// loadable by chrome://tracing or Perfetto).
+func (*HandlerT) ConsensusTrace(from, to uint64, format string) (interface{}, error) {
+	return consensusTrace(from, to, format)
+}
+
+// WriteConsensusTrace writes the consensus events for heights in [from, to] to the given file.
+func (*HandlerT) WriteConsensusTrace(file string, from, to uint64, format string) error {
+	data, err := consensusTrace(from, to, format)
+	if err != nil {
+		return err
+	}
+	content, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	log.Info("Writing consensus trace", "from", from, "to", to, "format", format, "dump", file)
+	return ioutil.WriteFile(expandHome(file), content, 0644)
+}
+
+// ConsensusTraceRange returns the heights currently held by the consensus tracer.
+func (*HandlerT) ConsensusTraceRange() ConsensusTraceRange {
+	min, max, ok := trace.DefaultTracer.Heights()
+	return ConsensusTraceRange{Enabled: trace.DefaultTracer.Enabled(), From: min, To: max, Empty: !ok}
+}
+
+// SetConsensusTrace turns consensus tracing on or off. Recorded events are kept.
+func (*HandlerT) SetConsensusTrace(enabled bool) {
+	trace.DefaultTracer.SetEnabled(enabled)
+}
+
+// ClearConsensusTrace drops all recorded consensus events.
+func (*HandlerT) ClearConsensusTrace() {
+	trace.DefaultTracer.Clear()
+}
@@ -30,6 +30,7 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/accounts"
 	"github.com/MatrixAINetwork/go-matrix/accounts/keystore"
 	"github.com/MatrixAINetwork/go-matrix/base58"
+	"github.com/MatrixAINetwork/go-matrix/baseinterface"
 	"github.com/MatrixAINetwork/go-matrix/ca"
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/common/hexutil"
@@ -45,11 +46,13 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/crc8"
 	"github.com/MatrixAINetwork/go-matrix/crypto"
 	"github.com/MatrixAINetwork/go-matrix/crypto/aes"
+	"github.com/MatrixAINetwork/go-matrix/election/dryrun"
 	"github.com/MatrixAINetwork/go-matrix/log"
 	"github.com/MatrixAINetwork/go-matrix/mc"
 	"github.com/MatrixAINetwork/go-matrix/p2p"
 	"github.com/MatrixAINetwork/go-matrix/params"
 	"github.com/MatrixAINetwork/go-matrix/params/enstrust"
+	"github.com/MatrixAINetwork/go-matrix/reward/slash"
 	"github.com/MatrixAINetwork/go-matrix/rlp"
 	"github.com/MatrixAINetwork/go-matrix/rpc"
 )
@@ -814,6 +817,546 @@ func (s *PublicBlockChainAPI) GetSlash(ctx context.Context, strAddress string, b
 	return (*hexutil.Big)(read), state.Error()
 }
 
+type RPCSlashPosition struct {
+	Position uint64       `json:"position"`
+	Amount   *hexutil.Big `json:"amount"`
+}
+
+type RPCSlashEvidence struct {
+	Type             uint8              `json:"type"`
+	Address          string             `json:"address"`
+	Number           uint64             `json:"number"`
+	StatsStartNumber uint64             `json:"statsStartNumber"`
+	ActualCount      uint64             `json:"actualCount"`
+	ExpectCount      uint64             `json:"expectCount"`
+	ProhibitCycleNum uint16             `json:"prohibitCycleNum"`
+	SlashRate        uint64             `json:"slashRate"`
+	Positions        []RPCSlashPosition `json:"positions"`
+	Reversed         bool               `json:"reversed"`
+	ReverseNumber    uint64             `json:"reverseNumber"`
+}
+
+// GetSlashEvidence returns the recorded reasons of penalties. An empty address returns all of them.
+func (s *PublicBlockChainAPI) GetSlashEvidence(ctx context.Context, strAddress string, blockNr rpc.BlockNumber) ([]RPCSlashEvidence, error) {
+	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	address := common.Address{}
+	if strAddress != "" {
+		address, err = base58.Base58DecodeToAddress(strAddress)
+		if err != nil {
+			return nil, err
+		}
+	}
+	list, err := slash.GetEvidence(state, address)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]RPCSlashEvidence, 0, len(list))
+	for _, v := range list {
+		evidence := RPCSlashEvidence{
+			Type:             v.Type,
+			Address:          base58.Base58EncodeToString(params.MAN_COIN, v.Address),
+			Number:           v.Number,
+			StatsStartNumber: v.StatsStartNumber,
+			ActualCount:      v.ActualCount,
+			ExpectCount:      v.ExpectCount,
+			ProhibitCycleNum: v.ProhibitCycleNum,
+			SlashRate:        v.SlashRate,
+			Positions:        make([]RPCSlashPosition, 0, len(v.Positions)),
+			Reversed:         v.Reversed,
+			ReverseNumber:    v.ReverseNumber,
+		}
+		for _, pos := range v.Positions {
+			evidence.Positions = append(evidence.Positions, RPCSlashPosition{Position: pos.Position, Amount: (*hexutil.Big)(pos.Amount)})
+		}
+		result = append(result, evidence)
+	}
+	return result, state.Error()
+}
+
+type RPCRewardCfgSchedule struct {
+	Key            string      `json:"key"`
+	ProposeNumber  uint64      `json:"proposeNumber"`
+	ActivateNumber uint64      `json:"activateNumber"`
+	Cfg            interface{} `json:"cfg"`
+}
+
+// GetPendingRewardCfg returns the reward config changes that have been proposed but not yet activated.
+func (s *PublicBlockChainAPI) GetPendingRewardCfg(ctx context.Context, blockNr rpc.BlockNumber) ([]RPCRewardCfgSchedule, error) {
+	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	result := make([]RPCRewardCfgSchedule, 0)
+	if !manversion.IsEnabled(manversion.FeatureDelta, matrixstate.GetVersionInfo(state)) {
+		return result, nil
+	}
+	list, err := matrixstate.GetRewardCfgSchedule(state)
+	if err != nil {
+		return nil, err
+	}
+	for i := range list.ScheduleList {
+		item := &list.ScheduleList[i]
+		cfg, err := matrixstate.DecodeRewardCfgSchedule(item)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, RPCRewardCfgSchedule{Key: item.Key, ProposeNumber: item.ProposeNumber, ActivateNumber: item.ActivateNumber, Cfg: cfg})
+	}
+	return result, state.Error()
+}
+
+type RPCCoinRewardPayout struct {
+	Number          uint64       `json:"number"`
+	MinerAmount     *hexutil.Big `json:"minerAmount"`
+	ValidatorAmount *hexutil.Big `json:"validatorAmount"`
+}
+
+type RPCCoinRewardPool struct {
+	CoinType    string                `json:"coinType"`
+	PoolAddress string                `json:"poolAddress"`
+	Balance     *hexutil.Big          `json:"balance"`
+	RewardCfg   *mc.BlkRewardCfg      `json:"rewardCfg"`
+	TotalPaid   *hexutil.Big          `json:"totalPaid"`
+	History     []RPCCoinRewardPayout `json:"history"`
+}
+
+// GetCoinRewardPool returns the reward pool config, remaining balance and payout history of a coin.
+// An empty coin type returns all of the pools.
+func (s *PublicBlockChainAPI) GetCoinRewardPool(ctx context.Context, coinType string, blockNr rpc.BlockNumber) ([]RPCCoinRewardPool, error) {
+	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	result := make([]RPCCoinRewardPool, 0)
+	if !manversion.IsEnabled(manversion.FeatureDelta, matrixstate.GetVersionInfo(state)) {
+		return result, nil
+	}
+	cfgList, err := matrixstate.GetCoinRewardCfg(state)
+	if err != nil {
+		return nil, err
+	}
+	poolList, err := matrixstate.GetCoinRewardPool(state)
+	if err != nil {
+		return nil, err
+	}
+
+	pools := make(map[string]int)
+	getPool := func(coin string) *RPCCoinRewardPool {
+		if index, ok := pools[coin]; ok {
+			return &result[index]
+		}
+		balance := big.NewInt(0)
+		if b := state.GetBalance(coin, common.CoinRewardPoolAddress); len(b) > 0 {
+			balance = b[common.MainAccount].Balance
+		}
+		result = append(result, RPCCoinRewardPool{
+			CoinType:    coin,
+			PoolAddress: base58.Base58EncodeToString(coin, common.CoinRewardPoolAddress),
+			Balance:     (*hexutil.Big)(balance),
+			TotalPaid:   (*hexutil.Big)(big.NewInt(0)),
+			History:     make([]RPCCoinRewardPayout, 0),
+		})
+		pools[coin] = len(result) - 1
+		return &result[len(result)-1]
+	}
+	for i := range cfgList.CfgList {
+		if coinType != "" && cfgList.CfgList[i].CoinType != coinType {
+			continue
+		}
+		getPool(cfgList.CfgList[i].CoinType).RewardCfg = &cfgList.CfgList[i].BlkRewardCfg
+	}
+	for _, info := range poolList.PoolList {
+		if coinType != "" && info.CoinType != coinType {
+			continue
+		}
+		pool := getPool(info.CoinType)
+		if info.TotalPaid != nil {
+			pool.TotalPaid = (*hexutil.Big)(info.TotalPaid)
+		}
+		for _, v := range info.History {
+			pool.History = append(pool.History, RPCCoinRewardPayout{Number: v.Number, MinerAmount: (*hexutil.Big)(v.MinerAmount), ValidatorAmount: (*hexutil.Big)(v.ValidatorAmount)})
+		}
+	}
+	return result, state.Error()
+}
+
+// ElectPlugs returns the names of the registered election plugins.
+func (s *PublicBlockChainAPI) ElectPlugs() []string {
+	return baseinterface.ElectPlugNames()
+}
+
+type RPCEquivocationEvidence struct {
+	Kind          uint8         `json:"kind"`
+	Number        uint64        `json:"number"`
+	ConsensusTurn uint32        `json:"consensusTurn"`
+	Account       string        `json:"account"`
+	Signer        string        `json:"signer"`
+	SignHashA     common.Hash   `json:"signHashA"`
+	SignHashB     common.Hash   `json:"signHashB"`
+	TxData        hexutil.Bytes `json:"txData"` // 作为双签证据交易(txType 15)的data提交
+}
+
+// GetEquivocationEvidence returns the equivocations this node has observed at heights in [from, to].
+func (s *PublicBlockChainAPI) GetEquivocationEvidence(ctx context.Context, from uint64, to uint64) ([]RPCEquivocationEvidence, error) {
+	if from > to {
+		return nil, errors.New("from is greater than to")
+	}
+	list := s.b.GetEquivocationEvidence(from, to)
+	result := make([]RPCEquivocationEvidence, 0, len(list))
+	for i := range list {
+		ev := &list[i]
+		hashA, hashB, err := slash.EquivocationSignHashes(ev)
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal([]mc.EquivocationEvidence{*ev})
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, RPCEquivocationEvidence{
+			Kind:          ev.Kind,
+			Number:        ev.Number,
+			ConsensusTurn: ev.ConsensusTurn,
+			Account:       base58.Base58EncodeToString(params.MAN_COIN, ev.Account),
+			Signer:        base58.Base58EncodeToString(params.MAN_COIN, ev.Signer),
+			SignHashA:     hashA,
+			SignHashB:     hashB,
+			TxData:        data,
+		})
+	}
+	return result, nil
+}
+
+type RPCEquivocationRecord struct {
+	Kind         uint8       `json:"kind"`
+	Account      string      `json:"account"`
+	Number       uint64      `json:"number"`
+	SignHashA    common.Hash `json:"signHashA"`
+	SignHashB    common.Hash `json:"signHashB"`
+	SubmitNumber uint64      `json:"submitNumber"`
+	SlashNumber  uint64      `json:"slashNumber"`
+}
+
+type RPCEquivocationRecords struct {
+	Pending []RPCEquivocationRecord `json:"pending"`
+	History []RPCEquivocationRecord `json:"history"`
+}
+
+// GetEquivocationRecords returns the equivocation evidences submitted on chain, both pending and already slashed.
+func (s *PublicBlockChainAPI) GetEquivocationRecords(ctx context.Context, blockNr rpc.BlockNumber) (*RPCEquivocationRecords, error) {
+	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	records, err := matrixstate.GetEquivocationRecords(state)
+	if err != nil {
+		return nil, err
+	}
+	convert := func(list []mc.EquivocationRecord) []RPCEquivocationRecord {
+		ret := make([]RPCEquivocationRecord, 0, len(list))
+		for _, v := range list {
+			ret = append(ret, RPCEquivocationRecord{
+				Kind:         v.Kind,
+				Account:      base58.Base58EncodeToString(params.MAN_COIN, v.Account),
+				Number:       v.Number,
+				SignHashA:    v.SignHashA,
+				SignHashB:    v.SignHashB,
+				SubmitNumber: v.SubmitNumber,
+				SlashNumber:  v.SlashNumber,
+			})
+		}
+		return ret
+	}
+	return &RPCEquivocationRecords{Pending: convert(records.Pending), History: convert(records.History)}, state.Error()
+}
+
+type RPCBLSPublicKey struct {
+	Account string        `json:"account"`
+	PubKey  hexutil.Bytes `json:"pubKey"`
+	Number  uint64        `json:"number"`
+}
+
+type RPCBLSKeys struct {
+	Switcher bool             `json:"switcher"`
+	Keys     []RPCBLSPublicKey `json:"keys"`
+}
+
+// GetBLSPublicKeys returns whether aggregate signatures are enabled and the BLS keys registered by deposit accounts.
+func (s *PublicBlockChainAPI) GetBLSPublicKeys(ctx context.Context, blockNr rpc.BlockNumber) (*RPCBLSKeys, error) {
+	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	cfg, err := matrixstate.GetBLSAggregateCfg(state)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := matrixstate.GetBLSPublicKeys(state)
+	if err != nil {
+		return nil, err
+	}
+	result := &RPCBLSKeys{Switcher: cfg.Switcher, Keys: make([]RPCBLSPublicKey, 0, len(keys.Keys))}
+	for _, key := range keys.Keys {
+		result.Keys = append(result.Keys, RPCBLSPublicKey{
+			Account: base58.Base58EncodeToString(params.MAN_COIN, key.Account),
+			PubKey:  key.PubKey,
+			Number:  key.Number,
+		})
+	}
+	return result, state.Error()
+}
+
+// GetBLSKeyRegistration returns the tx data (txType 16) that registers the BLS key of this node's current sign account.
+// The tx must be sent from the deposit account.
+func (s *PublicBlockChainAPI) GetBLSKeyRegistration(ctx context.Context) (hexutil.Bytes, error) {
+	reg, err := s.b.BLSKeyRegistration()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(reg)
+}
+
+type RPCOnlineAuditVote struct {
+	Signer string `json:"signer"`
+	Agree  bool   `json:"agree"`
+}
+
+type RPCOnlineConsensusAudit struct {
+	ReqHash     common.Hash          `json:"reqHash"`
+	Number      uint64               `json:"number"`
+	LeaderTurn  uint32               `json:"leaderTurn"`
+	Leader      string               `json:"leader"`
+	Node        string               `json:"node"`
+	OnlineState string               `json:"onlineState"`
+	LocalVote   uint8                `json:"localVote"` // 0:未投票 1:赞成 2:反对
+	LocalReason string               `json:"localReason"`
+	Votes       []RPCOnlineAuditVote `json:"votes"`
+	Result      uint8                `json:"result"` // 0:未形成共识 1:通过 2:POS验证失败
+	Reason      string               `json:"reason"`
+	Signers     []string             `json:"signers"`
+}
+
+// GetOnlineConsensusAudit returns how this node saw the online consensus of top nodes requested at heights in [from, to],
+// including the proposals, votes and results. An empty node returns all nodes.
+func (s *PublicBlockChainAPI) GetOnlineConsensusAudit(ctx context.Context, from uint64, to uint64, strNode string) ([]RPCOnlineConsensusAudit, error) {
+	if from > to {
+		return nil, errors.New("from is greater than to")
+	}
+	if to-from > 1000 {
+		return nil, errors.New("block range is greater than 1000")
+	}
+	node := common.Address{}
+	if strNode != "" {
+		var err error
+		node, err = base58.Base58DecodeToAddress(strNode)
+		if err != nil {
+			return nil, err
+		}
+	}
+	list := s.b.GetOnlineConsensusAudit(from, to, node)
+	result := make([]RPCOnlineConsensusAudit, 0, len(list))
+	for _, v := range list {
+		audit := RPCOnlineConsensusAudit{
+			ReqHash:     v.ReqHash,
+			Number:      v.Number,
+			LeaderTurn:  v.LeaderTurn,
+			Leader:      base58.Base58EncodeToString(params.MAN_COIN, v.Leader),
+			Node:        base58.Base58EncodeToString(params.MAN_COIN, v.Node),
+			OnlineState: v.OnlineState.String(),
+			LocalVote:   v.LocalVote,
+			LocalReason: v.LocalReason,
+			Votes:       make([]RPCOnlineAuditVote, 0, len(v.Votes)),
+			Result:      v.Result,
+			Reason:      v.Reason,
+			Signers:     make([]string, 0, len(v.Signers)),
+		}
+		for _, vote := range v.Votes {
+			audit.Votes = append(audit.Votes, RPCOnlineAuditVote{Signer: base58.Base58EncodeToString(params.MAN_COIN, vote.Signer), Agree: vote.Agree})
+		}
+		for _, signer := range v.Signers {
+			audit.Signers = append(audit.Signers, base58.Base58EncodeToString(params.MAN_COIN, signer))
+		}
+		result = append(result, audit)
+	}
+	return result, nil
+}
+
+type RPCFork struct {
+	Name         string            `json:"name"`
+	Version      string            `json:"version"`
+	Parent       string            `json:"parent"`
+	Number       uint64            `json:"number"` // 0:创世版本
+	Status       string            `json:"status"` // past, active, scheduled
+	Signed       bool              `json:"signed"`   // 是否已有版本超级节点签名
+	Approval     bool              `json:"approval"` // true:由超级区块批准激活
+	Features     []string          `json:"features"`
+	Plugins      map[string]string `json:"plugins"` // 包括沿用之前版本的插件
+	HasMigration bool              `json:"hasMigration"`
+	Description  string            `json:"description"`
+}
+
+// GetForks lists the registered block versions with their activation heights, enabled block plugins
+// and whether they are past, active or scheduled relative to the current block.
+func (s *PublicBlockChainAPI) GetForks(ctx context.Context) []RPCFork {
+	current := s.b.CurrentBlock()
+	curVersion := string(current.Version())
+	forks := manversion.Forks()
+	result := make([]RPCFork, 0, len(forks))
+	for _, fork := range forks {
+		status := "scheduled"
+		switch cmp := manversion.VersionCmp(fork.Version, curVersion); {
+		case cmp == 0:
+			status = "active"
+		case cmp < 0:
+			status = "past"
+		}
+		result = append(result, RPCFork{
+			Name:         fork.Name,
+			Version:      fork.Version,
+			Parent:       fork.Parent,
+			Number:       fork.Number,
+			Status:       status,
+			Signed:       len(fork.Signatures) != 0,
+			Approval:     fork.Approval,
+			Features:     fork.Features,
+			Plugins:      manversion.Plugins(fork.Version),
+			HasMigration: core.HasStateMigration(fork.Version),
+			Description:  fork.Description,
+		})
+	}
+	return result
+}
+
+type RPCForkBlock struct {
+	Number uint64      `json:"number"`
+	Hash   common.Hash `json:"hash"`
+	Leader string      `json:"leader"`
+	Time   uint64      `json:"time"`
+	Super  bool        `json:"super"`
+}
+
+type RPCSideChain struct {
+	CommonNumber      uint64         `json:"commonNumber"`
+	CommonHash        common.Hash    `json:"commonHash"`
+	Canonical         []RPCForkBlock `json:"canonical"` // 胜出分支，按高度从高到低
+	Side              []RPCForkBlock `json:"side"`      // 落选分支，按高度从高到低
+	CanonicalSuperSeq uint64         `json:"canonicalSuperSeq"`
+	SideSuperSeq      uint64         `json:"sideSuperSeq"`
+	Reason            string         `json:"reason"` // superBlockSeq, number, time, totalDifficulty, firstSeen
+	Reorg             bool           `json:"reorg"`  // true:原规范链落选
+	Depth             int            `json:"depth"`
+	Time              int64          `json:"time"`
+}
+
+func newRPCForkBlocks(blocks []core.ForkBlock) []RPCForkBlock {
+	result := make([]RPCForkBlock, 0, len(blocks))
+	for _, block := range blocks {
+		result = append(result, RPCForkBlock{
+			Number: block.Number,
+			Hash:   block.Hash,
+			Leader: base58.Base58EncodeToString(params.MAN_COIN, block.Leader),
+			Time:   block.Time,
+			Super:  block.Super,
+		})
+	}
+	return result
+}
+
+// GetSideChains lists the most recent fork choices seen by this node, newest first: the winning and losing
+// branches from their common ancestor, their super block sequences and why the canonical branch won.
+// A count of 0 returns all recorded fork choices.
+func (s *PublicBlockChainAPI) GetSideChains(ctx context.Context, count uint64) []RPCSideChain {
+	choices := s.b.RecentForkChoices(int(count))
+	result := make([]RPCSideChain, 0, len(choices))
+	for _, fc := range choices {
+		result = append(result, RPCSideChain{
+			CommonNumber:      fc.CommonNumber,
+			CommonHash:        fc.CommonHash,
+			Canonical:         newRPCForkBlocks(fc.Canonical),
+			Side:              newRPCForkBlocks(fc.Side),
+			CanonicalSuperSeq: fc.CanonicalSuperSeq,
+			SideSuperSeq:      fc.SideSuperSeq,
+			Reason:            fc.Reason,
+			Reorg:             fc.Reorg,
+			Depth:             fc.Depth(),
+			Time:              fc.Time,
+		})
+	}
+	return result
+}
+
+type RPCBlockConfidence struct {
+	Number                uint64 `json:"number"`
+	Canonical             bool   `json:"canonical"`
+	Confirmations         uint64 `json:"confirmations"`
+	BlockSuperSeq         uint64 `json:"blockSuperSeq"`
+	HeadSuperSeq          uint64 `json:"headSuperSeq"`
+	SuperBlockAfter       bool   `json:"superBlockAfter"` // 区块之后规范链上已有超级区块
+	NextBroadcastNumber   uint64 `json:"nextBroadcastNumber"`
+	BroadcastConfirmed    bool   `json:"broadcastConfirmed"` // 规范链已越过区块之后的广播区块
+	MaxReorgDepth         int    `json:"maxReorgDepth"`
+	RequiredConfirmations uint64 `json:"requiredConfirmations"`
+	Stable                bool   `json:"stable"`
+}
+
+// GetBlockConfidence evaluates how safe it is to treat a block as settled under the super block + POS/POW
+// fork choice rules. POS signed blocks are only replaced by a super block with a higher sequence, or by
+// a block of the same height after leader re-election, so a canonical block is stable when a super block
+// follows it on the canonical chain, or when it has more confirmations than the deepest reorg observed
+// by this node and the following broadcast block is on the canonical chain.
+func (s *PublicBlockChainAPI) GetBlockConfidence(ctx context.Context, hash common.Hash) (*RPCBlockConfidence, error) {
+	block, err := s.b.GetBlock(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, errors.New("block not found")
+	}
+	number := block.NumberU64()
+	st, header, err := s.b.StateAndHeaderByNumber(ctx, rpc.BlockNumber(number))
+	if st == nil || err != nil {
+		return nil, err
+	}
+	headState, head, err := s.b.StateAndHeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if headState == nil || err != nil {
+		return nil, err
+	}
+	blockSbs, err := matrixstate.GetSuperBlockCfg(st)
+	if err != nil {
+		return nil, err
+	}
+	headSbs, err := matrixstate.GetSuperBlockCfg(headState)
+	if err != nil {
+		return nil, err
+	}
+	bcInterval, err := matrixstate.GetBroadcastInterval(st)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &RPCBlockConfidence{
+		Number:              number,
+		Canonical:           header.Hash() == hash,
+		BlockSuperSeq:       blockSbs.Seq,
+		HeadSuperSeq:        headSbs.Seq,
+		NextBroadcastNumber: bcInterval.GetNextBroadcastNumber(number),
+		MaxReorgDepth:       s.b.MaxReorgDepth(),
+	}
+	result.RequiredConfirmations = uint64(result.MaxReorgDepth) + 1
+	if !result.Canonical {
+		return result, nil
+	}
+	headNumber := head.Number.Uint64()
+	result.Confirmations = headNumber - number
+	result.SuperBlockAfter = headSbs.Seq > blockSbs.Seq && headSbs.Num > number
+	result.BroadcastConfirmed = headNumber >= result.NextBroadcastNumber
+	result.Stable = result.SuperBlockAfter || (result.Confirmations >= result.RequiredConfirmations && result.BroadcastConfirmed)
+	return result, nil
+}
+
 type DepositDetail struct {
 	Address     string
 	SignAddress string
@@ -1864,7 +2407,7 @@ func (s *PublicBlockChainAPI) rpcOutputBlock1(b *types.Block, inclTx bool, fullT
 		"version":     hexutil.Bytes(head.Version),
 		"VrfValue":    hexutil.Bytes(head.VrfValue),
 	}
-	if manversion.VersionCmp(string(head.Version), manversion.VersionAIMine) >= 0 {
+	if manversion.IsEnabled(manversion.FeatureAIMine, string(head.Version)) {
 		fields["AIHash"] = head.AIHash
 		fields["AIMiner"] = base58.Base58EncodeToString(params.MAN_COIN, head.AICoinbase)
 		fields["Sm3Nonce"] = head.Sm3Nonce
@@ -2908,6 +3451,42 @@ func NewPrivateDebugAPI(b Backend) *PrivateDebugAPI {
 	return &PrivateDebugAPI{b: b}
 }
 
+// electDryRunSlot 同时只运行一个选举预演，避免占满CPU
+var electDryRunSlot = make(chan struct{}, 1)
+
+// ElectDryRun runs a registered election plugin with the given deposits, seed and config on a copy of
+// the state at blockNr. Missing config is read from the state and missing deposits from the deposit contract.
+// Only one dry run is executed at a time.
+func (api *PrivateDebugAPI) ElectDryRun(ctx context.Context, args dryrun.Args, blockNr rpc.BlockNumber) (*dryrun.Output, error) {
+	select {
+	case electDryRunSlot <- struct{}{}:
+		defer func() { <-electDryRunSlot }()
+	default:
+		return nil, errors.New("another elect dry run is in progress")
+	}
+	state, header, err := api.b.StateAndHeaderByNumber(ctx, blockNr)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	req, err := args.ToRequest(state)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.Deposits) == 0 {
+		if req.Deposits, err = ca.GetElectedByHeightAndRoleByHash(header.Hash(), req.Role); err != nil {
+			return nil, err
+		}
+	}
+	if req.SeqNum == 0 {
+		req.SeqNum = header.Number.Uint64()
+	}
+	result, err := dryrun.Run(req, state)
+	if err != nil {
+		return nil, err
+	}
+	return result.Output(), nil
+}
+
 // ChaindbProperty returns leveldb properties of the chain database.
 func (api *PrivateDebugAPI) ChaindbProperty(property string) (string, error) {
 	ldb, ok := api.b.ChainDb().(interface {
@@ -19,6 +19,7 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/event"
 	"github.com/MatrixAINetwork/go-matrix/man/downloader"
 	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/mc"
 	"github.com/MatrixAINetwork/go-matrix/params"
 	"github.com/MatrixAINetwork/go-matrix/rpc"
 )
@@ -71,6 +72,11 @@ type Backend interface {
 	CurrentBlock() *types.Block
 	GetDepositAccount(signAccount common.Address, blockHash common.Hash) (common.Address, error)
 	GetFutureRewards(*state.StateDBManage, rpc.BlockNumber) (interface{}, error)
+	GetEquivocationEvidence(from, to uint64) []mc.EquivocationEvidence
+	BLSKeyRegistration() (*mc.BLSKeyRegistration, error)
+	GetOnlineConsensusAudit(from, to uint64, node common.Address) []mc.OnlineConsensusAudit
+	RecentForkChoices(count int) []*core.ForkChoice
+	MaxReorgDepth() int
 	Genesis() *types.Block
 }
 
@@ -148,6 +148,19 @@ web3._extend({
 			name: 'stopWS',
 			call: 'admin_stopWS'
 		}),
+		new web3._extend.Method({
+			name: 'setTopNodePolicy',
+			call: 'admin_setTopNodePolicy',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'reloadTopNodePolicy',
+			call: 'admin_reloadTopNodePolicy'
+		}),
+		new web3._extend.Method({
+			name: 'reloadRPCAuth',
+			call: 'admin_reloadRPCAuth'
+		}),
 	],
 	properties: [
 		new web3._extend.Property({
@@ -166,6 +179,14 @@ web3._extend({
 			name: 'addressTable',
 			getter: 'admin_addressTable'
 		}),
+		new web3._extend.Property({
+			name: 'topNodePolicy',
+			getter: 'admin_topNodePolicy'
+		}),
+		new web3._extend.Property({
+			name: 'topNodes',
+			getter: 'admin_topNodes'
+		}),
 	]
 });
 `
@@ -194,6 +215,12 @@ web3._extend({
 			call: 'debug_setHead',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'electDryRun',
+			call: 'debug_electDryRun',
+			params: 2,
+			inputFormatter: [null, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
 		new web3._extend.Method({
 			name: 'seedHash',
 			call: 'debug_seedHash',
@@ -403,6 +430,33 @@ web3._extend({
 			call: 'debug_setInterestPrintLevel',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'consensusTrace',
+			call: 'debug_consensusTrace',
+			params: 3,
+			inputFormatter: [null, null, null]
+		}),
+		new web3._extend.Method({
+			name: 'writeConsensusTrace',
+			call: 'debug_writeConsensusTrace',
+			params: 4,
+			inputFormatter: [null, null, null, null]
+		}),
+		new web3._extend.Method({
+			name: 'consensusTraceRange',
+			call: 'debug_consensusTraceRange',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'setConsensusTrace',
+			call: 'debug_setConsensusTrace',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'clearConsensusTrace',
+			call: 'debug_clearConsensusTrace',
+			params: 0
+		}),
 	],
 	properties: []
 });
@@ -510,6 +564,71 @@ web3._extend({
 			call: 'man_getGasPrice',
 			params: 0,
 		}),
+		new web3._extend.Method({
+			name: 'getSlashEvidence',
+			call: 'man_getSlashEvidence',
+			params: 2,
+			inputFormatter: [null, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getEquivocationEvidence',
+			call: 'man_getEquivocationEvidence',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'getEquivocationRecords',
+			call: 'man_getEquivocationRecords',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getBLSPublicKeys',
+			call: 'man_getBLSPublicKeys',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getBLSKeyRegistration',
+			call: 'man_getBLSKeyRegistration',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'getOnlineConsensusAudit',
+			call: 'man_getOnlineConsensusAudit',
+			params: 3
+		}),
+		new web3._extend.Method({
+			name: 'getForks',
+			call: 'man_getForks',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'getSideChains',
+			call: 'man_getSideChains',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getBlockConfidence',
+			call: 'man_getBlockConfidence',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getPendingRewardCfg',
+			call: 'man_getPendingRewardCfg',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getCoinRewardPool',
+			call: 'man_getCoinRewardPool',
+			params: 2,
+			inputFormatter: [null, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'electPlugs',
+			call: 'man_electPlugs',
+			params: 0
+		}),
 	],
 	properties: [
 		new web3._extend.Property({
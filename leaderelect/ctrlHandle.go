@@ -10,7 +10,6 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/log"
 	"github.com/MatrixAINetwork/go-matrix/mc"
-	"github.com/MatrixAINetwork/go-matrix/params/manversion"
 )
 
 func (self *controller) handleMsg(data interface{}) {
@@ -70,7 +69,7 @@ func (self *controller) handleStartMsg(msg *startControllerMsg) {
 		return
 	}
 
-	if manversion.VersionCmp(string(msg.parentHeader.Version), manversion.VersionGamma) >= 0 {
+	if !isLeaderVersion(string(msg.parentHeader.Version)) {
 		log.Trace(self.logInfo, "开始消息处理", "版本号不匹配, 不处理消息", "header version", string(msg.parentHeader.Version))
 		return
 	}
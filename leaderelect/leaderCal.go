@@ -137,7 +137,7 @@ func calLeaderList(version string, preLeader common.Address, curNumber uint64, p
 		startPos = 0
 	} else {
 		if preIndex, err := findLeaderIndex(preLeader, validators); err != nil {
-			if manversion.VersionCmp(version, manversion.VersionGamma) >= 0 {
+			if manversion.IsEnabled(manversion.FeatureGamma, version) {
 				log.Info(logInfo, "未在验证者列表中未找到preLeader", preLeader.Hex(), "validators", validators, "版本", version)
 				startPos = 0
 			} else {
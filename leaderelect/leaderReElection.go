@@ -198,7 +198,7 @@ func (self *LeaderIdentity) blockPOSFinishedMsgHandle(msg *mc.BlockPOSFinishedNo
 		return
 	}
 
-	if manversion.VersionCmp(string(msg.Header.Version), manversion.VersionGamma) >= 0 {
+	if !isLeaderVersion(string(msg.Header.Version)) {
 		log.Trace(self.extraInfo, "区块POS完成消息处理", "版本号不匹配, 不处理消息", "header version", string(msg.Header.Version), "number", msg.Header.Number)
 		return
 	}
@@ -272,3 +272,8 @@ func (self *LeaderIdentity) rlBroadcastRspHandle(rsp *mc.HD_ReelectBroadcastRspM
 		log.Info(self.extraInfo, "重选广播响应消息", "controller接受消息失败", "err", err)
 	}
 }
+
+// isLeaderVersion 版本注册表中选用本模块计算leader的版本
+func isLeaderVersion(version string) bool {
+	return manversion.Plugin(version, manversion.PluginLeaderElect) == manversion.PlugLeaderV1
+}
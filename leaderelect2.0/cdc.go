@@ -115,7 +115,7 @@ func (dc *cdc) AnalysisState(parentHeader *types.Header, parentState StateReader
 }
 
 func (dc *cdc) nextBlockIsAIBlock(header *types.Header, bcInterval *mc.BCIntervalInfo) bool {
-	if manversion.VersionCmp(string(header.Version), manversion.VersionAIMine) < 0 && dc.number < manversion.VersionNumAIMine {
+	if !manversion.IsEnabled(manversion.FeatureAIMine, string(header.Version)) && dc.number < manversion.FeatureNumber(manversion.FeatureAIMine) {
 		log.Trace(dc.logInfo, "nextBlockIsAIBlock", "版本号且高度均未满足AI版本要求", "header version", string(header.Version), "number", dc.number)
 		return false
 	}
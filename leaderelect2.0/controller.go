@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/consensus/trace"
 	"github.com/MatrixAINetwork/go-matrix/log"
 	"github.com/MatrixAINetwork/go-matrix/mc"
 	"strconv"
@@ -98,6 +99,13 @@ func (self *controller) publishLeaderMsg() {
 		"共识状态", msg.ConsensusState, "共识轮次", msg.ConsensusTurn.String(), "重选轮次", msg.ReelectTurn,
 		"pre Leader", msg.PreLeader.Hex(), "Next Leader", msg.NextLeader.Hex())
 	mc.PublishEvent(mc.Leader_LeaderChangeNotify, msg)
+	trace.Record(trace.Event{Number: msg.Number, Module: trace.ModuleLeader, Kind: trace.KindLeader,
+		Turn: msg.ConsensusTurn.TotalTurns(), ReelectTurn: msg.ReelectTurn, Account: msg.Leader, Detail: "consensus=" + strconv.FormatBool(msg.ConsensusState)})
+}
+
+func (self *controller) recordTrace(kind trace.Kind, account common.Address, detail string) {
+	trace.Record(trace.Event{Number: self.dc.number, Module: trace.ModuleLeader, Kind: kind,
+		Turn: self.dc.curConsensusTurn.TotalTurns(), ReelectTurn: self.dc.curReelectTurn, Account: account, Detail: detail})
 }
 
 func (self *controller) setTimer(outTime int64, timer *time.Timer) {
@@ -8,9 +8,9 @@ import (
 
 	"github.com/MatrixAINetwork/go-matrix/ca"
 	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/consensus/trace"
 	"github.com/MatrixAINetwork/go-matrix/log"
 	"github.com/MatrixAINetwork/go-matrix/mc"
-	"github.com/MatrixAINetwork/go-matrix/params/manversion"
 )
 
 func (self *controller) handleMsg(data interface{}) {
@@ -70,7 +70,7 @@ func (self *controller) handleStartMsg(msg *startControllerMsg) {
 		return
 	}
 
-	if manversion.VersionCmp(string(msg.parentHeader.Version), manversion.VersionGamma) < 0 {
+	if !isLeaderVersion(string(msg.parentHeader.Version)) {
 		log.Trace(self.logInfo, "开始消息处理", "版本号不匹配, 不处理消息", "header version", string(msg.parentHeader.Version))
 		return
 	}
@@ -107,6 +107,7 @@ func (self *controller) handleStartMsg(msg *startControllerMsg) {
 
 	if self.dc.turnTime.SetBeginTime(msg.parentHeader.Time.Int64()) {
 		self.mp.SaveParentHeader(msg.parentHeader)
+		self.recordTrace(trace.KindRoundStart, msg.parentHeader.Leader, "")
 		if isFirstConsensusTurn(self.ConsensusTurn()) {
 			curTime := time.Now().Unix()
 			st, remainTime, reelectTurn := self.dc.turnTime.CalState(0, curTime)
@@ -155,6 +156,7 @@ func (self *controller) timeOutHandle() {
 		return
 	}
 
+	self.recordTrace(trace.KindTimeout, common.Address{}, self.State().String())
 	self.setTimer(remainTime, self.timer)
 	self.dc.state = st
 	self.startReelect(reelectTurn)
@@ -172,6 +174,7 @@ func (self *controller) processPOSState() {
 	}
 
 	log.Debug(self.logInfo, "POS完成", "状态切换为<挖矿结果等待阶段>")
+	self.recordTrace(trace.KindPosDone, self.dc.GetConsensusLeader(), "")
 	self.setTimer(0, self.timer)
 	self.dc.state = stMining
 }
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/consensus/trace"
 	"github.com/MatrixAINetwork/go-matrix/core/types"
 	"github.com/MatrixAINetwork/go-matrix/log"
 	"github.com/MatrixAINetwork/go-matrix/mc"
@@ -31,6 +32,7 @@ func (self *controller) startReelect(reelectTurn uint32) {
 	}
 	beginTime, endTime := self.dc.turnTime.CalTurnTime(self.dc.curConsensusTurn.TotalTurns(), self.dc.curReelectTurn)
 	master := self.dc.GetReelectMaster()
+	self.recordTrace(trace.KindReelectStart, master, "")
 	if master == self.dc.selfAddr {
 		log.Debug(self.logInfo, "(master)开启重选流程", master.Hex(), "轮次", self.curTurnInfo(), "高度", self.dc.number,
 			"轮次开始时间", time.Unix(beginTime, 0).String(), "轮次结束时间", time.Unix(endTime, 0).String(), "self", self.dc.selfAddr.Hex())
@@ -50,6 +52,7 @@ func (self *controller) startReelect(reelectTurn uint32) {
 func (self *controller) finishReelectWithPOS(posResult *mc.HD_BlkConsensusReqMsg, from common.Address) {
 	log.Info(self.logInfo, "完成leader重选", "POS结果重置，恢复并开始挖矿等待", "共识轮次", self.ConsensusTurn().String(), "高度", self.Number())
 	mc.PublishEvent(mc.Leader_RecoveryState, &mc.RecoveryStateMsg{Type: mc.RecoveryTypePOS, Header: posResult.Header, From: from})
+	self.recordTrace(trace.KindReelectFinish, from, "pos")
 	self.setTimer(0, self.timer)
 	self.setTimer(0, self.reelectTimer)
 	self.dc.state = stMining
@@ -68,6 +71,7 @@ func (self *controller) finishReelectWithRLConsensus(rlResult *mc.HD_V2_ReelectL
 
 	//缓存共识结果消息
 	self.mp.SaveRLConsensusMsg(rlResult)
+	self.recordTrace(trace.KindReelectFinish, rlResult.Req.InquiryReq.Master, "leader")
 
 	self.setTimer(0, self.reelectTimer)
 	self.selfCache.ClearSelfInquiryMsg()
@@ -459,6 +459,8 @@ func (api *PublicDebugAPI) DumpBlock(blockNr rpc.BlockNumber, cointyp string, ad
 	var block *types.Block
 	if blockNr == rpc.LatestBlockNumber {
 		block = api.man.blockchain.CurrentBlock()
+	} else if blockNr == rpc.FinalizedBlockNumber {
+		block = api.man.blockchain.GetBlockByHash(api.man.blockchain.CurrentFinalizedHeader().Hash())
 	} else {
 		block = api.man.blockchain.GetBlockByNumber(uint64(blockNr))
 	}
@@ -484,6 +486,8 @@ func (api *PublicDebugAPI) DumpBlockAccount(blockNr rpc.BlockNumber, address com
 	var block *types.Block
 	if blockNr == rpc.LatestBlockNumber {
 		block = api.man.blockchain.CurrentBlock()
+	} else if blockNr == rpc.FinalizedBlockNumber {
+		block = api.man.blockchain.GetBlockByHash(api.man.blockchain.CurrentFinalizedHeader().Hash())
 	} else {
 		block = api.man.blockchain.GetBlockByNumber(uint64(blockNr))
 	}
@@ -88,6 +88,9 @@ func (b *ManAPIBackend) HeaderByNumber(ctx context.Context, blockNr rpc.BlockNum
 	if blockNr == rpc.LatestBlockNumber {
 		return b.man.blockchain.CurrentBlock().Header(), nil
 	}
+	if blockNr == rpc.FinalizedBlockNumber {
+		return b.man.blockchain.CurrentFinalizedHeader(), nil
+	}
 	return b.man.blockchain.GetHeaderByNumber(uint64(blockNr)), nil
 }
 
@@ -105,6 +108,10 @@ func (b *ManAPIBackend) BlockByNumber(ctx context.Context, blockNr rpc.BlockNumb
 	if blockNr == rpc.LatestBlockNumber {
 		return b.man.blockchain.CurrentBlock(), nil
 	}
+	if blockNr == rpc.FinalizedBlockNumber {
+		header := b.man.blockchain.CurrentFinalizedHeader()
+		return b.man.blockchain.GetBlock(header.Hash(), header.Number.Uint64()), nil
+	}
 	return b.man.blockchain.GetBlockByNumber(uint64(blockNr)), nil
 }
 
@@ -444,6 +451,26 @@ type AllReward struct {
 	Interest  []InterestReward
 }
 
+func (b *ManAPIBackend) GetEquivocationEvidence(from, to uint64) []mc.EquivocationEvidence {
+	return b.man.blockVerify.Evidence().Evidences(from, to)
+}
+
+func (b *ManAPIBackend) BLSKeyRegistration() (*mc.BLSKeyRegistration, error) {
+	return b.man.signHelper.BLSKeyRegistration(b.man.BlockChain().CurrentBlock().Hash())
+}
+
+func (b *ManAPIBackend) GetOnlineConsensusAudit(from, to uint64, node common.Address) []mc.OnlineConsensusAudit {
+	return b.man.olConsensus.GetOnlineAudit(from, to, node)
+}
+
+func (b *ManAPIBackend) RecentForkChoices(count int) []*core.ForkChoice {
+	return b.man.BlockChain().RecentForkChoices(count)
+}
+
+func (b *ManAPIBackend) MaxReorgDepth() int {
+	return b.man.BlockChain().MaxReorgDepth()
+}
+
 func (b *ManAPIBackend) GetFutureRewards(state *state.StateDBManage, number rpc.BlockNumber) (interface{}, error) {
 	/*
 		bcInterval, err := manparams.GetBCIntervalInfoByNumber(uint64(number - 1))
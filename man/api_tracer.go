@@ -90,6 +90,8 @@ func (api *PrivateDebugAPI) TraceChain(ctx context.Context, start, end rpc.Block
 		from = api.man.miner.PendingBlock()
 	case rpc.LatestBlockNumber:
 		from = api.man.blockchain.CurrentBlock()
+	case rpc.FinalizedBlockNumber:
+		from = api.man.blockchain.GetBlockByHash(api.man.blockchain.CurrentFinalizedHeader().Hash())
 	default:
 		from = api.man.blockchain.GetBlockByNumber(uint64(start))
 	}
@@ -98,6 +100,8 @@ func (api *PrivateDebugAPI) TraceChain(ctx context.Context, start, end rpc.Block
 		to = api.man.miner.PendingBlock()
 	case rpc.LatestBlockNumber:
 		to = api.man.blockchain.CurrentBlock()
+	case rpc.FinalizedBlockNumber:
+		to = api.man.blockchain.GetBlockByHash(api.man.blockchain.CurrentFinalizedHeader().Hash())
 	default:
 		to = api.man.blockchain.GetBlockByNumber(uint64(end))
 	}
@@ -345,6 +349,8 @@ func (api *PrivateDebugAPI) TraceBlockByNumber(ctx context.Context, number rpc.B
 		block = api.man.miner.PendingBlock()
 	case rpc.LatestBlockNumber:
 		block = api.man.blockchain.CurrentBlock()
+	case rpc.FinalizedBlockNumber:
+		block = api.man.blockchain.GetBlockByHash(api.man.blockchain.CurrentFinalizedHeader().Hash())
 	default:
 		block = api.man.blockchain.GetBlockByNumber(uint64(number))
 	}
@@ -47,6 +47,7 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/man/downloader"
 	"github.com/MatrixAINetwork/go-matrix/man/filters"
 	"github.com/MatrixAINetwork/go-matrix/man/gasprice"
+	"github.com/MatrixAINetwork/go-matrix/man/matrixfeed"
 	"github.com/MatrixAINetwork/go-matrix/mandb"
 	"github.com/MatrixAINetwork/go-matrix/mc"
 	"github.com/MatrixAINetwork/go-matrix/miner"
@@ -98,6 +99,7 @@ type Matrix struct {
 	bloomIndexer  *core.ChainIndexer             // Bloom indexer operating during block imports
 
 	APIBackend *ManAPIBackend
+	feedEvents *matrixfeed.EventSystem // 拓扑、leader、角色、惩罚事件订阅
 
 	miner    *miner.Miner
 	gasPrice *big.Int
@@ -137,7 +139,7 @@ func (s *Matrix) AddLesServer(ls LesServer) {
 // initialisation of the common Matrix object)
 func New(ctx *pod.ServiceContext, config *Config) (*Matrix, error) {
 	if config.SyncMode == downloader.LightSync {
-		return nil, errors.New("can't run man.Matrix in light sync mode, use les.LightMatrix")
+		return nil, errors.New("can't run man.Matrix in light sync mode, use man.LightMatrix")
 	}
 	if !config.SyncMode.IsValid() {
 		return nil, fmt.Errorf("invalid sync mode %d", config.SyncMode)
@@ -236,6 +238,7 @@ func New(ctx *pod.ServiceContext, config *Config) (*Matrix, error) {
 	man.olConsensus.SetValidatorAccountInterface(topNodeInstance)
 	man.olConsensus.SetMessageSendInterface(topNodeInstance)
 	man.olConsensus.SetMessageCenterInterface(topNodeInstance)
+	man.olConsensus.SetAuditDB(chainDb)
 
 	if err = man.olConsensus.Start(); err != nil {
 		return nil, err
@@ -257,6 +260,7 @@ func New(ctx *pod.ServiceContext, config *Config) (*Matrix, error) {
 		gpoParams.Default = config.GasPrice
 	}
 	man.APIBackend.gpo = gasprice.NewOracle(man.APIBackend, gpoParams)
+	man.feedEvents = matrixfeed.NewEventSystem(matrixfeed.NewStateBackend(man.APIBackend))
 	depoistInfo.NewDepositInfo(man.APIBackend)
 	man.broadTx = broadcastTx.NewBroadCast(man.APIBackend) //
 
@@ -284,6 +288,7 @@ func New(ctx *pod.ServiceContext, config *Config) (*Matrix, error) {
 	if err != nil {
 		return nil, err
 	}
+	man.olConsensus.SetEvidenceCollector(man.blockVerify.Evidence())
 	man.lessDiskSvr = lessdisk.NewLessDiskSvr(params.DefLessDiskConfig, chainDb, man.blockchain)
 	man.lessDiskSvr.FuncSwitch(ctx.GetConfig().LessDisk)
 
@@ -438,6 +443,11 @@ func (s *Matrix) APIs() []rpc.API {
 			Version:   "1.0",
 			Service:   filters.NewPublicFilterAPI(s.APIBackend, false),
 			Public:    true,
+		}, {
+			Namespace: "man",
+			Version:   "1.0",
+			Service:   matrixfeed.NewPublicMatrixFeedAPI(s.feedEvents),
+			Public:    true,
 		}, {
 			Namespace: "admin",
 			Version:   "1.0",
@@ -618,6 +628,7 @@ func (s *Matrix) FetcherNotify(hash common.Hash, number uint64, addr common.Addr
 // Stop implements node.Service, terminating all internal goroutines used by the
 // Matrix protocol.
 func (s *Matrix) Stop() error {
+	s.feedEvents.Stop()
 	s.blockGen.Close()
 	s.blockVerify.Close()
 	s.olConsensus.Close()
@@ -90,6 +90,18 @@ func (f *Filter) Logs(ctx context.Context) ([]types.CoinLogs, error) {
 	}
 	head := header.Number.Uint64()
 
+	if f.begin == rpc.FinalizedBlockNumber.Int64() || f.end == rpc.FinalizedBlockNumber.Int64() {
+		finalized, _ := f.backend.HeaderByNumber(ctx, rpc.FinalizedBlockNumber)
+		if finalized == nil {
+			return nil, nil
+		}
+		if f.begin == rpc.FinalizedBlockNumber.Int64() {
+			f.begin = int64(finalized.Number.Uint64())
+		}
+		if f.end == rpc.FinalizedBlockNumber.Int64() {
+			f.end = int64(finalized.Number.Uint64())
+		}
+	}
 	if f.begin == -1 {
 		f.begin = int64(head)
 	}
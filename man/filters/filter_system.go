@@ -10,6 +10,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math/big"
 	"sync"
 	"time"
 
@@ -195,6 +196,20 @@ func (es *EventSystem) SubscribeLogs(crit matrix.FilterQuery, logs chan []types.
 	} else {
 		to = rpc.BlockNumber(crit.ToBlock.Int64())
 	}
+	// "finalized" resolves to the finalized block number at subscription time
+	if from == rpc.FinalizedBlockNumber || to == rpc.FinalizedBlockNumber {
+		finalized, err := es.backend.HeaderByNumber(context.Background(), rpc.FinalizedBlockNumber)
+		if err != nil || finalized == nil {
+			return nil, fmt.Errorf("finalized block not found")
+		}
+		number := new(big.Int).Set(finalized.Number)
+		if from == rpc.FinalizedBlockNumber {
+			from, crit.FromBlock = rpc.BlockNumber(number.Int64()), number
+		}
+		if to == rpc.FinalizedBlockNumber {
+			to, crit.ToBlock = rpc.BlockNumber(number.Int64()), number
+		}
+	}
 
 	// only interested in pending logs
 	if from == rpc.PendingBlockNumber && to == rpc.PendingBlockNumber {
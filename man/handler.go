@@ -93,6 +93,9 @@ type ProtocolManager struct {
 	LastCheckTime    int64
 	LastCheckBlkNum  uint64
 	Msgcenter        *mc.Center
+
+	chaindb         mandb.Database
+	lightDispatcher *lightDispatcher // 轻节点区块头及状态证明请求的应答分发
 	// wait group is used for graceful shutdowns during downloading
 	// and processing
 	wg sync.WaitGroup
@@ -115,6 +118,9 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 		txsyncCh:    make(chan *txsync),
 		quitSync:    make(chan struct{}),
 		Msgcenter:   MsgCenter,
+
+		chaindb:         chaindb,
+		lightDispatcher: newLightDispatcher(),
 	}
 	// Figure out whether to allow fast sync or not
 	if mode == downloader.FastSync && blockchain.CurrentBlock().NumberU64() > 0 {
@@ -158,6 +164,7 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 				}
 				return nil
 			},
+			RateLimitExempt: isResponseMsg,
 		})
 	}
 	if len(manager.SubProtocols) == 0 {
@@ -331,6 +338,12 @@ func (pm *ProtocolManager) handle(p *peer) error {
 		}
 	}
 
+	if err := p.CodecHandshake(); err != nil {
+		p.Log().Debug("Matrix codec handshake failed", "err", err)
+		return err
+	}
+	defer msgsend.RemovePeerCodecs(p.ID())
+
 	//	if rw, ok := p.rw.(*meteredMsgReadWriter); ok {
 	if rw, ok := p.rw.(*meteredMsgReadWriter); ok {
 		rw.Init(p.version)
@@ -465,6 +478,33 @@ func (pm *ProtocolManager) ParseNewBlockData(msg *p2p.Msg) (newBlockData, error)
 
 }
 
+// handleRelayed 处理经哨兵转发给本节点的消息，发送方为envelope.From
+func (pm *ProtocolManager) handleRelayed(p *peer, envelope *p2p.SentryEnvelope) error {
+	switch envelope.Code {
+	case common.AlgorithmMsg:
+		var m msgsend.NetData
+		if err := rlp.DecodeBytes(envelope.Payload, &m); err != nil {
+			log.Error("sentry relay algorithm message", "error", err)
+			p2p.Scores.Report(p.ID(), p2p.MsgClassConsensus, p2p.MsgInvalid)
+			return nil
+		}
+		return mc.PublishEvent(mc.P2P_HDMSG, &msgsend.AlgorithmMsg{Account: envelope.From, Data: m})
+
+	case common.NetworkMsg:
+		var m []*core.MsgStruct
+		if err := rlp.DecodeBytes(envelope.Payload, &m); err != nil {
+			log.Info("sentry relay", "NetworkMsg err", err)
+			p2p.Scores.Report(p.ID(), p2p.MsgClassTx, p2p.MsgInvalid)
+			return nil
+		}
+		go pm.txpool.ProcessMsg(core.NetworkMsgData{SendAddress: envelope.From, Data: m})
+
+	default:
+		log.Debug("sentry relay", "unsupported code", envelope.Code, "from", envelope.From.Hex())
+	}
+	return nil
+}
+
 // handleMsg is invoked whenever an inbound message is received from a remote
 // peer. The remote connection is torn down upon returning any error.
 func (pm *ProtocolManager) handleMsg(p *peer) error {
@@ -484,6 +524,9 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		// Status messages should never arrive after the handshake
 		return errResp(ErrExtraStatusMsg, "uncontrolled status message")
 
+	case p.version >= man64 && msg.Code == CodecVersionsMsg:
+		return errResp(ErrExtraStatusMsg, "uncontrolled codec versions message")
+
 	// Block header query, collect the requested headers and reply
 	case msg.Code == GetBlockHeadersMsg:
 		// Decode the complex header query
@@ -791,11 +834,18 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		// Retrieve and decode the propagated block
 		request, err := pm.ParseNewBlockData(&msg)
 		if err != nil {
+			p2p.Scores.Report(p.ID(), p2p.MsgClassBlock, p2p.MsgInvalid)
 			return err
 		}
 		request.Block.ReceivedAt = msg.ReceivedAt
 		request.Block.ReceivedFrom = p
 
+		// 其他节点已广播过的区块不扣分，只有同一节点重复发送才算重复消息
+		if p.knownBlocks.Has(request.Block.Hash()) {
+			p2p.Scores.Report(p.ID(), p2p.MsgClassBlock, p2p.MsgDuplicate)
+		} else if !pm.blockchain.HasBlock(request.Block.Hash(), request.Block.NumberU64()) {
+			p2p.Scores.Report(p.ID(), p2p.MsgClassBlock, p2p.MsgUseful)
+		}
 		// Mark the peer as owning the block and schedule it for import
 		p.MarkBlock(request.Block.Hash())
 		if request.Block.NumberU64() > pm.fetcher.MaxChainHeight {
@@ -910,6 +960,7 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		for i, tx := range txs {
 			// Validate and mark the remote transaction
 			if tx == nil {
+				p2p.Scores.Report(p.ID(), p2p.MsgClassTx, p2p.MsgInvalid)
 				return errResp(ErrDecode, "transaction %d is nil", i)
 			}
 			if nc := tx.Nonce(); nc < params.NonceAddOne {
@@ -917,6 +968,11 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 				tx.SetNonce(nc)
 			}
 			hash := tx.Hash()
+			if p.knownTxs.Has(hash) {
+				p2p.Scores.Report(p.ID(), p2p.MsgClassTx, p2p.MsgDuplicate)
+			} else {
+				p2p.Scores.Report(p.ID(), p2p.MsgClassTx, p2p.MsgUseful)
+			}
 			p.MarkTransaction(hash)
 			log.Info("==tcp tx hash", "from", tx.From().String(), "tx.Nonce", tx.Nonce(), "hash", hash.String(), "sender addr", p2p.ServerP2p.ConvertIdToAddress(p.ID()).String(),
 				"node id", p.ID().String())
@@ -937,6 +993,7 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		var m msgsend.NetData
 		if err := msg.Decode(&m); err != nil {
 			log.Error("algorithm message", "error", err)
+			p2p.Scores.Report(p.ID(), p2p.MsgClassConsensus, p2p.MsgInvalid)
 			return errResp(ErrDecode, "msg %v: %v", msg, err)
 		}
 		addr := p2p.ServerP2p.ConvertIdToAddress(p.ID())
@@ -948,6 +1005,63 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 	case msg.Code == common.BroadcastReqMsg:
 		return p.SendPongToBroad([]uint8{0})
 
+	case p.version >= man64 && msg.Code == common.SentryTicketMsg:
+		var ticket p2p.SentryTicket
+		if err := msg.Decode(&ticket); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		if err := p2p.Sentry.HandleTicket(p.ID(), &ticket); err != nil {
+			log.Debug("sentry ticket", "error", err, "peer", p.ID().TerminalString())
+			p2p.Scores.Report(p.ID(), p2p.MsgClassConsensus, p2p.MsgInvalid)
+		}
+
+	case p.version >= man64 && msg.Code == common.SentryRelayMsg:
+		var envelope p2p.SentryEnvelope
+		if err := msg.Decode(&envelope); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		deliver, err := p2p.Sentry.HandleEnvelope(p.ID(), &envelope)
+		if err != nil {
+			log.Debug("sentry relay", "error", err, "from", envelope.From.Hex(), "to", envelope.To.Hex())
+			p2p.Scores.Report(p.ID(), p2p.MsgClassConsensus, p2p.MsgInvalid)
+			return nil
+		}
+		if deliver {
+			return pm.handleRelayed(p, &envelope)
+		}
+
+	case p.version >= man64 && msg.Code == GetLightHeadersMsg:
+		var req getLightHeadersData
+		if err := msg.Decode(&req); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		return pm.serveLightHeaders(p, &req)
+
+	case p.version >= man64 && msg.Code == LightHeadersMsg:
+		var resp lightHeadersData
+		if err := msg.Decode(&resp); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		if !pm.lightDispatcher.deliver(resp.ReqID, resp.Headers) {
+			p.Log().Debug("Unrequested light headers", "reqID", resp.ReqID)
+		}
+
+	case p.version >= man64 && msg.Code == GetStateProofMsg:
+		var req getStateProofData
+		if err := msg.Decode(&req); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		return pm.serveStateProof(p, &req)
+
+	case p.version >= man64 && msg.Code == StateProofMsg:
+		var resp stateProofData
+		if err := msg.Decode(&resp); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		if !pm.lightDispatcher.deliver(resp.ReqID, resp.Nodes) {
+			p.Log().Debug("Unrequested state proof", "reqID", resp.ReqID)
+		}
+
 	case msg.Code == common.BroadcastRespMsg:
 		return p2p.Record(p.ID())
 
@@ -0,0 +1,278 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package man
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MatrixAINetwork/go-matrix/base58"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/common/hexutil"
+	"github.com/MatrixAINetwork/go-matrix/consensus"
+	"github.com/MatrixAINetwork/go-matrix/consensus/mtxdpos"
+	"github.com/MatrixAINetwork/go-matrix/core"
+	"github.com/MatrixAINetwork/go-matrix/core/rawdb"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/depoistInfo"
+	"github.com/MatrixAINetwork/go-matrix/internal/manapi"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/man/downloader"
+	"github.com/MatrixAINetwork/go-matrix/man/lightsync"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/msgsend"
+	"github.com/MatrixAINetwork/go-matrix/p2p"
+	"github.com/MatrixAINetwork/go-matrix/p2p/discover"
+	"github.com/MatrixAINetwork/go-matrix/params"
+	"github.com/MatrixAINetwork/go-matrix/params/manversion"
+	"github.com/MatrixAINetwork/go-matrix/pod"
+	"github.com/MatrixAINetwork/go-matrix/rpc"
+)
+
+var errNoLightPeer = errors.New("no man/64 peer to serve light requests")
+
+// LightMatrix 只同步区块头的轻节点，--syncmode light时代替Matrix运行。
+// 只与man/64的全节点连接，从创世区块开始用lightsync.Syncer验证区块头，余额等状态按需向全节点请求证明
+type LightMatrix struct {
+	config     *Config
+	chainDb    mandb.Database
+	genesis    *types.Header
+	syncer     *lightsync.Syncer
+	dispatcher *lightDispatcher
+	peers      *peerSet
+	newPeerCh  chan struct{}
+	quit       chan struct{}
+	wg         sync.WaitGroup
+}
+
+// NewLight 创建轻节点服务，创世区块与全节点一样来自gman init或配置
+func NewLight(ctx *pod.ServiceContext, config *Config) (*LightMatrix, error) {
+	if config.SyncMode != downloader.LightSync {
+		return nil, fmt.Errorf("invalid sync mode %d for light node", config.SyncMode)
+	}
+	chainDb, err := CreateDB(ctx, config, "chaindata")
+	if err != nil {
+		return nil, err
+	}
+	chainConfig, genesisHash, genesisErr := core.SetupGenesisBlock(chainDb, config.Genesis)
+	if _, ok := genesisErr.(*params.ConfigCompatError); genesisErr != nil && !ok {
+		return nil, genesisErr
+	}
+	genesis := rawdb.ReadHeader(chainDb, genesisHash, 0)
+	if genesis == nil {
+		return nil, fmt.Errorf("genesis header %x not found", genesisHash)
+	}
+	// 签名账户换算读取抵押合约状态，轻节点没有本地区块链
+	depoistInfo.NewDepositInfo(nil)
+	engine := mtxdpos.NewMtxDPOS(chainConfig.SimpleMode)
+	syncer, err := lightsync.NewSyncer(chainDb, genesis, func(version []byte) consensus.DPOSEngine { return engine })
+	if err != nil {
+		return nil, err
+	}
+	log.Info("轻节点", "创世区块", genesisHash.TerminalString(), "当前高度", syncer.Head().Number)
+	return &LightMatrix{
+		config:     config,
+		chainDb:    chainDb,
+		genesis:    genesis,
+		syncer:     syncer,
+		dispatcher: newLightDispatcher(),
+		peers:      newPeerSet(),
+		newPeerCh:  make(chan struct{}, 1),
+		quit:       make(chan struct{}),
+	}, nil
+}
+
+// Protocols 只运行man/64，更早的协议版本不支持轻节点请求
+func (l *LightMatrix) Protocols() []p2p.Protocol {
+	for i, version := range ProtocolVersions {
+		if version != man64 {
+			continue
+		}
+		return []p2p.Protocol{{
+			Name:    ProtocolName,
+			Version: version,
+			Length:  ProtocolLengths[i],
+			Run: func(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+				l.wg.Add(1)
+				defer l.wg.Done()
+				return l.handle(newPeer(int(version), p, rw))
+			},
+			PeerInfo: func(id discover.NodeID) interface{} {
+				if p := l.peers.Peer(fmt.Sprintf("%x", id[:8])); p != nil {
+					return p.Info()
+				}
+				return nil
+			},
+		}}
+	}
+	return nil
+}
+
+func (l *LightMatrix) APIs() []rpc.API {
+	return []rpc.API{{
+		Namespace: "light",
+		Version:   "1.0",
+		Service:   &PublicLightAPI{l},
+		Public:    true,
+	}}
+}
+
+func (l *LightMatrix) Start(srvr *p2p.Server) error {
+	srvr.NetWorkId = l.config.NetworkId
+	l.wg.Add(1)
+	go l.syncLoop()
+	return nil
+}
+
+func (l *LightMatrix) Stop() error {
+	close(l.quit)
+	l.peers.Close()
+	l.wg.Wait()
+	l.chainDb.Close()
+	return nil
+}
+
+// handle 握手时声明轻节点的最高区块，之后只处理轻节点请求的应答，其他消息丢弃
+func (l *LightMatrix) handle(p *peer) error {
+	head := l.syncer.Head()
+	if manversion.CanSwitchGammaCanonicalChain(time.Now().Unix()) {
+		if err := p.NewHandshake(l.config.NetworkId, head.Time.Uint64(), head.Hash(), 0, l.genesis.Hash(), 0, head.Number.Uint64()); err != nil {
+			p.Log().Debug("Matrix light handshake failed", "err", err)
+			return err
+		}
+	} else {
+		if err := p.Handshake(l.config.NetworkId, new(big.Int), head.Hash(), 0, l.genesis.Hash(), 0); err != nil {
+			p.Log().Debug("Matrix light handshake failed", "err", err)
+			return err
+		}
+	}
+	if err := p.CodecHandshake(); err != nil {
+		p.Log().Debug("Matrix codec handshake failed", "err", err)
+		return err
+	}
+	defer msgsend.RemovePeerCodecs(p.ID())
+
+	if err := l.peers.Register(p); err != nil {
+		return err
+	}
+	defer l.peers.Unregister(p.id)
+	select {
+	case l.newPeerCh <- struct{}{}:
+	default:
+	}
+
+	for {
+		if err := l.handleMsg(p); err != nil {
+			p.Log().Debug("Matrix light message handling failed", "err", err)
+			return err
+		}
+	}
+}
+
+func (l *LightMatrix) handleMsg(p *peer) error {
+	msg, err := p.rw.ReadMsg()
+	if err != nil {
+		return err
+	}
+	if msg.Size > ProtocolMaxMsgSize {
+		return errResp(ErrMsgTooLarge, "%v > %v", msg.Size, ProtocolMaxMsgSize)
+	}
+	defer msg.Discard()
+
+	switch msg.Code {
+	case LightHeadersMsg:
+		var resp lightHeadersData
+		if err := msg.Decode(&resp); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		if !l.dispatcher.deliver(resp.ReqID, resp.Headers) {
+			p.Log().Debug("Unrequested light headers", "reqID", resp.ReqID)
+		}
+
+	case StateProofMsg:
+		var resp stateProofData
+		if err := msg.Decode(&resp); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		if !l.dispatcher.deliver(resp.ReqID, resp.Nodes) {
+			p.Log().Debug("Unrequested state proof", "reqID", resp.ReqID)
+		}
+	}
+	return nil
+}
+
+// syncLoop 有新连接或定时从各连接同步区块头，一个连接失败时换下一个
+func (l *LightMatrix) syncLoop() {
+	defer l.wg.Done()
+	forceSync := time.NewTicker(forceSyncCycle)
+	defer forceSync.Stop()
+	for {
+		select {
+		case <-l.newPeerCh:
+		case <-forceSync.C:
+		case <-l.quit:
+			return
+		}
+		for _, p := range l.peers.PeersAll() {
+			if err := l.syncer.Sync(l.lightPeer(p)); err != nil {
+				log.Debug("轻节点同步", "同步失败", err, "peer", p.id)
+				continue
+			}
+			break
+		}
+	}
+}
+
+func (l *LightMatrix) lightPeer(p *peer) *lightPeer {
+	return &lightPeer{peer: p, dispatcher: l.dispatcher}
+}
+
+// Balance 从连接的全节点获取账户在最高区块上的余额，证明按已验证的区块头检查
+func (l *LightMatrix) Balance(coin string, account common.Address) (*lightsync.AccountState, error) {
+	var lastErr error = errNoLightPeer
+	for _, p := range l.peers.PeersAll() {
+		state, err := l.syncer.Balance(l.lightPeer(p), coin, account)
+		if err == nil {
+			return state, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// PublicLightAPI 轻节点的RPC接口
+type PublicLightAPI struct {
+	l *LightMatrix
+}
+
+// BlockNumber 返回已验证的最高区块高度
+func (api *PublicLightAPI) BlockNumber() hexutil.Uint64 {
+	return hexutil.Uint64(api.l.syncer.Head().Number.Uint64())
+}
+
+// GetBalance 与man_getBalance相同，地址带币种前缀，查询已验证的最高区块
+func (api *PublicLightAPI) GetBalance(strAddress string) ([]manapi.RPCBalanceType, error) {
+	strlist := strings.Split(strAddress, ".")
+	if len(strlist) < 2 || strlist[0] == "" {
+		return nil, errors.New("Illegal input address")
+	}
+	address, err := base58.Base58DecodeToAddress(strAddress)
+	if err != nil {
+		return nil, err
+	}
+	state, err := api.l.Balance(strlist[0], address)
+	if err != nil {
+		return nil, err
+	}
+	balance := make([]manapi.RPCBalanceType, 0, len(state.Balance))
+	for _, item := range state.Balance {
+		balance = append(balance, manapi.RPCBalanceType{AccountType: item.AccountType, Balance: (*hexutil.Big)(item.Balance)})
+	}
+	return balance, nil
+}
@@ -0,0 +1,134 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package man
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/man/lightsync"
+)
+
+const lightRequestTimeout = 10 * time.Second // 轻节点请求的超时时间
+
+var errLightTimeout = errors.New("light request timeout")
+
+// lightDispatcher 按请求ID把轻节点请求的应答交给等待者
+type lightDispatcher struct {
+	nextID  uint64
+	mu      sync.Mutex
+	pending map[uint64]chan interface{}
+}
+
+func newLightDispatcher() *lightDispatcher {
+	return &lightDispatcher{pending: make(map[uint64]chan interface{})}
+}
+
+func (d *lightDispatcher) request(send func(reqID uint64) error) (interface{}, error) {
+	reqID := atomic.AddUint64(&d.nextID, 1)
+	ch := make(chan interface{}, 1)
+	d.mu.Lock()
+	d.pending[reqID] = ch
+	d.mu.Unlock()
+	defer func() {
+		d.mu.Lock()
+		delete(d.pending, reqID)
+		d.mu.Unlock()
+	}()
+
+	if err := send(reqID); err != nil {
+		return nil, err
+	}
+	timer := time.NewTimer(lightRequestTimeout)
+	defer timer.Stop()
+	select {
+	case resp := <-ch:
+		return resp, nil
+	case <-timer.C:
+		return nil, errLightTimeout
+	}
+}
+
+// deliver 未知请求ID的应答丢弃
+func (d *lightDispatcher) deliver(reqID uint64, resp interface{}) bool {
+	d.mu.Lock()
+	ch, exist := d.pending[reqID]
+	d.mu.Unlock()
+	if !exist {
+		return false
+	}
+	select {
+	case ch <- resp:
+		return true
+	default:
+		return false
+	}
+}
+
+// lightPeer 把协议连接适配为lightsync.Peer
+type lightPeer struct {
+	peer       *peer
+	dispatcher *lightDispatcher
+}
+
+func (lp *lightPeer) ID() string {
+	return lp.peer.id
+}
+
+func (lp *lightPeer) RequestHeaders(origin uint64, amount int) ([]*types.Header, error) {
+	resp, err := lp.dispatcher.request(func(reqID uint64) error {
+		return lp.peer.RequestLightHeaders(reqID, origin, amount)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.([]*types.Header), nil
+}
+
+func (lp *lightPeer) RequestProof(blockHash common.Hash, query *lightsync.ProofQuery) ([][]byte, error) {
+	resp, err := lp.dispatcher.request(func(reqID uint64) error {
+		return lp.peer.RequestStateProof(reqID, blockHash, query)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.([][]byte), nil
+}
+
+// serveLightHeaders 从origin开始返回连续的规范链区块头
+func (pm *ProtocolManager) serveLightHeaders(p *peer, req *getLightHeadersData) error {
+	amount := req.Amount
+	if amount > lightsync.MaxHeaderFetch {
+		amount = lightsync.MaxHeaderFetch
+	}
+	headers := make([]*types.Header, 0, amount)
+	for number := req.Origin; number < req.Origin+amount; number++ {
+		header := pm.blockchain.GetHeaderByNumber(number)
+		if header == nil {
+			break
+		}
+		headers = append(headers, header)
+	}
+	return p.SendLightHeaders(req.ReqID, headers)
+}
+
+// serveStateProof 状态不可用时返回空证明，由请求方判断失败
+func (pm *ProtocolManager) serveStateProof(p *peer, req *getStateProofData) error {
+	header := pm.blockchain.GetHeaderByHash(req.BlockHash)
+	if header == nil {
+		return p.SendStateProof(req.ReqID, nil)
+	}
+	source := lightsync.NewNodeSource(pm.blockchain.StateCache().TrieDB(), pm.chaindb)
+	nodes, err := lightsync.BuildProof(source, header, &req.Query)
+	if err != nil {
+		p.Log().Debug("Failed to build state proof", "block", req.BlockHash, "kind", req.Query.Kind, "err", err)
+		nodes = nil
+	}
+	return p.SendStateProof(req.ReqID, nodes)
+}
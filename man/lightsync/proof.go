@@ -0,0 +1,337 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+// Package lightsync 实现只同步区块头的同步逻辑：区块头按父区块状态中的拓扑图、选举图用DPOS签名逐个验证，
+// 验证所需的状态数据以及账户余额通过状态证明按需向全节点获取。
+//
+// 状态证明是全节点执行一次查询时读到的全部trie节点(以及分区根列表)。轻节点只接受内容与其keccak哈希
+// 相符的节点，在由已验证区块头的Roots打开的状态上重放同一查询，缺少节点时查询失败。
+//
+// 全节点在man/64协议上应答区块头和状态证明请求，--syncmode light时man.LightMatrix运行Syncer。
+package lightsync
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/matrixstate"
+	"github.com/MatrixAINetwork/go-matrix/core/state"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/crypto"
+	"github.com/MatrixAINetwork/go-matrix/depoistInfo"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/params"
+	"github.com/MatrixAINetwork/go-matrix/trie"
+)
+
+const (
+	ProofKindValidators   = uint8(0) // 验证区块签名所需的拓扑图、选举图、超级节点等
+	ProofKindSignAccounts = uint8(1) // 签名账户对应的抵押账户
+	ProofKindBalances     = uint8(2) // 指定币种的账户余额和nonce
+
+	MaxProofAccounts = 64              // 单次查询最多的账户数量
+	MaxProofSize     = 4 * 1024 * 1024 // 单个证明的最大字节数
+)
+
+var (
+	ErrUnknownProofKind = errors.New("unknown proof kind")
+	ErrTooManyAccounts  = errors.New("too many accounts in proof query")
+	ErrMissingState     = errors.New("state is not available")
+	ErrIncompleteProof  = errors.New("incomplete state proof")
+	ErrProofTooLarge    = errors.New("state proof too large")
+	ErrUnknownCoin      = errors.New("coin not exist in header roots")
+	errNodeNotFound     = errors.New("node not found")
+)
+
+// ProofQuery 状态证明请求
+type ProofQuery struct {
+	Kind     uint8
+	Coin     string // ProofKindBalances使用，为空表示MAN
+	Accounts []common.Address
+}
+
+// SignAccount 签名账户及其对应的抵押账户(A0)和授权账户(A1)，抵押账户为空表示不是有效的签名账户
+type SignAccount struct {
+	Account common.Address
+	A0      common.Address
+	A1      common.Address
+}
+
+// AccountState 账户在某一币种下的余额和nonce
+type AccountState struct {
+	Account common.Address
+	Balance common.BalanceType
+	Nonce   uint64
+}
+
+// ProofResult 在状态证明上执行查询的结果，只有请求类型对应的字段有值
+type ProofResult struct {
+	Topology          *mc.TopologyGraph
+	Elect             *mc.ElectGraph
+	BCInterval        *mc.BCIntervalInfo
+	BroadcastAccounts []common.Address
+	VersionSupers     []common.Address
+	BlockSupers       []common.Address
+	BLSCfg            *mc.BLSAggregateCfg // 不支持聚合签名的版本为nil
+	BLSKeys           *mc.BLSPublicKeys
+
+	SignAccounts []SignAccount
+	Balances     []AccountState
+}
+
+// NodeSource 全节点读取trie节点及分区根列表
+type NodeSource func(hash common.Hash) ([]byte, error)
+
+// NewNodeSource trie节点先从状态缓存中取，分区根列表及已落盘的节点从数据库中取
+func NewNodeSource(triedb *trie.Database, db mandb.Database) NodeSource {
+	return func(hash common.Hash) ([]byte, error) {
+		if node, err := triedb.Node(hash); err == nil && len(node) != 0 {
+			return node, nil
+		}
+		return db.Get(hash[:])
+	}
+}
+
+func (q *ProofQuery) coin() string {
+	if q.Coin == "" {
+		return params.MAN_COIN
+	}
+	return q.Coin
+}
+
+func (q *ProofQuery) check() error {
+	if q.Kind > ProofKindBalances {
+		return ErrUnknownProofKind
+	}
+	if len(q.Accounts) > MaxProofAccounts {
+		return ErrTooManyAccounts
+	}
+	return nil
+}
+
+// 只打开查询需要的币种，避免证明中包含无关币种的分区根
+func (q *ProofQuery) roots(header *types.Header) ([]common.CoinRoot, error) {
+	coins := []string{params.MAN_COIN}
+	if q.Kind == ProofKindBalances && q.coin() != params.MAN_COIN {
+		coins = append(coins, q.coin())
+	}
+	roots := make([]common.CoinRoot, 0, len(coins))
+	for _, coin := range coins {
+		for _, root := range header.Roots {
+			if root.Cointyp == coin {
+				roots = append(roots, root)
+				break
+			}
+		}
+	}
+	if len(roots) != len(coins) {
+		return nil, ErrUnknownCoin
+	}
+	return roots, nil
+}
+
+// BuildProof 全节点在header对应的状态上执行查询，返回查询读到的全部节点
+func BuildProof(source NodeSource, header *types.Header, query *ProofQuery) ([][]byte, error) {
+	if err := query.check(); err != nil {
+		return nil, err
+	}
+	rec := &recorder{source: source, nodes: make(map[common.Hash][]byte)}
+	roots, err := query.roots(header)
+	if err != nil {
+		return nil, err
+	}
+	st, err := state.NewStateDBManage(roots, rec, state.NewDatabase(rec))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := runQuery(st, header.Number.Uint64(), query); err != nil {
+		return nil, err
+	}
+	if rec.missing {
+		return nil, ErrMissingState
+	}
+	if rec.size > MaxProofSize {
+		return nil, ErrProofTooLarge
+	}
+	return rec.list(), nil
+}
+
+// VerifyProof 轻节点用已验证的header校验证明并得到查询结果
+func VerifyProof(header *types.Header, query *ProofQuery, nodes [][]byte) (*ProofResult, error) {
+	if err := query.check(); err != nil {
+		return nil, err
+	}
+	wit := newWitness(nodes)
+	roots, err := query.roots(header)
+	if err != nil {
+		return nil, err
+	}
+	st, err := state.NewStateDBManage(roots, wit, state.NewDatabase(wit))
+	if err != nil {
+		return nil, err
+	}
+	result, err := runQuery(st, header.Number.Uint64(), query)
+	if wit.missing {
+		return nil, ErrIncompleteProof
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func runQuery(st *state.StateDBManage, number uint64, query *ProofQuery) (*ProofResult, error) {
+	result := &ProofResult{}
+	var err error
+	switch query.Kind {
+	case ProofKindValidators:
+		if result.Topology, err = matrixstate.GetTopologyGraph(st); err != nil {
+			return nil, err
+		}
+		if result.Elect, err = matrixstate.GetElectGraph(st); err != nil {
+			return nil, err
+		}
+		if result.BCInterval, err = matrixstate.GetBroadcastInterval(st); err != nil {
+			return nil, err
+		}
+		if result.BroadcastAccounts, err = matrixstate.GetBroadcastAccounts(st); err != nil {
+			return nil, err
+		}
+		if result.VersionSupers, err = matrixstate.GetVersionSuperAccounts(st); err != nil {
+			return nil, err
+		}
+		if result.BlockSupers, err = matrixstate.GetBlockSuperAccounts(st); err != nil {
+			return nil, err
+		}
+		// 聚合签名配置只在支持的版本中存在
+		if cfg, err := matrixstate.GetBLSAggregateCfg(st); err == nil {
+			result.BLSCfg = cfg
+			if result.BLSKeys, err = matrixstate.GetBLSPublicKeys(st); err != nil {
+				return nil, err
+			}
+		}
+
+	case ProofKindSignAccounts:
+		result.SignAccounts = make([]SignAccount, 0, len(query.Accounts))
+		for _, account := range query.Accounts {
+			result.SignAccounts = append(result.SignAccounts, signAccount(st, account, number))
+		}
+
+	case ProofKindBalances:
+		result.Balances = make([]AccountState, 0, len(query.Accounts))
+		coin := query.coin()
+		for _, account := range query.Accounts {
+			result.Balances = append(result.Balances, AccountState{
+				Account: account,
+				Balance: st.GetBalance(coin, account),
+				Nonce:   st.GetNonce(coin, account),
+			})
+		}
+	}
+	return result, nil
+}
+
+// 与BlockChain.GetA0AccountFromAnyAccount相同的换算：先按A1账户查，再按A2账户经委托关系查
+func signAccount(st *state.StateDBManage, account common.Address, number uint64) SignAccount {
+	if a0 := depoistInfo.GetDepositAccount(st, account); a0 != (common.Address{}) {
+		return SignAccount{Account: account, A0: a0, A1: account}
+	}
+	a1 := st.GetAuthFrom(params.MAN_COIN, account, number)
+	if a1 == (common.Address{}) {
+		return SignAccount{Account: account}
+	}
+	return SignAccount{Account: account, A0: depoistInfo.GetDepositAccount(st, a1), A1: a1}
+}
+
+// recorder 记录全节点查询时读到的节点，写操作全部丢弃
+type recorder struct {
+	source  NodeSource
+	mu      sync.Mutex
+	nodes   map[common.Hash][]byte
+	size    int
+	missing bool
+}
+
+func (r *recorder) Get(key []byte) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(key) != common.HashLength {
+		r.missing = true
+		return nil, errNodeNotFound
+	}
+	hash := common.BytesToHash(key)
+	if node, exist := r.nodes[hash]; exist {
+		return node, nil
+	}
+	node, err := r.source(hash)
+	if err != nil || len(node) == 0 {
+		r.missing = true
+		return nil, errNodeNotFound
+	}
+	r.nodes[hash] = node
+	r.size += len(node)
+	return node, nil
+}
+
+func (r *recorder) Has(key []byte) (bool, error) {
+	node, err := r.Get(key)
+	return node != nil, err
+}
+
+func (r *recorder) Put(key []byte, value []byte) error { return nil }
+func (r *recorder) Delete(key []byte) error            { return nil }
+func (r *recorder) Close()                             {}
+func (r *recorder) NewBatch() mandb.Batch              { return mandb.NewMemDatabase().NewBatch() }
+
+func (r *recorder) list() [][]byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	nodes := make([][]byte, 0, len(r.nodes))
+	for _, node := range r.nodes {
+		nodes = append(nodes, node)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return bytes.Compare(nodes[i], nodes[j]) < 0 })
+	return nodes
+}
+
+// witness 轻节点的证明数据库，节点以内容的keccak哈希为键，伪造的节点无法被按哈希取到
+type witness struct {
+	mu      sync.Mutex
+	nodes   map[common.Hash][]byte
+	missing bool
+}
+
+func newWitness(nodes [][]byte) *witness {
+	w := &witness{nodes: make(map[common.Hash][]byte, len(nodes))}
+	for _, node := range nodes {
+		w.nodes[crypto.Keccak256Hash(node)] = node
+	}
+	return w
+}
+
+func (w *witness) Get(key []byte) ([]byte, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(key) == common.HashLength {
+		if node, exist := w.nodes[common.BytesToHash(key)]; exist {
+			return node, nil
+		}
+	}
+	w.missing = true
+	return nil, errNodeNotFound
+}
+
+func (w *witness) Has(key []byte) (bool, error) {
+	node, err := w.Get(key)
+	return node != nil, err
+}
+
+func (w *witness) Put(key []byte, value []byte) error { return nil }
+func (w *witness) Delete(key []byte) error            { return nil }
+func (w *witness) Close()                             {}
+func (w *witness) NewBatch() mandb.Batch              { return mandb.NewMemDatabase().NewBatch() }
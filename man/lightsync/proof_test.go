@@ -0,0 +1,111 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package lightsync
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/matrixstate"
+	"github.com/MatrixAINetwork/go-matrix/core/state"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/params"
+	"github.com/MatrixAINetwork/go-matrix/params/manversion"
+)
+
+var (
+	testValidator = common.HexToAddress("0x1000")
+	testAccount   = common.HexToAddress("0x2000")
+)
+
+func newTestChainState(t *testing.T) (NodeSource, *types.Header) {
+	db := mandb.NewMemDatabase()
+	sdb := state.NewDatabase(db)
+	st, err := state.NewStateDBManage(nil, db, sdb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	matrixstate.SetVersionInfo(st, manversion.VersionZeta)
+	matrixstate.SetTopologyGraph(st, &mc.TopologyGraph{NodeList: []mc.TopologyNodeInfo{{Account: testValidator, Type: common.RoleValidator}}})
+	matrixstate.SetElectGraph(st, &mc.ElectGraph{Number: 10, ElectList: []mc.ElectNodeInfo{{Account: testValidator, Stock: 1, Type: common.RoleValidator}}})
+	matrixstate.SetBroadcastInterval(st, &mc.BCIntervalInfo{LastBCNumber: 0, LastReelectNumber: 0, BCInterval: 100})
+	matrixstate.SetBroadcastAccounts(st, []common.Address{common.HexToAddress("0x3000")})
+	matrixstate.SetVersionSuperAccounts(st, []common.Address{common.HexToAddress("0x4000")})
+	matrixstate.SetBlockSuperAccounts(st, []common.Address{common.HexToAddress("0x5000")})
+	st.SetBalance(params.MAN_COIN, common.MainAccount, testAccount, big.NewInt(1234))
+	st.SetNonce(params.MAN_COIN, testAccount, 7)
+
+	roots, _, err := st.Commit(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := &types.Header{Number: big.NewInt(10), Roots: roots}
+	return NewNodeSource(sdb.TrieDB(), db), header
+}
+
+func TestProofValidators(t *testing.T) {
+	source, header := newTestChainState(t)
+	query := &ProofQuery{Kind: ProofKindValidators}
+	nodes, err := BuildProof(source, header, query)
+	if err != nil {
+		t.Fatalf("build proof err: %v", err)
+	}
+	result, err := VerifyProof(header, query, nodes)
+	if err != nil {
+		t.Fatalf("verify proof err: %v", err)
+	}
+	if len(result.Topology.NodeList) != 1 || result.Topology.NodeList[0].Account != testValidator {
+		t.Fatalf("topology = %+v", result.Topology)
+	}
+	if result.Elect.Number != 10 || result.BCInterval.BCInterval != 100 {
+		t.Fatalf("elect = %+v, interval = %+v", result.Elect, result.BCInterval)
+	}
+	if len(result.BlockSupers) != 1 || result.BlockSupers[0] != common.HexToAddress("0x5000") {
+		t.Fatalf("block supers = %v", result.BlockSupers)
+	}
+
+	// 缺少任意一个节点都无法通过验证
+	for i := range nodes {
+		partial := append(append([][]byte{}, nodes[:i]...), nodes[i+1:]...)
+		if _, err := VerifyProof(header, query, partial); err == nil {
+			t.Fatalf("proof accepted without node %d", i)
+		}
+	}
+	// 篡改的节点按哈希取不到
+	tampered := append([][]byte{}, nodes...)
+	tampered[0] = append(append([]byte{}, nodes[0]...), 0x00)
+	if _, err := VerifyProof(header, query, tampered); err != ErrIncompleteProof {
+		t.Fatalf("err = %v, want %v", err, ErrIncompleteProof)
+	}
+}
+
+func TestProofBalances(t *testing.T) {
+	source, header := newTestChainState(t)
+	query := &ProofQuery{Kind: ProofKindBalances, Accounts: []common.Address{testAccount}}
+	nodes, err := BuildProof(source, header, query)
+	if err != nil {
+		t.Fatalf("build proof err: %v", err)
+	}
+	result, err := VerifyProof(header, query, nodes)
+	if err != nil {
+		t.Fatalf("verify proof err: %v", err)
+	}
+	balance := result.Balances[0]
+	if balance.Nonce != params.NonceAddOne+7 || len(balance.Balance) == 0 || balance.Balance[common.MainAccount].Balance.Cmp(big.NewInt(1234)) != 0 {
+		t.Fatalf("balance = %+v", balance)
+	}
+
+	// 另一区块的证明不能用于本区块
+	other := types.CopyHeader(header)
+	other.Roots = []common.CoinRoot{{Cointyp: params.MAN_COIN, Root: common.HexToHash("0x01")}}
+	if _, err := VerifyProof(other, query, nodes); err == nil {
+		t.Fatal("proof accepted for other state root")
+	}
+	if _, err := BuildProof(source, header, &ProofQuery{Kind: ProofKindBalances, Coin: "BTC"}); err != ErrUnknownCoin {
+		t.Fatalf("err = %v, want %v", err, ErrUnknownCoin)
+	}
+}
@@ -0,0 +1,207 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package lightsync
+
+import (
+	"sync"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/crypto"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/pkg/errors"
+)
+
+const maxCachedStates = 64 // 缓存已验证状态查询结果的区块数量
+
+// fetchFunc 获取指定区块状态上的查询结果，结果必须已经过证明校验
+type fetchFunc func(blockHash common.Hash, query *ProofQuery) (*ProofResult, error)
+
+type blockState struct {
+	validators *ProofResult
+	accounts   map[common.Address]SignAccount
+}
+
+// stateReader 为DPOS引擎提供父区块状态，数据来自状态证明而不是本地状态库
+type stateReader struct {
+	fetch   fetchFunc
+	mu      sync.Mutex
+	current common.Hash
+	states  map[common.Hash]*blockState
+	order   []common.Hash
+}
+
+func newStateReader(fetch fetchFunc) *stateReader {
+	return &stateReader{
+		fetch:  fetch,
+		states: make(map[common.Hash]*blockState),
+		order:  make([]common.Hash, 0, maxCachedStates),
+	}
+}
+
+// prepare 验证header前调用：当前区块设为父区块，并一次性获取所有签名账户的抵押账户
+func (r *stateReader) prepare(header *types.Header) error {
+	r.mu.Lock()
+	r.current = header.ParentHash
+	r.mu.Unlock()
+
+	if header.IsSuperHeader() || len(header.Signatures) == 0 {
+		return nil
+	}
+	hash := header.HashNoSignsAndNonce()
+	accounts := make([]common.Address, 0, len(header.Signatures))
+	for _, sign := range header.Signatures {
+		account, _, err := crypto.VerifySignWithValidate(hash.Bytes(), sign.Bytes())
+		if err != nil {
+			continue
+		}
+		accounts = append(accounts, account)
+	}
+	return r.loadAccounts(header.ParentHash, accounts)
+}
+
+func (r *stateReader) state(blockHash common.Hash) *blockState {
+	if st, exist := r.states[blockHash]; exist {
+		return st
+	}
+	st := &blockState{accounts: make(map[common.Address]SignAccount)}
+	if len(r.order) >= maxCachedStates {
+		delete(r.states, r.order[0])
+		r.order = r.order[1:]
+	}
+	r.states[blockHash] = st
+	r.order = append(r.order, blockHash)
+	return st
+}
+
+func (r *stateReader) validators(blockHash common.Hash) (*ProofResult, error) {
+	r.mu.Lock()
+	if st, exist := r.states[blockHash]; exist && st.validators != nil {
+		r.mu.Unlock()
+		return st.validators, nil
+	}
+	r.mu.Unlock()
+
+	result, err := r.fetch(blockHash, &ProofQuery{Kind: ProofKindValidators})
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	r.state(blockHash).validators = result
+	r.mu.Unlock()
+	return result, nil
+}
+
+func (r *stateReader) loadAccounts(blockHash common.Hash, accounts []common.Address) error {
+	r.mu.Lock()
+	st := r.state(blockHash)
+	query := &ProofQuery{Kind: ProofKindSignAccounts, Accounts: make([]common.Address, 0, len(accounts))}
+	for _, account := range accounts {
+		if _, exist := st.accounts[account]; !exist && len(query.Accounts) < MaxProofAccounts {
+			query.Accounts = append(query.Accounts, account)
+		}
+	}
+	r.mu.Unlock()
+	if len(query.Accounts) == 0 {
+		return nil
+	}
+
+	result, err := r.fetch(blockHash, query)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	st = r.state(blockHash)
+	for _, item := range result.SignAccounts {
+		st.accounts[item.Account] = item
+	}
+	return nil
+}
+
+func (r *stateReader) GetCurrentHash() common.Hash {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current
+}
+
+func (r *stateReader) GetGraphByHash(hash common.Hash) (*mc.TopologyGraph, *mc.ElectGraph, error) {
+	result, err := r.validators(hash)
+	if err != nil {
+		return nil, nil, err
+	}
+	return result.Topology, result.Elect, nil
+}
+
+func (r *stateReader) GetBroadcastAccounts(blockHash common.Hash) ([]common.Address, error) {
+	result, err := r.validators(blockHash)
+	if err != nil {
+		return nil, err
+	}
+	return result.BroadcastAccounts, nil
+}
+
+func (r *stateReader) GetVersionSuperAccounts(blockHash common.Hash) ([]common.Address, error) {
+	result, err := r.validators(blockHash)
+	if err != nil {
+		return nil, err
+	}
+	return result.VersionSupers, nil
+}
+
+func (r *stateReader) GetBlockSuperAccounts(blockHash common.Hash) ([]common.Address, error) {
+	result, err := r.validators(blockHash)
+	if err != nil {
+		return nil, err
+	}
+	return result.BlockSupers, nil
+}
+
+func (r *stateReader) GetBroadcastIntervalByHash(blockHash common.Hash) (*mc.BCIntervalInfo, error) {
+	result, err := r.validators(blockHash)
+	if err != nil {
+		return nil, err
+	}
+	return result.BCInterval, nil
+}
+
+func (r *stateReader) GetA0AccountFromAnyAccount(account common.Address, blockHash common.Hash) (common.Address, common.Address, error) {
+	r.mu.Lock()
+	item, exist := r.state(blockHash).accounts[account]
+	r.mu.Unlock()
+	if !exist {
+		if err := r.loadAccounts(blockHash, []common.Address{account}); err != nil {
+			return common.Address{}, common.Address{}, err
+		}
+		r.mu.Lock()
+		item = r.state(blockHash).accounts[account]
+		r.mu.Unlock()
+	}
+	if item.A0 == (common.Address{}) {
+		return common.Address{}, common.Address{}, errors.Errorf("account(%s) has no deposit account", account.Hex())
+	}
+	return item.A0, item.A1, nil
+}
+
+func (r *stateReader) GetBLSAggregateCfg(blockHash common.Hash) (*mc.BLSAggregateCfg, error) {
+	result, err := r.validators(blockHash)
+	if err != nil {
+		return nil, err
+	}
+	if result.BLSCfg == nil {
+		return nil, errors.New("bls aggregate config not exist")
+	}
+	return result.BLSCfg, nil
+}
+
+func (r *stateReader) GetBLSPublicKeys(blockHash common.Hash) (*mc.BLSPublicKeys, error) {
+	result, err := r.validators(blockHash)
+	if err != nil {
+		return nil, err
+	}
+	if result.BLSKeys == nil {
+		return nil, errors.New("bls public keys not exist")
+	}
+	return result.BLSKeys, nil
+}
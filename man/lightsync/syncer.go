@@ -0,0 +1,197 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package lightsync
+
+import (
+	"encoding/binary"
+	"sync"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/consensus"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/rlp"
+	"github.com/pkg/errors"
+)
+
+const MaxHeaderFetch = 64 // 单次请求的区块头数量
+
+// 区块头按完整内容保存：rawdb保存区块头时会裁剪多币种Roots并依赖本地状态恢复，轻节点没有本地状态
+var (
+	headerPrefix    = []byte("light-header-")    // headerPrefix + hash -> rlp(header)
+	canonicalPrefix = []byte("light-canonical-") // canonicalPrefix + number -> hash
+	headKey         = []byte("light-head")
+)
+
+var (
+	ErrNoCheckpoint   = errors.New("light sync checkpoint is nil")
+	ErrUnknownBlock   = errors.New("unknown block")
+	ErrBrokenHeaders  = errors.New("headers are not continuous")
+	ErrTooManyHeaders = errors.New("too many headers")
+)
+
+// Peer 提供区块头和状态证明的全节点
+type Peer interface {
+	ID() string
+	RequestHeaders(origin uint64, amount int) ([]*types.Header, error)
+	RequestProof(blockHash common.Hash, query *ProofQuery) ([][]byte, error)
+}
+
+// EngineFunc 按区块版本选择DPOS引擎，与BlockChain.DPOSEngine相同
+type EngineFunc func(version []byte) consensus.DPOSEngine
+
+// Syncer 从可信检查点开始只同步区块头，每个区块头的签名都按父区块状态中的验证者集合验证。
+// 抵押账户的换算依赖depoistInfo，使用前需要与全节点一样完成depoistInfo的初始化。
+type Syncer struct {
+	db     mandb.Database
+	engine EngineFunc
+	mu     sync.Mutex
+	head   *types.Header
+}
+
+// NewSyncer 数据库中已有同步进度时从进度继续，否则从checkpoint开始
+func NewSyncer(db mandb.Database, checkpoint *types.Header, engine EngineFunc) (*Syncer, error) {
+	s := &Syncer{db: db, engine: engine}
+	if hash, err := db.Get(headKey); err == nil && len(hash) == common.HashLength {
+		s.head = s.GetHeader(common.BytesToHash(hash))
+	}
+	if s.head == nil {
+		if checkpoint == nil {
+			return nil, ErrNoCheckpoint
+		}
+		if err := s.writeHeader(checkpoint); err != nil {
+			return nil, err
+		}
+		s.head = checkpoint
+	}
+	return s, nil
+}
+
+// Head 返回已验证的最高区块头
+func (s *Syncer) Head() *types.Header {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.head
+}
+
+// GetHeader 返回已验证的区块头
+func (s *Syncer) GetHeader(hash common.Hash) *types.Header {
+	data, err := s.db.Get(append(headerPrefix, hash.Bytes()...))
+	if err != nil || len(data) == 0 {
+		return nil
+	}
+	header := new(types.Header)
+	if err := rlp.DecodeBytes(data, header); err != nil {
+		// 与rawdb.ReadHeader相同，再次尝试使用旧header解析
+		oldHeader := new(types.HeaderV1)
+		if err := rlp.DecodeBytes(data, oldHeader); err != nil {
+			log.Error("轻节点同步", "区块头解码失败", err, "hash", hash.TerminalString())
+			return nil
+		}
+		header = oldHeader.TransferHeader()
+	}
+	return header
+}
+
+// GetHeaderByNumber 返回已验证的规范链区块头
+func (s *Syncer) GetHeaderByNumber(number uint64) *types.Header {
+	hash, err := s.db.Get(canonicalKey(number))
+	if err != nil || len(hash) != common.HashLength {
+		return nil
+	}
+	return s.GetHeader(common.BytesToHash(hash))
+}
+
+// Sync 从peer同步区块头直到peer没有更新的区块
+func (s *Syncer) Sync(peer Peer) error {
+	reader := newStateReader(func(blockHash common.Hash, query *ProofQuery) (*ProofResult, error) {
+		return s.fetch(peer, blockHash, query)
+	})
+	for {
+		head := s.Head()
+		headers, err := peer.RequestHeaders(head.Number.Uint64()+1, MaxHeaderFetch)
+		if err != nil {
+			return err
+		}
+		if len(headers) == 0 {
+			return nil
+		}
+		if len(headers) > MaxHeaderFetch {
+			return ErrTooManyHeaders
+		}
+		for _, header := range headers {
+			if err := s.verifyHeader(reader, head, header); err != nil {
+				log.Warn("轻节点同步", "区块头验证失败", err, "高度", header.Number, "peer", peer.ID())
+				return err
+			}
+			if err := s.writeHeader(header); err != nil {
+				return err
+			}
+			head = header
+		}
+		s.mu.Lock()
+		s.head = head
+		s.mu.Unlock()
+		log.Info("轻节点同步", "同步区块头", len(headers), "当前高度", head.Number, "peer", peer.ID())
+	}
+}
+
+// Balance 获取账户在最高区块上指定币种的余额
+func (s *Syncer) Balance(peer Peer, coin string, account common.Address) (*AccountState, error) {
+	head := s.Head()
+	result, err := s.fetch(peer, head.Hash(), &ProofQuery{Kind: ProofKindBalances, Coin: coin, Accounts: []common.Address{account}})
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Balances) != 1 {
+		return nil, ErrIncompleteProof
+	}
+	return &result.Balances[0], nil
+}
+
+func (s *Syncer) verifyHeader(reader *stateReader, parent, header *types.Header) error {
+	if header == nil || header.Number == nil {
+		return ErrBrokenHeaders
+	}
+	if header.Number.Uint64() != parent.Number.Uint64()+1 || header.ParentHash != parent.Hash() {
+		return ErrBrokenHeaders
+	}
+	if err := reader.prepare(header); err != nil {
+		return err
+	}
+	return s.engine(header.Version).VerifyBlock(reader, header)
+}
+
+func (s *Syncer) fetch(peer Peer, blockHash common.Hash, query *ProofQuery) (*ProofResult, error) {
+	header := s.GetHeader(blockHash)
+	if header == nil {
+		return nil, ErrUnknownBlock
+	}
+	nodes, err := peer.RequestProof(blockHash, query)
+	if err != nil {
+		return nil, err
+	}
+	return VerifyProof(header, query, nodes)
+}
+
+func (s *Syncer) writeHeader(header *types.Header) error {
+	data, err := rlp.EncodeToBytes(header)
+	if err != nil {
+		return err
+	}
+	hash := header.Hash()
+	batch := s.db.NewBatch()
+	batch.Put(append(headerPrefix, hash.Bytes()...), data)
+	batch.Put(canonicalKey(header.Number.Uint64()), hash.Bytes())
+	batch.Put(headKey, hash.Bytes())
+	return batch.Write()
+}
+
+func canonicalKey(number uint64) []byte {
+	key := make([]byte, len(canonicalPrefix)+8)
+	copy(key, canonicalPrefix)
+	binary.BigEndian.PutUint64(key[len(canonicalPrefix):], number)
+	return key
+}
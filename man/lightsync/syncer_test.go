@@ -0,0 +1,106 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package lightsync
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/consensus"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/params"
+)
+
+type testPeer struct {
+	source  NodeSource
+	headers map[common.Hash]*types.Header
+	chain   []*types.Header
+}
+
+func (p *testPeer) ID() string { return "test" }
+
+func (p *testPeer) RequestHeaders(origin uint64, amount int) ([]*types.Header, error) {
+	ret := make([]*types.Header, 0)
+	for _, header := range p.chain {
+		if header.Number.Uint64() >= origin && len(ret) < amount {
+			ret = append(ret, header)
+		}
+	}
+	return ret, nil
+}
+
+func (p *testPeer) RequestProof(blockHash common.Hash, query *ProofQuery) ([][]byte, error) {
+	return BuildProof(p.source, p.headers[blockHash], query)
+}
+
+// testEngine 只检查区块头的Coinbase是否为父区块拓扑图中的验证者
+type testEngine struct {
+	consensus.DPOSEngine
+}
+
+func (e *testEngine) VerifyBlock(reader consensus.StateReader, header *types.Header) error {
+	topology, _, err := reader.GetGraphByHash(header.ParentHash)
+	if err != nil {
+		return err
+	}
+	for _, node := range topology.NodeList {
+		if node.Account == header.Coinbase {
+			return nil
+		}
+	}
+	return errors.New("coinbase is not validator")
+}
+
+func TestSyncer(t *testing.T) {
+	source, checkpoint := newTestChainState(t)
+	next := &types.Header{Number: big.NewInt(11), ParentHash: checkpoint.Hash(), Coinbase: testValidator, Roots: checkpoint.Roots}
+	peer := &testPeer{
+		source:  source,
+		headers: map[common.Hash]*types.Header{checkpoint.Hash(): checkpoint, next.Hash(): next},
+		chain:   []*types.Header{next},
+	}
+	engine := func(version []byte) consensus.DPOSEngine { return &testEngine{} }
+
+	db := mandb.NewMemDatabase()
+	syncer, err := NewSyncer(db, checkpoint, engine)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := syncer.Sync(peer); err != nil {
+		t.Fatalf("sync err: %v", err)
+	}
+	if syncer.Head().Hash() != next.Hash() {
+		t.Fatalf("head = %d, want 11", syncer.Head().Number)
+	}
+	state, err := syncer.Balance(peer, params.MAN_COIN, testAccount)
+	if err != nil || state.Balance[common.MainAccount].Balance.Cmp(big.NewInt(1234)) != 0 {
+		t.Fatalf("balance = %+v, err = %v", state, err)
+	}
+
+	// 重启后从数据库中的进度继续
+	syncer, err = NewSyncer(db, nil, engine)
+	if err != nil || syncer.Head().Hash() != next.Hash() {
+		t.Fatalf("resume failed: %v", err)
+	}
+
+	// 签名者不在验证者集合中的区块头被拒绝
+	bad := &types.Header{Number: big.NewInt(12), ParentHash: next.Hash(), Coinbase: common.HexToAddress("0x9999"), Roots: checkpoint.Roots}
+	peer.headers[bad.Hash()] = bad
+	peer.chain = append(peer.chain, bad)
+	if err := syncer.Sync(peer); err == nil {
+		t.Fatal("header signed by non validator accepted")
+	}
+	// 不连续的区块头被拒绝
+	broken := &types.Header{Number: big.NewInt(12), ParentHash: common.HexToHash("0x01"), Coinbase: testValidator, Roots: checkpoint.Roots}
+	peer.chain = []*types.Header{next, broken}
+	if err := syncer.Sync(peer); err != ErrBrokenHeaders {
+		t.Fatalf("err = %v, want %v", err, ErrBrokenHeaders)
+	}
+	if syncer.Head().Hash() != next.Hash() {
+		t.Fatalf("head moved to %d", syncer.Head().Number)
+	}
+}
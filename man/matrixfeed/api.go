@@ -0,0 +1,165 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package matrixfeed
+
+import (
+	"context"
+
+	"github.com/MatrixAINetwork/go-matrix/base58"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/rpc"
+)
+
+// PublicMatrixFeedAPI offers subscriptions to topology, election, leader, role, slash and
+// super block events, e.g. man_subscribe("newTopology").
+type PublicMatrixFeedAPI struct {
+	events *EventSystem
+}
+
+// NewPublicMatrixFeedAPI returns a new PublicMatrixFeedAPI instance over the event
+// system, which is owned and stopped by the caller.
+func NewPublicMatrixFeedAPI(events *EventSystem) *PublicMatrixFeedAPI {
+	return &PublicMatrixFeedAPI{events: events}
+}
+
+// NewTopology sends the topology and election graphs of each block that changes them.
+func (api *PublicMatrixFeedAPI) NewTopology(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		events := make(chan *TopologyEvent, eventChanSize)
+		sub := api.events.SubscribeTopology(events)
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case ev := <-events:
+				notifier.Notify(rpcSub.ID, ev)
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
+// LeaderChanges sends leader and consensus turn changes.
+func (api *PublicMatrixFeedAPI) LeaderChanges(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		events := make(chan *LeaderEvent, eventChanSize)
+		sub := api.events.SubscribeLeader(events)
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case ev := <-events:
+				notifier.Notify(rpcSub.ID, ev)
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
+// RoleChanges sends the role changes of the watched accounts, of all accounts if none is given.
+func (api *PublicMatrixFeedAPI) RoleChanges(ctx context.Context, accounts []string) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	watched := make(map[common.Address]struct{}, len(accounts))
+	for _, account := range accounts {
+		addr, err := base58.Base58DecodeToAddress(account)
+		if err != nil {
+			return nil, err
+		}
+		watched[addr] = struct{}{}
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		events := make(chan *RoleEvent, eventChanSize)
+		sub := api.events.SubscribeRoles(events)
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case ev := <-events:
+				if _, ok := watched[ev.address]; ok || len(watched) == 0 {
+					notifier.Notify(rpcSub.ID, ev)
+				}
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
+// SlashEvents sends new slash evidences, slash reversals and black list changes.
+func (api *PublicMatrixFeedAPI) SlashEvents(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		events := make(chan *SlashEvent, eventChanSize)
+		sub := api.events.SubscribeSlash(events)
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case ev := <-events:
+				notifier.Notify(rpcSub.ID, ev)
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
+// SuperBlocks sends the super blocks inserted into the canonical chain.
+func (api *PublicMatrixFeedAPI) SuperBlocks(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		events := make(chan *SuperBlockEvent, eventChanSize)
+		sub := api.events.SubscribeSuperBlock(events)
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case ev := <-events:
+				notifier.Notify(rpcSub.ID, ev)
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
@@ -0,0 +1,118 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package matrixfeed
+
+import (
+	"github.com/MatrixAINetwork/go-matrix/base58"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/params"
+)
+
+// 惩罚事件类型
+const (
+	SlashKindEvidence        = "slash"
+	SlashKindReversal        = "slashReversed"
+	SlashKindBlacklistAdd    = "blacklistAdded"
+	SlashKindBlacklistRemove = "blacklistRemoved"
+)
+
+// 黑名单名称
+const (
+	BlackListBlockProduce = "blockProduce"
+	BlackListBasePower    = "basePower"
+	BlackListAccount      = "account"
+	BlackListElect        = "elect"
+)
+
+func manAddress(addr common.Address) string {
+	return base58.Base58EncodeToString(params.MAN_COIN, addr)
+}
+
+// TopologyNode 拓扑图或选举图中的节点
+type TopologyNode struct {
+	Account  string `json:"account"`
+	Position uint16 `json:"position"`
+	Role     string `json:"role"`
+	Stock    uint16 `json:"stock,omitempty"`
+	VIPLevel int    `json:"vipLevel,omitempty"`
+}
+
+func topologyNodes(graph *mc.TopologyGraph) []TopologyNode {
+	nodes := make([]TopologyNode, 0)
+	if graph == nil {
+		return nodes
+	}
+	for _, node := range graph.NodeList {
+		nodes = append(nodes, TopologyNode{Account: manAddress(node.Account), Position: node.Position, Role: node.Type.String()})
+	}
+	return nodes
+}
+
+func electNodes(list []mc.ElectNodeInfo) []TopologyNode {
+	nodes := make([]TopologyNode, 0, len(list))
+	for _, node := range list {
+		nodes = append(nodes, TopologyNode{
+			Account:  manAddress(node.Account),
+			Position: node.Position,
+			Role:     node.Type.String(),
+			Stock:    node.Stock,
+			VIPLevel: int(node.VIPLevel),
+		})
+	}
+	return nodes
+}
+
+// TopologyEvent 拓扑图或选举图在区块中发生变化
+type TopologyEvent struct {
+	Number             uint64         `json:"number"`
+	Hash               common.Hash    `json:"hash"`
+	TopologyChanged    bool           `json:"topologyChanged"`
+	ElectChanged       bool           `json:"electChanged"`
+	Topology           []TopologyNode `json:"topology"`
+	ElectNumber        uint64         `json:"electNumber"`
+	Elect              []TopologyNode `json:"elect"`
+	NextMinerElect     []TopologyNode `json:"nextMinerElect"`
+	NextValidatorElect []TopologyNode `json:"nextValidatorElect"`
+}
+
+// LeaderEvent leader或共识轮次发生变化
+type LeaderEvent struct {
+	Number         uint64 `json:"number"`
+	Leader         string `json:"leader"`
+	PreLeader      string `json:"preLeader"`
+	NextLeader     string `json:"nextLeader"`
+	ConsensusState bool   `json:"consensusState"`
+	ConsensusTurn  uint32 `json:"consensusTurn"`
+	ReelectTurn    uint32 `json:"reelectTurn"`
+}
+
+// RoleEvent 账户的角色在区块中发生变化
+type RoleEvent struct {
+	Number  uint64      `json:"number"`
+	Hash    common.Hash `json:"hash"`
+	Account string      `json:"account"`
+	OldRole string      `json:"oldRole"`
+	NewRole string      `json:"newRole"`
+	address common.Address
+}
+
+// SlashEvent 新的惩罚证据、惩罚撤销或黑名单变化
+type SlashEvent struct {
+	Number    uint64      `json:"number"`
+	Hash      common.Hash `json:"hash"`
+	Kind      string      `json:"kind"`
+	Account   string      `json:"account"`
+	SlashType *uint8      `json:"slashType,omitempty"`
+	BlackList string      `json:"blackList,omitempty"`
+}
+
+// SuperBlockEvent 超级区块上链
+type SuperBlockEvent struct {
+	Number   uint64      `json:"number"`
+	Hash     common.Hash `json:"hash"`
+	SuperSeq uint64      `json:"superSeq"`
+	Time     uint64      `json:"time"`
+}
@@ -0,0 +1,387 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+// Package matrixfeed turns matrix state changes of inserted blocks and internal leader
+// notifications into RPC subscriptions.
+package matrixfeed
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"sync/atomic"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/matrixstate"
+	"github.com/MatrixAINetwork/go-matrix/core/state"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/event"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+)
+
+const (
+	logInfo = "matrix feed"
+
+	// eventChanSize 内部事件通道的缓存大小
+	eventChanSize = 64
+)
+
+var errStateNotFound = errors.New("block state not found")
+
+// Backend 按区块哈希读取区块头和矩阵状态
+type Backend interface {
+	MatrixState(hash common.Hash) (matrixstate.StateDB, *types.Header, error)
+}
+
+// StateBackend 提供按哈希读取状态的API后端
+type StateBackend interface {
+	StateAndHeaderByHash(ctx context.Context, hash common.Hash) (*state.StateDBManage, *types.Header, error)
+}
+
+type stateBackend struct {
+	b StateBackend
+}
+
+// NewStateBackend 将API后端适配为Backend
+func NewStateBackend(b StateBackend) Backend {
+	return &stateBackend{b}
+}
+
+func (s *stateBackend) MatrixState(hash common.Hash) (matrixstate.StateDB, *types.Header, error) {
+	st, header, err := s.b.StateAndHeaderByHash(context.Background(), hash)
+	if err != nil {
+		return nil, nil, err
+	}
+	if st == nil || header == nil {
+		return nil, nil, errStateNotFound
+	}
+	return st, header, nil
+}
+
+type subscribeFunc func(aim mc.EventCode, ch interface{}) (event.Subscription, error)
+
+// snapshot 一个区块的矩阵状态中需要比较的部分
+type snapshot struct {
+	topology  *mc.TopologyGraph
+	elect     *mc.ElectGraph
+	roles     map[common.Address]common.RoleType
+	evidence  map[evidenceKey]bool // 值为是否已撤销
+	blackList map[string]map[common.Address]struct{}
+}
+
+type evidenceKey struct {
+	Type    uint8
+	Address common.Address
+	Number  uint64
+}
+
+// EventSystem 订阅区块上链和leader变化事件，比较前后区块的矩阵状态，分发给RPC订阅者。
+// 没有订阅者时不读取状态。
+type EventSystem struct {
+	backend Backend
+
+	topologyFeed event.Feed
+	leaderFeed   event.Feed
+	roleFeed     event.Feed
+	slashFeed    event.Feed
+	superFeed    event.Feed
+
+	subscribers int32
+	prev        *snapshot
+	lastLeader  *LeaderEvent
+
+	quit     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewEventSystem 创建事件系统并开始监听进程内的事件中心
+func NewEventSystem(backend Backend) *EventSystem {
+	es, err := newEventSystem(backend, mc.SubscribeEvent)
+	if err != nil {
+		log.Error(logInfo, "订阅事件失败", err)
+	}
+	return es
+}
+
+func newEventSystem(backend Backend, subscribe subscribeFunc) (*EventSystem, error) {
+	es := &EventSystem{
+		backend: backend,
+		quit:    make(chan struct{}),
+	}
+	blockCh := make(chan *mc.BlockInsertedMsg, eventChanSize)
+	blockSub, err := subscribe(mc.BlockInserted, blockCh)
+	if err != nil {
+		return es, err
+	}
+	leaderCh := make(chan *mc.LeaderChangeNotify, eventChanSize)
+	leaderSub, err := subscribe(mc.Leader_LeaderChangeNotify, leaderCh)
+	if err != nil {
+		blockSub.Unsubscribe()
+		return es, err
+	}
+	es.wg.Add(1)
+	go es.loop(blockCh, blockSub, leaderCh, leaderSub)
+	return es, nil
+}
+
+// Stop 停止监听并取消事件订阅，可重复调用
+func (es *EventSystem) Stop() {
+	es.stopOnce.Do(func() { close(es.quit) })
+	es.wg.Wait()
+}
+
+func (es *EventSystem) loop(blockCh chan *mc.BlockInsertedMsg, blockSub event.Subscription, leaderCh chan *mc.LeaderChangeNotify, leaderSub event.Subscription) {
+	defer es.wg.Done()
+	defer blockSub.Unsubscribe()
+	defer leaderSub.Unsubscribe()
+	for {
+		select {
+		case msg := <-blockCh:
+			if msg == nil || !msg.CanonState {
+				continue
+			}
+			if atomic.LoadInt32(&es.subscribers) == 0 {
+				es.prev = nil
+				continue
+			}
+			if err := es.processBlock(msg.Block.Hash); err != nil {
+				log.Warn(logInfo, "处理区块失败, number", msg.Block.Number, "hash", msg.Block.Hash.TerminalString(), "err", err)
+			}
+		case msg := <-leaderCh:
+			if msg != nil && atomic.LoadInt32(&es.subscribers) > 0 {
+				es.processLeader(msg)
+			}
+		case <-blockSub.Err():
+			return
+		case <-leaderSub.Err():
+			return
+		case <-es.quit:
+			return
+		}
+	}
+}
+
+func (es *EventSystem) processLeader(msg *mc.LeaderChangeNotify) {
+	ev := &LeaderEvent{
+		Number:         msg.Number,
+		Leader:         manAddress(msg.Leader),
+		PreLeader:      manAddress(msg.PreLeader),
+		NextLeader:     manAddress(msg.NextLeader),
+		ConsensusState: msg.ConsensusState,
+		ConsensusTurn:  msg.ConsensusTurn.TotalTurns(),
+		ReelectTurn:    msg.ReelectTurn,
+	}
+	// leader服务会重复公布相同的身份，只转发变化
+	if es.lastLeader != nil && *es.lastLeader == *ev {
+		return
+	}
+	es.lastLeader = ev
+	es.leaderFeed.Send(ev)
+}
+
+// processBlock 读取区块的矩阵状态，与上一个处理的区块比较并发送事件
+func (es *EventSystem) processBlock(hash common.Hash) error {
+	st, header, err := es.backend.MatrixState(hash)
+	if err != nil {
+		return err
+	}
+	number := header.Number.Uint64()
+	if header.IsSuperHeader() {
+		es.superFeed.Send(&SuperBlockEvent{Number: number, Hash: hash, SuperSeq: header.SuperBlockSeq(), Time: header.Time.Uint64()})
+	}
+
+	cur := readSnapshot(st)
+	prev := es.prev
+	es.prev = cur
+	if prev == nil {
+		return nil
+	}
+
+	topologyChanged := !reflect.DeepEqual(prev.topology, cur.topology)
+	electChanged := !reflect.DeepEqual(prev.elect, cur.elect)
+	if topologyChanged || electChanged {
+		ev := &TopologyEvent{
+			Number:             number,
+			Hash:               hash,
+			TopologyChanged:    topologyChanged,
+			ElectChanged:       electChanged,
+			Topology:           topologyNodes(cur.topology),
+			Elect:              make([]TopologyNode, 0),
+			NextMinerElect:     make([]TopologyNode, 0),
+			NextValidatorElect: make([]TopologyNode, 0),
+		}
+		if cur.elect != nil {
+			ev.ElectNumber = cur.elect.Number
+			ev.Elect = electNodes(cur.elect.ElectList)
+			ev.NextMinerElect = electNodes(cur.elect.NextMinerElect)
+			ev.NextValidatorElect = electNodes(cur.elect.NextValidatorElect)
+		}
+		es.topologyFeed.Send(ev)
+	}
+
+	for _, ev := range diffRoles(prev.roles, cur.roles) {
+		ev.Number, ev.Hash = number, hash
+		es.roleFeed.Send(ev)
+	}
+	for _, ev := range diffSlash(prev, cur) {
+		ev.Number, ev.Hash = number, hash
+		es.slashFeed.Send(ev)
+	}
+	return nil
+}
+
+// readSnapshot 读取需要比较的状态，旧版本状态中不存在的项视为空
+func readSnapshot(st matrixstate.StateDB) *snapshot {
+	snap := &snapshot{
+		roles:     make(map[common.Address]common.RoleType),
+		evidence:  make(map[evidenceKey]bool),
+		blackList: make(map[string]map[common.Address]struct{}),
+	}
+	if graph, err := matrixstate.GetTopologyGraph(st); err == nil {
+		snap.topology = graph
+	}
+	if graph, err := matrixstate.GetElectGraph(st); err == nil {
+		snap.elect = graph
+	}
+	if accounts, err := matrixstate.GetBroadcastAccounts(st); err == nil {
+		for _, addr := range accounts {
+			snap.roles[addr] = common.RoleBroadcast
+		}
+	}
+	if snap.topology != nil {
+		for _, node := range snap.topology.NodeList {
+			snap.roles[node.Account] = node.Type
+		}
+	}
+	if list, err := matrixstate.GetSlashEvidence(st); err == nil && list != nil {
+		for _, evidence := range list.EvidenceList {
+			snap.evidence[evidenceKey{evidence.Type, evidence.Address, evidence.Number}] = evidence.Reversed
+		}
+	}
+
+	addList := func(name string, accounts []common.Address) {
+		set := make(map[common.Address]struct{}, len(accounts))
+		for _, addr := range accounts {
+			set[addr] = struct{}{}
+		}
+		snap.blackList[name] = set
+	}
+	if list, err := matrixstate.GetBlockProduceBlackList(st); err == nil && list != nil {
+		accounts := make([]common.Address, 0, len(list.BlackList))
+		for _, item := range list.BlackList {
+			accounts = append(accounts, item.Address)
+		}
+		addList(BlackListBlockProduce, accounts)
+	}
+	if list, err := matrixstate.GetBasePowerBlackList(st); err == nil && list != nil {
+		accounts := make([]common.Address, 0, len(list.BlackList))
+		for _, item := range list.BlackList {
+			accounts = append(accounts, item.Address)
+		}
+		addList(BlackListBasePower, accounts)
+	}
+	if accounts, err := matrixstate.GetAccountBlackList(st); err == nil {
+		addList(BlackListAccount, accounts)
+	}
+	if accounts, err := matrixstate.GetElectBlackList(st); err == nil {
+		addList(BlackListElect, accounts)
+	}
+	return snap
+}
+
+func diffRoles(prev, cur map[common.Address]common.RoleType) []*RoleEvent {
+	events := make([]*RoleEvent, 0)
+	changed := func(addr common.Address, oldRole, newRole common.RoleType) {
+		events = append(events, &RoleEvent{Account: manAddress(addr), OldRole: oldRole.String(), NewRole: newRole.String(), address: addr})
+	}
+	for addr, role := range cur {
+		if old, ok := prev[addr]; !ok {
+			changed(addr, common.RoleDefault, role)
+		} else if old != role {
+			changed(addr, old, role)
+		}
+	}
+	for addr, role := range prev {
+		if _, ok := cur[addr]; !ok {
+			changed(addr, role, common.RoleDefault)
+		}
+	}
+	return events
+}
+
+func diffSlash(prev, cur *snapshot) []*SlashEvent {
+	events := make([]*SlashEvent, 0)
+	for key, reversed := range cur.evidence {
+		slashType := key.Type
+		wasReversed, existed := prev.evidence[key]
+		if !existed {
+			events = append(events, &SlashEvent{Kind: SlashKindEvidence, Account: manAddress(key.Address), SlashType: &slashType})
+		}
+		if reversed && !wasReversed {
+			events = append(events, &SlashEvent{Kind: SlashKindReversal, Account: manAddress(key.Address), SlashType: &slashType})
+		}
+	}
+	for name, set := range cur.blackList {
+		for addr := range set {
+			if _, ok := prev.blackList[name][addr]; !ok {
+				events = append(events, &SlashEvent{Kind: SlashKindBlacklistAdd, Account: manAddress(addr), BlackList: name})
+			}
+		}
+	}
+	for name, set := range prev.blackList {
+		for addr := range set {
+			if _, ok := cur.blackList[name][addr]; !ok {
+				events = append(events, &SlashEvent{Kind: SlashKindBlacklistRemove, Account: manAddress(addr), BlackList: name})
+			}
+		}
+	}
+	return events
+}
+
+// countedSub 取消订阅时减少订阅者计数
+type countedSub struct {
+	event.Subscription
+	es   *EventSystem
+	once sync.Once
+}
+
+func (s *countedSub) Unsubscribe() {
+	s.once.Do(func() {
+		s.Subscription.Unsubscribe()
+		atomic.AddInt32(&s.es.subscribers, -1)
+	})
+}
+
+func (es *EventSystem) counted(sub event.Subscription) event.Subscription {
+	atomic.AddInt32(&es.subscribers, 1)
+	return &countedSub{Subscription: sub, es: es}
+}
+
+// SubscribeTopology 订阅拓扑图和选举图的变化
+func (es *EventSystem) SubscribeTopology(ch chan<- *TopologyEvent) event.Subscription {
+	return es.counted(es.topologyFeed.Subscribe(ch))
+}
+
+// SubscribeLeader 订阅leader变化
+func (es *EventSystem) SubscribeLeader(ch chan<- *LeaderEvent) event.Subscription {
+	return es.counted(es.leaderFeed.Subscribe(ch))
+}
+
+// SubscribeRoles 订阅所有账户的角色变化
+func (es *EventSystem) SubscribeRoles(ch chan<- *RoleEvent) event.Subscription {
+	return es.counted(es.roleFeed.Subscribe(ch))
+}
+
+// SubscribeSlash 订阅惩罚和黑名单变化
+func (es *EventSystem) SubscribeSlash(ch chan<- *SlashEvent) event.Subscription {
+	return es.counted(es.slashFeed.Subscribe(ch))
+}
+
+// SubscribeSuperBlock 订阅超级区块
+func (es *EventSystem) SubscribeSuperBlock(ch chan<- *SuperBlockEvent) event.Subscription {
+	return es.counted(es.superFeed.Subscribe(ch))
+}
@@ -0,0 +1,221 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package matrixfeed
+
+import (
+	"encoding/binary"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/matrixstate"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/event"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/params/manversion"
+)
+
+var (
+	testValidator = common.HexToAddress("0x1000")
+	testMiner     = common.HexToAddress("0x2000")
+	testBroadcast = common.HexToAddress("0x3000")
+	superLeader   = common.HexToAddress("0x8111111111111111111111111111111111111111")
+)
+
+// testCenter 同步投递的事件中心，保证事件按发送顺序到达
+type testCenter map[mc.EventCode]*event.Feed
+
+func newTestCenter() testCenter {
+	return testCenter{mc.BlockInserted: new(event.Feed), mc.Leader_LeaderChangeNotify: new(event.Feed)}
+}
+
+func (c testCenter) subscribe(aim mc.EventCode, ch interface{}) (event.Subscription, error) {
+	feed, ok := c[aim]
+	if !ok {
+		return nil, mc.SubErrorNoThisEvent
+	}
+	return feed.Subscribe(ch), nil
+}
+
+func (c testCenter) send(aim mc.EventCode, data interface{}) {
+	c[aim].Send(data)
+}
+
+type memState map[common.Hash][]byte
+
+func (m memState) GetMatrixData(hash common.Hash) []byte      { return m[hash] }
+func (m memState) SetMatrixData(hash common.Hash, val []byte) { m[hash] = val }
+
+type testBlock struct {
+	st     memState
+	header *types.Header
+}
+
+type testBackend map[common.Hash]testBlock
+
+func (b testBackend) MatrixState(hash common.Hash) (matrixstate.StateDB, *types.Header, error) {
+	block, ok := b[hash]
+	if !ok {
+		return nil, nil, errStateNotFound
+	}
+	return block.st, block.header, nil
+}
+
+func (b testBackend) add(t *testing.T, header *types.Header, init func(st matrixstate.StateDB)) *mc.BlockInsertedMsg {
+	st := make(memState)
+	if err := matrixstate.SetVersionInfo(st, manversion.VersionZeta); err != nil {
+		t.Fatal(err)
+	}
+	init(st)
+	hash := header.Hash()
+	b[hash] = testBlock{st, header}
+	return &mc.BlockInsertedMsg{Block: mc.BlockInfo{Hash: hash, Number: header.Number.Uint64()}, CanonState: true}
+}
+
+func TestBlockEvents(t *testing.T) {
+	backend := make(testBackend)
+	center := newTestCenter()
+	es, err := newEventSystem(backend, center.subscribe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer es.Stop()
+
+	topologyCh := make(chan *TopologyEvent, eventChanSize)
+	roleCh := make(chan *RoleEvent, eventChanSize)
+	slashCh := make(chan *SlashEvent, eventChanSize)
+	superCh := make(chan *SuperBlockEvent, eventChanSize)
+	defer es.SubscribeTopology(topologyCh).Unsubscribe()
+	defer es.SubscribeRoles(roleCh).Unsubscribe()
+	defer es.SubscribeSlash(slashCh).Unsubscribe()
+	defer es.SubscribeSuperBlock(superCh).Unsubscribe()
+
+	first := backend.add(t, &types.Header{Number: big.NewInt(1), Time: big.NewInt(1)}, func(st matrixstate.StateDB) {
+		matrixstate.SetTopologyGraph(st, &mc.TopologyGraph{NodeList: []mc.TopologyNodeInfo{{Account: testValidator, Type: common.RoleValidator}}})
+		matrixstate.SetBroadcastAccounts(st, []common.Address{testBroadcast})
+		matrixstate.SetElectBlackList(st, []common.Address{testMiner})
+	})
+	extra := make([]byte, 8)
+	binary.BigEndian.PutUint64(extra, 3)
+	second := backend.add(t, &types.Header{Number: big.NewInt(2), Time: big.NewInt(2), Leader: superLeader, Extra: extra}, func(st matrixstate.StateDB) {
+		matrixstate.SetTopologyGraph(st, &mc.TopologyGraph{NodeList: []mc.TopologyNodeInfo{
+			{Account: testValidator, Type: common.RoleValidator},
+			{Account: testMiner, Type: common.RoleMiner},
+		}})
+		matrixstate.SetBroadcastAccounts(st, []common.Address{testBroadcast})
+		matrixstate.SetElectBlackList(st, []common.Address{})
+		matrixstate.SetSlashEvidence(st, &mc.SlashEvidenceList{EvidenceList: []mc.SlashEvidence{{Type: 1, Address: testValidator, Number: 2}}})
+	})
+	center.send(mc.BlockInserted, first)
+	center.send(mc.BlockInserted, second)
+
+	select {
+	case ev := <-superCh:
+		if ev.Number != 2 || ev.SuperSeq != 3 {
+			t.Errorf("super block event %+v", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("super block event timeout")
+	}
+	select {
+	case ev := <-topologyCh:
+		if ev.Number != 2 || !ev.TopologyChanged || ev.ElectChanged || len(ev.Topology) != 2 {
+			t.Errorf("topology event %+v", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("topology event timeout")
+	}
+	select {
+	case ev := <-roleCh:
+		if ev.address != testMiner || ev.OldRole != common.RoleType(common.RoleDefault).String() || ev.NewRole != common.RoleType(common.RoleMiner).String() {
+			t.Errorf("role event %+v", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("role event timeout")
+	}
+
+	kinds := make(map[string]string)
+	for i := 0; i < 2; i++ {
+		select {
+		case ev := <-slashCh:
+			kinds[ev.Kind] = ev.Account
+		case <-time.After(time.Second):
+			t.Fatal("slash event timeout")
+		}
+	}
+	if kinds[SlashKindEvidence] != manAddress(testValidator) || kinds[SlashKindBlacklistRemove] != manAddress(testMiner) {
+		t.Errorf("slash events %v", kinds)
+	}
+	select {
+	case ev := <-roleCh:
+		t.Errorf("unexpected role event %+v", ev)
+	case ev := <-topologyCh:
+		t.Errorf("unexpected topology event %+v", ev)
+	default:
+	}
+}
+
+func TestLeaderDedupe(t *testing.T) {
+	center := newTestCenter()
+	es, err := newEventSystem(make(testBackend), center.subscribe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer es.Stop()
+
+	leaderCh := make(chan *LeaderEvent, eventChanSize)
+	defer es.SubscribeLeader(leaderCh).Unsubscribe()
+
+	notify := &mc.LeaderChangeNotify{ConsensusState: true, Leader: testValidator, Number: 5}
+	center.send(mc.Leader_LeaderChangeNotify, notify)
+	center.send(mc.Leader_LeaderChangeNotify, notify)
+	center.send(mc.Leader_LeaderChangeNotify, &mc.LeaderChangeNotify{ConsensusState: true, Leader: testMiner, Number: 5, ReelectTurn: 1})
+
+	for _, want := range []common.Address{testValidator, testMiner} {
+		select {
+		case ev := <-leaderCh:
+			if ev.Leader != manAddress(want) {
+				t.Errorf("leader %s, want %s", ev.Leader, manAddress(want))
+			}
+		case <-time.After(time.Second):
+			t.Fatal("leader event timeout")
+		}
+	}
+	select {
+	case ev := <-leaderCh:
+		t.Errorf("unexpected leader event %+v", ev)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestStopUnsubscribes(t *testing.T) {
+	center := newTestCenter()
+	var subs []event.Subscription
+	subscribe := func(aim mc.EventCode, ch interface{}) (event.Subscription, error) {
+		sub, err := center.subscribe(aim, ch)
+		if err == nil {
+			subs = append(subs, sub)
+		}
+		return sub, err
+	}
+	es, err := newEventSystem(make(testBackend), subscribe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	es.Stop()
+	es.Stop()
+
+	if len(subs) != 2 {
+		t.Fatalf("subscriptions %d", len(subs))
+	}
+	for i, sub := range subs {
+		select {
+		case <-sub.Err():
+		case <-time.After(time.Second):
+			t.Errorf("subscription %d not released", i)
+		}
+	}
+}
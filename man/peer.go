@@ -10,7 +10,9 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/core/types"
 	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/man/lightsync"
 	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/msgsend"
 	"github.com/MatrixAINetwork/go-matrix/p2p"
 	"github.com/MatrixAINetwork/go-matrix/params"
 	"github.com/MatrixAINetwork/go-matrix/params/manversion"
@@ -354,6 +356,16 @@ func (p *peer) SendReceiptsRLP(receipts []rlp.RawValue) error {
 	return p2p.Send(p.rw, ReceiptsMsg, receipts)
 }
 
+// SendLightHeaders sends a batch of headers requested by a light client.
+func (p *peer) SendLightHeaders(reqID uint64, headers []*types.Header) error {
+	return p2p.Send(p.rw, LightHeadersMsg, &lightHeadersData{ReqID: reqID, Headers: headers})
+}
+
+// SendStateProof sends the trie nodes proving a light client state query.
+func (p *peer) SendStateProof(reqID uint64, nodes [][]byte) error {
+	return p2p.Send(p.rw, StateProofMsg, &stateProofData{ReqID: reqID, Nodes: nodes})
+}
+
 // SendPongToBroad sends a pong msg to broadcast node to represent alive.
 func (p *peer) SendPongToBroad(data []uint8) error {
 	return p2p.Send(p.rw, common.BroadcastRespMsg, data)
@@ -400,6 +412,18 @@ func (p *peer) RequestReceipts(hashes []common.Hash) error {
 	return p2p.Send(p.rw, GetReceiptsMsg, hashes)
 }
 
+// RequestLightHeaders fetches a batch of continuous headers for header-only sync.
+func (p *peer) RequestLightHeaders(reqID uint64, origin uint64, amount int) error {
+	p.Log().Debug("peer Fetching batch of light headers", "reqID", reqID, "origin", origin, "count", amount)
+	return p2p.Send(p.rw, GetLightHeadersMsg, &getLightHeadersData{ReqID: reqID, Origin: origin, Amount: uint64(amount)})
+}
+
+// RequestStateProof fetches the proof of a state query on the given block.
+func (p *peer) RequestStateProof(reqID uint64, blockHash common.Hash, query *lightsync.ProofQuery) error {
+	p.Log().Debug("peer Fetching state proof", "reqID", reqID, "block", blockHash, "kind", query.Kind)
+	return p2p.Send(p.rw, GetStateProofMsg, &getStateProofData{ReqID: reqID, BlockHash: blockHash, Query: *query})
+}
+
 // Handshake executes the eth protocol handshake, negotiating version number,
 // network IDs, difficulties, head and genesis blocks.
 func (p *peer) Handshake(network uint64, td *big.Int, head common.Hash, sbs uint64, genesis common.Hash, sbh uint64) error {
@@ -475,6 +499,59 @@ func (p *peer) NewHandshake(network uint64, blockTime uint64, head common.Hash,
 	return nil
 }
 
+// CodecHandshake exchanges the supported algorithm message codec versions
+// after the status handshake. Peers below man/64 don't take part and are
+// treated as legacy peers.
+func (p *peer) CodecHandshake() error {
+	if p.version < man64 {
+		return nil
+	}
+	errc := make(chan error, 2)
+	var versions []msgsend.CodecVersion // safe to read after two values have been received from errc
+
+	go func() {
+		errc <- p2p.Send(p.rw, CodecVersionsMsg, msgsend.SupportedVersions())
+	}()
+	go func() {
+		errc <- p.readCodecVersions(&versions)
+	}()
+	timeout := time.NewTimer(handshakeTimeout)
+	defer timeout.Stop()
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-errc:
+			if err != nil {
+				return err
+			}
+		case <-timeout.C:
+			return p2p.DiscReadTimeout
+		}
+	}
+	msgsend.SetPeerCodecs(p.ID(), versions)
+	if incompatible := msgsend.IncompatibleVersions(versions); len(incompatible) > 0 {
+		p.Log().Warn("算法消息版本与对方节点不一致, 这些消息不会发送给该节点", "消息码", incompatible)
+	}
+	return nil
+}
+
+func (p *peer) readCodecVersions(versions *[]msgsend.CodecVersion) error {
+	msg, err := p.rw.ReadMsg()
+	if err != nil {
+		return err
+	}
+	defer msg.Discard()
+	if msg.Code != CodecVersionsMsg {
+		return errResp(ErrNoStatusMsg, "second msg has code %x (!= %x)", msg.Code, CodecVersionsMsg)
+	}
+	if msg.Size > ProtocolMaxMsgSize {
+		return errResp(ErrMsgTooLarge, "%v > %v", msg.Size, ProtocolMaxMsgSize)
+	}
+	if err := msg.Decode(versions); err != nil {
+		return errResp(ErrDecode, "msg %v: %v", msg, err)
+	}
+	return nil
+}
+
 func (p *peer) readStatus(network uint64, status *statusData, genesis common.Hash) (err error) {
 	msg, err := p.rw.ReadMsg()
 	if err != nil {
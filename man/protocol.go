@@ -13,6 +13,7 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/core"
 	"github.com/MatrixAINetwork/go-matrix/core/types"
 	"github.com/MatrixAINetwork/go-matrix/event"
+	"github.com/MatrixAINetwork/go-matrix/man/lightsync"
 	"github.com/MatrixAINetwork/go-matrix/rlp"
 )
 
@@ -20,16 +21,17 @@ import (
 const (
 	man62 = 62
 	man63 = 63
+	man64 = 64
 )
 
 // ProtocolName is the official short name of the protocol used during capability negotiation.
 var ProtocolName = "man"
 
 // ProtocolVersions are the upported versions of the man protocol (first is primary).
-var ProtocolVersions = []uint{man63, man62}
+var ProtocolVersions = []uint{man64, man63, man62}
 
 // ProtocolLengths are the number of implemented message corresponding to different protocol versions.
-var ProtocolLengths = []uint64{21, 8}
+var ProtocolLengths = []uint64{28, 21, 8}
 
 const ProtocolMaxMsgSize = 20 * 1024 * 1024 // Maximum cap on the size of a protocol message
 
@@ -50,8 +52,28 @@ const (
 	NodeDataMsg    = 0x0e
 	GetReceiptsMsg = 0x0f
 	ReceiptsMsg    = 0x10
+
+	// Protocol messages belonging to man/64
+	// 轻节点区块头同步及状态证明，0x11~0x14为common中定义的公共消息
+	GetLightHeadersMsg = 0x15
+	LightHeadersMsg    = 0x16
+	GetStateProofMsg   = 0x17
+	StateProofMsg      = 0x18
+
+	// 状态握手之后交换算法消息的编解码版本
+	CodecVersionsMsg = 0x19
+	// 0x1a~0x1b为common中定义的哨兵模式消息
 )
 
+// isResponseMsg 本节点请求的应答消息，不受p2p速率限制，以免同步请求得不到回复
+func isResponseMsg(code uint64) bool {
+	switch code {
+	case BlockHeadersMsg, BlockBodiesMsg, NodeDataMsg, ReceiptsMsg, LightHeadersMsg, StateProofMsg, common.BroadcastRespMsg:
+		return true
+	}
+	return false
+}
+
 type errCode int
 
 const (
@@ -196,3 +218,28 @@ type blockBody struct {
 
 // blockBodiesData is the network packet for block content distribution.
 type blockBodiesData []*blockBody
+
+// getLightHeadersData 轻节点按高度连续请求区块头
+type getLightHeadersData struct {
+	ReqID  uint64
+	Origin uint64
+	Amount uint64
+}
+
+type lightHeadersData struct {
+	ReqID   uint64
+	Headers []*types.Header
+}
+
+// getStateProofData 轻节点请求指定区块状态上的查询证明
+type getStateProofData struct {
+	ReqID     uint64
+	BlockHash common.Hash
+	Query     lightsync.ProofQuery
+}
+
+// stateProofData 状态证明，全节点无法提供时Nodes为空
+type stateProofData struct {
+	ReqID uint64
+	Nodes [][]byte
+}
@@ -14,13 +14,13 @@ type Center struct {
 }
 
 var (
-	local = newCenter()
+	local = NewCenter()
 
 	SubErrorNoThisEvent  = errors.New("SubscribeEvent Failed No This Event")
 	PostErrorNoThisEvent = errors.New("PostEvent Failed No This Event")
 )
 
-func newCenter() *Center {
+func NewCenter() *Center {
 	msgCenter := &Center{FeedMap: make(map[EventCode]*event.Feed)}
 	msgCenter.init()
 	return msgCenter
@@ -32,19 +32,27 @@ func (c *Center) init() {
 	}
 }
 
-func SubscribeEvent(aim EventCode, ch interface{}) (event.Subscription, error) {
-	feed, ok := local.FeedMap[aim]
+func (c *Center) SubscribeEvent(aim EventCode, ch interface{}) (event.Subscription, error) {
+	feed, ok := c.FeedMap[aim]
 	if !ok {
 		return nil, SubErrorNoThisEvent
 	}
 	return feed.Subscribe(ch), nil
 }
 
-func PublishEvent(aim EventCode, data interface{}) error {
-	feed, ok := local.FeedMap[aim]
+func (c *Center) PublishEvent(aim EventCode, data interface{}) error {
+	feed, ok := c.FeedMap[aim]
 	if !ok {
 		return PostErrorNoThisEvent
 	}
 	go feed.Send(data)
 	return nil
 }
+
+func SubscribeEvent(aim EventCode, ch interface{}) (event.Subscription, error) {
+	return local.SubscribeEvent(aim, ch)
+}
+
+func PublishEvent(aim EventCode, data interface{}) error {
+	return local.PublishEvent(aim, data)
+}
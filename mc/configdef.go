@@ -7,6 +7,7 @@ import (
 	"math/big"
 
 	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/common/hexutil"
 )
 
 const (
@@ -65,6 +66,9 @@ const (
 	MSKeyBLKSelValidator    = "selValidator_blkreward"    // 验证者固定参与奖励名单
 	MSKeyTXSSelValidatorNum = "selValidator_txsrewardnum" // 验证者交易费参与奖励状态
 	MSKeyTXSSelValidator    = "selValidator_txsreward"    // 验证者交易费参与奖励名单
+	MSKeyRewardCfgSchedule  = "reward_cfg_schedule"       // 待生效的奖励配置变更
+	MSKeyCoinRewardCfg      = "coin_reward_cfg"           // 多币种奖励池配置
+	MSKeyCoinRewardPool     = "coin_reward_pool"          // 多币种奖励池发放记录
 
 	//奖励算法配置
 	MSKeyBlkCalc      = "blk_calc"
@@ -84,6 +88,16 @@ const (
 	MSKeyBasePowerSlashCfg    = "base_power_slash_cfg"    //
 	MSKeyBasePowerStats       = "base_power_stats"        //
 	MSKeyBasePowerBlackList   = "base_power_blacklist"    //
+
+	//惩罚证据记录
+	MSKeySlashEvidenceCfg = "slash_evidence_cfg" // 惩罚证据记录配置
+	MSKeySlashEvidence    = "slash_evidence"     // 惩罚证据列表
+	//双签惩罚
+	MSKeyEquivocationSlashCfg = "equivocation_slash_cfg" // 双签惩罚配置
+	MSKeyEquivocationRecords  = "equivocation_records"   // 已提交的双签证据
+	//BLS聚合签名
+	MSKeyBLSAggregateCfg = "bls_aggregate_cfg" // 聚合签名配置
+	MSKeyBLSPublicKeys   = "bls_public_keys"   // 验证者注册的BLS公钥
 	//交易配置
 	MSTxpoolGasLimitCfg = "man_TxpoolGasLimitCfg" //入池gas配置
 	MSCurrencyConfig    = "man_CurrencyConfig"    //币种配置
@@ -245,6 +259,46 @@ type SlashCfg struct {
 	SlashRate uint64
 }
 
+// 奖励配置变更提案，生效高度前一个区块写入对应的奖励配置
+type RewardCfgSchedule struct {
+	Key            string // 奖励配置key
+	ProposeNumber  uint64 // 提案高度
+	ActivateNumber uint64 // 生效高度
+	Data           []byte // 配置rlp编码
+}
+
+type RewardCfgScheduleList struct {
+	ScheduleList []RewardCfgSchedule
+}
+
+// 多币种奖励池配置，奖励从common.CoinRewardPoolAddress账户中对应币种的余额发放
+// 金额单位为币种配置中的CoinUnit
+type CoinRewardCfg struct {
+	CoinType     string
+	BlkRewardCfg BlkRewardCfg
+}
+
+type CoinRewardCfgList struct {
+	CfgList []CoinRewardCfg
+}
+
+// 奖励池单个区块的发放记录
+type CoinRewardPayout struct {
+	Number          uint64
+	MinerAmount     *big.Int
+	ValidatorAmount *big.Int
+}
+
+type CoinRewardPoolInfo struct {
+	CoinType  string
+	TotalPaid *big.Int
+	History   []CoinRewardPayout
+}
+
+type CoinRewardPoolList struct {
+	PoolList []CoinRewardPoolInfo
+}
+
 type SuperBlkCfg struct {
 	Seq uint64
 	Num uint64
@@ -327,6 +381,93 @@ type BasePowerSlashBlackList struct {
 type BasePowerSlashStatsStatus struct {
 	Number uint64
 }
+
+const (
+	SlashTypeBlockProduce = uint8(0) // 未出块惩罚，加入出块黑名单
+	SlashTypeBasePower    = uint8(1) // 算力检测惩罚，加入算力黑名单
+	SlashTypeInterest     = uint8(2) // 在线时长不足，扣除利息
+	SlashTypeEquivocation = uint8(3) // 双签，加入出块黑名单
+)
+
+type SlashEvidenceCfg struct {
+	Switcher   bool
+	MaxRecords uint16 // 最多保留的证据条数，超出后淘汰最早的记录
+}
+
+type SlashPosition struct {
+	Position uint64
+	Amount   *big.Int
+}
+
+// 每条惩罚的依据，由(Type, Address, Number)唯一确定
+type SlashEvidence struct {
+	Type             uint8
+	Address          common.Address
+	Number           uint64          // 惩罚生效高度
+	StatsStartNumber uint64          // 统计起始高度
+	ActualCount      uint64          // 统计周期内的出块数/算力上报数/在线时长
+	ExpectCount      uint64          // 门限值/最大在线时长
+	ProhibitCycleNum uint16          // 黑名单禁止周期
+	SlashRate        uint64          // 利息惩罚比例
+	Positions        []SlashPosition // 各仓位的利息惩罚金额
+	Reversed         bool            // 是否已被超级区块撤销
+	ReverseNumber    uint64          // 撤销高度
+}
+
+type SlashEvidenceList struct {
+	EvidenceList []SlashEvidence
+}
+
+// 超级区块撤销指定惩罚
+type SlashReversal struct {
+	Type    uint8
+	Address common.Address
+	Number  uint64
+}
+
+type EquivocationSlashCfg struct {
+	Switcher         bool
+	ProhibitCycleNum uint16 // 黑名单禁止周期
+	ValidNumber      uint64 // 证据有效期，作恶高度之后超过该区块数的证据不再接受，0表示不限制
+	MaxRecords       uint16 // 最多保留的已处理记录条数，用于证据去重
+}
+
+// 链上保存的双签证据摘要，由(Kind, Account, Number)唯一确定
+type EquivocationRecord struct {
+	Kind         uint8
+	Account      common.Address
+	Number       uint64 // 作恶高度
+	SignHashA    common.Hash
+	SignHashB    common.Hash
+	SubmitNumber uint64 // 证据上链高度
+	SlashNumber  uint64 // 惩罚执行高度，0表示待处理
+}
+
+type EquivocationRecords struct {
+	Pending []EquivocationRecord // 已上链待惩罚
+	History []EquivocationRecord // 已惩罚
+}
+
+// BLSAggregateCfg 开启后，验证者投票附带BLS签名，区块头中的签名列表替换为一个聚合签名
+type BLSAggregateCfg struct {
+	Switcher bool
+}
+
+type BLSPublicKey struct {
+	Account common.Address // 签名账户(A1)
+	PubKey  []byte
+	Number  uint64 // 注册高度
+}
+
+type BLSPublicKeys struct {
+	Keys []BLSPublicKey
+}
+
+// BLSKeyRegistration 注册BLS公钥交易的数据，Proof为私钥对公钥的签名，防止伪造公钥
+type BLSKeyRegistration struct {
+	PubKey hexutil.Bytes `json:"pubKey"`
+	Proof  hexutil.Bytes `json:"proof"`
+}
 type ChainState struct {
 	superSeq  uint64
 	curNumber uint64
@@ -93,5 +93,10 @@ const (
 
 	HD_FullBlkReqToBroadcast
 
+	//msgsend投递层
+	HD_ReliableMsg
+	HD_MsgAck
+	HD_EncryptedDatagram //不是消息，握手中声明支持加密数据报
+
 	LastEventCode
 )
@@ -37,15 +37,16 @@ type HD_MiningReqMsg struct {
 }
 
 type HD_MiningRspMsg struct {
-	From       common.Address
-	Number     uint64
-	BlockHash  common.Hash
-	Difficulty *big.Int
-	Nonce      types.BlockNonce
-	Coinbase   common.Address
-	MixDigest  common.Hash
-	Signatures []common.Signature
-	AIHash     common.Hash `json:"aiHash, omitempty"`
+	From           common.Address
+	Number         uint64
+	BlockHash      common.Hash
+	Difficulty     *big.Int
+	Nonce          types.BlockNonce
+	Coinbase       common.Address
+	MixDigest      common.Hash
+	Signatures     []common.Signature
+	AIHash         common.Hash           `json:"aiHash, omitempty"`
+	AggregateSigns []types.AggregateSign `json:"aggregateSigns,omitempty"`
 }
 
 type BlockGenor_BroadcastMiningReqMsg struct {
@@ -246,6 +247,28 @@ type HD_ConsensusVote struct {
 	Number   uint64
 	Sign     common.Signature
 	From     common.Address
+	BLSSign  []byte `json:",omitempty"` // 开启聚合签名时附带的BLS签名
+}
+
+const (
+	EquivocationKindBlock  = uint8(0) // 同一高度、同一leader的两个不同区块头都投了赞成票
+	EquivocationKindPOSReq = uint8(1) // leader在同一高度对两个不同区块头签名，即发出了冲突的POS请求
+	EquivocationKindOnline = uint8(2) // 同一高度、同一轮次对同一节点相反的在线状态都投了赞成票
+)
+
+// 双签证据，两个签名由同一签名账户对冲突的内容签出
+type EquivocationEvidence struct {
+	Kind          uint8
+	Number        uint64
+	ConsensusTurn uint32              // 共识轮次，仅作参考，签名内容不包含轮次
+	Account       common.Address      // 作恶者抵押账户
+	Signer        common.Address      // 签名账户
+	HeaderA       *types.Header       `rlp:"nil"`
+	HeaderB       *types.Header       `rlp:"nil"`
+	OnlineA       *OnlineConsensusReq `rlp:"nil"`
+	OnlineB       *OnlineConsensusReq `rlp:"nil"`
+	SignA         common.Signature
+	SignB         common.Signature
 }
 
 type HD_OnlineConsensusVotes struct {
@@ -259,6 +282,34 @@ type HD_OnlineConsensusVoteResultMsg struct {
 	From     common.Address
 }
 
+const (
+	OnlineAuditResultPending = uint8(0) // 未形成共识结果
+	OnlineAuditResultPassed  = uint8(1) // 共识结果POS验证通过
+	OnlineAuditResultFailed  = uint8(2) // 投票未通过POS验证
+)
+
+// 在线共识的一张投票
+type OnlineAuditVote struct {
+	Signer common.Address
+	Agree  bool
+}
+
+// 在线共识审计记录，记录一次在线状态共识请求的投票过程和结果
+type OnlineConsensusAudit struct {
+	ReqHash     common.Hash
+	Number      uint64
+	LeaderTurn  uint32
+	Leader      common.Address
+	Node        common.Address
+	OnlineState OnlineState
+	LocalVote   uint8  // 0:未投票 1:赞成 2:反对
+	LocalReason string // 本节点投票依据
+	Votes       []OnlineAuditVote
+	Result      uint8
+	Reason      string           // 共识结果说明
+	Signers     []common.Address // 通过POS验证的签名账户
+}
+
 //特殊交易
 type BroadCastEvent struct {
 	Txtyps string
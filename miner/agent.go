@@ -91,7 +91,7 @@ out:
 func (self *CpuAgent) mine(work *Work, stop <-chan struct{}) {
 	switch work.mineType {
 	case mineTaskTypePow:
-		if manversion.VersionCmp(string(work.header.Version), manversion.VersionAIMine) >= 0 {
+		if manversion.IsEnabled(manversion.FeatureAIMine, string(work.header.Version)) {
 			self.chain.Engine(work.header.Version).SealPow(self.chain, work.header, stop, self.returnCh, work.isBroadcastNode)
 		} else {
 			if result, err := self.chain.Engine(work.header.Version).SealPow(self.chain, work.header, stop, self.returnCh, work.isBroadcastNode); result != nil {
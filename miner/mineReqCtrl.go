@@ -234,6 +234,7 @@ func (ctrl *mineReqCtrl) SetMiningResult(result *types.Header) (*mineReqData, er
 		req.header.Coinbase = result.Coinbase
 		req.header.MixDigest = result.MixDigest
 		req.header.Signatures = result.Signatures
+		req.header.AggregateSigns = result.AggregateSigns
 		req.header.AIHash = result.AIHash
 	}
 
@@ -241,7 +241,7 @@ func (self *worker) update() {
 				continue
 			}
 
-			if manversion.VersionCmp(self.curVersion, manversion.VersionAIMine) >= 0 {
+			if manversion.IsEnabled(manversion.FeatureAIMine, self.curVersion) {
 				self.handlerV2.foundHandle(minedResult)
 			} else {
 				self.foundHandle(minedResult)
@@ -290,14 +290,14 @@ func (self *worker) RoleUpdatedMsgHandler(data *mc.RoleUpdatedMsg) {
 		self.curVersion = data.Version
 	}
 
-	if manversion.VersionCmp(self.curVersion, manversion.VersionDelta) == 0 && data.BlockNum+1 == manversion.VersionNumAIMine {
+	if aiMine, ok := manversion.FeatureFork(manversion.FeatureAIMine); ok && self.curVersion == aiMine.Parent && data.BlockNum+1 == aiMine.Number {
 		// 版本切换零界点，使用新版本挖矿
 		log.Trace(ModuleMiner, "CA身份消息处理", "版本切换零界点，使用新版本挖矿", "msg version", data.Version, "msg number", data.BlockNum)
 		self.stopMineResultSender()
-		self.curVersion = manversion.VersionAIMine
+		self.curVersion = aiMine.Version
 	}
 
-	if manversion.VersionCmp(self.curVersion, manversion.VersionAIMine) >= 0 {
+	if manversion.IsEnabled(manversion.FeatureAIMine, self.curVersion) {
 		// 新版本号，使用v2版处理流程
 		self.handlerV2.RoleUpdatedMsgHandler(data)
 		return
@@ -453,7 +453,7 @@ func (self *worker) addAgent(agent Agent) {
 	self.agents[agent] = struct{}{}
 	agent.SetReturnCh(self.recv)
 
-	if manversion.VersionCmp(self.curVersion, manversion.VersionAIMine) >= 0 {
+	if manversion.IsEnabled(manversion.FeatureAIMine, self.curVersion) {
 		if isNilTask(self.handlerV2.curMineTask) {
 			return
 		}
@@ -501,7 +501,7 @@ func (self *worker) makeCurrent(header *types.Header, isBroadcastNode bool) erro
 }
 
 func (self *worker) CommitNewWork(header *types.Header, isBroadcastNode bool) {
-	if manversion.VersionCmp(self.curVersion, manversion.VersionAIMine) >= 0 {
+	if manversion.IsEnabled(manversion.FeatureAIMine, self.curVersion) {
 		log.Error(ModuleMiner, "调用CommitNewWork版本错误", self.curVersion)
 		return
 	}
@@ -544,7 +544,7 @@ func (self *worker) makeCurrentV2(task mineTask, version []byte) error {
 }
 
 func (self *worker) CommitNewWorkV2(task mineTask) {
-	if manversion.VersionCmp(self.curVersion, manversion.VersionAIMine) < 0 {
+	if !manversion.IsEnabled(manversion.FeatureAIMine, self.curVersion) {
 		log.Error(ModuleMiner, "调用CommitNewWorkV2版本错误", self.curVersion)
 		return
 	}
@@ -659,13 +659,14 @@ func (self *worker) sendMineResultFunc(data interface{}, times uint32) {
 		self.hd.SendNodeMsg(mc.HD_BroadcastMiningRsp, rsp, common.RoleValidator, nil)
 	} else {
 		rsp := &mc.HD_MiningRspMsg{
-			BlockHash:  resultData.headerHash,
-			Difficulty: resultData.mineDiff,
-			Number:     resultData.header.Number.Uint64(),
-			Nonce:      resultData.header.Nonce,
-			Coinbase:   resultData.header.Coinbase,
-			MixDigest:  resultData.header.MixDigest,
-			Signatures: resultData.header.Signatures}
+			BlockHash:      resultData.headerHash,
+			Difficulty:     resultData.mineDiff,
+			Number:         resultData.header.Number.Uint64(),
+			Nonce:          resultData.header.Nonce,
+			Coinbase:       resultData.header.Coinbase,
+			MixDigest:      resultData.header.MixDigest,
+			Signatures:     resultData.header.Signatures,
+			AggregateSigns: resultData.header.AggregateSigns}
 
 		self.hd.SendNodeMsg(mc.HD_MiningRsp, rsp, common.RoleValidator|common.RoleBroadcast, nil)
 		log.Trace(ModuleMiner, "挖矿结果", "发送", "hash", rsp.BlockHash.TerminalString(), "次数", times, "高度", rsp.Number, "Nonce", rsp.Nonce)
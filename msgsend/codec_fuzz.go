@@ -0,0 +1,13 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+// +build gofuzz
+
+package msgsend
+
+// Fuzz is the entry point for the go-fuzz tool, it fuzzes every registered
+// algorithm message codec.
+func Fuzz(data []byte) int {
+	return fuzzCodecs(data)
+}
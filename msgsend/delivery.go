@@ -0,0 +1,381 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package msgsend
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/MatrixAINetwork/go-matrix/ca"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/metrics"
+	"github.com/MatrixAINetwork/go-matrix/p2p"
+	"github.com/MatrixAINetwork/go-matrix/rlp"
+	"github.com/hashicorp/golang-lru"
+)
+
+const (
+	peerQueueSize        = 256 // 每个目标节点的发送队列长度，队列满时丢弃新消息
+	receivedCacheSize    = 4096
+	defaultRetryInterval = time.Second
+	defaultMaxInterval   = 16 * time.Second
+	peerIdleTimeout      = time.Minute // 目标节点的发送协程空闲超时后退出，队列一并回收
+)
+
+var (
+	queueDepthGauge  = metrics.NewRegisteredGauge("msgsend/queue/depth", nil)
+	queueDropMeter   = metrics.NewRegisteredMeter("msgsend/queue/drop", nil)
+	sendFailMeter    = metrics.NewRegisteredMeter("msgsend/send/fail", nil)
+	retryMeter       = metrics.NewRegisteredMeter("msgsend/retry", nil)
+	retryDropMeter   = metrics.NewRegisteredMeter("msgsend/undelivered", nil)
+	ackMeter         = metrics.NewRegisteredMeter("msgsend/ack", nil)
+	duplicationMeter = metrics.NewRegisteredMeter("msgsend/duplicate", nil)
+	datagramMeter    = metrics.NewRegisteredMeter("msgsend/datagram", nil)
+)
+
+// DeliveryPolicy 消息的投递策略，按消息码配置。
+// Ack为true时消息封装为HD_ReliableMsg发送，接收方回复HD_MsgAck并按序号去重，
+// 未收到确认或发送失败时按退避间隔重发，最多重发MaxRetry次。
+// 握手时未声明支持HD_ReliableMsg的节点无法确认，改为按退避间隔固定重发MaxRetry次。
+// Datagram为true时消息经加密数据报通道(p2p.Udp)发送，消息过大或发送失败时改用RLPx连接
+type DeliveryPolicy struct {
+	Ack           bool
+	Datagram      bool
+	MaxRetry      int
+	RetryInterval time.Duration // 首次重发间隔，之后每次加倍
+	MaxInterval   time.Duration // 重发间隔上限
+}
+
+func (p DeliveryPolicy) backoff(tries int) time.Duration {
+	interval, max := p.RetryInterval, p.MaxInterval
+	if interval <= 0 {
+		interval = defaultRetryInterval
+	}
+	if max <= 0 {
+		max = defaultMaxInterval
+	}
+	for i := 0; i < tries && interval < max; i++ {
+		interval *= 2
+	}
+	if interval > max {
+		interval = max
+	}
+	return interval
+}
+
+// 默认策略：点对点的投票和应答消息发送失败(如连接尚未建立)时重发，不要求确认。
+// 需要确认的消息由业务模块通过HD.SetDeliveryPolicy设置
+var defaultPolicies = map[mc.EventCode]DeliveryPolicy{
+	mc.HD_TopNodeConsensusVote:         {MaxRetry: 3},
+	mc.HD_LeaderReelectInquiryRsp:      {MaxRetry: 3},
+	mc.HD_LeaderReelectVote:            {MaxRetry: 3},
+	mc.HD_LeaderReelectBroadcastRsp:    {MaxRetry: 3},
+	mc.HD_V2_LeaderReelectInquiryRsp:   {MaxRetry: 3},
+	mc.HD_V2_LeaderReelectVote:         {MaxRetry: 3},
+	mc.HD_V2_LeaderReelectBroadcastRsp: {MaxRetry: 3},
+	mc.HD_FullBlockRsp:                 {MaxRetry: 3},
+	mc.HD_V2_FullBlockRsp:              {MaxRetry: 3},
+}
+
+// reliableData HD_ReliableMsg的内容
+type reliableData struct {
+	Seq     uint64
+	SubCode uint32
+	Msg     []byte
+}
+
+// ackData HD_MsgAck的内容
+type ackData struct {
+	Seq uint64
+}
+
+// transport 投递层的底层发送接口，HD使用p2p
+type transport interface {
+	SendToSingle(addr common.Address, data NetData) error
+	SendDatagram(addr common.Address, data NetData) error
+	GroupMembers(roles common.RoleType) []common.Address
+	Self() common.Address
+	PeerCodecs(addr common.Address) (map[mc.EventCode]uint32, bool)
+}
+
+type p2pTransport struct{}
+
+func (p2pTransport) SendToSingle(addr common.Address, data NetData) error {
+	return p2p.SendToSingle(addr, common.AlgorithmMsg, data)
+}
+
+func (p2pTransport) SendDatagram(addr common.Address, data NetData) error {
+	payload, err := rlp.EncodeToBytes(&data)
+	if err != nil {
+		return err
+	}
+	return p2p.SendDatagram(addr, p2p.DatagramAlgorithmMsg, payload)
+}
+
+func (p2pTransport) GroupMembers(roles common.RoleType) []common.Address {
+	return ca.GetRolesByGroup(roles)
+}
+
+func (p2pTransport) Self() common.Address {
+	return p2p.ServerP2p.ManAddress
+}
+
+func (p2pTransport) PeerCodecs(addr common.Address) (map[mc.EventCode]uint32, bool) {
+	return getPeerCodecs(p2p.ServerP2p.ConvertAddressToId(addr))
+}
+
+// SendHandle 一次发送的句柄，Cancel后停止该消息尚未完成的重发
+type SendHandle struct {
+	cancelled int32
+}
+
+func (h *SendHandle) Cancel() {
+	atomic.StoreInt32(&h.cancelled, 1)
+}
+
+func (h *SendHandle) isCancelled() bool {
+	return h != nil && atomic.LoadInt32(&h.cancelled) == 1
+}
+
+type outMsg struct {
+	to      common.Address
+	subCode mc.EventCode
+	data    NetData
+	seq     uint64
+	policy  DeliveryPolicy
+	resend  bool // 对方不支持确认，发送成功后仍按策略重发
+	handle  *SendHandle
+	tries   int
+	timer   *time.Timer
+}
+
+type receivedKey struct {
+	from common.Address
+	seq  uint64
+}
+
+// delivery 算法消息投递层：每个目标节点一个有界队列，按策略确认、重发，接收时去重
+type delivery struct {
+	tp          transport
+	mu          sync.Mutex
+	policies    map[mc.EventCode]DeliveryPolicy
+	queues      map[common.Address]chan *outMsg
+	pending     map[uint64]*outMsg // 等待确认的消息
+	received    *lru.Cache
+	seq         uint64
+	depth       int64
+	idleTimeout time.Duration
+}
+
+func newDelivery(tp transport) *delivery {
+	received, _ := lru.New(receivedCacheSize)
+	d := &delivery{
+		tp:          tp,
+		policies:    make(map[mc.EventCode]DeliveryPolicy),
+		queues:      make(map[common.Address]chan *outMsg),
+		pending:     make(map[uint64]*outMsg),
+		received:    received,
+		idleTimeout: peerIdleTimeout,
+		// 序号从启动时间开始，避免重启后被对方当作重复消息丢弃
+		seq: uint64(time.Now().UnixNano()),
+	}
+	for subCode, policy := range defaultPolicies {
+		d.policies[subCode] = policy
+	}
+	return d
+}
+
+func (d *delivery) setPolicy(subCode mc.EventCode, policy DeliveryPolicy) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.policies[subCode] = policy
+}
+
+func (d *delivery) policy(subCode mc.EventCode) DeliveryPolicy {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.policies[subCode]
+}
+
+// send 将消息放入目标节点的发送队列，handle可为nil
+func (d *delivery) send(subCode mc.EventCode, data NetData, to common.Address, handle *SendHandle) {
+	if to == d.tp.Self() {
+		return
+	}
+	msg := &outMsg{to: to, subCode: subCode, data: data, policy: d.policy(subCode), handle: handle}
+	if msg.policy.Ack {
+		// 对方不支持投递层时不要求确认，改为固定重发
+		compatible, negotiated := peerCompatible(d.tp, to, mc.HD_ReliableMsg)
+		msg.policy.Ack = compatible && negotiated
+		msg.resend = !msg.policy.Ack
+	}
+	if msg.policy.Ack {
+		msg.seq = atomic.AddUint64(&d.seq, 1)
+		payload, err := rlp.EncodeToBytes(&reliableData{Seq: msg.seq, SubCode: data.SubCode, Msg: data.Msg})
+		if err != nil {
+			log.Error("HD", "封装可靠消息失败", err, "subCode", subCode)
+			return
+		}
+		msg.data = NetData{SubCode: uint32(mc.HD_ReliableMsg), Msg: payload}
+	}
+	d.enqueue(msg)
+}
+
+// enqueue 入队在锁内完成，发送协程回收队列时不会遗漏消息
+func (d *delivery) enqueue(msg *outMsg) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	queue, exist := d.queues[msg.to]
+	if !exist {
+		queue = make(chan *outMsg, peerQueueSize)
+		d.queues[msg.to] = queue
+		go d.sendLoop(msg.to, queue)
+	}
+
+	select {
+	case queue <- msg:
+		queueDepthGauge.Update(atomic.AddInt64(&d.depth, 1))
+	default:
+		queueDropMeter.Mark(1)
+		log.Warn("HD", "发送队列已满, 丢弃消息", msg.subCode, "to", msg.to.Hex(), "seq", msg.seq)
+	}
+}
+
+// sendLoop 依次发送同一目标节点的消息，发送阻塞时消息在队列中积压。
+// 空闲超时后退出并删除队列，目标节点离线后不再占用协程
+func (d *delivery) sendLoop(to common.Address, queue chan *outMsg) {
+	idle := time.NewTimer(d.idleTimeout)
+	defer idle.Stop()
+	for {
+		select {
+		case msg := <-queue:
+			queueDepthGauge.Update(atomic.AddInt64(&d.depth, -1))
+			d.process(msg)
+			if !idle.Stop() {
+				<-idle.C
+			}
+			idle.Reset(d.idleTimeout)
+
+		case <-idle.C:
+			d.mu.Lock()
+			if len(queue) == 0 {
+				delete(d.queues, to)
+				d.mu.Unlock()
+				log.Trace("HD", "发送协程空闲, 回收队列", to.Hex())
+				return
+			}
+			d.mu.Unlock()
+			idle.Reset(d.idleTimeout)
+		}
+	}
+}
+
+func (d *delivery) process(msg *outMsg) {
+	if msg.handle.isCancelled() {
+		return
+	}
+	if msg.policy.Ack {
+		d.waitAck(msg)
+	}
+	err := d.sendData(msg)
+	if err != nil {
+		sendFailMeter.Mark(1)
+		log.Debug("HD", "发送消息失败", msg.subCode, "to", msg.to.Hex(), "次数", msg.tries+1, "err", err)
+	}
+	if !msg.policy.Ack && (err != nil || msg.resend) {
+		d.retry(msg, msg.policy.backoff(msg.tries))
+	}
+}
+
+func (d *delivery) sendData(msg *outMsg) error {
+	if msg.policy.Datagram {
+		err := d.tp.SendDatagram(msg.to, msg.data)
+		if err == nil {
+			datagramMeter.Mark(1)
+			return nil
+		}
+		log.Debug("HD", "数据报发送失败, 改用连接发送", msg.subCode, "to", msg.to.Hex(), "err", err)
+	}
+	return d.tp.SendToSingle(msg.to, msg.data)
+}
+
+// waitAck 登记等待确认的消息，超时后重发
+func (d *delivery) waitAck(msg *outMsg) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.pending[msg.seq] = msg
+	msg.timer = time.AfterFunc(msg.policy.backoff(msg.tries), func() {
+		d.mu.Lock()
+		_, exist := d.pending[msg.seq]
+		delete(d.pending, msg.seq)
+		d.mu.Unlock()
+		if exist {
+			d.retry(msg, 0)
+		}
+	})
+}
+
+// retry 在delay后重发消息，重发次数已达上限时丢弃
+func (d *delivery) retry(msg *outMsg, delay time.Duration) {
+	if msg.handle.isCancelled() {
+		return
+	}
+	if msg.tries >= msg.policy.MaxRetry {
+		if msg.resend {
+			return
+		}
+		retryDropMeter.Mark(1)
+		log.Warn("HD", "消息未能送达, 丢弃", msg.subCode, "to", msg.to.Hex(), "重发次数", msg.tries, "seq", msg.seq)
+		return
+	}
+	msg.tries++
+	retryMeter.Mark(1)
+	if delay == 0 {
+		d.enqueue(msg)
+		return
+	}
+	time.AfterFunc(delay, func() { d.enqueue(msg) })
+}
+
+// handleAck 处理确认消息，停止对应消息的重发
+func (d *delivery) handleAck(from common.Address, payload []byte) {
+	var ack ackData
+	if err := rlp.DecodeBytes(payload, &ack); err != nil {
+		log.Error("HD", "解析确认消息失败", err, "from", from.Hex())
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	msg, exist := d.pending[ack.Seq]
+	if !exist || msg.to != from {
+		return
+	}
+	msg.timer.Stop()
+	delete(d.pending, ack.Seq)
+	ackMeter.Mark(1)
+}
+
+// handleReliable 回复确认并解出原消息，重复收到的消息返回false
+func (d *delivery) handleReliable(from common.Address, payload []byte) (NetData, bool) {
+	var data reliableData
+	if err := rlp.DecodeBytes(payload, &data); err != nil {
+		log.Error("HD", "解析可靠消息失败", err, "from", from.Hex())
+		return NetData{}, false
+	}
+	// 重复的消息也要回复确认，上次的确认可能已丢失
+	ack, err := rlp.EncodeToBytes(&ackData{Seq: data.Seq})
+	if err == nil {
+		d.enqueue(&outMsg{to: from, subCode: mc.HD_MsgAck, data: NetData{SubCode: uint32(mc.HD_MsgAck), Msg: ack}})
+	}
+	if exist, _ := d.received.ContainsOrAdd(receivedKey{from: from, seq: data.Seq}, struct{}{}); exist {
+		// 重复的可靠消息是对端因确认丢失而重传，不计入评分
+		duplicationMeter.Mark(1)
+		log.Trace("HD", "丢弃重复消息", data.SubCode, "from", from.Hex(), "seq", data.Seq)
+		return NetData{}, false
+	}
+	return NetData{SubCode: data.SubCode, Msg: data.Msg}, true
+}
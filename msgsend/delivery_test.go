@@ -0,0 +1,221 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package msgsend
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+)
+
+var (
+	testSelf = common.HexToAddress("0x01")
+	testPeer = common.HexToAddress("0x02")
+)
+
+type testTransport struct {
+	mu     sync.Mutex
+	sent   []NetData
+	fails  int                     // 前fails次发送失败
+	codecs map[mc.EventCode]uint32 // 对方节点协商的消息版本，nil表示未协商
+	udp    bool                    // 数据报通道可用
+	grams  []NetData
+}
+
+func (tp *testTransport) SendToSingle(addr common.Address, data NetData) error {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	tp.sent = append(tp.sent, data)
+	if tp.fails > 0 {
+		tp.fails--
+		return errors.New("peer not connected")
+	}
+	return nil
+}
+
+func (tp *testTransport) SendDatagram(addr common.Address, data NetData) error {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	if !tp.udp {
+		return errors.New("datagram not available")
+	}
+	tp.grams = append(tp.grams, data)
+	return nil
+}
+
+func (tp *testTransport) GroupMembers(roles common.RoleType) []common.Address {
+	return []common.Address{testSelf, testPeer}
+}
+
+func (tp *testTransport) Self() common.Address { return testSelf }
+
+func (tp *testTransport) PeerCodecs(addr common.Address) (map[mc.EventCode]uint32, bool) {
+	return tp.codecs, tp.codecs != nil
+}
+
+func (tp *testTransport) count() int {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	return len(tp.sent)
+}
+
+func (tp *testTransport) last() NetData {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	return tp.sent[len(tp.sent)-1]
+}
+
+func waitCount(t *testing.T, tp *testTransport, want int) {
+	for i := 0; i < 200 && tp.count() < want; i++ {
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if got := tp.count(); got != want {
+		t.Fatalf("sent %d messages, want %d", got, want)
+	}
+}
+
+func TestDeliveryRetryOnSendFailure(t *testing.T) {
+	tp := &testTransport{fails: 2}
+	d := newDelivery(tp)
+	d.setPolicy(mc.HD_MiningReq, DeliveryPolicy{MaxRetry: 3, RetryInterval: time.Millisecond})
+
+	d.send(mc.HD_MiningReq, NetData{SubCode: uint32(mc.HD_MiningReq)}, testSelf, nil)
+	d.send(mc.HD_MiningReq, NetData{SubCode: uint32(mc.HD_MiningReq)}, testPeer, nil)
+	// 两次失败后第三次发送成功，不再重发
+	waitCount(t, tp, 3)
+}
+
+func TestDeliveryAck(t *testing.T) {
+	sender, receiver := &testTransport{codecs: localVersions}, &testTransport{}
+	d, remote := newDelivery(sender), newDelivery(receiver)
+	d.setPolicy(mc.HD_MiningReq, DeliveryPolicy{Ack: true, MaxRetry: 5, RetryInterval: 50 * time.Millisecond})
+
+	d.send(mc.HD_MiningReq, NetData{SubCode: uint32(mc.HD_MiningReq), Msg: []byte("req")}, testPeer, nil)
+	waitCount(t, sender, 1)
+	wrapped := sender.last()
+	if mc.EventCode(wrapped.SubCode) != mc.HD_ReliableMsg {
+		t.Fatalf("subCode = %d, want HD_ReliableMsg", wrapped.SubCode)
+	}
+
+	// 未确认时重发，接收方对重复消息只交付一次但都回复确认
+	waitCount(t, sender, 2)
+	data, ok := remote.handleReliable(testSelf, wrapped.Msg)
+	if !ok || mc.EventCode(data.SubCode) != mc.HD_MiningReq || string(data.Msg) != "req" {
+		t.Fatalf("unwrapped = %v, %v", data, ok)
+	}
+	if _, ok := remote.handleReliable(testSelf, sender.last().Msg); ok {
+		t.Fatal("duplicate message delivered")
+	}
+	waitCount(t, receiver, 2)
+
+	// 其他节点的确认无效
+	d.handleAck(testSelf, receiver.last().Msg)
+	waitCount(t, sender, 3)
+	d.handleAck(testPeer, receiver.last().Msg)
+	time.Sleep(300 * time.Millisecond)
+	if sender.count() != 3 {
+		t.Fatalf("retried after ack, sent %d", sender.count())
+	}
+}
+
+func TestDeliveryDatagram(t *testing.T) {
+	tp := &testTransport{udp: true}
+	d := newDelivery(tp)
+	d.setPolicy(mc.HD_MiningReq, DeliveryPolicy{Datagram: true})
+	d.send(mc.HD_MiningReq, NetData{SubCode: uint32(mc.HD_MiningReq)}, testPeer, nil)
+	time.Sleep(30 * time.Millisecond)
+	tp.mu.Lock()
+	grams := len(tp.grams)
+	tp.mu.Unlock()
+	if grams != 1 || tp.count() != 0 {
+		t.Fatalf("datagrams %d, rlpx %d", grams, tp.count())
+	}
+
+	// 数据报不可用时改用连接发送
+	tp.mu.Lock()
+	tp.udp = false
+	tp.mu.Unlock()
+	d.send(mc.HD_MiningReq, NetData{SubCode: uint32(mc.HD_MiningReq)}, testPeer, nil)
+	waitCount(t, tp, 1)
+}
+
+func TestDeliveryQueueFull(t *testing.T) {
+	block := make(chan struct{})
+	tp := &blockingTransport{testTransport: &testTransport{}, block: block}
+	d := newDelivery(tp)
+	// 第一条消息被发送协程取出阻塞在发送中，其余排队，超出队列长度的丢弃
+	d.send(mc.HD_MiningReq, NetData{}, testPeer, nil)
+	time.Sleep(20 * time.Millisecond)
+	for i := 0; i < peerQueueSize+10; i++ {
+		d.send(mc.HD_MiningReq, NetData{}, testPeer, nil)
+	}
+	if len(d.queues[testPeer]) != peerQueueSize {
+		t.Fatalf("queue length = %d, want %d", len(d.queues[testPeer]), peerQueueSize)
+	}
+	close(block)
+	waitCount(t, tp.testTransport, peerQueueSize+1)
+}
+
+type blockingTransport struct {
+	*testTransport
+	block chan struct{}
+}
+
+func (tp *blockingTransport) SendToSingle(addr common.Address, data NetData) error {
+	<-tp.block
+	return tp.testTransport.SendToSingle(addr, data)
+}
+
+func TestDeliveryResendLegacyPeer(t *testing.T) {
+	tp := &testTransport{}
+	d := newDelivery(tp)
+	d.setPolicy(mc.HD_MiningReq, DeliveryPolicy{Ack: true, MaxRetry: 2, RetryInterval: time.Millisecond})
+
+	// 旧节点无法确认，发送成功后仍固定重发MaxRetry次
+	d.send(mc.HD_MiningReq, NetData{SubCode: uint32(mc.HD_MiningReq)}, testPeer, nil)
+	waitCount(t, tp, 3)
+	if mc.EventCode(tp.last().SubCode) != mc.HD_MiningReq {
+		t.Fatalf("legacy peer got subCode %d", tp.last().SubCode)
+	}
+}
+
+func TestDeliveryCancel(t *testing.T) {
+	tp := &testTransport{fails: 100}
+	d := newDelivery(tp)
+	d.setPolicy(mc.HD_MiningReq, DeliveryPolicy{MaxRetry: 10, RetryInterval: 100 * time.Millisecond, MaxInterval: 100 * time.Millisecond})
+
+	handle := new(SendHandle)
+	d.send(mc.HD_MiningReq, NetData{SubCode: uint32(mc.HD_MiningReq)}, testPeer, handle)
+	waitCount(t, tp, 1)
+	handle.Cancel()
+	time.Sleep(300 * time.Millisecond)
+	if tp.count() != 1 {
+		t.Fatalf("retried after cancel, sent %d", tp.count())
+	}
+}
+
+func TestDeliveryIdleReclaim(t *testing.T) {
+	tp := &testTransport{}
+	d := newDelivery(tp)
+	d.idleTimeout = 20 * time.Millisecond
+
+	d.send(mc.HD_MiningReq, NetData{SubCode: uint32(mc.HD_MiningReq)}, testPeer, nil)
+	waitCount(t, tp, 1)
+	time.Sleep(50 * time.Millisecond)
+	d.mu.Lock()
+	_, exist := d.queues[testPeer]
+	d.mu.Unlock()
+	if exist {
+		t.Fatal("idle queue not reclaimed")
+	}
+
+	// 回收后再次发送时重建队列
+	d.send(mc.HD_MiningReq, NetData{SubCode: uint32(mc.HD_MiningReq)}, testPeer, nil)
+	waitCount(t, tp, 2)
+}
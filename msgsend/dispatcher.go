@@ -9,19 +9,54 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/log"
 	"github.com/MatrixAINetwork/go-matrix/mc"
 	"github.com/MatrixAINetwork/go-matrix/p2p"
+	"github.com/MatrixAINetwork/go-matrix/p2p/discover"
+	"github.com/MatrixAINetwork/go-matrix/rlp"
 	"github.com/pkg/errors"
 )
 
+// Codec 算法消息编解码表，不订阅网络消息
+type Codec struct {
+	codecMap map[mc.EventCode]*codecSpec
+}
+
+func NewCodec() *Codec {
+	codec := &Codec{codecMap: make(map[mc.EventCode]*codecSpec)}
+	codec.initCodec()
+	return codec
+}
+
+func (self *Codec) Encode(subCode mc.EventCode, msg interface{}) (NetData, error) {
+	codec, err := self.findCodec(subCode)
+	if err != nil {
+		return NetData{}, err
+	}
+	data, err := codec.encode(msg)
+	if err != nil {
+		return NetData{}, err
+	}
+	return NetData{SubCode: uint32(subCode), Msg: data}, nil
+}
+
+func (self *Codec) Decode(data NetData, from common.Address) (interface{}, error) {
+	codec, err := self.findCodec(mc.EventCode(data.SubCode))
+	if err != nil {
+		return nil, err
+	}
+	return codec.decode(data.Msg, from)
+}
+
 type HD struct {
+	Codec
+	delivery *delivery
 	dataChan chan *AlgorithmMsg
 	dataSub  event.Subscription
-	codecMap map[mc.EventCode]MsgCodec
 }
 
 func NewHD() (*HD, error) {
 	hd := &HD{
+		Codec:    Codec{codecMap: make(map[mc.EventCode]*codecSpec)},
+		delivery: newDelivery(p2pTransport{}),
 		dataChan: make(chan *AlgorithmMsg, 10),
-		codecMap: make(map[mc.EventCode]MsgCodec),
 	}
 	//订阅网络消息
 	var err error
@@ -31,6 +66,8 @@ func NewHD() (*HD, error) {
 	}
 	//初始化编解码器
 	hd.initCodec()
+	//接收数据报通道的算法消息
+	p2p.Udp.Handle(p2p.DatagramAlgorithmMsg, hd.receiveDatagram)
 	//run
 	go hd.receive()
 
@@ -38,13 +75,24 @@ func NewHD() (*HD, error) {
 }
 
 func (self *HD) SendNodeMsg(subCode mc.EventCode, msg interface{}, Roles common.RoleType, nodes []common.Address) {
+	self.sendNodeMsg(subCode, msg, Roles, nodes, nil)
+}
+
+// SendNodeMsgWithHandle 按投递策略发送消息，返回的句柄用于停止尚未完成的重发
+func (self *HD) SendNodeMsgWithHandle(subCode mc.EventCode, msg interface{}, Roles common.RoleType, nodes []common.Address) *SendHandle {
+	handle := new(SendHandle)
+	self.sendNodeMsg(subCode, msg, Roles, nodes, handle)
+	return handle
+}
+
+func (self *HD) sendNodeMsg(subCode mc.EventCode, msg interface{}, Roles common.RoleType, nodes []common.Address, handle *SendHandle) {
 	codec, err := self.findCodec(subCode)
 	if err != nil {
 		log.Error("HD", "send findCodec err", err)
 		return
 	}
 
-	data, err := codec.EncodeFn(msg)
+	data, err := codec.encode(msg)
 	if err != nil {
 		log.Error("HD", "EncodeFn err", err, "subCode", subCode)
 		return
@@ -56,24 +104,39 @@ func (self *HD) SendNodeMsg(subCode mc.EventCode, msg interface{}, Roles common.
 	}
 
 	if nodes == nil {
-		log.Info("SendToGroup", "roles", Roles.String(), "SubCode", subCode)
-		go p2p.SendToGroup(Roles, common.AlgorithmMsg, sendData)
+		nodes = self.delivery.tp.GroupMembers(Roles)
+		log.Info("SendToGroup", "roles", Roles.String(), "SubCode", subCode, "total address count", len(nodes))
 	} else {
 		log.Info("SendToSignal", "total address count", len(nodes), "SubCode", subCode)
-		for _, addr := range nodes {
-			if err != nil {
-				log.Error("SendToSignal", "ConvertAddressToNodeId err", err, "address", addr.Hex())
-				continue
-			}
-			log.Info("SendToSignal", "address", addr.Hex())
-			go func() {
-				err := p2p.SendToSingle(addr, common.AlgorithmMsg, sendData)
-				if err != nil {
-					log.Error("SendToSignal", "address", addr.Hex(), "err", err)
-				}
-			}()
+	}
+	for _, addr := range nodes {
+		if compatible, _ := peerCompatible(self.delivery.tp, addr, subCode); !compatible {
+			logIncompatible(subCode, addr)
+			continue
 		}
+		self.delivery.send(subCode, sendData, addr, handle)
+	}
+}
+
+// SetDeliveryPolicy 设置消息码的投递策略
+func (self *HD) SetDeliveryPolicy(subCode mc.EventCode, policy DeliveryPolicy) {
+	self.delivery.setPolicy(subCode, policy)
+}
+
+// receiveDatagram 数据报中的消息与RLPx收到的消息一样交给receive处理
+func (self *HD) receiveDatagram(from discover.NodeID, payload []byte) {
+	var data NetData
+	if err := rlp.DecodeBytes(payload, &data); err != nil {
+		log.Warn("HD", "解析数据报失败", err, "from", from.TerminalString())
+		p2p.Scores.Report(from, p2p.MsgClassConsensus, p2p.MsgInvalid)
+		return
 	}
+	account := p2p.ServerP2p.ConvertIdToAddress(from)
+	if account == p2p.EmptyAddress {
+		log.Debug("HD", "数据报发送方账户未知", from.TerminalString())
+		return
+	}
+	self.dataChan <- &AlgorithmMsg{Account: account, Data: data}
 }
 
 func (self *HD) receive() {
@@ -82,31 +145,45 @@ func (self *HD) receive() {
 		case data := <-self.dataChan:
 			subCode := mc.EventCode(data.Data.SubCode)
 			log.Trace("HD", "SubCode", subCode, "from", data.Account.Hex())
+			switch subCode {
+			case mc.HD_MsgAck:
+				self.delivery.handleAck(data.Account, data.Data.Msg)
+				continue
+			case mc.HD_ReliableMsg:
+				netData, ok := self.delivery.handleReliable(data.Account, data.Data.Msg)
+				if !ok {
+					continue
+				}
+				subCode = mc.EventCode(netData.SubCode)
+				data = &AlgorithmMsg{Account: data.Account, Data: netData}
+			}
 			codec, err := self.findCodec(subCode)
 			if err != nil {
 				log.Error("HD", "receive findCodec err", err)
 				break
 			}
-			msg, err := codec.DecodeFn(data.Data.Msg, data.Account)
+			msg, err := codec.decode(data.Data.Msg, data.Account)
 			if err != nil {
-				log.Error("HD", "DecodeFn err", err, "subCode", subCode, "from", data.Account.Hex())
+				log.Warn("HD", "DecodeFn err", err, "subCode", subCode, "from", data.Account.Hex())
+				p2p.Scores.ReportAddress(data.Account, p2p.MsgClassConsensus, p2p.MsgInvalid)
 				break
 			}
+			p2p.Scores.ReportAddress(data.Account, p2p.MsgClassConsensus, p2p.MsgUseful)
 			mc.PublishEvent(subCode, msg)
 		}
 	}
 }
 
-func (self *HD) registerCodec(subCode mc.EventCode, codec MsgCodec) {
-	_, exist := self.codecMap[subCode]
+func (self *Codec) registerCodec(spec *codecSpec) {
+	_, exist := self.codecMap[spec.subCode]
 	if exist {
-		log.Error("HD", "注册编解码器失败, 已存在的消息码", subCode)
+		log.Error("HD", "注册编解码器失败, 已存在的消息码", spec.subCode)
 		return
 	}
-	self.codecMap[subCode] = codec
+	self.codecMap[spec.subCode] = spec
 }
 
-func (self *HD) findCodec(subCode mc.EventCode) (MsgCodec, error) {
+func (self *Codec) findCodec(subCode mc.EventCode) (*codecSpec, error) {
 	codec, OK := self.codecMap[subCode]
 	if !OK {
 		return nil, errors.Errorf("消息码[%v]的编解码器不存在", subCode)
@@ -12,39 +12,128 @@ import (
 	"github.com/pkg/errors"
 )
 
-func (self *HD) initCodec() {
-	self.registerCodec(mc.HD_BlkConsensusReq, new(blkConsensusReqCodec))
-	self.registerCodec(mc.HD_BlkConsensusVote, new(blkConsensusVoteCodec))
-	self.registerCodec(mc.HD_MiningReq, new(miningReqCodec))
-	self.registerCodec(mc.HD_MiningRsp, new(miningRspCodec))
-	self.registerCodec(mc.HD_BroadcastMiningRsp, new(broadcastMiningRspCodec))
-	self.registerCodec(mc.HD_NewBlockInsert, new(newBlockInsertCodec))
-	self.registerCodec(mc.HD_TopNodeConsensusReq, new(onlineConsensusReqCodec))
-	self.registerCodec(mc.HD_TopNodeConsensusVote, new(onlineConsensusVoteCodec))
-	self.registerCodec(mc.HD_TopNodeConsensusVoteResult, new(onlineConsensusResultCodec))
-	self.registerCodec(mc.HD_LeaderReelectInquiryReq, new(lrInquiryReqCodec))
-	self.registerCodec(mc.HD_LeaderReelectInquiryRsp, new(lrInquiryRspCodec))
-	self.registerCodec(mc.HD_LeaderReelectReq, new(lrReqCodec))
-	self.registerCodec(mc.HD_LeaderReelectVote, new(lrVoteCodec))
-	self.registerCodec(mc.HD_LeaderReelectBroadcast, new(lrResultBCCodec))
-	self.registerCodec(mc.HD_LeaderReelectBroadcastRsp, new(lrResultBCRspCodec))
-	self.registerCodec(mc.HD_FullBlockReq, new(fullBlockReqCodec))
-	self.registerCodec(mc.HD_FullBlockRsp, new(fullBlockRspCodec))
-
-	self.registerCodec(mc.HD_V2_LeaderReelectInquiryReq, new(lrInquiryReqCodecV2))
-	self.registerCodec(mc.HD_V2_LeaderReelectInquiryRsp, new(lrInquiryRspCodecV2))
-	self.registerCodec(mc.HD_V2_LeaderReelectReq, new(lrReqCodecV2))
-	self.registerCodec(mc.HD_V2_LeaderReelectVote, new(lrVoteCodecV2))
-	self.registerCodec(mc.HD_V2_LeaderReelectBroadcast, new(lrResultBCCodecV2))
-	self.registerCodec(mc.HD_V2_LeaderReelectBroadcastRsp, new(lrResultBCRspCodecV2))
-	self.registerCodec(mc.HD_V2_FullBlockReq, new(fullBlockReqCodecV2))
-	self.registerCodec(mc.HD_V2_FullBlockRsp, new(fullBlockRspCodecV2))
-	self.registerCodec(mc.HD_V2_MiningReq, new(miningReqCodecV2))
-	self.registerCodec(mc.HD_V2_PowMiningRsp, new(powMiningRspMsgcV2))
-	self.registerCodec(mc.HD_V2_AIMiningRsp, new(aiMiningRspMsgcV2))
-	self.registerCodec(mc.HD_BasePowerResult, new(basePowerDifficultyMsgcV2))
-
-	self.registerCodec(mc.HD_FullBlkReqToBroadcast, new(fullBlockReqToBroadcastCodecV2))
+func (self *Codec) initCodec() {
+	for i := range codecSpecs {
+		self.registerCodec(&codecSpecs[i])
+	}
+}
+
+// codecSpecs 全部算法消息的编解码声明：消息码、版本、大小上限、编解码器、解码后的校验
+var codecSpecs = []codecSpec{
+	{mc.HD_BlkConsensusReq, 1, headerMsgSize, new(blkConsensusReqCodec), nil},
+	{mc.HD_BlkConsensusVote, 1, smallMsgSize, new(blkConsensusVoteCodec), validateVote},
+	{mc.HD_MiningReq, 1, headerMsgSize, new(miningReqCodec), nil},
+	{mc.HD_MiningRsp, 1, smallMsgSize, new(miningRspCodec), nil},
+	{mc.HD_BroadcastMiningRsp, 1, blockMsgSize, new(broadcastMiningRspCodec), nil},
+	{mc.HD_NewBlockInsert, 1, headerMsgSize, new(newBlockInsertCodec), nil},
+	{mc.HD_TopNodeConsensusReq, 1, smallMsgSize, new(onlineConsensusReqCodec), validateOnlineReqs},
+	{mc.HD_TopNodeConsensusVote, 1, smallMsgSize, new(onlineConsensusVoteCodec), validateOnlineVotes},
+	{mc.HD_TopNodeConsensusVoteResult, 1, smallMsgSize, new(onlineConsensusResultCodec), validateOnlineResult},
+	{mc.HD_LeaderReelectInquiryReq, 1, smallMsgSize, new(lrInquiryReqCodec), nil},
+	{mc.HD_LeaderReelectInquiryRsp, 1, headerMsgSize, new(lrInquiryRspCodec), nil},
+	{mc.HD_LeaderReelectReq, 1, smallMsgSize, new(lrReqCodec), nil},
+	{mc.HD_LeaderReelectVote, 1, smallMsgSize, new(lrVoteCodec), validateVote},
+	{mc.HD_LeaderReelectBroadcast, 1, headerMsgSize, new(lrResultBCCodec), validateReelectBroadcast},
+	{mc.HD_LeaderReelectBroadcastRsp, 1, smallMsgSize, new(lrResultBCRspCodec), nil},
+	{mc.HD_FullBlockReq, 1, smallMsgSize, new(fullBlockReqCodec), validateFullBlockReq},
+	{mc.HD_FullBlockRsp, 1, blockMsgSize, new(fullBlockRspCodec), nil},
+
+	{mc.HD_V2_LeaderReelectInquiryReq, 1, smallMsgSize, new(lrInquiryReqCodecV2), nil},
+	{mc.HD_V2_LeaderReelectInquiryRsp, 1, headerMsgSize, new(lrInquiryRspCodecV2), nil},
+	{mc.HD_V2_LeaderReelectReq, 1, smallMsgSize, new(lrReqCodecV2), nil},
+	{mc.HD_V2_LeaderReelectVote, 1, smallMsgSize, new(lrVoteCodecV2), validateVote},
+	{mc.HD_V2_LeaderReelectBroadcast, 1, headerMsgSize, new(lrResultBCCodecV2), validateReelectBroadcast},
+	{mc.HD_V2_LeaderReelectBroadcastRsp, 1, smallMsgSize, new(lrResultBCRspCodecV2), nil},
+	{mc.HD_V2_FullBlockReq, 1, smallMsgSize, new(fullBlockReqCodecV2), validateFullBlockReq},
+	{mc.HD_V2_FullBlockRsp, 1, blockMsgSize, new(fullBlockRspCodecV2), nil},
+	{mc.HD_V2_MiningReq, 1, headerMsgSize, new(miningReqCodecV2), nil},
+	{mc.HD_V2_PowMiningRsp, 1, smallMsgSize, new(powMiningRspMsgcV2), validatePowMiningRsp},
+	{mc.HD_V2_AIMiningRsp, 1, smallMsgSize, new(aiMiningRspMsgcV2), nil},
+	{mc.HD_BasePowerResult, 1, smallMsgSize, new(basePowerDifficultyMsgcV2), nil},
+
+	{mc.HD_FullBlkReqToBroadcast, 1, blockMsgSize, new(fullBlockReqToBroadcastCodecV2), nil},
+}
+
+func validateVote(msg interface{}) error {
+	var signHash common.Hash
+	switch vote := msg.(type) {
+	case *mc.HD_ConsensusVote:
+		signHash = vote.SignHash
+	case *mc.HD_V2_ConsensusVote:
+		signHash = vote.SignHash
+	}
+	if signHash == (common.Hash{}) {
+		return errors.New("'SignHash' of the vote is empty")
+	}
+	return nil
+}
+
+func validateOnlineReqs(msg interface{}) error {
+	reqs := msg.(*mc.HD_OnlineConsensusReqs)
+	if len(reqs.ReqList) == 0 {
+		return errors.New("'ReqList' of the msg is empty")
+	}
+	for _, req := range reqs.ReqList {
+		if req == nil {
+			return errors.New("'ReqList' of the msg contains nil")
+		}
+	}
+	return nil
+}
+
+func validateOnlineVotes(msg interface{}) error {
+	votes := msg.(*mc.HD_OnlineConsensusVotes)
+	if len(votes.Votes) == 0 {
+		return errors.New("'Votes' of the msg is empty")
+	}
+	for i := range votes.Votes {
+		if err := validateVote(&votes.Votes[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateOnlineResult(msg interface{}) error {
+	if msg.(*mc.HD_OnlineConsensusVoteResultMsg).Req == nil {
+		return errors.New("'Req' of the msg is nil")
+	}
+	return nil
+}
+
+func validateReelectBroadcast(msg interface{}) error {
+	switch bc := msg.(type) {
+	case *mc.HD_ReelectBroadcastMsg:
+		if bc.POSResult == nil && bc.RLResult == nil {
+			return errors.New("'POSResult' and 'RLResult' of the msg are both nil")
+		}
+	case *mc.HD_V2_ReelectBroadcastMsg:
+		if bc.POSResult == nil && bc.RLResult == nil {
+			return errors.New("'POSResult' and 'RLResult' of the msg are both nil")
+		}
+	}
+	return nil
+}
+
+func validateFullBlockReq(msg interface{}) error {
+	var headerHash common.Hash
+	switch req := msg.(type) {
+	case *mc.HD_FullBlockReqMsg:
+		headerHash = req.HeaderHash
+	case *mc.HD_V2_FullBlockReqMsg:
+		headerHash = req.HeaderHash
+	}
+	if headerHash == (common.Hash{}) {
+		return errors.New("'HeaderHash' of the msg is empty")
+	}
+	return nil
+}
+
+func validatePowMiningRsp(msg interface{}) error {
+	if msg.(*mc.HD_V2_PowMiningRspMsg).Difficulty == nil {
+		return errors.New("'Difficulty' of the msg is nil")
+	}
+	return nil
 }
 
 //每个模块需要自己实现这两个接口
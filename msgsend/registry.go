@@ -0,0 +1,187 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package msgsend
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/metrics"
+	"github.com/MatrixAINetwork/go-matrix/p2p"
+	"github.com/MatrixAINetwork/go-matrix/p2p/discover"
+	"github.com/pkg/errors"
+)
+
+// 消息大小上限
+const (
+	smallMsgSize  = 64 * 1024        // 投票、询问等不含区块头的消息
+	headerMsgSize = 1024 * 1024      // 含区块头的消息
+	blockMsgSize  = 16 * 1024 * 1024 // 含完整区块的消息
+)
+
+var (
+	ErrMsgTooLarge = errors.New("message exceeds size limit")
+
+	decodeFailMeter   = metrics.NewRegisteredMeter("msgsend/decode/fail", nil)
+	validateFailMeter = metrics.NewRegisteredMeter("msgsend/validate/fail", nil)
+	incompatibleMeter = metrics.NewRegisteredMeter("msgsend/incompatible", nil)
+)
+
+// codecSpec 消息码的编解码声明。
+// 消息格式发生不兼容的变化时增加version，握手时交换各消息码的版本，不向版本不同的节点发送该消息
+type codecSpec struct {
+	subCode  mc.EventCode
+	version  uint32
+	maxSize  int
+	codec    MsgCodec
+	validate func(msg interface{}) error
+}
+
+func (spec *codecSpec) encode(msg interface{}) ([]byte, error) {
+	data, err := spec.codec.EncodeFn(msg)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > spec.maxSize {
+		return nil, errors.Errorf("%v: %d > %d", ErrMsgTooLarge, len(data), spec.maxSize)
+	}
+	return data, nil
+}
+
+func (spec *codecSpec) decode(data []byte, from common.Address) (interface{}, error) {
+	if len(data) > spec.maxSize {
+		decodeFailMeter.Mark(1)
+		return nil, errors.Errorf("%v: %d > %d", ErrMsgTooLarge, len(data), spec.maxSize)
+	}
+	msg, err := spec.codec.DecodeFn(data, from)
+	if err != nil {
+		decodeFailMeter.Mark(1)
+		return nil, err
+	}
+	if spec.validate != nil {
+		if err := spec.validate(msg); err != nil {
+			validateFailMeter.Mark(1)
+			return nil, errors.Errorf("invalid msg: %v", err)
+		}
+	}
+	return msg, nil
+}
+
+// CodecVersion 消息码及其版本，在man握手中交换
+type CodecVersion struct {
+	SubCode uint32
+	Version uint32
+}
+
+// 投递层消息由delivery处理，不经过编解码表，版本随投递层一起声明
+var deliveryVersions = []CodecVersion{
+	{SubCode: uint32(mc.HD_ReliableMsg), Version: 1},
+	{SubCode: uint32(mc.HD_MsgAck), Version: 1},
+	{SubCode: uint32(mc.HD_EncryptedDatagram), Version: 1},
+}
+
+// SupportedVersions 返回本节点支持的全部消息码版本，按消息码排序
+func SupportedVersions() []CodecVersion {
+	versions := make([]CodecVersion, 0, len(codecSpecs)+len(deliveryVersions))
+	for _, spec := range codecSpecs {
+		versions = append(versions, CodecVersion{SubCode: uint32(spec.subCode), Version: spec.version})
+	}
+	versions = append(versions, deliveryVersions...)
+	sort.Slice(versions, func(i, j int) bool { return versions[i].SubCode < versions[j].SubCode })
+	return versions
+}
+
+// IncompatibleVersions 返回与本节点版本不同或对方不支持的消息码
+func IncompatibleVersions(remote []CodecVersion) []mc.EventCode {
+	remoteMap := versionMap(remote)
+	list := make([]mc.EventCode, 0)
+	for _, local := range SupportedVersions() {
+		if version, exist := remoteMap[mc.EventCode(local.SubCode)]; !exist || version != local.Version {
+			list = append(list, mc.EventCode(local.SubCode))
+		}
+	}
+	return list
+}
+
+func versionMap(versions []CodecVersion) map[mc.EventCode]uint32 {
+	m := make(map[mc.EventCode]uint32, len(versions))
+	for _, item := range versions {
+		m[mc.EventCode(item.SubCode)] = item.Version
+	}
+	return m
+}
+
+var localVersions = versionMap(SupportedVersions())
+
+// 握手协商得到的节点消息版本，未协商的旧节点不在表中
+var peerCodecs = struct {
+	mu    sync.RWMutex
+	peers map[discover.NodeID]map[mc.EventCode]uint32
+}{peers: make(map[discover.NodeID]map[mc.EventCode]uint32)}
+
+// SetPeerCodecs 记录握手时节点声明的消息版本，双方都支持加密数据报时对该节点启用
+func SetPeerCodecs(id discover.NodeID, versions []CodecVersion) {
+	remote := versionMap(versions)
+	peerCodecs.mu.Lock()
+	peerCodecs.peers[id] = remote
+	peerCodecs.mu.Unlock()
+	if remote[mc.HD_EncryptedDatagram] == localVersions[mc.HD_EncryptedDatagram] {
+		p2p.Udp.EnablePeer(id)
+	}
+}
+
+// RemovePeerCodecs 节点断开时删除协商结果
+func RemovePeerCodecs(id discover.NodeID) {
+	peerCodecs.mu.Lock()
+	defer peerCodecs.mu.Unlock()
+	delete(peerCodecs.peers, id)
+	p2p.Udp.DisablePeer(id)
+}
+
+func getPeerCodecs(id discover.NodeID) (map[mc.EventCode]uint32, bool) {
+	peerCodecs.mu.RLock()
+	defer peerCodecs.mu.RUnlock()
+	versions, exist := peerCodecs.peers[id]
+	return versions, exist
+}
+
+// peerCompatible 节点能否解析消息码。negotiated为false表示节点未协商版本(旧版本节点)
+func peerCompatible(tp transport, addr common.Address, subCode mc.EventCode) (compatible bool, negotiated bool) {
+	versions, negotiated := tp.PeerCodecs(addr)
+	if !negotiated {
+		return true, false
+	}
+	local := localVersions[subCode]
+	version, exist := versions[subCode]
+	return exist && version == local, true
+}
+
+func logIncompatible(subCode mc.EventCode, to common.Address) {
+	incompatibleMeter.Mark(1)
+	log.Warn("HD", "对方节点不支持该消息版本, 不发送", subCode, "to", to.Hex())
+}
+
+// fuzzCodecs 以data[0]选择消息码，用其余数据解码；解码成功的消息重新编码后必须能再次解码。
+// 供go-fuzz(codec_fuzz.go)和单元测试使用
+func fuzzCodecs(data []byte) int {
+	if len(data) == 0 {
+		return -1
+	}
+	spec := &codecSpecs[int(data[0])%len(codecSpecs)]
+	msg, err := spec.decode(data[1:], common.Address{})
+	if err != nil {
+		return 0
+	}
+	encoded, err := spec.encode(msg)
+	if err != nil {
+		return 0
+	}
+	if _, err := spec.decode(encoded, common.Address{}); err != nil {
+		panic(errors.Errorf("消息码[%v]重新编码后解码失败: %v", spec.subCode, err))
+	}
+	return 1
+}
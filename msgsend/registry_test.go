@@ -0,0 +1,109 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package msgsend
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+)
+
+func TestCodecSpecs(t *testing.T) {
+	codec := NewCodec()
+	if len(codec.codecMap) != len(codecSpecs) {
+		t.Fatalf("registered %d codecs, declared %d", len(codec.codecMap), len(codecSpecs))
+	}
+	for _, spec := range codecSpecs {
+		if spec.version == 0 || spec.maxSize <= 0 || spec.codec == nil {
+			t.Errorf("bad spec for subCode %d: %+v", spec.subCode, spec)
+		}
+	}
+	versions := SupportedVersions()
+	if len(versions) != len(codecSpecs)+len(deliveryVersions) {
+		t.Fatalf("supported versions = %d", len(versions))
+	}
+	if len(IncompatibleVersions(versions)) != 0 {
+		t.Fatal("local versions incompatible with themselves")
+	}
+
+	remote := append([]CodecVersion{}, versions[1:]...)
+	remote[0].Version++
+	incompatible := IncompatibleVersions(remote)
+	if len(incompatible) != 2 || incompatible[0] != mc.EventCode(versions[0].SubCode) || incompatible[1] != mc.EventCode(versions[1].SubCode) {
+		t.Fatalf("incompatible = %v", incompatible)
+	}
+}
+
+func TestCodecSizeAndValidate(t *testing.T) {
+	codec := NewCodec()
+	vote := &mc.HD_ConsensusVote{Number: 10}
+	if _, err := codec.Encode(mc.HD_BlkConsensusVote, vote); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := codec.Encode(mc.HD_BlkConsensusVote, vote)
+	if _, err := codec.Decode(data, testPeer); err == nil || !strings.Contains(err.Error(), "SignHash") {
+		t.Fatalf("empty vote decoded, err = %v", err)
+	}
+
+	vote.SignHash = common.HexToHash("0x01")
+	data, _ = codec.Encode(mc.HD_BlkConsensusVote, vote)
+	msg, err := codec.Decode(data, testPeer)
+	if err != nil || msg.(*mc.HD_ConsensusVote).From != testPeer {
+		t.Fatalf("decode vote: %v, %v", msg, err)
+	}
+
+	data.Msg = append(data.Msg, bytes.Repeat([]byte(" "), smallMsgSize)...)
+	if _, err := codec.Decode(data, testPeer); err == nil || !strings.Contains(err.Error(), ErrMsgTooLarge.Error()) {
+		t.Fatalf("oversized vote decoded, err = %v", err)
+	}
+}
+
+func TestIncompatiblePeer(t *testing.T) {
+	tp := &testTransport{}
+	d := newDelivery(tp)
+	d.setPolicy(mc.HD_MiningReq, DeliveryPolicy{Ack: true})
+
+	// 未协商版本的旧节点照常发送，但不要求确认
+	if compatible, negotiated := peerCompatible(tp, testPeer, mc.HD_MiningReq); !compatible || negotiated {
+		t.Fatalf("legacy peer: compatible = %v, negotiated = %v", compatible, negotiated)
+	}
+	d.send(mc.HD_MiningReq, NetData{SubCode: uint32(mc.HD_MiningReq)}, testPeer, nil)
+	waitCount(t, tp, 1)
+	if mc.EventCode(tp.last().SubCode) != mc.HD_MiningReq {
+		t.Fatalf("legacy peer got subCode %d", tp.last().SubCode)
+	}
+
+	tp.codecs = map[mc.EventCode]uint32{mc.HD_MiningReq: localVersions[mc.HD_MiningReq] + 1}
+	if compatible, negotiated := peerCompatible(tp, testPeer, mc.HD_MiningReq); compatible || !negotiated {
+		t.Fatalf("upgraded peer: compatible = %v, negotiated = %v", compatible, negotiated)
+	}
+}
+
+// TestCodecsMalformedInput 以随机数据和截断的消息对全部编解码器做简单的模糊测试
+func TestCodecsMalformedInput(t *testing.T) {
+	seeds := [][]byte{
+		nil, []byte("{}"), []byte("null"), []byte("[]"), {0xc0}, {0x80},
+		[]byte(`{"Header":{}}`), []byte(`{"Header":null,"Txs":[]}`), []byte(`{"Votes":[{}]}`),
+		[]byte(`{"ReqList":[null]}`), []byte(`{"InquiryReq":{}}`),
+	}
+	rnd := rand.New(rand.NewSource(1))
+	for i := range codecSpecs {
+		for _, seed := range seeds {
+			fuzzCodecs(append([]byte{byte(i)}, seed...))
+			for cut := 0; cut < len(seed); cut++ {
+				fuzzCodecs(append([]byte{byte(i)}, seed[:cut]...))
+			}
+		}
+		for j := 0; j < 200; j++ {
+			data := make([]byte, rnd.Intn(64)+1)
+			rnd.Read(data)
+			data[0] = byte(i)
+			fuzzCodecs(data)
+		}
+	}
+}
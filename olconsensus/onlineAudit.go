@@ -0,0 +1,308 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package olconsensus
+
+import (
+	"encoding/binary"
+	"sort"
+	"sync"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/crypto"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/rlp"
+)
+
+const (
+	auditMemHeights  = 64    // 内存中保留审计记录的高度范围
+	auditKeepHeights = 10000 // 数据库中保留审计记录的高度范围
+	auditMaxPending  = 1024  // 请求未知的投票最多缓存数量
+
+	auditVoteNone   = uint8(0)
+	auditVoteAgree  = uint8(1)
+	auditVoteReject = uint8(2)
+)
+
+var auditDBPrefix = []byte("online-consensus-audit-")
+
+type auditPendingVote struct {
+	hash common.Hash
+	vote mc.OnlineAuditVote
+}
+
+// onlineAudit 记录在线共识的请求、投票和结果，按请求高度保存到数据库，用于事后解释节点上下线的原因。
+// 修改过的高度先标记，由flush批量写入数据库
+type onlineAudit struct {
+	mu      sync.Mutex
+	db      mandb.Database
+	records map[common.Hash]*mc.OnlineConsensusAudit
+	pending []auditPendingVote
+	dirty   map[uint64]struct{}
+	highest uint64
+	isVoter func(common.Address) bool // 为nil时记录全部投票
+}
+
+func newOnlineAudit() *onlineAudit {
+	return &onlineAudit{
+		records: make(map[common.Hash]*mc.OnlineConsensusAudit),
+		pending: make([]auditPendingVote, 0),
+		dirty:   make(map[uint64]struct{}),
+	}
+}
+
+func (oa *onlineAudit) setDB(db mandb.Database) {
+	oa.mu.Lock()
+	defer oa.mu.Unlock()
+	oa.db = db
+}
+
+// addProposal 记录共识请求，之前缓存的该请求的投票一并记录。调用者需先检查请求的轮次，
+// 否则伪造的高度会推进highest，使正常的请求被当作过期请求
+func (oa *onlineAudit) addProposal(reqHash common.Hash, req *mc.OnlineConsensusReq) {
+	if req == nil {
+		return
+	}
+	oa.mu.Lock()
+	defer oa.mu.Unlock()
+	if _, exist := oa.records[reqHash]; exist {
+		return
+	}
+	if oa.expired(req.Number) {
+		return
+	}
+	record := &mc.OnlineConsensusAudit{
+		ReqHash:     reqHash,
+		Number:      req.Number,
+		LeaderTurn:  req.LeaderTurn,
+		Leader:      req.Leader,
+		Node:        req.Node,
+		OnlineState: req.OnlineState,
+		Votes:       make([]mc.OnlineAuditVote, 0),
+		Signers:     make([]common.Address, 0),
+	}
+	oa.records[reqHash] = record
+
+	remain := oa.pending[:0]
+	for _, item := range oa.pending {
+		if item.hash == reqHash {
+			addAuditVote(record, item.vote)
+		} else {
+			remain = append(remain, item)
+		}
+	}
+	oa.pending = remain
+
+	if req.Number > oa.highest {
+		oa.advance(req.Number)
+	}
+	oa.save(record.Number)
+}
+
+// addLocalVote 记录本节点的投票及依据
+func (oa *onlineAudit) addLocalVote(reqHash common.Hash, agree bool, reason string) {
+	oa.mu.Lock()
+	defer oa.mu.Unlock()
+	record, exist := oa.records[reqHash]
+	if !exist {
+		return
+	}
+	if agree {
+		record.LocalVote = auditVoteAgree
+	} else {
+		record.LocalVote = auditVoteReject
+	}
+	record.LocalReason = reason
+	oa.save(record.Number)
+}
+
+// addVote 记录收到的投票，签名无法恢复或不是当前拓扑中验证者的投票忽略
+func (oa *onlineAudit) addVote(signHash common.Hash, sign common.Signature) {
+	signer, validate, err := crypto.VerifySignWithValidate(signHash.Bytes(), sign.Bytes())
+	if err != nil {
+		return
+	}
+	if oa.isVoter != nil && !oa.isVoter(signer) {
+		return
+	}
+	vote := mc.OnlineAuditVote{Signer: signer, Agree: validate}
+
+	oa.mu.Lock()
+	defer oa.mu.Unlock()
+	record, exist := oa.records[signHash]
+	if !exist {
+		oa.pending = append(oa.pending, auditPendingVote{hash: signHash, vote: vote})
+		if len(oa.pending) > auditMaxPending {
+			oa.pending = oa.pending[len(oa.pending)-auditMaxPending:]
+		}
+		return
+	}
+	if addAuditVote(record, vote) {
+		oa.save(record.Number)
+	}
+}
+
+// addResult 记录共识结果，已通过的结果不会被失败结果覆盖。
+// 只有POS验证通过的结果才会补记请求，未通过的结果只更新已有的记录
+func (oa *onlineAudit) addResult(reqHash common.Hash, req *mc.OnlineConsensusReq, result uint8, reason string, signers []common.Address) {
+	if req == nil {
+		return
+	}
+	oa.mu.Lock()
+	record, exist := oa.records[reqHash]
+	oa.mu.Unlock()
+	if !exist && result == mc.OnlineAuditResultPassed {
+		// 只收到了共识结果消息
+		oa.addProposal(reqHash, req)
+	}
+
+	oa.mu.Lock()
+	defer oa.mu.Unlock()
+	record, exist = oa.records[reqHash]
+	if !exist || record.Result == mc.OnlineAuditResultPassed {
+		return
+	}
+	record.Result = result
+	record.Reason = reason
+	if signers != nil {
+		record.Signers = signers
+	}
+	oa.save(record.Number)
+}
+
+// query 返回请求高度在[from, to]范围内的审计记录，node为空时返回全部节点
+func (oa *onlineAudit) query(from, to uint64, node common.Address) []mc.OnlineConsensusAudit {
+	oa.mu.Lock()
+	defer oa.mu.Unlock()
+	oa.flushLocked()
+	ret := make([]mc.OnlineConsensusAudit, 0)
+	for number := from; number <= to; number++ {
+		for _, record := range oa.load(number) {
+			if (node == common.Address{}) || record.Node == node {
+				ret = append(ret, record)
+			}
+		}
+		if number == to {
+			break
+		}
+	}
+	return ret
+}
+
+func addAuditVote(record *mc.OnlineConsensusAudit, vote mc.OnlineAuditVote) bool {
+	for _, item := range record.Votes {
+		if item.Signer == vote.Signer {
+			return false
+		}
+	}
+	record.Votes = append(record.Votes, vote)
+	return true
+}
+
+// expired 请求高度是否已超出内存中保留的范围
+func (oa *onlineAudit) expired(number uint64) bool {
+	return oa.highest > auditMemHeights && number < oa.highest-auditMemHeights
+}
+
+// 高度推进时清理内存中的旧记录，并删除数据库中超出保留范围的记录
+func (oa *onlineAudit) advance(number uint64) {
+	// 被清理的记录需先写入数据库
+	oa.flushLocked()
+	old := oa.highest
+	oa.highest = number
+	for hash, record := range oa.records {
+		if oa.expired(record.Number) {
+			delete(oa.records, hash)
+		}
+	}
+	if oa.db == nil || number <= auditKeepHeights {
+		return
+	}
+	start := uint64(0)
+	if old > auditKeepHeights {
+		start = old - auditKeepHeights
+	}
+	end := number - auditKeepHeights
+	if end-start > auditMemHeights {
+		start = end - auditMemHeights
+	}
+	for n := start; n < end; n++ {
+		oa.db.Delete(auditDBKey(n))
+	}
+}
+
+func (oa *onlineAudit) recordsAt(number uint64) []mc.OnlineConsensusAudit {
+	list := make([]mc.OnlineConsensusAudit, 0)
+	for _, record := range oa.records {
+		if record.Number == number {
+			list = append(list, *record)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].LeaderTurn != list[j].LeaderTurn {
+			return list[i].LeaderTurn < list[j].LeaderTurn
+		}
+		return list[i].Node.Hex() < list[j].Node.Hex()
+	})
+	return list
+}
+
+// save 标记该高度的记录需要写入数据库
+func (oa *onlineAudit) save(number uint64) {
+	if oa.db == nil {
+		return
+	}
+	oa.dirty[number] = struct{}{}
+}
+
+// flush 把修改过的高度批量写入数据库
+func (oa *onlineAudit) flush() {
+	oa.mu.Lock()
+	defer oa.mu.Unlock()
+	oa.flushLocked()
+}
+
+func (oa *onlineAudit) flushLocked() {
+	if oa.db == nil || len(oa.dirty) == 0 {
+		return
+	}
+	batch := oa.db.NewBatch()
+	for number := range oa.dirty {
+		data, err := rlp.EncodeToBytes(oa.recordsAt(number))
+		if err != nil {
+			log.Error("共识节点状态", "审计记录编码失败", err)
+			continue
+		}
+		batch.Put(auditDBKey(number), data)
+	}
+	if err := batch.Write(); err != nil {
+		log.Error("共识节点状态", "审计记录写入数据库失败", err)
+		return
+	}
+	oa.dirty = make(map[uint64]struct{})
+}
+
+func (oa *onlineAudit) load(number uint64) []mc.OnlineConsensusAudit {
+	if oa.db == nil {
+		return oa.recordsAt(number)
+	}
+	data, err := oa.db.Get(auditDBKey(number))
+	if err != nil || len(data) == 0 {
+		return nil
+	}
+	list := make([]mc.OnlineConsensusAudit, 0)
+	if err := rlp.DecodeBytes(data, &list); err != nil {
+		log.Error("共识节点状态", "读取数据库中的审计记录失败", err, "高度", number)
+		return nil
+	}
+	return list
+}
+
+func auditDBKey(number uint64) []byte {
+	key := make([]byte, len(auditDBPrefix)+8)
+	copy(key, auditDBPrefix)
+	binary.BigEndian.PutUint64(key[len(auditDBPrefix):], number)
+	return key
+}
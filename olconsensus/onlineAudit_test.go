@@ -0,0 +1,127 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package olconsensus
+
+import (
+	"math"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/crypto"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+)
+
+func TestOnlineAudit(t *testing.T) {
+	db := mandb.NewMemDatabase()
+	audit := newOnlineAudit()
+	audit.setDB(db)
+
+	node := common.HexToAddress("0x3000")
+	req := &mc.OnlineConsensusReq{Number: 30, LeaderTurn: 1, Leader: common.HexToAddress("0x1000"), Node: node, OnlineState: mc.OffLine}
+	reqHash := types.RlpHash(req)
+
+	agreeKey, _ := crypto.GenerateKey()
+	rejectKey, _ := crypto.GenerateKey()
+	agreeSign, _ := crypto.SignWithValidate(reqHash.Bytes(), true, agreeKey)
+	rejectSign, _ := crypto.SignWithValidate(reqHash.Bytes(), false, rejectKey)
+
+	// 投票先于请求到达
+	audit.addVote(reqHash, common.BytesToSignature(agreeSign))
+	audit.addProposal(reqHash, req)
+	audit.addVote(reqHash, common.BytesToSignature(rejectSign))
+	audit.addVote(reqHash, common.BytesToSignature(rejectSign))
+	audit.addLocalVote(reqHash, true, "last 3 heartbeats all missed")
+	audit.addResult(reqHash, req, mc.OnlineAuditResultPending, "votes POS not reached", nil)
+	audit.addResult(reqHash, req, mc.OnlineAuditResultPassed, "", []common.Address{crypto.PubkeyToAddress(agreeKey.PublicKey)})
+	audit.addResult(reqHash, req, mc.OnlineAuditResultFailed, "result POS verify failed", nil)
+
+	// 批量写入前数据库中没有记录
+	reloaded := newOnlineAudit()
+	reloaded.setDB(db)
+	if list := reloaded.query(0, 100, node); len(list) != 0 {
+		t.Fatalf("records written before flush: %+v", list)
+	}
+	audit.flush()
+
+	// 从数据库读取
+	list := reloaded.query(0, 100, node)
+	if len(list) != 1 {
+		t.Fatalf("records = %d, want 1", len(list))
+	}
+	record := list[0]
+	if record.ReqHash != reqHash || record.OnlineState != mc.OffLine || record.LocalVote != auditVoteAgree {
+		t.Fatalf("unexpected record %+v", record)
+	}
+	if len(record.Votes) != 2 || !record.Votes[0].Agree || record.Votes[1].Agree {
+		t.Fatalf("unexpected votes %+v", record.Votes)
+	}
+	if record.Result != mc.OnlineAuditResultPassed || len(record.Signers) != 1 {
+		t.Fatalf("passed result overwritten: %+v", record)
+	}
+	if list := reloaded.query(0, 100, common.HexToAddress("0x4000")); len(list) != 0 {
+		t.Fatalf("node filter failed: %+v", list)
+	}
+	if list := reloaded.query(31, 100, common.Address{}); len(list) != 0 {
+		t.Fatalf("range filter failed: %+v", list)
+	}
+}
+
+func TestOnlineAuditPrune(t *testing.T) {
+	db := mandb.NewMemDatabase()
+	audit := newOnlineAudit()
+	audit.setDB(db)
+
+	node := common.HexToAddress("0x3000")
+	old := &mc.OnlineConsensusReq{Number: 1, Leader: common.HexToAddress("0x1000"), Node: node, OnlineState: mc.OnLine}
+	audit.addProposal(types.RlpHash(old), old)
+	cur := &mc.OnlineConsensusReq{Number: auditKeepHeights + 10, Leader: common.HexToAddress("0x1000"), Node: node, OnlineState: mc.OnLine}
+	audit.addProposal(types.RlpHash(cur), cur)
+
+	if len(audit.records) != 1 {
+		t.Fatalf("memory records = %d, want 1", len(audit.records))
+	}
+	if list := audit.query(0, 10, node); len(list) != 0 {
+		t.Fatalf("expired record not deleted: %+v", list)
+	}
+	// 过期的请求不再记录
+	audit.addProposal(types.RlpHash(old), old)
+	if len(audit.records) != 1 {
+		t.Fatalf("expired proposal recorded")
+	}
+}
+
+func TestOnlineAuditForged(t *testing.T) {
+	audit := newOnlineAudit()
+	voter, _ := crypto.GenerateKey()
+	other, _ := crypto.GenerateKey()
+	voterAddr := crypto.PubkeyToAddress(voter.PublicKey)
+	audit.isVoter = func(addr common.Address) bool { return addr == voterAddr }
+
+	node := common.HexToAddress("0x3000")
+	// 未通过验证的结果不会记录请求，也不会推进高度
+	forged := &mc.OnlineConsensusReq{Number: math.MaxUint64, Leader: common.HexToAddress("0x1000"), Node: node, OnlineState: mc.OffLine}
+	audit.addResult(types.RlpHash(forged), forged, mc.OnlineAuditResultFailed, "result POS verify failed", nil)
+	if len(audit.records) != 0 || audit.highest != 0 {
+		t.Fatalf("forged result recorded, highest %d", audit.highest)
+	}
+
+	req := &mc.OnlineConsensusReq{Number: 30, Leader: common.HexToAddress("0x1000"), Node: node, OnlineState: mc.OffLine}
+	reqHash := types.RlpHash(req)
+	audit.addProposal(reqHash, req)
+	voterSign, _ := crypto.SignWithValidate(reqHash.Bytes(), true, voter)
+	otherSign, _ := crypto.SignWithValidate(reqHash.Bytes(), true, other)
+	audit.addVote(reqHash, common.BytesToSignature(otherSign))
+	audit.addVote(reqHash, common.BytesToSignature(voterSign))
+	if votes := audit.records[reqHash].Votes; len(votes) != 1 || votes[0].Signer != voterAddr {
+		t.Fatalf("unexpected votes %+v", votes)
+	}
+
+	// 高度接近上限时过期判断不溢出
+	audit.highest = math.MaxUint64
+	if !audit.expired(30) || audit.expired(math.MaxUint64-1) {
+		t.Fatal("expired check overflowed")
+	}
+}
@@ -9,9 +9,12 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/ca"
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/consensus"
+	"github.com/MatrixAINetwork/go-matrix/consensus/evidence"
 	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/crypto"
 	"github.com/MatrixAINetwork/go-matrix/event"
 	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
 	"github.com/MatrixAINetwork/go-matrix/mc"
 	"github.com/MatrixAINetwork/go-matrix/params/manparams"
 )
@@ -36,6 +39,7 @@ type TopNodeService struct {
 	msgCenter       MessageCenterInterface
 	stateReader     StateReaderInterface
 	cr              ChainReader
+	evidence        *evidence.Collector
 
 	roleUpdateCh       chan *mc.RoleUpdatedMsg
 	roleUpdateSub      event.Subscription
@@ -69,13 +73,53 @@ func NewTopNodeService(cr ChainReader) *TopNodeService {
 	//	go t.update()
 
 	t.stateMap = newTopNodeState(64, t.extraInfo)
+	t.stateMap.audit.isVoter = t.isTopologyVoter
 	return t
 }
 
+// isTopologyVoter 投票的签名账户是否属于当前拓扑图中的验证者
+func (serv *TopNodeService) isTopologyVoter(signer common.Address) bool {
+	if serv.validatorReader == nil {
+		return false
+	}
+	account, _, err := serv.validatorReader.GetA0AccountFromAnyAccount(signer, serv.cr.CurrentBlock().Hash())
+	if err != nil {
+		return false
+	}
+	return serv.stateMap.isTopologyValidator(account)
+}
+
 func (serv *TopNodeService) SetValidatorReader(reader consensus.StateReader) {
 	serv.validatorReader = reader
 }
 
+func (serv *TopNodeService) SetEvidenceCollector(collector *evidence.Collector) {
+	serv.evidence = collector
+}
+
+// 记录在线共识请求和投票，用于发现双签
+func (serv *TopNodeService) collectEvidence(req *mc.OnlineConsensusReq, signHash common.Hash, signs ...common.Signature) {
+	if serv.evidence == nil {
+		return
+	}
+	if req != nil {
+		serv.evidence.AddOnlineReq(req, serv.cr.CurrentBlock().Hash())
+	}
+	for _, sign := range signs {
+		serv.evidence.AddVote(signHash, sign)
+	}
+}
+
+// SetAuditDB 设置在线共识审计记录的数据库，未设置时只在内存中保留最近的记录
+func (serv *TopNodeService) SetAuditDB(db mandb.Database) {
+	serv.stateMap.audit.setDB(db)
+}
+
+// GetOnlineAudit 返回请求高度在[from, to]范围内的在线共识审计记录，node为空时返回全部节点
+func (serv *TopNodeService) GetOnlineAudit(from, to uint64, node common.Address) []mc.OnlineConsensusAudit {
+	return serv.stateMap.audit.query(from, to, node)
+}
+
 func (serv *TopNodeService) SetTopNodeStateInterface(inter TopNodeStateInterface) {
 	serv.topNodeState = inter
 }
@@ -170,6 +214,7 @@ func (serv *TopNodeService) update() {
 
 				//log.Debug(serv.extraInfo, "处理CA通知消息", "", "块高", data.BlockNum)
 				serv.stateMap.SetCurStates(data.BlockNum+1, topology, electOnline)
+				serv.stateMap.audit.flush()
 				go serv.LeaderChangeNotifyHandler(serv.msgCheck.GetCurLeader())
 			}
 		case data := <-serv.leaderChangeCh:
@@ -186,6 +231,7 @@ func (serv *TopNodeService) update() {
 			go serv.OnlineConsensusVoteResultMsgHandler(data)
 		case <-serv.quitCh:
 			log.Info(serv.extraInfo, "收到退出消息", "退出")
+			serv.stateMap.audit.flush()
 			return
 		}
 	}
@@ -297,6 +343,7 @@ func (serv *TopNodeService) consensusReqMsgHandler(msg *mc.HD_OnlineConsensusReq
 	for i := 0; i < len(requests); i++ {
 		item := requests[i]
 		reqHash := types.RlpHash(item)
+		serv.collectEvidence(item, reqHash)
 		switch serv.msgCheck.CheckRound(item.Number, item.LeaderTurn) {
 		case 1: // localRound > reqRound
 			log.Debug(serv.extraInfo, "处理共识请求", "轮次过低，抛弃请求", "当前number", serv.msgCheck.curNumber, "当前turn", serv.msgCheck.curLeaderTurn, "req Number", item.Number, "req turn", item.LeaderTurn, "请求hash", reqHash.TerminalString())
@@ -344,6 +391,8 @@ func (serv *TopNodeService) consensusVoteMsgHandler(msg []mc.HD_ConsensusVote) {
 
 	for i := 0; i < len(msg); i++ {
 		item := msg[i]
+		serv.collectEvidence(nil, item.SignHash, item.Sign)
+		serv.stateMap.audit.addVote(item.SignHash, item.Sign)
 		serv.consensusVotes(serv.dposRing.addVote(item.SignHash, &item))
 	}
 }
@@ -359,13 +408,16 @@ func (serv *TopNodeService) OnlineConsensusVoteResultMsgHandler(msg *mc.HD_Onlin
 		log.Error(serv.extraInfo, "处理共识结果消息", "共识消息已过期")
 		return
 	}
+	serv.collectEvidence(msg.Req, types.RlpHash(msg.Req), msg.SignList...)
 	//todo:从状态树获取版本号
 	tempSigns, err := serv.cr.DPOSEngine([]byte(serv.cr.CurrentBlock().Version())).VerifyHash(serv.validatorReader, types.RlpHash(msg.Req), msg.SignList)
 	if err != nil {
 		log.Error(serv.extraInfo, "处理共识结果消息", "POS验证失败", "err", err)
+		serv.stateMap.audit.addResult(types.RlpHash(msg.Req), msg.Req, mc.OnlineAuditResultFailed, "result POS verify failed: "+err.Error(), nil)
 	} else {
 		log.Debug(serv.extraInfo, "处理共识结果消息", "验证通过，缓存状态", "状态", msg.Req.OnlineState.String(), "投票数", len(tempSigns))
 		serv.stateMap.SaveConsensusResult(msg)
+		serv.stateMap.audit.addResult(types.RlpHash(msg.Req), msg.Req, mc.OnlineAuditResultPassed, "", recoverSigners(types.RlpHash(msg.Req), tempSigns))
 	}
 }
 
@@ -395,6 +447,7 @@ func (serv *TopNodeService) consensusVotes(proposal interface{}, votes []voteInf
 	rightSigns, err := serv.cr.DPOSEngine(serv.cr.CurrentBlock().Version()).VerifyHash(serv.validatorReader, votes[0].data.SignHash, signList)
 	if err != nil {
 		log.Debug(serv.extraInfo, "处理共识投票", "POS失败", "节点", prop.Node.Hex(), "状态", prop.OnlineState.String(), "投票数", len(signList), "err", err)
+		serv.stateMap.audit.addResult(votes[0].data.SignHash, prop, mc.OnlineAuditResultPending, "votes POS not reached: "+err.Error(), nil)
 		return
 	}
 	log.Trace(serv.extraInfo, "处理共识投票", "POS通过，发送共识结果消息", "节点", prop.Node.Hex(), "状态", prop.OnlineState.String())
@@ -426,8 +479,11 @@ func (serv *TopNodeService) voteToReq(tempReq *mc.OnlineConsensusReq) (common.Si
 		log.Error(serv.extraInfo, "处理共识请求", "对请求的hash错误")
 		return common.Signature{}, common.Hash{}, voteFailed
 	}
+	// 轮次检查通过后才记录请求
+	serv.stateMap.audit.addProposal(reqHash, tempReq)
 	// TODO 优化，一次获取一个节点的在线状态 GetTopNodeOnlineState
-	ok = serv.stateMap.checkNodeState(tempReq.Node, serv.topNodeState.GetTopNodeOnlineState(), tempReq.OnlineState)
+	ok, reason := serv.stateMap.checkNodeState(tempReq.Node, serv.topNodeState.GetTopNodeOnlineState(), tempReq.OnlineState)
+	serv.stateMap.audit.addLocalVote(reqHash, ok, reason)
 	log.Trace(serv.extraInfo, "处理共识请求", "对共识请求进行投票", "高度", tempReq.Number, "轮次", tempReq.LeaderTurn,
 		"检查状态", tempReq.OnlineState.String(), "ok", ok, "node", tempReq.Node.Hex(), "hash", reqHash.TerminalString(), "leader", tempReq.Leader.Hex())
 
@@ -455,3 +511,15 @@ func (serv *TopNodeService) voteToReq(tempReq *mc.OnlineConsensusReq) (common.Si
 func (serv *TopNodeService) GetConsensusOnlineResults() []*mc.HD_OnlineConsensusVoteResultMsg {
 	return serv.stateMap.GetConsensusResults()
 }
+
+func recoverSigners(signHash common.Hash, signs []common.Signature) []common.Address {
+	signers := make([]common.Address, 0, len(signs))
+	for _, sign := range signs {
+		signer, _, err := crypto.VerifySignWithValidate(signHash.Bytes(), sign.Bytes())
+		if err != nil {
+			continue
+		}
+		signers = append(signers, signer)
+	}
+	return signers
+}
@@ -66,6 +66,14 @@ func (tsdpos *testDPOSEngine) VerifyHashWithVerifiedSignsAndBlock(reader consens
 	return tsdpos.dops.VerifyHashWithVerifiedSignsAndBlock(reader, signs, blockHash)
 }
 
+func (tsdpos *testDPOSEngine) AggregateVotes(reader consensus.StateReader, signHash common.Hash, blsSigns map[common.Address][]byte, blockHash common.Hash) (*types.AggregateSign, error) {
+	return tsdpos.dops.AggregateVotes(reader, signHash, blsSigns, blockHash)
+}
+
+func (tsdpos *testDPOSEngine) VerifyAggregate(reader consensus.StateReader, signHash common.Hash, aggregate *types.AggregateSign, blockHash common.Hash) error {
+	return tsdpos.dops.VerifyAggregate(reader, signHash, aggregate, blockHash)
+}
+
 func (tsdpos *testDPOSEngine) VerifyStocksWithBlock(reader consensus.StateReader, validators []common.Address, blockHash common.Hash) bool {
 	return true
 }
@@ -5,6 +5,7 @@ package olconsensus
 
 import (
 	"errors"
+	"fmt"
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/log"
 	"github.com/MatrixAINetwork/go-matrix/mc"
@@ -26,6 +27,7 @@ type topNodeState struct {
 	consensusResultRing []*mc.HD_OnlineConsensusVoteResultMsg //  todo   使用环，增加数量限制
 	capacity            int
 	last                int
+	audit               *onlineAudit
 	extraInfo           string
 }
 
@@ -37,10 +39,23 @@ func newTopNodeState(capacity int, info string) *topNodeState {
 		consensusResultRing: make([]*mc.HD_OnlineConsensusVoteResultMsg, capacity),
 		capacity:            capacity,
 		last:                capacity - 1,
+		audit:               newOnlineAudit(),
 		extraInfo:           info,
 	}
 }
 
+// isTopologyValidator 是否为当前拓扑图中的验证者
+func (ts *topNodeState) isTopologyValidator(node common.Address) bool {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+	for _, info := range ts.curTopologyNodes {
+		if info.Account == node && info.Type == common.RoleValidator {
+			return true
+		}
+	}
+	return false
+}
+
 func (ts *topNodeState) SetCurStates(curNumber uint64, topologyGroup *mc.TopologyGraph, electStates *mc.ElectOnlineStatus) {
 	log.Info("共识节点状态", "设置当前节点状态", "区块高度，拓扑图，选举节点")
 	if topologyGroup == nil || electStates == nil {
@@ -218,22 +233,28 @@ func (ts *topNodeState) nodeIsNeedCheckState(node common.Address) (needCheck boo
 	return false, mc.OffLine
 }
 
-func (ts *topNodeState) checkNodeState(node common.Address, nodesOnlineInfo []NodeOnLineInfo, checkState mc.OnlineState) bool {
+// checkNodeState 检查本地心跳是否满足检查状态的条件，并给出说明作为投票依据
+func (ts *topNodeState) checkNodeState(node common.Address, nodesOnlineInfo []NodeOnLineInfo, checkState mc.OnlineState) (bool, string) {
 	for _, item := range nodesOnlineInfo {
 		if item.Address != node {
 			continue
 		}
-
 		switch checkState {
 		case mc.OnLine:
-			return isOnline(item.OnlineState)
+			if isOnline(item.OnlineState) {
+				return true, fmt.Sprintf("last %d heartbeats all received", onlineNum)
+			}
+			return false, fmt.Sprintf("missed heartbeats within last %d, heartbeats %v", onlineNum, item.OnlineState)
 		case mc.OffLine:
-			return isOffline(item.OnlineState)
+			if isOffline(item.OnlineState) {
+				return true, fmt.Sprintf("last %d heartbeats all missed", offlineNum)
+			}
+			return false, fmt.Sprintf("received heartbeats within last %d, heartbeats %v", offlineNum, item.OnlineState)
 		default:
-			return false
+			return false, fmt.Sprintf("unknown online state %s", checkState.String())
 		}
 	}
-	return false
+	return false, "no local heartbeat info for node"
 }
 
 func isOnline(state []uint8) bool {
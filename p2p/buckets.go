@@ -12,7 +12,6 @@ import (
 	"sync"
 	"time"
 
-	"github.com/MatrixAINetwork/go-matrix/ca"
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/event"
 	"github.com/MatrixAINetwork/go-matrix/log"
@@ -36,15 +35,24 @@ type Bucket struct {
 	blockChain chan mc.BlockToBucket
 	quit       chan struct{}
 
+	env NetEnv
 	log log.Logger
 }
 
 // Init bucket.
-var Buckets = &Bucket{
-	role:  common.RoleNil,
-	ids:   make([]common.Address, 0),
-	quit:  make(chan struct{}),
-	rings: ring.New(4),
+var Buckets = NewBucket(p2pEnv{})
+
+// NewBucket 创建使用env维护连接的桶，节点运行时使用Buckets
+func NewBucket(env NetEnv) *Bucket {
+	b := &Bucket{
+		role:  common.RoleNil,
+		ids:   make([]common.Address, 0),
+		quit:  make(chan struct{}),
+		rings: ring.New(4),
+		env:   env,
+	}
+	b.init()
+	return b
 }
 
 const (
@@ -69,8 +77,6 @@ func (b *Bucket) init() {
 
 // Start bucket.
 func (b *Bucket) Start() {
-	b.init()
-
 	b.log.Info("buckets start!")
 
 	timeoutTimer := time.NewTimer(time.Second * 60)
@@ -99,73 +105,77 @@ func (b *Bucket) Start() {
 				<-timeoutTimer.C
 			}
 			timeoutTimer.Reset(time.Second * 60)
+			b.HandleBlock(h)
+		case <-b.quit:
+			return
+		}
+	}
+}
 
-			// only bottom nodes will into this buckets.
-			if h.Role > common.RoleBucket {
-				b.role = common.RoleNil
-				break
-			}
+// HandleBlock 按区块中的桶节点和本节点角色维护连接
+func (b *Bucket) HandleBlock(h mc.BlockToBucket) {
+	// only bottom nodes will into this buckets.
+	if h.Role > common.RoleBucket {
+		b.role = common.RoleNil
+		return
+	}
 
-			// down to default, disconnect all peers first
-			if b.role != h.Role && h.Role == common.RoleDefault {
-				b.disconnectPeers()
-			}
+	// down to default, disconnect all peers first
+	if b.role != h.Role && h.Role == common.RoleDefault {
+		b.disconnectPeers()
+	}
 
-			if b.role != h.Role {
-				b.role = h.Role
-			}
+	if b.role != h.Role {
+		b.role = h.Role
+	}
 
-			b.ids = h.Ms
-			// maintain nodes in buckets
-			b.maintainNodes(h.Ms)
+	b.ids = h.Ms
+	// maintain nodes in buckets
+	b.maintainNodes(h.Ms)
 
-			// if not in bucket, do nothing
-			if b.role != common.RoleBucket {
-				b.linkBucketPeer()
-				break
-			}
+	// if not in bucket, do nothing
+	if b.role != common.RoleBucket {
+		b.linkBucketPeer()
+		return
+	}
 
-			// modify max peers in buckets
-			if b.nodesCount() >= MaxBucketContent {
-				MaxLink = 2
-				b.disconnectOnePeer()
-			} else {
-				MaxLink = 3
-			}
+	// modify max peers in buckets
+	if b.nodesCount() >= MaxBucketContent {
+		MaxLink = 2
+		b.disconnectOnePeer()
+	} else {
+		MaxLink = 3
+	}
 
-			// adjust bucket order
-			temp := &big.Int{}
-			if temp.Mod(h.Height, big.NewInt(300)) == big.NewInt(50) {
-				b.rings = b.rings.Prev()
-			}
+	// adjust bucket order
+	temp := &big.Int{}
+	if temp.Mod(h.Height, big.NewInt(300)) == big.NewInt(50) {
+		b.rings = b.rings.Prev()
+	}
 
-			if len(b.ids) <= BucketLimit {
-				b.maintainOuter()
-				break
-			}
+	if len(b.ids) <= BucketLimit {
+		b.maintainOuter()
+		return
+	}
 
-			// maintain outer
-			selfBucket, err := b.selfBucket()
-			if err != nil {
-				b.log.Error("bucket number wrong", "error", err)
-				break
-			}
-			b.self = selfBucket
-
-			// maintain inner
-			b.maintainInner()
-			switch selfBucket {
-			case b.rings.Value.(int64):
-				b.maintainOuter()
-			case b.rings.Next().Value.(int64):
-				b.disconnectMiner()
-			case b.rings.Prev().Value.(int64):
-				miners := ca.GetRolesByGroupWithNextElect(common.RoleMiner | common.RoleBackupValidator)
-				b.outer(MaxLink, miners)
-			}
-		case <-b.quit:
-			return
-		}
+	// maintain outer
+	selfBucket, err := b.selfBucket()
+	if err != nil {
+		b.log.Error("bucket number wrong", "error", err)
+		return
+	}
+	b.self = selfBucket
+
+	// maintain inner
+	b.maintainInner()
+	switch selfBucket {
+	case b.rings.Value.(int64):
+		b.maintainOuter()
+	case b.rings.Next().Value.(int64):
+		b.disconnectMiner()
+	case b.rings.Prev().Value.(int64):
+		miners := b.env.RolesByGroupWithNextElect(common.RoleMiner | common.RoleBackupValidator)
+		b.outer(MaxLink, miners)
 	}
 }
 
@@ -180,7 +190,12 @@ func (b *Bucket) Stop() {
 func (b *Bucket) maintainNodes(elected []common.Address) {
 	// remake every time instead of delete
 	b.bucket = make(map[int64][]common.Address)
+	added := make(map[common.Address]struct{}, len(elected))
 	for _, v := range elected {
+		if _, ok := added[v]; ok {
+			continue
+		}
+		added[v] = struct{}{}
 		b.bucketAdd(v)
 	}
 	for index, bkt := range b.bucket {
@@ -198,33 +213,35 @@ func (b *Bucket) nodesCount() (count int) {
 
 // DisconnectMiner older disconnect miner.
 func (b *Bucket) disconnectMiner() {
-	miners := ca.GetRolesByGroupWithNextElect(common.RoleMiner | common.RoleBackupMiner)
+	miners := b.env.RolesByGroupWithNextElect(common.RoleMiner | common.RoleBackupMiner)
 	for _, miner := range miners {
-		ServerP2p.RemovePeerByAddress(miner)
+		b.env.RemovePeerByAddress(miner)
 	}
 }
 
 // disconnectPeers disconnect all peers
 func (b *Bucket) disconnectPeers() {
-	for _, peer := range ServerP2p.Peers() {
-		ServerP2p.RemovePeer(discover.NewNode(peer.ID(), nil, 0, 0))
+	for _, id := range b.env.Peers() {
+		b.env.RemovePeer(id)
 	}
 }
 
 // disconnectOnePeer if nodes in buckets more than 2 thousand, then disconnect one peer.
 func (b *Bucket) disconnectOnePeer() {
-	for _, peer := range ServerP2p.Peers() {
-		ServerP2p.RemovePeer(discover.NewNode(peer.ID(), nil, 0, 0))
+	for _, id := range b.env.Peers() {
+		b.env.RemovePeer(id)
 		break
 	}
 }
 
 // MaintainInner maintain bucket inner.
 func (b *Bucket) maintainInner() {
-	count := 0
 	next := (b.self + 1) % 4
-	for _, peer := range ServerP2p.Peers() {
-		signAddr := ServerP2p.ConvertIdToAddress(peer.ID())
+	dropBadPeers(b.env, b.bucket[next])
+
+	count := 0
+	for _, id := range b.env.Peers() {
+		signAddr := b.env.AddressOf(id)
 		if signAddr == EmptyAddress {
 			continue
 		}
@@ -248,13 +265,15 @@ func (b *Bucket) maintainInner() {
 
 // MaintainOuter maintain bucket outer.
 func (b *Bucket) maintainOuter() {
-	count := 0
-	miners := ca.GetRolesByGroupWithNextElect(common.RoleMiner | common.RoleBackupMiner)
+	miners := b.env.RolesByGroupWithNextElect(common.RoleMiner | common.RoleBackupMiner)
 	b.log.Info("maintainOuter", "peer info", miners)
-	for _, peer := range ServerP2p.Peers() {
+	dropBadPeers(b.env, miners)
+
+	count := 0
+	for _, peer := range b.env.Peers() {
 		for _, miner := range miners {
-			id := ServerP2p.ConvertAddressToId(miner)
-			if id != EmptyNodeId && peer.ID() == id {
+			id := b.env.IdOf(miner)
+			if id != EmptyNodeId && peer == id {
 				count++
 				break
 			}
@@ -272,13 +291,13 @@ func (b *Bucket) maintainOuter() {
 
 // SelfBucket return self bucket number.
 func (b *Bucket) selfBucket() (int64, error) {
-	return b.peerBucket(ServerP2p.ManAddress)
+	return b.peerBucket(b.env.Self())
 }
 
 func (b *Bucket) peerBucket(addr common.Address) (int64, error) {
 	m := big.Int{}
 	if b.self < common.RoleBucket {
-		return m.Mod(MockHash(ServerP2p.Self().ID).Big(), big.NewInt(4)).Int64(), nil
+		return m.Mod(MockHash(b.env.SelfID()).Big(), big.NewInt(4)).Int64(), nil
 	}
 
 	if addr != EmptyAddress {
@@ -299,10 +318,10 @@ func (b *Bucket) linkBucketPeer() {
 		return
 	}
 	count := 0
-	for _, peer := range ServerP2p.Peers() {
-		signAddr := ServerP2p.ConvertIdToAddress(peer.ID())
+	for _, id := range b.env.Peers() {
+		signAddr := b.env.AddressOf(id)
 		if signAddr == EmptyAddress {
-			b.log.Error("not found sign address", "id", peer.ID())
+			b.log.Error("not found sign address", "id", id)
 			continue
 		}
 		pid, err := b.peerBucket(signAddr)
@@ -329,14 +348,8 @@ func (b *Bucket) bucketAdd(addr common.Address) {
 	b.lock.Lock()
 	defer b.lock.Unlock()
 
-	m := big.Int{}
-	mod := m.Mod(addr.Hash().Big(), big.NewInt(4)).Int64()
-
-	for _, n := range b.bucket[mod] {
-		if n == addr {
-			return
-		}
-	}
+	// 等同于addr.Hash().Big()对4取模，桶节点数以千计时避免大数运算，重复的节点由maintainNodes排除
+	mod := int64(addr.Hash()[common.HashLength-1] % 4)
 	b.bucket[mod] = append(b.bucket[mod], addr)
 }
 
@@ -349,7 +362,7 @@ func (b *Bucket) inner(num int, bucket int64) {
 
 	for _, value := range peers {
 		b.log.Info("peer", "p2p", value)
-		ServerP2p.AddPeerTask(value)
+		b.env.AddPeer(value)
 	}
 }
 
@@ -362,48 +375,24 @@ func (b *Bucket) outer(num int, ids []common.Address) {
 
 	for _, value := range peers {
 		b.log.Info("peer", "p2p", value)
-		ServerP2p.AddPeerTask(value)
+		b.env.AddPeer(value)
 	}
 }
 
-// RandomPeers random peers from next buckets.
+// RandomPeers random peers from next buckets, higher scored peers first.
 func (b *Bucket) randomInnerPeersByBucketNumber(num int, bucket int64) (nodes []common.Address) {
-	length := len(b.bucket[bucket])
-
-	if length <= MaxLink {
-		return b.bucket[bucket]
-	}
-
-	randoms := Random(length, num)
-	for _, ran := range randoms {
-		for index := range b.bucket[bucket] {
-			if index == ran {
-				nodes = append(nodes, b.bucket[bucket][index])
-				break
-			}
-		}
-	}
-	if len(nodes) <= 0 {
-		return b.randomInnerPeersByBucketNumber(num, (bucket+1)%4)
+	if len(b.bucket[bucket]) <= MaxLink {
+		num = len(b.bucket[bucket])
 	}
-	return nodes
+	return selectByScore(b.bucket[bucket], num, envScore(b.env))
 }
 
-// RandomOuterPeers random peers from overstory.
+// RandomOuterPeers random peers from overstory, higher scored peers first.
 func (b *Bucket) randomOuterPeers(num int, ids []common.Address) (nodes []common.Address) {
 	if len(ids) <= MaxLink {
-		return ids
-	}
-
-	randoms := Random(len(ids), num)
-	for _, ran := range randoms {
-		for index := range ids {
-			if ran == index {
-				nodes = append(nodes, ids[index])
-			}
-		}
+		num = len(ids)
 	}
-	return nodes
+	return selectByScore(ids, num, envScore(b.env))
 }
 
 // Random a int number.
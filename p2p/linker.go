@@ -9,7 +9,6 @@ import (
 	"sync"
 	"time"
 
-	"github.com/MatrixAINetwork/go-matrix/ca"
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/event"
 	"github.com/MatrixAINetwork/go-matrix/log"
@@ -37,6 +36,14 @@ type Linker struct {
 
 	topNode      map[common.RoleType]map[common.Address][]uint8
 	topNodeCache map[common.RoleType]map[common.Address][]uint8
+
+	env NetEnv
+
+	policyMu sync.RWMutex
+	policy   *linkPolicy
+
+	sentries map[discover.NodeID]struct{} // 隐藏在哨兵之后时只连接的哨兵节点
+	protect  []common.Address             // 作为哨兵时代理的验证者
 }
 
 type NodeAliveInfo struct {
@@ -44,17 +51,26 @@ type NodeAliveInfo struct {
 	Position   uint16
 	Type       common.RoleType
 	Heartbeats []uint8
+	Reachable  bool // 当前是否与该节点相连
 }
 
 const MaxLinkers = 1000
 
-var Link = &Linker{
-	role:         common.RoleNil,
-	selfPeer:     make(map[common.RoleType][]*Peer),
-	quit:         make(chan struct{}),
-	activeQuit:   make(chan struct{}),
-	topNode:      make(map[common.RoleType]map[common.Address][]uint8),
-	topNodeCache: make(map[common.RoleType]map[common.Address][]uint8),
+var Link = NewLinker(p2pEnv{})
+
+// NewLinker 创建使用env维护连接的顶层节点连接器，节点运行时使用Link
+func NewLinker(env NetEnv) *Linker {
+	policy, _ := LinkPolicy{}.compile()
+	return &Linker{
+		role:         common.RoleNil,
+		selfPeer:     make(map[common.RoleType][]*Peer),
+		quit:         make(chan struct{}),
+		activeQuit:   make(chan struct{}),
+		topNode:      make(map[common.RoleType]map[common.Address][]uint8),
+		topNodeCache: make(map[common.RoleType]map[common.Address][]uint8),
+		env:          env,
+		policy:       policy,
+	}
 }
 
 var (
@@ -86,17 +102,9 @@ func (l *Linker) Start() {
 					continue
 				}
 
-				if r.Role <= common.RoleBucket {
-					l.role = common.RoleNil
+				if !l.Maintain(r.Role) {
 					break
 				}
-				if l.role != r.Role {
-					l.role = r.Role
-				}
-				dropNodes := ca.GetDropNode()
-				l.dropNode(dropNodes)
-
-				l.maintainPeer()
 
 				if r.BroadCastInterval.IsReElectionNumber(height) {
 					l.topNodeCache = l.topNode
@@ -178,6 +186,70 @@ func (l *Linker) initTopNodeMapCache() {
 	l.topMu.Unlock()
 }
 
+// Maintain 按连接策略和本节点角色维护顶层节点的连接：断开拓扑中移除的节点，连接同级及更高级的节点。
+// 连接策略对任何角色都生效，角色不高于桶节点时不维护拓扑连接，返回false
+func (l *Linker) Maintain(role common.RoleType) bool {
+	l.applyPolicy()
+	if len(l.sentries) > 0 {
+		l.maintainHidden()
+		if role <= common.RoleBucket {
+			l.role = common.RoleNil
+			return false
+		}
+		l.role = role
+		return true
+	}
+	if role <= common.RoleBucket {
+		l.role = common.RoleNil
+		// 哨兵代替被保护的验证者连接全部顶层节点
+		if l.protecting() {
+			l.dropNode(l.env.DropNodes())
+			l.link(common.RoleBackupMiner)
+		}
+		return false
+	}
+	if l.role != role {
+		l.role = role
+	}
+	l.dropNode(l.env.DropNodes())
+	l.maintainPeer()
+	return true
+}
+
+// SetSentry 设置哨兵模式：sentries非空时本节点是隐藏在这些哨兵之后的验证者，只与哨兵相连；
+// protect非空时本节点是这些验证者的哨兵，被保护的验证者在拓扑中时连接全部顶层节点
+func (l *Linker) SetSentry(sentries []SentryNode, protect []common.Address) {
+	l.sentries = make(map[discover.NodeID]struct{}, len(sentries))
+	for _, sentry := range sentries {
+		l.sentries[sentry.Node.ID] = struct{}{}
+	}
+	l.protect = protect
+}
+
+// maintainHidden 断开哨兵以外的节点，哨兵作为静态节点由拨号维持连接
+func (l *Linker) maintainHidden() {
+	for _, id := range l.env.Peers() {
+		if _, ok := l.sentries[id]; !ok {
+			l.env.RemovePeer(id)
+		}
+	}
+}
+
+// protecting 被保护的验证者是否在当前或下届拓扑中
+func (l *Linker) protecting() bool {
+	if len(l.protect) == 0 {
+		return false
+	}
+	tops := l.env.RolesByGroupWithNextElect(common.RoleValidator | common.RoleBackupValidator | common.RoleMiner | common.RoleBackupMiner)
+	set := addressSet(tops)
+	for _, addr := range l.protect {
+		if _, ok := set[addr]; ok {
+			return true
+		}
+	}
+	return false
+}
+
 // MaintainPeer
 func (l *Linker) maintainPeer() {
 	l.link(l.role)
@@ -186,7 +258,7 @@ func (l *Linker) maintainPeer() {
 // disconnect all peers.
 func (l *Linker) dropNode(drops []common.Address) {
 	for _, drop := range drops {
-		ServerP2p.RemovePeerByAddress(drop)
+		l.env.RemovePeerByAddress(drop)
 	}
 }
 
@@ -195,7 +267,7 @@ func (l *Linker) dropNodeDefer(drops []common.Address) {
 	select {
 	case <-time.After(time.Second * 5):
 		for _, drop := range drops {
-			ServerP2p.RemovePeerByAddress(drop)
+			l.env.RemovePeerByAddress(drop)
 		}
 	}
 }
@@ -203,18 +275,19 @@ func (l *Linker) dropNodeDefer(drops []common.Address) {
 // Link peers that should to link.
 // link peers by group
 func (l *Linker) link(roleType common.RoleType) {
-	all := ca.GetTopologyInLinker()
+	policy := l.getPolicy()
+	all := l.env.TopologyInLinker()
 	for key, peers := range all {
 		if key >= roleType {
-			for _, peer := range peers {
-				ServerP2p.AddPeerTask(peer)
+			for _, peer := range policy.limit(key, peers) {
+				l.env.AddPeer(peer)
 			}
 		}
 	}
 	if roleType&(common.RoleValidator|common.RoleBackupValidator) != 0 {
-		gap := ca.GetGapValidator()
+		gap := policy.allow(l.env.GapValidators())
 		for _, val := range gap {
-			ServerP2p.AddPeerTask(val)
+			l.env.AddPeer(val)
 		}
 	}
 }
@@ -239,10 +312,10 @@ func (l *Linker) recordTopNodeActiveInfo() {
 	defer l.topMu.Unlock()
 
 	for i := int(common.RoleBackupMiner); i <= int(common.RoleValidator); i = i << 1 {
-		topNodes := ca.GetRolesByGroup(common.RoleType(i))
+		topNodes := l.env.RolesByGroup(common.RoleType(i))
 
 		for _, tn := range topNodes {
-			if tn == ServerP2p.ManAddress {
+			if tn == l.env.Self() {
 				continue
 			}
 			if _, ok := l.topNode[common.RoleType(i)][tn]; !ok {
@@ -254,9 +327,9 @@ func (l *Linker) recordTopNodeActiveInfo() {
 	for role := range l.topNode {
 		for key := range l.topNode[role] {
 			ok := false
-			for _, peer := range ServerP2p.Peers() {
-				id := ServerP2p.ConvertAddressToId(key)
-				if id != EmptyNodeId && peer.ID() == id {
+			for _, peer := range l.env.Peers() {
+				id := l.env.IdOf(key)
+				if id != EmptyNodeId && peer == id {
 					ok = true
 				}
 			}
@@ -274,24 +347,32 @@ func (l *Linker) recordTopNodeActiveInfo() {
 
 // GetTopNodeAliveInfo
 func GetTopNodeAliveInfo(roleType common.RoleType) (result []NodeAliveInfo) {
-	Link.topMu.RLock()
-	defer Link.topMu.RUnlock()
-
-	if len(Link.topNode) <= 0 {
-		for key, val := range Link.topNodeCache {
-			if (key & roleType) != 0 {
-				for signAddr, hearts := range val {
-					result = append(result, NodeAliveInfo{Account: signAddr, Heartbeats: hearts})
-				}
-			}
-		}
-		return
+	return Link.TopNodeAliveInfo(roleType)
+}
+
+// TopNodeAliveInfo 返回角色属于roleType的顶层节点的心跳记录和当前是否相连
+func (l *Linker) TopNodeAliveInfo(roleType common.RoleType) (result []NodeAliveInfo) {
+	connected := make(map[discover.NodeID]struct{})
+	for _, id := range l.env.Peers() {
+		connected[id] = struct{}{}
+	}
+	reachable := func(addr common.Address) bool {
+		id := l.env.IdOf(addr)
+		_, ok := connected[id]
+		return ok && id != EmptyNodeId
 	}
 
-	for key, vals := range Link.topNode {
+	l.topMu.RLock()
+	defer l.topMu.RUnlock()
+
+	nodes := l.topNode
+	if len(nodes) <= 0 {
+		nodes = l.topNodeCache
+	}
+	for key, vals := range nodes {
 		if (key & roleType) != 0 {
 			for signAddr, val := range vals {
-				result = append(result, NodeAliveInfo{Account: signAddr, Heartbeats: val})
+				result = append(result, NodeAliveInfo{Account: signAddr, Type: key, Heartbeats: val, Reachable: reachable(signAddr)})
 			}
 		}
 	}
@@ -299,25 +380,24 @@ func GetTopNodeAliveInfo(roleType common.RoleType) (result []NodeAliveInfo) {
 }
 
 func (l *Linker) ToLink() {
-	Link.mu.Lock()
-	defer Link.mu.Unlock()
+	l.mu.Lock()
+	defer l.mu.Unlock()
 
 	l.linkMap = make(map[common.Address]uint32)
-	h := ca.GetHash()
-	elects, _ := ca.GetElectedByHeightByHash(h)
+	elects := l.getPolicy().allow(l.env.Elected())
 
 	if len(elects) <= MaxLinkers {
 		for _, elect := range elects {
-			ServerP2p.AddPeerTask(elect.SignAddress)
-			l.linkMap[elect.SignAddress] = 0
+			l.env.AddPeer(elect)
+			l.linkMap[elect] = 0
 		}
 		return
 	}
 
 	randoms := Random(len(elects), MaxLinkers)
 	for _, index := range randoms {
-		ServerP2p.AddPeerTask(elects[index].SignAddress)
-		l.linkMap[elects[index].SignAddress] = 0
+		l.env.AddPeer(elects[index])
+		l.linkMap[elects[index]] = 0
 	}
 }
 
@@ -0,0 +1,184 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package p2p
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/p2p/discover"
+)
+
+var errPolicyConflict = errors.New("address is both static and denied")
+
+// LinkPolicy 运维配置的顶层节点连接策略，在拓扑决定的连接之外生效。
+// 验证者位于哨兵节点之后时，哨兵和验证者互相配置为Static，验证者通过Deny和RoleCaps限制其他连接
+type LinkPolicy struct {
+	// Static 始终连接的节点账户，不论本节点和对方当前的角色
+	Static []common.Address `toml:",omitempty" json:"static"`
+	// Trusted 不计入角色连接数上限的节点账户
+	Trusted []common.Address `toml:",omitempty" json:"trusted"`
+	// Deny 不连接的节点账户，不主动连接，也在握手时拒绝其连入，已建立的连接在下一个区块断开
+	Deny []common.Address `toml:",omitempty" json:"deny"`
+	// RoleCaps 每个角色最多主动连接的拓扑节点数，键为角色名(validator、backup validator、miner、backup miner)，未配置的角色不限制
+	RoleCaps map[string]int `toml:",omitempty" json:"roleCaps"`
+}
+
+// linkPolicy 校验后的连接策略
+type linkPolicy struct {
+	config  LinkPolicy
+	static  map[common.Address]struct{}
+	trusted map[common.Address]struct{}
+	deny    map[common.Address]struct{}
+	caps    map[common.RoleType]int
+}
+
+// policyRoles 可以配置连接数上限的角色
+var policyRoles = []common.RoleType{
+	common.RoleValidator,
+	common.RoleBackupValidator,
+	common.RoleMiner,
+	common.RoleBackupMiner,
+}
+
+func addressSet(list []common.Address) map[common.Address]struct{} {
+	set := make(map[common.Address]struct{}, len(list))
+	for _, addr := range list {
+		set[addr] = struct{}{}
+	}
+	return set
+}
+
+// compile 校验策略并建立索引
+func (p LinkPolicy) compile() (*linkPolicy, error) {
+	policy := &linkPolicy{
+		config:  p,
+		static:  addressSet(p.Static),
+		trusted: addressSet(p.Trusted),
+		deny:    addressSet(p.Deny),
+		caps:    make(map[common.RoleType]int),
+	}
+	for addr := range policy.static {
+		if _, ok := policy.deny[addr]; ok {
+			return nil, fmt.Errorf("%v: %s", errPolicyConflict, addr.Hex())
+		}
+	}
+	for name, limit := range p.RoleCaps {
+		if limit < 0 {
+			return nil, fmt.Errorf("negative connection cap %d for role %q", limit, name)
+		}
+		found := false
+		for _, role := range policyRoles {
+			if role.String() == name {
+				policy.caps[role] = limit
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown role %q in connection caps", name)
+		}
+	}
+	return policy, nil
+}
+
+func (p *linkPolicy) isStatic(addr common.Address) bool {
+	_, ok := p.static[addr]
+	return ok
+}
+
+func (p *linkPolicy) isTrusted(addr common.Address) bool {
+	_, ok := p.trusted[addr]
+	return ok
+}
+
+func (p *linkPolicy) isDenied(addr common.Address) bool {
+	_, ok := p.deny[addr]
+	return ok
+}
+
+// allow 过滤掉被拒绝的节点
+func (p *linkPolicy) allow(addrs []common.Address) []common.Address {
+	if len(p.deny) == 0 {
+		return addrs
+	}
+	result := make([]common.Address, 0, len(addrs))
+	for _, addr := range addrs {
+		if !p.isDenied(addr) {
+			result = append(result, addr)
+		}
+	}
+	return result
+}
+
+// limit 按角色连接数上限截取需要连接的节点，静态节点和信任节点不计数
+func (p *linkPolicy) limit(role common.RoleType, addrs []common.Address) []common.Address {
+	addrs = p.allow(addrs)
+	max, ok := p.caps[role]
+	if !ok {
+		return addrs
+	}
+	result := make([]common.Address, 0, len(addrs))
+	count := 0
+	for _, addr := range addrs {
+		if p.isStatic(addr) || p.isTrusted(addr) {
+			result = append(result, addr)
+			continue
+		}
+		if count < max {
+			result = append(result, addr)
+			count++
+		}
+	}
+	return result
+}
+
+// allowPeer 握手时检查对方节点是否被拒绝，地址未知的节点不在此拒绝
+func (l *Linker) allowPeer(id discover.NodeID) bool {
+	addr := l.env.AddressOf(id)
+	if addr == EmptyAddress {
+		return true
+	}
+	return !l.getPolicy().isDenied(addr)
+}
+
+// SetPolicy 校验并替换连接策略，从下一个区块开始按新策略维护连接
+func (l *Linker) SetPolicy(policy LinkPolicy) error {
+	compiled, err := policy.compile()
+	if err != nil {
+		return err
+	}
+	l.policyMu.Lock()
+	l.policy = compiled
+	l.policyMu.Unlock()
+	log.Info("p2p link", "更新连接策略, static", len(policy.Static), "trusted", len(policy.Trusted), "deny", len(policy.Deny), "caps", len(policy.RoleCaps))
+	return nil
+}
+
+// Policy 当前的连接策略
+func (l *Linker) Policy() LinkPolicy {
+	return l.getPolicy().config
+}
+
+func (l *Linker) getPolicy() *linkPolicy {
+	l.policyMu.RLock()
+	defer l.policyMu.RUnlock()
+	return l.policy
+}
+
+// applyPolicy 断开被拒绝的节点，连接静态节点
+func (l *Linker) applyPolicy() {
+	policy := l.getPolicy()
+	for _, addr := range policy.config.Deny {
+		l.env.RemovePeerByAddress(addr)
+	}
+	for _, addr := range policy.config.Static {
+		if addr != l.env.Self() {
+			l.env.AddPeer(addr)
+		}
+	}
+}
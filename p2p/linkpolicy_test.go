@@ -0,0 +1,145 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package p2p
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/p2p/discover"
+)
+
+// policyEnv 只记录连接操作的NetEnv
+type policyEnv struct {
+	self   common.Address
+	topo   map[common.RoleType][]common.Address
+	linked map[common.Address]bool
+}
+
+func newPolicyEnv(topo map[common.RoleType][]common.Address) *policyEnv {
+	return &policyEnv{self: common.BigToAddress(common.Big1), topo: topo, linked: make(map[common.Address]bool)}
+}
+
+func (e *policyEnv) Self() common.Address    { return e.self }
+func (e *policyEnv) SelfID() discover.NodeID { return EmptyNodeId }
+func (e *policyEnv) Peers() []discover.NodeID {
+	ids := make([]discover.NodeID, 0)
+	for addr := range e.linked {
+		ids = append(ids, e.IdOf(addr))
+	}
+	return ids
+}
+func (e *policyEnv) AddressOf(id discover.NodeID) common.Address {
+	return common.BytesToAddress(id[:common.AddressLength])
+}
+func (e *policyEnv) IdOf(addr common.Address) discover.NodeID {
+	var id discover.NodeID
+	copy(id[:], addr[:])
+	return id
+}
+func (e *policyEnv) AddPeer(addr common.Address)   { e.linked[addr] = true }
+func (e *policyEnv) RemovePeer(id discover.NodeID) { delete(e.linked, e.AddressOf(id)) }
+func (e *policyEnv) RemovePeerByAddress(addr common.Address) {
+	delete(e.linked, addr)
+}
+func (e *policyEnv) RolesByGroup(roles common.RoleType) []common.Address {
+	result := make([]common.Address, 0)
+	for role, addrs := range e.topo {
+		if role&roles != 0 {
+			result = append(result, addrs...)
+		}
+	}
+	return result
+}
+func (e *policyEnv) RolesByGroupWithNextElect(roles common.RoleType) []common.Address {
+	return e.RolesByGroup(roles)
+}
+func (e *policyEnv) TopologyInLinker() map[common.RoleType][]common.Address { return e.topo }
+func (e *policyEnv) GapValidators() []common.Address                        { return nil }
+func (e *policyEnv) DropNodes() []common.Address                            { return nil }
+func (e *policyEnv) Elected() []common.Address                              { return e.RolesByGroup(common.RoleAll) }
+
+func policyAddrs(start, n int64) []common.Address {
+	addrs := make([]common.Address, 0, n)
+	for i := start; i < start+n; i++ {
+		addrs = append(addrs, common.BigToAddress(big.NewInt(i)))
+	}
+	return addrs
+}
+
+func TestLinkPolicy(t *testing.T) {
+	validators, miners := policyAddrs(10, 4), policyAddrs(20, 6)
+	env := newPolicyEnv(map[common.RoleType][]common.Address{
+		common.RoleValidator: validators,
+		common.RoleMiner:     miners,
+	})
+	static := common.BigToAddress(big.NewInt(99))
+	l := NewLinker(env)
+	err := l.SetPolicy(LinkPolicy{
+		Static:   []common.Address{static},
+		Trusted:  []common.Address{miners[5]},
+		Deny:     []common.Address{validators[1]},
+		RoleCaps: map[string]int{"miner": 2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env.linked[validators[1]] = true
+	if !l.Maintain(common.RoleMiner) {
+		t.Fatal("miner not maintained")
+	}
+	want := []common.Address{static, validators[0], validators[2], validators[3], miners[0], miners[1], miners[5]}
+	for _, addr := range want {
+		if !env.linked[addr] {
+			t.Errorf("%x not linked", addr)
+		}
+	}
+	if len(env.linked) != len(want) {
+		t.Errorf("linked %d nodes, want %d", len(env.linked), len(want))
+	}
+
+	// 桶节点不维护拓扑连接，仍然连接静态节点
+	env.linked = make(map[common.Address]bool)
+	if l.Maintain(common.RoleBucket) || len(env.linked) != 1 || !env.linked[static] {
+		t.Fatalf("bucket linked %v", env.linked)
+	}
+
+	// 被拒绝的节点在握手时被拒绝连入
+	if l.allowPeer(env.IdOf(validators[1])) || !l.allowPeer(env.IdOf(validators[0])) {
+		t.Error("inbound deny check")
+	}
+
+	l.initTopNodeMap()
+	l.recordTopNodeActiveInfo()
+	env.linked[validators[0]] = true
+	for _, info := range l.TopNodeAliveInfo(common.RoleValidator) {
+		if info.Type != common.RoleValidator || info.Reachable != (info.Account == validators[0]) {
+			t.Errorf("alive info %+v", info)
+		}
+	}
+}
+
+func TestLinkPolicyInvalid(t *testing.T) {
+	addr := common.BigToAddress(common.Big1)
+	l := NewLinker(newPolicyEnv(nil))
+	if err := l.SetPolicy(LinkPolicy{RoleCaps: map[string]int{"miner": 3}}); err != nil {
+		t.Fatal(err)
+	}
+	invalid := []LinkPolicy{
+		{Static: []common.Address{addr}, Deny: []common.Address{addr}},
+		{RoleCaps: map[string]int{"broadcaster": 1}},
+		{RoleCaps: map[string]int{"validator": -1}},
+	}
+	for i, policy := range invalid {
+		if err := l.SetPolicy(policy); err == nil {
+			t.Errorf("policy %d accepted", i)
+		}
+	}
+	if l.Policy().RoleCaps["miner"] != 3 {
+		t.Fatal("invalid policy replaced current policy")
+	}
+}
@@ -103,17 +103,22 @@ func SendToSingle(addr common.Address, msgCode uint64, data interface{}) error {
 		return nil
 	}
 	id := ServerP2p.ConvertAddressToId(addr)
+	if id != EmptyNodeId {
+		peers := ServerP2p.Peers()
+		for _, peer := range peers {
+			if id == peer.ID() {
+				return Send(peer.MsgReadWriter(), msgCode, data)
+			}
+		}
+	}
+	// 没有直接连接时经哨兵转发
+	if err := Sentry.Relay(addr, msgCode, data); err != errSentryNoRoute {
+		return err
+	}
 	if id == EmptyNodeId {
 		log.Error("send to single peer failed, id convert failed", "peer addr", addr)
 		return ErrCanNotConvert
 	}
-
-	peers := ServerP2p.Peers()
-	for _, peer := range peers {
-		if id == peer.ID() {
-			return Send(peer.MsgReadWriter(), msgCode, data)
-		}
-	}
 	return ErrCanNotFindPeer
 }
 
@@ -126,20 +131,27 @@ func SendToGroupWithBackup(to common.RoleType, msgCode uint64, data interface{})
 			continue
 		}
 		id := ServerP2p.ConvertAddressToId(addr)
-		if id == EmptyNodeId {
+		if id == EmptyNodeId && !Sentry.Routed(addr) {
 			log.Error("send to single peer failed, id convert failed", "peer addr", addr)
 			continue
 		}
 
+		bSend := false
 		for _, peer := range peers {
 			if id == peer.ID() {
 				err := Send(peer.MsgReadWriter(), msgCode, data)
 				if err != nil {
 					log.Error("send to group with backup", "error:", err)
 				}
+				bSend = true
 				break
 			}
 		}
+		if !bSend {
+			if err := Sentry.Relay(addr, msgCode, data); err != nil && err != errSentryNoRoute {
+				log.Error("send to group with backup", "sentry relay error", err)
+			}
+		}
 	}
 
 	return nil
@@ -157,7 +169,7 @@ func SendToGroup(to common.RoleType, msgCode uint64, data interface{}) error {
 		bSend := false
 
 		id := ServerP2p.ConvertAddressToId(addr)
-		if id == EmptyNodeId {
+		if id == EmptyNodeId && !Sentry.Routed(addr) {
 			log.Error("send to single peer failed, id convert failed", "peer addr", addr)
 			continue
 		}
@@ -173,6 +185,9 @@ func SendToGroup(to common.RoleType, msgCode uint64, data interface{}) error {
 				break
 			}
 		}
+		if !bSend && Sentry.Relay(addr, msgCode, data) == nil {
+			bSend = true
+		}
 		if !bSend {
 			log.Error("message.go", "该节点未发送成功 nodeId", id)
 		}
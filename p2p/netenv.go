@@ -0,0 +1,99 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package p2p
+
+import (
+	"github.com/MatrixAINetwork/go-matrix/ca"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/p2p/discover"
+)
+
+// NetEnv Bucket和Linker维护连接时使用的节点身份、拓扑和连接操作。
+// 节点运行时使用ServerP2p和ca(p2pEnv)，拓扑仿真(p2p/simulations/topology)为每个虚拟节点提供自己的实现
+type NetEnv interface {
+	Self() common.Address
+	SelfID() discover.NodeID
+	// Peers 返回已连接的节点
+	Peers() []discover.NodeID
+	AddressOf(id discover.NodeID) common.Address
+	IdOf(addr common.Address) discover.NodeID
+	// AddPeer 添加连接任务
+	AddPeer(addr common.Address)
+	// RemovePeer 断开连接，不删除连接任务
+	RemovePeer(id discover.NodeID)
+	// RemovePeerByAddress 删除连接任务并断开连接
+	RemovePeerByAddress(addr common.Address)
+
+	RolesByGroup(roles common.RoleType) []common.Address
+	RolesByGroupWithNextElect(roles common.RoleType) []common.Address
+	// TopologyInLinker 返回当前拓扑及下届选举的顶层节点，并记录下来用于计算DropNodes
+	TopologyInLinker() map[common.RoleType][]common.Address
+	GapValidators() []common.Address
+	// DropNodes 返回上次TopologyInLinker中有、本次没有的节点
+	DropNodes() []common.Address
+	// Elected 返回当前区块的全部抵押节点
+	Elected() []common.Address
+}
+
+type p2pEnv struct{}
+
+func (p2pEnv) Self() common.Address { return ServerP2p.ManAddress }
+
+func (p2pEnv) SelfID() discover.NodeID { return ServerP2p.Self().ID }
+
+func (p2pEnv) Peers() []discover.NodeID {
+	peers := ServerP2p.Peers()
+	ids := make([]discover.NodeID, 0, len(peers))
+	for _, peer := range peers {
+		ids = append(ids, peer.ID())
+	}
+	return ids
+}
+
+func (p2pEnv) AddressOf(id discover.NodeID) common.Address { return ServerP2p.ConvertIdToAddress(id) }
+
+func (p2pEnv) IdOf(addr common.Address) discover.NodeID { return ServerP2p.ConvertAddressToId(addr) }
+
+func (p2pEnv) AddPeer(addr common.Address) { ServerP2p.AddPeerTask(addr) }
+
+func (p2pEnv) RemovePeer(id discover.NodeID) { ServerP2p.RemovePeer(discover.NewNode(id, nil, 0, 0)) }
+
+func (p2pEnv) RemovePeerByAddress(addr common.Address) { ServerP2p.RemovePeerByAddress(addr) }
+
+func (p2pEnv) RolesByGroup(roles common.RoleType) []common.Address {
+	return ca.GetRolesByGroup(roles)
+}
+
+func (p2pEnv) RolesByGroupWithNextElect(roles common.RoleType) []common.Address {
+	return ca.GetRolesByGroupWithNextElect(roles)
+}
+
+func (p2pEnv) TopologyInLinker() map[common.RoleType][]common.Address {
+	return ca.GetTopologyInLinker()
+}
+
+func (p2pEnv) GapValidators() []common.Address { return ca.GetGapValidator() }
+
+func (p2pEnv) DropNodes() []common.Address { return ca.GetDropNode() }
+
+func (p2pEnv) Elected() []common.Address {
+	elects, _ := ca.GetElectedByHeightByHash(ca.GetHash())
+	result := make([]common.Address, 0, len(elects))
+	for _, elect := range elects {
+		result = append(result, elect.SignAddress)
+	}
+	return result
+}
+
+// envScore 按节点账户查询评分
+func envScore(env NetEnv) func(common.Address) float64 {
+	return func(addr common.Address) float64 {
+		id := env.IdOf(addr)
+		if id == EmptyNodeId {
+			return 0
+		}
+		return Scores.Score(id)
+	}
+}
@@ -97,6 +97,9 @@ type Peer struct {
 
 	// events receives message send / receive events if set
 	events *event.Feed
+
+	// limiters holds the per-protocol inbound rate limiters, only touched by readLoop
+	limiters map[string]*rateLimiter
 }
 
 // NewPeer returns a peer for testing purposes.
@@ -168,6 +171,12 @@ func newPeer(conn *conn, protocols []Protocol) *Peer {
 		protoErr: make(chan error, len(protomap)+1), // protocols + pingLoop
 		closed:   make(chan struct{}),
 		log:      log.New("id", conn.id, "conn", conn.flags),
+		limiters: make(map[string]*rateLimiter),
+	}
+	for name := range protomap {
+		if limit, ok := ProtocolRateLimits[name]; ok {
+			p.limiters[name] = newRateLimiter(limit)
+		}
 	}
 	return p
 }
@@ -280,6 +289,12 @@ func (p *Peer) handle(msg Msg) error {
 		if err != nil {
 			return fmt.Errorf("msg code out of range: %v", msg.Code)
 		}
+		if p.rateLimited(proto, msg) {
+			rateLimitMeter.Mark(1)
+			Scores.rateLimited(p.ID())
+			p.log.Trace("Dropping rate limited message", "protocol", proto.Name, "code", msg.Code-proto.offset)
+			return msg.Discard()
+		}
 		select {
 		case proto.in <- msg:
 			return nil
@@ -290,6 +305,18 @@ func (p *Peer) handle(msg Msg) error {
 	return nil
 }
 
+// rateLimited reports whether the subprotocol message exceeds the per-peer rate limit.
+func (p *Peer) rateLimited(proto *protoRW, msg Msg) bool {
+	limiter, ok := p.limiters[proto.Name]
+	if !ok {
+		return false
+	}
+	if proto.RateLimitExempt != nil && proto.RateLimitExempt(msg.Code-proto.offset) {
+		return false
+	}
+	return !limiter.allow(msg.ReceivedAt)
+}
+
 func countMatchingProtocols(protocols []Protocol, caps []Cap) int {
 	n := 0
 	for _, cap := range caps {
@@ -419,6 +446,7 @@ type PeerInfo struct {
 		Static        bool   `json:"static"`
 	} `json:"network"`
 	Protocols map[string]interface{} `json:"protocols"` // Sub-protocol specific metadata fields
+	Score     float64                `json:"score"`     // Message quality score, see Scores
 }
 
 // Info gathers and returns a collection of metadata known about a peer.
@@ -440,6 +468,7 @@ func (p *Peer) Info() *PeerInfo {
 	info.Network.Inbound = p.rw.is(inboundConn)
 	info.Network.Trusted = p.rw.is(trustedConn)
 	info.Network.Static = p.rw.is(staticDialedConn)
+	info.Score = Scores.Score(p.ID())
 
 	// Gather all the running protocol infos
 	for _, proto := range p.running {
@@ -40,6 +40,11 @@ type Protocol struct {
 	// about a certain peer in the network. If an info retrieval function is set,
 	// but returns nil, it is assumed that the protocol handshake is still running.
 	PeerInfo func(id discover.NodeID) interface{}
+
+	// RateLimitExempt is an optional filter reporting the message codes
+	// (relative to the protocol offset) that bypass the per-peer rate limit,
+	// such as responses to our own requests.
+	RateLimitExempt func(code uint64) bool
 }
 
 func (p Protocol) cap() Cap {
@@ -0,0 +1,272 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package p2p
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/metrics"
+	"github.com/MatrixAINetwork/go-matrix/p2p/discover"
+)
+
+// MsgClass 参与评分的消息类别
+type MsgClass uint8
+
+const (
+	MsgClassConsensus MsgClass = iota // 算法(共识)消息
+	MsgClassTx                        // 交易
+	MsgClassBlock                     // 区块广播
+	msgClassCount
+)
+
+// MsgResult 消息的处理结果
+type MsgResult uint8
+
+const (
+	MsgUseful    MsgResult = iota // 有效的新消息
+	MsgInvalid                    // 无法解析或校验失败
+	MsgDuplicate                  // 重复消息
+	msgResultCount
+)
+
+// 各类消息处理结果对评分的影响
+var scoreWeights = [msgClassCount][msgResultCount]float64{
+	MsgClassConsensus: {MsgUseful: 1, MsgInvalid: -10, MsgDuplicate: -0.2},
+	MsgClassTx:        {MsgUseful: 0.1, MsgInvalid: -5, MsgDuplicate: -0.05},
+	MsgClassBlock:     {MsgUseful: 2, MsgInvalid: -20, MsgDuplicate: -0.2},
+}
+
+const (
+	rateLimitPenalty = -1 // 每条超出速率限制被丢弃的消息
+	scoreHalfLife    = 10 * time.Minute
+	maxScore         = 100
+	minScore         = -100
+	maxScoredPeers   = 4096
+
+	// DisconnectScore 评分低于该值的桶节点(矿工)被断开，并且不再被选为连接对象
+	DisconnectScore = -50
+
+	// 重复消息最多把评分扣到该值，正常广播难免重复，只靠重复消息不会被断开
+	duplicateScoreFloor = DisconnectScore / 2
+)
+
+var (
+	rateLimitMeter       = metrics.NewRegisteredMeter("p2p/ratelimit/drop", nil)
+	scoreDisconnectMeter = metrics.NewRegisteredMeter("p2p/score/disconnect", nil)
+)
+
+// PeerScore 节点评分及各类消息计数，评分按半衰期向0衰减
+type PeerScore struct {
+	Score   float64                               `json:"score"`
+	Counts  [msgClassCount][msgResultCount]uint64 `json:"counts"`
+	Limited uint64                                `json:"limited"`
+	Updated time.Time                             `json:"updated"`
+}
+
+func (s *PeerScore) decay(now time.Time) {
+	if elapsed := now.Sub(s.Updated); elapsed > 0 && !s.Updated.IsZero() {
+		s.Score *= math.Pow(0.5, float64(elapsed)/float64(scoreHalfLife))
+	}
+	s.Updated = now
+}
+
+func (s *PeerScore) add(delta float64) {
+	s.Score = math.Max(minScore, math.Min(maxScore, s.Score+delta))
+}
+
+type peerScores struct {
+	mu    sync.Mutex
+	peers map[discover.NodeID]*PeerScore
+}
+
+// Scores 全部节点的评分，节点断开后保留，用于重新选择连接对象
+var Scores = &peerScores{peers: make(map[discover.NodeID]*PeerScore)}
+
+// get 返回衰减到当前时间的评分记录，调用者需持有锁
+func (s *peerScores) get(id discover.NodeID, now time.Time) *PeerScore {
+	score, ok := s.peers[id]
+	if !ok {
+		if len(s.peers) >= maxScoredPeers {
+			s.evict()
+		}
+		score = &PeerScore{}
+		s.peers[id] = score
+	}
+	score.decay(now)
+	return score
+}
+
+// evict 删除最久没有更新的记录
+func (s *peerScores) evict() {
+	var (
+		oldest discover.NodeID
+		last   time.Time
+	)
+	for id, score := range s.peers {
+		if last.IsZero() || score.Updated.Before(last) {
+			oldest, last = id, score.Updated
+		}
+	}
+	delete(s.peers, oldest)
+}
+
+// Report 记录节点消息的处理结果
+func (s *peerScores) Report(id discover.NodeID, class MsgClass, result MsgResult) {
+	if class >= msgClassCount || result >= msgResultCount {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	score := s.get(id, time.Now())
+	score.Counts[class][result]++
+	delta := scoreWeights[class][result]
+	if result == MsgDuplicate {
+		delta = math.Max(delta, math.Min(0, duplicateScoreFloor-score.Score))
+	}
+	score.add(delta)
+}
+
+// ReportAddress 按节点账户记录消息处理结果，无法转换为节点ID时忽略
+func (s *peerScores) ReportAddress(addr common.Address, class MsgClass, result MsgResult) {
+	if id := addressToId(addr); id != EmptyNodeId {
+		s.Report(id, class, result)
+	}
+}
+
+// addressToId 节点服务未启动时返回EmptyNodeId
+func addressToId(addr common.Address) discover.NodeID {
+	if ServerP2p == nil || ServerP2p.ntab == nil {
+		return EmptyNodeId
+	}
+	return ServerP2p.ConvertAddressToId(addr)
+}
+
+func (s *peerScores) rateLimited(id discover.NodeID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	score := s.get(id, time.Now())
+	score.Limited++
+	score.add(rateLimitPenalty)
+}
+
+// Score 返回节点当前评分，没有记录时为0
+func (s *peerScores) Score(id discover.NodeID) float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	score, ok := s.peers[id]
+	if !ok {
+		return 0
+	}
+	score.decay(time.Now())
+	return score.Score
+}
+
+// ScoreByAddress 按节点账户返回评分
+func (s *peerScores) ScoreByAddress(addr common.Address) float64 {
+	id := addressToId(addr)
+	if id == EmptyNodeId {
+		return 0
+	}
+	return s.Score(id)
+}
+
+// Get 返回节点评分记录的副本
+func (s *peerScores) Get(id discover.NodeID) (PeerScore, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	score, ok := s.peers[id]
+	if !ok {
+		return PeerScore{}, false
+	}
+	score.decay(time.Now())
+	return *score, true
+}
+
+// selectByScore 从候选节点中选出num个：排除评分低于DisconnectScore的节点，评分高的优先，评分相同时随机
+func selectByScore(candidates []common.Address, num int, scoreOf func(common.Address) float64) []common.Address {
+	type scored struct {
+		addr  common.Address
+		score float64
+	}
+	list := make([]scored, 0, len(candidates))
+	for _, addr := range candidates {
+		if score := scoreOf(addr); score >= DisconnectScore {
+			list = append(list, scored{addr, score})
+		}
+	}
+	rand.Shuffle(len(list), func(i, j int) { list[i], list[j] = list[j], list[i] })
+	sort.SliceStable(list, func(i, j int) bool { return list[i].score > list[j].score })
+	if num > len(list) {
+		num = len(list)
+	}
+	nodes := make([]common.Address, 0, num)
+	for _, item := range list[:num] {
+		nodes = append(nodes, item.addr)
+	}
+	return nodes
+}
+
+// RateLimit 子协议每个连接的消息速率限制
+type RateLimit struct {
+	Rate  float64 // 每秒消息数
+	Burst float64 // 突发消息数上限
+}
+
+// ProtocolRateLimits 按子协议名配置的速率限制，未配置的协议不限制；
+// 子协议可通过Protocol.RateLimitExempt豁免请求的应答等消息
+var ProtocolRateLimits = map[string]RateLimit{
+	"man": {Rate: 500, Burst: 2000},
+}
+
+// rateLimiter 令牌桶
+type rateLimiter struct {
+	limit  RateLimit
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(limit RateLimit) *rateLimiter {
+	return &rateLimiter{limit: limit, tokens: limit.Burst}
+}
+
+func (l *rateLimiter) allow(now time.Time) bool {
+	if !l.last.IsZero() {
+		l.tokens = math.Min(l.limit.Burst, l.tokens+now.Sub(l.last).Seconds()*l.limit.Rate)
+	}
+	l.last = now
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// dropBadPeers 断开候选节点中评分低于DisconnectScore的已连接节点，返回断开的数量
+func dropBadPeers(env NetEnv, candidates []common.Address) int {
+	connected := make(map[discover.NodeID]struct{})
+	for _, id := range env.Peers() {
+		connected[id] = struct{}{}
+	}
+	dropped := 0
+	for _, addr := range candidates {
+		id := env.IdOf(addr)
+		if _, ok := connected[id]; !ok || id == EmptyNodeId {
+			continue
+		}
+		if score := Scores.Score(id); score < DisconnectScore {
+			log.Info("p2p score", "断开评分过低的节点", addr.Hex(), "score", score)
+			scoreDisconnectMeter.Mark(1)
+			env.RemovePeerByAddress(addr)
+			dropped++
+		}
+	}
+	return dropped
+}
@@ -0,0 +1,118 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package p2p
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/p2p/discover"
+)
+
+func TestPeerScore(t *testing.T) {
+	scores := &peerScores{peers: make(map[discover.NodeID]*PeerScore)}
+	id := discover.NodeID{1}
+	for i := 0; i < 10; i++ {
+		scores.Report(id, MsgClassConsensus, MsgUseful)
+	}
+	scores.Report(id, MsgClassBlock, MsgInvalid)
+	score, ok := scores.Get(id)
+	if !ok || score.Counts[MsgClassConsensus][MsgUseful] != 10 || score.Counts[MsgClassBlock][MsgInvalid] != 1 {
+		t.Fatalf("counts = %v", score.Counts)
+	}
+	if score.Score > -9.9 || score.Score < -10.1 {
+		t.Fatalf("score = %v, want -10", score.Score)
+	}
+
+	// 一个半衰期后评分减半
+	scores.peers[id].Updated = time.Now().Add(-scoreHalfLife)
+	if s := scores.Score(id); s > -4.9 || s < -5.1 {
+		t.Fatalf("decayed score = %v, want -5", s)
+	}
+	for i := 0; i < 100; i++ {
+		scores.rateLimited(id)
+	}
+	if s := scores.Score(id); s > minScore+0.1 {
+		t.Fatalf("score = %v, want %v", s, minScore)
+	}
+}
+
+func TestSelectByScore(t *testing.T) {
+	a, b, c, d := common.Address{1}, common.Address{2}, common.Address{3}, common.Address{4}
+	values := map[common.Address]float64{a: 5, b: DisconnectScore - 1, c: 10}
+	scoreOf := func(addr common.Address) float64 { return values[addr] }
+
+	nodes := selectByScore([]common.Address{a, b, c, d}, 2, scoreOf)
+	if len(nodes) != 2 || nodes[0] != c || nodes[1] != a {
+		t.Fatalf("selected = %v, want [c a]", nodes)
+	}
+	// 评分过低的节点不会被选中
+	nodes = selectByScore([]common.Address{a, b, c, d}, 10, scoreOf)
+	if len(nodes) != 3 {
+		t.Fatalf("selected = %v", nodes)
+	}
+	for _, node := range nodes {
+		if node == b {
+			t.Fatal("selected peer below disconnect score")
+		}
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	limiter := newRateLimiter(RateLimit{Rate: 10, Burst: 5})
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		if !limiter.allow(now) {
+			t.Fatalf("message %d limited within burst", i)
+		}
+	}
+	if limiter.allow(now) {
+		t.Fatal("message allowed beyond burst")
+	}
+	// 100ms恢复一个令牌
+	if !limiter.allow(now.Add(100*time.Millisecond)) || limiter.allow(now.Add(100*time.Millisecond)) {
+		t.Fatal("token refill mismatch")
+	}
+}
+
+func TestDuplicateScoreFloor(t *testing.T) {
+	scores := &peerScores{peers: make(map[discover.NodeID]*PeerScore)}
+	id := discover.NodeID{1}
+	for i := 0; i < 1000; i++ {
+		scores.Report(id, MsgClassBlock, MsgDuplicate)
+	}
+	// 只有重复消息时评分不会低于duplicateScoreFloor
+	if s := scores.Score(id); s < duplicateScoreFloor-0.1 || s < DisconnectScore {
+		t.Fatalf("score = %v, want %v", s, duplicateScoreFloor)
+	}
+	// 无效消息照常扣分
+	for i := 0; i < 2; i++ {
+		scores.Report(id, MsgClassBlock, MsgInvalid)
+	}
+	if s := scores.Score(id); s >= DisconnectScore {
+		t.Fatalf("score = %v, want below %v", s, DisconnectScore)
+	}
+}
+
+func TestRateLimitExempt(t *testing.T) {
+	p := &Peer{limiters: map[string]*rateLimiter{"man": newRateLimiter(RateLimit{Rate: 1, Burst: 1})}}
+	proto := &protoRW{
+		Protocol: Protocol{Name: "man", RateLimitExempt: func(code uint64) bool { return code == 4 }},
+		offset:   baseProtocolLength,
+	}
+	now := time.Now()
+	request := Msg{Code: baseProtocolLength + 3, ReceivedAt: now}
+	response := Msg{Code: baseProtocolLength + 4, ReceivedAt: now}
+	if p.rateLimited(proto, request) {
+		t.Fatal("first message limited")
+	}
+	if !p.rateLimited(proto, request) {
+		t.Fatal("message allowed beyond burst")
+	}
+	if p.rateLimited(proto, response) {
+		t.Fatal("exempt response limited")
+	}
+}
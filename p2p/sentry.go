@@ -0,0 +1,436 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package p2p
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/crypto"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/p2p/discover"
+	"github.com/MatrixAINetwork/go-matrix/rlp"
+)
+
+// 哨兵模式：验证者只连接自己的哨兵节点，不运行节点发现和数据报服务，不向其他节点暴露自己的地址。
+// 哨兵按配置的enode地址作为信任的静态节点连接。
+// 验证者定期向哨兵发送凭证(SentryTicket)，哨兵把凭证转发给自己的连接，通告自己代理该验证者账户。
+// 发往验证者的算法消息和交易消息封装为SentryEnvelope经哨兵转发，区块随哨兵的正常区块广播到达验证者。
+// 哨兵只转发发送方账户经过连接握手验证的消息，以及已注册验证者发出的消息。
+
+const (
+	sentryInterval   = 15 * time.Second // 验证者发送凭证、哨兵通告凭证的间隔
+	sentryTicketLife = 2 * time.Minute  // 凭证有效期，过期的路由不再使用
+)
+
+var (
+	errSentryTicket       = errors.New("invalid sentry ticket")
+	errSentryExpired      = errors.New("sentry ticket expired")
+	errSentryNotProtected = errors.New("validator is not protected by this sentry")
+	errSentryUnauthorized = errors.New("unauthorized sentry envelope")
+	errSentryNoRoute      = errors.New("no sentry route")
+	errSentryNoSignature  = errors.New("sentry mode requires a man address signature")
+	errSentryDatagram     = errors.New("node is behind sentries, datagram not allowed")
+	errSentryNode         = errors.New("sentry needs both account and enode")
+)
+
+// SentryNode 隐藏的验证者使用的哨兵。不运行节点发现时无法由账户查到节点，需要同时配置账户和enode地址
+type SentryNode struct {
+	Account common.Address
+	Node    *discover.Node
+}
+
+// SentryTicket 验证者授权哨兵代理自己账户的凭证
+type SentryTicket struct {
+	Validator  common.Address   // 验证者账户
+	NodeID     discover.NodeID  // 验证者节点ID
+	AccountSig common.Signature // 验证者账户对节点ID的签名，与节点发现中使用的签名相同
+	Sentry     common.Address   // 被授权的哨兵账户
+	Expire     uint64           // 过期时间，unix秒
+	Sig        []byte           // 验证者节点私钥对以上内容的签名
+}
+
+func (t *SentryTicket) hash() []byte {
+	data, _ := rlp.EncodeToBytes([]interface{}{t.Validator, t.NodeID, t.AccountSig, t.Sentry, t.Expire})
+	return crypto.Keccak256(data)
+}
+
+func (t *SentryTicket) sign(key *ecdsa.PrivateKey) error {
+	sig, err := crypto.Sign(t.hash(), key)
+	if err != nil {
+		return err
+	}
+	t.Sig = sig
+	return nil
+}
+
+// verify 校验节点私钥签名和账户对节点ID的签名
+func (t *SentryTicket) verify(now time.Time) error {
+	if t.Expire < uint64(now.Unix()) {
+		return errSentryExpired
+	}
+	pub, err := crypto.SigToPub(t.hash(), t.Sig)
+	if err != nil || discover.PubkeyID(pub) != t.NodeID {
+		return errSentryTicket
+	}
+	accountSig := t.AccountSig
+	addr, _, err := crypto.VerifySignWithValidate(common.BytesToHash(t.NodeID.Bytes()).Bytes(), accountSig[:])
+	if err != nil || addr != t.Validator {
+		return errSentryTicket
+	}
+	return nil
+}
+
+// SentryEnvelope 经哨兵转发的消息，Payload为Code对应消息的rlp编码
+type SentryEnvelope struct {
+	From    common.Address
+	To      common.Address
+	Code    uint64
+	Payload []byte
+}
+
+// SentryRouter 哨兵模式下的凭证管理和消息转发
+type SentryRouter struct {
+	mu  sync.RWMutex
+	env NetEnv
+	// send 向已连接的节点发送消息
+	send func(id discover.NodeID, code uint64, data interface{}) error
+	now  func() time.Time
+
+	key        *ecdsa.PrivateKey
+	accountSig common.Signature
+	sentries   map[common.Address]discover.NodeID // 本节点是验证者时使用的哨兵
+	protect    map[common.Address]struct{}        // 本节点是哨兵时代理的验证者
+
+	clients map[common.Address]*SentryTicket                     // 已向本节点注册的验证者
+	routes  map[common.Address]map[discover.NodeID]*SentryTicket // 其他哨兵通告的验证者
+
+	quit chan struct{}
+}
+
+// Sentry 节点运行时的哨兵路由
+var Sentry = NewSentryRouter(p2pEnv{}, sendToPeer)
+
+func NewSentryRouter(env NetEnv, send func(id discover.NodeID, code uint64, data interface{}) error) *SentryRouter {
+	return &SentryRouter{
+		env:      env,
+		send:     send,
+		now:      time.Now,
+		sentries: make(map[common.Address]discover.NodeID),
+		protect:  make(map[common.Address]struct{}),
+		clients:  make(map[common.Address]*SentryTicket),
+		routes:   make(map[common.Address]map[discover.NodeID]*SentryTicket),
+	}
+}
+
+func sendToPeer(id discover.NodeID, code uint64, data interface{}) error {
+	for _, peer := range ServerP2p.Peers() {
+		if peer.ID() == id {
+			return Send(peer.MsgReadWriter(), code, data)
+		}
+	}
+	return ErrCanNotFindPeer
+}
+
+// Configure 设置哨兵模式。sentries非空时本节点是隐藏在这些哨兵之后的验证者，protect非空时本节点是这些验证者的哨兵
+func (s *SentryRouter) Configure(key *ecdsa.PrivateKey, accountSig common.Signature, sentries []SentryNode, protect []common.Address) error {
+	if len(sentries) > 0 && accountSig == (common.Signature{}) {
+		return errSentryNoSignature
+	}
+	sentryIds := make(map[common.Address]discover.NodeID, len(sentries))
+	for _, sentry := range sentries {
+		if sentry.Node == nil || sentry.Account == EmptyAddress {
+			return errSentryNode
+		}
+		sentryIds[sentry.Account] = sentry.Node.ID
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.key = key
+	s.accountSig = accountSig
+	s.sentries = sentryIds
+	s.protect = addressSet(protect)
+	return nil
+}
+
+// Start 验证者和哨兵定期发送凭证，未配置哨兵模式时不启动
+func (s *SentryRouter) Start() {
+	s.mu.Lock()
+	if (len(s.sentries) == 0 && len(s.protect) == 0) || s.quit != nil {
+		s.mu.Unlock()
+		return
+	}
+	s.quit = make(chan struct{})
+	quit := s.quit
+	log.Info("p2p sentry", "启动哨兵模式, 哨兵", len(s.sentries), "代理验证者", len(s.protect))
+	s.mu.Unlock()
+
+	tk := time.NewTicker(sentryInterval)
+	defer tk.Stop()
+	for {
+		s.maintain()
+		select {
+		case <-tk.C:
+		case <-quit:
+			return
+		}
+	}
+}
+
+func (s *SentryRouter) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.quit != nil {
+		close(s.quit)
+		s.quit = nil
+	}
+}
+
+// Hidden 本节点是否隐藏在哨兵之后
+func (s *SentryRouter) Hidden() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.sentries) > 0
+}
+
+// allowPeer 隐藏的验证者只接受哨兵的连接
+func (s *SentryRouter) allowPeer(id discover.NodeID) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if len(s.sentries) == 0 {
+		return true
+	}
+	return s.isSentry(id)
+}
+
+// isSentry id是否为本节点的哨兵，调用方持有锁
+func (s *SentryRouter) isSentry(id discover.NodeID) bool {
+	for _, sentry := range s.sentries {
+		if sentry == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Routed 是否有到达addr的哨兵路由，有路由的节点不直接发送数据报
+func (s *SentryRouter) Routed(addr common.Address) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if len(s.sentries) > 0 {
+		return true
+	}
+	if _, ok := s.clients[addr]; ok {
+		return true
+	}
+	return len(s.routes[addr]) > 0
+}
+
+// maintain 验证者向已连接的哨兵发送凭证，哨兵向其他连接通告已注册的验证者，同时清理过期的凭证
+func (s *SentryRouter) maintain() {
+	now := s.now()
+	connected := s.connected()
+
+	s.mu.Lock()
+	tickets := make(map[discover.NodeID]*SentryTicket)
+	self := s.env.Self()
+	for sentry, id := range s.sentries {
+		if _, ok := connected[id]; !ok {
+			continue
+		}
+		ticket := &SentryTicket{
+			Validator:  self,
+			NodeID:     s.env.SelfID(),
+			AccountSig: s.accountSig,
+			Sentry:     sentry,
+			Expire:     uint64(now.Add(sentryTicketLife).Unix()),
+		}
+		if err := ticket.sign(s.key); err != nil {
+			log.Error("p2p sentry", "签名凭证失败", err)
+			continue
+		}
+		tickets[id] = ticket
+	}
+	announce := make([]*SentryTicket, 0, len(s.clients))
+	for addr, ticket := range s.clients {
+		if ticket.Expire < uint64(now.Unix()) {
+			delete(s.clients, addr)
+			continue
+		}
+		announce = append(announce, ticket)
+	}
+	for addr, routes := range s.routes {
+		for id, ticket := range routes {
+			if ticket.Expire < uint64(now.Unix()) {
+				delete(routes, id)
+			}
+		}
+		if len(routes) == 0 {
+			delete(s.routes, addr)
+		}
+	}
+	s.mu.Unlock()
+
+	for id, ticket := range tickets {
+		if err := s.send(id, common.SentryTicketMsg, ticket); err != nil {
+			log.Debug("p2p sentry", "发送凭证失败", err, "sentry", id.TerminalString())
+		}
+	}
+	for _, ticket := range announce {
+		s.announce(ticket, connected)
+	}
+}
+
+func (s *SentryRouter) connected() map[discover.NodeID]struct{} {
+	peers := make(map[discover.NodeID]struct{})
+	for _, id := range s.env.Peers() {
+		peers[id] = struct{}{}
+	}
+	return peers
+}
+
+// announce 把验证者的凭证发给除验证者以外的连接
+func (s *SentryRouter) announce(ticket *SentryTicket, connected map[discover.NodeID]struct{}) {
+	for id := range connected {
+		if id == ticket.NodeID {
+			continue
+		}
+		if err := s.send(id, common.SentryTicketMsg, ticket); err != nil {
+			log.Debug("p2p sentry", "通告验证者失败", err, "peer", id.TerminalString())
+		}
+	}
+}
+
+// HandleTicket 处理from发来的凭证：验证者向本节点注册，或其他哨兵通告其代理的验证者
+func (s *SentryRouter) HandleTicket(from discover.NodeID, ticket *SentryTicket) error {
+	if err := ticket.verify(s.now()); err != nil {
+		return err
+	}
+	self := s.env.Self()
+	if ticket.Validator == self {
+		return nil
+	}
+
+	if ticket.Sentry == self && ticket.NodeID == from {
+		s.mu.Lock()
+		if _, ok := s.protect[ticket.Validator]; !ok {
+			s.mu.Unlock()
+			return errSentryNotProtected
+		}
+		_, registered := s.clients[ticket.Validator]
+		s.clients[ticket.Validator] = ticket
+		s.mu.Unlock()
+		if !registered {
+			log.Info("p2p sentry", "验证者已注册", ticket.Validator.Hex(), "node", from.TerminalString())
+			s.announce(ticket, s.connected())
+		}
+		return nil
+	}
+
+	// 通告只能由凭证授权的哨兵发出
+	if ticket.Sentry != s.env.AddressOf(from) {
+		return errSentryTicket
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	routes, ok := s.routes[ticket.Validator]
+	if !ok {
+		routes = make(map[discover.NodeID]*SentryTicket)
+		s.routes[ticket.Validator] = routes
+	}
+	routes[from] = ticket
+	return nil
+}
+
+// HandleEnvelope 处理from发来的转发消息，返回消息是否由本节点接收；发往其他节点的消息在这里转发
+func (s *SentryRouter) HandleEnvelope(from discover.NodeID, envelope *SentryEnvelope) (bool, error) {
+	now := uint64(s.now().Unix())
+	sender := s.env.AddressOf(from)
+	self := s.env.Self()
+
+	s.mu.RLock()
+	client, fromClient := s.clients[envelope.From]
+	fromClient = fromClient && client.NodeID == from && client.Expire >= now
+	fromSentry := s.isSentry(from)
+	route, routed := s.routes[envelope.From][from]
+	routed = routed && route.Expire >= now
+	target, toClient := s.clients[envelope.To]
+	s.mu.RUnlock()
+
+	switch {
+	case envelope.To == self:
+		// 直接的连接、本节点的哨兵，或通告了发送方的哨兵
+		if (sender == envelope.From && sender != EmptyAddress) || fromClient || fromSentry || routed {
+			return true, nil
+		}
+		return false, errSentryUnauthorized
+
+	case fromClient:
+		// 注册的验证者发出的消息
+		return false, s.forward(envelope)
+
+	case toClient:
+		// 发往注册的验证者，发送方必须是握手验证过的账户
+		if sender != envelope.From || sender == EmptyAddress {
+			return false, errSentryUnauthorized
+		}
+		return false, s.send(target.NodeID, common.SentryRelayMsg, envelope)
+	}
+	return false, errSentryUnauthorized
+}
+
+// forward 把已注册验证者发出的消息转发给目标节点
+func (s *SentryRouter) forward(envelope *SentryEnvelope) error {
+	id := s.env.IdOf(envelope.To)
+	if _, ok := s.connected()[id]; ok && id != EmptyNodeId {
+		return s.send(id, common.SentryRelayMsg, envelope)
+	}
+	return s.relayEnvelope(envelope)
+}
+
+// Relay 无法直接发送给to时，经哨兵转发，没有可用的哨兵时返回errSentryNoRoute
+func (s *SentryRouter) Relay(to common.Address, code uint64, data interface{}) error {
+	if !s.Routed(to) {
+		return errSentryNoRoute
+	}
+	payload, err := rlp.EncodeToBytes(data)
+	if err != nil {
+		return err
+	}
+	return s.relayEnvelope(&SentryEnvelope{From: s.env.Self(), To: to, Code: code, Payload: payload})
+}
+
+func (s *SentryRouter) relayEnvelope(envelope *SentryEnvelope) error {
+	now := uint64(s.now().Unix())
+	connected := s.connected()
+	candidates := make([]discover.NodeID, 0)
+
+	s.mu.RLock()
+	if client, ok := s.clients[envelope.To]; ok {
+		candidates = append(candidates, client.NodeID)
+	}
+	for id, ticket := range s.routes[envelope.To] {
+		if ticket.Expire >= now {
+			candidates = append(candidates, id)
+		}
+	}
+	for _, id := range s.sentries {
+		candidates = append(candidates, id)
+	}
+	s.mu.RUnlock()
+
+	for _, id := range candidates {
+		if _, ok := connected[id]; !ok || id == EmptyNodeId {
+			continue
+		}
+		if err := s.send(id, common.SentryRelayMsg, envelope); err == nil {
+			return nil
+		}
+	}
+	return errSentryNoRoute
+}
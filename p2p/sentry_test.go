@@ -0,0 +1,230 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package p2p
+
+import (
+	"crypto/ecdsa"
+	"testing"
+	"time"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/crypto"
+	"github.com/MatrixAINetwork/go-matrix/p2p/discover"
+	"github.com/MatrixAINetwork/go-matrix/rlp"
+)
+
+// sentryNet 内存中的节点和连接，消息直接交给对方的SentryRouter处理
+type sentryNet struct {
+	nodes     map[discover.NodeID]*sentryNode
+	delivered map[common.Address][]*SentryEnvelope
+}
+
+type sentryNode struct {
+	net     *sentryNet
+	account common.Address
+	key     *ecdsa.PrivateKey
+	sig     common.Signature
+	id      discover.NodeID
+	peers   map[discover.NodeID]bool
+	router  *SentryRouter
+}
+
+func (net *sentryNet) newNode(t *testing.T) *sentryNode {
+	key, _ := crypto.GenerateKey()
+	accountKey, _ := crypto.GenerateKey()
+	id := discover.PubkeyID(&key.PublicKey)
+	sig, err := crypto.Sign(common.BytesToHash(id.Bytes()).Bytes(), accountKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	node := &sentryNode{
+		net:     net,
+		account: crypto.PubkeyToAddress(accountKey.PublicKey),
+		key:     key,
+		sig:     common.BytesToSignature(sig),
+		id:      id,
+		peers:   make(map[discover.NodeID]bool),
+	}
+	node.router = NewSentryRouter(node, node.send)
+	net.nodes[id] = node
+	return node
+}
+
+func (net *sentryNet) connect(a, b *sentryNode) {
+	a.peers[b.id] = true
+	b.peers[a.id] = true
+}
+
+// send 对方处理消息，发给对方本身的消息记录下来
+func (n *sentryNode) send(id discover.NodeID, code uint64, data interface{}) error {
+	peer, ok := n.net.nodes[id]
+	if !ok || !n.peers[id] {
+		return ErrCanNotFindPeer
+	}
+	raw, err := rlp.EncodeToBytes(data)
+	if err != nil {
+		return err
+	}
+	switch code {
+	case common.SentryTicketMsg:
+		var ticket SentryTicket
+		if err := rlp.DecodeBytes(raw, &ticket); err != nil {
+			return err
+		}
+		return peer.router.HandleTicket(n.id, &ticket)
+	case common.SentryRelayMsg:
+		var envelope SentryEnvelope
+		if err := rlp.DecodeBytes(raw, &envelope); err != nil {
+			return err
+		}
+		deliver, err := peer.router.HandleEnvelope(n.id, &envelope)
+		if deliver {
+			n.net.delivered[peer.account] = append(n.net.delivered[peer.account], &envelope)
+		}
+		return err
+	}
+	return nil
+}
+
+func (n *sentryNode) sentryNode() SentryNode {
+	return SentryNode{Account: n.account, Node: discover.NewNode(n.id, nil, 0, 0)}
+}
+
+func (n *sentryNode) Self() common.Address    { return n.account }
+func (n *sentryNode) SelfID() discover.NodeID { return n.id }
+func (n *sentryNode) Peers() []discover.NodeID {
+	ids := make([]discover.NodeID, 0, len(n.peers))
+	for id := range n.peers {
+		ids = append(ids, id)
+	}
+	return ids
+}
+func (n *sentryNode) AddressOf(id discover.NodeID) common.Address {
+	if node, ok := n.net.nodes[id]; ok {
+		return node.account
+	}
+	return EmptyAddress
+}
+func (n *sentryNode) IdOf(addr common.Address) discover.NodeID {
+	for id, node := range n.net.nodes {
+		if node.account == addr {
+			return id
+		}
+	}
+	return EmptyNodeId
+}
+func (n *sentryNode) AddPeer(addr common.Address)             {}
+func (n *sentryNode) RemovePeer(id discover.NodeID)           {}
+func (n *sentryNode) RemovePeerByAddress(addr common.Address) {}
+func (n *sentryNode) RolesByGroup(roles common.RoleType) []common.Address {
+	return nil
+}
+func (n *sentryNode) RolesByGroupWithNextElect(roles common.RoleType) []common.Address {
+	return nil
+}
+func (n *sentryNode) TopologyInLinker() map[common.RoleType][]common.Address { return nil }
+func (n *sentryNode) GapValidators() []common.Address                        { return nil }
+func (n *sentryNode) DropNodes() []common.Address                            { return nil }
+func (n *sentryNode) Elected() []common.Address                              { return nil }
+
+func TestSentryRelay(t *testing.T) {
+	net := &sentryNet{nodes: make(map[discover.NodeID]*sentryNode), delivered: make(map[common.Address][]*SentryEnvelope)}
+	validator, sentry, remote := net.newNode(t), net.newNode(t), net.newNode(t)
+	net.connect(validator, sentry)
+	net.connect(sentry, remote)
+
+	if err := validator.router.Configure(validator.key, validator.sig, []SentryNode{sentry.sentryNode()}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := sentry.router.Configure(sentry.key, sentry.sig, nil, []common.Address{validator.account}); err != nil {
+		t.Fatal(err)
+	}
+	if remote.router.Routed(validator.account) {
+		t.Fatal("route before ticket")
+	}
+
+	// 验证者注册后，哨兵向remote通告
+	validator.router.maintain()
+	if !remote.router.Routed(validator.account) {
+		t.Fatal("validator not announced")
+	}
+	if err := remote.router.Relay(validator.account, common.AlgorithmMsg, []byte{1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := validator.router.Relay(remote.account, common.AlgorithmMsg, []byte{2}); err != nil {
+		t.Fatal(err)
+	}
+	if got := net.delivered[validator.account]; len(got) != 1 || got[0].From != remote.account {
+		t.Fatalf("validator received %v", got)
+	}
+	if got := net.delivered[remote.account]; len(got) != 1 || got[0].From != validator.account {
+		t.Fatalf("remote received %v", got)
+	}
+	if !validator.router.allowPeer(sentry.id) || validator.router.allowPeer(remote.id) {
+		t.Fatal("hidden validator accepts wrong peers")
+	}
+
+	// 冒充其他账户的消息不转发
+	forged := &SentryEnvelope{From: net.newNode(t).account, To: validator.account, Code: common.AlgorithmMsg}
+	if _, err := sentry.router.HandleEnvelope(remote.id, forged); err != errSentryUnauthorized {
+		t.Fatalf("forged envelope: %v", err)
+	}
+	if len(net.delivered[validator.account]) != 1 {
+		t.Fatal("forged envelope delivered")
+	}
+
+	// 凭证过期后不再路由
+	remote.router.now = func() time.Time { return time.Now().Add(sentryTicketLife + time.Minute) }
+	remote.router.maintain()
+	if remote.router.Routed(validator.account) {
+		t.Fatal("expired route kept")
+	}
+}
+
+func TestSentryTicket(t *testing.T) {
+	net := &sentryNet{nodes: make(map[discover.NodeID]*sentryNode), delivered: make(map[common.Address][]*SentryEnvelope)}
+	validator, sentry, other := net.newNode(t), net.newNode(t), net.newNode(t)
+	net.connect(validator, sentry)
+	net.connect(other, sentry)
+	sentry.router.Configure(sentry.key, sentry.sig, nil, []common.Address{validator.account})
+
+	now := time.Now()
+	ticket := &SentryTicket{Validator: validator.account, NodeID: validator.id, AccountSig: validator.sig, Sentry: sentry.account, Expire: uint64(now.Add(time.Minute).Unix())}
+	if err := ticket.sign(validator.key); err != nil {
+		t.Fatal(err)
+	}
+	if err := ticket.verify(now); err != nil {
+		t.Fatal(err)
+	}
+	if err := ticket.verify(now.Add(2 * time.Minute)); err != errSentryExpired {
+		t.Fatalf("expired ticket: %v", err)
+	}
+
+	// 账户签名与节点不符
+	bad := *ticket
+	bad.AccountSig = other.sig
+	if err := bad.sign(validator.key); err != nil {
+		t.Fatal(err)
+	}
+	if err := sentry.router.HandleTicket(validator.id, &bad); err != errSentryTicket {
+		t.Fatalf("wrong account signature: %v", err)
+	}
+	// 未授权的哨兵通告
+	if err := other.router.HandleTicket(validator.id, ticket); err != errSentryTicket {
+		t.Fatalf("announce from unauthorized sentry: %v", err)
+	}
+	// 哨兵不代理的验证者
+	unprotected := &SentryTicket{Validator: other.account, NodeID: other.id, AccountSig: other.sig, Sentry: sentry.account, Expire: ticket.Expire}
+	unprotected.sign(other.key)
+	if err := sentry.router.HandleTicket(other.id, unprotected); err != errSentryNotProtected {
+		t.Fatalf("unprotected validator: %v", err)
+	}
+	if err := sentry.router.Configure(sentry.key, common.Signature{}, []SentryNode{validator.sentryNode()}, nil); err != errSentryNoSignature {
+		t.Fatalf("hidden without signature: %v", err)
+	}
+	if err := sentry.router.Configure(sentry.key, sentry.sig, []SentryNode{{Account: validator.account}}, nil); err != errSentryNode {
+		t.Fatalf("sentry without enode: %v", err)
+	}
+}
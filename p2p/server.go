@@ -138,6 +138,17 @@ type Config struct {
 	ManAddress common.Address
 	Signature  common.Signature
 	SignTime   time.Time
+
+	// TopNodePolicy 顶层节点连接策略：始终连接的节点、拒绝连接的节点和每个角色的连接数上限，
+	// 运行时可以通过admin接口重新加载
+	TopNodePolicy LinkPolicy `toml:",omitempty"`
+
+	// Sentries 哨兵节点的账户和enode地址。配置后本节点作为验证者隐藏在哨兵之后，只与哨兵相连，
+	// 不运行节点发现和数据报服务，算法消息和交易消息经哨兵转发，需要ManAddress的签名
+	Sentries []SentryNode `toml:",omitempty"`
+
+	// SentryFor 本节点作为哨兵代理的验证者账户，只接受这些验证者的注册
+	SentryFor []common.Address `toml:",omitempty"`
 }
 
 // Server manages all peer connections.
@@ -278,10 +289,17 @@ func (srv *Server) Peers() []*Peer {
 }
 
 func (srv *Server) AddressTable() map[common.Address]*discover.Node {
+	if srv.ntab == nil {
+		return nil
+	}
 	return srv.ntab.GetAllAddress()
 }
 
+// ConvertAddressToId 账户对应的节点，不运行节点发现时返回EmptyNodeId
 func (srv *Server) ConvertAddressToId(addr common.Address) discover.NodeID {
+	if srv.ntab == nil {
+		return EmptyNodeId
+	}
 	node := srv.ntab.ResolveNode(addr, EmptyNodeId)
 	if node != nil {
 		return node.ID
@@ -289,7 +307,11 @@ func (srv *Server) ConvertAddressToId(addr common.Address) discover.NodeID {
 	return EmptyNodeId
 }
 
+// ConvertIdToAddress 节点对应的账户，不运行节点发现时返回EmptyAddress
 func (srv *Server) ConvertIdToAddress(id discover.NodeID) common.Address {
+	if srv.ntab == nil {
+		return EmptyAddress
+	}
 	node := srv.ntab.ResolveNode(EmptyAddress, id)
 	if node != nil {
 		return node.Address
@@ -323,6 +345,10 @@ func (srv *Server) AddPeerByAddress(addr common.Address) {
 		return
 	}
 	srv.log.Info("add peer by address into task", "addr", addr.Hex())
+	if srv.ntab == nil {
+		srv.DelTasks(addr)
+		return
+	}
 	node := srv.ntab.GetNodeByAddress(addr)
 	if node == nil {
 		srv.CouTask(addr)
@@ -353,6 +379,9 @@ func (srv *Server) RemovePeer(node *discover.Node) {
 func (srv *Server) RemovePeerByAddress(addr common.Address) {
 	srv.DelTasks(addr)
 
+	if srv.ntab == nil {
+		return
+	}
 	node := srv.ntab.ResolveNode(addr, EmptyNodeId)
 	if node != nil {
 		srv.RemovePeer(node)
@@ -411,6 +440,8 @@ func (srv *Server) Stop() {
 		srv.listener.Close()
 	}
 	close(srv.quit)
+	Udp.Stop()
+	Sentry.Stop()
 	srv.loopWG.Wait()
 }
 
@@ -460,6 +491,24 @@ func (srv *Server) Start() (err error) {
 	if srv.PrivateKey == nil {
 		return fmt.Errorf("Server.PrivateKey must be set to a non-nil key")
 	}
+	if err := Link.SetPolicy(srv.TopNodePolicy); err != nil {
+		return fmt.Errorf("invalid top node policy: %v", err)
+	}
+	if err := Sentry.Configure(srv.PrivateKey, srv.Signature, srv.Sentries, srv.SentryFor); err != nil {
+		return err
+	}
+	Link.SetSentry(srv.Sentries, srv.SentryFor)
+	if len(srv.Sentries) > 0 {
+		// 节点发现会公布本节点的地址，隐藏的验证者只按enode地址连接哨兵
+		if !srv.NoDiscovery {
+			srv.log.Info("node is behind sentries, discovery disabled")
+			srv.NoDiscovery = true
+		}
+		for _, sentry := range srv.Sentries {
+			srv.StaticNodes = append(srv.StaticNodes, sentry.Node)
+			srv.TrustedNodes = append(srv.TrustedNodes, sentry.Node)
+		}
+	}
 	if srv.newTransport == nil {
 		srv.newTransport = newRLPX
 	}
@@ -570,19 +619,15 @@ func (srv *Server) Start() (err error) {
 	}
 
 	srv.loopWG.Add(1)
-	//add by zw
-	go Receiveudp()
-	go CustSend()
-	//add by zw
 	go srv.run(dialer)
 	go srv.runTask()
 
-	Custsrv = srv
 	srv.running = true
 
 	go Buckets.Start()
 	go Link.Start()
-	go UdpStart()
+	go Sentry.Start()
+	srv.startDatagram()
 
 	return nil
 }
@@ -829,6 +874,10 @@ func (srv *Server) encHandshakeChecks(peers map[discover.NodeID]*Peer, inboundCo
 		return DiscAlreadyConnected
 	case c.id == srv.Self().ID:
 		return DiscSelf
+	case !Sentry.allowPeer(c.id):
+		return DiscUselessPeer
+	case !Link.allowPeer(c.id):
+		return DiscUselessPeer
 	default:
 		return nil
 	}
@@ -0,0 +1,133 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package topology
+
+import (
+	"github.com/MatrixAINetwork/go-matrix/common"
+)
+
+// measure 统计当前的连接
+func (sim *Simulator) measure() Metrics {
+	m := Metrics{Height: sim.height, RejectedDials: sim.rejected}
+	total := 0
+	for _, node := range sim.nodes {
+		count := node.PeerCount()
+		total += count
+		if count > m.MaxPeers {
+			m.MaxPeers = count
+		}
+		if count == 0 {
+			m.Isolated++
+		}
+		if node.Role() == common.RoleBroadcast {
+			m.BroadcastLinks += count
+		}
+	}
+	m.Links = total / 2
+	if len(sim.nodes) > 0 {
+		m.AvgPeers = float64(total) / float64(len(sim.nodes))
+	}
+
+	m.Components, m.Largest = sim.components()
+
+	validators := sim.roles.members(common.RoleValidator, common.RoleBackupValidator)
+	pairs, linked := 0, 0
+	for i, a := range validators {
+		for _, b := range validators[i+1:] {
+			pairs++
+			if sim.nodes[a].Connected(sim.nodes[b]) {
+				linked++
+			}
+		}
+	}
+	m.ValidatorMesh = ratio(linked, pairs)
+
+	toValidator := sim.hops(validators)
+	m.MinerReach, _ = sim.reach(toValidator, common.RoleMiner, common.RoleBackupMiner)
+
+	toTop := sim.hops(sim.roles.members(topRoles...))
+	m.BucketReach, m.BucketHops = sim.reach(toTop, common.RoleBucket)
+	m.DefaultReach, _ = sim.reach(toTop, common.RoleDefault)
+	return m
+}
+
+// hops 从sources出发按连接做广度优先搜索，返回各节点的跳数，不可达为-1
+func (sim *Simulator) hops(sources []int) []int {
+	dist := make([]int, len(sim.nodes))
+	for i := range dist {
+		dist[i] = -1
+	}
+	queue := make([]int, 0, len(sources))
+	for _, i := range sources {
+		dist[i] = 0
+		queue = append(queue, i)
+	}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for i := range sim.nodes[cur].peers {
+			if dist[i] < 0 {
+				dist[i] = dist[cur] + 1
+				queue = append(queue, i)
+			}
+		}
+	}
+	return dist
+}
+
+// reach 返回角色属于roles的节点中可达的比例，以及可达节点的平均跳数
+func (sim *Simulator) reach(dist []int, roles ...common.RoleType) (float64, float64) {
+	members := sim.roles.members(roles...)
+	reached, hops := 0, 0
+	for _, i := range members {
+		if dist[i] >= 0 {
+			reached++
+			hops += dist[i]
+		}
+	}
+	avg := 0.0
+	if reached > 0 {
+		avg = float64(hops) / float64(reached)
+	}
+	return ratio(reached, len(members)), avg
+}
+
+// components 返回连通分量数和最大分量的节点占比
+func (sim *Simulator) components() (int, float64) {
+	seen := make([]bool, len(sim.nodes))
+	count, largest := 0, 0
+	for start := range sim.nodes {
+		if seen[start] {
+			continue
+		}
+		count++
+		size := 0
+		stack := []int{start}
+		seen[start] = true
+		for len(stack) > 0 {
+			cur := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			size++
+			for i := range sim.nodes[cur].peers {
+				if !seen[i] {
+					seen[i] = true
+					stack = append(stack, i)
+				}
+			}
+		}
+		if size > largest {
+			largest = size
+		}
+	}
+	return count, ratio(largest, len(sim.nodes))
+}
+
+// ratio 没有统计对象时为1
+func ratio(part, total int) float64 {
+	if total == 0 {
+		return 1
+	}
+	return float64(part) / float64(total)
+}
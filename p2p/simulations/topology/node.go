@@ -0,0 +1,186 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package topology
+
+import (
+	"sort"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/p2p"
+	"github.com/MatrixAINetwork/go-matrix/p2p/discover"
+)
+
+// Node 虚拟节点，运行自己的p2p.Bucket和p2p.Linker，连接由仿真器直接建立
+type Node struct {
+	Index   int
+	Address common.Address
+	ID      discover.NodeID
+
+	sim    *Simulator
+	bucket *p2p.Bucket
+	linker *p2p.Linker
+	peers  map[int]*Node
+
+	broadcastActive bool
+	// 与ca.GetTopologyInLinker和ca.GetDropNode相同，记录前后两次的顶层节点
+	frontNodes   []common.Address
+	currentNodes []common.Address
+}
+
+func newNode(sim *Simulator, index int, addr common.Address, id discover.NodeID) *Node {
+	node := &Node{
+		Index:   index,
+		Address: addr,
+		ID:      id,
+		sim:     sim,
+		peers:   make(map[int]*Node),
+	}
+	node.bucket = p2p.NewBucket(node)
+	node.linker = p2p.NewLinker(node)
+	return node
+}
+
+// Role 节点当前的角色
+func (n *Node) Role() common.RoleType {
+	return n.sim.roles[n.Index]
+}
+
+// PeerCount 节点当前的连接数
+func (n *Node) PeerCount() int {
+	return len(n.peers)
+}
+
+// Connected 节点是否与other相连
+func (n *Node) Connected(other *Node) bool {
+	_, ok := n.peers[other.Index]
+	return ok
+}
+
+func (n *Node) Self() common.Address { return n.Address }
+
+func (n *Node) SelfID() discover.NodeID { return n.ID }
+
+// Peers 按节点序号返回已连接的节点，保证仿真的处理顺序稳定
+func (n *Node) Peers() []discover.NodeID {
+	index := make([]int, 0, len(n.peers))
+	for i := range n.peers {
+		index = append(index, i)
+	}
+	sort.Ints(index)
+	ids := make([]discover.NodeID, 0, len(index))
+	for _, i := range index {
+		ids = append(ids, n.peers[i].ID)
+	}
+	return ids
+}
+
+func (n *Node) AddressOf(id discover.NodeID) common.Address {
+	if node, ok := n.sim.byID[id]; ok {
+		return node.Address
+	}
+	return p2p.EmptyAddress
+}
+
+func (n *Node) IdOf(addr common.Address) discover.NodeID {
+	if node, ok := n.sim.byAddress[addr]; ok {
+		return node.ID
+	}
+	return p2p.EmptyNodeId
+}
+
+func (n *Node) AddPeer(addr common.Address) {
+	if peer, ok := n.sim.byAddress[addr]; ok {
+		n.sim.connect(n, peer)
+	}
+}
+
+func (n *Node) RemovePeer(id discover.NodeID) {
+	if peer, ok := n.sim.byID[id]; ok {
+		n.sim.disconnect(n, peer)
+	}
+}
+
+func (n *Node) RemovePeerByAddress(addr common.Address) {
+	if peer, ok := n.sim.byAddress[addr]; ok {
+		n.sim.disconnect(n, peer)
+	}
+}
+
+func (n *Node) RolesByGroup(roles common.RoleType) []common.Address {
+	result := make([]common.Address, 0)
+	for role, addrs := range n.sim.groups {
+		if role&roles != 0 {
+			result = append(result, addrs...)
+		}
+	}
+	return result
+}
+
+func (n *Node) RolesByGroupWithNextElect(roles common.RoleType) []common.Address {
+	result := n.RolesByGroup(roles)
+	exist := make(map[common.Address]struct{}, len(result))
+	for _, addr := range result {
+		exist[addr] = struct{}{}
+	}
+	for role, addrs := range n.sim.nextElect {
+		if role&roles == 0 {
+			continue
+		}
+		for _, addr := range addrs {
+			if _, ok := exist[addr]; !ok {
+				exist[addr] = struct{}{}
+				result = append(result, addr)
+			}
+		}
+	}
+	return result
+}
+
+func (n *Node) TopologyInLinker() map[common.RoleType][]common.Address {
+	n.frontNodes = n.currentNodes
+	n.currentNodes = make([]common.Address, 0)
+
+	result := make(map[common.RoleType][]common.Address)
+	exist := make(map[common.Address]struct{})
+	for role, addrs := range n.sim.groups {
+		for _, addr := range addrs {
+			exist[addr] = struct{}{}
+			n.currentNodes = append(n.currentNodes, addr)
+			result[role] = append(result[role], addr)
+		}
+	}
+	for role, addrs := range n.sim.nextElect {
+		for _, addr := range addrs {
+			if _, ok := exist[addr]; !ok {
+				exist[addr] = struct{}{}
+				n.currentNodes = append(n.currentNodes, addr)
+				result[role] = append(result[role], addr)
+			}
+		}
+	}
+	return result
+}
+
+func (n *Node) GapValidators() []common.Address {
+	return n.RolesByGroup(common.RoleValidator | common.RoleBackupValidator)
+}
+
+func (n *Node) DropNodes() []common.Address {
+	current := make(map[common.Address]struct{}, len(n.currentNodes))
+	for _, addr := range n.currentNodes {
+		current[addr] = struct{}{}
+	}
+	result := make([]common.Address, 0)
+	for _, addr := range n.frontNodes {
+		if _, ok := current[addr]; !ok {
+			result = append(result, addr)
+		}
+	}
+	return result
+}
+
+func (n *Node) Elected() []common.Address {
+	return n.sim.elected
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package topology
+
+import (
+	"math/rand"
+	"sort"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+)
+
+// 参与拓扑的顶层角色，按ca中拓扑图的顺序
+var topRoles = []common.RoleType{
+	common.RoleValidator,
+	common.RoleBackupValidator,
+	common.RoleMiner,
+	common.RoleBackupMiner,
+}
+
+func isTop(role common.RoleType) bool {
+	for _, top := range topRoles {
+		if role == top {
+			return true
+		}
+	}
+	return false
+}
+
+// roleState 某一高度全部节点的角色，按节点序号索引
+type roleState []common.RoleType
+
+func (rs roleState) clone() roleState {
+	return append(roleState{}, rs...)
+}
+
+// graph 生成拓扑图，包含验证者、备份验证者、矿工和备份矿工，与ca中的拓扑图一致
+func (rs roleState) graph(nodes []*Node) *mc.TopologyGraph {
+	tg := &mc.TopologyGraph{}
+	for _, role := range topRoles {
+		position := uint16(0)
+		for i, r := range rs {
+			if r == role {
+				tg.NodeList = append(tg.NodeList, mc.TopologyNodeInfo{Account: nodes[i].Address, Position: position, Type: role, NodeNumber: uint8(position)})
+				position++
+			}
+		}
+	}
+	tg.CurNodeNumber = uint8(len(tg.NodeList))
+	return tg
+}
+
+// groups 返回各角色的节点账户，与ca.GetRolesByGroup一致：拓扑图中的节点和广播节点，不含桶节点
+func (rs roleState) groups(nodes []*Node) map[common.RoleType][]common.Address {
+	groups := make(map[common.RoleType][]common.Address)
+	for i, role := range rs {
+		if isTop(role) || role == common.RoleBroadcast {
+			groups[role] = append(groups[role], nodes[i].Address)
+		}
+	}
+	return groups
+}
+
+// members 返回角色属于roles的节点序号
+func (rs roleState) members(roles ...common.RoleType) []int {
+	list := make([]int, 0)
+	for i, role := range rs {
+		for _, r := range roles {
+			if role == r {
+				list = append(list, i)
+				break
+			}
+		}
+	}
+	return list
+}
+
+// reelect 在验证者和矿工中分别重新分配角色，各角色的数量不变
+func (rs roleState) reelect(rnd *rand.Rand) roleState {
+	next := rs.clone()
+	shuffle := func(roles ...common.RoleType) {
+		pool := rs.members(roles...)
+		counts := make(map[common.RoleType]int)
+		for _, i := range pool {
+			counts[rs[i]]++
+		}
+		rnd.Shuffle(len(pool), func(i, j int) { pool[i], pool[j] = pool[j], pool[i] })
+		for _, role := range roles {
+			for k := 0; k < counts[role]; k++ {
+				next[pool[0]] = role
+				pool = pool[1:]
+			}
+		}
+	}
+	shuffle(common.RoleValidator, common.RoleBackupValidator)
+	shuffle(common.RoleMiner, common.RoleBackupMiner, common.RoleBucket)
+	return next
+}
+
+// RoleChange 在Height高度把第Node个节点的角色改为Role，节点序号见Simulator.Nodes
+type RoleChange struct {
+	Height uint64
+	Node   int
+	Role   common.RoleType
+}
+
+func sortChanges(changes []RoleChange) []RoleChange {
+	sorted := append([]RoleChange{}, changes...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Height < sorted[j].Height })
+	return sorted
+}
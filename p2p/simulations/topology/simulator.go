@@ -0,0 +1,306 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+// Package topology 进程内的Matrix拓扑连接仿真。
+// 按配置生成验证者、矿工、广播节点、桶节点和普通节点，每个虚拟节点运行自己的 p2p.Bucket 和 p2p.Linker，
+// 通过 p2p.NetEnv 接入仿真的拓扑和连接表，不建立真实的网络连接。
+// 每个区块按 ca 的方式把角色和桶节点交给 Bucket 和 Linker，按计划执行重选和角色变化，
+// 然后统计连接数、连通分量以及验证者、矿工、桶节点之间的可达性。
+// 连接请求立即成功(除非超过连接数上限)，不模拟握手、延迟和丢包。
+package topology
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"math/rand"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/crypto"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/p2p/discover"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrValidatorNum = errors.New("topology simulation needs at least one validator")
+	ErrChangeNode   = errors.New("role change node index out of range")
+)
+
+type Config struct {
+	Seed             int64 // 决定节点账户和重选结果
+	Validators       int
+	BackupValidators int
+	Miners           int
+	BackupMiners     int
+	Broadcast        int
+	BucketMiners     int // 当选但不在拓扑图中的矿工，进入桶
+	Defaults         int // 普通节点
+	MaxPeers         int // 每个节点的连接数上限，0为不限制
+
+	BCInterval      uint64 // 广播区块间隔，广播节点在广播高度重新连接全部抵押节点
+	ReelectInterval uint64 // 重选间隔，0为不重选
+	NextElectLead   uint64 // 重选前多少个区块公布下届选举结果
+	Changes         []RoleChange
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Seed:             1,
+		Validators:       11,
+		BackupValidators: 5,
+		Miners:           21,
+		BackupMiners:     11,
+		Broadcast:        1,
+		BucketMiners:     400,
+		Defaults:         200,
+		MaxPeers:         10000, // 与pod的默认配置相同
+		BCInterval:       100,
+		ReelectInterval:  300,
+		NextElectLead:    10,
+	}
+}
+
+// Metrics 某一高度的连接统计
+type Metrics struct {
+	Height         uint64
+	Links          int     // 连接数
+	AvgPeers       float64 // 节点平均连接数
+	MaxPeers       int     // 连接数最多的节点的连接数
+	Isolated       int     // 没有任何连接的节点数
+	Components     int     // 连通分量数，孤立节点各算一个
+	Largest        float64 // 最大连通分量的节点占比
+	ValidatorMesh  float64 // 验证者及备份验证者之间直接相连的比例
+	MinerReach     float64 // 矿工及备份矿工中能到达验证者的比例
+	BucketReach    float64 // 桶节点中能到达顶层节点的比例
+	BucketHops     float64 // 能到达的桶节点到最近顶层节点的平均跳数
+	DefaultReach   float64 // 普通节点中能到达顶层节点的比例
+	RejectedDials  int     // 本区块因连接数上限失败的连接请求
+	RoleChanges    int     // 本区块角色发生变化的节点数
+	BroadcastLinks int     // 广播节点的连接数之和
+}
+
+func (m Metrics) String() string {
+	return fmt.Sprintf("height %d: links %d avg %.1f max %d isolated %d components %d largest %.3f validatorMesh %.3f minerReach %.3f bucketReach %.3f bucketHops %.2f defaultReach %.3f rejected %d changes %d",
+		m.Height, m.Links, m.AvgPeers, m.MaxPeers, m.Isolated, m.Components, m.Largest, m.ValidatorMesh, m.MinerReach, m.BucketReach, m.BucketHops, m.DefaultReach, m.RejectedDials, m.RoleChanges)
+}
+
+type Simulator struct {
+	cfg       Config
+	rand      *rand.Rand
+	nodes     []*Node
+	byAddress map[common.Address]*Node
+	byID      map[discover.NodeID]*Node
+
+	height    uint64
+	roles     roleState
+	pending   roleState // 已公布、尚未生效的下届选举
+	changes   []RoleChange
+	groups    map[common.RoleType][]common.Address
+	nextElect map[common.RoleType][]common.Address
+	buckets   []common.Address
+	elected   []common.Address
+	graph     *mc.TopologyGraph
+
+	rejected int
+	history  []Metrics
+}
+
+func New(cfg Config) (*Simulator, error) {
+	if cfg.Validators < 1 {
+		return nil, ErrValidatorNum
+	}
+	sim := &Simulator{
+		cfg:       cfg,
+		rand:      rand.New(rand.NewSource(cfg.Seed)),
+		byAddress: make(map[common.Address]*Node),
+		byID:      make(map[discover.NodeID]*Node),
+		changes:   sortChanges(cfg.Changes),
+		nextElect: make(map[common.RoleType][]common.Address),
+	}
+	counts := []struct {
+		role common.RoleType
+		num  int
+	}{
+		{common.RoleValidator, cfg.Validators},
+		{common.RoleBackupValidator, cfg.BackupValidators},
+		{common.RoleMiner, cfg.Miners},
+		{common.RoleBackupMiner, cfg.BackupMiners},
+		{common.RoleBroadcast, cfg.Broadcast},
+		{common.RoleBucket, cfg.BucketMiners},
+		{common.RoleDefault, cfg.Defaults},
+	}
+	for _, item := range counts {
+		for i := 0; i < item.num; i++ {
+			index := len(sim.nodes)
+			addr, id := simIdentity(cfg.Seed, index)
+			node := newNode(sim, index, addr, id)
+			sim.nodes = append(sim.nodes, node)
+			sim.byAddress[addr] = node
+			sim.byID[id] = node
+			sim.roles = append(sim.roles, item.role)
+		}
+	}
+	for _, change := range sim.changes {
+		if change.Node < 0 || change.Node >= len(sim.nodes) {
+			return nil, ErrChangeNode
+		}
+	}
+	sim.applyRoles(sim.roles)
+	return sim, nil
+}
+
+// simIdentity 由种子和序号生成节点账户和节点ID，不生成私钥，仿真中不需要签名
+func simIdentity(seed int64, index int) (common.Address, discover.NodeID) {
+	buf := make([]byte, 16)
+	binary.BigEndian.PutUint64(buf, uint64(seed))
+	binary.BigEndian.PutUint64(buf[8:], uint64(index))
+	hash := crypto.Keccak256(buf)
+	var id discover.NodeID
+	copy(id[:], hash)
+	copy(id[len(hash):], crypto.Keccak256(hash))
+	return common.BytesToAddress(hash[12:]), id
+}
+
+// Nodes 返回全部节点，顺序为验证者、备份验证者、矿工、备份矿工、广播节点、桶节点、普通节点
+func (sim *Simulator) Nodes() []*Node {
+	return sim.nodes
+}
+
+// NodesByRole 返回当前角色为role的节点
+func (sim *Simulator) NodesByRole(role common.RoleType) []*Node {
+	list := make([]*Node, 0)
+	for _, i := range sim.roles.members(role) {
+		list = append(list, sim.nodes[i])
+	}
+	return list
+}
+
+// Graph 当前的拓扑图
+func (sim *Simulator) Graph() *mc.TopologyGraph {
+	return sim.graph
+}
+
+func (sim *Simulator) Height() uint64 {
+	return sim.height
+}
+
+// History 每个区块的连接统计
+func (sim *Simulator) History() []Metrics {
+	return sim.history
+}
+
+// Run 运行blocks个区块，返回最后一个区块的连接统计
+func (sim *Simulator) Run(blocks uint64) Metrics {
+	var metrics Metrics
+	for i := uint64(0); i < blocks; i++ {
+		metrics = sim.Step()
+	}
+	return metrics
+}
+
+// Step 产生下一个区块：执行重选和角色变化，各节点按新角色维护连接，然后统计连接
+func (sim *Simulator) Step() Metrics {
+	sim.height++
+	sim.rejected = 0
+	changed := sim.updateRoles()
+
+	number := new(big.Int).SetUint64(sim.height)
+	for _, node := range sim.nodes {
+		role := node.Role()
+		node.bucket.HandleBlock(mc.BlockToBucket{Ms: sim.buckets, Height: number, Role: role})
+		if !node.linker.Maintain(role) || role != common.RoleBroadcast {
+			continue
+		}
+		if !node.broadcastActive || (sim.cfg.BCInterval > 0 && sim.height%sim.cfg.BCInterval == 0) {
+			node.linker.ToLink()
+			node.broadcastActive = true
+		}
+	}
+
+	metrics := sim.measure()
+	metrics.RoleChanges = changed
+	sim.history = append(sim.history, metrics)
+	return metrics
+}
+
+// updateRoles 按重选间隔和角色变化计划更新角色，返回角色发生变化的节点数
+func (sim *Simulator) updateRoles() int {
+	next, cloned := sim.roles, false
+	if interval := sim.cfg.ReelectInterval; interval > 0 {
+		lead := sim.cfg.NextElectLead
+		if lead >= interval {
+			lead = interval - 1
+		}
+		if (sim.height+lead)%interval == 0 {
+			sim.pending = sim.roles.reelect(sim.rand)
+			sim.publishNextElect()
+		}
+		if sim.height%interval == 0 && sim.pending != nil {
+			next, cloned = sim.pending, true
+			sim.pending = nil
+			sim.nextElect = make(map[common.RoleType][]common.Address)
+		}
+	}
+	for len(sim.changes) > 0 && sim.changes[0].Height <= sim.height {
+		if !cloned {
+			next, cloned = sim.roles.clone(), true
+		}
+		next[sim.changes[0].Node] = sim.changes[0].Role
+		sim.changes = sim.changes[1:]
+	}
+	changed := 0
+	for i := range next {
+		if next[i] != sim.roles[i] {
+			changed++
+		}
+	}
+	if changed > 0 {
+		sim.applyRoles(next)
+	}
+	return changed
+}
+
+func (sim *Simulator) publishNextElect() {
+	sim.nextElect = make(map[common.RoleType][]common.Address)
+	for i, role := range sim.pending {
+		if isTop(role) {
+			sim.nextElect[role] = append(sim.nextElect[role], sim.nodes[i].Address)
+		}
+	}
+}
+
+func (sim *Simulator) applyRoles(roles roleState) {
+	sim.roles = roles
+	sim.graph = roles.graph(sim.nodes)
+	sim.groups = roles.groups(sim.nodes)
+	sim.buckets = make([]common.Address, 0)
+	sim.elected = make([]common.Address, 0)
+	for i, role := range roles {
+		if role == common.RoleBucket {
+			sim.buckets = append(sim.buckets, sim.nodes[i].Address)
+		}
+		if role == common.RoleBucket || isTop(role) {
+			sim.elected = append(sim.elected, sim.nodes[i].Address)
+		}
+	}
+}
+
+// connect 建立双向连接，任一方连接数已满时失败
+func (sim *Simulator) connect(a, b *Node) {
+	if a == b || a.Connected(b) {
+		return
+	}
+	if max := sim.cfg.MaxPeers; max > 0 && (a.PeerCount() >= max || b.PeerCount() >= max) {
+		sim.rejected++
+		return
+	}
+	a.peers[b.Index] = b
+	b.peers[a.Index] = a
+}
+
+func (sim *Simulator) disconnect(a, b *Node) {
+	delete(a.peers, b.Index)
+	delete(b.peers, a.Index)
+}
@@ -0,0 +1,145 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package topology
+
+import (
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+)
+
+func smallConfig() Config {
+	cfg := DefaultConfig()
+	cfg.BucketMiners = 60
+	cfg.Defaults = 30
+	cfg.BCInterval = 5
+	cfg.ReelectInterval = 0
+	return cfg
+}
+
+func newSim(t *testing.T, cfg Config) *Simulator {
+	sim, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sim
+}
+
+func checkConnected(t *testing.T, m Metrics) {
+	t.Helper()
+	if m.Components != 1 || m.Isolated != 0 {
+		t.Fatalf("network split: %v", m)
+	}
+	if m.ValidatorMesh != 1 || m.MinerReach != 1 || m.BucketReach != 1 || m.DefaultReach != 1 {
+		t.Fatalf("unreachable nodes: %v", m)
+	}
+}
+
+func TestSimulationRoles(t *testing.T) {
+	cfg := smallConfig()
+	sim := newSim(t, cfg)
+	if len(sim.Graph().NodeList) != cfg.Validators+cfg.BackupValidators+cfg.Miners+cfg.BackupMiners {
+		t.Fatalf("topology graph has %d nodes", len(sim.Graph().NodeList))
+	}
+	if len(sim.NodesByRole(common.RoleBucket)) != cfg.BucketMiners || len(sim.NodesByRole(common.RoleBroadcast)) != cfg.Broadcast {
+		t.Fatal("wrong role assignment")
+	}
+
+	checkConnected(t, sim.Run(3))
+	if len(sim.History()) != 3 || sim.Height() != 3 {
+		t.Fatalf("history %d, height %d", len(sim.History()), sim.Height())
+	}
+	// 广播节点连接全部抵押节点
+	broadcast := sim.NodesByRole(common.RoleBroadcast)[0]
+	for _, node := range sim.NodesByRole(common.RoleBucket) {
+		if !broadcast.Connected(node) {
+			t.Fatalf("broadcast not linked to bucket node %d", node.Index)
+		}
+	}
+}
+
+func TestSimulationReelect(t *testing.T) {
+	cfg := smallConfig()
+	cfg.ReelectInterval = 10
+	cfg.NextElectLead = 3
+	sim := newSim(t, cfg)
+	sim.Run(7)
+
+	// 下届选举公布后，验证者提前连接下届的矿工
+	validator := sim.NodesByRole(common.RoleValidator)[0]
+	upcoming := validator.RolesByGroupWithNextElect(common.RoleMiner)
+	if len(upcoming) <= cfg.Miners {
+		t.Fatalf("next elect not published, %d miners", len(upcoming))
+	}
+
+	before := append(roleState{}, sim.roles...)
+	sim.Run(3)
+	last := sim.History()[len(sim.History())-1]
+	if last.RoleChanges == 0 {
+		t.Fatal("no role changes at reelection")
+	}
+	for _, role := range append(topRoles, common.RoleBucket) {
+		if len(before.members(role)) != len(sim.roles.members(role)) {
+			t.Fatalf("role %v count changed", role)
+		}
+	}
+	checkConnected(t, sim.Run(2))
+}
+
+func TestSimulationRoleChange(t *testing.T) {
+	cfg := smallConfig()
+	cfg.Changes = []RoleChange{{Height: 3, Node: 0, Role: common.RoleDefault}}
+	sim := newSim(t, cfg)
+	demoted := sim.Nodes()[0]
+	sim.Run(2)
+	if !demoted.Connected(sim.Nodes()[1]) {
+		t.Fatal("validators not linked")
+	}
+
+	m := sim.Run(2)
+	if sim.History()[2].RoleChanges != 1 || demoted.Role() != common.RoleDefault {
+		t.Fatalf("role change not applied: %v", demoted.Role())
+	}
+	for _, node := range sim.NodesByRole(common.RoleValidator) {
+		if demoted.Connected(node) {
+			t.Fatalf("demoted node still linked to validator %d", node.Index)
+		}
+	}
+	checkConnected(t, m)
+
+	if _, err := New(Config{Validators: 1, Changes: []RoleChange{{Node: 5}}}); err != ErrChangeNode {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestSimulationMaxPeers(t *testing.T) {
+	cfg := smallConfig()
+	cfg.MaxPeers = 8
+	sim := newSim(t, cfg)
+	sim.Run(3)
+	rejected := 0
+	for _, m := range sim.History() {
+		if m.MaxPeers > cfg.MaxPeers {
+			t.Fatalf("node has %d peers", m.MaxPeers)
+		}
+		rejected += m.RejectedDials
+	}
+	if rejected == 0 {
+		t.Fatal("no dial rejected")
+	}
+}
+
+func TestSimulationThousandsOfNodes(t *testing.T) {
+	if testing.Short() {
+		t.Skip("large topology simulation")
+	}
+	cfg := DefaultConfig()
+	cfg.BucketMiners = 1500
+	cfg.Defaults = 500
+	cfg.ReelectInterval = 3
+	cfg.NextElectLead = 1
+	sim := newSim(t, cfg)
+	checkConnected(t, sim.Run(4))
+}
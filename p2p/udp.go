@@ -4,99 +4,531 @@
 package p2p
 
 import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	crand "crypto/rand"
+	"fmt"
 	"net"
+	"sync"
+	"time"
 
 	"github.com/MatrixAINetwork/go-matrix/ca"
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/crypto"
+	"github.com/MatrixAINetwork/go-matrix/crypto/ecies"
 	"github.com/MatrixAINetwork/go-matrix/log"
 	"github.com/MatrixAINetwork/go-matrix/mc"
-	"github.com/MatrixAINetwork/go-matrix/params"
+	"github.com/MatrixAINetwork/go-matrix/metrics"
+	"github.com/MatrixAINetwork/go-matrix/p2p/discover"
 	"github.com/MatrixAINetwork/go-matrix/rlp"
+	"github.com/pkg/errors"
 )
 
-func UdpStart() {
-	addr, err := net.ResolveUDPAddr("udp", ":30000")
+// 数据报旁路通道：不经过RLPx连接，直接以UDP发送给对方节点。
+// 报文用双方发现协议身份(节点密钥)的ECDH共享密钥做AES-GCM加密和认证，
+// 明文中带发送时间，超出时间窗口或重复的报文被丢弃。
+//
+// 报文格式: version(1) | 发送方节点ID(64) | nonce(12) | AES-GCM(rlp(datagramBody))，
+// 附加认证数据为报文头和接收方节点ID
+//
+// 加密格式按节点启用：man/64握手中双方都声明了mc.HD_EncryptedDatagram时，msgsend调用EnablePeer。
+// 未协商的节点交易仍以旧格式发送，即明文的rlp(交易列表)，算法消息不发送，由调用方改用RLPx。
+// 未升级的节点仍发送明文交易，因此两种格式都接收
+const (
+	DatagramPort = 30000
+
+	datagramVersion    = 1
+	datagramNonceSize  = 12
+	datagramHeaderSize = 1 + len(discover.NodeID{}) + datagramNonceSize
+	datagramTagSize    = 16
+	maxDatagramSize    = 65507 // IPv4下UDP报文数据的上限
+
+	// MaxDatagramPayload 单个数据报可携带的数据上限，超出的消息应通过RLPx发送
+	MaxDatagramPayload = maxDatagramSize - datagramHeaderSize - datagramTagSize - 32
+
+	datagramWindow    = 20 * time.Second // 发送时间与本地时间的最大偏差
+	maxDatagramSeen   = 64 * 1024        // 时间窗口内记录的报文数上限
+	maxDatagramKeys   = 1024             // 缓存的共享密钥数上限
+	rlpListPrefix     = 0xc0             // 旧格式报文是rlp列表，首字节不小于0xc0
+	datagramTxTargets = 2                // 交易发送的验证者数量
+)
+
+// DatagramCode 数据报的消息类型
+type DatagramCode uint64
+
+const (
+	DatagramTx           DatagramCode = iota + 1 // 交易，见UdpSend
+	DatagramAlgorithmMsg                         // msgsend算法消息
+)
+
+// DatagramHandler 处理解密后的数据报，from为经过认证的发送方
+type DatagramHandler func(from discover.NodeID, payload []byte)
+
+var (
+	errDatagramNotStarted = errors.New("datagram transport not started")
+	errDatagramTooLarge   = errors.New("datagram payload too large")
+	errDatagramShort      = errors.New("datagram too short")
+	errDatagramVersion    = errors.New("unknown datagram version")
+	errDatagramExpired    = errors.New("datagram outside time window")
+	errDatagramReplay     = errors.New("datagram replayed")
+	errDatagramSelf       = errors.New("datagram from self")
+	errDatagramLimited    = errors.New("too many datagrams from unknown senders")
+	errDatagramLegacy     = errors.New("peer has not negotiated encrypted datagrams")
+
+	datagramInMeter     = metrics.NewRegisteredMeter("p2p/datagram/in", nil)
+	datagramOutMeter    = metrics.NewRegisteredMeter("p2p/datagram/out", nil)
+	datagramDropMeter   = metrics.NewRegisteredMeter("p2p/datagram/drop", nil)
+	datagramReplayMeter = metrics.NewRegisteredMeter("p2p/datagram/replay", nil)
+
+	// unknownSenderLimit 未缓存密钥的发送方每个报文都要做一次ECDH，限制其速率
+	unknownSenderLimit = RateLimit{Rate: 200, Burst: 400}
+)
+
+// datagramBody 数据报的明文
+type datagramBody struct {
+	Code    uint64
+	Time    uint64 // 发送时间，unix纳秒
+	Payload []byte
+}
+
+type datagramSeenKey struct {
+	from  discover.NodeID
+	nonce [datagramNonceSize]byte
+}
+
+// Datagram 加密数据报传输
+type Datagram struct {
+	mu       sync.RWMutex
+	priv     *ecdsa.PrivateKey
+	self     discover.NodeID
+	conn     *net.UDPConn
+	handlers map[DatagramCode]DatagramHandler
+	keys     map[discover.NodeID]cipher.AEAD
+	unknown  *rateLimiter                  // 未知发送方的密钥计算速率
+	seen     map[datagramSeenKey]time.Time // 值为记录的过期时间
+	now      func() time.Time
+	peers    map[discover.NodeID]struct{} // 握手中协商了加密格式的节点
+}
+
+// Udp 本节点的数据报传输，随p2p服务启动
+var Udp = NewDatagram()
+
+func NewDatagram() *Datagram {
+	return &Datagram{
+		handlers: make(map[DatagramCode]DatagramHandler),
+		keys:     make(map[discover.NodeID]cipher.AEAD),
+		unknown:  newRateLimiter(unknownSenderLimit),
+		seen:     make(map[datagramSeenKey]time.Time),
+		now:      time.Now,
+		peers:    make(map[discover.NodeID]struct{}),
+	}
+}
+
+// EnablePeer 节点id在握手中协商了加密格式，之后发给它的数据报使用加密格式
+func (d *Datagram) EnablePeer(id discover.NodeID) {
+	d.mu.Lock()
+	d.peers[id] = struct{}{}
+	d.mu.Unlock()
+}
+
+// DisablePeer 节点id断开连接，恢复为未协商
+func (d *Datagram) DisablePeer(id discover.NodeID) {
+	d.mu.Lock()
+	delete(d.peers, id)
+	d.mu.Unlock()
+}
+
+func (d *Datagram) peerEnabled(id discover.NodeID) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	_, exist := d.peers[id]
+	return exist
+}
+
+// Handle 注册消息类型的处理函数，可在启动前调用
+func (d *Datagram) Handle(code DatagramCode, handler DatagramHandler) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.handlers[code] = handler
+}
+
+// Start 以节点密钥在listenAddr上接收数据报
+func (d *Datagram) Start(priv *ecdsa.PrivateKey, listenAddr string) error {
+	addr, err := net.ResolveUDPAddr("udp", listenAddr)
 	if err != nil {
-		log.Error("Can't resolve address: ", "p2p udp", err)
-		return
+		return err
 	}
 	conn, err := net.ListenUDP("udp", addr)
 	if err != nil {
-		log.Error("Error listening:", "p2p udp", err)
+		return err
+	}
+	d.mu.Lock()
+	if d.conn != nil {
+		d.mu.Unlock()
+		conn.Close()
+		return errors.New("datagram transport already started")
+	}
+	d.priv, d.self, d.conn = priv, discover.PubkeyID(&priv.PublicKey), conn
+	d.mu.Unlock()
+
+	go d.readLoop(conn)
+	return nil
+}
+
+// Stop 关闭监听
+func (d *Datagram) Stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.conn != nil {
+		d.conn.Close()
+		d.conn = nil
+	}
+}
+
+// LocalAddr 返回监听地址，未启动时为nil
+func (d *Datagram) LocalAddr() *net.UDPAddr {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.conn == nil {
+		return nil
+	}
+	return d.conn.LocalAddr().(*net.UDPAddr)
+}
+
+// SendTo 加密并发送数据报到节点to的地址addr
+func (d *Datagram) SendTo(to discover.NodeID, addr *net.UDPAddr, code DatagramCode, payload []byte) error {
+	if len(payload) > MaxDatagramPayload {
+		return errors.Errorf("%v: %d > %d", errDatagramTooLarge, len(payload), MaxDatagramPayload)
+	}
+	d.mu.RLock()
+	conn, self := d.conn, d.self
+	d.mu.RUnlock()
+	if conn == nil {
+		return errDatagramNotStarted
+	}
+	if !d.peerEnabled(to) {
+		return errDatagramLegacy
+	}
+	packet, err := d.seal(to, self, code, payload)
+	if err != nil {
+		return err
+	}
+	if _, err := conn.WriteToUDP(packet, addr); err != nil {
+		return err
+	}
+	datagramOutMeter.Mark(1)
+	return nil
+}
+
+func (d *Datagram) seal(to, self discover.NodeID, code DatagramCode, payload []byte) ([]byte, error) {
+	aead, err := d.sharedKey(to)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := rlp.EncodeToBytes(&datagramBody{Code: uint64(code), Time: uint64(d.now().UnixNano()), Payload: payload})
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, datagramHeaderSize, datagramHeaderSize+len(plain)+datagramTagSize)
+	header[0] = datagramVersion
+	copy(header[1:], self[:])
+	if _, err := crand.Read(header[1+len(self):]); err != nil {
+		return nil, err
+	}
+	nonce := header[1+len(self):]
+	d.storeKey(to, aead)
+	return aead.Seal(header, nonce, plain, datagramAAD(header, to)), nil
+}
+
+// sendPlain 以旧格式的明文发送交易
+func (d *Datagram) sendPlain(addr *net.UDPAddr, payload []byte) error {
+	d.mu.RLock()
+	conn := d.conn
+	d.mu.RUnlock()
+	if conn == nil {
+		return errDatagramNotStarted
+	}
+	if _, err := conn.WriteToUDP(payload, addr); err != nil {
+		return err
+	}
+	datagramOutMeter.Mark(1)
+	return nil
+}
+
+// open 认证并解密数据报
+func (d *Datagram) open(packet []byte) (discover.NodeID, *datagramBody, error) {
+	var from discover.NodeID
+	if len(packet) < datagramHeaderSize+datagramTagSize {
+		return from, nil, errDatagramShort
+	}
+	if packet[0] != datagramVersion {
+		return from, nil, errDatagramVersion
+	}
+	copy(from[:], packet[1:])
+	d.mu.RLock()
+	self := d.self
+	d.mu.RUnlock()
+	if from == self {
+		return from, nil, errDatagramSelf
+	}
+	aead, err := d.openKey(from)
+	if err != nil {
+		return from, nil, err
+	}
+	header := packet[:datagramHeaderSize]
+	nonce := header[1+len(from):]
+	plain, err := aead.Open(nil, nonce, packet[datagramHeaderSize:], datagramAAD(header, self))
+	if err != nil {
+		return from, nil, err
+	}
+	d.storeKey(from, aead)
+	body := new(datagramBody)
+	if err := rlp.DecodeBytes(plain, body); err != nil {
+		return from, nil, err
+	}
+
+	// 解密成功后再检查时间和重复，避免伪造的报文占用记录
+	now := d.now()
+	sent := time.Unix(0, int64(body.Time))
+	if sent.Before(now.Add(-datagramWindow)) || sent.After(now.Add(datagramWindow)) {
+		return from, nil, errDatagramExpired
+	}
+	key := datagramSeenKey{from: from}
+	copy(key.nonce[:], nonce)
+	if !d.markSeen(key, sent.Add(datagramWindow), now) {
+		datagramReplayMeter.Mark(1)
+		return from, nil, errDatagramReplay
+	}
+	return from, body, nil
+}
+
+// markSeen 记录报文，已记录过时返回false
+func (d *Datagram) markSeen(key datagramSeenKey, expire, now time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, exist := d.seen[key]; exist {
+		return false
+	}
+	if len(d.seen) >= maxDatagramSeen {
+		for k, t := range d.seen {
+			if t.Before(now) {
+				delete(d.seen, k)
+			}
+		}
+		// 时间窗口内的报文过多，无法判断是否重复，丢弃
+		if len(d.seen) >= maxDatagramSeen {
+			return false
+		}
+	}
+	d.seen[key] = expire
+	return true
+}
+
+// openKey 返回解密发送方报文的密钥。未缓存的发送方需要计算ECDH，超出速率限制时丢弃报文，
+// 伪造的节点ID无法让接收方为每个报文做一次ECDH
+func (d *Datagram) openKey(from discover.NodeID) (cipher.AEAD, error) {
+	d.mu.Lock()
+	aead, exist := d.keys[from]
+	if !exist && !d.unknown.allow(d.now()) {
+		d.mu.Unlock()
+		return nil, errDatagramLimited
+	}
+	d.mu.Unlock()
+	if exist {
+		return aead, nil
+	}
+	return d.sharedKey(from)
+}
+
+// sharedKey 返回与节点id通信的AES-GCM密钥，由双方节点密钥的ECDH共享密钥生成
+func (d *Datagram) sharedKey(id discover.NodeID) (cipher.AEAD, error) {
+	d.mu.RLock()
+	aead, exist := d.keys[id]
+	priv := d.priv
+	d.mu.RUnlock()
+	if exist {
+		return aead, nil
+	}
+	if priv == nil {
+		return nil, errDatagramNotStarted
+	}
+	pub, err := id.Pubkey()
+	if err != nil {
+		return nil, err
+	}
+	shared, err := ecies.ImportECDSA(priv).GenerateShared(ecies.ImportECDSAPublic(pub), 16, 16)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(crypto.Keccak256(shared, []byte("matrix datagram")))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// storeKey 缓存共享密钥。接收时只缓存解密成功的发送方，伪造的节点ID不会占用缓存
+func (d *Datagram) storeKey(id discover.NodeID, aead cipher.AEAD) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, exist := d.keys[id]; exist {
 		return
 	}
-	defer conn.Close()
+	if len(d.keys) >= maxDatagramKeys {
+		d.keys = make(map[discover.NodeID]cipher.AEAD)
+	}
+	d.keys[id] = aead
+}
 
-	buf := make([]byte, params.MaxUdpBuf)
+func datagramAAD(header []byte, to discover.NodeID) []byte {
+	return append(append(make([]byte, 0, len(header)+len(to)), header...), to[:]...)
+}
 
+func (d *Datagram) readLoop(conn *net.UDPConn) {
+	buf := make([]byte, maxDatagramSize)
 	for {
-		var mxtxs []*types.Transaction_Mx
-		n, _, err := conn.ReadFromUDP(buf)
+		n, from, err := conn.ReadFromUDP(buf)
 		if err != nil {
-			log.Error("UDP read error", "err", err)
+			log.Debug("p2p datagram", "停止接收", err)
 			return
 		}
-
-		err = rlp.DecodeBytes(buf[:n], &mxtxs)
-		if err != nil {
-			log.Error("rlp decode error", "err", err)
-			continue
-		}
-		mc.PublishEvent(mc.SendUdpTx, mxtxs)
+		d.handlePacket(buf[:n], from)
 	}
 }
 
-func UdpSend(data interface{}) {
-	bytes, err := rlp.EncodeToBytes(data)
-	if err != nil {
-		log.Error("error", "p2p udp", err)
+func (d *Datagram) handlePacket(packet []byte, addr *net.UDPAddr) {
+	if len(packet) > 0 && packet[0] >= rlpListPrefix {
+		d.handlePlain(packet)
 		return
 	}
-
-	signAddr := make([]common.Address, 0)
-	if ca.InDuration() {
-		signAddr = ca.GetRolesByGroupOnlyNextElect(common.RoleValidator | common.RoleBackupValidator)
-	} else {
-		signAddr = ca.GetRolesByGroup(common.RoleValidator | common.RoleBackupValidator)
+	from, body, err := d.open(packet)
+	if err != nil {
+		datagramDropMeter.Mark(1)
+		log.Trace("p2p datagram", "丢弃数据报", err, "addr", addr)
+		return
 	}
-	if len(signAddr) <= 2 {
-		for _, id := range signAddr {
-			log.Info("upd", "send tx addr", id.String(), "node id", ServerP2p.ConvertAddressToId(id).String()) //YY add log
-			send(id, bytes)
-		}
+	d.mu.RLock()
+	handler, exist := d.handlers[DatagramCode(body.Code)]
+	d.mu.RUnlock()
+	if !exist {
+		datagramDropMeter.Mark(1)
+		log.Debug("p2p datagram", "未知的消息类型", body.Code, "from", from.TerminalString())
 		return
 	}
+	datagramInMeter.Mark(1)
+	handler(from, body.Payload)
+}
 
-	is := Random(len(signAddr), 2)
-	for _, i := range is {
-		log.Info("upd", "send tx addr", signAddr[i].String(), "node id", ServerP2p.ConvertAddressToId(signAddr[i]).String()) //YY add log
-		send(signAddr[i], bytes)
+// handlePlain 旧格式的明文交易报文，没有经过认证的发送方
+func (d *Datagram) handlePlain(packet []byte) {
+	d.mu.RLock()
+	handler, exist := d.handlers[DatagramTx]
+	d.mu.RUnlock()
+	if !exist {
+		datagramDropMeter.Mark(1)
+		return
 	}
+	datagramInMeter.Mark(1)
+	handler(EmptyNodeId, packet)
 }
 
-func send(address common.Address, data []byte) {
+// SendDatagram 通过数据报发送消息给节点账户address
+func SendDatagram(address common.Address, code DatagramCode, payload []byte) error {
+	if ServerP2p == nil || ServerP2p.ntab == nil {
+		return errDatagramNotStarted
+	}
+	if Sentry.Routed(address) {
+		return errSentryDatagram
+	}
 	n := ServerP2p.ntab.ResolveNode(address, EmptyNodeId)
 	if n == nil {
-		log.Error("can't send udp to", "addr", address)
+		return fmt.Errorf("can't resolve node %s", address.Hex())
+	}
+	addr := &net.UDPAddr{IP: n.IP, Port: DatagramPort}
+	if code == DatagramTx && !Udp.peerEnabled(n.ID) {
+		return Udp.sendPlain(addr, payload)
+	}
+	return Udp.SendTo(n.ID, addr, code, payload)
+}
+
+// startDatagram 随p2p服务启动数据报通道，接收普通节点发送的交易
+func (srv *Server) startDatagram() {
+	// 隐藏的验证者不监听数据报，交易和算法消息经哨兵转发
+	if Sentry.Hidden() {
 		return
 	}
+	Udp.Handle(DatagramTx, handleUdpTxs)
+	if err := Udp.Start(srv.PrivateKey, fmt.Sprintf(":%d", DatagramPort)); err != nil {
+		log.Error("p2p datagram", "启动失败", err)
+	}
+}
 
-	addr, err := net.ResolveUDPAddr("udp", n.IP.String()+":30000")
-	if err != nil {
-		log.Error("Can't resolve address: ", "p2p udp", err)
+func handleUdpTxs(from discover.NodeID, payload []byte) {
+	var mxtxs []*types.Transaction_Mx
+	if err := rlp.DecodeBytes(payload, &mxtxs); err != nil {
+		log.Debug("p2p datagram", "解析交易失败", err, "from", from.TerminalString())
+		if from != EmptyNodeId {
+			Scores.Report(from, MsgClassTx, MsgInvalid)
+		}
 		return
 	}
-	conn, err := net.DialUDP("udp", nil, addr)
+	mc.PublishEvent(mc.SendUdpTx, mxtxs)
+}
+
+// UdpSend 将交易以数据报发送给随机的验证者，换届期间发送给下届验证者
+func UdpSend(txs []*types.Transaction_Mx) {
+	signAddr := make([]common.Address, 0)
+	if ca.InDuration() {
+		signAddr = ca.GetRolesByGroupOnlyNextElect(common.RoleValidator | common.RoleBackupValidator)
+	} else {
+		signAddr = ca.GetRolesByGroup(common.RoleValidator | common.RoleBackupValidator)
+	}
+	targets := signAddr
+	if len(signAddr) > datagramTxTargets {
+		targets = make([]common.Address, 0, datagramTxTargets)
+		for _, i := range Random(len(signAddr), datagramTxTargets) {
+			targets = append(targets, signAddr[i])
+		}
+	}
+
+	payloads, err := splitUdpTxs(txs)
 	if err != nil {
-		log.Error("Can't dial: ", "p2p udp", err)
+		log.Error("error", "p2p udp", err)
 		return
 	}
-	defer conn.Close()
+	for _, addr := range targets {
+		log.Info("upd", "send tx addr", addr.String(), "datagrams", len(payloads))
+		for _, payload := range payloads {
+			if err := SendDatagram(addr, DatagramTx, payload); err != nil {
+				log.Error("can't send udp to", "addr", addr, "err", err)
+				break
+			}
+		}
+	}
+}
 
-	_, err = conn.Write(data)
+// splitUdpTxs 编码交易，超出数据报大小时对半拆分
+func splitUdpTxs(txs []*types.Transaction_Mx) ([][]byte, error) {
+	payload, err := rlp.EncodeToBytes(txs)
 	if err != nil {
-		log.Error("failed:", "p2p udp", err)
-		return
+		return nil, err
+	}
+	if len(payload) <= MaxDatagramPayload {
+		return [][]byte{payload}, nil
+	}
+	if len(txs) == 1 {
+		return nil, errors.Errorf("%v: tx %d bytes", errDatagramTooLarge, len(payload))
+	}
+	half := len(txs) / 2
+	first, err := splitUdpTxs(txs[:half])
+	if err != nil {
+		return nil, err
+	}
+	second, err := splitUdpTxs(txs[half:])
+	if err != nil {
+		return nil, err
 	}
+	return append(first, second...), nil
 }
@@ -0,0 +1,199 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package p2p
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/crypto"
+	"github.com/MatrixAINetwork/go-matrix/p2p/discover"
+	"github.com/MatrixAINetwork/go-matrix/rlp"
+)
+
+type datagramRecv struct {
+	from    discover.NodeID
+	payload []byte
+}
+
+func startTestDatagram(t *testing.T) (*Datagram, discover.NodeID, chan datagramRecv) {
+	key, _ := crypto.GenerateKey()
+	d := NewDatagram()
+	recv := make(chan datagramRecv, 10)
+	d.Handle(DatagramAlgorithmMsg, func(from discover.NodeID, payload []byte) {
+		recv <- datagramRecv{from, payload}
+	})
+	if err := d.Start(key, "127.0.0.1:0"); err != nil {
+		t.Fatal(err)
+	}
+	return d, discover.PubkeyID(&key.PublicKey), recv
+}
+
+func TestDatagramSendReceive(t *testing.T) {
+	a, aID, _ := startTestDatagram(t)
+	defer a.Stop()
+	b, bID, recv := startTestDatagram(t)
+	defer b.Stop()
+
+	a.EnablePeer(bID)
+	if err := a.SendTo(bID, b.LocalAddr(), DatagramAlgorithmMsg, []byte("vote")); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case msg := <-recv:
+		if msg.from != aID || string(msg.payload) != "vote" {
+			t.Fatalf("received %x from %v", msg.payload, msg.from)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("datagram not received")
+	}
+
+	if err := a.SendTo(bID, b.LocalAddr(), DatagramAlgorithmMsg, make([]byte, MaxDatagramPayload+1)); err == nil {
+		t.Fatal("oversized datagram sent")
+	}
+}
+
+func TestDatagramRejects(t *testing.T) {
+	a, _, _ := startTestDatagram(t)
+	defer a.Stop()
+	b, bID, _ := startTestDatagram(t)
+	defer b.Stop()
+	c, cID, _ := startTestDatagram(t)
+	defer c.Stop()
+
+	packet, err := a.seal(bID, discover.PubkeyID(&a.priv.PublicKey), DatagramAlgorithmMsg, []byte("tx"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := c.open(packet); err == nil {
+		t.Fatal("datagram opened by other node")
+	}
+	tampered := append([]byte{}, packet...)
+	tampered[len(tampered)-1] ^= 1
+	if _, _, err := b.open(tampered); err == nil {
+		t.Fatal("tampered datagram accepted")
+	}
+	forged := append([]byte{}, packet...)
+	copy(forged[1:], cID[:])
+	if _, _, err := b.open(forged); err == nil {
+		t.Fatal("datagram with forged sender accepted")
+	}
+	if _, body, err := b.open(packet); err != nil || !bytes.Equal(body.Payload, []byte("tx")) {
+		t.Fatalf("open: %v, %v", body, err)
+	}
+	if _, _, err := b.open(packet); err != errDatagramReplay {
+		t.Fatalf("replayed datagram: err = %v", err)
+	}
+
+	a.now = func() time.Time { return time.Now().Add(-2 * datagramWindow) }
+	stale, _ := a.seal(bID, discover.PubkeyID(&a.priv.PublicKey), DatagramAlgorithmMsg, []byte("tx"))
+	if _, _, err := b.open(stale); err != errDatagramExpired {
+		t.Fatalf("stale datagram: err = %v", err)
+	}
+	if len(b.keys) != 1 {
+		t.Fatalf("cached %d keys, want only the authenticated sender", len(b.keys))
+	}
+}
+
+func TestDatagramUnknownSenderLimit(t *testing.T) {
+	a, _, _ := startTestDatagram(t)
+	defer a.Stop()
+	b, bID, _ := startTestDatagram(t)
+	defer b.Stop()
+
+	packet, err := a.seal(bID, discover.PubkeyID(&a.priv.PublicKey), DatagramAlgorithmMsg, []byte("tx"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	b.now = func() time.Time { return now }
+	for i := 0; i < int(unknownSenderLimit.Burst); i++ {
+		key, _ := crypto.GenerateKey()
+		forged := append([]byte{}, packet...)
+		id := discover.PubkeyID(&key.PublicKey)
+		copy(forged[1:], id[:])
+		if _, _, err := b.open(forged); err == errDatagramLimited {
+			t.Fatalf("limited after %d unknown senders", i)
+		}
+	}
+	if _, _, err := b.open(packet); err != errDatagramLimited {
+		t.Fatalf("unknown sender over the limit: err = %v", err)
+	}
+	now = now.Add(time.Second)
+	if _, _, err := b.open(packet); err != nil {
+		t.Fatal(err)
+	}
+	// 已缓存密钥的发送方不受限制
+	b.unknown = newRateLimiter(RateLimit{})
+	again, _ := a.seal(bID, discover.PubkeyID(&a.priv.PublicKey), DatagramAlgorithmMsg, []byte("tx"))
+	if _, _, err := b.open(again); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDatagramLegacy(t *testing.T) {
+	a, _, _ := startTestDatagram(t)
+	defer a.Stop()
+	b, bID, _ := startTestDatagram(t)
+	defer b.Stop()
+	recv := make(chan datagramRecv, 1)
+	b.Handle(DatagramTx, func(from discover.NodeID, payload []byte) {
+		recv <- datagramRecv{from, payload}
+	})
+
+	// 握手中未协商加密格式的节点只发送明文交易
+	if err := a.SendTo(bID, b.LocalAddr(), DatagramAlgorithmMsg, []byte("vote")); err != errDatagramLegacy {
+		t.Fatalf("encrypted datagram to a legacy peer: err = %v", err)
+	}
+	payload, _ := rlp.EncodeToBytes([]*types.Transaction_Mx{})
+	if err := a.sendPlain(b.LocalAddr(), payload); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case msg := <-recv:
+		if msg.from != EmptyNodeId || !bytes.Equal(msg.payload, payload) {
+			t.Fatalf("received %x from %v", msg.payload, msg.from)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("plain datagram not received")
+	}
+
+	// 协商后使用加密格式，断开后恢复
+	a.EnablePeer(bID)
+	if err := a.SendTo(bID, b.LocalAddr(), DatagramAlgorithmMsg, []byte("vote")); err != nil {
+		t.Fatal(err)
+	}
+	a.DisablePeer(bID)
+	if err := a.SendTo(bID, b.LocalAddr(), DatagramAlgorithmMsg, []byte("vote")); err != errDatagramLegacy {
+		t.Fatalf("encrypted datagram after disconnect: err = %v", err)
+	}
+}
+
+func TestSplitUdpTxs(t *testing.T) {
+	data := string(make([]byte, MaxDatagramPayload/3))
+	txs := make([]*types.Transaction_Mx, 5)
+	for i := range txs {
+		txs[i] = &types.Transaction_Mx{Currency: data}
+	}
+	payloads, err := splitUdpTxs(txs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(payloads) != 3 {
+		t.Fatalf("split into %d datagrams, want 3", len(payloads))
+	}
+	for _, payload := range payloads {
+		if len(payload) > MaxDatagramPayload {
+			t.Fatalf("datagram of %d bytes", len(payload))
+		}
+	}
+
+	txs[0].Currency = string(make([]byte, MaxDatagramPayload))
+	if _, err := splitUdpTxs(txs[:1]); err == nil {
+		t.Fatal("oversized tx split")
+	}
+}
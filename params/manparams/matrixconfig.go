@@ -28,7 +28,9 @@ const (
 	BlkVoteSendInterval     = 3
 	BlkVoteSendTimes        = 8
 	MinerReqSendInterval    = 3
+	MinerReqSendTimes       = 20
 	PosedReqSendInterval    = 10
+	PosedReqSendTimes       = 12
 	MinerResultSendInterval = 3
 
 	MinerPickTimeout = 20
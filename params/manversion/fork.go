@@ -0,0 +1,291 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package manversion
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+)
+
+// 区块插件类型，与blkmanage中注册插件使用的类型一致
+const (
+	PluginCommon    = "common"
+	PluginBroadcast = "broadcast"
+)
+
+// 区块插件名称，由blkmanage映射为具体的插件实现
+const (
+	PlugBase      = "base"
+	PlugBroadcast = "broadcast"
+	PlugAIMine    = "aimine"
+)
+
+// 按版本选择处理模块的插件类型，各模块只处理选中自己的版本的区块和消息
+const (
+	PluginBlockGenor  = "blkgenor"
+	PluginLeaderElect = "leaderelect"
+)
+
+const (
+	PlugGenorV1  = "blkgenor"
+	PlugGenorV2  = "blkgenor2.0"
+	PlugLeaderV1 = "leaderelect"
+	PlugLeaderV2 = "leaderelect2.0"
+)
+
+// 版本特性，由引入特性的版本声明，之后的版本均启用。代码按特性判断，不直接比较版本号
+const (
+	FeatureGamma         = "gamma"         // 出块难度周期、leader计算及拓扑生成的Gamma规则
+	FeatureDelta         = "delta"         // 奖励、惩罚及状态配置的Delta规则
+	FeatureAIMine        = "aimine"        // AI挖矿区块及区块头的AI字段
+	FeatureAggregateSign = "aggregatesign" // 区块头携带BLS聚合签名
+)
+
+var (
+	ErrForkVersionOrder = errors.New("fork version must be greater than registered versions")
+	ErrForkNumberOrder  = errors.New("fork number must be greater than registered fork numbers")
+	ErrForkParent       = errors.New("fork parent version not registered")
+	ErrForkExist        = errors.New("fork version already registered")
+	ErrForkFeature      = errors.New("fork feature already introduced by another version")
+	ErrForkApproval     = errors.New("fork activated by super block must not have a fork number")
+	ErrForkUnknown      = errors.New("fork version not registered")
+)
+
+// Fork 描述一个区块版本：激活方式、启用的区块插件和引入的特性。
+// Number为0表示不按高度切换：Approval为true时由超级区块签名者出具的超级区块携带该版本号激活，
+// 否则为创世版本。Number不为0时在Number高度由Parent版本切换为该版本。
+// Signatures为版本超级节点对版本号的签名，出块时写入区块头，验证区块时校验
+type Fork struct {
+	Name        string
+	Version     string
+	Parent      string // 切换时前一区块必须是的版本，为空表示不限制
+	Number      uint64
+	Approval    bool
+	Signatures  []common.Signature
+	Plugins     map[string]string // 插件类型 -> 插件名称，未声明的类型沿用之前的版本
+	Features    []string          // 该版本引入的特性
+	Description string
+}
+
+type forkRegistry struct {
+	mu       sync.RWMutex
+	forks    []*Fork          // 按版本号升序
+	features map[string]*Fork // 特性 -> 引入特性的版本
+}
+
+var registry = &forkRegistry{forks: make([]*Fork, 0), features: make(map[string]*Fork)}
+
+// RegisterFork 注册新版本，版本号和切换高度必须大于已注册的版本，用于按计划加入后续版本
+func RegisterFork(fork Fork) error {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	var lastNumber uint64
+	for _, item := range registry.forks {
+		if item.Version == fork.Version {
+			return ErrForkExist
+		}
+		if VersionCmp(item.Version, fork.Version) > 0 {
+			return ErrForkVersionOrder
+		}
+		if item.Number > lastNumber {
+			lastNumber = item.Number
+		}
+	}
+	if fork.Number != 0 && fork.Number <= lastNumber {
+		return ErrForkNumberOrder
+	}
+	if fork.Approval && fork.Number != 0 {
+		return ErrForkApproval
+	}
+	if fork.Parent != "" && findFork(registry.forks, fork.Parent) == nil {
+		return ErrForkParent
+	}
+	for _, feature := range fork.Features {
+		if _, exist := registry.features[feature]; exist {
+			return ErrForkFeature
+		}
+	}
+
+	item := fork
+	item.Plugins = make(map[string]string, len(fork.Plugins))
+	for kind, name := range fork.Plugins {
+		item.Plugins[kind] = name
+	}
+	item.Features = append([]string(nil), fork.Features...)
+	for _, feature := range item.Features {
+		registry.features[feature] = &item
+	}
+	registry.forks = append(registry.forks, &item)
+	sort.Slice(registry.forks, func(i, j int) bool {
+		return VersionCmp(registry.forks[i].Version, registry.forks[j].Version) < 0
+	})
+
+	VersionList = append(VersionList, []byte(item.Version))
+	if len(item.Signatures) != 0 {
+		VersionSignatureMap[item.Version] = item.Signatures
+	}
+	return nil
+}
+
+// Forks 返回全部已注册的版本，按版本号升序
+func Forks() []Fork {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	list := make([]Fork, 0, len(registry.forks))
+	for _, item := range registry.forks {
+		list = append(list, *item)
+	}
+	return list
+}
+
+// GetFork 按版本号查询
+func GetFork(version string) (Fork, bool) {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	if item := findFork(registry.forks, version); item != nil {
+		return *item, true
+	}
+	return Fork{}, false
+}
+
+// ForkAt 返回在number高度切换的版本
+func ForkAt(number uint64) (Fork, bool) {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	if number == 0 {
+		return Fork{}, false
+	}
+	for _, item := range registry.forks {
+		if item.Number == number {
+			return *item, true
+		}
+	}
+	return Fork{}, false
+}
+
+// NextVersion 按注册的切换高度计算number高度区块的版本号
+func NextVersion(number uint64, preVersion string) (string, error) {
+	fork, ok := ForkAt(number)
+	if !ok {
+		return preVersion, nil
+	}
+	if VersionCmp(preVersion, fork.Version) >= 0 {
+		return preVersion, nil
+	}
+	if fork.Parent != "" && VersionCmp(preVersion, fork.Parent) != 0 {
+		parent, _ := GetFork(fork.Parent)
+		return "", fmt.Errorf("%s版本切换点(%d), 前一区块版本号(%s)不是%s版本(%s), 无法切换版本", fork.Name, number, preVersion, parent.Name, fork.Parent)
+	}
+	return fork.Version, nil
+}
+
+// FeatureFork 返回引入特性的版本
+func FeatureFork(feature string) (Fork, bool) {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	if item, exist := registry.features[feature]; exist {
+		return *item, true
+	}
+	return Fork{}, false
+}
+
+// IsEnabled version版本是否启用特性，未注册的特性不启用
+func IsEnabled(feature string, version string) bool {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	item, exist := registry.features[feature]
+	if !exist {
+		return false
+	}
+	return VersionCmp(version, item.Version) >= 0
+}
+
+// FeatureNumber 特性的切换高度，特性未注册或不按高度切换时返回math.MaxUint64
+func FeatureNumber(feature string) uint64 {
+	fork, ok := FeatureFork(feature)
+	if !ok || fork.Approval {
+		return math.MaxUint64
+	}
+	return fork.Number
+}
+
+// Plugin 返回version版本启用的kind类型插件，按不高于version的各版本依次覆盖
+func Plugin(version string, kind string) string {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	name := ""
+	for _, item := range registry.forks {
+		if VersionCmp(item.Version, version) > 0 {
+			break
+		}
+		if plug, exist := item.Plugins[kind]; exist {
+			name = plug
+		}
+	}
+	return name
+}
+
+// Plugins 返回version版本启用的全部插件，包括沿用之前版本的插件
+func Plugins(version string) map[string]string {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	plugins := make(map[string]string)
+	for _, item := range registry.forks {
+		if VersionCmp(item.Version, version) > 0 {
+			break
+		}
+		for kind, name := range item.Plugins {
+			plugins[kind] = name
+		}
+	}
+	return plugins
+}
+
+// PluginNumber 最早启用kind类型name插件的版本切换高度，未注册或不按高度切换时返回math.MaxUint64
+func PluginNumber(kind string, name string) uint64 {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	for _, item := range registry.forks {
+		if item.Plugins[kind] != name {
+			continue
+		}
+		if item.Approval {
+			return math.MaxUint64
+		}
+		return item.Number
+	}
+	return math.MaxUint64
+}
+
+// CheckSuperBlockVersion 校验超级区块携带的版本号。超级区块升级到的版本必须已注册，
+// 由超级区块激活的版本还要求前一区块是其Parent版本
+func CheckSuperBlockVersion(version string, preVersion string) error {
+	if VersionCmp(version, preVersion) <= 0 {
+		return nil
+	}
+	fork, ok := GetFork(version)
+	if !ok {
+		return ErrForkUnknown
+	}
+	if fork.Approval && fork.Parent != "" && VersionCmp(preVersion, fork.Parent) != 0 {
+		return fmt.Errorf("%s版本由超级区块激活, 前一区块版本号(%s)不是%s", fork.Name, preVersion, fork.Parent)
+	}
+	return nil
+}
+
+func findFork(forks []*Fork, version string) *Fork {
+	for _, item := range forks {
+		if item.Version == version {
+			return item
+		}
+	}
+	return nil
+}
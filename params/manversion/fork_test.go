@@ -0,0 +1,150 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package manversion
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNextVersion(t *testing.T) {
+	tests := []struct {
+		number     uint64
+		preVersion string
+		want       string
+		err        bool
+	}{
+		{VersionNumGamma, VersionAlpha, VersionGamma, false},
+		{VersionNumGamma, VersionDelta, VersionDelta, false},
+		{VersionNumDelta, VersionGamma, VersionDelta, false},
+		{VersionNumDelta, VersionAlpha, "", true},
+		{VersionNumAIMine, VersionDelta, VersionAIMine, false},
+		{VersionNumZeta, VersionDelta, "", true},
+		{VersionNumZeta, VersionAIMine, VersionZeta, false},
+		{VersionNumZeta + 1, VersionAIMine, VersionAIMine, false},
+	}
+	for i, test := range tests {
+		got, err := NextVersion(test.number, test.preVersion)
+		if (err != nil) != test.err || got != test.want {
+			t.Errorf("test %d: got (%s, %v), want (%s, err %v)", i, got, err, test.want, test.err)
+		}
+	}
+}
+
+func TestRegisterFork(t *testing.T) {
+	if err := RegisterFork(Fork{Version: VersionDelta}); err != ErrForkExist {
+		t.Fatalf("err = %v, want %v", err, ErrForkExist)
+	}
+	if err := RegisterFork(Fork{Version: "1.0.0.6", Number: VersionNumAIMine}); err != ErrForkNumberOrder {
+		t.Fatalf("err = %v, want %v", err, ErrForkNumberOrder)
+	}
+	if err := RegisterFork(Fork{Version: "1.0.0.6", Parent: "0.9", Number: VersionNumZeta + 100}); err != ErrForkParent {
+		t.Fatalf("err = %v, want %v", err, ErrForkParent)
+	}
+
+	next := Fork{Name: "Next", Version: "1.0.0.6", Parent: VersionZeta, Number: VersionNumZeta + 100, Plugins: map[string]string{PluginCommon: PlugAIMine}}
+	if err := RegisterFork(next); err != nil {
+		t.Fatalf("register fork err: %v", err)
+	}
+	if !IsCorrectVersion([]byte(next.Version)) {
+		t.Fatal("registered version is not correct")
+	}
+	if version, _ := NextVersion(next.Number, VersionZeta); version != next.Version {
+		t.Fatalf("version = %s, want %s", version, next.Version)
+	}
+	forks := Forks()
+	if forks[len(forks)-1].Version != next.Version {
+		t.Fatalf("forks not sorted: %+v", forks)
+	}
+}
+
+func TestForkFeatures(t *testing.T) {
+	tests := []struct {
+		feature string
+		version string
+		want    bool
+	}{
+		{FeatureGamma, VersionBeta, false},
+		{FeatureGamma, VersionGamma, true},
+		{FeatureGamma, VersionZeta, true},
+		{FeatureDelta, VersionGamma, false},
+		{FeatureDelta, VersionDelta, true},
+		{FeatureAIMine, VersionDelta, false},
+		{FeatureAIMine, VersionAIMine, true},
+		{FeatureAIMine, VersionZeta, true},
+		{FeatureAggregateSign, VersionZeta, false},
+	}
+	for i, test := range tests {
+		if got := IsEnabled(test.feature, test.version); got != test.want {
+			t.Errorf("test %d: IsEnabled(%s, %s) = %v, want %v", i, test.feature, test.version, got, test.want)
+		}
+	}
+	if number := FeatureNumber(FeatureAIMine); number != VersionNumAIMine {
+		t.Fatalf("aimine number = %d, want %d", number, VersionNumAIMine)
+	}
+	if number := FeatureNumber(FeatureAggregateSign); number != math.MaxUint64 {
+		t.Fatalf("unregistered feature number = %d", number)
+	}
+	if err := RegisterFork(Fork{Version: "1.0.0.9", Features: []string{FeatureAIMine}}); err != ErrForkFeature {
+		t.Fatalf("err = %v, want %v", err, ErrForkFeature)
+	}
+}
+
+func TestForkPlugins(t *testing.T) {
+	tests := []struct {
+		version string
+		kind    string
+		want    string
+	}{
+		{VersionAlpha, PluginCommon, PlugBase},
+		{VersionDelta, PluginCommon, PlugBase},
+		{VersionZeta, PluginCommon, PlugAIMine},
+		{VersionZeta, PluginBroadcast, PlugBroadcast},
+		{VersionBeta, PluginLeaderElect, PlugLeaderV1},
+		{VersionGamma, PluginLeaderElect, PlugLeaderV2},
+		{VersionDelta, PluginBlockGenor, PlugGenorV1},
+		{VersionAIMine, PluginBlockGenor, PlugGenorV2},
+	}
+	for i, test := range tests {
+		if got := Plugin(test.version, test.kind); got != test.want {
+			t.Errorf("test %d: Plugin(%s, %s) = %s, want %s", i, test.version, test.kind, got, test.want)
+		}
+	}
+	if number := PluginNumber(PluginBlockGenor, PlugGenorV2); number != VersionNumAIMine {
+		t.Fatalf("blkgenor2.0 number = %d, want %d", number, VersionNumAIMine)
+	}
+	if number := PluginNumber(PluginLeaderElect, PlugLeaderV2); number != VersionNumGamma {
+		t.Fatalf("leaderelect2.0 number = %d, want %d", number, VersionNumGamma)
+	}
+}
+
+func TestApprovalFork(t *testing.T) {
+	if err := RegisterFork(Fork{Version: "1.0.0.7", Approval: true, Number: VersionNumZeta + 200}); err != ErrForkApproval {
+		t.Fatalf("err = %v, want %v", err, ErrForkApproval)
+	}
+	fork := Fork{Name: "Approval", Version: "1.0.0.7", Parent: VersionZeta, Approval: true, Features: []string{FeatureAggregateSign}}
+	if err := RegisterFork(fork); err != nil {
+		t.Fatalf("register fork err: %v", err)
+	}
+	// 不按高度切换，由超级区块激活
+	if number := FeatureNumber(FeatureAggregateSign); number != math.MaxUint64 {
+		t.Fatalf("approval feature number = %d", number)
+	}
+	if !IsEnabled(FeatureAggregateSign, fork.Version) || IsEnabled(FeatureAggregateSign, VersionZeta) {
+		t.Fatal("approval fork feature not enabled by version")
+	}
+	if err := CheckSuperBlockVersion(fork.Version, VersionZeta); err != nil {
+		t.Fatalf("super block version rejected: %v", err)
+	}
+	if err := CheckSuperBlockVersion(fork.Version, VersionAIMine); err == nil {
+		t.Fatal("super block version accepted with wrong parent")
+	}
+	if err := CheckSuperBlockVersion("1.0.0.8", VersionZeta); err != ErrForkUnknown {
+		t.Fatalf("err = %v, want %v", err, ErrForkUnknown)
+	}
+	if err := CheckSuperBlockVersion(VersionAIMine, VersionZeta); err != nil {
+		t.Fatalf("super block keeping lower version rejected: %v", err)
+	}
+}
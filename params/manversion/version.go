@@ -5,7 +5,7 @@
 package manversion
 
 import (
-	"bytes"
+	"fmt"
 
 	"github.com/MatrixAINetwork/go-matrix/common"
 )
@@ -36,12 +36,31 @@ var VersionList [][]byte
 var VersionSignatureMap map[string][]common.Signature
 
 func init() {
-	VersionList = [][]byte{[]byte(VersionAlpha), []byte(VersionBeta), []byte(VersionGamma), []byte(VersionDelta), []byte(VersionAIMine), []byte(VersionZeta)}
+	VersionList = make([][]byte, 0)
 	VersionSignatureMap = make(map[string][]common.Signature)
-	VersionSignatureMap[VersionGamma] = []common.Signature{common.BytesToSignature(common.FromHex(VersionSignatureGamma))}
-	VersionSignatureMap[VersionDelta] = []common.Signature{common.BytesToSignature(common.FromHex(VersionSignatureDelta))}
-	VersionSignatureMap[VersionAIMine] = []common.Signature{common.BytesToSignature(common.FromHex(VersionSignatureAIMine))}
-	VersionSignatureMap[VersionZeta] = []common.Signature{common.BytesToSignature(common.FromHex(VersionSignatureZeta))}
+
+	basePlugins := map[string]string{PluginCommon: PlugBase, PluginBroadcast: PlugBroadcast,
+		PluginBlockGenor: PlugGenorV1, PluginLeaderElect: PlugLeaderV1}
+	gammaPlugins := map[string]string{PluginLeaderElect: PlugLeaderV2}
+	aiMinePlugins := map[string]string{PluginCommon: PlugAIMine, PluginBlockGenor: PlugGenorV2}
+	forks := []Fork{
+		{Name: "Alpha", Version: VersionAlpha, Plugins: basePlugins, Description: "创世版本"},
+		{Name: "Beta", Version: VersionBeta, Description: "增加版本号示例"},
+		{Name: "Gamma", Version: VersionGamma, Number: VersionNumGamma, Plugins: gammaPlugins, Features: []string{FeatureGamma},
+			Signatures: []common.Signature{common.BytesToSignature(common.FromHex(VersionSignatureGamma))}},
+		{Name: "Delta", Version: VersionDelta, Parent: VersionGamma, Number: VersionNumDelta, Features: []string{FeatureDelta},
+			Signatures: []common.Signature{common.BytesToSignature(common.FromHex(VersionSignatureDelta))}},
+		{Name: "AIMine", Version: VersionAIMine, Parent: VersionDelta, Number: VersionNumAIMine, Plugins: aiMinePlugins, Features: []string{FeatureAIMine},
+			Signatures:  []common.Signature{common.BytesToSignature(common.FromHex(VersionSignatureAIMine))},
+			Description: "AI挖矿"},
+		{Name: "Zeta", Version: VersionZeta, Parent: VersionAIMine, Number: VersionNumZeta,
+			Signatures: []common.Signature{common.BytesToSignature(common.FromHex(VersionSignatureZeta))}},
+	}
+	for _, fork := range forks {
+		if err := RegisterFork(fork); err != nil {
+			panic(fmt.Sprintf("register fork %s err: %v", fork.Version, err))
+		}
+	}
 }
 
 // version1 > version2 return 1
@@ -62,20 +81,16 @@ func IsCorrectVersion(version []byte) bool {
 	if len(version) == 0 {
 		return false
 	}
-	for _, item := range VersionList {
-		if bytes.Equal(version, item) {
-			return true
-		}
-	}
-	return false
+	_, ok := GetFork(string(version))
+	return ok
 }
 
 func GetVersionSignature(version []byte) []common.Signature {
 	if len(version) == 0 {
 		return nil
 	}
-	if sig, ok := VersionSignatureMap[string(version)]; ok {
-		return sig
+	if fork, ok := GetFork(string(version)); ok && len(fork.Signatures) != 0 {
+		return fork.Signatures
 	}
 	return nil
 }
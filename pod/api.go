@@ -64,6 +64,62 @@ func (api *PrivateAdminAPI) RemovePeer(url string) (bool, error) {
 	return true, nil
 }
 
+// TopNodePolicy returns the top node link policy currently in effect.
+func (api *PrivateAdminAPI) TopNodePolicy() (p2p.LinkPolicy, error) {
+	if api.node.Server() == nil {
+		return p2p.LinkPolicy{}, ErrNodeStopped
+	}
+	return p2p.Link.Policy(), nil
+}
+
+// SetTopNodePolicy replaces the top node link policy. The new policy applies from
+// the next block and is lost on restart unless written to the config as well.
+func (api *PrivateAdminAPI) SetTopNodePolicy(policy p2p.LinkPolicy) (bool, error) {
+	if api.node.Server() == nil {
+		return false, ErrNodeStopped
+	}
+	if err := p2p.Link.SetPolicy(policy); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ReloadTopNodePolicy reads the top node link policy again from top-nodes.json in
+// the data directory, falling back to the configured P2P.TopNodePolicy section.
+func (api *PrivateAdminAPI) ReloadTopNodePolicy() (bool, error) {
+	if api.node.Server() == nil {
+		return false, ErrNodeStopped
+	}
+	policy, err := api.node.config.TopNodePolicy()
+	if err != nil {
+		return false, err
+	}
+	if err := p2p.Link.SetPolicy(policy); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ReloadRPCAuth reads the RPC auth config again from rpc-auth.json in the data
+// directory, falling back to the configured RPCAuth section. Running HTTP and
+// websocket endpoints apply it to the next call.
+func (api *PrivateAdminAPI) ReloadRPCAuth() (bool, error) {
+	api.node.lock.RLock()
+	defer api.node.lock.RUnlock()
+
+	if api.node.rpcAuth == nil {
+		return false, ErrNodeStopped
+	}
+	config, err := api.node.config.RPCAuthConfig()
+	if err != nil {
+		return false, err
+	}
+	if err := api.node.rpcAuth.SetConfig(config); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 // PeerEvents creates an RPC subscription which receives peer events from the
 // node's p2p.Server
 func (api *PrivateAdminAPI) PeerEvents(ctx context.Context) (*rpc.Subscription, error) {
@@ -146,7 +202,7 @@ func (api *PrivateAdminAPI) StartRPC(host *string, port *int, cors *string, apis
 		}
 	}
 
-	if err := api.node.startHTTP(fmt.Sprintf("%s:%d", *host, *port), api.node.rpcAPIs, modules, allowedOrigins, allowedVHosts); err != nil {
+	if err := api.node.startHTTP(fmt.Sprintf("%s:%d", *host, *port), api.node.rpcAPIs, modules, allowedOrigins, allowedVHosts, api.node.httpServices); err != nil {
 		return false, err
 	}
 	return true, nil
@@ -248,6 +304,15 @@ func (api *PublicAdminAPI) AddressTable() (map[common.Address]*discover.Node, er
 	return server.AddressTable(), nil
 }
 
+// TopNodes reports the heartbeats of the validators and miners in the topology
+// and whether each of them is currently connected.
+func (api *PublicAdminAPI) TopNodes() ([]p2p.NodeAliveInfo, error) {
+	if api.node.Server() == nil {
+		return nil, ErrNodeStopped
+	}
+	return p2p.GetTopNodeAliveInfo(common.RoleValidator | common.RoleBackupValidator | common.RoleMiner | common.RoleBackupMiner), nil
+}
+
 // NodeInfo retrieves all the information we know about the host node at the
 // protocol granularity.
 func (api *PublicAdminAPI) NodeInfo() (*p2p.NodeInfo, error) {
@@ -21,6 +21,7 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/log"
 	"github.com/MatrixAINetwork/go-matrix/p2p"
 	"github.com/MatrixAINetwork/go-matrix/p2p/discover"
+	"github.com/MatrixAINetwork/go-matrix/rpc"
 )
 
 const (
@@ -31,6 +32,8 @@ const (
 	datadirStaticNodes     = "static-nodes.json"  // Path within the datadir to the static node list
 	datadirTrustedNodes    = "trusted-nodes.json" // Path within the datadir to the trusted node list
 	datadirNodeDatabase    = "nodes"              // Path within the datadir to store the node infos
+	datadirTopNodePolicy   = "top-nodes.json"     // Path within the datadir to the top node link policy
+	datadirRPCAuth         = "rpc-auth.json"      // Path within the datadir to the RPC auth config
 )
 
 // Config represents a small collection of configuration values to fine tune the
@@ -135,6 +138,11 @@ type Config struct {
 	// private APIs to untrusted users is a major security risk.
 	WSExposeAll bool `toml:",omitempty"`
 
+	// RPCAuth configures bearer token and JWT authentication, per method permissions,
+	// per token rate limits and audit logging on the HTTP and websocket endpoints.
+	// The IPC endpoint is trusted and not authenticated.
+	RPCAuth rpc.AuthConfig `toml:",omitempty"`
+
 	// Logger is a custom logger to use with the p2p.Server.
 	Logger log.Logger `toml:",omitempty"`
 
@@ -333,6 +341,42 @@ func (c *Config) TrustedNodes() []*discover.Node {
 	return c.parsePersistentNodes(c.resolvePath(datadirTrustedNodes))
 }
 
+// TopNodePolicy returns the top node link policy. A top-nodes.json file in the
+// data directory takes precedence over the P2P.TopNodePolicy config section, and
+// is read again whenever the policy is reloaded through the admin API.
+func (c *Config) TopNodePolicy() (p2p.LinkPolicy, error) {
+	if c.DataDir == "" {
+		return c.P2P.TopNodePolicy, nil
+	}
+	path := c.resolvePath(datadirTopNodePolicy)
+	if _, err := os.Stat(path); err != nil {
+		return c.P2P.TopNodePolicy, nil
+	}
+	var policy p2p.LinkPolicy
+	if err := common.LoadJSON(path, &policy); err != nil {
+		return p2p.LinkPolicy{}, err
+	}
+	return policy, nil
+}
+
+// RPCAuthConfig returns the RPC auth config. An rpc-auth.json file in the data
+// directory takes precedence over the RPCAuth config section, and is read again
+// whenever the config is reloaded through the admin API.
+func (c *Config) RPCAuthConfig() (rpc.AuthConfig, error) {
+	if c.DataDir == "" {
+		return c.RPCAuth, nil
+	}
+	path := c.resolvePath(datadirRPCAuth)
+	if _, err := os.Stat(path); err != nil {
+		return c.RPCAuth, nil
+	}
+	var config rpc.AuthConfig
+	if err := common.LoadJSON(path, &config); err != nil {
+		return rpc.AuthConfig{}, err
+	}
+	return config, nil
+}
+
 // parsePersistentNodes parses a list of discovery node URLs loaded from a .json
 // file from within the data directory.
 func (c *Config) parsePersistentNodes(path string) []*discover.Node {
@@ -12,6 +12,7 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/p2p/discover"
 	"io/ioutil"
 	"net"
+	"net/http"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -57,10 +58,12 @@ type Node struct {
 	ipcListener net.Listener // IPC RPC listener socket to serve API requests
 	ipcHandler  *rpc.Server  // IPC RPC request handler to process the API requests
 
-	httpEndpoint  string       // HTTP endpoint (interface + port) to listen at (empty = HTTP disabled)
-	httpWhitelist []string     // HTTP RPC modules to allow through this endpoint
-	httpListener  net.Listener // HTTP RPC listener socket to server API requests
-	httpHandler   *rpc.Server  // HTTP RPC request handler to process the API requests
+	httpEndpoint  string                  // HTTP endpoint (interface + port) to listen at (empty = HTTP disabled)
+	httpWhitelist []string                // HTTP RPC modules to allow through this endpoint
+	httpListener  net.Listener            // HTTP RPC listener socket to server API requests
+	httpHandler   *rpc.Server             // HTTP RPC request handler to process the API requests
+	httpServices  map[string]http.Handler // Extra HTTP handlers provided by the services
+	rpcAuth       *rpc.Authenticator      // Authenticator shared by the HTTP and websocket endpoints
 
 	wsEndpoint string       // Websocket endpoint (interface + port) to listen at (empty = websocket disabled)
 	wsListener net.Listener // Websocket RPC listener socket to server API requests
@@ -249,6 +252,11 @@ func (n *Node) Start() error {
 	if n.serverConfig.NodeDatabase == "" {
 		n.serverConfig.NodeDatabase = n.config.NodeDB()
 	}
+	policy, err := n.config.TopNodePolicy()
+	if err != nil {
+		return err
+	}
+	n.serverConfig.TopNodePolicy = policy
 	p2p.ServerP2p.Config = n.serverConfig
 	running := p2p.ServerP2p
 	// get sign account
@@ -357,9 +365,27 @@ func (n *Node) openDataDir() error {
 func (n *Node) startRPC(services map[reflect.Type]Service) error {
 	// Gather all the possible APIs to surface
 	apis := n.apis()
+	handlers := make(map[string]http.Handler)
 	for _, service := range services {
 		apis = append(apis, service.APIs()...)
+		if httpService, ok := service.(HTTPService); ok {
+			for path, handler := range httpService.HTTPHandlers() {
+				if _, exist := handlers[path]; exist {
+					return fmt.Errorf("duplicate HTTP handler path %q", path)
+				}
+				handlers[path] = handler
+			}
+		}
+	}
+	authConfig, err := n.config.RPCAuthConfig()
+	if err != nil {
+		return err
+	}
+	auth, err := rpc.NewAuthenticator(authConfig)
+	if err != nil {
+		return err
 	}
+	n.rpcAuth = auth
 	// Start the various API endpoints, terminating all in case of errors
 	if err := n.startInProc(apis); err != nil {
 		return err
@@ -368,7 +394,7 @@ func (n *Node) startRPC(services map[reflect.Type]Service) error {
 		n.stopInProc()
 		return err
 	}
-	if err := n.startHTTP(n.httpEndpoint, apis, n.config.HTTPModules, n.config.HTTPCors, n.config.HTTPVirtualHosts); err != nil {
+	if err := n.startHTTP(n.httpEndpoint, apis, n.config.HTTPModules, n.config.HTTPCors, n.config.HTTPVirtualHosts, handlers); err != nil {
 		n.stopIPC()
 		n.stopInProc()
 		return err
@@ -381,6 +407,7 @@ func (n *Node) startRPC(services map[reflect.Type]Service) error {
 	}
 	// All API endpoints started successfully
 	n.rpcAPIs = apis
+	n.httpServices = handlers
 	return nil
 }
 
@@ -436,16 +463,19 @@ func (n *Node) stopIPC() {
 }
 
 // startHTTP initializes and starts the HTTP RPC endpoint.
-func (n *Node) startHTTP(endpoint string, apis []rpc.API, modules []string, cors []string, vhosts []string) error {
+func (n *Node) startHTTP(endpoint string, apis []rpc.API, modules []string, cors []string, vhosts []string, handlers map[string]http.Handler) error {
 	// Short circuit if the HTTP endpoint isn't being exposed
 	if endpoint == "" {
+		if len(handlers) > 0 {
+			n.log.Warn("HTTP endpoint disabled, service handlers not served", "count", len(handlers))
+		}
 		return nil
 	}
-	listener, handler, err := rpc.StartHTTPEndpoint(endpoint, apis, modules, cors, vhosts)
+	listener, handler, err := rpc.StartHTTPEndpoint(endpoint, apis, modules, cors, vhosts, handlers, n.rpcAuth)
 	if err != nil {
 		return err
 	}
-	n.log.Info("HTTP endpoint opened", "url", fmt.Sprintf("http://%s", endpoint), "cors", strings.Join(cors, ","), "vhosts", strings.Join(vhosts, ","))
+	n.log.Info("HTTP endpoint opened", "url", fmt.Sprintf("http://%s", endpoint), "cors", strings.Join(cors, ","), "vhosts", strings.Join(vhosts, ","), "auth", n.rpcAuth.Enabled())
 	// All listeners booted successfully
 	n.httpEndpoint = endpoint
 	n.httpListener = listener
@@ -474,11 +504,11 @@ func (n *Node) startWS(endpoint string, apis []rpc.API, modules []string, wsOrig
 	if endpoint == "" {
 		return nil
 	}
-	listener, handler, err := rpc.StartWSEndpoint(endpoint, apis, modules, wsOrigins, exposeAll)
+	listener, handler, err := rpc.StartWSEndpoint(endpoint, apis, modules, wsOrigins, exposeAll, n.rpcAuth)
 	if err != nil {
 		return err
 	}
-	n.log.Info("WebSocket endpoint opened", "url", fmt.Sprintf("ws://%s", listener.Addr()))
+	n.log.Info("WebSocket endpoint opened", "url", fmt.Sprintf("ws://%s", listener.Addr()), "auth", n.rpcAuth.Enabled())
 	// All listeners booted successfully
 	n.wsEndpoint = endpoint
 	n.wsListener = listener
@@ -517,6 +547,8 @@ func (n *Node) Stop() error {
 	n.stopHTTP()
 	n.stopIPC()
 	n.rpcAPIs = nil
+	n.httpServices = nil
+	n.rpcAuth = nil
 	failure := &StopError{
 		Services: make(map[reflect.Type]error),
 	}
@@ -5,6 +5,7 @@
 package pod
 
 import (
+	"net/http"
 	"reflect"
 
 	"github.com/MatrixAINetwork/go-matrix/mc"
@@ -98,3 +99,10 @@ type Service interface {
 	// are all terminated.
 	Stop() error
 }
+
+// HTTPService is an optional interface of services that serve plain HTTP handlers
+// (e.g. GraphQL) on the HTTP-RPC endpoint. Handlers are keyed by their path and
+// share the endpoint's CORS and virtual host settings.
+type HTTPService interface {
+	HTTPHandlers() map[string]http.Handler
+}
@@ -35,52 +35,11 @@ func (self *ReElection) GetElectConfig(hash common.Hash) (*mc.ElectConfigInfo_Al
 		log.Error("GetElectInfo", "获取state失败", err, "hash", hash)
 		return nil, err
 	}
-	electInfo, err := matrixstate.GetElectConfigInfo(st)
-	if err != nil || electInfo == nil {
-		log.Error("GetElectInfo", "获取选举基础信息失败 err", err)
-		return nil, err
-	}
-
-	electMinerNum, err := matrixstate.GetElectMinerNum(st)
-	if err != nil {
-		log.Error("MSKeyElectMinerNum", "获取MSKeyElectMinerNum err", err)
-		return nil, err
-	}
-
-	blackList, err := matrixstate.GetElectBlackList(st)
+	elect, err := matrixstate.GetElectConfigInfoAll(st)
 	if err != nil {
-		log.Error("MSKeyElectBlackList", "MSKeyElectBlackList", "反射失败", "hash", hash)
+		log.Error("GetElectInfo", "获取选举配置失败 err", err, "hash", hash)
 		return nil, err
 	}
-
-	innerMiners, err := matrixstate.GetInnerMinerAccounts(st)
-	if err != nil {
-		log.Error("MSKeyInnerList", "MSKeyAccountInnerMiners", "反射失败", "hash", hash)
-		return nil, err
-	}
-	blackList = append(blackList, innerMiners...)
-
-	whiteList, err := matrixstate.GetElectWhiteList(st)
-	if err != nil {
-		log.Error("MSKeyElectWhiteList", "MSKeyElectWhiteList", "反射失败", "hash", hash)
-		return nil, err
-	}
-
-	whiteListSwitcher, err := matrixstate.GetElectWhiteListSwitcher(st)
-	if err != nil {
-		log.Error("MSKeyElectWhiteList", "MSKeyElectWhiteList", "反射失败", "hash", hash)
-		return nil, err
-	}
-	elect := &mc.ElectConfigInfo_All{
-		MinerNum:          electMinerNum.MinerNum,
-		ValidatorNum:      electInfo.ValidatorNum,
-		BackValidator:     electInfo.BackValidator,
-		ElectPlug:         electInfo.ElectPlug,
-		WhiteList:         whiteList,
-		BlackList:         blackList,
-		WhiteListSwitcher: whiteListSwitcher,
-	}
-
 	return elect, nil
 }
 func (self *ReElection) GetViPList(hash common.Hash) ([]mc.VIPConfig, error) {
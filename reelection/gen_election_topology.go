@@ -105,7 +105,7 @@ func (p *ReElection) getOnlineStatus(version string, onlineResults []*mc.HD_Onli
 		// 节点为当前拓扑图节点
 		if topology.AccountIsInGraph(node) {
 			if state == mc.OffLine {
-				if manversion.VersionCmp(version, manversion.VersionGamma) >= 0 {
+				if manversion.IsEnabled(manversion.FeatureGamma, version) {
 					if node == ca.GetDepositAddress() {
 						log.Trace(Module, "生成拓扑变化信息", "不将自己的下线共识放入出块共识中", "状态", state, "node", node.Hex())
 						continue
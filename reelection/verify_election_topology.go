@@ -110,7 +110,7 @@ func (p *ReElection) verifyChgNetTopology(version string, header *types.Header,
 	if len(header.NetTopology.NetTopologyData) == 0 {
 		return nil
 	}
-	if manversion.VersionCmp(version, manversion.VersionGamma) >= 0 {
+	if manversion.IsEnabled(manversion.FeatureGamma, version) {
 		for _, item := range onlineConsensusResults {
 			if item.Req.Node == header.Leader {
 				log.Warn(Module, "verifyChgNetTopology", "leader出块共识中存在自己的下线共识", "leader", header.Leader.Hex())
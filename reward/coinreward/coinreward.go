@@ -0,0 +1,225 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package coinreward
+
+import (
+	"math/big"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/matrixstate"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/params/manversion"
+	"github.com/MatrixAINetwork/go-matrix/reward/cfg"
+	"github.com/MatrixAINetwork/go-matrix/reward/leaderreward"
+	"github.com/MatrixAINetwork/go-matrix/reward/mineroutreward"
+	"github.com/MatrixAINetwork/go-matrix/reward/rewardexec"
+	"github.com/MatrixAINetwork/go-matrix/reward/selectedreward"
+	"github.com/MatrixAINetwork/go-matrix/reward/util"
+)
+
+const (
+	PackageName = "多币种奖励"
+
+	MaxPayoutHistory = 100 //每个币种保留的发放记录数
+)
+
+// 奖励池的出块奖励直接发给上一个出块矿工，不经过前矿工奖励的状态记录
+type poolSetRewards struct {
+	leader      leaderreward.LeaderReward
+	selected    selectedreward.SelectedReward
+	innerMiners []common.Address
+	bcInterval  *mc.BCIntervalInfo
+}
+
+func (psr *poolSetRewards) SetLeaderRewards(reward *big.Int, Leader common.Address, num uint64) map[common.Address]*big.Int {
+	return psr.leader.SetLeaderRewards(reward, Leader, num)
+}
+
+func (psr *poolSetRewards) GetSelectedRewards(reward *big.Int, state util.StateDB, roleType common.RoleType, number uint64, rate uint64, topology *mc.TopologyGraph, elect *mc.ElectGraph) map[common.Address]*big.Int {
+	return psr.selected.GetSelectedRewards(reward, state, roleType, number, rate, topology, elect)
+}
+
+func (psr *poolSetRewards) SetMinerOutRewards(_, reward *big.Int, state util.StateDB, chain util.ChainReader, num uint64, parentHash common.Hash, coinType string) map[common.Address]*big.Int {
+	coinbase, err := mineroutreward.GetMinerOutCoinbase(num, reward, chain, psr.bcInterval, parentHash, psr.innerMiners)
+	if nil != err {
+		return nil
+	}
+	rewards := make(map[common.Address]*big.Int)
+	util.SetAccountRewards(rewards, coinbase, reward)
+	log.Debug(PackageName, "币种", coinType, "出块矿工", coinbase.String(), "奖励金额", reward)
+	return rewards
+}
+
+type CoinReward struct {
+	chain             util.ChainReader
+	st                util.StateDB
+	cfgList           []mc.CoinRewardCfg
+	coinConfig        []common.CoinConfig
+	bcInterval        *mc.BCIntervalInfo
+	foundationAccount common.Address
+	innerMiners       []common.Address
+	topology          *mc.TopologyGraph
+	elect             *mc.ElectGraph
+}
+
+// New 读取多币种奖励池配置，没有配置任何币种时返回nil
+func New(chain util.ChainReader, st util.StateDB, preSt util.StateDB, ppreSt util.StateDB, coinConfig []common.CoinConfig) *CoinReward {
+	if !manversion.IsEnabled(manversion.FeatureDelta, matrixstate.GetVersionInfo(preSt)) {
+		return nil
+	}
+	cfgList, err := matrixstate.GetCoinRewardCfg(preSt)
+	if nil != err {
+		log.Error(PackageName, "获取状态树配置错误", err)
+		return nil
+	}
+	if 0 == len(cfgList.CfgList) {
+		return nil
+	}
+
+	interval, err := matrixstate.GetBroadcastInterval(preSt)
+	if err != nil {
+		log.Error(PackageName, "获取广播周期失败", err)
+		return nil
+	}
+	foundationAccount, err := matrixstate.GetFoundationAccount(preSt)
+	if err != nil {
+		log.Error(PackageName, "获取基金会账户数据失败", err)
+		return nil
+	}
+	innerMinerAccounts, err := matrixstate.GetInnerMinerAccounts(ppreSt)
+	if err != nil {
+		log.Error(PackageName, "获取内部矿工账户数据失败", err)
+		return nil
+	}
+	currentTop, originElectNodes, err := chain.GetGraphByState(preSt)
+	if err != nil {
+		log.Error(PackageName, "获取拓扑图错误", err)
+		return nil
+	}
+
+	return &CoinReward{
+		chain:             chain,
+		st:                st,
+		cfgList:           cfgList.CfgList,
+		coinConfig:        coinConfig,
+		bcInterval:        interval,
+		foundationAccount: foundationAccount,
+		innerMiners:       innerMinerAccounts,
+		topology:          currentTop,
+		elect:             originElectNodes,
+	}
+}
+
+func (cr *CoinReward) findCoinConfig(coinType string) *common.CoinConfig {
+	for i := range cr.coinConfig {
+		if cr.coinConfig[i].CoinType == coinType {
+			return &cr.coinConfig[i]
+		}
+	}
+	return nil
+}
+
+// CalcRewardMount 计算奖励池在当前高度的矿工和验证者奖励总额，金额单位为币种的CoinUnit
+func CalcRewardMount(rewardCfg *mc.BlkRewardCfg, coinUnit *big.Int, num uint64) (*big.Int, *big.Int) {
+	if nil == coinUnit || coinUnit.Sign() <= 0 {
+		coinUnit = util.ManPrice
+	}
+	minerMount := new(big.Int).Mul(new(big.Int).SetUint64(rewardCfg.MinerMount), coinUnit)
+	validatorMount := new(big.Int).Mul(new(big.Int).SetUint64(rewardCfg.ValidatorMount), coinUnit)
+	minerReward := util.CalcRewardMount(minerMount, util.CalcN(rewardCfg.MinerAttenuationNum, num-1), rewardCfg.MinerAttenuationRate)
+	validatorReward := util.CalcRewardMount(validatorMount, util.CalcN(rewardCfg.ValidatorAttenuationNum, num-1), rewardCfg.ValidatorAttenuationRate)
+	return minerReward, validatorReward
+}
+
+// CalcRewards 计算各币种奖励池的发放交易，并记录发放历史。余额不足的奖励池本区块不发放
+func (cr *CoinReward) CalcRewards(Leader common.Address, num uint64, parentHash common.Hash) []common.RewarTx {
+	if cr.bcInterval.IsBroadcastNumber(num) {
+		return nil
+	}
+	poolList, err := matrixstate.GetCoinRewardPool(cr.st)
+	if nil != err {
+		log.Error(PackageName, "获取奖励池发放记录错误", err)
+		return nil
+	}
+
+	rewardList := make([]common.RewarTx, 0)
+	for i := range cr.cfgList {
+		poolCfg := &cr.cfgList[i]
+		coinConfig := cr.findCoinConfig(poolCfg.CoinType)
+		if nil == coinConfig {
+			log.Warn(PackageName, "币种不存在，不发放奖励", poolCfg.CoinType)
+			continue
+		}
+		var coinUnit *big.Int
+		if nil != coinConfig.CoinUnit {
+			coinUnit = coinConfig.CoinUnit.ToInt()
+		}
+		minerReward, validatorReward := CalcRewardMount(&poolCfg.BlkRewardCfg, coinUnit, num)
+		total := new(big.Int).Add(minerReward, validatorReward)
+		if total.Sign() <= 0 {
+			continue
+		}
+		balance := cr.st.GetBalance(poolCfg.CoinType, common.CoinRewardPoolAddress)
+		if len(balance) == 0 || balance[common.MainAccount].Balance.Cmp(total) < 0 {
+			log.Warn(PackageName, "奖励池余额不足，不发放奖励，币种", poolCfg.CoinType, "奖励总额", total)
+			continue
+		}
+
+		rewardCfg := cfg.New(&poolCfg.BlkRewardCfg, &poolSetRewards{innerMiners: cr.innerMiners, bcInterval: cr.bcInterval}, nil, cr.innerMiners, util.BlkReward, "")
+		blockReward := rewardexec.New(cr.chain, rewardCfg, cr.st, cr.bcInterval, cr.foundationAccount, cr.topology, cr.elect)
+		if nil == blockReward {
+			log.Error(PackageName, "奖励池配置错误，币种", poolCfg.CoinType)
+			continue
+		}
+		minerRewards, validatorRewards := blockReward.CalcPoolRewards(minerReward, validatorReward, Leader, num, parentHash, poolCfg.CoinType)
+		minerPaid, validatorPaid := rewardSum(minerRewards), rewardSum(validatorRewards)
+		if 0 != len(minerRewards) {
+			rewardList = append(rewardList, common.RewarTx{CoinRange: coinConfig.CoinRange, CoinType: coinConfig.CoinType, Fromaddr: common.CoinRewardPoolAddress, To_Amont: minerRewards, RewardTyp: common.RewardMinerType})
+		}
+		if 0 != len(validatorRewards) {
+			rewardList = append(rewardList, common.RewarTx{CoinRange: coinConfig.CoinRange, CoinType: coinConfig.CoinType, Fromaddr: common.CoinRewardPoolAddress, To_Amont: validatorRewards, RewardTyp: common.RewardValidatorType})
+		}
+		if minerPaid.Sign() > 0 || validatorPaid.Sign() > 0 {
+			recordPayout(poolList, poolCfg.CoinType, mc.CoinRewardPayout{Number: num, MinerAmount: minerPaid, ValidatorAmount: validatorPaid})
+		}
+	}
+	if 0 != len(rewardList) {
+		if err := matrixstate.SetCoinRewardPool(cr.st, poolList); err != nil {
+			log.Error(PackageName, "写奖励池发放记录错误", err)
+			return nil
+		}
+	}
+	return rewardList
+}
+
+func rewardSum(rewards map[common.Address]*big.Int) *big.Int {
+	sum := big.NewInt(0)
+	for _, v := range rewards {
+		sum.Add(sum, v)
+	}
+	return sum
+}
+
+func recordPayout(poolList *mc.CoinRewardPoolList, coinType string, payout mc.CoinRewardPayout) {
+	var info *mc.CoinRewardPoolInfo
+	for i := range poolList.PoolList {
+		if poolList.PoolList[i].CoinType == coinType {
+			info = &poolList.PoolList[i]
+			break
+		}
+	}
+	if nil == info {
+		poolList.PoolList = append(poolList.PoolList, mc.CoinRewardPoolInfo{CoinType: coinType, TotalPaid: big.NewInt(0)})
+		info = &poolList.PoolList[len(poolList.PoolList)-1]
+	}
+	if nil == info.TotalPaid {
+		info.TotalPaid = big.NewInt(0)
+	}
+	info.TotalPaid = new(big.Int).Add(info.TotalPaid, new(big.Int).Add(payout.MinerAmount, payout.ValidatorAmount))
+	info.History = append(info.History, payout)
+	if len(info.History) > MaxPayoutHistory {
+		info.History = info.History[len(info.History)-MaxPayoutHistory:]
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package coinreward
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/mc"
+)
+
+func TestCalcRewardMount(t *testing.T) {
+	rewardCfg := &mc.BlkRewardCfg{
+		MinerMount:               10,
+		MinerAttenuationRate:     5000,
+		MinerAttenuationNum:      100,
+		ValidatorMount:           6,
+		ValidatorAttenuationRate: 5000,
+		ValidatorAttenuationNum:  100,
+	}
+	unit := big.NewInt(1000)
+
+	miner, validator := CalcRewardMount(rewardCfg, unit, 50)
+	if miner.Cmp(big.NewInt(10000)) != 0 || validator.Cmp(big.NewInt(6000)) != 0 {
+		t.Fatalf("first period reward error, miner %v validator %v", miner, validator)
+	}
+	miner, validator = CalcRewardMount(rewardCfg, unit, 150)
+	if miner.Cmp(big.NewInt(5000)) != 0 || validator.Cmp(big.NewInt(3000)) != 0 {
+		t.Fatalf("attenuated reward error, miner %v validator %v", miner, validator)
+	}
+}
+
+func TestRecordPayout(t *testing.T) {
+	poolList := &mc.CoinRewardPoolList{}
+	for i := uint64(1); i <= MaxPayoutHistory+10; i++ {
+		recordPayout(poolList, "BTC", mc.CoinRewardPayout{Number: i, MinerAmount: big.NewInt(2), ValidatorAmount: big.NewInt(1)})
+	}
+	recordPayout(poolList, "ETH", mc.CoinRewardPayout{Number: 1, MinerAmount: big.NewInt(5), ValidatorAmount: big.NewInt(0)})
+
+	if len(poolList.PoolList) != 2 {
+		t.Fatalf("pool count error %d", len(poolList.PoolList))
+	}
+	btc := poolList.PoolList[0]
+	if len(btc.History) != MaxPayoutHistory || btc.History[0].Number != 11 {
+		t.Fatalf("history not trimmed, len %d first %d", len(btc.History), btc.History[0].Number)
+	}
+	if btc.TotalPaid.Cmp(big.NewInt(3*(MaxPayoutHistory+10))) != 0 {
+		t.Fatalf("total paid error %v", btc.TotalPaid)
+	}
+	if poolList.PoolList[1].TotalPaid.Cmp(big.NewInt(5)) != 0 {
+		t.Fatalf("total paid error %v", poolList.PoolList[1].TotalPaid)
+	}
+}
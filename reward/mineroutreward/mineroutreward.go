@@ -145,6 +145,11 @@ func (mr *MinerOutReward) SetMinerOutRewards(_, curReward *big.Int, state util.S
 	return rewards
 }
 
+// GetMinerOutCoinbase 获取应发放出块奖励的矿工账户，跳过广播区块、超级区块和基金会矿工
+func GetMinerOutCoinbase(num uint64, reward *big.Int, reader util.ChainReader, bcInterval *mc.BCIntervalInfo, parentHash common.Hash, innerMiners []common.Address) (common.Address, error) {
+	return new(MinerOutReward).canSetMinerOutRewards(num, reward, reader, bcInterval, parentHash, innerMiners)
+}
+
 func (mr *MinerOutReward) canSetMinerOutRewards(num uint64, reward *big.Int, reader util.ChainReader, bcInterval *mc.BCIntervalInfo, parentHash common.Hash, innerMiners []common.Address) (common.Address, error) {
 	if num < 2 {
 		log.Debug(PackageName, "高度为小于2 不发放奖励：", "")
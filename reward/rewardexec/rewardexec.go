@@ -197,6 +197,28 @@ func (br *BlockReward) CalcNodesRewards(blockReward *big.Int, Leader common.Addr
 	return rewards
 }
 
+// CalcPoolRewards 按奖励池给出的金额计算矿工和验证者奖励，余额由调用方检查
+func (br *BlockReward) CalcPoolRewards(minerReward *big.Int, validatorReward *big.Int, Leader common.Address, num uint64, parentHash common.Hash, coinType string) (map[common.Address]*big.Int, map[common.Address]*big.Int) {
+	if nil == br.rewardCfg {
+		log.Error(PackageName, "奖励配置为空", "")
+		return nil, nil
+	}
+
+	if br.bcInterval.IsBroadcastNumber(num) {
+		log.Warn(PackageName, "广播周期不处理", "")
+		return nil, nil
+	}
+
+	var minerRewards, validatorRewards map[common.Address]*big.Int
+	if minerReward.Sign() > 0 {
+		minerRewards = br.getMinerRewards(minerReward, num, util.BlkReward, parentHash, coinType)
+	}
+	if validatorReward.Sign() > 0 {
+		validatorRewards = br.getValidatorRewards(validatorReward, Leader, num)
+	}
+	return minerRewards, validatorRewards
+}
+
 func (br *BlockReward) GetRewardCfg() *cfg.RewardCfg {
 
 	return br.rewardCfg
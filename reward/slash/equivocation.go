@@ -0,0 +1,242 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package slash
+
+import (
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/matrixstate"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/core/vm"
+	"github.com/MatrixAINetwork/go-matrix/crypto"
+	"github.com/MatrixAINetwork/go-matrix/depoistInfo"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/params"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrEquivocationDisabled  = errors.New("equivocation slash is disabled")
+	ErrEquivocationExist     = errors.New("equivocation evidence already submitted")
+	ErrEquivocationExpired   = errors.New("equivocation evidence expired")
+	ErrEquivocationNotSigned = errors.New("equivocation evidence signer mismatch")
+	ErrEquivocationContent   = errors.New("equivocation evidence content is not conflicting")
+)
+
+// EquivocationSignHashes 返回证据中两个被签名内容的hash，并检查两者确实冲突
+func EquivocationSignHashes(ev *mc.EquivocationEvidence) (common.Hash, common.Hash, error) {
+	switch ev.Kind {
+	case mc.EquivocationKindBlock, mc.EquivocationKindPOSReq:
+		if nil == ev.HeaderA || nil == ev.HeaderB || nil == ev.HeaderA.Number || nil == ev.HeaderB.Number {
+			return common.Hash{}, common.Hash{}, errors.New("equivocation evidence header is nil")
+		}
+		a, b := ev.HeaderA, ev.HeaderB
+		if a.Number.Uint64() != ev.Number || b.Number.Uint64() != ev.Number {
+			return common.Hash{}, common.Hash{}, errors.Errorf("header number mismatch, evidence %d, headers %d %d", ev.Number, a.Number.Uint64(), b.Number.Uint64())
+		}
+		// 签名内容中没有共识轮次，leader在每个轮次按轮次开始时间重新生成区块头，
+		// 同一父区块、同一leader、同一时间戳的区块头才能认定为同一轮次；同一leader被重新选中时时间戳不同，不算双签
+		if a.ParentHash != b.ParentHash || a.Leader != b.Leader || nil == a.Time || nil == b.Time || a.Time.Cmp(b.Time) != 0 {
+			return common.Hash{}, common.Hash{}, ErrEquivocationContent
+		}
+		if ev.Kind == mc.EquivocationKindPOSReq && a.Leader != ev.Account {
+			return common.Hash{}, common.Hash{}, errors.Errorf("POS request equivocation account %s is not leader %s", ev.Account.Hex(), a.Leader.Hex())
+		}
+		hashA, hashB := a.HashNoSignsAndNonce(), b.HashNoSignsAndNonce()
+		if hashA == hashB {
+			return common.Hash{}, common.Hash{}, ErrEquivocationContent
+		}
+		return hashA, hashB, nil
+
+	case mc.EquivocationKindOnline:
+		if nil == ev.OnlineA || nil == ev.OnlineB {
+			return common.Hash{}, common.Hash{}, errors.New("equivocation evidence online request is nil")
+		}
+		a, b := ev.OnlineA, ev.OnlineB
+		if a.Number != ev.Number || b.Number != ev.Number {
+			return common.Hash{}, common.Hash{}, errors.Errorf("online request number mismatch, evidence %d, requests %d %d", ev.Number, a.Number, b.Number)
+		}
+		if a.LeaderTurn != b.LeaderTurn || a.Leader != b.Leader || a.Node != b.Node || a.OnlineState == b.OnlineState {
+			return common.Hash{}, common.Hash{}, ErrEquivocationContent
+		}
+		return types.RlpHash(a), types.RlpHash(b), nil
+
+	default:
+		return common.Hash{}, common.Hash{}, errors.Errorf("unknown equivocation kind %d", ev.Kind)
+	}
+}
+
+// 只有赞成票才构成双签，反对票对应的签名内容是hash+1
+func recoverVoteSigner(hash common.Hash, sign common.Signature) (common.Address, error) {
+	signer, validate, err := crypto.VerifySignWithValidate(hash.Bytes(), sign.Bytes())
+	if err != nil {
+		return common.Address{}, err
+	}
+	if !validate {
+		return common.Address{}, errors.New("equivocation evidence contains a reject vote")
+	}
+	return signer, nil
+}
+
+// 签名账户可能是A1账户或A2(委托)账户，换算为A0抵押账户
+func depositAccountOf(st vm.StateDBManager, signer common.Address, number uint64) common.Address {
+	if account := depoistInfo.GetDepositAccount(st, signer); account != (common.Address{}) {
+		return account
+	}
+	a1 := st.GetAuthFrom(params.MAN_COIN, signer, number)
+	if a1 == (common.Address{}) {
+		return common.Address{}
+	}
+	return depoistInfo.GetDepositAccount(st, a1)
+}
+
+// VerifyEquivocation 校验双签证据：内容冲突、两个签名来自同一签名账户、签名账户属于证据中的抵押账户
+func VerifyEquivocation(st vm.StateDBManager, ev *mc.EquivocationEvidence) (common.Hash, common.Hash, error) {
+	if nil == ev {
+		return common.Hash{}, common.Hash{}, errors.New("equivocation evidence is nil")
+	}
+	hashA, hashB, err := EquivocationSignHashes(ev)
+	if err != nil {
+		return common.Hash{}, common.Hash{}, err
+	}
+	signerA, err := recoverVoteSigner(hashA, ev.SignA)
+	if err != nil {
+		return common.Hash{}, common.Hash{}, err
+	}
+	signerB, err := recoverVoteSigner(hashB, ev.SignB)
+	if err != nil {
+		return common.Hash{}, common.Hash{}, err
+	}
+	if signerA != signerB || signerA != ev.Signer {
+		return common.Hash{}, common.Hash{}, ErrEquivocationNotSigned
+	}
+	if account := depositAccountOf(st, ev.Signer, ev.Number); account == (common.Address{}) || account != ev.Account {
+		return common.Hash{}, common.Hash{}, errors.Errorf("signer %s does not belong to deposit account %s", ev.Signer.Hex(), ev.Account.Hex())
+	}
+	return hashA, hashB, nil
+}
+
+func equivocationRecorded(records *mc.EquivocationRecords, kind uint8, account common.Address, number uint64) bool {
+	for _, list := range [][]mc.EquivocationRecord{records.Pending, records.History} {
+		for _, v := range list {
+			if v.Kind == kind && v.Number == number && v.Account.Equal(account) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SubmitEquivocation 校验双签证据并加入待惩罚列表，任一证据不合法时整体失败，状态不做修改
+func SubmitEquivocation(st vm.StateDBManager, evidences []mc.EquivocationEvidence, num uint64) error {
+	if !evidenceSupported(st) {
+		return errors.Errorf("equivocation evidence not supported in version %s", matrixstate.GetVersionInfo(st))
+	}
+	if 0 == len(evidences) {
+		return errors.New("equivocation evidence list is empty")
+	}
+	cfg, err := matrixstate.GetEquivocationSlashCfg(st)
+	if nil != err {
+		return err
+	}
+	if !cfg.Switcher {
+		return ErrEquivocationDisabled
+	}
+	records, err := matrixstate.GetEquivocationRecords(st)
+	if nil != err {
+		return err
+	}
+
+	for i := range evidences {
+		ev := &evidences[i]
+		if ev.Number >= num {
+			return errors.Errorf("equivocation number %d is not lower than current number %d", ev.Number, num)
+		}
+		if cfg.ValidNumber != 0 && num-ev.Number > cfg.ValidNumber {
+			return ErrEquivocationExpired
+		}
+		if equivocationRecorded(records, ev.Kind, ev.Account, ev.Number) {
+			return ErrEquivocationExist
+		}
+		hashA, hashB, err := VerifyEquivocation(st, ev)
+		if err != nil {
+			return err
+		}
+		log.Info(PackageName, "提交双签证据，类型", ev.Kind, "账户", ev.Account, "作恶高度", ev.Number, "提交高度", num)
+		records.Pending = append(records.Pending, mc.EquivocationRecord{
+			Kind:         ev.Kind,
+			Account:      ev.Account,
+			Number:       ev.Number,
+			SignHashA:    hashA,
+			SignHashB:    hashB,
+			SubmitNumber: num,
+		})
+	}
+	return matrixstate.SetEquivocationRecords(st, records)
+}
+
+// ProcessEquivocation 对已上链的双签证据执行惩罚：加入出块黑名单并记录惩罚证据
+func ProcessEquivocation(st vm.StateDBManager, num uint64) {
+	if !evidenceSupported(st) {
+		return
+	}
+	records, err := matrixstate.GetEquivocationRecords(st)
+	if nil != err {
+		log.Error(PackageName, "获取双签证据错误", err)
+		return
+	}
+	if 0 == len(records.Pending) {
+		return
+	}
+	cfg, err := matrixstate.GetEquivocationSlashCfg(st)
+	if nil != err {
+		log.Error(PackageName, "获取双签惩罚配置错误", err)
+		return
+	}
+	blackList, err := matrixstate.GetBlockProduceBlackList(st)
+	if nil != err {
+		log.Error(PackageName, "获取出块黑名单错误", err)
+		return
+	}
+
+	evidences := make([]mc.SlashEvidence, 0, len(records.Pending))
+	for _, v := range records.Pending {
+		found := false
+		for k := range blackList.BlackList {
+			if blackList.BlackList[k].Address.Equal(v.Account) {
+				found = true
+				if blackList.BlackList[k].ProhibitCycleCounter < cfg.ProhibitCycleNum {
+					blackList.BlackList[k].ProhibitCycleCounter = cfg.ProhibitCycleNum
+				}
+			}
+		}
+		if !found {
+			blackList.BlackList = append(blackList.BlackList, mc.UserBlockProduceSlash{Address: v.Account, ProhibitCycleCounter: cfg.ProhibitCycleNum})
+		}
+		log.Info(PackageName, "双签惩罚，账户", v.Account, "作恶高度", v.Number, "禁止周期", cfg.ProhibitCycleNum)
+		evidences = append(evidences, mc.SlashEvidence{
+			Type:             mc.SlashTypeEquivocation,
+			Address:          v.Account,
+			Number:           num,
+			StatsStartNumber: v.Number,
+			ProhibitCycleNum: cfg.ProhibitCycleNum,
+		})
+		v.SlashNumber = num
+		records.History = append(records.History, v)
+	}
+	records.Pending = make([]mc.EquivocationRecord, 0)
+	if cfg.MaxRecords != 0 && len(records.History) > int(cfg.MaxRecords) {
+		records.History = records.History[len(records.History)-int(cfg.MaxRecords):]
+	}
+
+	if err := matrixstate.SetBlockProduceBlackList(st, blackList); err != nil {
+		log.Error(PackageName, "写出块黑名单错误", err)
+		return
+	}
+	if err := matrixstate.SetEquivocationRecords(st, records); err != nil {
+		log.Error(PackageName, "写双签证据错误", err)
+		return
+	}
+	RecordEvidence(st, evidences...)
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package slash
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/matrixstate"
+	"github.com/MatrixAINetwork/go-matrix/core/state"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/crypto"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/params/manversion"
+)
+
+func equivocationHeader(extra string, time int64) *types.Header {
+	return &types.Header{
+		ParentHash: common.HexToHash("0x01"),
+		Leader:     common.HexToAddress("0x1000"),
+		Number:     big.NewInt(10),
+		Difficulty: big.NewInt(1),
+		Time:       big.NewInt(time),
+		Extra:      []byte(extra),
+	}
+}
+
+func TestEquivocationSignHashes(t *testing.T) {
+	a, b := equivocationHeader("a", 1), equivocationHeader("b", 1)
+	ev := &mc.EquivocationEvidence{Kind: mc.EquivocationKindBlock, Number: 10, HeaderA: a, HeaderB: b}
+	hashA, hashB, err := EquivocationSignHashes(ev)
+	if err != nil || hashA != a.HashNoSignsAndNonce() || hashB != b.HashNoSignsAndNonce() {
+		t.Fatalf("conflicting headers rejected: %v", err)
+	}
+
+	// 同一leader在不同轮次生成的区块头时间戳不同，不构成双签
+	ev.HeaderB = equivocationHeader("b", 20)
+	if _, _, err := EquivocationSignHashes(ev); err != ErrEquivocationContent {
+		t.Fatalf("err = %v, want %v", err, ErrEquivocationContent)
+	}
+	ev.HeaderB = a
+	if _, _, err := EquivocationSignHashes(ev); err != ErrEquivocationContent {
+		t.Fatalf("identical headers: err = %v, want %v", err, ErrEquivocationContent)
+	}
+
+	// 在线共识请求不同轮次不构成双签
+	reqA := &mc.OnlineConsensusReq{Number: 10, LeaderTurn: 1, Leader: common.HexToAddress("0x1000"), Node: common.HexToAddress("0x3000"), OnlineState: mc.OnLine}
+	reqB := *reqA
+	reqB.OnlineState = mc.OffLine
+	online := &mc.EquivocationEvidence{Kind: mc.EquivocationKindOnline, Number: 10, OnlineA: reqA, OnlineB: &reqB}
+	if _, _, err := EquivocationSignHashes(online); err != nil {
+		t.Fatalf("conflicting online requests rejected: %v", err)
+	}
+	reqB.LeaderTurn = 2
+	if _, _, err := EquivocationSignHashes(online); err != ErrEquivocationContent {
+		t.Fatalf("err = %v, want %v", err, ErrEquivocationContent)
+	}
+}
+
+func TestVerifyEquivocationSigner(t *testing.T) {
+	keyA, _ := crypto.GenerateKey()
+	keyB, _ := crypto.GenerateKey()
+	a, b := equivocationHeader("a", 1), equivocationHeader("b", 1)
+	signA, _ := crypto.SignWithValidate(a.HashNoSignsAndNonce().Bytes(), true, keyA)
+	signB, _ := crypto.SignWithValidate(b.HashNoSignsAndNonce().Bytes(), true, keyB)
+	ev := &mc.EquivocationEvidence{
+		Kind:    mc.EquivocationKindBlock,
+		Number:  10,
+		Signer:  crypto.PubkeyToAddress(keyA.PublicKey),
+		HeaderA: a,
+		HeaderB: b,
+		SignA:   common.BytesToSignature(signA),
+		SignB:   common.BytesToSignature(signB),
+	}
+	// 两个签名来自不同账户
+	if _, _, err := VerifyEquivocation(nil, ev); err != ErrEquivocationNotSigned {
+		t.Fatalf("err = %v, want %v", err, ErrEquivocationNotSigned)
+	}
+	// 反对票不构成双签
+	rejectB, _ := crypto.SignWithValidate(b.HashNoSignsAndNonce().Bytes(), false, keyA)
+	ev.SignB = common.BytesToSignature(rejectB)
+	if _, _, err := VerifyEquivocation(nil, ev); err == nil {
+		t.Fatal("reject vote accepted as equivocation")
+	}
+}
+
+func TestProcessEquivocation(t *testing.T) {
+	chaindb := mandb.NewMemDatabase()
+	st, _ := state.NewStateDBManage(nil, chaindb, state.NewDatabase(chaindb))
+	matrixstate.SetVersionInfo(st, manversion.VersionDelta)
+
+	account := common.HexToAddress("0x2000")
+	cfg := &mc.EquivocationSlashCfg{Switcher: false, ProhibitCycleNum: 4, ValidNumber: 100, MaxRecords: 10}
+	if err := matrixstate.SetEquivocationSlashCfg(st, cfg); err != nil {
+		t.Fatalf("set cfg failed: %v", err)
+	}
+	ev := mc.EquivocationEvidence{Kind: mc.EquivocationKindBlock, Number: 10, Account: account}
+	if err := SubmitEquivocation(st, []mc.EquivocationEvidence{ev}, 20); err != ErrEquivocationDisabled {
+		t.Fatalf("err = %v, want %v", err, ErrEquivocationDisabled)
+	}
+	cfg.Switcher = true
+	matrixstate.SetEquivocationSlashCfg(st, cfg)
+	if err := SubmitEquivocation(st, []mc.EquivocationEvidence{ev}, 200); err != ErrEquivocationExpired {
+		t.Fatalf("err = %v, want %v", err, ErrEquivocationExpired)
+	}
+	ev.HeaderA, ev.HeaderB = equivocationHeader("a", 1), equivocationHeader("b", 20)
+	if err := SubmitEquivocation(st, []mc.EquivocationEvidence{ev}, 20); err != ErrEquivocationContent {
+		t.Fatalf("err = %v, want %v", err, ErrEquivocationContent)
+	}
+
+	records := &mc.EquivocationRecords{
+		Pending: []mc.EquivocationRecord{{Kind: mc.EquivocationKindBlock, Account: account, Number: 10, SubmitNumber: 20}},
+		History: make([]mc.EquivocationRecord, 0),
+	}
+	if err := matrixstate.SetEquivocationRecords(st, records); err != nil {
+		t.Fatalf("set records failed: %v", err)
+	}
+	ProcessEquivocation(st, 21)
+
+	blackList, err := matrixstate.GetBlockProduceBlackList(st)
+	if err != nil || len(blackList.BlackList) != 1 || blackList.BlackList[0].Address != account || blackList.BlackList[0].ProhibitCycleCounter != cfg.ProhibitCycleNum {
+		t.Fatalf("unexpected black list %+v, err %v", blackList, err)
+	}
+	records, err = matrixstate.GetEquivocationRecords(st)
+	if err != nil || len(records.Pending) != 0 || len(records.History) != 1 || records.History[0].SlashNumber != 21 {
+		t.Fatalf("unexpected records %+v, err %v", records, err)
+	}
+	// 已惩罚的证据不能重复提交
+	if err := SubmitEquivocation(st, []mc.EquivocationEvidence{ev}, 22); err != ErrEquivocationExist {
+		t.Fatalf("err = %v, want %v", err, ErrEquivocationExist)
+	}
+}
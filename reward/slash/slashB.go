@@ -149,10 +149,11 @@ func (bp *SlashDelta) CalcSlash(currentState *state.StateDBManage, num uint64, u
 		return
 	}
 
-	bp.SetSlash(electGraph, upTimeMap, interestCalcMap, currentState)
+	bp.SetSlash(electGraph, upTimeMap, interestCalcMap, currentState, num)
 }
 
-func (bp *SlashDelta) SetSlash(electGraph *mc.ElectGraph, upTimeMap map[common.Address]uint64, allAccountInterest map[common.Address][]common.OperationalInterestSlash, currentState *state.StateDBManage) {
+func (bp *SlashDelta) SetSlash(electGraph *mc.ElectGraph, upTimeMap map[common.Address]uint64, allAccountInterest map[common.Address][]common.OperationalInterestSlash, currentState *state.StateDBManage, num uint64) {
+	evidences := make([]mc.SlashEvidence, 0)
 	for _, v := range electGraph.ElectList {
 		if v.Type == common.RoleValidator || v.Type == common.RoleBackupValidator {
 
@@ -168,19 +169,37 @@ func (bp *SlashDelta) SetSlash(electGraph *mc.ElectGraph, upTimeMap map[common.A
 			}
 			slashRate := bp.getSlashRate(upTime)
 
-			bp.addSlash(v.Account, bcInterest, currentState, slashRate)
-
+			positions := bp.addSlash(v.Account, bcInterest, currentState, slashRate)
+			if 0 == slashRate || 0 == len(positions) {
+				continue
+			}
+			evidences = append(evidences, mc.SlashEvidence{
+				Type:             mc.SlashTypeInterest,
+				Address:          v.Account,
+				Number:           num,
+				StatsStartNumber: bp.bcInterval.GetLastBroadcastNumber(),
+				ActualCount:      upTime,
+				ExpectCount:      bp.eleMaxOnlineTime,
+				SlashRate:        slashRate,
+				Positions:        positions,
+			})
 		}
 
 	}
+	RecordEvidence(currentState, evidences...)
 }
 
-func (bp *SlashDelta) addSlash(account common.Address, accountInterest []common.OperationalInterestSlash, currentState *state.StateDBManage, rate uint64) {
+// 返回本周期各仓位新增的惩罚金额
+func (bp *SlashDelta) addSlash(account common.Address, accountInterest []common.OperationalInterestSlash, currentState *state.StateDBManage, rate uint64) []mc.SlashPosition {
 
 	accountSlash, _ := depoistInfo.GetSlash_v2(currentState, account)
 	newSlashData := make([]common.OperationalInterestSlash, 0)
+	positions := make([]mc.SlashPosition, 0)
 	for _, bcInterest := range accountInterest {
 		slash := bp.getSlash(rate, bcInterest.OperAmount)
+		if slash.Cmp(big.NewInt(0)) > 0 {
+			positions = append(positions, mc.SlashPosition{Position: bcInterest.Position, Amount: new(big.Int).Set(slash)})
+		}
 		for _, slashData := range accountSlash.CalcDeposit {
 			if bcInterest.Position == slashData.Position {
 				slash = slashData.OperAmount.Add(slashData.OperAmount, slash)
@@ -193,6 +212,7 @@ func (bp *SlashDelta) addSlash(account common.Address, accountInterest []common.
 	}
 	accountSlash.CalcDeposit = newSlashData
 	depoistInfo.AddSlash_v2(currentState, account, accountSlash)
+	return positions
 }
 
 func (bp *SlashDelta) GetElectAndInterest(currentState *state.StateDBManage, num uint64, parentHash common.Hash, upTimeMap map[common.Address]uint64, time uint64) (map[common.Address][]common.OperationalInterestSlash, *mc.ElectGraph, error) {
@@ -0,0 +1,247 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package slash
+
+import (
+	"math/big"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/matrixstate"
+	"github.com/MatrixAINetwork/go-matrix/core/vm"
+	"github.com/MatrixAINetwork/go-matrix/depoistInfo"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/params/manversion"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrEvidenceNotFound    = errors.New("slash evidence not found")
+	ErrEvidenceReversed    = errors.New("slash evidence already reversed")
+	ErrEvidenceUnknownType = errors.New("unknown slash evidence type")
+	ErrEvidenceSuperseded  = errors.New("slash evidence superseded by a later record")
+	ErrEvidenceExpired     = errors.New("slash evidence prohibit cycle has expired")
+	ErrEvidenceSettled     = errors.New("slash evidence interest already settled")
+)
+
+// 证据记录从Delta版本开始支持，之前版本的状态树中没有对应的key
+func evidenceSupported(st matrixstate.StateDB) bool {
+	return manversion.IsEnabled(manversion.FeatureDelta, matrixstate.GetVersionInfo(st))
+}
+
+// RecordEvidence 将惩罚依据写入状态树，配置关闭时不做任何修改
+func RecordEvidence(st matrixstate.StateDB, evidences ...mc.SlashEvidence) {
+	if 0 == len(evidences) || !evidenceSupported(st) {
+		return
+	}
+	cfg, err := matrixstate.GetSlashEvidenceCfg(st)
+	if nil != err || nil == cfg {
+		log.Error(PackageName, "获取惩罚证据配置错误", err)
+		return
+	}
+	if !cfg.Switcher {
+		return
+	}
+	list, err := matrixstate.GetSlashEvidence(st)
+	if nil != err {
+		log.Error(PackageName, "获取惩罚证据列表错误", err)
+		return
+	}
+	for _, v := range evidences {
+		log.Debug(PackageName, "记录惩罚证据，类型", v.Type, "账户", v.Address, "高度", v.Number)
+		list.EvidenceList = append(list.EvidenceList, v)
+	}
+	if cfg.MaxRecords != 0 && len(list.EvidenceList) > int(cfg.MaxRecords) {
+		list.EvidenceList = list.EvidenceList[len(list.EvidenceList)-int(cfg.MaxRecords):]
+	}
+	if err := matrixstate.SetSlashEvidence(st, list); err != nil {
+		log.Error(PackageName, "写惩罚证据列表错误", err)
+	}
+}
+
+// GetEvidence 获取惩罚证据，address为空时返回全部
+func GetEvidence(st matrixstate.StateDB, address common.Address) ([]mc.SlashEvidence, error) {
+	if !evidenceSupported(st) {
+		return make([]mc.SlashEvidence, 0), nil
+	}
+	list, err := matrixstate.GetSlashEvidence(st)
+	if nil != err {
+		return nil, err
+	}
+	if address == (common.Address{}) {
+		return list.EvidenceList, nil
+	}
+	ret := make([]mc.SlashEvidence, 0)
+	for _, v := range list.EvidenceList {
+		if v.Address.Equal(address) {
+			ret = append(ret, v)
+		}
+	}
+	return ret, nil
+}
+
+// ReverseEvidence 撤销一条惩罚：从对应黑名单中移除，或退还尚未结算的利息惩罚，并将证据标记为已撤销。
+// 撤销只作用于该证据造成的惩罚：黑名单的禁止周期已被之后的证据重置、已经结束，或利息惩罚已经结算时返回错误
+func ReverseEvidence(st vm.StateDBManager, reversal mc.SlashReversal, num uint64) error {
+	if !evidenceSupported(st) {
+		return errors.Errorf("slash reversal not supported in version %s", matrixstate.GetVersionInfo(st))
+	}
+	list, err := matrixstate.GetSlashEvidence(st)
+	if nil != err {
+		return err
+	}
+	index := -1
+	for k, v := range list.EvidenceList {
+		if v.Type == reversal.Type && v.Number == reversal.Number && v.Address.Equal(reversal.Address) {
+			index = k
+			break
+		}
+	}
+	if index < 0 {
+		return ErrEvidenceNotFound
+	}
+	evidence := &list.EvidenceList[index]
+	if evidence.Reversed {
+		return ErrEvidenceReversed
+	}
+
+	switch evidence.Type {
+	case mc.SlashTypeBlockProduce, mc.SlashTypeEquivocation:
+		err = reverseBlockProduce(st, list.EvidenceList, index)
+	case mc.SlashTypeBasePower:
+		err = reverseBasePower(st, list.EvidenceList, index)
+	case mc.SlashTypeInterest:
+		err = reverseInterest(st, evidence)
+	default:
+		err = ErrEvidenceUnknownType
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Info(PackageName, "撤销惩罚，类型", evidence.Type, "账户", evidence.Address, "惩罚高度", evidence.Number, "撤销高度", num)
+	evidence.Reversed = true
+	evidence.ReverseNumber = num
+	return matrixstate.SetSlashEvidence(st, list)
+}
+
+// 出块惩罚和双签惩罚共用出块黑名单
+func sameBlackList(a, b uint8) bool {
+	blockProduce := func(t uint8) bool { return t == mc.SlashTypeBlockProduce || t == mc.SlashTypeEquivocation }
+	return a == b || (blockProduce(a) && blockProduce(b))
+}
+
+// banShared 检查证据index对应的黑名单禁止周期是否只由它造成。
+// 之后记录的同一黑名单证据会重置禁止周期，此时返回错误；之前记录的证据仍未撤销时，禁止周期无法区分归属，返回true
+func banShared(evidences []mc.SlashEvidence, index int) (bool, error) {
+	evidence := &evidences[index]
+	shared := false
+	for k, v := range evidences {
+		if k == index || v.Reversed || !v.Address.Equal(evidence.Address) || !sameBlackList(v.Type, evidence.Type) {
+			continue
+		}
+		if k > index {
+			return false, ErrEvidenceSuperseded
+		}
+		shared = true
+	}
+	return shared, nil
+}
+
+func reverseBlockProduce(st matrixstate.StateDB, evidences []mc.SlashEvidence, index int) error {
+	shared, err := banShared(evidences, index)
+	if nil != err {
+		return err
+	}
+	address := evidences[index].Address
+	blackList, err := matrixstate.GetBlockProduceBlackList(st)
+	if nil != err {
+		return err
+	}
+	banned := false
+	newList := make([]mc.UserBlockProduceSlash, 0, len(blackList.BlackList))
+	for _, v := range blackList.BlackList {
+		if !v.Address.Equal(address) {
+			newList = append(newList, v)
+		} else if v.ProhibitCycleCounter > 0 {
+			banned = true
+		}
+	}
+	if !banned {
+		return ErrEvidenceExpired
+	}
+	if shared {
+		log.Info(PackageName, "之前的惩罚证据未撤销，保留黑名单，账户", address)
+		return nil
+	}
+	return matrixstate.SetBlockProduceBlackList(st, &mc.BlockProduceSlashBlackList{BlackList: newList})
+}
+
+func reverseBasePower(st matrixstate.StateDB, evidences []mc.SlashEvidence, index int) error {
+	shared, err := banShared(evidences, index)
+	if nil != err {
+		return err
+	}
+	address := evidences[index].Address
+	blackList, err := matrixstate.GetBasePowerBlackList(st)
+	if nil != err {
+		return err
+	}
+	banned := false
+	newList := make([]mc.BasePowerSlash, 0, len(blackList.BlackList))
+	for _, v := range blackList.BlackList {
+		if !v.Address.Equal(address) {
+			newList = append(newList, v)
+		} else if v.ProhibitCycleCounter > 0 {
+			banned = true
+		}
+	}
+	if !banned {
+		return ErrEvidenceExpired
+	}
+	if shared {
+		log.Info(PackageName, "之前的惩罚证据未撤销，保留黑名单，账户", address)
+		return nil
+	}
+	return matrixstate.SetBasePowerBlackList(st, &mc.BasePowerSlashBlackList{BlackList: newList})
+}
+
+// 利息惩罚在支付利息时才结算，只能退还尚未结算的惩罚
+func reverseInterest(st vm.StateDBManager, evidence *mc.SlashEvidence) error {
+	if 0 == len(evidence.Positions) {
+		return errors.New("slash evidence has no position detail")
+	}
+	payNum, err := matrixstate.GetInterestPayNum(st)
+	if nil != err {
+		return err
+	}
+	if payNum >= evidence.Number {
+		return ErrEvidenceSettled
+	}
+	accountSlash, err := depoistInfo.GetSlash_v2(st, evidence.Address)
+	if nil != err {
+		return err
+	}
+	for _, pos := range evidence.Positions {
+		if nil == pos.Amount {
+			continue
+		}
+		found := false
+		for k, slashData := range accountSlash.CalcDeposit {
+			if pos.Position != slashData.Position || nil == slashData.OperAmount {
+				continue
+			}
+			remain := new(big.Int).Sub(slashData.OperAmount, pos.Amount)
+			if remain.Sign() < 0 {
+				return errors.Errorf("position %d slash %s less than evidence amount %s", pos.Position, slashData.OperAmount, pos.Amount)
+			}
+			accountSlash.CalcDeposit[k].OperAmount = remain
+			found = true
+		}
+		if !found {
+			return errors.Errorf("position %d has no pending slash", pos.Position)
+		}
+	}
+	return depoistInfo.AddSlash_v2(st, evidence.Address, accountSlash)
+}
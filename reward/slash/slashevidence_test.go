@@ -0,0 +1,82 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package slash
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/matrixstate"
+	"github.com/MatrixAINetwork/go-matrix/core/state"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/params/manversion"
+)
+
+func TestReverseBlockProduceEvidence(t *testing.T) {
+	chaindb := mandb.NewMemDatabase()
+	st, _ := state.NewStateDBManage(nil, chaindb, state.NewDatabase(chaindb))
+	matrixstate.SetVersionInfo(st, manversion.VersionDelta)
+
+	account := common.HexToAddress("0x2000")
+	expired := common.HexToAddress("0x3000")
+	list := &mc.SlashEvidenceList{EvidenceList: []mc.SlashEvidence{
+		{Type: mc.SlashTypeBlockProduce, Address: account, Number: 100, ProhibitCycleNum: 2},
+		{Type: mc.SlashTypeBlockProduce, Address: expired, Number: 100, ProhibitCycleNum: 2},
+		{Type: mc.SlashTypeEquivocation, Address: account, Number: 300, ProhibitCycleNum: 4},
+	}}
+	if err := matrixstate.SetSlashEvidence(st, list); err != nil {
+		t.Fatalf("set evidence failed: %v", err)
+	}
+	blackList := &mc.BlockProduceSlashBlackList{BlackList: []mc.UserBlockProduceSlash{{Address: account, ProhibitCycleCounter: 3}}}
+	if err := matrixstate.SetBlockProduceBlackList(st, blackList); err != nil {
+		t.Fatalf("set black list failed: %v", err)
+	}
+
+	// 禁止周期已结束的证据不能撤销
+	if err := ReverseEvidence(st, mc.SlashReversal{Type: mc.SlashTypeBlockProduce, Address: expired, Number: 100}, 400); err != ErrEvidenceExpired {
+		t.Fatalf("err = %v, want %v", err, ErrEvidenceExpired)
+	}
+	// 之后的双签证据重置了禁止周期，先撤销之前的证据会解除之后的惩罚
+	if err := ReverseEvidence(st, mc.SlashReversal{Type: mc.SlashTypeBlockProduce, Address: account, Number: 100}, 400); err != ErrEvidenceSuperseded {
+		t.Fatalf("err = %v, want %v", err, ErrEvidenceSuperseded)
+	}
+	// 撤销最近的证据，之前的证据仍未撤销，黑名单保留
+	if err := ReverseEvidence(st, mc.SlashReversal{Type: mc.SlashTypeEquivocation, Address: account, Number: 300}, 400); err != nil {
+		t.Fatalf("reverse failed: %v", err)
+	}
+	if blackList, _ := matrixstate.GetBlockProduceBlackList(st); len(blackList.BlackList) != 1 {
+		t.Fatalf("black list %+v, want account kept", blackList)
+	}
+	if err := ReverseEvidence(st, mc.SlashReversal{Type: mc.SlashTypeBlockProduce, Address: account, Number: 100}, 401); err != nil {
+		t.Fatalf("reverse failed: %v", err)
+	}
+	if blackList, _ := matrixstate.GetBlockProduceBlackList(st); len(blackList.BlackList) != 0 {
+		t.Fatalf("black list %+v, want empty", blackList)
+	}
+	if err := ReverseEvidence(st, mc.SlashReversal{Type: mc.SlashTypeBlockProduce, Address: account, Number: 100}, 402); err != ErrEvidenceReversed {
+		t.Fatalf("err = %v, want %v", err, ErrEvidenceReversed)
+	}
+}
+
+func TestReverseSettledInterest(t *testing.T) {
+	chaindb := mandb.NewMemDatabase()
+	st, _ := state.NewStateDBManage(nil, chaindb, state.NewDatabase(chaindb))
+	matrixstate.SetVersionInfo(st, manversion.VersionDelta)
+
+	account := common.HexToAddress("0x2000")
+	list := &mc.SlashEvidenceList{EvidenceList: []mc.SlashEvidence{
+		{Type: mc.SlashTypeInterest, Address: account, Number: 100, Positions: []mc.SlashPosition{{Position: 0, Amount: big.NewInt(10)}}},
+	}}
+	if err := matrixstate.SetSlashEvidence(st, list); err != nil {
+		t.Fatalf("set evidence failed: %v", err)
+	}
+	if err := matrixstate.SetInterestPayNum(st, 120); err != nil {
+		t.Fatalf("set interest pay number failed: %v", err)
+	}
+	if err := ReverseEvidence(st, mc.SlashReversal{Type: mc.SlashTypeInterest, Address: account, Number: 100}, 130); err != ErrEvidenceSettled {
+		t.Fatalf("err = %v, want %v", err, ErrEvidenceSettled)
+	}
+}
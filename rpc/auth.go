@@ -0,0 +1,368 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package rpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/dgrijalva/jwt-go"
+)
+
+// authKey is the context key of the credential presented with a request.
+type authKey struct{}
+
+// DefaultPrivileged lists the namespaces and methods whose calls are audit logged
+// when AuthConfig.Privileged is empty.
+var DefaultPrivileged = []string{"admin", "debug", "personal", "miner", "man_importSuperBlock", "eth_importSuperBlock"}
+
+// AuthToken grants a permission set to the holder of a static bearer token, or of a
+// JWT whose "sub" claim is the token name. JWTs must carry an "exp" claim.
+//
+// A permission is "*" for every method, a namespace such as "man" for all of its
+// methods, or a single method such as "personal_setEntrustSignAccount". Subscriptions
+// are granted one by one as "<namespace>_subscribe:<name>", e.g. "man_subscribe:newHeads",
+// or all together as "<namespace>_subscribe".
+type AuthToken struct {
+	Name        string
+	Token       string   `toml:",omitempty"`
+	Permissions []string `toml:",omitempty"`
+
+	// RateLimit is the number of calls per second the token may make, shared by
+	// all connections using it. Zero means unlimited.
+	RateLimit float64 `toml:",omitempty"`
+	Burst     int     `toml:",omitempty"`
+}
+
+// AuthConfig configures authentication of the HTTP and WebSocket RPC endpoints.
+// Authentication is enabled when any token or a JWT secret is set.
+type AuthConfig struct {
+	Tokens []AuthToken `toml:",omitempty"`
+
+	// JWTSecret is the HMAC secret of HS256 signed JWTs.
+	JWTSecret string `toml:",omitempty"`
+
+	// Anonymous is the permission set of requests without credentials.
+	Anonymous []string `toml:",omitempty"`
+
+	// Privileged lists namespaces and methods whose calls are audit logged,
+	// DefaultPrivileged if empty.
+	Privileged []string `toml:",omitempty"`
+}
+
+// Enabled reports whether the config requires authentication.
+func (c *AuthConfig) Enabled() bool {
+	return len(c.Tokens) > 0 || c.JWTSecret != ""
+}
+
+// unauthorizedError is returned when the credential is invalid or lacks the
+// permission for the called method.
+type unauthorizedError struct{ message string }
+
+func (e *unauthorizedError) ErrorCode() int { return -32001 }
+
+func (e *unauthorizedError) Error() string { return e.message }
+
+// limitExceededError is returned when a token exceeds its rate limit.
+type limitExceededError struct{ name string }
+
+func (e *limitExceededError) ErrorCode() int { return -32005 }
+
+func (e *limitExceededError) Error() string {
+	return fmt.Sprintf("rate limit of token %s exceeded", e.name)
+}
+
+// permissions is a set of granted namespaces and methods.
+type permissions map[string]struct{}
+
+func newPermissions(list []string) permissions {
+	p := make(permissions, len(list))
+	for _, item := range list {
+		p[item] = struct{}{}
+	}
+	return p
+}
+
+// subscriptionNameSeparator joins <namespace>_subscribe and the subscription name
+// in the method name a subscription is authorized as, e.g. "man_subscribe:newHeads".
+const subscriptionNameSeparator = ":"
+
+// allows reports whether the set contains the method, its namespace or "*".
+// Subscriptions are also granted by "<namespace>_subscribe" for all subscriptions
+// of the namespace, and unsubscribing by any granted subscription of the namespace.
+func (p permissions) allows(method string) bool {
+	if _, ok := p["*"]; ok {
+		return true
+	}
+	if _, ok := p[method]; ok {
+		return true
+	}
+	i := strings.Index(method, serviceMethodSeparator)
+	if i <= 0 {
+		return false
+	}
+	namespace := method[:i]
+	if _, ok := p[namespace]; ok {
+		return true
+	}
+	if j := strings.Index(method, subscriptionNameSeparator); j > 0 {
+		_, ok := p[method[:j]]
+		return ok
+	}
+	if method == namespace+unsubscribeMethodSuffix {
+		for perm := range p {
+			if strings.HasPrefix(perm, namespace+subscribeMethodSuffix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// rateLimiter is a token bucket refilled at rate tokens per second.
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	b := float64(burst)
+	if b < 1 {
+		b = rate
+		if b < 1 {
+			b = 1
+		}
+	}
+	return &rateLimiter{rate: rate, burst: b, tokens: b, last: time.Now()}
+}
+
+func (l *rateLimiter) allow(now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// identity is a resolved credential.
+type identity struct {
+	name    string
+	perms   permissions
+	limiter *rateLimiter
+}
+
+const anonymousName = "anonymous"
+
+// Authenticator checks the credentials and permissions of RPC calls, enforces the
+// per token rate limits and writes the audit log of privileged calls. One instance
+// is shared by the HTTP and WebSocket endpoints so that limits apply across both.
+type Authenticator struct {
+	mu         sync.RWMutex
+	enabled    bool
+	tokens     map[string]*identity // by static token
+	names      map[string]*identity // by name, for JWT subjects
+	secret     []byte
+	anonymous  *identity
+	privileged permissions
+}
+
+// NewAuthenticator creates an authenticator from the config.
+func NewAuthenticator(config AuthConfig) (*Authenticator, error) {
+	a := new(Authenticator)
+	if err := a.SetConfig(config); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// SetConfig replaces the tokens and permissions. Rate limit state is kept for
+// tokens whose name and limit are unchanged.
+func (a *Authenticator) SetConfig(config AuthConfig) error {
+	tokens := make(map[string]*identity)
+	names := make(map[string]*identity)
+	for _, token := range config.Tokens {
+		if token.Name == "" {
+			return errors.New("rpc auth token without name")
+		}
+		if _, exist := names[token.Name]; exist {
+			return fmt.Errorf("duplicate rpc auth token name %q", token.Name)
+		}
+		id := &identity{name: token.Name, perms: newPermissions(token.Permissions), limiter: newRateLimiter(token.RateLimit, token.Burst)}
+		names[token.Name] = id
+		if token.Token == "" {
+			continue
+		}
+		if _, exist := tokens[token.Token]; exist {
+			return fmt.Errorf("rpc auth token %q reuses the secret of another token", token.Name)
+		}
+		tokens[token.Token] = id
+	}
+	privileged := config.Privileged
+	if len(privileged) == 0 {
+		privileged = DefaultPrivileged
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for name, id := range names {
+		if old, ok := a.names[name]; ok && old.limiter != nil && id.limiter != nil &&
+			old.limiter.rate == id.limiter.rate && old.limiter.burst == id.limiter.burst {
+			id.limiter = old.limiter
+		}
+	}
+	a.enabled = config.Enabled()
+	a.tokens, a.names = tokens, names
+	a.secret = []byte(config.JWTSecret)
+	a.anonymous = &identity{name: anonymousName, perms: newPermissions(config.Anonymous)}
+	a.privileged = newPermissions(privileged)
+	return nil
+}
+
+// Enabled reports whether calls have to be authenticated.
+func (a *Authenticator) Enabled() bool {
+	if a == nil {
+		return false
+	}
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.enabled
+}
+
+// resolve maps a credential to its identity. An empty credential is anonymous.
+func (a *Authenticator) resolve(credential string) (*identity, Error) {
+	if credential == "" {
+		return a.anonymous, nil
+	}
+	for token, id := range a.tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(credential)) == 1 {
+			return id, nil
+		}
+	}
+	if len(a.secret) == 0 {
+		return nil, &unauthorizedError{"invalid token"}
+	}
+	token, err := jwt.Parse(credential, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+		}
+		return a.secret, nil
+	})
+	if err != nil || !token.Valid {
+		return nil, &unauthorizedError{"invalid token"}
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, &unauthorizedError{"invalid token"}
+	}
+	// A token without "exp" would stay valid forever once leaked.
+	if !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+		return nil, &unauthorizedError{"token has no expiry"}
+	}
+	sub, _ := claims["sub"].(string)
+	id, ok := a.names[sub]
+	if !ok {
+		return nil, &unauthorizedError{fmt.Sprintf("unknown token subject %q", sub)}
+	}
+	return id, nil
+}
+
+// authorize checks the credential in ctx against the method, returning the caller
+// name and whether the call is privileged.
+func (a *Authenticator) authorize(ctx context.Context, method string) (string, bool, Error) {
+	if a == nil {
+		return "", false, nil
+	}
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	privileged := a.privileged.allows(method)
+	if !a.enabled {
+		return "", privileged, nil
+	}
+	credential, _ := ctx.Value(authKey{}).(string)
+	id, err := a.resolve(credential)
+	if err != nil {
+		auditDenied(ctx, "", method, err)
+		return "", privileged, err
+	}
+	// the metadata service only lists the modules and is needed by the console
+	if !strings.HasPrefix(method, MetadataApi+serviceMethodSeparator) && !id.perms.allows(method) {
+		err := &unauthorizedError{fmt.Sprintf("%s is not permitted to call %s", id.name, method)}
+		auditDenied(ctx, id.name, method, err)
+		return id.name, privileged, err
+	}
+	if id.limiter != nil && !id.limiter.allow(time.Now()) {
+		return id.name, privileged, &limitExceededError{id.name}
+	}
+	return id.name, privileged, nil
+}
+
+func auditDenied(ctx context.Context, caller, method string, err error) {
+	log.Warn("RPC call denied", "caller", caller, "remote", ctx.Value("remote"), "method", method, "err", err)
+}
+
+// audit logs the outcome of a privileged call.
+func audit(ctx context.Context, caller, method string, start time.Time, err error) {
+	if err != nil {
+		log.Warn("RPC privileged call", "caller", caller, "remote", ctx.Value("remote"), "method", method, "elapsed", time.Since(start), "err", err)
+		return
+	}
+	log.Info("RPC privileged call", "caller", caller, "remote", ctx.Value("remote"), "method", method, "elapsed", time.Since(start))
+}
+
+// requestCredential extracts the bearer token from the Authorization header or,
+// for clients that cannot set headers such as browser WebSockets, the token
+// query parameter.
+func requestCredential(r *http.Request) string {
+	if header := r.Header.Get("Authorization"); header != "" {
+		if strings.HasPrefix(strings.ToLower(header), "bearer ") {
+			return strings.TrimSpace(header[len("bearer "):])
+		}
+		return ""
+	}
+	return r.URL.Query().Get("token")
+}
+
+// HTTPHandler protects a non JSON-RPC handler served on the HTTP endpoint with the
+// permission name, e.g. "graphql".
+func (a *Authenticator) HTTPHandler(name string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), authKey{}, requestCredential(r))
+		ctx = context.WithValue(ctx, "remote", r.RemoteAddr)
+		caller, privileged, err := a.authorize(ctx, name)
+		if err != nil {
+			code := http.StatusUnauthorized
+			if _, ok := err.(*limitExceededError); ok {
+				code = http.StatusTooManyRequests
+			}
+			http.Error(w, err.Error(), code)
+			return
+		}
+		if privileged {
+			audit(ctx, caller, name, time.Now(), nil)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,260 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+func TestPermissions(t *testing.T) {
+	p := newPermissions([]string{"man", "personal_setEntrustSignAccount"})
+	tests := map[string]bool{
+		"man_getBalance":                 true,
+		"man_subscribe:newHeads":         true,
+		"man_unsubscribe":                true,
+		"personal_setEntrustSignAccount": true,
+		"personal_unlockAccount":         false,
+		"manx_getBalance":                false,
+		"debug_setHead":                  false,
+	}
+	for method, want := range tests {
+		if got := p.allows(method); got != want {
+			t.Errorf("%s: got %v, want %v", method, got, want)
+		}
+	}
+	if !newPermissions([]string{"*"}).allows("debug_setHead") {
+		t.Error("* does not allow every method")
+	}
+
+	p = newPermissions([]string{"man_subscribe:newHeads", "debug_subscribe"})
+	tests = map[string]bool{
+		"man_subscribe:newHeads":   true,
+		"man_subscribe:logs":       false,
+		"man_unsubscribe":          true,
+		"debug_subscribe:anything": true,
+		"net_unsubscribe":          false,
+	}
+	for method, want := range tests {
+		if got := p.allows(method); got != want {
+			t.Errorf("%s: got %v, want %v", method, got, want)
+		}
+	}
+}
+
+func TestAuthSubscriptions(t *testing.T) {
+	newClient := func(anonymous ...string) *Client {
+		auth, err := NewAuthenticator(AuthConfig{Tokens: []AuthToken{{Name: "a", Token: "t"}}, Anonymous: anonymous})
+		if err != nil {
+			t.Fatal(err)
+		}
+		server := NewServer()
+		server.SetAuthenticator(auth)
+		if err := server.RegisterName("nftest", new(NotificationTestService)); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(server.Stop)
+		return DialInProc(server)
+	}
+	ctx := context.Background()
+
+	client := newClient("nftest_subscribe:someSubscription")
+	sub, err := client.Subscribe(ctx, "nftest", make(chan int), "someSubscription", 0, 1)
+	if err != nil {
+		t.Fatalf("permitted subscription: %v", err)
+	}
+	defer sub.Unsubscribe()
+	if _, err := client.Subscribe(ctx, "nftest", make(chan int), "hangSubscription", 1); errorCode(err) != -32001 {
+		t.Errorf("subscription not denied: %v", err)
+	}
+	var ok bool
+	if err := client.Call(&ok, "nftest_unsubscribe", "0x1"); errorCode(err) == -32001 {
+		t.Errorf("unsubscribe denied to subscriber: %v", err)
+	}
+
+	client = newClient("nftest_echo")
+	if err := client.Call(&ok, "nftest_unsubscribe", "0x1"); errorCode(err) != -32001 {
+		t.Errorf("unsubscribe not denied: %v", err)
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	l := newRateLimiter(2, 2)
+	now := time.Now()
+	if !l.allow(now) || !l.allow(now) {
+		t.Fatal("burst not allowed")
+	}
+	if l.allow(now) {
+		t.Fatal("limit not enforced")
+	}
+	if !l.allow(now.Add(500 * time.Millisecond)) {
+		t.Fatal("bucket not refilled")
+	}
+}
+
+func newAuthTestClient(t *testing.T, config AuthConfig, token string) *Client {
+	auth, err := NewAuthenticator(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := NewServer()
+	server.SetAuthenticator(auth)
+	if err := server.RegisterName("test", new(Service)); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.RegisterName("admin", new(Service)); err != nil {
+		t.Fatal(err)
+	}
+	httpsrv := httptest.NewServer(server)
+	t.Cleanup(httpsrv.Close)
+
+	client, err := DialHTTP(httpsrv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "" {
+		client.SetHeader("Authorization", "Bearer "+token)
+	}
+	return client
+}
+
+func errorCode(err error) int {
+	if rpcErr, ok := err.(Error); ok {
+		return rpcErr.ErrorCode()
+	}
+	return 0
+}
+
+func TestAuthTokenPermissions(t *testing.T) {
+	config := AuthConfig{
+		Tokens: []AuthToken{
+			{Name: "reader", Token: "secret-reader", Permissions: []string{"test_echo"}},
+			{Name: "operator", Token: "secret-operator", Permissions: []string{"test", "admin"}, RateLimit: 1, Burst: 2},
+		},
+		Anonymous: []string{"test_rets"},
+	}
+	var result Result
+	var str string
+
+	anonymous := newAuthTestClient(t, config, "")
+	if err := anonymous.Call(&str, "test_rets"); err != nil {
+		t.Errorf("anonymous call to permitted method: %v", err)
+	}
+	if err := anonymous.Call(&result, "test_echo", "x", 1, &Args{"y"}); errorCode(err) != -32001 {
+		t.Errorf("anonymous call not denied: %v", err)
+	}
+	var modules map[string]string
+	if err := anonymous.Call(&modules, "rpc_modules"); err != nil {
+		t.Errorf("metadata call denied: %v", err)
+	}
+
+	reader := newAuthTestClient(t, config, "secret-reader")
+	if err := reader.Call(&result, "test_echo", "x", 1, &Args{"y"}); err != nil {
+		t.Errorf("reader call to permitted method: %v", err)
+	}
+	if err := reader.Call(nil, "admin_noArgsRets"); errorCode(err) != -32001 {
+		t.Errorf("reader admin call not denied: %v", err)
+	}
+
+	invalid := newAuthTestClient(t, config, "wrong")
+	if err := invalid.Call(&str, "test_rets"); errorCode(err) != -32001 {
+		t.Errorf("invalid token not denied: %v", err)
+	}
+
+	operator := newAuthTestClient(t, config, "secret-operator")
+	for i := 0; i < 2; i++ {
+		if err := operator.Call(nil, "admin_noArgsRets"); err != nil {
+			t.Fatalf("operator call %d: %v", i, err)
+		}
+	}
+	if err := operator.Call(nil, "admin_noArgsRets"); errorCode(err) != -32005 {
+		t.Errorf("rate limit not enforced: %v", err)
+	}
+}
+
+func TestAuthJWT(t *testing.T) {
+	secret := "jwt-secret"
+	config := AuthConfig{
+		Tokens:    []AuthToken{{Name: "monitor", Permissions: []string{"test"}}},
+		JWTSecret: secret,
+	}
+	sign := func(claims jwt.MapClaims, key string) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(key))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	var str string
+
+	valid := sign(jwt.MapClaims{"sub": "monitor", "exp": time.Now().Add(time.Minute).Unix()}, secret)
+	if err := newAuthTestClient(t, config, valid).Call(&str, "test_rets"); err != nil {
+		t.Errorf("valid jwt: %v", err)
+	}
+	expired := sign(jwt.MapClaims{"sub": "monitor", "exp": time.Now().Add(-time.Minute).Unix()}, secret)
+	if err := newAuthTestClient(t, config, expired).Call(&str, "test_rets"); errorCode(err) != -32001 {
+		t.Errorf("expired jwt not denied: %v", err)
+	}
+	noExpiry := sign(jwt.MapClaims{"sub": "monitor", "iat": time.Now().Unix()}, secret)
+	if err := newAuthTestClient(t, config, noExpiry).Call(&str, "test_rets"); errorCode(err) != -32001 {
+		t.Errorf("jwt without exp not denied: %v", err)
+	}
+	forged := sign(jwt.MapClaims{"sub": "monitor"}, "other")
+	if err := newAuthTestClient(t, config, forged).Call(&str, "test_rets"); errorCode(err) != -32001 {
+		t.Errorf("forged jwt not denied: %v", err)
+	}
+	unknown := sign(jwt.MapClaims{"sub": "nobody"}, secret)
+	if err := newAuthTestClient(t, config, unknown).Call(&str, "test_rets"); errorCode(err) != -32001 {
+		t.Errorf("unknown subject not denied: %v", err)
+	}
+}
+
+func TestAuthHTTPHandler(t *testing.T) {
+	auth, err := NewAuthenticator(AuthConfig{Tokens: []AuthToken{{Name: "ql", Token: "secret", Permissions: []string{"graphql"}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := NewServer()
+	server.SetAuthenticator(auth)
+	extra := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	httpsrv := newHTTPServer(nil, []string{"*"}, server, map[string]http.Handler{"/graphql": extra})
+
+	rec := httptest.NewRecorder()
+	httpsrv.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "http://localhost/graphql", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("anonymous status %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	httpsrv.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "http://localhost/graphql?token=secret", nil))
+	if rec.Code != http.StatusTeapot {
+		t.Errorf("authenticated status %d", rec.Code)
+	}
+}
+
+func TestAuthReloadKeepsLimit(t *testing.T) {
+	config := AuthConfig{Tokens: []AuthToken{{Name: "a", Token: "t", Permissions: []string{"*"}, RateLimit: 1, Burst: 1}}}
+	auth, err := NewAuthenticator(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	limiter := auth.names["a"].limiter
+	if err := auth.SetConfig(config); err != nil {
+		t.Fatal(err)
+	}
+	if auth.names["a"].limiter != limiter {
+		t.Error("rate limit state reset by reload")
+	}
+	config.Tokens = append(config.Tokens, AuthToken{Name: "a", Token: "u"})
+	if err := auth.SetConfig(config); err == nil {
+		t.Error("duplicate token name accepted")
+	}
+}
@@ -6,12 +6,15 @@ package rpc
 
 import (
 	"net"
+	"net/http"
 
 	"github.com/MatrixAINetwork/go-matrix/log"
 )
 
-// StartHTTPEndpoint starts the HTTP RPC endpoint, configured with cors/vhosts/modules
-func StartHTTPEndpoint(endpoint string, apis []API, modules []string, cors []string, vhosts []string) (net.Listener, *Server, error) {
+// StartHTTPEndpoint starts the HTTP RPC endpoint, configured with cors/vhosts/modules.
+// Extra handlers are served on their own paths next to the JSON-RPC handler. Calls
+// are checked by auth if it is not nil.
+func StartHTTPEndpoint(endpoint string, apis []API, modules []string, cors []string, vhosts []string, handlers map[string]http.Handler, auth *Authenticator) (net.Listener, *Server, error) {
 	// Generate the whitelist based on the allowed modules
 	whitelist := make(map[string]bool)
 	for _, module := range modules {
@@ -19,6 +22,7 @@ func StartHTTPEndpoint(endpoint string, apis []API, modules []string, cors []str
 	}
 	// Register all the APIs exposed by the services
 	handler := NewServer()
+	handler.SetAuthenticator(auth)
 	for _, api := range apis {
 		if whitelist[api.Namespace] || (len(whitelist) == 0 && api.Public) {
 			if err := handler.RegisterName(api.Namespace, api.Service); err != nil {
@@ -35,12 +39,12 @@ func StartHTTPEndpoint(endpoint string, apis []API, modules []string, cors []str
 	if listener, err = net.Listen("tcp", endpoint); err != nil {
 		return nil, nil, err
 	}
-	go NewHTTPServer(cors, vhosts, handler).Serve(listener)
+	go newHTTPServer(cors, vhosts, handler, handlers).Serve(listener)
 	return listener, handler, err
 }
 
-// StartWSEndpoint starts a websocket endpoint
-func StartWSEndpoint(endpoint string, apis []API, modules []string, wsOrigins []string, exposeAll bool) (net.Listener, *Server, error) {
+// StartWSEndpoint starts a websocket endpoint, calls are checked by auth if it is not nil.
+func StartWSEndpoint(endpoint string, apis []API, modules []string, wsOrigins []string, exposeAll bool, auth *Authenticator) (net.Listener, *Server, error) {
 
 	// Generate the whitelist based on the allowed modules
 	whitelist := make(map[string]bool)
@@ -49,6 +53,7 @@ func StartWSEndpoint(endpoint string, apis []API, modules []string, wsOrigins []
 	}
 	// Register all the APIs exposed by the services
 	handler := NewServer()
+	handler.SetAuthenticator(auth)
 	for _, api := range apis {
 		if exposeAll || whitelist[api.Namespace] || (len(whitelist) == 0 && api.Public) {
 			if err := handler.RegisterName(api.Namespace, api.Service); err != nil {
@@ -34,6 +34,9 @@ type httpConn struct {
 	req       *http.Request
 	closeOnce sync.Once
 	closed    chan struct{}
+
+	mu      sync.Mutex // protects headers
+	headers http.Header
 }
 
 // httpConn is treated specially by Client.
@@ -66,7 +69,7 @@ func DialHTTPWithClient(endpoint string, client *http.Client) (*Client, error) {
 
 	initctx := context.Background()
 	return newClient(initctx, func(context.Context) (net.Conn, error) {
-		return &httpConn{client: client, req: req, closed: make(chan struct{})}, nil
+		return &httpConn{client: client, req: req, closed: make(chan struct{}), headers: make(http.Header)}, nil
 	})
 }
 
@@ -75,6 +78,18 @@ func DialHTTP(endpoint string) (*Client, error) {
 	return DialHTTPWithClient(endpoint, new(http.Client))
 }
 
+// SetHeader adds a custom HTTP header to the client's requests, e.g. the bearer
+// token of an authenticated endpoint. It has no effect on non-HTTP clients.
+func (c *Client) SetHeader(key, value string) {
+	if !c.isHTTP {
+		return
+	}
+	hc := c.writeConn.(*httpConn)
+	hc.mu.Lock()
+	hc.headers.Set(key, value)
+	hc.mu.Unlock()
+}
+
 func (c *Client) sendHTTP(ctx context.Context, op *requestOp, msg interface{}) error {
 	hc := c.writeConn.(*httpConn)
 	respBody, err := hc.doRequest(ctx, msg)
@@ -125,6 +140,18 @@ func (hc *httpConn) doRequest(ctx context.Context, msg interface{}) (io.ReadClos
 	req.Body = ioutil.NopCloser(bytes.NewReader(body))
 	req.ContentLength = int64(len(body))
 
+	hc.mu.Lock()
+	if len(hc.headers) > 0 {
+		req.Header = make(http.Header, len(hc.req.Header)+len(hc.headers))
+		for key, values := range hc.req.Header {
+			req.Header[key] = values
+		}
+		for key, values := range hc.headers {
+			req.Header[key] = values
+		}
+	}
+	hc.mu.Unlock()
+
 	resp, err := hc.client.Do(req)
 	if err != nil {
 		return nil, err
@@ -150,8 +177,27 @@ func (t *httpReadWriteNopCloser) Close() error {
 //
 // Deprecated: Server implements http.Handler
 func NewHTTPServer(cors []string, vhosts []string, srv *Server) *http.Server {
+	return newHTTPServer(cors, vhosts, srv, nil)
+}
+
+// newHTTPServer creates a new HTTP RPC server, routing the extra handlers by path
+// and everything else to the JSON-RPC server.
+func newHTTPServer(cors []string, vhosts []string, srv *Server, handlers map[string]http.Handler) *http.Server {
+	var mux http.Handler = srv
+	if len(handlers) > 0 {
+		serveMux := http.NewServeMux()
+		serveMux.Handle("/", srv)
+		for path, handler := range handlers {
+			if srv.auth != nil {
+				// extra handlers are permitted by their path name, e.g. "graphql"
+				handler = srv.auth.HTTPHandler(strings.Trim(path, "/"), handler)
+			}
+			serveMux.Handle(path, handler)
+		}
+		mux = serveMux
+	}
 	// Wrap the CORS-handler within a host-handler
-	handler := newCorsHandler(srv, cors)
+	handler := newCorsHandler(mux, cors)
 	handler = newVHostHandler(vhosts, handler)
 	return &http.Server{Handler: handler}
 }
@@ -173,6 +219,7 @@ func (srv *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	ctx = context.WithValue(ctx, "remote", r.RemoteAddr)
 	ctx = context.WithValue(ctx, "scheme", r.Proto)
 	ctx = context.WithValue(ctx, "local", r.Host)
+	ctx = context.WithValue(ctx, authKey{}, requestCredential(r))
 
 	body := io.LimitReader(r.Body, maxRequestContentLength)
 	codec := NewJSONCodec(&httpReadWriteNopCloser{body, w})
@@ -200,7 +247,7 @@ func validateRequest(r *http.Request) (int, error) {
 	return 0, nil
 }
 
-func newCorsHandler(srv *Server, allowedOrigins []string) http.Handler {
+func newCorsHandler(srv http.Handler, allowedOrigins []string) http.Handler {
 	// disable CORS support if user has not specified a custom CORS configuration
 	if len(allowedOrigins) == 0 {
 		return srv
@@ -40,3 +40,22 @@ func testHTTPErrorResponse(t *testing.T, method, contentType, body string, expec
 		t.Fatalf("response code should be %d not %d", expected, code)
 	}
 }
+
+func TestHTTPServerExtraHandlers(t *testing.T) {
+	extra := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	server := newHTTPServer(nil, []string{"*"}, NewServer(), map[string]http.Handler{"/extra": extra})
+
+	rec := httptest.NewRecorder()
+	server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "http://localhost/extra", nil))
+	if rec.Code != http.StatusTeapot {
+		t.Fatalf("extra handler not served, status %d", rec.Code)
+	}
+	// 其他路径仍然由JSON-RPC处理
+	rec = httptest.NewRecorder()
+	server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "http://localhost/", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("rpc handler not served, status %d", rec.Code)
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/MatrixAINetwork/go-matrix/log"
 	"gopkg.in/fatih/set.v0"
@@ -46,6 +47,12 @@ func NewServer() *Server {
 	return server
 }
 
+// SetAuthenticator makes the server check every call with the authenticator. It
+// must be set before the server starts serving requests.
+func (s *Server) SetAuthenticator(auth *Authenticator) {
+	s.auth = auth
+}
+
 // RPCService gives meta information about the server.
 // e.g. gives information about the loaded modules.
 type RPCService struct {
@@ -203,8 +210,14 @@ func (s *Server) serveRequest(ctx context.Context, codec ServerCodec, singleShot
 // response back using the given codec. It will block until the codec is closed or the server is
 // stopped. In either case the codec is closed.
 func (s *Server) ServeCodec(codec ServerCodec, options CodecOption) {
+	s.serveCodec(context.Background(), codec, options)
+}
+
+// serveCodec is ServeCodec with a connection context, e.g. carrying the credential
+// of a WebSocket connection.
+func (s *Server) serveCodec(ctx context.Context, codec ServerCodec, options CodecOption) {
 	defer codec.Close()
-	s.serveRequest(context.Background(), codec, false, options)
+	s.serveRequest(ctx, codec, false, options)
 }
 
 // ServeSingleRequest reads and processes a single RPC request from the given codec. It will not
@@ -243,11 +256,21 @@ func (s *Server) createSubscription(ctx context.Context, c ServerCodec, req *ser
 }
 
 // handle executes a request and returns the response from the callback.
-func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverRequest) (interface{}, func()) {
+func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverRequest) (response interface{}, callback func()) {
+	var callErr error
 	if req.err != nil {
 		return codec.CreateErrorResponse(&req.id, req.err), nil
 	}
 
+	caller, privileged, authErr := s.auth.authorize(ctx, req.method)
+	if authErr != nil {
+		return codec.CreateErrorResponse(&req.id, authErr), nil
+	}
+	if privileged {
+		start := time.Now()
+		defer func() { audit(ctx, caller, req.method, start, callErr) }()
+	}
+
 	if req.isUnsubscribe { // cancel subscription, first param must be the subscription id
 		if len(req.args) >= 1 && req.args[0].Kind() == reflect.String {
 			notifier, supported := NotifierFromContext(ctx)
@@ -257,6 +280,7 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 
 			subid := ID(req.args[0].String())
 			if err := notifier.unsubscribe(subid); err != nil {
+				callErr = err
 				return codec.CreateErrorResponse(&req.id, &callbackError{err.Error()}), nil
 			}
 
@@ -268,6 +292,7 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 	if req.callb.isSubscribe {
 		subid, err := s.createSubscription(ctx, codec, req)
 		if err != nil {
+			callErr = err
 			return codec.CreateErrorResponse(&req.id, &callbackError{err.Error()}), nil
 		}
 
@@ -285,6 +310,7 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 		rpcErr := &invalidParamsError{fmt.Sprintf("%s%s%s expects %d parameters, got %d",
 			req.svcname, serviceMethodSeparator, req.callb.method.Name,
 			len(req.callb.argTypes), len(req.args))}
+		callErr = rpcErr
 		return codec.CreateErrorResponse(&req.id, rpcErr), nil
 	}
 
@@ -305,6 +331,7 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 	if req.callb.errPos >= 0 { // test if method returned an error
 		if !reply[req.callb.errPos].IsNil() {
 			e := reply[req.callb.errPos].Interface().(error)
+			callErr = e
 			res := codec.CreateErrorResponse(&req.id, &callbackError{e.Error()})
 			return res, nil
 		}
@@ -382,7 +409,7 @@ func (s *Server) readRequest(codec ServerCodec) ([]*serverRequest, bool, Error)
 		}
 
 		if r.isPubSub && strings.HasSuffix(r.method, unsubscribeMethodSuffix) {
-			requests[i] = &serverRequest{id: r.id, isUnsubscribe: true}
+			requests[i] = &serverRequest{id: r.id, method: r.method, isUnsubscribe: true}
 			argTypes := []reflect.Type{reflect.TypeOf("")} // expect subscription id as first arg
 			if args, err := codec.ParseRequestArguments(argTypes, r.params); err == nil {
 				requests[i].args = args
@@ -399,7 +426,7 @@ func (s *Server) readRequest(codec ServerCodec) ([]*serverRequest, bool, Error)
 
 		if r.isPubSub { // man_subscribe, r.method contains the subscription method name
 			if callb, ok := svc.subscriptions[r.method]; ok {
-				requests[i] = &serverRequest{id: r.id, svcname: svc.name, callb: callb}
+				requests[i] = &serverRequest{id: r.id, svcname: svc.name, method: r.service + subscribeMethodSuffix + subscriptionNameSeparator + r.method, callb: callb}
 				if r.params != nil && len(callb.argTypes) > 0 {
 					argTypes := []reflect.Type{reflect.TypeOf("")}
 					argTypes = append(argTypes, callb.argTypes...)
@@ -416,7 +443,7 @@ func (s *Server) readRequest(codec ServerCodec) ([]*serverRequest, bool, Error)
 		}
 
 		if callb, ok := svc.callbacks[r.method]; ok { // lookup RPC method
-			requests[i] = &serverRequest{id: r.id, svcname: svc.name, callb: callb}
+			requests[i] = &serverRequest{id: r.id, svcname: svc.name, method: r.service + serviceMethodSeparator + r.method, callb: callb}
 			if r.params != nil && len(callb.argTypes) > 0 {
 				if args, err := codec.ParseRequestArguments(callb.argTypes, r.params); err == nil {
 					requests[i].args = args
@@ -34,6 +34,7 @@ func TestBlockNumberJSONUnmarshal(t *testing.T) {
 		14: {`someString`, true, BlockNumber(0)},
 		15: {`""`, true, BlockNumber(0)},
 		16: {``, true, BlockNumber(0)},
+		17: {`"finalized"`, false, FinalizedBlockNumber},
 	}
 
 	for i, test := range tests {
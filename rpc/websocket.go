@@ -56,7 +56,12 @@ func (srv *Server) WebsocketHandler(allowedOrigins []string) http.Handler {
 			decoder := func(v interface{}) error {
 				return websocketJSONCodec.Receive(conn, v)
 			}
-			srv.ServeCodec(NewCodec(conn, encoder, decoder), OptionMethodInvocation|OptionSubscriptions)
+			ctx := context.Background()
+			if r := conn.Request(); r != nil {
+				ctx = context.WithValue(ctx, "remote", r.RemoteAddr)
+				ctx = context.WithValue(ctx, authKey{}, requestCredential(r))
+			}
+			srv.serveCodec(ctx, NewCodec(conn, encoder, decoder), OptionMethodInvocation|OptionSubscriptions)
 		},
 	}
 }
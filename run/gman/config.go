@@ -153,6 +153,9 @@ func makeFullNode(ctx *cli.Context) *pod.Node {
 	if cfg.Manstats.URL != "" {
 		utils.RegisterManStatsService(stack, cfg.Manstats.URL)
 	}
+	if ctx.GlobalBool(utils.GraphQLEnabledFlag.Name) {
+		utils.RegisterGraphQLService(stack)
+	}
 	return stack
 }
 
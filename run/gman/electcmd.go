@@ -0,0 +1,67 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/MatrixAINetwork/go-matrix/baseinterface"
+	"github.com/MatrixAINetwork/go-matrix/election/dryrun"
+	"github.com/MatrixAINetwork/go-matrix/params/manversion"
+	"github.com/MatrixAINetwork/go-matrix/run/utils"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	electDryRunCommand = cli.Command{
+		Action:    utils.MigrateFlags(electDryRun),
+		Name:      "electdryrun",
+		Usage:     "Run a registered election plugin offline",
+		ArgsUsage: "<argsFile>",
+		Category:  "MISCELLANEOUS COMMANDS",
+		Description: `
+The electdryrun command reads election arguments (plug, role, seed, trials,
+deposits and electConfig) from a JSON file, runs the election plugin against
+an empty in-memory state and prints the elected topology and, when trials is
+greater than 1, how often each account was elected.
+
+Registered plugins: ` + fmt.Sprint(baseinterface.ElectPlugNames()) + `
+`,
+	}
+)
+
+func electDryRun(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("Usage: gman electdryrun <argsFile>")
+	}
+	data, err := ioutil.ReadFile(ctx.Args().First())
+	if err != nil {
+		utils.Fatalf("Failed to read args file: %v", err)
+	}
+	var args dryrun.Args
+	if err := json.Unmarshal(data, &args); err != nil {
+		utils.Fatalf("Invalid args file: %v", err)
+	}
+	req, err := args.ToRequest(nil)
+	if err != nil {
+		utils.Fatalf("Invalid args: %v", err)
+	}
+	st, err := dryrun.NewState(manversion.VersionZeta)
+	if err != nil {
+		utils.Fatalf("Failed to create state: %v", err)
+	}
+	result, err := dryrun.Run(req, st)
+	if err != nil {
+		utils.Fatalf("Elect dry run failed: %v", err)
+	}
+	out, err := json.MarshalIndent(result.Output(), "", "  ")
+	if err != nil {
+		utils.Fatalf("Failed to encode result: %v", err)
+	}
+	fmt.Println(string(out))
+	return nil
+}
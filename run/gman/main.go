@@ -150,6 +150,7 @@ var (
 		utils.RPCListenAddrFlag,
 		utils.RPCPortFlag,
 		utils.RPCApiFlag,
+		utils.GraphQLEnabledFlag,
 		utils.WSEnabledFlag,
 		utils.WSListenAddrFlag,
 		utils.WSPortFlag,
@@ -182,6 +183,7 @@ func init() {
 		signCommand,
 		signSuperBlockCommand,
 		signVersionCommand,
+		superBlockCommand,
 		// See monitorcmd.go:
 		monitorCommand,
 		// See accountcmd.go:
@@ -197,6 +199,8 @@ func init() {
 		versionCommand,
 		bugCommand,
 		licenseCommand,
+		// See electcmd.go:
+		electDryRunCommand,
 		// See config.go
 		dumpConfigCommand,
 		CommitCommand,
@@ -326,11 +330,18 @@ func startNode(ctx *cli.Context, stack *pod.Node) {
 		}
 	}()
 
+	log.Info("MainBootNode", "data", params.MainnetBootnodes)
+	if ctx.GlobalBool(utils.LightModeFlag.Name) || ctx.GlobalString(utils.SyncModeFlag.Name) == "light" {
+		var light *man.LightMatrix
+		if err := stack.Service(&light); err != nil {
+			utils.Fatalf("Matrix light service not running :%v", err)
+		}
+		return
+	}
 	var matrix *man.Matrix
 	if err := stack.Service(&matrix); err != nil {
 		utils.Fatalf("Matrix service not running :%v", err)
 	}
-	log.Info("MainBootNode", "data", params.MainnetBootnodes)
 
 	// Start auxiliary services if enabled
 	if ctx.GlobalBool(utils.MiningEnabledFlag.Name) || ctx.GlobalBool(utils.DeveloperFlag.Name) {
@@ -0,0 +1,341 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/MatrixAINetwork/go-matrix/accounts/keystore"
+	"github.com/MatrixAINetwork/go-matrix/base58"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core"
+	"github.com/MatrixAINetwork/go-matrix/core/matrixstate"
+	"github.com/MatrixAINetwork/go-matrix/core/state"
+	"github.com/MatrixAINetwork/go-matrix/core/superblock"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/params"
+	"github.com/MatrixAINetwork/go-matrix/pod"
+	"github.com/MatrixAINetwork/go-matrix/run/utils"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	superBlockCommand = cli.Command{
+		Name:     "superblock",
+		Usage:    "Build, sign, check and dry-run super blocks",
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+The superblock command guides the construction of a super block:
+
+    gman superblock propose <manifest>          write an empty manifest on top of the current head
+    gman superblock build <manifest> <block>     apply the manifest to the parent state and compute roots
+    gman superblock sign <block> <keyfile>       sign the block offline with a super block account key
+    gman superblock merge <block> <signed>...    merge signatures collected from several signers
+    gman superblock check <block>                check signatures against MSKeyAccountBlockSupers
+    gman superblock dryrun <block>               import the block into a copy of the local chain
+
+The manifest lists matrix state changes (mstate, same format as the genesis
+file), MAN balances to set and the election black list. The block file is
+the format accepted by importSuperBlock.`,
+		Subcommands: []cli.Command{
+			{
+				Name:      "propose",
+				Usage:     "Write an empty super block manifest on top of the current head",
+				Action:    utils.MigrateFlags(superBlockPropose),
+				ArgsUsage: "<manifest>",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+				},
+			},
+			{
+				Name:      "build",
+				Usage:     "Build the super block described by a manifest and compute its roots",
+				Action:    utils.MigrateFlags(superBlockBuild),
+				ArgsUsage: "<manifest> <block>",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+				},
+			},
+			{
+				Name:      "sign",
+				Usage:     "Sign a super block offline with a super block account key file",
+				Action:    utils.MigrateFlags(superBlockSign),
+				ArgsUsage: "<block> <keyfile>",
+				Flags: []cli.Flag{
+					utils.PasswordFileFlag,
+				},
+			},
+			{
+				Name:      "merge",
+				Usage:     "Merge super block signatures from several signed files",
+				Action:    utils.MigrateFlags(superBlockMerge),
+				ArgsUsage: "<block> <signed>...",
+			},
+			{
+				Name:      "check",
+				Usage:     "Check super block signatures against the current super block accounts",
+				Action:    utils.MigrateFlags(superBlockCheck),
+				ArgsUsage: "<block>",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+				},
+			},
+			{
+				Name:      "dryrun",
+				Usage:     "Import a super block into a copy of the local chain",
+				Action:    utils.MigrateFlags(superBlockDryRun),
+				ArgsUsage: "<block>",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+				},
+				Description: `
+The dryrun command copies the local chain database into a temporary directory,
+imports the super block there and prints the resulting state. The database is
+opened read-only and the copy fails while a node is running on it, stop the
+node before running the command.`,
+			},
+		},
+	}
+)
+
+func superBlockPropose(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("Usage: gman superblock propose <manifest>")
+	}
+	stack, _ := makeConfigNode(ctx)
+	chain, _ := utils.MakeChain(ctx, stack)
+	defer chain.Stop()
+
+	seq, err := chain.GetSuperBlockSeq()
+	if err != nil {
+		utils.Fatalf("Failed to get super block seq: %v", err)
+	}
+	manifest := superblock.Propose(chain.CurrentHeader(), seq)
+	writeJSONFile(ctx.Args().First(), manifest)
+	fmt.Println("Exported super block manifest to", ctx.Args().First())
+	return nil
+}
+
+func superBlockBuild(ctx *cli.Context) error {
+	if len(ctx.Args()) != 2 {
+		utils.Fatalf("Usage: gman superblock build <manifest> <block>")
+	}
+	manifest := new(superblock.Manifest)
+	readJSONFile(ctx.Args().First(), manifest)
+
+	stack, _ := makeConfigNode(ctx)
+	chain, chainDB := utils.MakeChain(ctx, stack)
+	defer chain.Stop()
+
+	parent := chain.GetHeaderByHash(manifest.ParentHash)
+	if parent == nil {
+		utils.Fatalf("Parent block %s not found", manifest.ParentHash.Hex())
+	}
+	st, err := chain.StateAtBlockHash(parent.Hash())
+	if err != nil {
+		utils.Fatalf("Failed to get parent state: %v", err)
+	}
+	genesis, err := manifest.ToGenesis(parent, st)
+	if err != nil {
+		utils.Fatalf("Invalid manifest: %v", err)
+	}
+	if err := superblock.ComputeRoots(genesis, parent, chainDB, state.NewDatabase(chainDB), chain.Config()); err != nil {
+		utils.Fatalf("Failed to compute roots: %v", err)
+	}
+	writeJSONFile(ctx.Args().Get(1), genesis)
+	fmt.Println("sign hash:", superblock.SignHash(genesis).Hex())
+	fmt.Println("Exported super block to", ctx.Args().Get(1))
+	return nil
+}
+
+func superBlockSign(ctx *cli.Context) error {
+	if len(ctx.Args()) != 2 {
+		utils.Fatalf("Usage: gman superblock sign <block> <keyfile>")
+	}
+	genesis := readSuperBlock(ctx.Args().First())
+	keyJSON, err := ioutil.ReadFile(ctx.Args().Get(1))
+	if err != nil {
+		utils.Fatalf("Failed to read key file: %v", err)
+	}
+	passphrase := getPassPhrase("", false, 0, utils.MakePasswordList(ctx))
+	key, err := keystore.DecryptKey(keyJSON, passphrase)
+	if err != nil {
+		utils.Fatalf("Failed to decrypt key file: %v", err)
+	}
+	account, err := superblock.Sign(genesis, key.PrivateKey)
+	if err != nil {
+		utils.Fatalf("Failed to sign super block: %v", err)
+	}
+	writeJSONFile(ctx.Args().First(), genesis)
+	fmt.Println("sign hash:", superblock.SignHash(genesis).Hex())
+	fmt.Println("Signed by", base58.Base58EncodeToString(params.MAN_COIN, account), "signatures:", len(genesis.Signatures))
+	return nil
+}
+
+func superBlockMerge(ctx *cli.Context) error {
+	if len(ctx.Args()) < 2 {
+		utils.Fatalf("Usage: gman superblock merge <block> <signed>...")
+	}
+	genesis := readSuperBlock(ctx.Args().First())
+	others := make([]*core.Genesis, 0, len(ctx.Args())-1)
+	for _, path := range ctx.Args()[1:] {
+		others = append(others, readSuperBlock(path))
+	}
+	if err := superblock.Merge(genesis, others...); err != nil {
+		utils.Fatalf("Failed to merge signatures: %v", err)
+	}
+	writeJSONFile(ctx.Args().First(), genesis)
+	fmt.Println("Merged signatures:", len(genesis.Signatures))
+	return nil
+}
+
+func superBlockCheck(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("Usage: gman superblock check <block>")
+	}
+	genesis := readSuperBlock(ctx.Args().First())
+
+	stack, _ := makeConfigNode(ctx)
+	chain, _ := utils.MakeChain(ctx, stack)
+	defer chain.Stop()
+
+	result, err := superblock.Check(chain, chain.DPOSEngine([]byte(genesis.Version)), genesis)
+	if err != nil {
+		utils.Fatalf("Failed to check super block: %v", err)
+	}
+	fmt.Println("sign hash:", result.Hash.Hex())
+	fmt.Printf("super block accounts: %d, signed: %d, invalid signatures: %d\n", len(result.SuperAccounts), len(result.Signed), result.Invalid)
+	for _, account := range result.Missing {
+		fmt.Println("missing:", base58.Base58EncodeToString(params.MAN_COIN, account))
+	}
+	if result.Err != nil {
+		utils.Fatalf("Super block signatures not enough: %v", result.Err)
+	}
+	fmt.Println("Super block signatures reach the threshold")
+	return nil
+}
+
+func superBlockDryRun(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("Usage: gman superblock dryrun <block>")
+	}
+	genesis := readSuperBlock(ctx.Args().First())
+	stack, cfg := makeConfigNode(ctx)
+
+	tmpDir, err := ioutil.TempDir("", "superblock-dryrun")
+	if err != nil {
+		return fmt.Errorf("failed to create temp dir: %v", err)
+	}
+	err = dryRunSuperBlock(ctx, stack, cfg.Node, tmpDir, genesis)
+	if rmErr := os.RemoveAll(tmpDir); rmErr != nil {
+		fmt.Println("Failed to remove temp dir", tmpDir, rmErr)
+	}
+	return err
+}
+
+// dryRunSuperBlock 将本地链数据库复制到tmpDir中，在副本上插入超级区块并打印结果
+func dryRunSuperBlock(ctx *cli.Context, stack *pod.Node, nodeCfg pod.Config, tmpDir string, genesis *core.Genesis) error {
+	nodeCfg.DataDir = tmpDir
+	tmpStack, err := pod.New(&nodeCfg)
+	if err != nil {
+		return fmt.Errorf("failed to create the protocol stack: %v", err)
+	}
+	if err := copyChainData(stack.ResolvePath("chaindata"), tmpStack.ResolvePath("chaindata")); err != nil {
+		return err
+	}
+
+	chain, _, err := utils.NewChain(ctx, tmpStack)
+	if err != nil {
+		return err
+	}
+	defer chain.Stop()
+
+	block, err := chain.InsertSuperBlock(genesis, false)
+	if err != nil {
+		return fmt.Errorf("dry run failed: %v", err)
+	}
+	st, err := chain.StateAt(block.Root())
+	if err != nil {
+		return fmt.Errorf("failed to get super block state: %v", err)
+	}
+	fmt.Println("Dry run succeeded, number:", block.NumberU64(), "hash:", block.Hash().Hex(), "seq:", block.Header().SuperBlockSeq())
+	for addr := range genesis.Alloc {
+		fmt.Println("balance:", base58.Base58EncodeToString(params.MAN_COIN, addr), st.GetBalanceByType(params.MAN_COIN, addr, common.MainAccount))
+	}
+	if blackList, err := matrixstate.GetElectBlackList(st); err == nil {
+		for _, addr := range blackList {
+			fmt.Println("black list:", base58.Base58EncodeToString(params.MAN_COIN, addr))
+		}
+	}
+	return nil
+}
+
+func readSuperBlock(path string) *core.Genesis {
+	genesis := new(core.Genesis)
+	readJSONFile(path, genesis)
+	return genesis
+}
+
+func readJSONFile(path string, v interface{}) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		utils.Fatalf("Failed to read %s: %v", path, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		utils.Fatalf("Invalid file %s: %v", path, err)
+	}
+}
+
+func writeJSONFile(path string, v interface{}) {
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		utils.Fatalf("Failed to encode %s: %v", path, err)
+	}
+	if err := ioutil.WriteFile(path, out, 0644); err != nil {
+		utils.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
+
+// copyChainData 以只读方式打开本地链数据库，逐条复制到dst中的新数据库。
+// 节点运行时持有数据库锁，只读打开会失败，不会复制到不一致的数据
+func copyChainData(src, dst string) error {
+	srcDB, err := leveldb.OpenFile(src, &opt.Options{ReadOnly: true, ErrorIfMissing: true})
+	if err != nil {
+		return fmt.Errorf("failed to open chain database %s read-only, stop the node first: %v", src, err)
+	}
+	defer srcDB.Close()
+	dstDB, err := leveldb.OpenFile(dst, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create chain database copy: %v", err)
+	}
+	defer dstDB.Close()
+
+	it := srcDB.NewIterator(nil, nil)
+	defer it.Release()
+	batch, size := new(leveldb.Batch), 0
+	for it.Next() {
+		batch.Put(it.Key(), it.Value())
+		size += len(it.Key()) + len(it.Value())
+		if size >= mandb.IdealBatchSize {
+			if err := dstDB.Write(batch, nil); err != nil {
+				return fmt.Errorf("failed to copy chain database: %v", err)
+			}
+			batch.Reset()
+			size = 0
+		}
+	}
+	if err := it.Error(); err != nil {
+		return fmt.Errorf("failed to read chain database: %v", err)
+	}
+	if err := dstDB.Write(batch, nil); err != nil {
+		return fmt.Errorf("failed to copy chain database: %v", err)
+	}
+	return nil
+}
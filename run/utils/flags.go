@@ -35,6 +35,7 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/core/vm"
 	"github.com/MatrixAINetwork/go-matrix/crypto"
 	"github.com/MatrixAINetwork/go-matrix/dashboard"
+	"github.com/MatrixAINetwork/go-matrix/graphql"
 	"github.com/MatrixAINetwork/go-matrix/log"
 	"github.com/MatrixAINetwork/go-matrix/man"
 	"github.com/MatrixAINetwork/go-matrix/man/downloader"
@@ -446,6 +447,10 @@ var (
 		Usage: "API's offered over the HTTP-RPC interface",
 		Value: "",
 	}
+	GraphQLEnabledFlag = cli.BoolFlag{
+		Name:  "graphql",
+		Usage: "Enable the GraphQL query endpoint on the HTTP-RPC server (path /graphql)",
+	}
 	IPCDisabledFlag = cli.BoolFlag{
 		Name:  "ipcdisable",
 		Usage: "Disable the IPC-RPC server",
@@ -1351,6 +1356,9 @@ func SetDashboardConfig(ctx *cli.Context, cfg *dashboard.Config) {
 func RegisterManService(stack *pod.Node, cfg *man.Config) {
 	var err error
 	err = stack.Register(func(ctx *pod.ServiceContext) (pod.Service, error) {
+		if cfg.SyncMode == downloader.LightSync {
+			return man.NewLight(ctx, cfg)
+		}
 		fullNode, err := man.New(ctx, cfg)
 		return fullNode, err
 	})
@@ -1380,6 +1388,19 @@ func RegisterManStatsService(stack *pod.Node, url string) {
 	}
 }
 
+// RegisterGraphQLService adds the GraphQL query service to a node, served on the HTTP-RPC endpoint.
+func RegisterGraphQLService(stack *pod.Node) {
+	if err := stack.Register(func(ctx *pod.ServiceContext) (pod.Service, error) {
+		var manServ *man.Matrix
+		if err := ctx.Service(&manServ); err != nil {
+			return nil, err
+		}
+		return graphql.New(manServ.APIBackend)
+	}); err != nil {
+		Fatalf("Failed to register the GraphQL service: %v", err)
+	}
+}
+
 // SetupNetwork configures the system for either the main net or some test network.
 func SetupNetwork(ctx *cli.Context) {
 	// TODO(fjl): move target gas limit into config
@@ -1388,6 +1409,14 @@ func SetupNetwork(ctx *cli.Context) {
 
 // MakeChainDatabase open an LevelDB using the flags passed to the client and will hard crash if it fails.
 func MakeChainDatabase(ctx *cli.Context, stack *pod.Node) mandb.Database {
+	chainDb, err := openChainDatabase(ctx, stack)
+	if err != nil {
+		Fatalf("Could not open database: %v", err)
+	}
+	return chainDb
+}
+
+func openChainDatabase(ctx *cli.Context, stack *pod.Node) (mandb.Database, error) {
 	var (
 		cache   = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheDatabaseFlag.Name) / 100
 		handles = makeDatabaseHandles()
@@ -1396,11 +1425,7 @@ func MakeChainDatabase(ctx *cli.Context, stack *pod.Node) mandb.Database {
 	if ctx.GlobalBool(LightModeFlag.Name) {
 		name = "lightchaindata"
 	}
-	chainDb, err := stack.OpenDatabase(name, cache, handles)
-	if err != nil {
-		Fatalf("Could not open database: %v", err)
-	}
-	return chainDb
+	return stack.OpenDatabase(name, cache, handles)
 }
 
 func MakeChain1Database(ctx *cli.Context, stack *pod.Node, name string) mandb.Database {
@@ -1434,34 +1459,49 @@ func MakeGenesis(ctx *cli.Context) *core.Genesis {
 
 // MakeChain creates a chain manager from set command line flags.
 func MakeChain(ctx *cli.Context, stack *pod.Node) (chain *core.BlockChain, chainDb mandb.Database) {
-	var err error
-	chainDb = MakeChainDatabase(ctx, stack)
+	chain, chainDb, err := NewChain(ctx, stack)
+	if err != nil {
+		Fatalf("%v", err)
+	}
+	return chain, chainDb
+}
+
+// NewChain is like MakeChain but returns an error instead of exiting, for commands
+// that have to clean up after a failure.
+func NewChain(ctx *cli.Context, stack *pod.Node) (*core.BlockChain, mandb.Database, error) {
+	chainDb, err := openChainDatabase(ctx, stack)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Could not open database: %v", err)
+	}
 
 	config, _, err := core.SetupGenesisBlock(chainDb, MakeGenesis(ctx))
 	if err != nil {
-		Fatalf("%v", err)
+		chainDb.Close()
+		return nil, nil, err
 	}
 
 	engine, dposEngine := createEngineMap(ctx, stack, config, chainDb)
 
 	if gcmode := ctx.GlobalString(GCModeFlag.Name); gcmode != "full" && gcmode != "archive" {
-		Fatalf("--%s must be either 'full' or 'archive'", GCModeFlag.Name)
+		chainDb.Close()
+		return nil, nil, fmt.Errorf("--%s must be either 'full' or 'archive'", GCModeFlag.Name)
 	}
-	cache := &core.CacheConfig{
+	cacheConfig := &core.CacheConfig{
 		Disabled:      ctx.GlobalString(GCModeFlag.Name) == "archive",
 		TrieNodeLimit: man.DefaultConfig.TrieCache,
 		TrieTimeLimit: man.DefaultConfig.TrieTimeout,
 	}
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheGCFlag.Name) {
-		cache.TrieNodeLimit = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheGCFlag.Name) / 100
+		cacheConfig.TrieNodeLimit = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheGCFlag.Name) / 100
 	}
 	vmcfg := vm.Config{EnablePreimageRecording: ctx.GlobalBool(VMEnableDebugFlag.Name)}
 
-	chain, err = core.NewBlockChain(chainDb, cache, config, vmcfg, engine, dposEngine)
+	chain, err := core.NewBlockChain(chainDb, cacheConfig, config, vmcfg, engine, dposEngine)
 	if err != nil {
-		Fatalf("Can't create BlockChain: %v", err)
+		chainDb.Close()
+		return nil, nil, fmt.Errorf("Can't create BlockChain: %v", err)
 	}
-	return chain, chainDb
+	return chain, chainDb, nil
 }
 
 func createEngineMap(ctx *cli.Context, stack *pod.Node, config *params.ChainConfig, chainDb mandb.Database) (map[string]consensus.Engine, map[string]consensus.DPOSEngine) {